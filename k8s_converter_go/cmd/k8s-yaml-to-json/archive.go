@@ -0,0 +1,425 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// Archive formats of -archive, also chosen by the extension of -output.
+const (
+	archiveTar   = "tar"
+	archiveTarGz = "tar.gz"
+	archiveZip   = "zip"
+)
+
+// archiveEpoch is the modification time of archive entries with -canonical,
+// and of the entries of stdin and URL input, which have none: the earliest
+// time a zip file can hold.
+var archiveEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// archiveFormat returns the archive format of outputFile: the -archive value
+// format, tar or zip, or else the format named by its extension, .tar,
+// .tar.gz, .tgz or .zip. A tar archive whose name ends in .gz or .tgz is
+// compressed with gzip. It returns "" when outputFile is not an archive.
+func archiveFormat(outputFile, format string) (string, error) {
+	name := strings.ToLower(outputFile)
+	gzipped := strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz")
+	switch format {
+	case "":
+		switch {
+		case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+			return archiveTarGz, nil
+		case strings.HasSuffix(name, ".tar"):
+			return archiveTar, nil
+		case strings.HasSuffix(name, ".zip"):
+			return archiveZip, nil
+		}
+		return "", nil
+	case archiveTar:
+		if gzipped {
+			return archiveTarGz, nil
+		}
+		return archiveTar, nil
+	case archiveZip:
+		return archiveZip, nil
+	}
+	return "", fmt.Errorf("invalid -archive value '%s': must be tar or zip", format)
+}
+
+// archiveWriter writes the entries of an archive to an AtomicFile, so that
+// the archive only replaces its destination once every entry is written.
+type archiveWriter struct {
+	file *converter.AtomicFile
+	gzip *gzip.Writer
+	tar  *tar.Writer
+	zip  *zip.Writer
+	// owners maps the entry names already written to the input that
+	// produced them, and secret is whether any of them holds a Secret.
+	owners map[string]string
+	secret bool
+}
+
+// createArchive starts writing the archive of format at path.
+func createArchive(path, format string) (*archiveWriter, error) {
+	file, err := converter.CreateAtomic(path, 0644)
+	if err != nil {
+		return nil, &outputError{fmt.Errorf("writing archive: %w", err)}
+	}
+	archive := &archiveWriter{file: file, owners: make(map[string]string)}
+	switch format {
+	case archiveZip:
+		archive.zip = zip.NewWriter(file)
+	case archiveTarGz:
+		archive.gzip = gzip.NewWriter(file)
+		archive.tar = tar.NewWriter(archive.gzip)
+	default:
+		archive.tar = tar.NewWriter(file)
+	}
+	return archive, nil
+}
+
+// add writes data as the entry name, a slash-separated path, produced by
+// owner. The entry has the mode of fileMode(secret), or 0644. Two entries
+// with the same name are an error.
+func (a *archiveWriter) add(name, owner string, data []byte, modTime time.Time, secret bool) error {
+	if previous, ok := a.owners[name]; ok {
+		return &outputError{fmt.Errorf("archive entry name collision: %s and %s both map to %s", previous, owner, name)}
+	}
+	a.owners[name] = owner
+	a.secret = a.secret || secret
+	mode := fileMode(secret)
+	if mode == 0 {
+		mode = 0644
+	}
+	var err error
+	if a.zip != nil {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime}
+		header.SetMode(mode)
+		var w io.Writer
+		if w, err = a.zip.CreateHeader(header); err == nil {
+			_, err = w.Write(data)
+		}
+	} else {
+		header := &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: int64(mode), Size: int64(len(data)), ModTime: modTime}
+		if err = a.tar.WriteHeader(header); err == nil {
+			_, err = a.tar.Write(data)
+		}
+	}
+	if err != nil {
+		return &outputError{fmt.Errorf("writing archive: %w", err)}
+	}
+	return nil
+}
+
+// commit finishes the archive and renames it into place.
+func (a *archiveWriter) commit() error {
+	var err error
+	if a.zip != nil {
+		err = a.zip.Close()
+	} else {
+		err = a.tar.Close()
+		if a.gzip != nil && err == nil {
+			err = a.gzip.Close()
+		}
+	}
+	if err != nil {
+		a.file.Abort()
+		return &outputError{fmt.Errorf("writing archive: %w", err)}
+	}
+	if mode := fileMode(a.secret); mode != 0 {
+		if err := a.file.Chmod(mode); err != nil {
+			a.file.Abort()
+			return &outputError{fmt.Errorf("writing archive: %w", err)}
+		}
+	}
+	if err := a.file.Commit(); err != nil {
+		return &outputError{fmt.Errorf("writing archive: %w", err)}
+	}
+	return nil
+}
+
+// entryTime returns the modification time of the archive entries converted
+// from inputFile: archiveEpoch with -canonical or for input that is not a
+// file, and otherwise the modification time of inputFile, to the second.
+func entryTime(inputFile string, opts converter.Options) time.Time {
+	if opts.Canonical || inputFile == stdinInput || isURL(inputFile) {
+		return archiveEpoch
+	}
+	info, err := os.Stat(inputFile)
+	if err != nil {
+		return archiveEpoch
+	}
+	return info.ModTime().UTC().Truncate(time.Second)
+}
+
+// writeArchive converts inputFile, or the files of directory and glob input
+// found under root when files is not nil, into the archive of format at
+// outputFile, without writing any other file. Entries are named as the
+// output files would be: by the rules of -split and -name-template with
+// split set, and otherwise by the output tree of a directory, or the JSON
+// file name of a single input. It returns the number of entries written, or
+// the input that failed with its error, in which case nothing is written.
+// Files with no document matching the filters are skipped.
+func writeArchive(inputFile, root string, files []string, outputFile, format string, split bool, opts converter.Options) (int, string, error) {
+	batch := files != nil
+	if !batch {
+		files = []string{inputFile}
+	}
+	if err := checkOverwrite([]string{outputFile}); err != nil {
+		return 0, inputFile, err
+	}
+	archive, err := createArchive(outputFile, format)
+	if err != nil {
+		return 0, inputFile, err
+	}
+	opts.Reverse = false
+	fail := func(path string, err error) (int, string, error) {
+		archive.file.Abort()
+		return 0, path, err
+	}
+
+	for _, path := range files {
+		opts.Warn = printWarning(path)
+		// Entries are named relative to the root of directory and glob input
+		name := jsonFileName(filepath.Base(inputPath(path)))
+		if path == stdinInput {
+			name = "stdin.json"
+		}
+		if batch {
+			rel, err := relativePath(root, path)
+			if err != nil {
+				return fail(path, err)
+			}
+			name = jsonFileName(rel)
+		}
+		data, err := readInputFile(path)
+		if err != nil {
+			return fail(path, err)
+		}
+		modTime := entryTime(path, opts)
+
+		if !split {
+			output, err := converter.Convert(data, opts)
+			if batch && errors.Is(err, converter.ErrNoMatch) {
+				infof("Skipped %s: %v", path, err)
+				continue
+			}
+			if err == nil {
+				err = archive.add(filepath.ToSlash(name), displayName(path), output, modTime, true)
+			}
+			if err != nil {
+				return fail(path, err)
+			}
+			continue
+		}
+
+		documents, err := converter.Decode(data, opts)
+		if batch && errors.Is(err, converter.ErrNoMatch) {
+			infof("Skipped %s: %v", path, err)
+			continue
+		}
+		if err != nil {
+			return fail(path, err)
+		}
+		// A -name-template names entries from the top of the archive
+		dir := filepath.Dir(name)
+		if nameTemplate != nil {
+			dir = "."
+		}
+		for _, doc := range documents {
+			entry, err := splitPath(path, dir, doc)
+			if err == nil {
+				data, err = marshalSplitDocument(doc, opts)
+			}
+			if err == nil {
+				owner := fmt.Sprintf("%s document %d", displayName(path), doc.Index)
+				err = archive.add(filepath.ToSlash(entry), owner, data, modTime, holdsSecret(doc))
+			}
+			if err != nil {
+				return fail(path, err)
+			}
+		}
+	}
+	if err := archive.commit(); err != nil {
+		return 0, inputFile, err
+	}
+	return len(archive.owners), inputFile, nil
+}
+
+// archiveFiles holds the YAML entries of the archives given as -input, read
+// by openInput as files. They are named by the path of the archive joined
+// with the path of the entry, such as manifests.tgz/apps/web.yaml.
+var archiveFiles = make(map[string][]byte)
+
+// inputArchiveFormat returns the archive format of the file at path by its
+// magic bytes, tar, tar.gz or zip, or "" for any other file, including
+// gzip-compressed YAML.
+func inputArchiveFormat(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	header := make([]byte, 512)
+	n, _ := io.ReadFull(file, header)
+	header = header[:n]
+	if bytes.HasPrefix(header, []byte("PK\x03\x04")) || bytes.HasPrefix(header, []byte("PK\x05\x06")) {
+		return archiveZip, nil
+	}
+	format := archiveTar
+	if bytes.HasPrefix(header, []byte{0x1f, 0x8b}) {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		decompressed, err := gzip.NewReader(file)
+		if err != nil {
+			return "", nil
+		}
+		header = make([]byte, 512)
+		n, _ := io.ReadFull(decompressed, header)
+		header, format = header[:n], archiveTarGz
+	}
+	if len(header) >= 262 && string(header[257:262]) == "ustar" {
+		return format, nil
+	}
+	return "", nil
+}
+
+// checkEntryName returns an error for the name of an archive entry that is
+// an absolute path or has a ".." element, so that no entry can name an
+// output outside the output directory.
+func checkEntryName(name string) error {
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(slashed, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" || (len(slashed) > 1 && slashed[1] == ':') {
+		return fmt.Errorf("archive entry %q is an absolute path", name)
+	}
+	for _, element := range strings.Split(slashed, "/") {
+		if element == ".." {
+			return fmt.Errorf("archive entry %q leaves the archive", name)
+		}
+	}
+	return nil
+}
+
+// readInputArchive reads the YAML entries of the archive of format at path
+// into archiveFiles and returns their names, in lexical order, with the
+// number of entries skipped for not being YAML files or matching an
+// -exclude pattern. Directories are left out, and an entry with an absolute
+// path or a ".." element fails the whole archive. As every entry is held in
+// memory, -max-size limits the entries read together, not each of them.
+// Errors are *inputError.
+func readInputArchive(path, format string) ([]string, skippedFiles, error) {
+	skipped := skippedFiles{archive: true}
+	names := make(map[string]bool)
+	// total is the size of the entries read so far
+	var total int64
+	// add reads the entry of an archive named entry, unless it is skipped
+	add := func(entry string, regular bool, open func() (io.ReadCloser, error)) error {
+		if err := checkEntryName(entry); err != nil {
+			return &inputError{err: fmt.Errorf("reading archive %s: %w", path, err)}
+		}
+		name := filepath.Join(path, filepath.FromSlash(entry))
+		switch {
+		case !regular || !isYAMLFile(trimGzipSuffix(entry)):
+			skipped.notYAML++
+			logSkip(name, "not a YAML file")
+			return nil
+		case isExcluded(path, name, false):
+			skipped.excluded++
+			logSkip(name, "excluded")
+			return nil
+		}
+		r, err := open()
+		if err != nil {
+			return &inputError{err: fmt.Errorf("reading %s: %w", name, err)}
+		}
+		defer r.Close()
+		remaining := maxInputSize - total
+		if maxInputSize > 0 {
+			r = io.NopCloser(io.LimitReader(r, remaining+1))
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return &inputError{err: fmt.Errorf("reading %s: %w", name, err)}
+		}
+		if maxInputSize > 0 && int64(len(data)) > remaining {
+			if total == 0 {
+				return tooLargeError(name)
+			}
+			return &inputError{
+				code: errorTooLarge,
+				err:  fmt.Errorf("the YAML entries of %s are larger than the -max-size limit of %s together; raise -max-size to convert them", displayName(path), formatSize(maxInputSize)),
+			}
+		}
+		total += int64(len(data))
+		archiveFiles[name], names[name] = data, true
+		return nil
+	}
+	fail := func(err error) ([]string, skippedFiles, error) {
+		return nil, skipped, &inputError{err: fmt.Errorf("reading archive %s: %w", path, err)}
+	}
+
+	if format == archiveZip {
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return fail(err)
+		}
+		defer archive.Close()
+		for _, file := range archive.File {
+			if file.FileInfo().IsDir() {
+				continue
+			}
+			if err := add(file.Name, file.Mode().IsRegular(), file.Open); err != nil {
+				return nil, skipped, err
+			}
+		}
+	} else {
+		input, err := os.Open(path)
+		if err != nil {
+			return fail(err)
+		}
+		defer input.Close()
+		var r io.Reader = input
+		if format == archiveTarGz {
+			if r, err = gzip.NewReader(input); err != nil {
+				return fail(err)
+			}
+		}
+		archive := tar.NewReader(r)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fail(err)
+			}
+			if header.Typeflag == tar.TypeDir {
+				continue
+			}
+			open := func() (io.ReadCloser, error) { return io.NopCloser(archive), nil }
+			if err := add(header.Name, header.Typeflag == tar.TypeReg, open); err != nil {
+				return nil, skipped, err
+			}
+		}
+	}
+
+	files := []string{}
+	for name := range names {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, skipped, nil
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestArchiveFormat(t *testing.T) {
+	tests := []struct {
+		output, flag string
+		want         string
+		wantErr      bool
+	}{
+		{output: "bundle.tar.gz", want: archiveTarGz},
+		{output: "bundle.TGZ", want: archiveTarGz},
+		{output: "bundle.tar", want: archiveTar},
+		{output: "bundle.zip", want: archiveZip},
+		{output: "bundle.json", want: ""},
+		{output: "build/", want: ""},
+		{output: "bundle", flag: "tar", want: archiveTar},
+		{output: "bundle.gz", flag: "tar", want: archiveTarGz},
+		{output: "bundle.tar", flag: "zip", want: archiveZip},
+		{output: "bundle", flag: "rar", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := archiveFormat(tt.output, tt.flag)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("archiveFormat(%q, %q) = %q, %v, want %q, error %v", tt.output, tt.flag, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// archiveEntry is an entry read back from an archive.
+type archiveEntry struct {
+	name, content string
+	modTime       time.Time
+}
+
+// readArchive returns the entries of the archive at path.
+func readArchive(t *testing.T, path, format string) []archiveEntry {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []archiveEntry
+	if format == archiveZip {
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range archive.File {
+			r, err := file.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := io.ReadAll(r)
+			entries = append(entries, archiveEntry{file.Name, string(content), file.Modified.UTC()})
+		}
+		return entries
+	}
+	var r io.Reader = bytes.NewReader(data)
+	if format == archiveTarGz {
+		if r, err = gzip.NewReader(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(archive)
+		entries = append(entries, archiveEntry{header.Name, string(content), header.ModTime.UTC()})
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/web.yaml":      "kind: Deployment\nmetadata:\n  name: web\n---\nkind: Service\nmetadata:\n  name: web\n",
+		"in/apps/db.yaml":  "kind: StatefulSet\nmetadata:\n  name: db\n",
+		"in/other.yaml":    "kind: ConfigMap\nmetadata:\n  name: other\n",
+		"broken/bad.yaml":  "kind: [\n",
+		"clash/a.yaml":     "kind: ConfigMap\nmetadata:\n  name: app\n",
+		"clash/sub/a.yaml": "kind: ConfigMap\nmetadata:\n  name: app\n",
+	})
+	root := filepath.Join(dir, "in")
+	files := []string{filepath.Join(root, "apps", "db.yaml"), filepath.Join(root, "other.yaml"), filepath.Join(root, "web.yaml")}
+	opts := converter.Options{Compact: true, Kinds: []string{"Deployment", "Service", "StatefulSet"}}
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, path := range files {
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		split  bool
+		want   []archiveEntry
+	}{
+		{
+			name: "Output tree", format: archiveTarGz,
+			want: []archiveEntry{
+				{"apps/db.json", `{"kind":"StatefulSet","metadata":{"name":"db"}}`, modTime},
+				{"web.json", `[{"kind":"Deployment","metadata":{"name":"web"}},{"kind":"Service","metadata":{"name":"web"}}]`, modTime},
+			},
+		},
+		{
+			name: "Split", format: archiveZip, split: true,
+			want: []archiveEntry{
+				{"apps/statefulset-db.json", `{"kind":"StatefulSet","metadata":{"name":"db"}}`, modTime},
+				{"deployment-web.json", `{"kind":"Deployment","metadata":{"name":"web"}}`, modTime},
+				{"service-web.json", `{"kind":"Service","metadata":{"name":"web"}}`, modTime},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "bundle")
+			entries, _, err := writeArchive(root, root, files, out, tt.format, tt.split, opts)
+			if err != nil || entries != len(tt.want) {
+				t.Fatalf("writeArchive() = %d, %v, want %d entries", entries, err, len(tt.want))
+			}
+			if got := readArchive(t, out, tt.format); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Archives are reproducible with -canonical, whatever the source times
+	canonical := opts
+	canonical.Canonical = true
+	var archives [][]byte
+	for i, format := range []string{archiveTarGz, archiveTarGz, archiveZip, archiveZip} {
+		out := filepath.Join(t.TempDir(), "bundle")
+		if _, _, err := writeArchive(root, root, files, out, format, true, canonical); err != nil {
+			t.Fatalf("writeArchive(-canonical) error = %v", err)
+		}
+		data, _ := os.ReadFile(out)
+		archives = append(archives, data)
+		if got := readArchive(t, out, format)[0].modTime; !got.Equal(archiveEpoch) {
+			t.Errorf("-canonical entry time = %v, want %v", got, archiveEpoch)
+		}
+		if i%2 == 0 {
+			later := modTime.Add(time.Hour)
+			os.Chtimes(files[0], later, later)
+		}
+	}
+	if !bytes.Equal(archives[0], archives[1]) || !bytes.Equal(archives[2], archives[3]) {
+		t.Error("-canonical archives of the same input differ")
+	}
+
+	// A failure leaves nothing behind
+	out := filepath.Join(dir, "failed.tar")
+	bad := filepath.Join(dir, "broken", "bad.yaml")
+	if _, failed, err := writeArchive(bad, "", nil, out, archiveTar, false, converter.Options{}); err == nil || failed != bad {
+		t.Errorf("broken input: failed = %q, error = %v, want an error for %s", failed, err, bad)
+	}
+	clash := filepath.Join(dir, "clash")
+	clashFiles := []string{filepath.Join(clash, "a.yaml"), filepath.Join(clash, "sub", "a.yaml")}
+	// Documents of different files named the same by a -name-template
+	nameTemplate, _ = parseNameTemplate("{{.Name}}.json")
+	defer func() { nameTemplate = nil }()
+	if _, _, err := writeArchive(clash, clash, clashFiles, out, archiveTar, true, converter.Options{}); err == nil {
+		t.Error("colliding entry names: error = nil, want a collision")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("failed archive was written: %v", err)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+// assertNoTempFiles fails t if dir holds a temporary file of an AtomicFile.
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(dir, ".*.tmp-*"))
+	if len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+// nopWriteCloser is a writer with a Close method that does nothing.
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// testEntry is an entry of an archive made by makeArchive.
+type testEntry struct {
+	name, content string
+}
+
+// makeArchive writes the entries to a new archive of format at path.
+// Entries whose name ends in / are directories.
+func makeArchive(t *testing.T, path, format string, entries ...testEntry) {
+	t.Helper()
+	var buf bytes.Buffer
+	if format == archiveZip {
+		archive := zip.NewWriter(&buf)
+		for _, entry := range entries {
+			w, err := archive.Create(entry.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(entry.content))
+		}
+		archive.Close()
+	} else {
+		var w io.WriteCloser = nopWriteCloser{&buf}
+		if format == archiveTarGz {
+			w = gzip.NewWriter(&buf)
+		}
+		archive := tar.NewWriter(w)
+		for _, entry := range entries {
+			header := &tar.Header{Typeflag: tar.TypeReg, Name: entry.name, Mode: 0644, Size: int64(len(entry.content))}
+			if strings.HasSuffix(entry.name, "/") {
+				header.Typeflag, header.Mode, header.Size = tar.TypeDir, 0755, 0
+			}
+			if err := archive.WriteHeader(header); err != nil {
+				t.Fatal(err)
+			}
+			archive.Write([]byte(entry.content))
+		}
+		archive.Close()
+		w.Close()
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInputArchiveFormat(t *testing.T) {
+	dir := t.TempDir()
+	entry := testEntry{"web.yaml", "kind: Service\n"}
+	for _, format := range []string{archiveTar, archiveTarGz, archiveZip} {
+		// The extension does not matter
+		path := filepath.Join(dir, "bundle-"+strings.ReplaceAll(format, ".", "-"))
+		makeArchive(t, path, format, entry)
+		if got, err := inputArchiveFormat(path); err != nil || got != format {
+			t.Errorf("inputArchiveFormat(%s archive) = %q, %v, want %q", format, got, err, format)
+		}
+	}
+
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	w.Write([]byte("kind: Service\n"))
+	w.Close()
+	writeTree(t, dir, map[string]string{"web.yaml": "kind: Service\n", "web.yaml.gz": compressed.String(), "empty.yaml": ""})
+	for _, name := range []string{"web.yaml", "web.yaml.gz", "empty.yaml"} {
+		if got, err := inputArchiveFormat(filepath.Join(dir, name)); err != nil || got != "" {
+			t.Errorf("inputArchiveFormat(%s) = %q, %v, want not an archive", name, got, err)
+		}
+	}
+}
+
+func TestCheckEntryName(t *testing.T) {
+	tests := map[string]bool{
+		"web.yaml":           true,
+		"apps/web.yaml":      true,
+		"./apps/web.yaml":    true,
+		"apps/..web.yaml":    true,
+		"/etc/web.yaml":      false,
+		"../web.yaml":        false,
+		"apps/../../web.yml": false,
+		`apps\..\web.yaml`:   false,
+		"C:/web.yaml":        false,
+	}
+	for name, ok := range tests {
+		if err := checkEntryName(name); (err == nil) != ok {
+			t.Errorf("checkEntryName(%q) = %v, want ok %v", name, err, ok)
+		}
+	}
+}
+
+func TestReadInputArchive(t *testing.T) {
+	dir := t.TempDir()
+	entries := []testEntry{
+		{"apps/", ""},
+		{"apps/web.yaml", "kind: Deployment\n"},
+		{"README.md", "# Manifests\n"},
+		{"db.yml", "kind: StatefulSet\n"},
+		{"apps/vendor/lib.yaml", "kind: ConfigMap\n"},
+	}
+	defer func(patterns []excludePattern) { excludes = patterns }(excludes)
+	pattern, err := parseExclude("vendor/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	excludes = []excludePattern{pattern}
+
+	for _, format := range []string{archiveTar, archiveTarGz, archiveZip} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(dir, "manifests."+format)
+			makeArchive(t, path, format, entries...)
+			files, skipped, err := readInputArchive(path, format)
+			if err != nil {
+				t.Fatalf("readInputArchive() error = %v", err)
+			}
+			want := []string{filepath.Join(path, "apps", "web.yaml"), filepath.Join(path, "db.yml")}
+			if !reflect.DeepEqual(files, want) {
+				t.Errorf("files = %v, want %v", files, want)
+			}
+			if skipped.notYAML != 1 || skipped.excluded != 1 {
+				t.Errorf("skipped = %+v, want 1 not YAML and 1 excluded", skipped)
+			}
+			// Entries are read as files, with their path in the archive
+			if data, err := readInputFile(want[0]); err != nil || string(data) != "kind: Deployment\n" {
+				t.Errorf("readInputFile(%s) = %q, %v", want[0], data, err)
+			}
+		})
+	}
+
+	// Entries that would write outside the output directory fail the
+	// whole archive
+	for _, name := range []string{"../evil.yaml", "/etc/evil.yaml"} {
+		path := filepath.Join(dir, "slip.tar")
+		makeArchive(t, path, archiveTar, testEntry{"web.yaml", "kind: Service\n"}, testEntry{name, "kind: Secret\n"})
+		if files, _, err := readInputArchive(path, archiveTar); err == nil {
+			t.Errorf("entry %q: files = %v, want an error", name, files)
+		}
+	}
+}
+
+func TestReadInputArchiveMaxSize(t *testing.T) {
+	defer func(size int64) { maxInputSize = size }(maxInputSize)
+	maxInputSize = 40
+	dir := t.TempDir()
+	service := testEntry{"a.yaml", "kind: Service\nmetadata:\n  name: a\n"}
+
+	tests := []struct {
+		name    string
+		file    string
+		entries []testEntry
+		wantErr string
+	}{
+		{name: "within the limit", file: "within.tar", entries: []testEntry{service, {"README.md", strings.Repeat("#", 100)}}},
+		{name: "entry", file: "entry.tar", entries: []testEntry{{"big.yaml", strings.Repeat("# padding\n", 5)}}, wantErr: "big.yaml is larger than the -max-size limit of 40"},
+		{name: "entries together", file: "together.tar", entries: []testEntry{service, {"b.yaml", "kind: Service\nmetadata:\n  name: b\n"}}, wantErr: "the YAML entries of " + filepath.Join(dir, "together.tar") + " are larger than the -max-size limit of 40 together"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			makeArchive(t, path, archiveTar, tt.entries...)
+			_, _, err := readInputArchive(path, archiveTar)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("readInputArchive() error = %v", err)
+				}
+				return
+			}
+			var inputErr *inputError
+			if !errors.As(err, &inputErr) || inputErr.code != errorTooLarge || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readInputArchive() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
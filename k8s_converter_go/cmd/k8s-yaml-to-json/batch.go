@@ -0,0 +1,767 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// isYAMLFile reports whether path has a .yaml or .yml extension, optionally
+// followed by .gz.
+func isYAMLFile(path string) bool {
+	lower := strings.ToLower(trimGzipSuffix(path))
+	return strings.HasSuffix(lower, ".yaml") || strings.HasSuffix(lower, ".yml")
+}
+
+// trimGzipSuffix removes a trailing .gz extension from path.
+func trimGzipSuffix(path string) string {
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		return path[:len(path)-len(".gz")]
+	}
+	return path
+}
+
+// jsonFileName replaces the YAML extension of path, and any .gz extension
+// after it, with .json.
+func jsonFileName(path string) string {
+	path = trimGzipSuffix(path)
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+}
+
+// siblingOutputPath returns the path of the JSON file written next to path,
+// as with -in-place. It fails with an *outputError instead of returning path
+// itself, as for a file with a .json extension, so that writing the output
+// can never truncate its source.
+func siblingOutputPath(path string) (string, error) {
+	out := jsonFileName(path)
+	if sameFile(path, out) {
+		return "", &outputError{fmt.Errorf("the output of %s would replace the input file itself", path)}
+	}
+	return out, nil
+}
+
+// sameFile reports whether a and b name the same file, either as the same
+// path or, when both exist, by resolving to the same file.
+func sameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// batchOutputPath returns the output path for a file found under inputDir.
+// Without an output directory the JSON file is written next to its source;
+// otherwise it is written to the same relative path under outputDir, so that
+// manifests/apps/web.yaml becomes build/apps/web.json.
+func batchOutputPath(inputDir, outputDir, path string) (string, error) {
+	if outputDir == "" {
+		return siblingOutputPath(path)
+	}
+	rel, err := relativePath(inputDir, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(outputDir, jsonFileName(rel)), nil
+}
+
+// relativePath returns path relative to root. Both are made absolute first,
+// so that a relative root and an absolute path map the same way as two
+// relative ones. A path outside root is an error, so that no output is ever
+// written outside the output directory.
+func relativePath(root, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the input directory %s", path, root)
+	}
+	return rel, nil
+}
+
+// hasGlobMeta reports whether pattern contains glob metacharacters.
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// globRoot returns the leading directory of pattern that contains no glob
+// metacharacters.
+func globRoot(pattern string) string {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, segment := range segments {
+		if hasGlobMeta(segment) {
+			root := filepath.FromSlash(strings.Join(segments[:i], "/"))
+			if root == "" {
+				if i > 0 {
+					return string(filepath.Separator)
+				}
+				return "."
+			}
+			return root
+		}
+	}
+	return filepath.Dir(pattern)
+}
+
+// globFiles returns the YAML files matching pattern, in lexical order, with
+// the number of matches skipped. In addition to filepath.Match syntax, a
+// "**" path segment matches zero or more directories. Matches are excluded by
+// the -exclude patterns relative to globRoot, whatever the pattern matches.
+func globFiles(pattern string) ([]string, skippedFiles, error) {
+	root := globRoot(pattern)
+	var skipped skippedFiles
+	var matches []string
+	if !strings.Contains(pattern, "**") {
+		var err error
+		matches, err = filepath.Glob(pattern)
+		if err != nil {
+			return nil, skipped, err
+		}
+	} else {
+		patternSegments := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Excluded directories are not walked at all
+			if isExcluded(root, path, d.IsDir()) {
+				skipped.excluded++
+				logSkip(path, "excluded")
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			pathSegments := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+			ok, err := matchSegments(patternSegments, pathSegments)
+			if err != nil {
+				return err
+			}
+			if ok {
+				matches = append(matches, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, skipped, err
+		}
+	}
+
+	var files []string
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		switch {
+		case err != nil || info.IsDir():
+		case isExcluded(root, match, false):
+			skipped.excluded++
+			logSkip(match, "excluded")
+		case !isYAMLFile(match):
+			skipped.notYAML++
+			logSkip(match, "not a YAML file")
+		default:
+			files = append(files, match)
+		}
+	}
+	return files, skipped, nil
+}
+
+// matchSegments matches path segments against pattern segments, where a "**"
+// pattern segment matches any number of path segments.
+func matchSegments(pattern, path []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if ok, err := matchSegments(pattern[1:], path[i:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(path) == 0 {
+			return false, nil
+		}
+		ok, err := filepath.Match(pattern[0], path[0])
+		if !ok || err != nil {
+			return false, err
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0, nil
+}
+
+// expandInput expands a directory or glob pattern input into the YAML files
+// it refers to and the root directory their output paths are relative to,
+// with the number of files skipped without being read. A directory with a
+// kustomization file refers to the files it lists, and a tar or zip archive
+// to its YAML entries, read into archiveFiles. For any other input,
+// including stdin and URLs, files is nil.
+func expandInput(input string) (root string, files []string, skipped skippedFiles, err error) {
+	if input == stdinInput || isURL(input) {
+		return "", nil, skipped, nil
+	}
+	info, statErr := os.Stat(input)
+
+	// Expand glob patterns unless a file with that exact name exists
+	if statErr != nil && hasGlobMeta(input) {
+		files, skipped, err := globFiles(input)
+		if err != nil {
+			return "", nil, skipped, usageErrorf(nil, "invalid glob pattern '%s': %v", input, err)
+		}
+		if len(files) == 0 {
+			return "", nil, skipped, &inputError{code: errorInputNotFound, err: fmt.Errorf("no YAML files match pattern '%s'", input)}
+		}
+		return globRoot(input), files, skipped, nil
+	}
+
+	// Convert the files a kustomization lists, or else walk the directory
+	// recursively
+	if statErr == nil && info.IsDir() {
+		if kustomization := findKustomization(input); kustomization != "" {
+			listed, err := kustomizationFiles(kustomization)
+			if err != nil {
+				return "", nil, skipped, err
+			}
+			files := listed
+			if len(excludes) > 0 && listed != nil {
+				files = []string{}
+				for _, path := range listed {
+					if isExcluded(input, path, false) {
+						skipped.excluded++
+						logSkip(path, "excluded")
+						continue
+					}
+					files = append(files, path)
+				}
+			}
+			return input, files, skipped, nil
+		}
+		files, skipped, err := findYAMLFiles(input)
+		if err != nil {
+			return "", nil, skipped, &inputError{err: fmt.Errorf("reading input directory: %v", err)}
+		}
+		if files == nil {
+			files = []string{}
+		}
+		return input, files, skipped, nil
+	}
+
+	// Convert the YAML entries of tar and zip archives as files below the
+	// archive
+	if statErr == nil && info.Mode().IsRegular() {
+		format, err := inputArchiveFormat(input)
+		if err != nil {
+			return "", nil, skipped, &inputError{err: fmt.Errorf("reading input file: %w", err)}
+		}
+		if format != "" {
+			files, skipped, err := readInputArchive(input, format)
+			return input, files, skipped, err
+		}
+	}
+	return "", nil, skipped, nil
+}
+
+// batchOptions controls how directory and glob input is converted.
+type batchOptions struct {
+	// outputDir is the directory outputs are written under, or "" to write
+	// each output next to its source.
+	outputDir string
+	// stdout prints the output of every file to stdout in file order
+	// instead of writing it to a file, for -output -.
+	stdout bool
+	// failFast stops the conversion at the first file that fails, as it
+	// does unless -keep-going is given. Files already being converted by
+	// other workers are finished, and their outputs written whole.
+	failFast bool
+	// split writes each document of a file to its own JSON file.
+	split bool
+	// workers is the number of files converted concurrently. Values below 1
+	// convert one file at a time.
+	workers int
+	// dryRun converts every file without writing anything, printing where
+	// each output would have been written instead.
+	dryRun bool
+	// diff converts every file without writing anything, comparing the
+	// result with its existing output and printing the differences
+	// instead. diffExact compares the bytes rather than the structure.
+	diff      bool
+	diffExact bool
+	// listOutputs prints a line for every file converted, naming the
+	// outputs written for it.
+	listOutputs bool
+	// progress reports the number of files finished while a large batch is
+	// converted.
+	progress bool
+	// incremental skips the files whose outputs are up to date, by
+	// incrementalMtime or incrementalHash, or is "" to convert every file.
+	// prune removes the outputs of sources converted by an earlier
+	// incremental run that are no longer part of the batch.
+	incremental string
+	prune       bool
+}
+
+// fileResult is the outcome of converting a single file of a batch.
+type fileResult struct {
+	// attempted is false for files not converted because an earlier file
+	// failed with batchOptions.failFast set.
+	attempted bool
+	// upToDate is set for files not converted because their outputs are up
+	// to date with batchOptions.incremental; outputs lists them.
+	upToDate bool
+	err      error
+	// warnings are the conversion warnings, printed once every file has
+	// been converted so that the messages of different files never
+	// interleave.
+	warnings []converter.Warning
+	// data is the output of the file when it is printed to stdout, with
+	// batchOptions.stdout or -tee.
+	data []byte
+	// documents and dir are the decoded documents and their output
+	// directory in split mode, written in file order after conversion.
+	documents []converter.Document
+	dir       string
+	// outputs are the files written, and duration the time spent
+	// converting the file.
+	outputs  []string
+	duration time.Duration
+	// diff is the difference from the existing output in diff mode, or ""
+	// when the output is up to date.
+	diff string
+}
+
+// batchResult counts the files of a batch conversion by outcome.
+type batchResult struct {
+	converted int
+	// skipped counts the files with no document matching the filters.
+	skipped int
+	failed  int
+	// notAttempted counts the files not converted because an earlier file
+	// failed with batchOptions.failFast set.
+	notAttempted int
+	// unread counts the files of the input that were skipped before being
+	// read, for not being YAML files or matching an -exclude pattern.
+	unread skippedFiles
+	// err is the error of the first file that failed, in file order. When
+	// no file failed, it is the error that stopped the batch before any
+	// output was written, such as existing outputs that may not be
+	// overwritten.
+	err error
+	// files is the outcome of every file, in file order.
+	files  []fileReport
+	dryRun bool
+	// stale counts the files whose existing output differs in diff mode;
+	// they are not counted as converted.
+	stale int
+	diff  bool
+	// upToDate counts the files skipped with batchOptions.incremental, and
+	// pruned lists the stale outputs removed with batchOptions.prune.
+	upToDate    int
+	pruned      []string
+	incremental bool
+}
+
+// outputs returns every file written by the batch, in file order.
+func (r batchResult) outputs() []string {
+	var outputs []string
+	for _, file := range r.files {
+		outputs = append(outputs, file.Outputs...)
+	}
+	return outputs
+}
+
+// convertFiles converts each YAML file found under root, using up to
+// batch.workers concurrent workers. Conversion continues past failures
+// unless batch.failFast is set, in which case no further file is started
+// and split output is written for no file after the first that failed.
+// Warnings are printed in file order once every file has been converted,
+// followed by every failure.
+func convertFiles(root string, files []string, batch batchOptions, opts converter.Options) batchResult {
+	// Batch conversion always goes from YAML to JSON
+	opts.Reverse = false
+
+	// Find the files that are up to date before converting anything, and
+	// the digests of the others to record once they are converted
+	results := make([]fileResult, len(files))
+	digests := make([]string, len(files))
+	var state *incrementalState
+	if batch.incremental != "" {
+		stateDir := batch.outputDir
+		if stateDir == "" {
+			stateDir = root
+		}
+		state = loadState(batch.incremental, root, stateDir, optionsDigest(batch, opts))
+		state.claim(files)
+		for i, path := range files {
+			out := ""
+			if !batch.split {
+				var err error
+				if out, err = batchOutputPath(root, batch.outputDir, path); err != nil {
+					continue
+				}
+			}
+			outputs, digest, upToDate := state.check(path, out)
+			digests[i] = digest
+			if upToDate {
+				results[i] = fileResult{attempted: true, upToDate: true, outputs: outputs}
+				verbosef(1, []logAttr{{"file", path}, {"status", statusUpToDate}}, "Skipping %s: up to date", path)
+			}
+		}
+	}
+
+	// Check every output path before converting anything
+	if !batch.split && !batch.dryRun && !batch.diff && !batch.stdout {
+		var outputs []string
+		for i, path := range files {
+			if results[i].upToDate {
+				continue
+			}
+			if out, err := batchOutputPath(root, batch.outputDir, path); err == nil {
+				outputs = append(outputs, out)
+			}
+		}
+		if err := checkOverwrite(outputs); err != nil {
+			return batchResult{err: err}
+		}
+	}
+
+	workers := batch.workers
+	if workers < 1 {
+		workers = 1
+	}
+	start := time.Now()
+	verbosef(2, []logAttr{{"files", len(files)}, {"workers", workers}}, "Converting %d files with %d workers", len(files), workers)
+	var prog *progress
+	if batch.progress {
+		prog = startProgress(len(files))
+	}
+	var stop atomic.Bool
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if stop.Load() {
+					continue
+				}
+				if !results[i].upToDate {
+					results[i] = convertBatchFile(root, files[i], batch, opts)
+				}
+				failed := results[i].err != nil && !errors.Is(results[i].err, converter.ErrNoMatch)
+				prog.add(failed)
+				if batch.failFast && failed {
+					stop.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	prog.finish()
+
+	// Split output paths are only known once the files have been decoded.
+	// Documents of different files that map to the same path are reported
+	// before anything is written; collisions within a file fail that file.
+	if batch.split && !batch.dryRun {
+		var outputs, collisions []string
+		owners := make(map[string]int)
+		for i, result := range results {
+			if !result.attempted || result.upToDate || result.err != nil {
+				continue
+			}
+			for _, doc := range result.documents {
+				out, err := splitPath(files[i], result.dir, doc)
+				if err != nil {
+					continue
+				}
+				outputs = append(outputs, out)
+				owner, ok := owners[out]
+				if !ok {
+					owners[out] = i
+				} else if owner != i {
+					collisions = append(collisions, fmt.Sprintf("%s and %s document %d both map to %s", files[owner], files[i], doc.Index, out))
+				}
+			}
+		}
+		if len(collisions) > 0 {
+			return batchResult{err: &outputError{fmt.Errorf("output file name collision: %s", strings.Join(collisions, "; "))}}
+		}
+		if err := checkOverwrite(outputs); err != nil {
+			return batchResult{err: err}
+		}
+	}
+
+	// Report in file order, writing split output now so that file name
+	// collisions are resolved the same way on every run
+	claimed := make(map[string]string)
+	var failures []fileFailure
+	total := batchResult{dryRun: batch.dryRun, diff: batch.diff, incremental: batch.incremental != ""}
+	halted := false
+	for i, path := range files {
+		result := results[i]
+		if !result.attempted || (halted && batch.split) {
+			total.files = append(total.files, fileReport{File: path, Status: statusNotAttempted})
+			total.notAttempted++
+			continue
+		}
+		for _, warning := range result.warnings {
+			printWarning(path)(warning)
+		}
+		if batch.split && !result.upToDate && result.err == nil {
+			start := time.Now()
+			result.outputs, result.err = writeSplit(path, result.documents, result.dir, opts, claimed, batch.dryRun)
+			result.duration += time.Since(start)
+		}
+		file := fileReport{File: path, DurationMS: milliseconds(result.duration)}
+		switch {
+		case result.upToDate:
+			file.Status, file.Outputs = statusUpToDate, result.outputs
+			total.upToDate++
+			state.record(path, "", result.outputs)
+		case errors.Is(result.err, converter.ErrNoMatch):
+			infof("Skipped %s: %v", path, result.err)
+			file.Status = statusSkipped
+			total.skipped++
+			state.forget(path)
+		case result.err != nil:
+			state.forget(path)
+			failures = append(failures, fileFailure{path, result.err})
+			if total.failed == 0 {
+				total.err = result.err
+			}
+			report := newJSONError(path, result.err)
+			file.Status, file.Error = statusFailed, &report
+			total.failed++
+			halted = batch.failFast
+		case result.diff != "":
+			file.Status, file.Outputs = statusStale, result.outputs
+			total.stale++
+			fmt.Fprint(stdout, result.diff)
+		case batch.diff:
+			file.Status, file.Outputs = statusUpToDate, result.outputs
+			total.converted++
+		default:
+			file.Status, file.Outputs = statusConverted, result.outputs
+			total.converted++
+			state.record(path, digests[i], result.outputs)
+			if result.data != nil {
+				printOutput(result.data)
+			}
+			if batch.listOutputs && !batch.dryRun {
+				successf("Converted %s to %s", path, strings.Join(result.outputs, ", "))
+			}
+		}
+		total.files = append(total.files, file)
+	}
+	if batch.dryRun {
+		for _, file := range total.files {
+			printFilePlan(file)
+		}
+	}
+	for _, failure := range failures {
+		reportFailure("Failed to convert", failure.path, failure.err)
+	}
+	if state != nil {
+		var err error
+		if batch.prune {
+			total.pruned, err = state.prune(files, total.outputs())
+			for _, path := range total.pruned {
+				verbosef(1, []logAttr{{"file", path}}, "Pruned %s", path)
+			}
+		}
+		if err == nil {
+			err = state.save()
+		}
+		// The state is reported with the failures of the batch if there
+		// are any, or else as the error of the batch
+		if err != nil && total.failed > 0 {
+			reportFailure("Failed to update", state.path(), err)
+		} else if err != nil {
+			total.err = err
+		}
+	}
+	elapsed := time.Since(start)
+	verbosef(1, []logAttr{{"files", len(files)}, durationAttr(elapsed)}, "Finished %d files in %s", len(files), formatDuration(milliseconds(elapsed)))
+	return total
+}
+
+// fileFailure is a file of a batch that failed to convert.
+type fileFailure struct {
+	path string
+	err  error
+}
+
+// convertBatchFile converts a single file of a batch. In split mode the
+// file is only decoded; its documents are written by convertFiles.
+func convertBatchFile(root, path string, batch batchOptions, opts converter.Options) (result fileResult) {
+	result.attempted = true
+	start := time.Now()
+	verbosef(1, []logAttr{{"file", path}}, "Converting %s", path)
+	defer func() {
+		result.duration = time.Since(start)
+		logFinished(path, result.err, result.diff != "", result.duration)
+	}()
+	opts.Warn = func(w converter.Warning) {
+		result.warnings = append(result.warnings, w)
+	}
+	if batch.stdout {
+		var data []byte
+		if data, result.err = readInputFile(path); result.err == nil {
+			result.data, result.err = converter.Convert(data, opts)
+		}
+		return result
+	}
+	out, err := batchOutputPath(root, batch.outputDir, path)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	verbosef(2, []logAttr{{"file", path}, {"output", out}}, "Output of %s: %s", path, out)
+	if batch.split {
+		// A -name-template names files from the top of the output directory
+		result.dir = filepath.Dir(out)
+		if nameTemplate != nil {
+			result.dir = batch.outputDir
+		}
+		var data []byte
+		if data, result.err = readInputFile(path); result.err == nil {
+			result.documents, result.err = converter.Decode(data, opts)
+		}
+		return result
+	}
+	if batch.dryRun || batch.diff {
+		var data, output []byte
+		if data, result.err = readInputFile(path); result.err == nil {
+			output, result.err = converter.Convert(data, opts)
+		}
+		if result.err == nil && batch.diff {
+			result.diff, result.err = compareOutput(path, out, output, batch.diffExact)
+		}
+		if result.err == nil {
+			result.outputs = []string{out}
+		}
+		return result
+	}
+	if err := makeOutputDir(filepath.Dir(out)); err != nil {
+		result.err = &outputError{err}
+		return result
+	}
+	// Archive entries are only in memory, and with -tee the output is
+	// printed in file order once every file is converted
+	if _, entry := archiveFiles[path]; entry || teeOutput {
+		var data, output []byte
+		if data, result.err = readInputFile(path); result.err == nil {
+			output, result.err = converter.Convert(data, opts)
+		}
+		if result.err == nil {
+			if err := writeOutputFile(out, output, true); err != nil {
+				result.err = &converter.IOError{Op: "write", Path: out, Err: err}
+			}
+		}
+		if teeOutput {
+			result.data = output
+		}
+	} else {
+		result.err = converter.ConvertFile(path, out, opts)
+	}
+	if result.err == nil {
+		result.outputs = []string{out}
+	}
+	return result
+}
+
+// logFinished logs, with -v, how the conversion of the file at path ended,
+// with err and stale for a file whose output is out of date with -diff, and
+// the time it took. The split output of a batch is only written later, so a
+// split file of a batch is logged once its documents have been decoded.
+func logFinished(path string, err error, stale bool, d time.Duration) {
+	status := statusConverted
+	switch {
+	case errors.Is(err, converter.ErrNoMatch):
+		status = statusSkipped
+	case err != nil:
+		status = statusFailed
+	case stale:
+		status = statusStale
+	}
+	attrs := []logAttr{{"file", path}, {"status", status}, durationAttr(d)}
+	verbosef(1, attrs, "Finished %s in %s: %s", path, formatDuration(milliseconds(d)), status)
+}
+
+// printBatchSummary prints the totals of a batch conversion. A batch
+// without failures prints them as a success message. Files not attempted
+// after a failure and outputs pruned are counted when there are any, files
+// up to date with -incremental always, and with -exclude, the files skipped
+// without being read are counted too.
+func printBatchSummary(result batchResult) {
+	report := logf
+	if result.failed == 0 {
+		report = successf
+	}
+	unread := ""
+	if len(result.pruned) > 0 {
+		unread = fmt.Sprintf(", %d stale outputs pruned", len(result.pruned))
+	}
+	if result.notAttempted > 0 {
+		unread += fmt.Sprintf(", %d not attempted", result.notAttempted)
+	}
+	if len(excludes) > 0 || result.unread.archive {
+		unread += fmt.Sprintf("; %d excluded, %d not YAML", result.unread.excluded, result.unread.notYAML)
+	}
+	if result.dryRun {
+		report("Dry run: would convert %d files, %d skipped, %d failed%s", result.converted, result.skipped, result.failed, unread)
+		return
+	}
+	if result.diff {
+		if result.stale > 0 {
+			report = logf
+		}
+		report("%d files up to date, %d stale, %d skipped, %d failed%s", result.converted, result.stale, result.skipped, result.failed, unread)
+		return
+	}
+	upToDate := ""
+	if result.incremental {
+		upToDate = fmt.Sprintf(", %d up to date", result.upToDate)
+	}
+	if result.skipped > 0 {
+		report("Converted %d files%s, %d skipped, %d failed%s", result.converted, upToDate, result.skipped, result.failed, unread)
+		return
+	}
+	report("Converted %d files%s, %d failed%s", result.converted, upToDate, result.failed, unread)
+}
+
+// convertSplitFile converts the file at path and writes each of its documents
+// to its own JSON file in dir.
+func convertSplitFile(path, dir string, opts converter.Options, claimed map[string]string) error {
+	data, err := readInputFile(path)
+	if err != nil {
+		return err
+	}
+	documents, err := converter.Decode(data, opts)
+	if err != nil {
+		return err
+	}
+	_, err = writeSplit(path, documents, dir, opts, claimed, false)
+	return err
+}
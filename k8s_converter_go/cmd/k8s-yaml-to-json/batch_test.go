@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// writeTree creates the given files (relative path to content) under dir.
+func writeTree(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestFindYAMLFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"deployment.yaml":      "kind: Deployment\n",
+		"apps/web/service.yml": "kind: Service\n",
+		"archive/all.yaml.gz":  "",
+		"README.md":            "not yaml\n",
+		"apps/notes.txt":       "not yaml\n",
+		"apps/notes.txt.gz":    "",
+	})
+
+	files, _, err := findYAMLFiles(dir)
+	if err != nil {
+		t.Fatalf("findYAMLFiles() error = %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "apps/web/service.yml"),
+		filepath.Join(dir, "archive/all.yaml.gz"),
+		filepath.Join(dir, "deployment.yaml"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("findYAMLFiles() = %v, want %v", files, want)
+	}
+}
+
+func TestJSONFileName(t *testing.T) {
+	tests := map[string]string{
+		"deployment.yaml":    "deployment.json",
+		"apps/service.yml":   "apps/service.json",
+		"all.yaml.gz":        "all.json",
+		"archive/all.YML.GZ": "archive/all.json",
+	}
+	for path, want := range tests {
+		if got := jsonFileName(path); got != want {
+			t.Errorf("jsonFileName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestBatchOutputPath(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		inputDir  string
+		outputDir string
+		path      string
+		want      string
+		wantErr   bool
+	}{
+		{name: "Next to the source", inputDir: "manifests", path: "manifests/apps/web/deploy.yaml", want: "manifests/apps/web/deploy.json"},
+		{name: "Nested tree", inputDir: "manifests", outputDir: "build", path: "manifests/apps/web/deploy.yaml", want: "build/apps/web/deploy.json"},
+		{name: "Dots in the name", inputDir: "manifests", outputDir: "build", path: "manifests/apps/app.v2.yaml", want: "build/apps/app.v2.json"},
+		{name: "Dots in directories", inputDir: "manifests", outputDir: "build", path: "manifests/v1.2/app.v2.yml.gz", want: "build/v1.2/app.v2.json"},
+		{name: "Trailing slash and dot segments", inputDir: "./manifests/", outputDir: "build/", path: "manifests/./apps/deploy.yaml", want: "build/apps/deploy.json"},
+		{name: "Absolute input directory", inputDir: filepath.Join(cwd, "manifests"), outputDir: "build", path: "manifests/apps/deploy.yaml", want: "build/apps/deploy.json"},
+		{name: "Absolute path", inputDir: "manifests", outputDir: "/tmp/build", path: filepath.Join(cwd, "manifests/apps/deploy.yaml"), want: "/tmp/build/apps/deploy.json"},
+		{name: "Glob root", inputDir: ".", outputDir: "build", path: "apps/deploy.yaml", want: "build/apps/deploy.json"},
+		{name: "Outside the input directory", inputDir: "manifests", outputDir: "build", path: "manifests/../secrets/token.yaml", wantErr: true},
+		{name: "Prefix of the input directory", inputDir: "manifests", outputDir: "build", path: "manifests-old/deploy.yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := batchOutputPath(filepath.FromSlash(tt.inputDir), filepath.FromSlash(tt.outputDir), filepath.FromSlash(tt.path))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("batchOutputPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != filepath.FromSlash(tt.want) {
+				t.Errorf("batchOutputPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSiblingOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"web.yaml": "kind: Service\n", "web.json": "{}\n"})
+	// link.json resolves to link.yaml, so writing it would truncate its
+	// source
+	writeTree(t, dir, map[string]string{"link.yaml": "kind: Service\n"})
+	if err := os.Symlink("link.yaml", filepath.Join(dir, "link.json")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if got, err := siblingOutputPath(filepath.Join(dir, "web.yaml")); err != nil || got != filepath.Join(dir, "web.json") {
+		t.Errorf("siblingOutputPath(web.yaml) = %q, %v, want web.json", got, err)
+	}
+	for _, name := range []string{"web.json", "./web.json", "link.yaml"} {
+		_, err := siblingOutputPath(filepath.Join(dir, name))
+		var outputErr *outputError
+		if !errors.As(err, &outputErr) || !strings.Contains(err.Error(), "would replace the input file itself") {
+			t.Errorf("siblingOutputPath(%s) error = %v, want an *outputError", name, err)
+		}
+	}
+}
+
+func TestConvertFilesMirrorsTree(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"apps/web/deploy.yaml": "kind: Deployment\n",
+		"apps/web/app.v2.yaml": "kind: Service\n",
+		"base/config.map.yml":  "kind: ConfigMap\n",
+	})
+	writeTree(t, outside, map[string]string{"shared.yaml": "kind: Secret\n"})
+	if err := os.Symlink(filepath.Join(outside, "shared.yaml"), filepath.Join(root, "base/shared.yaml")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	files, _, err := findYAMLFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputDir := filepath.Join(t.TempDir(), "build")
+
+	result := convertFiles(root, files, batchOptions{outputDir: outputDir, workers: 2}, converter.Options{})
+	if result.failed > 0 {
+		t.Fatalf("convertFiles() failed: %v", result.err)
+	}
+	var got []string
+	err = filepath.WalkDir(outputDir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(outputDir, path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"apps/web/app.v2.json", "apps/web/deploy.json", "base/config.map.json", "base/shared.json"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("output tree = %v, want %v", got, want)
+	}
+	// The symlinked file is written inside the output directory only
+	if entries, _ := os.ReadDir(outside); len(entries) != 1 {
+		t.Errorf("files written next to the symlink target: %v", entries)
+	}
+}
+
+func TestConvertFiles(t *testing.T) {
+	files := map[string]string{
+		"a.yaml":        "kind: Deployment\n",
+		"b/broken.yaml": "This is not valid: YAML: content\n",
+		"c/d.yml":       "kind: Service\n",
+	}
+
+	tests := []struct {
+		name          string
+		outputDir     bool
+		failFast      bool
+		split         bool
+		workers       int
+		kinds         []string
+		wantConverted int
+		wantSkipped   int
+		wantFailed    int
+		wantOutputs   []string
+	}{
+		{
+			name:          "Next to sources",
+			wantConverted: 2,
+			wantFailed:    1,
+			wantOutputs:   []string{"a.json", "c/d.json"},
+		},
+		{
+			name:          "Under output directory",
+			outputDir:     true,
+			wantConverted: 2,
+			wantFailed:    1,
+			wantOutputs:   []string{"a.json", "c/d.json"},
+		},
+		{
+			name:          "Fail fast",
+			failFast:      true,
+			wantConverted: 1,
+			wantFailed:    1,
+			wantOutputs:   []string{"a.json"},
+		},
+		{
+			name:          "Parallel",
+			workers:       4,
+			wantConverted: 2,
+			wantFailed:    1,
+			wantOutputs:   []string{"a.json", "c/d.json"},
+		},
+		{
+			name:          "Parallel split",
+			split:         true,
+			workers:       4,
+			wantConverted: 2,
+			wantFailed:    1,
+			wantOutputs:   []string{"doc-1.json", "c/doc-1.json"},
+		},
+		{
+			name:          "Kind filter",
+			kinds:         []string{"service"},
+			wantConverted: 1,
+			wantSkipped:   1,
+			wantFailed:    1,
+			wantOutputs:   []string{"c/d.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeTree(t, inputDir, files)
+			outputDir, outputRoot := "", inputDir
+			if tt.outputDir {
+				outputDir = t.TempDir()
+				outputRoot = outputDir
+			}
+
+			root, files, _, err := expandInput(inputDir)
+			if err != nil {
+				t.Fatalf("expandInput() error = %v", err)
+			}
+			var errOutput bytes.Buffer
+			savedStderr := stderr
+			stderr = &errOutput
+			defer func() { stderr = savedStderr }()
+			result := convertFiles(root, files, batchOptions{outputDir: outputDir, failFast: tt.failFast, split: tt.split, workers: tt.workers}, converter.Options{Kinds: tt.kinds})
+			if result.converted != tt.wantConverted || result.skipped != tt.wantSkipped || result.failed != tt.wantFailed {
+				t.Errorf("convertFiles() = %d, %d, %d, want %d, %d, %d", result.converted, result.skipped, result.failed, tt.wantConverted, tt.wantSkipped, tt.wantFailed)
+			}
+			if exitCode(result.err) != exitInvalid {
+				t.Errorf("convertFiles() first error = %v, want a parse error", result.err)
+			}
+			want := []string{"Failed to convert " + filepath.Join(inputDir, "b", "broken.yaml") + ":1:24: mapping values are not allowed in this context"}
+			if tt.kinds != nil {
+				want = append([]string{"Skipped " + filepath.Join(inputDir, "a.yaml") + ": no documents match the filters"}, want...)
+			}
+			if got := strings.Split(strings.TrimSuffix(errOutput.String(), "\n"), "\n"); !reflect.DeepEqual(got, want) {
+				t.Errorf("stderr = %q, want %q", got, want)
+			}
+			if tt.kinds != nil {
+				if _, err := os.Stat(filepath.Join(outputRoot, "a.json")); err == nil {
+					t.Errorf("Skipped file a.yaml was converted")
+				}
+			}
+			for _, name := range tt.wantOutputs {
+				if _, err := os.Stat(filepath.Join(outputRoot, name)); err != nil {
+					t.Errorf("Expected output %s: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGlobFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"deploy/web.yaml":          "kind: Deployment\n",
+		"deploy/db.yml":            "kind: StatefulSet\n",
+		"deploy/notes.txt":         "not yaml\n",
+		"deploy/nested/cache.yaml": "kind: Deployment\n",
+		"other/svc.yaml":           "kind: Service\n",
+	})
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{
+			name:    "Single directory",
+			pattern: "deploy/*.yaml",
+			want:    []string{"deploy/web.yaml"},
+		},
+		{
+			name:    "Non-YAML matches are skipped",
+			pattern: "deploy/*",
+			want:    []string{"deploy/db.yml", "deploy/web.yaml"},
+		},
+		{
+			name:    "Double star",
+			pattern: "**/*.yaml",
+			want:    []string{"deploy/nested/cache.yaml", "deploy/web.yaml", "other/svc.yaml"},
+		},
+		{
+			name:    "Double star below a directory",
+			pattern: "deploy/**/*.y?ml",
+			want:    []string{"deploy/nested/cache.yaml", "deploy/web.yaml"},
+		},
+		{
+			name:    "No matches",
+			pattern: "missing/*.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, _, err := globFiles(filepath.Join(dir, tt.pattern))
+			if err != nil {
+				t.Fatalf("globFiles() error = %v", err)
+			}
+			var want []string
+			for _, name := range tt.want {
+				want = append(want, filepath.Join(dir, name))
+			}
+			if !reflect.DeepEqual(files, want) {
+				t.Errorf("globFiles() = %v, want %v", files, want)
+			}
+		})
+	}
+}
+
+func TestGlobRoot(t *testing.T) {
+	tests := map[string]string{
+		"deploy/*.yaml":    "deploy",
+		"a/b/**/*.yaml":    "a/b",
+		"*.yaml":           ".",
+		"/srv/*/app.yaml":  "/srv",
+		"deploy/web.yaml":  "deploy",
+		"deploy/[ab].yaml": "deploy",
+	}
+	for pattern, want := range tests {
+		if got := globRoot(pattern); got != want {
+			t.Errorf("globRoot(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func TestExpandInput(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.yaml":   "kind: Deployment\n",
+		"b/c.yaml": "kind: Service\n",
+	})
+
+	tests := []struct {
+		name        string
+		input       string
+		wantRoot    string
+		wantFiles   int
+		wantBatch   bool
+		expectError bool
+	}{
+		{name: "Single file", input: filepath.Join(dir, "a.yaml")},
+		{name: "Stdin", input: stdinInput},
+		{name: "Directory", input: dir, wantRoot: dir, wantFiles: 2, wantBatch: true},
+		{name: "Glob", input: filepath.Join(dir, "*.yaml"), wantRoot: dir, wantFiles: 1, wantBatch: true},
+		{name: "Glob without matches", input: filepath.Join(dir, "*.yml"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, files, _, err := expandInput(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expandInput() error = %v, expectError %v", err, tt.expectError)
+			}
+			if root != tt.wantRoot || len(files) != tt.wantFiles || (files != nil) != tt.wantBatch {
+				t.Errorf("expandInput() = %q, %v, want root %q with %d files", root, files, tt.wantRoot, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func BenchmarkConvertFiles(b *testing.B) {
+	inputDir := b.TempDir()
+	tree := make(map[string]string)
+	for i := 0; i < 400; i++ {
+		tree[fmt.Sprintf("app-%d/deployment.yaml", i)] = fmt.Sprintf(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-%d
+  labels:
+    app: app-%d
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: app
+          image: registry.example.com/app:%d
+          ports:
+            - containerPort: 8080
+`, i, i, i)
+	}
+	writeTree(b, inputDir, tree)
+	root, files, _, err := expandInput(inputDir)
+	if err != nil {
+		b.Fatalf("expandInput() error = %v", err)
+	}
+
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			outputDir := b.TempDir()
+			for i := 0; i < b.N; i++ {
+				if result := convertFiles(root, files, batchOptions{outputDir: outputDir, workers: workers}, converter.Options{}); result.failed > 0 {
+					b.Fatalf("convertFiles() failed %d files", result.failed)
+				}
+			}
+		})
+	}
+}
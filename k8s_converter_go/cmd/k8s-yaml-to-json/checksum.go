@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checksumAlgorithms are the digests -checksum can compute.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// checksumListName is the file -checksum-list writes in the output directory.
+const checksumListName = "checksums.txt"
+
+// checksumOptions records the digests of converted outputs, in the format of
+// coreutils sha256sum and sha512sum so that they can be checked with -c.
+type checksumOptions struct {
+	// algorithm is a key of checksumAlgorithms, or "" to compute no
+	// digests.
+	algorithm string
+	// list writes every digest to checksumListName instead of to a file
+	// named after each output with the algorithm as extension, such as
+	// web.json.sha256.
+	list bool
+}
+
+// write records the digests of outputs, files that have just been written
+// under dir. Checksum files are replaced whenever their outputs are written,
+// so that they never describe an older output.
+func (c checksumOptions) write(dir string, outputs []string) error {
+	if c.algorithm == "" || len(outputs) == 0 {
+		return nil
+	}
+	if !c.list {
+		for _, output := range outputs {
+			line, err := c.fileLine(output, filepath.Base(output))
+			if err != nil {
+				return err
+			}
+			if err := writeChecksumFile(output+"."+c.algorithm, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// List the files in name order, whatever order they were written in
+	outputs = append([]string(nil), outputs...)
+	sort.Strings(outputs)
+	var lines strings.Builder
+	for _, output := range outputs {
+		name, err := filepath.Rel(dir, output)
+		if err != nil {
+			return &outputError{fmt.Errorf("writing checksum file: %w", err)}
+		}
+		line, err := c.fileLine(output, filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		lines.WriteString(line)
+	}
+	return writeChecksumFile(filepath.Join(dir, checksumListName), lines.String())
+}
+
+// fileLine returns the checksum line of the file at path, listed as name.
+func (c checksumOptions) fileLine(path, name string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", &outputError{fmt.Errorf("computing checksum: %w", err)}
+	}
+	defer file.Close()
+	digest := checksumAlgorithms[c.algorithm]()
+	if _, err := io.Copy(digest, file); err != nil {
+		return "", &outputError{fmt.Errorf("computing checksum: %w", err)}
+	}
+	return fmt.Sprintf("%x  %s\n", digest.Sum(nil), name), nil
+}
+
+// writeChecksumFile writes the checksum lines in content to path.
+func writeChecksumFile(path, content string) error {
+	if err := writeOutputFile(path, []byte(content), false); err != nil {
+		return &outputError{fmt.Errorf("writing checksum file: %w", err)}
+	}
+	return nil
+}
+
+// record runs convert, which writes its output to outputFile or to stdout
+// when outputFile is "", and records the digest of the output.
+func (c checksumOptions) record(outputFile string, convert func() error) error {
+	if outputFile == "" {
+		return c.hashStdout(convert)
+	}
+	if err := convert(); err != nil {
+		return err
+	}
+	return c.write(filepath.Dir(outputFile), []string{outputFile})
+}
+
+// hashStdout runs convert, hashing everything it prints to stdout. When
+// convert succeeds the digest is printed to stderr as the checksum line of
+// stdin, "<digest>  -", keeping stdout the converted output alone.
+func (c checksumOptions) hashStdout(convert func() error) error {
+	if c.algorithm == "" {
+		return convert()
+	}
+	digest := checksumAlgorithms[c.algorithm]()
+	saved := stdout
+	stdout = io.MultiWriter(stdout, digest)
+	err := convert()
+	stdout = saved
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "%x  -\n", digest.Sum(nil))
+	return nil
+}
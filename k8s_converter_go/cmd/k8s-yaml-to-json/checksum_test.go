@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecksumWrite(t *testing.T) {
+	sha256Line := func(content, name string) string {
+		return fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(content)), name)
+	}
+	sha512Line := func(content, name string) string {
+		return fmt.Sprintf("%x  %s\n", sha512.Sum512([]byte(content)), name)
+	}
+
+	tests := []struct {
+		name      string
+		checksums checksumOptions
+		want      map[string]string
+	}{
+		{name: "Disabled", want: map[string]string{}},
+		{
+			name:      "File next to each output",
+			checksums: checksumOptions{algorithm: "sha256"},
+			want: map[string]string{
+				"web.json.sha256":     sha256Line("{\"kind\":\"Service\"}\n", "web.json"),
+				"sub/api.json.sha256": sha256Line("{}", "api.json"),
+			},
+		},
+		{
+			name:      "SHA-512",
+			checksums: checksumOptions{algorithm: "sha512"},
+			want: map[string]string{
+				"web.json.sha512":     sha512Line("{\"kind\":\"Service\"}\n", "web.json"),
+				"sub/api.json.sha512": sha512Line("{}", "api.json"),
+			},
+		},
+		{
+			name:      "List",
+			checksums: checksumOptions{algorithm: "sha256", list: true},
+			want: map[string]string{
+				checksumListName: sha256Line("{}", "sub/api.json") + sha256Line("{\"kind\":\"Service\"}\n", "web.json"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, map[string]string{
+				"web.json":     "{\"kind\":\"Service\"}\n",
+				"sub/api.json": "{}",
+			})
+			outputs := []string{filepath.Join(dir, "web.json"), filepath.Join(dir, "sub", "api.json")}
+			if err := tt.checksums.write(dir, outputs); err != nil {
+				t.Fatalf("write() error = %v", err)
+			}
+			for name, want := range tt.want {
+				if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != want {
+					t.Errorf("%s = %q, %v, want %q", name, data, err, want)
+				}
+			}
+			matches, _ := filepath.Glob(filepath.Join(dir, "*.sha*"))
+			lists, _ := filepath.Glob(filepath.Join(dir, checksumListName))
+			nested, _ := filepath.Glob(filepath.Join(dir, "sub", "*.sha*"))
+			if got := len(matches) + len(lists) + len(nested); got != len(tt.want) {
+				t.Errorf("wrote %d checksum files, want %d", got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestChecksumHashStdout(t *testing.T) {
+	var out, errOutput bytes.Buffer
+	savedStdout, savedStderr := stdout, stderr
+	stdout, stderr = &out, &errOutput
+	defer func() { stdout, stderr = savedStdout, savedStderr }()
+
+	err := checksumOptions{algorithm: "sha256"}.hashStdout(func() error {
+		return writeOutput("", []byte(`{"kind":"Service"}`), "")
+	})
+	if err != nil {
+		t.Fatalf("hashStdout() error = %v", err)
+	}
+	if out.String() != "{\"kind\":\"Service\"}\n" {
+		t.Errorf("stdout = %q", out.String())
+	}
+	if want := fmt.Sprintf("%x  -\n", sha256.Sum256(out.Bytes())); errOutput.String() != want {
+		t.Errorf("stderr = %q, want %q", errOutput.String(), want)
+	}
+	if stdout != &out {
+		t.Error("hashStdout() did not restore stdout")
+	}
+}
+
+func TestChecksumFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/web.yaml":     "kind: Service\n",
+		"in/sub/api.yaml": "kind: Service\nmetadata:\n  name: api\n",
+		"config.json":     `{"kind":"ConfigMap"}`,
+		"single/.keep":    "",
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     []string
+	}{
+		{
+			name:     "Single file",
+			args:     []string{"-checksum", "sha256", "-input", filepath.Join(dir, "in", "web.yaml"), "-output", filepath.Join(dir, "single", "web.json")},
+			wantCode: exitOK,
+			want:     []string{"single/web.json.sha256"},
+		},
+		{
+			name:     "Directory list",
+			args:     []string{"-checksum", "sha512", "-checksum-list", "-input", filepath.Join(dir, "in"), "-output", filepath.Join(dir, "batch")},
+			wantCode: exitOK,
+			want:     []string{"batch/" + checksumListName},
+		},
+		{
+			name:     "Split",
+			args:     []string{"-checksum", "sha256", "-split", "-input", filepath.Join(dir, "in", "sub", "api.yaml"), "-output", filepath.Join(dir, "split")},
+			wantCode: exitOK,
+			want:     []string{"split/service-api.json.sha256"},
+		},
+		{
+			name:     "Reverse",
+			args:     []string{"-checksum", "sha256", "-input", filepath.Join(dir, "config.json"), "-output", filepath.Join(dir, "config.yaml")},
+			wantCode: exitOK,
+			want:     []string{"config.yaml.sha256"},
+		},
+		{name: "Unknown algorithm", args: []string{"-checksum", "md5", "-input", filepath.Join(dir, "in", "web.yaml")}, wantCode: exitUsage},
+		{name: "List without algorithm", args: []string{"-checksum-list", "-input", filepath.Join(dir, "in", "web.yaml")}, wantCode: exitUsage},
+		{name: "With dry run", args: []string{"-checksum", "sha256", "-dry-run", "-input", filepath.Join(dir, "in", "web.yaml")}, wantCode: exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errOutput, code := runCommand(t, "", tt.args...)
+			if code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d; stderr = %q", code, tt.wantCode, errOutput)
+			}
+			for _, name := range tt.want {
+				if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("checksum file %s: %v", name, err)
+				}
+			}
+		})
+	}
+
+	stdoutText, errOutput, code := runCommand(t, "", "-checksum", "sha256", "-input", filepath.Join(dir, "in", "web.yaml"))
+	if want := fmt.Sprintf("%x  -\n", sha256.Sum256([]byte(stdoutText))); code != exitOK || errOutput != want {
+		t.Errorf("stdout output: exit code = %d, stderr = %q, want %q", code, errOutput, want)
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// clusterTimeout bounds each request to the API server.
+const clusterTimeout = 30 * time.Second
+
+// fieldManager is the field manager of the dry-run applies of
+// -cluster-validate.
+const fieldManager = "k8s-yaml-to-json"
+
+// Outcomes of -cluster-validate for a resource the API server did not admit.
+const (
+	// clusterWebhook is a rejection by a validating or mutating admission
+	// webhook.
+	clusterWebhook = "webhook"
+	// clusterUnknownField is a field the schema of the kind does not have,
+	// reported with fieldValidation=Strict.
+	clusterUnknownField = "unknown_field"
+	// clusterSchema is a value the API server found invalid, such as a
+	// missing required field or a wrong type.
+	clusterSchema = "schema"
+	// clusterUnknownKind is a kind the API server does not serve, such as a
+	// custom resource whose definition is not installed.
+	clusterUnknownKind = "unknown_kind"
+	// clusterRejected is any other rejection, such as by a built-in
+	// admission plugin or for missing permissions.
+	clusterRejected = "rejected"
+)
+
+// clusterLabels are the words -cluster-validate prints for each outcome.
+var clusterLabels = map[string]string{
+	clusterWebhook:      "REJECTED (admission webhook)",
+	clusterUnknownField: "REJECTED (unknown field)",
+	clusterSchema:       "REJECTED (schema)",
+	clusterUnknownKind:  "REJECTED (unknown kind)",
+	clusterRejected:     "REJECTED",
+}
+
+// clusterError is a document the API server did not admit in a dry run.
+type clusterError struct {
+	outcome string
+	// resource names the document, such as "Deployment prod/web".
+	resource string
+	document int
+	line     int
+	message  string
+}
+
+func (e *clusterError) Error() string {
+	return e.resource + ": " + e.message
+}
+
+// clusterUnreachableError is a failure to talk to the API server, after
+// which no document can be validated.
+type clusterUnreachableError struct {
+	server string
+	err    error
+}
+
+func (e *clusterUnreachableError) Error() string {
+	return fmt.Sprintf("cannot reach the cluster at %s: %v", e.server, e.err)
+}
+
+func (e *clusterUnreachableError) Unwrap() error {
+	return e.err
+}
+
+// clusterClient submits documents to an API server as dry runs and reads
+// their live objects. Every request that could change the cluster carries
+// dryRun=All, so that no resource is ever persisted.
+type clusterClient struct {
+	config    *clusterConfig
+	discovery *discovery.DiscoveryClient
+	dynamic   dynamic.Interface
+	// mapper maps the kinds of documents to resources, discovering each
+	// group version once.
+	mapper meta.RESTMapper
+}
+
+// newClusterClient returns a client for the API server of config.
+func newClusterClient(config *clusterConfig) (*clusterClient, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config.rest)
+	if err != nil {
+		return nil, kubeconfigErrorf("invalid kubeconfig: %v", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config.rest)
+	if err != nil {
+		return nil, kubeconfigErrorf("invalid kubeconfig: %v", err)
+	}
+	return &clusterClient{
+		config:    config,
+		discovery: discoveryClient,
+		dynamic:   dynamicClient,
+		mapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)),
+	}, nil
+}
+
+// unreachable returns the *clusterUnreachableError of err.
+func (c *clusterClient) unreachable(err error) error {
+	return &clusterUnreachableError{c.config.rest.Host, err}
+}
+
+// ping checks that the API server answers and accepts the credentials, so
+// that an unreachable cluster is reported once instead of for every
+// document. The discovery of v1, which every cluster serves, is probed
+// rather than /version, which clusters usually serve to anonymous clients.
+func (c *clusterClient) ping() error {
+	_, err := c.discovery.ServerResourcesForGroupVersion("v1")
+	if apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err) {
+		return c.unreachable(fmt.Errorf("the credentials of %s are not accepted: %v", c.config.source, err))
+	}
+	if err != nil {
+		return c.unreachable(err)
+	}
+	return nil
+}
+
+// rejection returns the *clusterError of doc with outcome and message.
+func rejection(doc converter.Document, outcome, message string) error {
+	return &clusterError{outcome: outcome, resource: resourceName(doc), document: doc.Index, line: doc.Line, message: message}
+}
+
+// resource returns the client of the collection doc belongs to, such as the
+// deployments of namespace prod. Namespaced documents without a namespace are
+// in the namespace of the kubeconfig context.
+func (c *clusterClient) resource(doc converter.Document) (dynamic.ResourceInterface, error) {
+	apiVersion, kind := doc.APIVersion(), doc.Kind()
+	generateName := ""
+	if object, ok := doc.Value.(*converter.Object); ok {
+		if metadata, ok := object.Get("metadata"); ok {
+			if metadata, ok := metadata.(*converter.Object); ok {
+				value, _ := metadata.Get("generateName")
+				generateName, _ = value.(string)
+			}
+		}
+	}
+	if apiVersion == "" || kind == "" || (doc.Name() == "" && generateName == "") {
+		return nil, rejection(doc, clusterRejected, "apiVersion, kind and metadata.name or metadata.generateName are required")
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, rejection(doc, clusterRejected, err.Error())
+	}
+	mapping, err := c.mapper.RESTMapping(gv.WithKind(kind).GroupKind(), gv.Version)
+	if meta.IsNoMatchError(err) {
+		return nil, rejection(doc, clusterUnknownKind, fmt.Sprintf("the server does not serve kind %s in %s", kind, apiVersion))
+	}
+	if err != nil {
+		return nil, c.unreachable(err)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return c.dynamic.Resource(mapping.Resource), nil
+	}
+	namespace := doc.Namespace()
+	if namespace == "" {
+		namespace = c.config.namespace
+	}
+	return c.dynamic.Resource(mapping.Resource).Namespace(namespace), nil
+}
+
+// failure returns the error of a failed request for doc: a *clusterError, or
+// a *clusterUnreachableError when the API server itself fails.
+func (c *clusterClient) failure(doc converter.Document, err error) error {
+	var statusErr apierrors.APIStatus
+	if !errors.As(err, &statusErr) {
+		return c.unreachable(err)
+	}
+	status := statusErr.Status()
+	outcome := classifyRejection(int(status.Code), status)
+	if outcome == "" {
+		return c.unreachable(errors.New(status.Message))
+	}
+	return rejection(doc, outcome, status.Message)
+}
+
+// validate submits doc as a dry run and returns a *clusterError when the API
+// server does not admit it. Documents with a name are applied server-side,
+// as kubectl apply --server-side does, so that both new and existing
+// resources are checked; documents with only metadata.generateName are
+// created.
+func (c *clusterClient) validate(doc converter.Document) error {
+	resource, err := c.resource(doc)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(doc.Value)
+	if err != nil {
+		return err
+	}
+	if name := doc.Name(); name != "" {
+		force := true
+		_, err = resource.Patch(context.Background(), name, types.ApplyPatchType, body, metav1.PatchOptions{
+			DryRun:          []string{metav1.DryRunAll},
+			Force:           &force,
+			FieldManager:    fieldManager,
+			FieldValidation: "Strict",
+		})
+	} else {
+		object := &unstructured.Unstructured{}
+		if err := object.UnmarshalJSON(body); err != nil {
+			return rejection(doc, clusterRejected, err.Error())
+		}
+		_, err = resource.Create(context.Background(), object, metav1.CreateOptions{
+			DryRun:          []string{metav1.DryRunAll},
+			FieldManager:    fieldManager,
+			FieldValidation: "Strict",
+		})
+	}
+	if err != nil {
+		return c.failure(doc, err)
+	}
+	return nil
+}
+
+// classifyRejection returns the outcome of a response with code and status
+// other than success, or "" for a failure of the API server itself, such as
+// expired credentials, rather than of the document.
+func classifyRejection(code int, status metav1.Status) string {
+	switch {
+	case strings.Contains(status.Message, "admission webhook"):
+		return clusterWebhook
+	case strings.Contains(status.Message, "unknown field"):
+		return clusterUnknownField
+	case code == http.StatusUnauthorized || code >= 500:
+		return ""
+	case code == http.StatusUnprocessableEntity || status.Reason == metav1.StatusReasonInvalid:
+		return clusterSchema
+	}
+	return clusterRejected
+}
+
+// resourceName names doc by kind, namespace and name, such as
+// "Deployment prod/web".
+func resourceName(doc converter.Document) string {
+	name := doc.Name()
+	if name == "" {
+		name = "(generated)"
+	}
+	if namespace := doc.Namespace(); namespace != "" {
+		name = namespace + "/" + name
+	}
+	kind := doc.Kind()
+	if kind == "" {
+		kind = fmt.Sprintf("document %d", doc.Index)
+	}
+	return kind + " " + name
+}
+
+// eachClusterDocument checks that the API server of config can be reached,
+// then decodes every file and calls check for each of its documents.
+// Failures are reported as they are found, rejections by the API server with
+// the label of their outcome, and the error of the first is returned with the
+// number of rejections. An API server that fails stops the run, as it cannot
+// check the remaining documents either.
+func eachClusterDocument(config *clusterConfig, files []string, opts converter.Options, check func(client *clusterClient, path string, doc converter.Document) error) (rejected int, firstErr error) {
+	client, err := newClusterClient(config)
+	if err == nil {
+		err = client.ping()
+	}
+	if err != nil {
+		reportError("", err)
+		return 0, err
+	}
+	verbosef(1, []logAttr{{"server", config.rest.Host}}, "Checking against %s (%s)", config.rest.Host, config.source)
+	// Lists are checked item by item, as kubectl applies them
+	opts.ExplodeLists = true
+
+	fail := func(prefix, path string, err error) {
+		reportFailure(prefix, path, err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, path := range files {
+		data, err := readInputFile(path)
+		var documents []converter.Document
+		if err == nil {
+			opts.Warn = printWarning(path)
+			documents, err = converter.Decode(data, opts)
+		}
+		if err != nil {
+			fail("FAIL", path, err)
+			continue
+		}
+		for _, doc := range documents {
+			err := check(client, path, doc)
+			var clusterErr *clusterError
+			switch {
+			case errors.As(err, &clusterErr):
+				rejected++
+				fail(clusterLabels[clusterErr.outcome], path, err)
+			case err != nil:
+				reportError(path, err)
+				return rejected, err
+			}
+		}
+	}
+	return rejected, firstErr
+}
+
+// clusterValidateFiles submits every document of files to the API server of
+// config as a dry run, printing a line per resource and a summary, and never
+// writing any output. It returns the error of the first file or resource
+// that failed.
+func clusterValidateFiles(config *clusterConfig, files []string, opts converter.Options) error {
+	admitted := 0
+	rejected, err := eachClusterDocument(config, files, opts, func(client *clusterClient, path string, doc converter.Document) error {
+		if err := client.validate(doc); err != nil {
+			return err
+		}
+		admitted++
+		successf("ADMITTED %s: %s", displayName(path), resourceName(doc))
+		return nil
+	})
+	var unreachableErr *clusterUnreachableError
+	if errors.As(err, &unreachableErr) {
+		return err
+	}
+	if err != nil {
+		logf("Cluster validation: %d admitted, %d rejected", admitted, rejected)
+	} else {
+		successf("Cluster validation: %d admitted, %d rejected", admitted, rejected)
+	}
+	return err
+}
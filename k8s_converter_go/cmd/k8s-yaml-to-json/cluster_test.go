@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeLiveObjects are the objects fakeAPIServer serves, by path.
+var fakeLiveObjects = map[string]string{
+	"/apis/apps/v1/namespaces/prod/deployments/web": `{"apiVersion":"apps/v1","kind":"Deployment",` +
+		`"metadata":{"name":"web","namespace":"prod","uid":"1234","resourceVersion":"42","labels":{"app":"web"}},` +
+		`"spec":{"replicas":3,"progressDeadlineSeconds":600},"status":{"readyReplicas":3}}`,
+	"/api/v1/namespaces/prod": `{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"prod","uid":"5678"},"spec":{"finalizers":["kubernetes"]}}`,
+}
+
+// fakeAPIServer serves /version to anyone, and discovery for v1 and apps/v1
+// and fakeLiveObjects to the token secret, and answers dry runs by the name
+// of the resource, failing the test for any request that could change the
+// cluster without dryRun=All. The token denied is authenticated but
+// forbidden everything. It returns a function listing the requests other
+// than GET received so far.
+func fakeAPIServer(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/version" {
+			fmt.Fprint(w, `{"major":"1","minor":"31","gitVersion":"v1.31.0"}`)
+			return
+		}
+		switch r.Header.Get("Authorization") {
+		case "Bearer secret":
+		case "Bearer denied":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","code":403,"reason":"Forbidden","message":"forbidden: User \"denied\" cannot get path \"%s\""}`, r.URL.Path)
+			return
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet {
+			if r.URL.Query().Get("dryRun") != "All" || r.URL.Query().Get("fieldValidation") != "Strict" {
+				t.Errorf("%s %s without dryRun=All and fieldValidation=Strict", r.Method, r.URL)
+			}
+			mu.Lock()
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			mu.Unlock()
+		}
+		list := func(groupVersion string, resources ...metav1.APIResource) {
+			json.NewEncoder(w).Encode(metav1.APIResourceList{GroupVersion: groupVersion, APIResources: resources})
+		}
+		status := func(code int, reason metav1.StatusReason, message string) {
+			w.WriteHeader(code)
+			json.NewEncoder(w).Encode(metav1.Status{TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}, Status: "Failure", Code: int32(code), Reason: reason, Message: message})
+		}
+		switch {
+		case r.URL.Path == "/api":
+			fmt.Fprint(w, `{"kind":"APIVersions","versions":["v1"]}`)
+		case r.URL.Path == "/apis":
+			fmt.Fprint(w, `{"kind":"APIGroupList","apiVersion":"v1","groups":[{"name":"apps","versions":[{"groupVersion":"apps/v1","version":"v1"}],"preferredVersion":{"groupVersion":"apps/v1","version":"v1"}}]}`)
+		case r.URL.Path == "/api/v1":
+			list("v1", metav1.APIResource{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}, metav1.APIResource{Name: "namespaces", Kind: "Namespace"})
+		case r.URL.Path == "/apis/apps/v1":
+			list("apps/v1", metav1.APIResource{Name: "deployments", Kind: "Deployment", Namespaced: true}, metav1.APIResource{Name: "deployments/status", Kind: "Deployment", Namespaced: true})
+		case r.Method == http.MethodGet && fakeLiveObjects[r.URL.Path] != "":
+			fmt.Fprint(w, fakeLiveObjects[r.URL.Path])
+		case r.Method == http.MethodGet:
+			status(http.StatusNotFound, metav1.StatusReasonNotFound, "not found")
+		case strings.HasSuffix(r.URL.Path, "/hooked"):
+			status(http.StatusBadRequest, metav1.StatusReasonBadRequest, `admission webhook "policy.example.com" denied the request: images must come from registry.example.com`)
+		case strings.HasSuffix(r.URL.Path, "/typo"):
+			status(http.StatusBadRequest, metav1.StatusReasonBadRequest, `.spec.replica: field not declared in schema; strict decoding error: unknown field "spec.replica"`)
+		case strings.HasSuffix(r.URL.Path, "/invalid"):
+			status(http.StatusUnprocessableEntity, metav1.StatusReasonInvalid, `Deployment.apps "invalid" is invalid: spec.selector: Required value`)
+		default:
+			// Admitted: the dry run returns the object
+			io.Copy(w, r.Body)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requests...)
+	}
+}
+
+// clusterEntry is the kubeconfig cluster of server, trusting its certificate.
+func clusterEntry(server *httptest.Server) string {
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return "{server: " + server.URL + ", certificate-authority-data: " + base64.StdEncoding.EncodeToString(ca) + "}"
+}
+
+// writeKubeconfig writes a kubeconfig for server to dir and returns its path.
+func writeKubeconfig(t *testing.T, dir string, server *httptest.Server) string {
+	t.Helper()
+	writeTree(t, dir, map[string]string{
+		"kubeconfig": "current-context: test\n" +
+			"contexts: [{name: test, context: {cluster: test, user: test, namespace: team}}]\n" +
+			"clusters: [{name: test, cluster: " + clusterEntry(server) + "}]\n" +
+			"users: [{name: test, user: {token: secret}}]\n",
+	})
+	return filepath.Join(dir, "kubeconfig")
+}
+
+func TestClusterValidate(t *testing.T) {
+	server, requests := fakeAPIServer(t)
+	dir := t.TempDir()
+	kubeconfig := writeKubeconfig(t, dir, server)
+	writeTree(t, dir, map[string]string{
+		"ok.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: prod\n---\n" +
+			"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: prod\n---\n" +
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  generateName: settings-\n",
+		"bad.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: hooked\n---\n" +
+			"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: typo\n---\n" +
+			"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: invalid\n---\n" +
+			"apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n",
+	})
+
+	ok, bad := filepath.Join(dir, "ok.yaml"), filepath.Join(dir, "bad.yaml")
+
+	stdoutText, stderrText, code := runCommand(t, "", "-input", ok, "-cluster-validate", "-kubeconfig", kubeconfig)
+	if code != exitOK || stdoutText != "" {
+		t.Fatalf("admitted: exit code = %d, stdout = %q, stderr = %s", code, stdoutText, stderrText)
+	}
+	for _, want := range []string{"ADMITTED " + ok + ": Namespace prod", "ADMITTED " + ok + ": Deployment prod/web", "ADMITTED " + ok + ": ConfigMap (generated)", "Cluster validation: 3 admitted, 0 rejected"} {
+		if !strings.Contains(stderrText, want) {
+			t.Errorf("stderr = %s, want %q", stderrText, want)
+		}
+	}
+	want := []string{
+		"PATCH /api/v1/namespaces/prod",
+		"PATCH /apis/apps/v1/namespaces/prod/deployments/web",
+		"POST /api/v1/namespaces/team/configmaps",
+	}
+	if got := requests(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+
+	_, stderrText, code = runCommand(t, "", "-input", bad, "-cluster-validate", "-kubeconfig", kubeconfig)
+	if code != exitInvalid {
+		t.Errorf("rejected: exit code = %d, want %d", code, exitInvalid)
+	}
+	for _, want := range []string{
+		"REJECTED (admission webhook) " + bad + `:1: Deployment hooked: admission webhook "policy.example.com" denied the request`,
+		"REJECTED (unknown field) " + bad + `:6: Deployment typo:`,
+		"REJECTED (schema) " + bad + ":11: Deployment invalid:",
+		"REJECTED (unknown kind) " + bad + ":16: Widget w: the server does not serve kind Widget in example.com/v1",
+		"Cluster validation: 0 admitted, 4 rejected",
+	} {
+		if !strings.Contains(stderrText, want) {
+			t.Errorf("stderr = %s, want %q", stderrText, want)
+		}
+	}
+
+	_, stderrText, _ = runCommand(t, "", "-input", bad, "-cluster-validate", "-kubeconfig", kubeconfig, "-error-format", "json")
+	for _, want := range []string{errorWebhook, errorUnknownField, errorClusterSchema, errorUnknownKind} {
+		if !strings.Contains(stderrText, `"error":"`+want+`"`) {
+			t.Errorf("JSON errors = %s, want %s", stderrText, want)
+		}
+	}
+}
+
+func TestClusterValidateUnreachable(t *testing.T) {
+	server, _ := fakeAPIServer(t)
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: prod\n"})
+	input := filepath.Join(dir, "a.yaml")
+
+	// A server that is down
+	down := httptest.NewTLSServer(http.NotFoundHandler())
+	kubeconfig := writeKubeconfig(t, t.TempDir(), down)
+	down.Close()
+	_, stderrText, code := runCommand(t, "", "-input", input, "-cluster-validate", "-kubeconfig", kubeconfig)
+	if code != exitUnreachable || !strings.Contains(stderrText, "cannot reach the cluster at "+down.URL) {
+		t.Errorf("down: exit code = %d, stderr = %s", code, stderrText)
+	}
+
+	// A server that answers /version to anyone but refuses the credentials
+	writeTree(t, dir, map[string]string{
+		"anonymous": "current-context: test\ncontexts: [{name: test, context: {cluster: test}}]\nclusters: [{name: test, cluster: " + clusterEntry(server) + "}]\n",
+		"denied":    "current-context: test\ncontexts: [{name: test, context: {cluster: test, user: test}}]\nclusters: [{name: test, cluster: " + clusterEntry(server) + "}]\nusers: [{name: test, user: {token: denied}}]\n",
+	})
+	tests := []struct {
+		kubeconfig string
+		want       string
+	}{
+		{"anonymous", "the server has asked for the client to provide credentials"},
+		{"denied", `User "denied" cannot get path "/api/v1"`},
+	}
+	for _, tt := range tests {
+		kubeconfig := filepath.Join(dir, tt.kubeconfig)
+		_, stderrText, code = runCommand(t, "", "-input", input, "-cluster-validate", "-kubeconfig", kubeconfig)
+		want := "cannot reach the cluster at " + server.URL + ": the credentials of context test of kubeconfig " + kubeconfig + " are not accepted: "
+		if code != exitUnreachable || !strings.Contains(stderrText, want) || !strings.Contains(stderrText, tt.want) {
+			t.Errorf("%s: exit code = %d, stderr = %s, want %q", tt.kubeconfig, code, stderrText, tt.want)
+		}
+		if strings.Contains(stderrText, "REJECTED") {
+			t.Errorf("%s: stderr = %s, want no rejected document", tt.kubeconfig, stderrText)
+		}
+	}
+}
+
+func TestClusterValidateUsage(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.yaml": "kind: Service\n", "kubeconfig": "clusters: [\n"})
+	input := filepath.Join(dir, "a.yaml")
+
+	tests := [][]string{
+		{"-input", input, "-cluster-validate", "-output", filepath.Join(dir, "a.json")},
+		{"-input", input, "-cluster-validate", "-validate"},
+		{"-input", input, "-cluster-validate", "-dry-run"},
+		{"-input", input, "-kubeconfig", filepath.Join(dir, "kubeconfig")},
+		{"-input", input, "-cluster-validate", "-kubeconfig", filepath.Join(dir, "kubeconfig")},
+	}
+	for _, args := range tests {
+		if _, _, code := runCommand(t, "", args...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// live returns the object of doc in the cluster, with the fields populated by
+// the API server removed as by -clean, and false when it does not exist.
+// Documents with only metadata.generateName never exist, as applying them
+// creates a new object.
+func (c *clusterClient) live(doc converter.Document) (interface{}, bool, error) {
+	resource, err := c.resource(doc)
+	if err != nil || doc.Name() == "" {
+		return nil, false, err
+	}
+	object, err := resource.Get(context.Background(), doc.Name(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, c.failure(doc, err)
+	}
+	data, err := object.MarshalJSON()
+	if err != nil {
+		return nil, false, c.unreachable(err)
+	}
+	documents, err := converter.Decode(data, converter.Options{Clean: true})
+	if err != nil || len(documents) != 1 {
+		return nil, false, c.unreachable(fmt.Errorf("invalid response for %s: %v", resourceName(doc), err))
+	}
+	return documents[0].Value, true, nil
+}
+
+// clusterDrift splits the differences from the live object of a resource to
+// its converted document into drift, the values the document sets that the
+// cluster does not have, and liveOnly, the fields only the live object has,
+// such as defaults and fields set by controllers. Applying the document
+// changes the drift but leaves the live-only fields as they are.
+func clusterDrift(live, converted interface{}) (drift, liveOnly []converter.Difference) {
+	applied := appliedPart(live, converted)
+	return converter.Diff(applied, converted), converter.Diff(live, applied)
+}
+
+// appliedPart returns the part of live that applying converted sets. As a
+// server-side apply merges mappings field by field, the fields of a mapping
+// that converted leaves out are left out; lists and the items inside them
+// are replaced, so they are kept whole, and a list item converted drops is
+// drift rather than live only.
+func appliedPart(live, converted interface{}) interface{} {
+	liveObject, ok := live.(*converter.Object)
+	convertedObject, isObject := converted.(*converter.Object)
+	if !ok || !isObject {
+		return live
+	}
+	part := converter.NewObject()
+	for _, key := range liveObject.Keys() {
+		if convertedValue, ok := convertedObject.Get(key); ok {
+			liveValue, _ := liveObject.Get(key)
+			part.Set(key, appliedPart(liveValue, convertedValue))
+		}
+	}
+	return part
+}
+
+// clusterDiffFiles compares every document of files with its live object in
+// the API server of config, printing the changed paths of every resource
+// that drifted to stdout, and reports whether any did or does not exist in
+// the cluster. Nothing is ever written to the cluster.
+func clusterDiffFiles(config *clusterConfig, files []string, opts converter.Options) (bool, error) {
+	var inSync, drifted, created int
+	rejected, err := eachClusterDocument(config, files, opts, func(client *clusterClient, path string, doc converter.Document) error {
+		live, found, err := client.live(doc)
+		if err != nil {
+			return err
+		}
+		location := fmt.Sprintf("%s:%d", displayName(path), doc.Line)
+		if !found {
+			created++
+			fmt.Fprintf(stdout, "%s: would create (%s)\n", resourceName(doc), location)
+			return nil
+		}
+		drift, liveOnly := clusterDrift(live, doc.Value)
+		if len(drift) == 0 {
+			inSync++
+			successf("%s: in sync (%s), %d live-only fields", resourceName(doc), location, len(liveOnly))
+			return nil
+		}
+		drifted++
+		var diff strings.Builder
+		fmt.Fprintf(&diff, "--- %s (live)\n+++ %s (converted)\n", resourceName(doc), location)
+		for _, difference := range drift {
+			diff.WriteString(difference.String() + "\n")
+		}
+		for _, difference := range liveOnly {
+			fmt.Fprintf(&diff, "= %s (live only)\n", strings.TrimPrefix(difference.String(), "- "))
+		}
+		fmt.Fprint(stdout, diff.String())
+		return nil
+	})
+	var unreachableErr *clusterUnreachableError
+	if errors.As(err, &unreachableErr) {
+		return false, err
+	}
+	summary := fmt.Sprintf("Cluster diff: %d in sync, %d drifted, %d would create, %d rejected", inSync, drifted, created, rejected)
+	if err != nil || drifted > 0 || created > 0 {
+		logf("%s", summary)
+	} else {
+		successf("%s", summary)
+	}
+	return drifted > 0 || created > 0, err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestClusterDrift(t *testing.T) {
+	live := converter.NewObject()
+	live.Set("replicas", 3)
+	live.Set("progressDeadlineSeconds", 600)
+	converted := converter.NewObject()
+	converted.Set("replicas", 5)
+	converted.Set("paused", true)
+
+	drift, liveOnly := clusterDrift(live, converted)
+	var got []string
+	for _, difference := range drift {
+		got = append(got, difference.String())
+	}
+	if want := "~ .replicas: 3 -> 5\n+ .paused: true"; strings.Join(got, "\n") != want {
+		t.Errorf("drift = %q, want %q", got, want)
+	}
+	if len(liveOnly) != 1 || liveOnly[0].Path != ".progressDeadlineSeconds" {
+		t.Errorf("live-only = %v, want .progressDeadlineSeconds", liveOnly)
+	}
+}
+
+func TestClusterDriftLists(t *testing.T) {
+	container := func(name string, fields ...string) *converter.Object {
+		object := converter.NewObject()
+		object.Set("name", name)
+		for _, field := range fields {
+			object.Set(field, "live")
+		}
+		return object
+	}
+	spec := func(containers ...interface{}) *converter.Object {
+		object := converter.NewObject()
+		object.Set("containers", containers)
+		object.Set("restartPolicy", "Always")
+		return object
+	}
+	live := spec(container("app", "terminationMessagePath"), container("sidecar"))
+	converted := spec(container("app"))
+	converted.Delete("restartPolicy")
+
+	drift, liveOnly := clusterDrift(live, converted)
+	var got []string
+	for _, difference := range drift {
+		got = append(got, difference.String())
+	}
+	want := "- .containers[0].terminationMessagePath: \"live\"\n- .containers[1]: {\"name\":\"sidecar\"}"
+	if strings.Join(got, "\n") != want {
+		t.Errorf("drift = %q, want %q", got, want)
+	}
+	if len(liveOnly) != 1 || liveOnly[0].Path != ".restartPolicy" {
+		t.Errorf("live-only = %v, want .restartPolicy", liveOnly)
+	}
+}
+
+func TestClusterDiff(t *testing.T) {
+	server, requests := fakeAPIServer(t)
+	dir := t.TempDir()
+	kubeconfig := writeKubeconfig(t, dir, server)
+	writeTree(t, dir, map[string]string{
+		"synced.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: prod\n",
+		"app.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: prod\n  labels:\n    app: web\n    tier: frontend\nspec:\n  replicas: 5\n---\n" +
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n",
+	})
+	synced, app := filepath.Join(dir, "synced.yaml"), filepath.Join(dir, "app.yaml")
+
+	// Server-populated and live-only fields are not drift
+	stdoutText, stderrText, code := runCommand(t, "", "-input", synced, "-cluster-diff", "-kubeconfig", kubeconfig)
+	if code != exitOK || stdoutText != "" || !strings.Contains(stderrText, "Namespace prod: in sync ("+synced+":1), 1 live-only fields") {
+		t.Errorf("in sync: exit code = %d, stdout = %q, stderr = %s", code, stdoutText, stderrText)
+	}
+
+	stdoutText, stderrText, code = runCommand(t, "", "-input", app, "-cluster-diff", "-kubeconfig", kubeconfig)
+	want := "--- Deployment prod/web (live)\n" +
+		"+++ " + app + ":1 (converted)\n" +
+		"+ .metadata.labels.tier: \"frontend\"\n" +
+		"~ .spec.replicas: 3 -> 5\n" +
+		"= .spec.progressDeadlineSeconds: 600 (live only)\n" +
+		"ConfigMap settings: would create (" + app + ":12)\n"
+	if code != exitStale || stdoutText != want {
+		t.Errorf("drift: exit code = %d, stdout = %q, want %q", code, stdoutText, want)
+	}
+	if !strings.Contains(stderrText, "Cluster diff: 0 in sync, 1 drifted, 1 would create, 0 rejected") {
+		t.Errorf("drift: stderr = %s", stderrText)
+	}
+	if got := requests(); len(got) != 0 {
+		t.Errorf("-cluster-diff sent requests that could change the cluster: %q", got)
+	}
+
+	if _, _, code := runCommand(t, "", "-input", app, "-cluster-diff", "-cluster-validate"); code != exitUsage {
+		t.Errorf("-cluster-diff with -cluster-validate: exit code = %d, want %d", code, exitUsage)
+	}
+}
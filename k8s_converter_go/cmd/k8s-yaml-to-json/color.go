@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Values of -color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// ANSI escape sequences of the colors of JSON tokens, the same as jq's.
+const (
+	colorKey    = "\x1b[34;1m"
+	colorString = "\x1b[32m"
+	colorNumber = "\x1b[36m"
+	colorBool   = "\x1b[33m"
+	colorNull   = "\x1b[90m"
+	colorReset  = "\x1b[0m"
+)
+
+// parseColor reports whether JSON printed to stdout is colorized for a
+// -color value: always, never, or auto to colorize when stdout is a terminal
+// and the NO_COLOR environment variable is not set.
+func parseColor(value string) (bool, error) {
+	switch value {
+	case colorAlways:
+		return true, nil
+	case colorNever:
+		return false, nil
+	case colorAuto:
+		return os.Getenv("NO_COLOR") == "" && isTerminal(stdout), nil
+	}
+	return false, fmt.Errorf("invalid -color value '%s': must be auto, always or never", value)
+}
+
+// colorStdout colorizes the JSON printed to stdout until the returned
+// function is called.
+func colorStdout() (restore func()) {
+	saved := stdout
+	writer := &colorWriter{w: saved}
+	stdout = writer
+	return func() {
+		writer.finish()
+		stdout = saved
+	}
+}
+
+// colorWriter colorizes the JSON written to it token by token: the bytes
+// written through are those written to it, with an escape sequence before
+// and after every key, string, number, boolean and null. It follows the
+// structure of the JSON rather than matching patterns, so a string holding
+// braces, colons or quotes is colored as a single string, and a token split
+// across writes is colored as one. Any number of JSON values may follow
+// each other, as in NDJSON output.
+type colorWriter struct {
+	w io.Writer
+	// containers holds the '{' and '[' of the objects and arrays open, and
+	// key is set where the next string of an object is a key rather than a
+	// value.
+	containers []byte
+	key        bool
+	// str is set inside a string, escaped after its backslashes, and
+	// literal inside a number, true, false or null.
+	str     bool
+	escaped bool
+	literal bool
+	buf     bytes.Buffer
+}
+
+func (c *colorWriter) Write(p []byte) (int, error) {
+	c.buf.Reset()
+	for _, b := range p {
+		if c.str {
+			c.buf.WriteByte(b)
+			switch {
+			case c.escaped:
+				c.escaped = false
+			case b == '\\':
+				c.escaped = true
+			case b == '"':
+				c.str = false
+				c.buf.WriteString(colorReset)
+			}
+			continue
+		}
+		if c.literal {
+			if isLiteralByte(b) {
+				c.buf.WriteByte(b)
+				continue
+			}
+			c.literal = false
+			c.buf.WriteString(colorReset)
+		}
+		switch {
+		case b == '"':
+			if c.key {
+				c.buf.WriteString(colorKey)
+			} else {
+				c.buf.WriteString(colorString)
+			}
+			c.str = true
+		case b == 't' || b == 'f':
+			c.buf.WriteString(colorBool)
+			c.literal = true
+		case b == 'n':
+			c.buf.WriteString(colorNull)
+			c.literal = true
+		case b == '-' || (b >= '0' && b <= '9'):
+			c.buf.WriteString(colorNumber)
+			c.literal = true
+		case b == '{' || b == '[':
+			c.containers = append(c.containers, b)
+			c.key = b == '{'
+		case b == '}' || b == ']':
+			if len(c.containers) > 0 {
+				c.containers = c.containers[:len(c.containers)-1]
+			}
+			c.key = false
+		case b == ':':
+			c.key = false
+		case b == ',':
+			c.key = len(c.containers) > 0 && c.containers[len(c.containers)-1] == '{'
+		}
+		c.buf.WriteByte(b)
+	}
+	if _, err := c.w.Write(c.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// finish ends the color of a token left open by the last write.
+func (c *colorWriter) finish() {
+	if c.str || c.literal {
+		io.WriteString(c.w, colorReset)
+		c.str, c.literal = false, false
+	}
+}
+
+// isLiteralByte reports whether b may be part of a JSON number, true, false
+// or null.
+func isLiteralByte(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '.' || b == '+' || b == '-'
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestColorWriter(t *testing.T) {
+	const (
+		k = colorKey
+		s = colorString
+		n = colorNumber
+		b = colorBool
+		z = colorNull
+		r = colorReset
+	)
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Object",
+			input: `{"replicas": 3, "paused": false, "image": "nginx", "owner": null}`,
+			want:  `{` + k + `"replicas"` + r + `: ` + n + `3` + r + `, ` + k + `"paused"` + r + `: ` + b + `false` + r + `, ` + k + `"image"` + r + `: ` + s + `"nginx"` + r + `, ` + k + `"owner"` + r + `: ` + z + `null` + r + `}`,
+		},
+		{
+			name:  "Strings holding JSON syntax",
+			input: `{"a:{": "b\", \"c\": [1,"}`,
+			want:  `{` + k + `"a:{"` + r + `: ` + s + `"b\", \"c\": [1,"` + r + `}`,
+		},
+		{
+			name:  "Nested containers",
+			input: `{"a": [{"b": -1.5e3}, "c"], "d": {}}`,
+			want:  `{` + k + `"a"` + r + `: [{` + k + `"b"` + r + `: ` + n + `-1.5e3` + r + `}, ` + s + `"c"` + r + `], ` + k + `"d"` + r + `: {}}`,
+		},
+		{
+			name:  "Values one after the other",
+			input: "{\"a\":true}\n[\"b\"]\n",
+			want:  "{" + k + `"a"` + r + ":" + b + "true" + r + "}\n[" + s + `"b"` + r + "]\n",
+		},
+		{
+			name:  "Literal at the end of the output",
+			input: `42`,
+			want:  n + `42` + r,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var whole, split bytes.Buffer
+			w := &colorWriter{w: &whole}
+			w.Write([]byte(tt.input))
+			w.finish()
+			if whole.String() != tt.want {
+				t.Errorf("colorized = %q, want %q", whole.String(), tt.want)
+			}
+
+			// Tokens split across writes are colored the same
+			w = &colorWriter{w: &split}
+			for i := 0; i < len(tt.input); i++ {
+				w.Write([]byte{tt.input[i]})
+			}
+			w.finish()
+			if split.String() != tt.want {
+				t.Errorf("colorized byte by byte = %q, want %q", split.String(), tt.want)
+			}
+
+			if plain := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(whole.String(), ""); plain != tt.input {
+				t.Errorf("colorized output without colors = %q, want the input %q", plain, tt.input)
+			}
+		})
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	// stdout is not a terminal in tests
+	tests := []struct {
+		value   string
+		noColor string
+		want    bool
+	}{
+		{value: colorAuto, want: false},
+		{value: colorAlways, want: true},
+		{value: colorAlways, noColor: "1", want: true},
+		{value: colorNever, want: false},
+	}
+	for _, tt := range tests {
+		t.Setenv("NO_COLOR", tt.noColor)
+		if got, err := parseColor(tt.value); err != nil || got != tt.want {
+			t.Errorf("parseColor(%q) with NO_COLOR=%q = %v, %v, want %v", tt.value, tt.noColor, got, err, tt.want)
+		}
+	}
+	if _, err := parseColor("yes"); err == nil || !strings.Contains(err.Error(), "invalid -color value") {
+		t.Errorf("parseColor(yes) error = %v, want an invalid value error", err)
+	}
+}
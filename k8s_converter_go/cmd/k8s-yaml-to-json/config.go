@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is the config file read from the current directory when
+// -config is not given.
+const defaultConfigFile = ".k8s-yaml-to-json.yaml"
+
+// applyConfig sets the flags named by the keys of the config file at path to
+// its values, except for the flags already set on the command line or by
+// environment variables, which take precedence. Without an explicit path, defaultConfigFile is read if it
+// exists. Keys mirror the flag names without the leading dash, and repeatable
+// flags such as -exclude take a list:
+//
+//	clean: true
+//	indent: 4
+//	exclude: [vendor/, "*-test.yaml"]
+//
+// An unknown key, or a value a flag does not accept, is a *usageError with
+// the errorConfig code. The flags set are recorded in sources, if not nil, as
+// for applyDefaults.
+func applyConfig(flags *flag.FlagSet, path string, sources map[string]string) error {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return &usageError{code: errorConfig, message: fmt.Sprintf("reading config file: %v", err)}
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return configErrorf(path, 0, "%v", err)
+	}
+	if len(root.Content) == 0 {
+		return nil
+	}
+	mapping := root.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return configErrorf(path, mapping.Line, "must be a mapping of flag names to values")
+	}
+
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		f := flags.Lookup(key.Value)
+		if f == nil || f.Name == "config" || commandLineOnly[f.Name] {
+			return configErrorf(path, key.Line, "unknown key '%s'", key.Value)
+		}
+		if set[f.Name] {
+			continue
+		}
+		values, err := configValues(f, value)
+		if err != nil {
+			return configErrorf(path, value.Line, "%s: %v", key.Value, err)
+		}
+		for _, v := range values {
+			if err := flags.Set(f.Name, v); err != nil {
+				return configErrorf(path, value.Line, "invalid value %q for %s: %v", v, key.Value, err)
+			}
+		}
+		if sources != nil {
+			sources[f.Name] = "config file " + path
+		}
+	}
+	return nil
+}
+
+// configValues returns the values to set a flag to for its config value: a
+// scalar, or a list of scalars for a repeatable flag.
+func configValues(f *flag.Flag, node *yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return nil, errors.New("a value is required")
+		}
+		return []string{node.Value}, nil
+	case yaml.SequenceNode:
+		if !isRepeatable(f) {
+			return nil, errors.New("takes a single value, not a list")
+		}
+		values := make([]string, len(node.Content))
+		for i, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, errors.New("list items must be scalars")
+			}
+			values[i] = item.Value
+		}
+		return values, nil
+	default:
+		return nil, errors.New("must be a scalar or a list")
+	}
+}
+
+// isRepeatable reports whether f may be given several times, adding a value
+// each time.
+func isRepeatable(f *flag.Flag) bool {
+	switch f.Value.(type) {
+	case *repeatedFlag, *listFlag:
+		return true
+	}
+	return false
+}
+
+// configErrorf returns the *usageError for a mistake at line of the config
+// file at path, or in the whole file when line is 0.
+func configErrorf(path string, line int, format string, args ...interface{}) error {
+	location := path
+	if line > 0 {
+		location = fmt.Sprintf("%s:%d", path, line)
+	}
+	return &usageError{code: errorConfig, message: fmt.Sprintf("config file %s: %s", location, fmt.Sprintf(format, args...))}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// configFlags is a small flag set with a flag of every kind the config file
+// can set.
+type configFlags struct {
+	set      *flag.FlagSet
+	clean    *bool
+	indent   *string
+	maxDepth *int
+	includes repeatedFlag
+	kinds    listFlag
+}
+
+func newConfigFlags() *configFlags {
+	f := &configFlags{set: flag.NewFlagSet("test", flag.ContinueOnError)}
+	f.clean = f.set.Bool("clean", false, "")
+	f.indent = f.set.String("indent", "2", "")
+	f.maxDepth = f.set.Int("max-depth", 10, "")
+	f.set.Var(&f.includes, "include", "")
+	f.set.Var(&f.kinds, "kind", "")
+	f.set.String("config", "", "")
+	f.set.Bool("no-env", false, "")
+	return f
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	const config = "clean: true\nindent: 4\nmax-depth: 3\ninclude: [a.yaml, b.yaml]\nkind: Service,Secret\n"
+	tests := []struct {
+		name         string
+		config       string
+		args         []string
+		wantClean    bool
+		wantIndent   string
+		wantDepth    int
+		wantIncludes []string
+		wantKinds    []string
+	}{
+		{
+			name:       "Defaults without a config file",
+			wantIndent: "2",
+			wantDepth:  10,
+		},
+		{
+			name:         "Config file over defaults",
+			config:       config,
+			wantClean:    true,
+			wantIndent:   "4",
+			wantDepth:    3,
+			wantIncludes: []string{"a.yaml", "b.yaml"},
+			wantKinds:    []string{"Service", "Secret"},
+		},
+		{
+			name:         "Flags over the config file",
+			config:       config,
+			args:         []string{"-indent", "tab", "-include", "c.yaml", "-kind", "Pod"},
+			wantClean:    true,
+			wantIndent:   "tab",
+			wantDepth:    3,
+			wantIncludes: []string{"c.yaml"},
+			wantKinds:    []string{"Pod"},
+		},
+		{
+			name:         "Boolean flag set to false over true in the config file",
+			config:       config,
+			args:         []string{"-clean=false"},
+			wantIndent:   "4",
+			wantDepth:    3,
+			wantIncludes: []string{"a.yaml", "b.yaml"},
+			wantKinds:    []string{"Service", "Secret"},
+		},
+		{
+			name:       "Flag set to its default over the config file",
+			config:     config,
+			args:       []string{"-max-depth", "10", "-indent", "2", "-clean=false", "-include=", "-kind="},
+			wantIndent: "2",
+			wantDepth:  10,
+			// An empty value is still a value
+			wantIncludes: []string{""},
+		},
+		{
+			name:       "Empty config file",
+			config:     "# nothing yet\n",
+			wantIndent: "2",
+			wantDepth:  10,
+		},
+		{
+			name:       "Boolean false in the config file",
+			config:     "clean: false\n",
+			wantIndent: "2",
+			wantDepth:  10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newConfigFlags()
+			if err := f.set.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if tt.config != "" {
+				if err := applyConfig(f.set, writeConfig(t, tt.config), nil); err != nil {
+					t.Fatalf("applyConfig() error = %v", err)
+				}
+			}
+			if *f.clean != tt.wantClean || *f.indent != tt.wantIndent || *f.maxDepth != tt.wantDepth {
+				t.Errorf("clean, indent, max-depth = %v, %q, %d, want %v, %q, %d", *f.clean, *f.indent, *f.maxDepth, tt.wantClean, tt.wantIndent, tt.wantDepth)
+			}
+			if !reflect.DeepEqual([]string(f.includes), tt.wantIncludes) || !reflect.DeepEqual([]string(f.kinds), tt.wantKinds) {
+				t.Errorf("include, kind = %q, %q, want %q, %q", f.includes, f.kinds, tt.wantIncludes, tt.wantKinds)
+			}
+		})
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "Unknown key", config: "clean: true\ncleen: true\n", wantErr: "config.yaml:2: unknown key 'cleen'"},
+		{name: "Config key", config: "config: other.yaml\n", wantErr: "unknown key 'config'"},
+		{name: "Command-line only key", config: "no-env: true\n", wantErr: "unknown key 'no-env'"},
+		{name: "Invalid boolean", config: "clean: maybe\n", wantErr: `config.yaml:1: invalid value "maybe" for clean`},
+		{name: "Invalid number", config: "max-depth: deep\n", wantErr: `invalid value "deep" for max-depth`},
+		{name: "List for a single value", config: "indent: [2, 4]\n", wantErr: "indent: takes a single value, not a list"},
+		{name: "Mapping value", config: "include:\n  a: b\n", wantErr: "config.yaml:2: include: must be a scalar or a list"},
+		{name: "Missing value", config: "indent:\n", wantErr: "indent: a value is required"},
+		{name: "Not a mapping", config: "- clean\n", wantErr: "must be a mapping of flag names to values"},
+		{name: "Invalid YAML", config: "clean: [true\n", wantErr: "config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyConfig(newConfigFlags().set, writeConfig(t, tt.config), nil)
+			var usageErr *usageError
+			if !errors.As(err, &usageErr) || usageErr.code != errorConfig || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("applyConfig() error = %v, want a %s error containing %q", err, errorConfig, tt.wantErr)
+			}
+		})
+	}
+
+	// An explicit path must exist
+	if err := applyConfig(newConfigFlags().set, filepath.Join(t.TempDir(), "missing.yaml"), nil); err == nil || errorCode(err) != errorConfig {
+		t.Errorf("applyConfig(missing) error = %v, want a %s error", err, errorConfig)
+	}
+}
+
+func TestApplyConfigDefaultFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// Without the default file, nothing changes
+	f := newConfigFlags()
+	if err := applyConfig(f.set, "", nil); err != nil || *f.clean {
+		t.Fatalf("applyConfig() without %s: clean = %v, error = %v", defaultConfigFile, *f.clean, err)
+	}
+
+	if err := os.WriteFile(defaultConfigFile, []byte("clean: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f = newConfigFlags()
+	if err := applyConfig(f.set, "", nil); err != nil || !*f.clean {
+		t.Errorf("applyConfig() with %s: clean = %v, error = %v", defaultConfigFile, *f.clean, err)
+	}
+}
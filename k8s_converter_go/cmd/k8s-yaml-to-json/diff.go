@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// diffFile converts inputFile and compares the result with the current
+// content of outputFile, printing the differences to stdout. It reports
+// whether outputFile is stale.
+func diffFile(inputFile, outputFile string, exact bool, opts converter.Options) (bool, error) {
+	data, err := readInputFile(inputFile)
+	if err != nil {
+		return false, err
+	}
+	output, err := converter.Convert(data, opts)
+	if err != nil {
+		return false, err
+	}
+	diff, err := compareOutput(inputFile, outputFile, output, exact)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprint(stdout, diff)
+	return diff != "", nil
+}
+
+// compareOutput compares output, converted from inputFile, with the current
+// content of outputFile and returns the differences, or "" when there are
+// none. The comparison is structural, ignoring whitespace, key order and
+// the digits of equal numbers, and the differences are listed by path.
+// With exact set, or when output is not JSON, the bytes are compared
+// instead and the differences are a unified diff.
+func compareOutput(inputFile, outputFile string, output []byte, exact bool) (string, error) {
+	existing, err := os.ReadFile(outputFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Sprintf("%s does not exist\n", outputFile), nil
+	}
+	if err != nil {
+		return "", &inputError{err: fmt.Errorf("reading output file: %w", err)}
+	}
+	header := fmt.Sprintf("--- %s\n+++ %s (converted)\n", outputFile, displayName(inputFile))
+
+	if !exact {
+		if values, err := decodeJSONValues(output); err == nil {
+			existingValues, err := decodeJSONValues(existing)
+			if err != nil {
+				return header + fmt.Sprintf("%s is not valid JSON: %v\n", outputFile, err), nil
+			}
+			differences := converter.Diff(jsonStream(existingValues), jsonStream(values))
+			if len(differences) == 0 {
+				return "", nil
+			}
+			var diff strings.Builder
+			diff.WriteString(header)
+			for _, difference := range differences {
+				diff.WriteString(difference.String() + "\n")
+			}
+			return diff.String(), nil
+		}
+	}
+
+	if bytes.Equal(existing, output) {
+		return "", nil
+	}
+	return header + unifiedDiff(existing, output), nil
+}
+
+// decodeJSONValues decodes every JSON value in data, such as the documents of
+// -separate or NDJSON output, keeping the exact digits of numbers.
+func decodeJSONValues(data []byte) ([]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values []interface{}
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if values == nil {
+		return nil, errors.New("no JSON value")
+	}
+	return values, nil
+}
+
+// jsonStream returns the single value of a stream of JSON values, or the
+// values as an array when there are several.
+func jsonStream(values []interface{}) interface{} {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
+
+// diffContext is the number of unchanged lines shown around each change of
+// a unified diff.
+const diffContext = 3
+
+// maxDiffCells bounds the number of line pairs compared to find the shortest
+// diff. Larger changes are shown as the removal of every old line followed by
+// the addition of every new one.
+const maxDiffCells = 1 << 22
+
+// diffLine is a line of a diff: op is ' ' for an unchanged line, '-' for a
+// removed line and '+' for an added line.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the hunks of a unified diff from old to new, without
+// the file header.
+func unifiedDiff(old, new []byte) string {
+	edits := lineEdits(splitLines(old), splitLines(new))
+	var diff strings.Builder
+	for start := 0; start < len(edits); {
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		// Join changes separated by few enough unchanged lines into one hunk
+		end := start
+		for {
+			for end < len(edits) && edits[end].op != ' ' {
+				end++
+			}
+			next := end
+			for next < len(edits) && edits[next].op == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		first := max(start-diffContext, 0)
+		last := min(end+diffContext, len(edits))
+		writeHunk(&diff, edits, first, last)
+		start = last
+	}
+	return diff.String()
+}
+
+// writeHunk writes edits[first:last] as a hunk of a unified diff.
+func writeHunk(diff *strings.Builder, edits []diffLine, first, last int) {
+	oldLine, newLine := 0, 0
+	for _, edit := range edits[:first] {
+		if edit.op != '+' {
+			oldLine++
+		}
+		if edit.op != '-' {
+			newLine++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, edit := range edits[first:last] {
+		if edit.op != '+' {
+			oldCount++
+		}
+		if edit.op != '-' {
+			newCount++
+		}
+	}
+	// An empty range starts at the line before it
+	if oldCount > 0 {
+		oldLine++
+	}
+	if newCount > 0 {
+		newLine++
+	}
+	fmt.Fprintf(diff, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+	for _, edit := range edits[first:last] {
+		diff.WriteByte(edit.op)
+		diff.WriteString(edit.text)
+		if !strings.HasSuffix(edit.text, "\n") {
+			diff.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits data into lines, each keeping its newline.
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineEdits returns the shortest sequence of unchanged, removed and added
+// lines that turns old into new, with removals before additions.
+func lineEdits(old, new []string) []diffLine {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	var edits []diffLine
+	for _, line := range old[:prefix] {
+		edits = append(edits, diffLine{' ', line})
+	}
+	a, b := old[prefix:len(old)-suffix], new[prefix:len(new)-suffix]
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			edits = append(edits, diffLine{'-', line})
+		}
+		for _, line := range b {
+			edits = append(edits, diffLine{'+', line})
+		}
+	} else {
+		// common[i][j] is the length of the longest common subsequence of
+		// a[i:] and b[j:]
+		common := make([][]int32, len(a)+1)
+		for i := range common {
+			common[i] = make([]int32, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					common[i][j] = common[i+1][j+1] + 1
+				} else {
+					common[i][j] = max(common[i+1][j], common[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			switch {
+			case i < len(a) && j < len(b) && a[i] == b[j]:
+				edits = append(edits, diffLine{' ', a[i]})
+				i++
+				j++
+			case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+				edits = append(edits, diffLine{'-', a[i]})
+				i++
+			default:
+				edits = append(edits, diffLine{'+', b[j]})
+				j++
+			}
+		}
+	}
+	for _, line := range old[len(old)-suffix:] {
+		edits = append(edits, diffLine{' ', line})
+	}
+	return edits
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestCompareOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"same.json":     "{\"spec\": {\"replicas\": 3.0}, \"kind\": \"Deployment\"}\n",
+		"changed.json":  `{"kind":"Deployment","spec":{"replicas":5,"paused":true}}`,
+		"invalid.json":  "not json\n",
+		"separate.json": "{\"kind\":\"A\"}\n{\"kind\":\"C\"}\n",
+	})
+	output := []byte("{\n  \"kind\": \"Deployment\",\n  \"spec\": {\n    \"replicas\": 3\n  }\n}")
+	header := func(name string) string {
+		return "--- " + filepath.Join(dir, name) + "\n+++ a.yaml (converted)\n"
+	}
+
+	tests := []struct {
+		name   string
+		file   string
+		output []byte
+		exact  bool
+		want   string
+	}{
+		{name: "Whitespace, key order and number digits", file: "same.json", output: output},
+		{
+			name:   "Changed paths",
+			file:   "changed.json",
+			output: output,
+			want:   header("changed.json") + "- .spec.paused: true\n~ .spec.replicas: 5 -> 3\n",
+		},
+		{
+			name:   "Several documents",
+			file:   "separate.json",
+			output: []byte("{\"kind\":\"A\"}\n{\"kind\":\"B\"}\n"),
+			want:   header("separate.json") + "~ [1].kind: \"C\" -> \"B\"\n",
+		},
+		{
+			name:   "Missing output",
+			file:   "missing.json",
+			output: output,
+			want:   filepath.Join(dir, "missing.json") + " does not exist\n",
+		},
+		{
+			name:   "Invalid existing output",
+			file:   "invalid.json",
+			output: output,
+			want:   header("invalid.json") + filepath.Join(dir, "invalid.json") + " is not valid JSON: invalid character 'o' in literal null (expecting 'u')\n",
+		},
+		{
+			name:   "Exact",
+			file:   "same.json",
+			output: []byte("{\"spec\": {\"replicas\": 3.0}, \"kind\": \"Deployment\"}\n"),
+			exact:  true,
+		},
+		{
+			name:   "Exact with differences",
+			file:   "same.json",
+			output: []byte("{\"spec\": {\"replicas\": 3}, \"kind\": \"Deployment\"}\n"),
+			exact:  true,
+			want: header("same.json") + "@@ -1,1 +1,1 @@\n" +
+				"-{\"spec\": {\"replicas\": 3.0}, \"kind\": \"Deployment\"}\n" +
+				"+{\"spec\": {\"replicas\": 3}, \"kind\": \"Deployment\"}\n",
+		},
+		{
+			name:   "Output that is not JSON is compared exactly",
+			file:   "invalid.json",
+			output: []byte("not json\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compareOutput("a.yaml", filepath.Join(dir, tt.file), tt.output, tt.exact)
+			if err != nil {
+				t.Fatalf("compareOutput() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("compareOutput() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	numbered := func(from, to int, replace map[int]string) string {
+		var lines []string
+		for i := from; i <= to; i++ {
+			line := "line " + strings.Repeat("x", i%3) + string(rune('a'+i%26))
+			if text, ok := replace[i]; ok {
+				line = text
+			}
+			lines = append(lines, line)
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{name: "Equal", old: "a\nb\n", new: "a\nb\n"},
+		{
+			name: "Added and removed lines",
+			old:  "a\nb\nc\nd\n",
+			new:  "a\nc\nd\ne\n",
+			want: "@@ -1,4 +1,4 @@\n a\n-b\n c\n d\n+e\n",
+		},
+		{
+			name: "Separate hunks",
+			old:  numbered(0, 19, nil),
+			new:  numbered(0, 19, map[int]string{1: "first", 17: "second"}),
+			want: "@@ -1,5 +1,5 @@\n line a\n-line xb\n+first\n line xxc\n line d\n line xe\n" +
+				"@@ -15,6 +15,6 @@\n line xxo\n line p\n line xq\n-line xxr\n+second\n line s\n line xt\n",
+		},
+		{
+			name: "Missing final newline",
+			old:  "a\nb\n",
+			new:  "a\nb",
+			want: "@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			name: "Empty old file",
+			old:  "",
+			new:  "a\n",
+			want: "@@ -0,0 +1,1 @@\n+a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff([]byte(tt.old), []byte(tt.new)); got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffBatch(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"web.yaml":    "kind: Service\nmetadata:\n  name: web\n",
+		"api.yaml":    "kind: Service\nmetadata:\n  name: api\n",
+		"broken.yaml": "This is not valid: YAML: content\n",
+		"new.yaml":    "kind: Secret\n",
+	})
+	outputDir := t.TempDir()
+	writeTree(t, outputDir, map[string]string{
+		"web.json": `{"metadata":{"name":"web"},"kind":"Service"}`,
+		"api.json": `{"kind":"Service","metadata":{"name":"old-api"}}`,
+	})
+	files, _, err := findYAMLFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureStdout(t)
+	result := convertFiles(root, files, batchOptions{outputDir: outputDir, workers: 2, diff: true}, converter.Options{})
+	if result.converted != 1 || result.stale != 2 || result.failed != 1 {
+		t.Errorf("convertFiles() = %d up to date, %d stale, %d failed, want 1, 2, 1", result.converted, result.stale, result.failed)
+	}
+	want := "--- " + filepath.Join(outputDir, "api.json") + "\n+++ " + filepath.Join(root, "api.yaml") + " (converted)\n" +
+		"~ .metadata.name: \"old-api\" -> \"api\"\n" +
+		filepath.Join(outputDir, "new.json") + " does not exist\n"
+	if output.String() != want {
+		t.Errorf("diff:\n%s\nwant:\n%s", output.String(), want)
+	}
+	statuses := make(map[string]string)
+	for _, file := range result.files {
+		statuses[filepath.Base(file.File)] = file.Status
+	}
+	if statuses["web.yaml"] != statusUpToDate || statuses["api.yaml"] != statusStale || statuses["new.yaml"] != statusStale {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 2 {
+		t.Errorf("diff wrote files: %v", entries)
+	}
+}
+
+func TestDiffFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"config.yaml": "kind: ConfigMap\nmetadata:\n  name: config\n",
+		"config.json": "{\"kind\": \"ConfigMap\", \"metadata\": {\"name\": \"config\"}}\n",
+		"stale.json":  "{\"kind\": \"ConfigMap\"}\n",
+	})
+	input := filepath.Join(dir, "config.yaml")
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{name: "Up to date", args: []string{"-diff", "-output", filepath.Join(dir, "config.json")}, wantCode: exitOK},
+		{name: "Stale", args: []string{"-diff", "-output", filepath.Join(dir, "stale.json")}, wantCode: exitStale},
+		{name: "Exact", args: []string{"-diff-exact", "-output", filepath.Join(dir, "config.json")}, wantCode: exitStale},
+		{name: "Without output", args: []string{"-diff"}, wantCode: exitUsage},
+		{name: "With dry run", args: []string{"-diff", "-dry-run", "-output", filepath.Join(dir, "config.json")}, wantCode: exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errOutput, code := runCommand(t, "", append([]string{"-input", input}, tt.args...)...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d; stderr = %q", code, tt.wantCode, errOutput)
+			}
+		})
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "stale.json")); string(data) != "{\"kind\": \"ConfigMap\"}\n" {
+		t.Errorf("-diff overwrote the output: %q", data)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// stdoutDestination is the destination printed by a dry run when the output
+// would be printed to stdout.
+const stdoutDestination = "<stdout>"
+
+// printPlan prints that source would be written to destination in a dry run,
+// flagging destinations that already exist.
+func printPlan(source, destination string) {
+	line := fmt.Sprintf("%s -> %s", displayName(source), destination)
+	if destination != stdoutDestination {
+		if _, err := os.Stat(destination); err == nil {
+			line += " (exists)"
+		}
+	}
+	fmt.Fprintln(stdout, line)
+}
+
+// printFilePlan prints the outcome of a file of a batch dry run: a line for
+// every output it would write, or why it would write none.
+func printFilePlan(file fileReport) {
+	switch file.Status {
+	case statusConverted:
+		for _, output := range file.Outputs {
+			printPlan(file.File, output)
+		}
+	case statusSkipped:
+		fmt.Fprintf(stdout, "%s (skipped: no documents match the filters)\n", file.File)
+	case statusFailed:
+		fmt.Fprintf(stdout, "%s (fails to convert)\n", file.File)
+	case statusNotAttempted:
+		fmt.Fprintf(stdout, "%s (not attempted)\n", file.File)
+	}
+}
+
+// dryRunFile converts inputFile without writing anything and prints where the
+// output would have been written: outputFile, stdout, or in split mode the
+// file of every document in the outputFile directory.
+func dryRunFile(inputFile, outputFile string, split bool, opts converter.Options) error {
+	paths, err := planFile(inputFile, outputFile, split, opts)
+	if err != nil {
+		fmt.Fprintf(stdout, "%s (fails to convert)\n", displayName(inputFile))
+		return err
+	}
+	for _, path := range paths {
+		printPlan(inputFile, path)
+	}
+	return nil
+}
+
+// planFile converts inputFile without writing anything and returns the paths
+// the output would have been written to.
+func planFile(inputFile, outputFile string, split bool, opts converter.Options) ([]string, error) {
+	data, err := readInputFile(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	if split {
+		documents, err := converter.Decode(data, opts)
+		if err != nil {
+			return nil, err
+		}
+		return writeSplit(inputFile, documents, outputFile, opts, make(map[string]string), true)
+	}
+	if _, err := converter.Convert(data, opts); err != nil {
+		return nil, err
+	}
+	if outputFile == "" {
+		return []string{stdoutDestination}, nil
+	}
+	return []string{outputFile}, nil
+}
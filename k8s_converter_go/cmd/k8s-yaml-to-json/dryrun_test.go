@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// captureStdout redirects stdout to the returned buffer until the test ends.
+func captureStdout(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buffer bytes.Buffer
+	previous := stdout
+	stdout = &buffer
+	t.Cleanup(func() { stdout = previous })
+	return &buffer
+}
+
+func TestDryRunBatch(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"a.yaml":        "kind: Service\nmetadata:\n  name: web\n",
+		"b/broken.yaml": "This is not valid: YAML: content\n",
+		"c.yaml":        "kind: Secret\n",
+		"d.yaml":        "kind: Deployment\nmetadata:\n  name: web\n---\nkind: Service\nmetadata:\n  name: api\n",
+	})
+	files, _, err := findYAMLFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputDir := t.TempDir()
+	writeTree(t, outputDir, map[string]string{"a.json": "{}\n", "service-api.json": "{}\n"})
+	opts := converter.Options{Kinds: []string{"Deployment", "Service"}}
+
+	tests := []struct {
+		name  string
+		split bool
+		want  []string
+	}{
+		{
+			name: "Files",
+			want: []string{
+				filepath.Join(root, "a.yaml") + " -> " + filepath.Join(outputDir, "a.json") + " (exists)",
+				filepath.Join(root, "b/broken.yaml") + " (fails to convert)",
+				filepath.Join(root, "c.yaml") + " (skipped: no documents match the filters)",
+				filepath.Join(root, "d.yaml") + " -> " + filepath.Join(outputDir, "d.json"),
+			},
+		},
+		{
+			name:  "Split",
+			split: true,
+			want: []string{
+				filepath.Join(root, "a.yaml") + " -> " + filepath.Join(outputDir, "service-web.json"),
+				filepath.Join(root, "b/broken.yaml") + " (fails to convert)",
+				filepath.Join(root, "c.yaml") + " (skipped: no documents match the filters)",
+				filepath.Join(root, "d.yaml") + " -> " + filepath.Join(outputDir, "deployment-web.json"),
+				filepath.Join(root, "d.yaml") + " -> " + filepath.Join(outputDir, "service-api.json") + " (exists)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := captureStdout(t)
+			result := convertFiles(root, files, batchOptions{outputDir: outputDir, split: tt.split, workers: 2, dryRun: true}, opts)
+			if result.converted != 2 || result.skipped != 1 || result.failed != 1 {
+				t.Errorf("convertFiles() = %d, %d, %d, want 2, 1, 1", result.converted, result.skipped, result.failed)
+			}
+			if want := strings.Join(tt.want, "\n") + "\n"; output.String() != want {
+				t.Errorf("plan:\n%s\nwant:\n%s", output.String(), want)
+			}
+		})
+	}
+
+	// Nothing but the existing files is in the output directory
+	var written []string
+	filepath.WalkDir(outputDir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			written = append(written, path)
+		}
+		return err
+	})
+	if len(written) != 2 {
+		t.Errorf("dry run wrote files: %v", written)
+	}
+}
+
+func TestDryRunFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"all.yaml":    "kind: Service\nmetadata:\n  name: web\n---\nkind: Service\nmetadata:\n  name: web\n",
+		"config.yaml": "kind: ConfigMap\nmetadata:\n  name: config\n",
+		"config.json": "{}\n",
+	})
+
+	tests := []struct {
+		name    string
+		input   string
+		output  string
+		split   bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Stdout",
+			input: "config.yaml",
+			want:  filepath.Join(dir, "config.yaml") + " -> <stdout>\n",
+		},
+		{
+			name:   "Output file",
+			input:  "config.yaml",
+			output: filepath.Join(dir, "config.json"),
+			want:   filepath.Join(dir, "config.yaml") + " -> " + filepath.Join(dir, "config.json") + " (exists)\n",
+		},
+		{
+			name:    "Split collision",
+			input:   "all.yaml",
+			output:  filepath.Join(dir, "out"),
+			split:   true,
+			want:    filepath.Join(dir, "all.yaml") + " (fails to convert)\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := captureStdout(t)
+			err := dryRunFile(filepath.Join(dir, tt.input), tt.output, tt.split, converter.Options{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("dryRunFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if output.String() != tt.want {
+				t.Errorf("dryRunFile() printed %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); err == nil {
+		t.Errorf("dry run created the output directory")
+	}
+}
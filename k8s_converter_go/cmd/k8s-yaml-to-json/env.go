@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// envPrefix starts the names of the environment variables that set flags:
+// K8SY2J_OUTPUT sets -output and K8SY2J_MAX_ALIAS_NODES sets -max-alias-nodes.
+const envPrefix = "K8SY2J_"
+
+// commandLineOnly are the flags that cannot be set by environment variables
+// or the config file, since -no-env decides whether the environment is read
+// at all.
+var commandLineOnly = map[string]bool{"no-env": true}
+
+// envName returns the name of the environment variable setting a flag.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets the flags not given on the command line to the values of
+// their environment variables in environ, a list of KEY=value strings as
+// returned by os.Environ. A variable with the prefix that names no flag is
+// reported as a warning, and repeatable flags take a single value there. A
+// value a flag does not accept is a *usageError with the errorEnv code. The
+// flags set are recorded in sources, if not nil, as for applyDefaults.
+func applyEnv(flags *flag.FlagSet, environ []string, sources map[string]string) error {
+	names := make(map[string]string)
+	flags.VisitAll(func(f *flag.Flag) {
+		if !commandLineOnly[f.Name] {
+			names[envName(f.Name)] = f.Name
+		}
+	})
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var unknown []string
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		name, ok := names[key]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		if set[name] {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return &usageError{code: errorEnv, message: fmt.Sprintf("invalid value %q for environment variable %s: %v", value, key, err)}
+		}
+		if sources != nil {
+			sources[name] = "environment variable " + key
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		warnf("", "ignoring environment variable %s, which does not name a flag", key)
+	}
+	return nil
+}
+
+// logSources logs, with -vv, where each flag not given on the command line
+// was set from, in flag name order.
+func logSources(sources map[string]string) {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		verbosef(2, []logAttr{{"flag", name}, {"source", sources[name]}}, "Using -%s from %s", name, sources[name])
+	}
+}
+
+// applyDefaults sets the flags not given on the command line from the
+// environment, unless noEnv is set, and then from the config file, so that
+// flags take precedence over the environment and the environment over the
+// config file. It returns where each flag it set was set from, by name, for
+// logging with -vv.
+func applyDefaults(flags *flag.FlagSet, configFile *string, noEnv bool) (map[string]string, error) {
+	sources := make(map[string]string)
+	if !noEnv {
+		if err := applyEnv(flags, os.Environ(), sources); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyConfig(flags, *configFile, sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"output":          "K8SY2J_OUTPUT",
+		"compact":         "K8SY2J_COMPACT",
+		"max-alias-nodes": "K8SY2J_MAX_ALIAS_NODES",
+	}
+	for flagName, want := range tests {
+		if got := envName(flagName); got != want {
+			t.Errorf("envName(%q) = %q, want %q", flagName, got, want)
+		}
+	}
+}
+
+func TestApplyDefaultsPrecedence(t *testing.T) {
+	const config = "clean: true\nindent: 4\nmax-depth: 3\nkind: Service\n"
+	tests := []struct {
+		name       string
+		env        []string
+		args       []string
+		noEnv      bool
+		wantClean  bool
+		wantIndent string
+		wantDepth  int
+		wantKinds  []string
+	}{
+		{
+			name:       "Config file without environment variables",
+			wantClean:  true,
+			wantIndent: "4",
+			wantDepth:  3,
+			wantKinds:  []string{"Service"},
+		},
+		{
+			name:       "Environment variables over the config file",
+			env:        []string{"K8SY2J_INDENT=tab", "K8SY2J_CLEAN=false", "K8SY2J_KIND=Pod,Secret"},
+			wantIndent: "tab",
+			wantDepth:  3,
+			wantKinds:  []string{"Pod", "Secret"},
+		},
+		{
+			name:       "Flags over environment variables",
+			env:        []string{"K8SY2J_INDENT=tab", "K8SY2J_CLEAN=true", "K8SY2J_MAX_DEPTH=5"},
+			args:       []string{"-indent", "8", "-clean=false"},
+			wantIndent: "8",
+			wantDepth:  5,
+			wantKinds:  []string{"Service"},
+		},
+		{
+			name:       "No environment variables with -no-env",
+			env:        []string{"K8SY2J_INDENT=tab", "K8SY2J_CLEAN=false", "K8SY2J_MAX_DEPTH=bad"},
+			noEnv:      true,
+			wantClean:  true,
+			wantIndent: "4",
+			wantDepth:  3,
+			wantKinds:  []string{"Service"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newConfigFlags()
+			if err := f.set.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range tt.env {
+				key, value, _ := strings.Cut(entry, "=")
+				t.Setenv(key, value)
+			}
+			path := writeConfig(t, config)
+			if _, err := applyDefaults(f.set, &path, tt.noEnv); err != nil {
+				t.Fatalf("applyDefaults() error = %v", err)
+			}
+			if *f.clean != tt.wantClean || *f.indent != tt.wantIndent || *f.maxDepth != tt.wantDepth {
+				t.Errorf("clean, indent, max-depth = %v, %q, %d, want %v, %q, %d", *f.clean, *f.indent, *f.maxDepth, tt.wantClean, tt.wantIndent, tt.wantDepth)
+			}
+			if !reflect.DeepEqual([]string(f.kinds), tt.wantKinds) {
+				t.Errorf("kind = %q, want %q", f.kinds, tt.wantKinds)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          []string
+		wantErr      string
+		wantWarnings []string
+	}{
+		{
+			name:    "Invalid boolean",
+			env:     []string{"K8SY2J_CLEAN=maybe"},
+			wantErr: `invalid value "maybe" for environment variable K8SY2J_CLEAN`,
+		},
+		{
+			name:    "Invalid number",
+			env:     []string{"K8SY2J_MAX_DEPTH=deep"},
+			wantErr: "K8SY2J_MAX_DEPTH",
+		},
+		{
+			name: "Unknown and command-line only variables",
+			env:  []string{"K8SY2J_CLEEN=true", "K8SY2J_NO_ENV=true", "K8SY2J_CLEAN=true", "HOME=/root"},
+			wantWarnings: []string{
+				"Warning: ignoring environment variable K8SY2J_CLEEN, which does not name a flag",
+				"Warning: ignoring environment variable K8SY2J_NO_ENV, which does not name a flag",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errOutput := captureStderr(t)
+			err := applyEnv(newConfigFlags().set, tt.env, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("applyEnv() error = %v", err)
+				}
+			} else {
+				var usageErr *usageError
+				if !errors.As(err, &usageErr) || usageErr.code != errorEnv || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("applyEnv() error = %v, want a %s error containing %q", err, errorEnv, tt.wantErr)
+				}
+			}
+			if got := strings.Split(strings.TrimSpace(errOutput.String()), "\n"); len(tt.wantWarnings) > 0 && !reflect.DeepEqual(got, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", got, tt.wantWarnings)
+			}
+		})
+	}
+}
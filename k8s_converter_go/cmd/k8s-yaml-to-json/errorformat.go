@@ -0,0 +1,441 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// Values of -error-format.
+const (
+	errorFormatText = "text"
+	errorFormatJSON = "json"
+)
+
+// jsonErrors reports errors as JSON objects, set with -error-format json.
+var jsonErrors bool
+
+// Error codes reported by -error-format json. They are stable so that tools
+// can tell failures apart without parsing messages; new failures get new
+// codes.
+const (
+	errorUsage         = "usage"
+	errorConfig        = "invalid_config"
+	errorEnv           = "invalid_env"
+	errorMissingInput  = "missing_input"
+	errorBadExtension  = "bad_extension"
+	errorInputNotFound = "input_not_found"
+	errorRead          = "read_error"
+	errorTooLarge      = "input_too_large"
+	errorDecompress    = "decompress_error"
+	errorNotText       = "not_text"
+	errorYAMLParse     = "yaml_parse"
+	errorJSONParse     = "json_parse"
+	errorHelmTemplate  = "helm_template"
+	errorInvalidYAML   = "invalid_yaml"
+	errorNonStringKey  = "non_string_key"
+	errorDuplicateKey  = "duplicate_key"
+	errorMissingFields = "missing_fields"
+	errorSchema        = "schema_validation"
+	errorName          = "invalid_name"
+	errorLabel         = "invalid_label"
+	errorImage         = "invalid_image"
+	errorQuantity      = "invalid_quantity"
+	errorAlias         = "yaml_alias"
+	errorAliasLimit    = "yaml_alias_limit"
+	errorUndefinedEnv  = "undefined_env"
+	errorValues        = "undefined_values"
+	errorQuery         = "query_path"
+	errorPathNotFound  = "path_not_found"
+	errorSetField      = "set_path"
+	errorEncode        = "encode_error"
+	errorRoundTrip     = "roundtrip_mismatch"
+	errorTyped         = "typed_decode"
+	errorDeprecated    = "deprecated_api"
+	errorTemplate      = "template_error"
+	errorNoMatch       = "no_match"
+	errorWrite         = "write_error"
+	errorOutputExists  = "output_exists"
+	errorKubeconfig    = "invalid_kubeconfig"
+	errorUnreachable   = "cluster_unreachable"
+	errorWebhook       = "cluster_webhook_rejected"
+	errorUnknownField  = "cluster_unknown_field"
+	errorClusterSchema = "cluster_schema_error"
+	errorUnknownKind   = "cluster_unknown_kind"
+	errorRejected      = "cluster_rejected"
+	errorUnclassified  = "error"
+)
+
+// jsonError is the object written to stderr for an error with -error-format
+// json. Fields that are not known are omitted.
+type jsonError struct {
+	Error    string `json:"error"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Document int    `json:"document,omitempty"`
+	Message  string `json:"message"`
+}
+
+// newJSONError describes err for inputFile as a jsonError.
+func newJSONError(inputFile string, err error) jsonError {
+	report := jsonError{Error: errorCode(err), Message: err.Error()}
+	if inputFile != "" {
+		report.File = displayName(inputFile)
+	}
+
+	var usageErr *usageError
+	var pathErr *fs.PathError
+	var ioErr *converter.IOError
+	var parseErr *converter.ParseError
+	var templateErr *converter.TemplateError
+	var aliasErr *converter.AliasError
+	var aliasLimitErr *converter.AliasLimitError
+	var duplicateErr *converter.DuplicateKeyError
+	var missingErr *converter.MissingFieldsError
+	var schemaErr *converter.SchemaError
+	var nameErr *converter.NameError
+	var labelErr *converter.LabelError
+	var imageErr *converter.ImageError
+	var quantityErr *converter.QuantityError
+	var queryErr *converter.QueryError
+	var notFoundErr *converter.PathNotFoundError
+	var setErr *converter.SetFieldError
+	var roundTripErr *converter.RoundTripError
+	var typedErr *converter.TypedError
+	var deprecatedErr *converter.DeprecatedAPIError
+	var renderErr *renderError
+	var clusterErr *clusterError
+	var sizeErr *converter.SizeLimitError
+	switch {
+	case errors.As(err, &usageErr):
+		report.Message = usageErr.message
+	case errors.As(err, &sizeErr):
+		report.Message = "the input is " + tooLargeMessage(sizeErr.Limit)
+	case errors.As(err, &ioErr):
+		report.File = ioErr.Path
+	case errors.As(err, &pathErr):
+		report.File = pathErr.Path
+	case errors.As(err, &templateErr):
+		report.Line = templateErr.Line
+		report.Message = templateErr.Hint()
+	case errors.As(err, &parseErr):
+		report.Line, report.Column, report.Document = parseErr.Line, parseErr.Column, parseErr.Document
+		report.Message = parseErr.Detail()
+	case errors.As(err, &aliasErr):
+		report.Line, report.Column, report.Document = aliasErr.Line, aliasErr.Column, aliasErr.Document
+		report.Message = aliasErr.Detail()
+	case errors.As(err, &aliasLimitErr):
+		report.Line, report.Column, report.Document = aliasLimitErr.Line, aliasLimitErr.Column, aliasLimitErr.Document
+		report.Message = aliasLimitErr.Detail()
+	case errors.As(err, &duplicateErr) && len(duplicateErr.Duplicates) > 0:
+		first := duplicateErr.Duplicates[0]
+		report.Line, report.Column, report.Document = first.Line, first.Column, first.Document
+	case errors.As(err, &missingErr) && len(missingErr.Documents) > 0:
+		first := missingErr.Documents[0]
+		report.Line, report.Document = first.Line, first.Document
+	case errors.As(err, &schemaErr) && len(schemaErr.Violations) > 0:
+		report.Document = schemaErr.Violations[0].Document
+	case errors.As(err, &nameErr) && len(nameErr.Violations) > 0:
+		report.Line, report.Document = nameErr.Violations[0].Line, nameErr.Violations[0].Document
+	case errors.As(err, &labelErr) && len(labelErr.Violations) > 0:
+		report.Line, report.Document = labelErr.Violations[0].Line, labelErr.Violations[0].Document
+	case errors.As(err, &imageErr) && len(imageErr.Violations) > 0:
+		report.Line, report.Document = imageErr.Violations[0].Line, imageErr.Violations[0].Document
+	case errors.As(err, &quantityErr) && len(quantityErr.Violations) > 0:
+		report.Line, report.Document = quantityErr.Violations[0].Line, quantityErr.Violations[0].Document
+	case errors.As(err, &queryErr):
+		report.Document = queryErr.Document
+	case errors.As(err, &notFoundErr):
+		report.Line, report.Document = notFoundErr.Line, notFoundErr.Document
+	case errors.As(err, &setErr):
+		report.Line, report.Document = setErr.Line, setErr.Document
+	case errors.As(err, &roundTripErr):
+		report.Document = roundTripErr.Document
+	case errors.As(err, &typedErr):
+		report.Document = typedErr.Document
+	case errors.As(err, &deprecatedErr):
+		report.Line, report.Document = deprecatedErr.Line, deprecatedErr.Document
+	case errors.As(err, &renderErr):
+		report.Document = renderErr.document
+		report.Message = renderErr.err.Error()
+	case errors.As(err, &clusterErr):
+		report.Line, report.Document = clusterErr.line, clusterErr.document
+	}
+	return report
+}
+
+// errorCode returns the -error-format json code for the class of err.
+func errorCode(err error) string {
+	var usageErr *usageError
+	var inputErr *inputError
+	var outputErr *outputError
+	var overwriteErr *overwriteError
+	var ioErr *converter.IOError
+	var parseErr *converter.ParseError
+	var sizeErr *converter.SizeLimitError
+	switch {
+	case errors.As(err, &usageErr):
+		if usageErr.code != "" {
+			return usageErr.code
+		}
+		return errorUsage
+	case errors.Is(err, converter.ErrNoMatch):
+		return errorNoMatch
+	case errors.As(err, &sizeErr):
+		return errorTooLarge
+	case errors.As(err, &inputErr) && inputErr.code != "":
+		return inputErr.code
+	case errors.As(err, &overwriteErr):
+		return errorOutputExists
+	case errors.As(err, &outputErr):
+		return errorWrite
+	case errors.As(err, &ioErr) && ioErr.Op == "write":
+		return errorWrite
+	case errors.As(err, &inputErr), errors.As(err, &ioErr):
+		if errors.Is(err, fs.ErrNotExist) {
+			return errorInputNotFound
+		}
+		return errorRead
+	}
+
+	var decompressErr *converter.DecompressError
+	var templateErr *converter.TemplateError
+	var encodeErr *converter.EncodeError
+	var keyErr *converter.KeyError
+	var duplicateErr *converter.DuplicateKeyError
+	var missingErr *converter.MissingFieldsError
+	var schemaErr *converter.SchemaError
+	var nameErr *converter.NameError
+	var labelErr *converter.LabelError
+	var imageErr *converter.ImageError
+	var quantityErr *converter.QuantityError
+	var aliasErr *converter.AliasError
+	var aliasLimitErr *converter.AliasLimitError
+	var envErr *converter.MissingEnvError
+	var valuesErr *converter.ValuesError
+	var queryErr *converter.QueryError
+	var notFoundErr *converter.PathNotFoundError
+	var setErr *converter.SetFieldError
+	var roundTripErr *converter.RoundTripError
+	var typedErr *converter.TypedError
+	var deprecatedErr *converter.DeprecatedAPIError
+	var renderErr *renderError
+	switch {
+	case errors.As(err, &decompressErr):
+		return errorDecompress
+	case errors.Is(err, converter.ErrNotText):
+		return errorNotText
+	case errors.As(err, &templateErr):
+		return errorHelmTemplate
+	case errors.As(err, &parseErr):
+		if parseErr.Format == "YAML" {
+			return errorYAMLParse
+		}
+		return errorJSONParse
+	case errors.Is(err, converter.ErrInvalidYAML):
+		return errorInvalidYAML
+	case errors.As(err, &encodeErr):
+		return errorEncode
+	case errors.As(err, &keyErr):
+		return errorNonStringKey
+	case errors.As(err, &duplicateErr):
+		return errorDuplicateKey
+	case errors.As(err, &missingErr):
+		return errorMissingFields
+	case errors.As(err, &schemaErr):
+		return errorSchema
+	case errors.As(err, &nameErr):
+		return errorName
+	case errors.As(err, &labelErr):
+		return errorLabel
+	case errors.As(err, &imageErr):
+		return errorImage
+	case errors.As(err, &quantityErr):
+		return errorQuantity
+	case errors.As(err, &aliasErr):
+		return errorAlias
+	case errors.As(err, &aliasLimitErr):
+		return errorAliasLimit
+	case errors.As(err, &envErr):
+		return errorUndefinedEnv
+	case errors.As(err, &valuesErr):
+		return errorValues
+	case errors.As(err, &queryErr):
+		return errorQuery
+	case errors.As(err, &notFoundErr):
+		return errorPathNotFound
+	case errors.As(err, &setErr):
+		return errorSetField
+	case errors.As(err, &roundTripErr):
+		return errorRoundTrip
+	case errors.As(err, &typedErr):
+		return errorTyped
+	case errors.As(err, &deprecatedErr):
+		return errorDeprecated
+	case errors.As(err, &renderErr):
+		return errorTemplate
+	}
+
+	var clusterErr *clusterError
+	var unreachableErr *clusterUnreachableError
+	switch {
+	case errors.As(err, &unreachableErr):
+		return errorUnreachable
+	case errors.As(err, &clusterErr):
+		switch clusterErr.outcome {
+		case clusterWebhook:
+			return errorWebhook
+		case clusterUnknownField:
+			return errorUnknownField
+		case clusterSchema:
+			return errorClusterSchema
+		case clusterUnknownKind:
+			return errorUnknownKind
+		}
+		return errorRejected
+	}
+	return errorUnclassified
+}
+
+// printJSONError writes err for inputFile to stderr as a single-line JSON
+// object, or one object per problem when err lists several.
+func printJSONError(inputFile string, err error) {
+	for _, problem := range problems(inputFile, err) {
+		data, _ := json.Marshal(problem)
+		stderr.Write(append(data, '\n'))
+	}
+}
+
+// problems splits err for inputFile into the problems it lists, each
+// described as by newJSONError: the errors of a *converter.MultiError, and
+// every violation of the errors that list several, such as
+// *converter.NameError. They are ordered by document. Any other error, or an
+// error that lists a single violation, is the one problem newJSONError
+// describes.
+func problems(inputFile string, err error) []jsonError {
+	var multiErr *converter.MultiError
+	if errors.As(err, &multiErr) {
+		var list []jsonError
+		for i, e := range multiErr.Errors {
+			for _, problem := range splitProblems(inputFile, e) {
+				if problem.Document == 0 {
+					problem.Document = multiErr.Documents[i]
+				}
+				list = append(list, problem)
+			}
+		}
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Document < list[j].Document })
+		return list
+	}
+	if list := splitProblems(inputFile, err); len(list) > 1 {
+		return list
+	}
+	return []jsonError{newJSONError(inputFile, err)}
+}
+
+// splitProblems returns a problem for each violation err lists, with a
+// message that names its kind, as it is among the problems of a
+// *converter.MultiError.
+func splitProblems(inputFile string, err error) []jsonError {
+	report := newJSONError(inputFile, err)
+	var list []jsonError
+	add := func(line, column, document int, message string) {
+		problem := report
+		problem.Line, problem.Column, problem.Document, problem.Message = line, column, document, message
+		list = append(list, problem)
+	}
+	violations := func(prefix string, violations []converter.NameViolation) {
+		for _, v := range violations {
+			document := v.Document
+			v.Document = 0
+			add(v.Line, 0, document, prefix+v.String())
+		}
+	}
+	var parseErr *converter.ParseError
+	var templateErr *converter.TemplateError
+	var duplicateErr *converter.DuplicateKeyError
+	var missingErr *converter.MissingFieldsError
+	var schemaErr *converter.SchemaError
+	var nameErr *converter.NameError
+	var labelErr *converter.LabelError
+	var imageErr *converter.ImageError
+	var quantityErr *converter.QuantityError
+	switch {
+	case errors.As(err, &templateErr):
+	case errors.As(err, &parseErr):
+		add(parseErr.Line, parseErr.Column, parseErr.Document, fmt.Sprintf("error parsing %s: %s", parseErr.Format, parseErr.Detail()))
+	case errors.As(err, &duplicateErr):
+		for _, d := range duplicateErr.Duplicates {
+			add(d.Line, d.Column, d.Document, fmt.Sprintf("duplicate key %q in %s (first defined at line %d)", d.Key, d.Path, d.FirstLine))
+		}
+	case errors.As(err, &missingErr):
+		for _, doc := range missingErr.Documents {
+			add(doc.Line, 0, doc.Document, "missing "+strings.Join(doc.Fields, ", "))
+		}
+	case errors.As(err, &schemaErr):
+		for _, v := range schemaErr.Violations {
+			document := v.Document
+			v.Document = 0
+			add(0, 0, document, "schema validation failed: "+v.String())
+		}
+	case errors.As(err, &nameErr):
+		violations("invalid name: ", nameErr.Violations)
+	case errors.As(err, &labelErr):
+		violations("invalid label: ", labelErr.Violations)
+	case errors.As(err, &imageErr):
+		violations("invalid image: ", imageErr.Violations)
+	case errors.As(err, &quantityErr):
+		violations("invalid quantity: ", quantityErr.Violations)
+	}
+	if len(list) == 0 {
+		return []jsonError{report}
+	}
+	return list
+}
+
+// describeProblems describes the problems of an error for inputFile, when
+// there are several, on a line each, grouped under the document they are in.
+func describeProblems(inputFile string, list []jsonError) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d errors", displayName(inputFile), len(list))
+	document := -1
+	for _, problem := range list {
+		if problem.Document != document {
+			document = problem.Document
+			if document > 0 {
+				fmt.Fprintf(&b, "\n  document %d:", document)
+			} else {
+				b.WriteString("\n  stream:")
+			}
+		}
+		location := problem.File
+		if location == "" {
+			location = displayName(inputFile)
+		}
+		if problem.Line > 0 {
+			location += ":" + strconv.Itoa(problem.Line)
+			if problem.Column > 0 {
+				location += ":" + strconv.Itoa(problem.Column)
+			}
+		}
+		fmt.Fprintf(&b, "\n    %s: %s", location, problem.Message)
+	}
+	return b.String()
+}
+
+// reportFailure reports a file that failed in a batch, -validate or -watch
+// run: a line starting with prefix, or a JSON object with -error-format json.
+func reportFailure(prefix, path string, err error) {
+	if jsonErrors {
+		printJSONError(path, err)
+		return
+	}
+	logf("%s %s", prefix, describeError(path, err))
+}
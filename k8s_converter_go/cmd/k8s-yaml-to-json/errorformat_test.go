@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestJSONErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"valid.yaml":      "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n",
+		"broken.yaml":     "apiVersion: v1\nkind: ConfigMap\ndata:\n  key: value: other\n",
+		"notes.txt":       "kind: ConfigMap\n",
+		"batch/a.yaml":    "kind: Deployment\n",
+		"batch/bad.yaml":  "This is not valid: YAML: content\n",
+		"template.yaml":   "kind: ConfigMap\nmetadata:\n  name: {{ .Values.name }}\n",
+		"duplicate.yaml":  "kind: ConfigMap\nmetadata:\n  name: a\n  name: b\n",
+		"second-doc.yaml": "kind: ConfigMap\n---\nkind: [Secret\n",
+		"several.yaml":    "kind: ConfigMap\nmetadata:\n  name: Bad_Name\n---\nkind: [Secret\n---\nkind: Pod\nmetadata:\n  name: Q_x\n",
+	})
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	subdomainRule := "must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character"
+
+	tests := []struct {
+		name string
+		args []string
+		want []jsonError
+	}{
+		{
+			name: "Unknown flag",
+			args: []string{"-error-format", "json", "-no-such-flag"},
+			want: []jsonError{{Error: errorUsage, Message: "flag provided but not defined: -no-such-flag"}},
+		},
+		{
+			name: "Missing input",
+			args: []string{"-error-format", "json"},
+			want: []jsonError{{Error: errorMissingInput, Message: "Input file is required"}},
+		},
+		{
+			name: "Bad extension",
+			args: []string{"-error-format", "json", "-input", path("notes.txt")},
+			want: []jsonError{{Error: errorBadExtension, File: path("notes.txt"), Message: "Input file '" + path("notes.txt") + "' does not have a .yaml or .yml extension"}},
+		},
+		{
+			name: "Missing file",
+			args: []string{"-error-format", "json", "-input", path("missing.yaml")},
+			want: []jsonError{{Error: errorInputNotFound, File: path("missing.yaml"), Message: "reading input file: open " + path("missing.yaml") + ": no such file or directory"}},
+		},
+		{
+			name: "Glob without matches",
+			args: []string{"-error-format", "json", "-input", path("*.yml")},
+			want: []jsonError{{Error: errorInputNotFound, File: path("*.yml"), Message: "no YAML files match pattern '" + path("*.yml") + "'"}},
+		},
+		{
+			name: "Parse error",
+			args: []string{"-error-format", "json", "-input", path("broken.yaml")},
+			want: []jsonError{{Error: errorYAMLParse, File: path("broken.yaml"), Line: 4, Column: 13, Document: 1, Message: "mapping values are not allowed in this context"}},
+		},
+		{
+			name: "Parse error in a later document",
+			args: []string{"-error-format", "json", "-input", path("second-doc.yaml")},
+			want: []jsonError{{Error: errorYAMLParse, File: path("second-doc.yaml"), Line: 3, Column: 8, Document: 2, Message: "did not find expected ',' or ']'"}},
+		},
+		{
+			name: "Helm template",
+			args: []string{"-error-format", "json", "-input", path("template.yaml")},
+			want: []jsonError{{Error: errorHelmTemplate, File: path("template.yaml"), Line: 3, Message: (&converter.TemplateError{}).Hint()}},
+		},
+		{
+			name: "Duplicate key",
+			args: []string{"-error-format", "json", "-strict-keys", "-input", path("duplicate.yaml")},
+			want: []jsonError{{Error: errorDuplicateKey, File: path("duplicate.yaml"), Line: 4, Column: 3, Document: 1, Message: `duplicate key "name" in .metadata at line 4, column 3 (first defined at line 3)`}},
+		},
+		{
+			name: "No match",
+			args: []string{"-error-format", "json", "-kind", "Secret", "-input", path("valid.yaml")},
+			want: []jsonError{{Error: errorNoMatch, File: path("valid.yaml"), Message: "no documents match the filters"}},
+		},
+		{
+			name: "Write error",
+			args: []string{"-error-format", "json", "-input", path("valid.yaml"), "-output", path("missing/out.json")},
+			want: []jsonError{{Error: errorWrite, File: path("missing/out.json"), Message: "writing output file: open " + path("missing/out.json") + ": no such file or directory"}},
+		},
+		{
+			name: "Batch failures",
+			args: []string{"-error-format", "json", "-quiet", "-input", path("batch"), "-output", path("out"), "-report", path("report.json")},
+			want: []jsonError{{Error: errorYAMLParse, File: path("batch/bad.yaml"), Line: 1, Column: 24, Document: 1, Message: "mapping values are not allowed in this context"}},
+		},
+		{
+			name: "Validate failures",
+			args: []string{"-error-format", "json", "-quiet", "-validate", "-input", path("batch")},
+			want: []jsonError{{Error: errorYAMLParse, File: path("batch/bad.yaml"), Line: 1, Column: 24, Document: 1, Message: "mapping values are not allowed in this context"}},
+		},
+		{
+			name: "Several errors",
+			args: []string{"-error-format", "json", "-validate-names", "-input", path("several.yaml")},
+			want: []jsonError{
+				{Error: errorName, File: path("several.yaml"), Line: 1, Document: 1, Message: `invalid name: ConfigMap: metadata.name "Bad_Name" is not a valid DNS-1123 subdomain: ` + subdomainRule},
+				{Error: errorYAMLParse, File: path("several.yaml"), Line: 5, Column: 8, Document: 2, Message: "error parsing YAML: did not find expected ',' or ']'"},
+				{Error: errorName, File: path("several.yaml"), Line: 7, Document: 3, Message: `invalid name: Pod: metadata.name "Q_x" is not a valid DNS-1123 subdomain: ` + subdomainRule},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errOutput, _ := runCommand(t, "", tt.args...)
+			lines := strings.Split(strings.TrimSpace(errOutput), "\n")
+			// Batch runs end with the human-readable summary
+			if strings.HasPrefix(lines[len(lines)-1], "Converted ") {
+				lines = lines[:len(lines)-1]
+			}
+			var got []jsonError
+			for _, line := range lines {
+				var report jsonError
+				if err := json.Unmarshal([]byte(line), &report); err != nil {
+					t.Fatalf("stderr line %q is not a JSON object: %v", line, err)
+				}
+				got = append(got, report)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupedErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"several.yaml": "kind: ConfigMap\nmetadata:\n  name: a\n  name: b\n---\nkind: [Secret\n---\nkind: Pod\n",
+	})
+	input := filepath.Join(dir, "several.yaml")
+	output := filepath.Join(dir, "out.json")
+
+	_, errOutput, code := runCommand(t, "", "-input", input, "-output", output, "-strict-keys", "-k8s-strict")
+	want := "Error: " + input + ": 3 errors\n" +
+		"  document 1:\n" +
+		"    " + input + ":4:3: duplicate key \"name\" in .metadata (first defined at line 3)\n" +
+		"  document 2:\n" +
+		"    " + input + ":6:8: error parsing YAML: did not find expected ',' or ']'\n" +
+		"  document 3:\n" +
+		"    " + input + ":8: missing apiVersion, metadata.name\n"
+	if code != exitInvalid || errOutput != want {
+		t.Errorf("exit code = %d, stderr = %q, want %q", code, errOutput, want)
+	}
+	if _, err := os.Stat(output); err == nil {
+		t.Error("output written despite the errors")
+	}
+}
+
+func TestInvalidErrorFormat(t *testing.T) {
+	_, errOutput, code := runCommand(t, "", "-error-format", "xml")
+	if code != exitUsage || !strings.HasPrefix(errOutput, "Error: invalid -error-format value 'xml'") {
+		t.Errorf("exit code = %d, stderr = %q", code, errOutput)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: usageErrorf(nil, "-raw requires -query"), want: errorUsage},
+		{err: &usageError{code: errorBadExtension}, want: errorBadExtension},
+		{err: &inputError{err: fmt.Errorf("reading input file: %w", os.ErrNotExist)}, want: errorInputNotFound},
+		{err: &inputError{err: errors.New("connection refused")}, want: errorRead},
+		{err: &converter.IOError{Op: "read", Path: "a.yaml", Err: os.ErrPermission}, want: errorRead},
+		{err: &converter.IOError{Op: "write", Path: "a.json", Err: os.ErrPermission}, want: errorWrite},
+		{err: &outputError{errors.New("disk full")}, want: errorWrite},
+		{err: &converter.DecompressError{Err: errors.New("unexpected EOF")}, want: errorDecompress},
+		{err: fmt.Errorf("%w: it contains a NUL byte at offset 7", converter.ErrNotText), want: errorNotText},
+		{err: &converter.ParseError{Format: "YAML", Err: errors.New("bad")}, want: errorYAMLParse},
+		{err: &converter.ParseError{Format: "JSON", Err: errors.New("bad")}, want: errorJSONParse},
+		{err: &converter.TemplateError{Err: &converter.ParseError{Format: "YAML"}}, want: errorHelmTemplate},
+		{err: converter.ErrInvalidYAML, want: errorInvalidYAML},
+		{err: &converter.EncodeError{Format: "JSON", Err: errors.New("bad")}, want: errorEncode},
+		{err: &converter.KeyError{Path: ".", Key: 1}, want: errorNonStringKey},
+		{err: &converter.DuplicateKeyError{}, want: errorDuplicateKey},
+		{err: &converter.MissingFieldsError{}, want: errorMissingFields},
+		{err: &converter.SchemaError{}, want: errorSchema},
+		{err: &converter.NameError{}, want: errorName},
+		{err: &converter.LabelError{}, want: errorLabel},
+		{err: &converter.ImageError{}, want: errorImage},
+		{err: &converter.QuantityError{}, want: errorQuantity},
+		{err: &converter.AliasError{}, want: errorAlias},
+		{err: &converter.AliasLimitError{}, want: errorAliasLimit},
+		{err: &converter.MissingEnvError{}, want: errorUndefinedEnv},
+		{err: &converter.ValuesError{}, want: errorValues},
+		{err: &converter.QueryError{}, want: errorQuery},
+		{err: &converter.PathNotFoundError{}, want: errorPathNotFound},
+		{err: &converter.SetFieldError{}, want: errorSetField},
+		{err: &converter.RoundTripError{}, want: errorRoundTrip},
+		{err: &converter.TypedError{Err: errors.New("bad")}, want: errorTyped},
+		{err: &converter.DeprecatedAPIError{}, want: errorDeprecated},
+		{err: &renderError{document: 2, err: errors.New("bad")}, want: errorTemplate},
+		{err: fmt.Errorf("a.yaml: %w", converter.ErrNoMatch), want: errorNoMatch},
+		{err: errors.New("something else"), want: errorUnclassified},
+	}
+	for _, tt := range tests {
+		if got := errorCode(tt.err); got != tt.want {
+			t.Errorf("errorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// excludes are the -exclude patterns, which skip files and directories of
+// directory and glob input before they are read.
+var excludes []excludePattern
+
+// excludePattern is a gitignore-style pattern matched against paths relative
+// to the input root, with forward slashes:
+//
+//   - A pattern with a slash at its start or in the middle, such as
+//     charts/*/templates, matches the whole relative path; a leading slash
+//     only anchors the pattern. Other patterns, such as *-test.yaml, match
+//     the name of a file or directory at any depth.
+//   - A trailing slash, as in vendor/, matches directories only. Other
+//     patterns match both files and directories.
+//   - A "**" segment matches any number of directories, and the rest of a
+//     segment follows filepath.Match syntax.
+//
+// A matching directory excludes everything below it. Negated patterns
+// starting with "!" are not supported.
+type excludePattern struct {
+	segments []string
+	anchored bool
+	dirOnly  bool
+}
+
+// parseExclude parses an -exclude pattern.
+func parseExclude(pattern string) (excludePattern, error) {
+	var p excludePattern
+	value := filepath.ToSlash(strings.TrimSpace(pattern))
+	if strings.HasPrefix(value, "!") {
+		return p, fmt.Errorf("invalid -exclude pattern '%s': negated patterns are not supported", pattern)
+	}
+	if strings.HasSuffix(value, "/") {
+		p.dirOnly = true
+		value = strings.TrimRight(value, "/")
+	}
+	p.anchored = strings.Contains(value, "/")
+	value = strings.TrimPrefix(value, "/")
+	if value == "" {
+		return p, fmt.Errorf("invalid -exclude pattern '%s': must name a file or directory", pattern)
+	}
+	p.segments = strings.Split(value, "/")
+	for _, segment := range p.segments {
+		if _, err := filepath.Match(segment, ""); err != nil {
+			return p, fmt.Errorf("invalid -exclude pattern '%s': %v", pattern, err)
+		}
+	}
+	return p, nil
+}
+
+// match reports whether the pattern matches the path, split into segments,
+// of a file or directory.
+func (p excludePattern) match(segments []string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.anchored {
+		ok, _ := matchSegments(p.segments, segments)
+		return ok
+	}
+	ok, _ := filepath.Match(p.segments[0], segments[len(segments)-1])
+	return ok
+}
+
+// isExcluded reports whether the file or directory at path, below root,
+// matches an -exclude pattern. A path inside an excluded directory is
+// excluded as well, and paths outside root, such as the files a
+// kustomization lists from ../base, are never excluded.
+func isExcluded(root, path string, isDir bool) bool {
+	if len(excludes) == 0 {
+		return false
+	}
+	rel, err := relativePath(root, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for _, p := range excludes {
+		for i := 1; i <= len(segments); i++ {
+			// Every parent of the path is a directory
+			if p.match(segments[:i], isDir || i < len(segments)) {
+				return true
+			}
+		}
+	}
+	return false
+}
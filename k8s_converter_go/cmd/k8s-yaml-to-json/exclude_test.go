@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setExcludes sets the -exclude patterns for the rest of the test.
+func setExcludes(t *testing.T, patterns ...string) {
+	t.Helper()
+	saved := excludes
+	t.Cleanup(func() { excludes = saved })
+	excludes = nil
+	for _, pattern := range patterns {
+		exclude, err := parseExclude(pattern)
+		if err != nil {
+			t.Fatalf("parseExclude(%q) error = %v", pattern, err)
+		}
+		excludes = append(excludes, exclude)
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{pattern: "vendor/", path: "vendor", isDir: true, want: true},
+		{pattern: "vendor/", path: "apps/vendor", isDir: true, want: true},
+		{pattern: "vendor/", path: "apps/vendor/lib/x.yaml", want: true},
+		{pattern: "vendor/", path: "vendor", isDir: false, want: false},
+		{pattern: "vendor", path: "vendor", isDir: false, want: true},
+		{pattern: "*-test.yaml", path: "apps/web-test.yaml", want: true},
+		{pattern: "*-test.yaml", path: "apps/web.yaml", want: false},
+		{pattern: "charts/*/templates/", path: "charts/web/templates/deploy.yaml", want: true},
+		{pattern: "charts/*/templates/", path: "apps/charts/web/templates/deploy.yaml", want: false},
+		{pattern: "charts/*/templates/", path: "charts/web/values.yaml", want: false},
+		{pattern: "/web.yaml", path: "web.yaml", want: true},
+		{pattern: "/web.yaml", path: "apps/web.yaml", want: false},
+		{pattern: "**/testdata", path: "a/b/testdata/x.yaml", want: true},
+		{pattern: "apps/**/secret.yaml", path: "apps/secret.yaml", want: true},
+		{pattern: "apps/**/secret.yaml", path: "apps/prod/eu/secret.yaml", want: true},
+		{pattern: "docs/**", path: "docs/guide/x.yaml", want: true},
+	}
+
+	root := filepath.FromSlash("repo")
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			setExcludes(t, tt.pattern)
+			if got := isExcluded(root, filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+				t.Errorf("isExcluded(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExcludeErrors(t *testing.T) {
+	for _, pattern := range []string{"!keep.yaml", "/", "apps/[", ""} {
+		if _, err := parseExclude(pattern); err == nil || !strings.Contains(err.Error(), "invalid -exclude pattern") {
+			t.Errorf("parseExclude(%q) error = %v, want an invalid pattern error", pattern, err)
+		}
+	}
+}
+
+func TestFindYAMLFilesExclude(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"apps/web.yaml":                   "kind: Deployment\n",
+		"apps/web-test.yaml":              "kind: Pod\n",
+		"apps/README.md":                  "docs\n",
+		"apps/vendor/lib.yaml":            "kind: ConfigMap\n",
+		"charts/web/Chart.yaml":           "name: web\n",
+		"charts/web/templates/a.yaml":     "{{ .Values }}\n",
+		"charts/web/templates/sub/b.yaml": "{{ .Values }}\n",
+		"vendor/deep/nested/c.yaml":       "kind: Secret\n",
+	})
+	setExcludes(t, "vendor/", "charts/*/templates/", "*-test.yaml")
+
+	// Excluded directories are never read, so an unreadable one is fine
+	locked := filepath.Join(root, "vendor", "deep")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	files, skipped, err := findYAMLFiles(root)
+	if err != nil {
+		t.Fatalf("findYAMLFiles() error = %v", err)
+	}
+	want := []string{"apps/web.yaml", "charts/web/Chart.yaml"}
+	if got := relativePaths(t, root, files); !reflect.DeepEqual(got, want) {
+		t.Errorf("findYAMLFiles() = %v, want %v", got, want)
+	}
+	// apps/web-test.yaml, apps/vendor, charts/web/templates and vendor
+	if want := (skippedFiles{excluded: 4, notYAML: 1}); skipped != want {
+		t.Errorf("findYAMLFiles() skipped = %+v, want %+v", skipped, want)
+	}
+}
+
+func TestGlobFilesExclude(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"charts/web/templates/a.yaml": "{{ .Values }}\n",
+		"charts/web/values.yaml":      "replicas: 1\n",
+		"charts/db/templates/b.yaml":  "{{ .Values }}\n",
+	})
+	// Exclusion takes precedence over the glob, with and without "**"
+	setExcludes(t, "templates/")
+	tests := map[string][]string{
+		"charts/*/*/*.yaml": nil,
+		"charts/**/*.yaml":  {filepath.Join(dir, "charts", "web", "values.yaml")},
+	}
+	for pattern, want := range tests {
+		files, skipped, err := globFiles(filepath.Join(dir, pattern))
+		if err != nil {
+			t.Fatalf("globFiles(%s) error = %v", pattern, err)
+		}
+		if !reflect.DeepEqual(files, want) || skipped.excluded != 2 {
+			t.Errorf("globFiles(%s) = %v, skipped %+v, want %v with both templates excluded", pattern, files, skipped, want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// Exit codes. They are stable so that scripts can tell the class of a
+// failure apart; new classes get new codes.
+const (
+	exitOK = 0
+	// exitStale is returned by -diff when the existing output differs from
+	// the conversion. It is the same code as exitFailure, as with diff(1).
+	exitStale = 1
+	// exitFailure is any failure not covered by another code, such as an
+	// input that cannot be watched.
+	exitFailure = 1
+	// exitUsage is a mistake in the command line, such as conflicting flags
+	// or an input without the expected extension.
+	exitUsage = 2
+	// exitInput is a failure to find or read the input, such as a missing
+	// file or corrupt gzip data.
+	exitInput = 3
+	// exitInvalid is input that does not parse or fails validation.
+	exitInvalid = 4
+	// exitOutput is a failure to write the output.
+	exitOutput = 5
+	// exitNoMatch is returned when document filters such as -kind and
+	// -selector select no documents, so that CI can tell a mistyped filter
+	// from a failed conversion.
+	exitNoMatch = 6
+	// exitRoundTrip is a document that -verify-roundtrip found to change when
+	// its JSON is converted back to YAML.
+	exitRoundTrip = 7
+	// exitUnreachable is a failure of -cluster-validate to reach the API
+	// server or to be accepted by it.
+	exitUnreachable = 8
+)
+
+// usageError is a mistake in the command line. The usage of flags, when set,
+// is printed after its message. code, when set, is reported by -error-format
+// json instead of errorUsage.
+type usageError struct {
+	code    string
+	message string
+	flags   *flag.FlagSet
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+// usageErrorf returns a *usageError with a formatted message.
+func usageErrorf(flags *flag.FlagSet, format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...), flags: flags}
+}
+
+// inputError is a failure to find or read an input. code, when set, is
+// reported by -error-format json instead of the code derived from err.
+type inputError struct {
+	code string
+	err  error
+}
+
+func (e *inputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *inputError) Unwrap() error {
+	return e.err
+}
+
+// outputError is a failure to write an output.
+type outputError struct {
+	err error
+}
+
+func (e *outputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *outputError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code for the class of err, or exitOK when err is
+// nil.
+func exitCode(err error) int {
+	var usageErr *usageError
+	var inputErr *inputError
+	var outputErr *outputError
+	var ioErr *converter.IOError
+	var decompressErr *converter.DecompressError
+	var sizeErr *converter.SizeLimitError
+	var roundTripErr *converter.RoundTripError
+	var unreachableErr *clusterUnreachableError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, converter.ErrNoMatch):
+		return exitNoMatch
+	case errors.As(err, &inputErr), errors.As(err, &decompressErr), errors.As(err, &sizeErr):
+		return exitInput
+	case errors.As(err, &outputErr):
+		return exitOutput
+	case errors.As(err, &ioErr):
+		if ioErr.Op == "write" {
+			return exitOutput
+		}
+		return exitInput
+	case errors.As(err, &roundTripErr):
+		return exitRoundTrip
+	case errors.As(err, &unreachableErr):
+		return exitUnreachable
+	case isInvalidInput(err):
+		return exitInvalid
+	}
+	return exitFailure
+}
+
+// isInvalidInput reports whether err is a parse or validation error of the
+// input.
+func isInvalidInput(err error) bool {
+	var parseErr *converter.ParseError
+	var templateErr *converter.TemplateError
+	var encodeErr *converter.EncodeError
+	var keyErr *converter.KeyError
+	var duplicateErr *converter.DuplicateKeyError
+	var missingErr *converter.MissingFieldsError
+	var schemaErr *converter.SchemaError
+	var nameErr *converter.NameError
+	var labelErr *converter.LabelError
+	var imageErr *converter.ImageError
+	var quantityErr *converter.QuantityError
+	var aliasErr *converter.AliasError
+	var aliasLimitErr *converter.AliasLimitError
+	var envErr *converter.MissingEnvError
+	var valuesErr *converter.ValuesError
+	var queryErr *converter.QueryError
+	var notFoundErr *converter.PathNotFoundError
+	var setErr *converter.SetFieldError
+	var typedErr *converter.TypedError
+	var deprecatedErr *converter.DeprecatedAPIError
+	var clusterErr *clusterError
+	return errors.Is(err, converter.ErrInvalidYAML) ||
+		errors.Is(err, converter.ErrNotText) ||
+		errors.As(err, &parseErr) ||
+		errors.As(err, &templateErr) ||
+		errors.As(err, &encodeErr) ||
+		errors.As(err, &keyErr) ||
+		errors.As(err, &duplicateErr) ||
+		errors.As(err, &missingErr) ||
+		errors.As(err, &schemaErr) ||
+		errors.As(err, &nameErr) ||
+		errors.As(err, &labelErr) ||
+		errors.As(err, &imageErr) ||
+		errors.As(err, &quantityErr) ||
+		errors.As(err, &aliasErr) ||
+		errors.As(err, &aliasLimitErr) ||
+		errors.As(err, &envErr) ||
+		errors.As(err, &valuesErr) ||
+		errors.As(err, &queryErr) ||
+		errors.As(err, &notFoundErr) ||
+		errors.As(err, &setErr) ||
+		errors.As(err, &typedErr) ||
+		errors.As(err, &deprecatedErr) ||
+		errors.As(err, &clusterErr)
+}
+
+// reportError prints a message describing err for inputFile, as a JSON object
+// with -error-format json, and returns the exit code for it. It returns exitOK without printing anything when err is
+// nil.
+func reportError(inputFile string, err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if jsonErrors {
+		printJSONError(inputFile, err)
+		return exitCode(err)
+	}
+	var usageErr *usageError
+	var templateErr *converter.TemplateError
+	var parseErr *converter.ParseError
+	var encodeErr *converter.EncodeError
+	var roundTripErr *converter.RoundTripError
+	var deprecatedErr *converter.DeprecatedAPIError
+	var aliasErr *converter.AliasError
+	var aliasLimitErr *converter.AliasLimitError
+	var sizeErr *converter.SizeLimitError
+	var nameErr *converter.NameError
+	var labelErr *converter.LabelError
+	var imageErr *converter.ImageError
+	var quantityErr *converter.QuantityError
+	var renderErr *renderError
+	switch {
+	case errors.As(err, &usageErr):
+		logf("Error: %s", usageErr.message)
+		if usageErr.flags != nil {
+			usageErr.flags.Usage()
+		}
+	case len(problems(inputFile, err)) > 1:
+		logf("Error: %s", describeError(inputFile, err))
+	case errors.Is(err, converter.ErrNoMatch), errors.Is(err, converter.ErrNotText), errors.As(err, &renderErr):
+		logf("Error: %s: %v", displayName(inputFile), err)
+	case errors.As(err, &templateErr), errors.As(err, &roundTripErr), errors.As(err, &deprecatedErr), errors.As(err, &aliasErr), errors.As(err, &aliasLimitErr), errors.As(err, &sizeErr),
+		errors.As(err, &nameErr), errors.As(err, &labelErr), errors.As(err, &imageErr), errors.As(err, &quantityErr):
+		logf("Error: %s", describeError(inputFile, err))
+	case errors.Is(err, converter.ErrInvalidYAML):
+		logf("Error: File '%s' contains %v", displayName(inputFile), err)
+	case errors.As(err, &parseErr):
+		logf("Error parsing %s: %s", parseErr.Format, formatParseError(inputFile, parseErr))
+	case errors.As(err, &encodeErr):
+		logf("Error converting to %s: %v", encodeErr.Format, encodeErr.Err)
+	default:
+		logf("Error: %v", err)
+	}
+	return exitCode(err)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"valid.yaml":         "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n",
+		"broken.yaml":        "This is not valid: YAML: content\n",
+		"duplicate.yaml":     "kind: ConfigMap\nkind: Secret\n",
+		"notes.txt":          "kind: ConfigMap\n",
+		"binary.yaml":        "\x7fELF\x02\x01\x01\x00",
+		"empty-docs.yaml":    "kind: Service\n---\n# Source: unused.yaml\n",
+		"output-is-file":     "",
+		"batch/a.yaml":       "kind: Deployment\n",
+		"batch/b.yaml":       "kind: Service\n",
+		"batch-bad/ok.yaml":  "kind: Deployment\n",
+		"batch-bad/bad.yaml": "This is not valid: YAML: content\n",
+	})
+	corrupt := filepath.Join(dir, "corrupt.yaml.gz")
+	writeGzip(t, corrupt, "kind: ConfigMap\n")
+	data, err := os.ReadFile(corrupt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(corrupt, data[:len(data)-6], 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "Success", args: []string{"-input", path("valid.yaml")}, want: exitOK},
+		{name: "Help", args: []string{"-help"}, want: exitOK},
+		{name: "Unknown flag", args: []string{"-no-such-flag"}, want: exitUsage},
+		{name: "Invalid flag value", args: []string{"-input", path("valid.yaml"), "-format", "xml"}, want: exitUsage},
+		{name: "Conflicting flags", args: []string{"-input", path("valid.yaml"), "-decode-secrets", "-redact-secrets"}, want: exitUsage},
+		{name: "Missing input flag", args: []string{}, want: exitUsage},
+		{name: "Wrong extension", args: []string{"-input", path("notes.txt")}, want: exitUsage},
+		{name: "Missing file", args: []string{"-input", path("missing.yaml")}, want: exitInput},
+		{name: "Glob without matches", args: []string{"-input", path("*.yml")}, want: exitInput},
+		{name: "Corrupt gzip", args: []string{"-input", corrupt}, want: exitInput},
+		{name: "Invalid YAML", args: []string{"-input", path("broken.yaml")}, want: exitInvalid},
+		{name: "Invalid empty docs policy", args: []string{"-input", path("valid.yaml"), "-empty-docs", "keep"}, want: exitUsage},
+		{name: "Empty document rejected", args: []string{"-input", path("empty-docs.yaml"), "-empty-docs", "error"}, want: exitInvalid},
+		{name: "Empty document skipped", args: []string{"-input", path("empty-docs.yaml")}, want: exitOK},
+		{name: "Binary input", args: []string{"-input", path("binary.yaml")}, want: exitInvalid},
+		{name: "Strict keys", args: []string{"-input", path("duplicate.yaml"), "-strict-keys"}, want: exitInvalid},
+		{name: "Kubernetes strict", args: []string{"-input", path("batch/a.yaml"), "-k8s-strict"}, want: exitInvalid},
+		{name: "Validate missing file", args: []string{"-validate", "-input", path("missing.yaml")}, want: exitInput},
+		{name: "Validate invalid YAML", args: []string{"-validate", "-input", path("broken.yaml")}, want: exitInvalid},
+		{name: "Batch failure", args: []string{"-input", path("batch-bad"), "-output", path("out-bad")}, want: exitInvalid},
+		{name: "Output directory missing", args: []string{"-input", path("valid.yaml"), "-output", path("missing/out.json")}, want: exitOutput},
+		{name: "Split into a file", args: []string{"-input", path("valid.yaml"), "-split", "-output", path("output-is-file")}, want: exitOutput},
+		{name: "Batch output into a file", args: []string{"-input", path("batch"), "-output", path("output-is-file")}, want: exitOutput},
+		{name: "No match", args: []string{"-input", path("valid.yaml"), "-kind", "Secret"}, want: exitNoMatch},
+		{name: "Batch no match", args: []string{"-input", path("batch"), "-output", path("out-none"), "-kind", "Secret"}, want: exitNoMatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errOutput, code := runCommand(t, "", tt.args...)
+			if code != tt.want {
+				t.Errorf("exit code = %d, want %d; stderr:\n%s", code, tt.want, errOutput)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: nil, want: exitOK},
+		{err: usageErrorf(nil, "-raw requires -query"), want: exitUsage},
+		{err: &inputError{err: errors.New("no YAML files match pattern")}, want: exitInput},
+		{err: &converter.IOError{Op: "read", Path: "a.yaml", Err: os.ErrNotExist}, want: exitInput},
+		{err: &converter.DecompressError{Err: errors.New("unexpected EOF")}, want: exitInput},
+		{err: &converter.ParseError{Format: "YAML", Err: errors.New("bad")}, want: exitInvalid},
+		{err: converter.ErrInvalidYAML, want: exitInvalid},
+		{err: fmt.Errorf("%w: it contains a NUL byte at offset 7", converter.ErrNotText), want: exitInvalid},
+		{err: &converter.MissingFieldsError{}, want: exitInvalid},
+		{err: &converter.SchemaError{}, want: exitInvalid},
+		{err: &converter.DeprecatedAPIError{APIVersion: "batch/v1beta1", Kind: "CronJob"}, want: exitInvalid},
+		{err: &converter.TypedError{APIVersion: "apps/v1", Kind: "Deployment", Err: errors.New("bad")}, want: exitInvalid},
+		{err: &outputError{errors.New("disk full")}, want: exitOutput},
+		{err: &converter.IOError{Op: "write", Path: "a.json", Err: errors.New("disk full")}, want: exitOutput},
+		{err: fmt.Errorf("a.yaml: %w", converter.ErrNoMatch), want: exitNoMatch},
+		{err: &converter.RoundTripError{Path: ".a"}, want: exitRoundTrip},
+		{err: errors.New("something else"), want: exitFailure},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+// writeGzip writes content gzip-compressed to path.
+func writeGzip(t *testing.T, path, content string) {
+	t.Helper()
+	var compressed strings.Builder
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(compressed.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// Values of -incremental.
+const (
+	// incrementalMtime skips a file whose output is not older than it, as
+	// make does. It is the mode of -incremental given alone.
+	incrementalMtime = "mtime"
+	// incrementalHash skips a file whose content has the digest recorded
+	// in the state file when its outputs were written, so that a file
+	// touched but not changed is skipped too.
+	incrementalHash = "hash"
+)
+
+// stateFileName is the file -incremental records every conversion in, in
+// the output directory, or in the input directory for outputs written next
+// to their sources.
+const stateFileName = ".k8s-yaml-to-json-state.json"
+
+// incrementalFlag is the -incremental flag, given alone for
+// incrementalMtime or as -incremental=hash.
+type incrementalFlag struct {
+	mode string
+}
+
+func (f *incrementalFlag) String() string {
+	return f.mode
+}
+
+func (f *incrementalFlag) Set(value string) error {
+	switch value {
+	case incrementalMtime, incrementalHash:
+		f.mode = value
+		return nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("must be mtime or hash")
+	}
+	f.mode = ""
+	if enabled {
+		f.mode = incrementalMtime
+	}
+	return nil
+}
+
+// IsBoolFlag lets -incremental be given without a value.
+func (f *incrementalFlag) IsBoolFlag() bool {
+	return true
+}
+
+// stateFile is the content of the -incremental state file.
+type stateFile struct {
+	Version int `json:"version"`
+	// Options is the optionsDigest of the run that wrote the outputs.
+	Options string `json:"options,omitempty"`
+	// Files maps each source, relative to the input root and with forward
+	// slashes, to its last conversion.
+	Files map[string]stateEntry `json:"files"`
+}
+
+// stateEntry is the last conversion of a source.
+type stateEntry struct {
+	// SHA256 is the digest of the source when it was converted, or "" when
+	// it was found up to date by its modification time without being read.
+	SHA256 string `json:"sha256,omitempty"`
+	// Outputs are the files written for the source, relative to the
+	// directory of the state file and with forward slashes.
+	Outputs []string `json:"outputs"`
+}
+
+// incrementalState decides which files of a batch are up to date with
+// -incremental, and records the outputs of the others for the next run.
+type incrementalState struct {
+	mode string
+	// root is the input root the sources are relative to, and dir the
+	// directory of the state file.
+	root  string
+	dir   string
+	files map[string]stateEntry
+	// options is the optionsDigest of this run, and stale is set when the
+	// outputs recorded were written with other options, so that none of
+	// them is up to date.
+	options string
+	stale   bool
+}
+
+// optionsDigest returns the hex SHA-256 digest of the options the files of
+// a batch are converted with and of the layout of their outputs, so that
+// changing a flag such as -clean or -indent between two -incremental runs
+// converts every file again. The environment of -env-subst and the content
+// of -schema-dir and -crd-dir are not part of it. It returns "" for options
+// that cannot be encoded, with which no output is ever up to date.
+func optionsDigest(batch batchOptions, opts converter.Options) string {
+	// Func fields, such as the warning handler, cannot be encoded
+	options := make(map[string]interface{})
+	value := reflect.ValueOf(opts)
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).Kind() != reflect.Func {
+			options[value.Type().Field(i).Name] = value.Field(i).Interface()
+		}
+	}
+	settings := map[string]interface{}{"options": options, "split": batch.split}
+	if nameTemplate != nil {
+		settings["nameTemplate"] = nameTemplate.Root.String()
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// loadState reads the state file in dir for sources under root, for a run
+// with the optionsDigest options. A missing state file is an empty state;
+// one that cannot be read is ignored with a warning, so that every file is
+// converted again.
+func loadState(mode, root, dir, options string) *incrementalState {
+	s := &incrementalState{mode: mode, root: root, dir: dir, files: make(map[string]stateEntry), options: options}
+	data, err := os.ReadFile(s.path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return s
+	}
+	var state stateFile
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+	if err != nil {
+		warnf(s.path(), "ignoring the -incremental state file: %v", err)
+		return s
+	}
+	for source, entry := range state.Files {
+		s.files[source] = entry
+	}
+	// The outputs are kept for -prune and to be replaced without -force
+	if len(s.files) > 0 && (options == "" || state.Options != options) {
+		s.stale = true
+		verbosef(1, []logAttr{{"file", s.path()}}, "Converting every file: the options differ from the last -incremental run")
+	}
+	return s
+}
+
+// path returns the path of the state file.
+func (s *incrementalState) path() string {
+	return filepath.Join(s.dir, stateFileName)
+}
+
+// key returns the key of the source at path in the state file.
+func (s *incrementalState) key(path string) (string, bool) {
+	rel, err := relativePath(s.root, path)
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// outputs returns the recorded outputs of the source at path.
+func (s *incrementalState) outputs(path string) []string {
+	key, ok := s.key(path)
+	if !ok {
+		return nil
+	}
+	var outputs []string
+	for _, output := range s.files[key].Outputs {
+		outputs = append(outputs, filepath.Join(s.dir, filepath.FromSlash(output)))
+	}
+	return outputs
+}
+
+// claim lets this run replace the outputs recorded for files without
+// -force, as claimedOutputs does for the outputs of -watch: they were
+// written by an earlier run for the same sources.
+func (s *incrementalState) claim(files []string) {
+	for _, path := range files {
+		for _, output := range s.outputs(path) {
+			claimedOutputs[output] = true
+		}
+	}
+}
+
+// check reports whether the outputs of the source at path are up to date,
+// returning them. out is the output the source is converted to, or "" for
+// split output, whose files are only known from the state file. For a file
+// that is not up to date, digest is the digest of its content, to record
+// once it is converted.
+func (s *incrementalState) check(path, out string) (outputs []string, digest string, upToDate bool) {
+	if s.stale {
+		return nil, fileDigest(path), false
+	}
+	if s.mode == incrementalMtime {
+		source, err := os.Stat(path)
+		if _, entry := archiveFiles[path]; entry && err != nil {
+			// Archive entries are as old as the archive they are read from
+			source, err = os.Stat(s.root)
+		}
+		if err == nil {
+			if output, err := os.Stat(out); err == nil && !source.ModTime().After(output.ModTime()) {
+				return []string{out}, "", true
+			}
+		}
+		return nil, fileDigest(path), false
+	}
+
+	digest = fileDigest(path)
+	key, ok := s.key(path)
+	entry, recorded := s.files[key]
+	if !ok || !recorded || digest == "" || entry.SHA256 != digest {
+		return nil, digest, false
+	}
+	outputs = s.outputs(path)
+	if len(outputs) == 0 || (out != "" && (len(outputs) != 1 || filepath.Clean(outputs[0]) != filepath.Clean(out))) {
+		return nil, digest, false
+	}
+	for _, output := range outputs {
+		if _, err := os.Stat(output); err != nil {
+			return nil, digest, false
+		}
+	}
+	return outputs, digest, true
+}
+
+// fileDigest returns the hex SHA-256 digest of the content of the file at
+// path, or "" when it cannot be read, so that the file is converted and the
+// error reported then.
+func fileDigest(path string) string {
+	data, err := readInputFile(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// record records the outputs written for the source at path, with the
+// digest of its content. An empty digest keeps the digest recorded for the
+// same outputs, for a file found up to date by its modification time. A nil
+// state, without -incremental, records nothing.
+func (s *incrementalState) record(path, digest string, outputs []string) {
+	if s == nil {
+		return
+	}
+	key, ok := s.key(path)
+	if !ok {
+		return
+	}
+	entry := stateEntry{SHA256: digest, Outputs: []string{}}
+	for _, output := range outputs {
+		rel, err := relativePath(s.dir, output)
+		if err != nil {
+			delete(s.files, key)
+			return
+		}
+		entry.Outputs = append(entry.Outputs, filepath.ToSlash(rel))
+	}
+	if old, ok := s.files[key]; ok && digest == "" && equalStrings(old.Outputs, entry.Outputs) {
+		entry.SHA256 = old.SHA256
+	}
+	s.files[key] = entry
+}
+
+// forget removes the source at path from the state, for a file that failed
+// or no longer has any output, so that it is converted on the next run.
+func (s *incrementalState) forget(path string) {
+	if s == nil {
+		return
+	}
+	if key, ok := s.key(path); ok {
+		delete(s.files, key)
+	}
+}
+
+// prune removes the outputs recorded for every source that is not among
+// files, because it was deleted, renamed or excluded, and forgets the
+// source. Outputs in keep, written for the files of this run, are never
+// removed, nor are paths outside the directory of the state file. The
+// checksum files of -checksum are removed with their outputs, and so are the
+// directories they leave empty. It returns the outputs removed, in source
+// order.
+func (s *incrementalState) prune(files, keep []string) ([]string, error) {
+	current := make(map[string]bool)
+	for _, path := range files {
+		if key, ok := s.key(path); ok {
+			current[key] = true
+		}
+	}
+	kept := make(map[string]bool)
+	for _, output := range keep {
+		kept[filepath.Clean(output)] = true
+	}
+	var removed []string
+	for _, source := range sortedSources(s.files) {
+		if current[source] {
+			continue
+		}
+		for _, output := range s.files[source].Outputs {
+			path := filepath.Join(s.dir, filepath.FromSlash(output))
+			if kept[filepath.Clean(path)] || !filepath.IsLocal(filepath.FromSlash(output)) {
+				continue
+			}
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return removed, &outputError{fmt.Errorf("pruning stale output: %w", err)}
+			} else if err == nil {
+				removed = append(removed, path)
+			}
+			for algorithm := range checksumAlgorithms {
+				os.Remove(path + "." + algorithm)
+			}
+			// Remove the directories left empty, up to the state directory
+			for dir := filepath.Dir(filepath.FromSlash(output)); dir != "."; dir = filepath.Dir(dir) {
+				if os.Remove(filepath.Join(s.dir, dir)) != nil {
+					break
+				}
+			}
+		}
+		delete(s.files, source)
+	}
+	return removed, nil
+}
+
+// save writes the state file.
+func (s *incrementalState) save() error {
+	data, err := json.MarshalIndent(stateFile{Version: 1, Options: s.options, Files: s.files}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := makeOutputDir(s.dir); err != nil {
+		return &outputError{err}
+	}
+	if err := writeOutputFile(s.path(), append(data, '\n'), false); err != nil {
+		return &outputError{fmt.Errorf("writing -incremental state file: %w", err)}
+	}
+	return nil
+}
+
+// sortedSources returns the sources of files in sorted order.
+func sortedSources(files map[string]stateEntry) []string {
+	sources := make([]string, 0, len(files))
+	for source := range files {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// equalStrings reports whether a and b hold the same strings in the same
+// order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
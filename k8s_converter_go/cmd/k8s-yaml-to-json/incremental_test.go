@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIncrementalFlagSet(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "true", want: incrementalMtime},
+		{value: "mtime", want: incrementalMtime},
+		{value: "hash", want: incrementalHash},
+		{value: "false", want: ""},
+		{value: "sha1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			f := incrementalFlag{mode: incrementalHash}
+			err := f.Set(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && f.mode != tt.want {
+				t.Errorf("Set(%q) mode = %q, want %q", tt.value, f.mode, tt.want)
+			}
+		})
+	}
+}
+
+// setModTime sets the modification time of each file to t.
+func setModTime(t *testing.T, at time.Time, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIncrementalMtime(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/a.yaml":      "kind: Service\n",
+		"in/b/b.yaml":    "kind: Deployment\n",
+		"in/broken.yaml": "kind: [\n",
+	})
+	input := filepath.Join(dir, "in")
+	output := filepath.Join(dir, "out")
+	args := []string{"-input", input, "-output", output, "-incremental", "-keep-going", "-workers", "1"}
+
+	_, stderr, code := runCommand(t, "", args...)
+	if code != exitInvalid || !strings.Contains(stderr, "Converted 2 files, 0 up to date, 1 failed") {
+		t.Fatalf("first run: exit code = %d, stderr = %s", code, stderr)
+	}
+
+	// Outputs newer than their sources are not written again; failed files
+	// are always converted again
+	old := time.Now().Add(-time.Hour)
+	setModTime(t, old, filepath.Join(input, "a.yaml"), filepath.Join(input, "b", "b.yaml"))
+	setModTime(t, old.Add(time.Minute), filepath.Join(output, "a.json"), filepath.Join(output, "b", "b.json"))
+	_, stderr, code = runCommand(t, "", args...)
+	if code != exitInvalid || !strings.Contains(stderr, "Converted 0 files, 2 up to date, 1 failed") {
+		t.Errorf("second run: exit code = %d, stderr = %s", code, stderr)
+	}
+	info, err := os.Stat(filepath.Join(output, "a.json"))
+	if err != nil || !info.ModTime().Equal(old.Add(time.Minute)) {
+		t.Errorf("up-to-date output was written again: %v", err)
+	}
+
+	// A source newer than its output is converted again
+	setModTime(t, old.Add(2*time.Minute), filepath.Join(input, "a.yaml"))
+	_, stderr, _ = runCommand(t, "", args...)
+	if !strings.Contains(stderr, "Converted 1 files, 1 up to date, 1 failed") {
+		t.Errorf("touched source: stderr = %s", stderr)
+	}
+}
+
+func TestIncrementalHash(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/a.yaml":   "kind: Service\n",
+		"in/b/b.yaml": "kind: Deployment\n---\nkind: ConfigMap\n",
+	})
+	input := filepath.Join(dir, "in")
+	output := filepath.Join(dir, "out")
+	report := filepath.Join(dir, "report.json")
+	args := []string{"-input", input, "-output", output, "-incremental=hash", "-split", "-report", report}
+
+	if _, stderr, code := runCommand(t, "", args...); code != exitOK || !strings.Contains(stderr, "Converted 2 files, 0 up to date, 0 failed") {
+		t.Fatalf("first run: exit code = %d, stderr = %s", code, stderr)
+	}
+	data, err := os.ReadFile(filepath.Join(output, stateFileName))
+	if err != nil {
+		t.Fatalf("state file not written: %v", err)
+	}
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("state file is not JSON: %v", err)
+	}
+	if outputs := state.Files["b/b.yaml"].Outputs; len(outputs) != 2 || state.Files["b/b.yaml"].SHA256 == "" {
+		t.Errorf("state of b/b.yaml = %+v, want a digest and 2 outputs", state.Files["b/b.yaml"])
+	}
+
+	// A touched but unchanged file is up to date, and a changed one is
+	// converted again, replacing its own outputs without -force
+	future := time.Now().Add(time.Hour)
+	setModTime(t, future, filepath.Join(input, "a.yaml"), filepath.Join(input, "b", "b.yaml"))
+	writeTree(t, dir, map[string]string{"in/a.yaml": "kind: Service\nmetadata:\n  name: web\n"})
+	if _, stderr, code := runCommand(t, "", args...); code != exitOK || !strings.Contains(stderr, "Converted 1 files, 1 up to date, 0 failed") {
+		t.Errorf("second run: exit code = %d, stderr = %s", code, stderr)
+	}
+	var got batchReport
+	if data, err := os.ReadFile(report); err != nil || json.Unmarshal(data, &got) != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	if got.Processed != 2 || got.Converted != 1 || got.UpToDate != 1 || got.Files[1].Status != statusUpToDate || len(got.Files[1].Outputs) != 2 {
+		t.Errorf("report = %+v", got)
+	}
+
+	// A missing output makes its source out of date
+	if err := os.Remove(filepath.Join(output, "b", "doc-2.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, _ := runCommand(t, "", args...); !strings.Contains(stderr, "Converted 1 files, 1 up to date") {
+		t.Errorf("missing output: stderr = %s", stderr)
+	}
+}
+
+func TestIncrementalOptionsChanged(t *testing.T) {
+	for _, mode := range []string{incrementalMtime, incrementalHash} {
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, map[string]string{
+				"in/a.yaml": "kind: Service\nstatus:\n  ready: true\n",
+				"in/b.yaml": "kind: Deployment\n",
+			})
+			input := filepath.Join(dir, "in")
+			output := filepath.Join(dir, "out")
+			args := []string{"-input", input, "-output", output, "-incremental=" + mode}
+
+			if _, stderr, code := runCommand(t, "", args...); code != exitOK || !strings.Contains(stderr, "Converted 2 files, 0 up to date, 0 failed") {
+				t.Fatalf("first run: exit code = %d, stderr = %s", code, stderr)
+			}
+
+			// A flag that changes the output converts every file again
+			_, stderr, code := runCommand(t, "", append(args, "-clean")...)
+			if code != exitOK || !strings.Contains(stderr, "Converted 2 files, 0 up to date, 0 failed") {
+				t.Errorf("-clean: exit code = %d, stderr = %s", code, stderr)
+			}
+			if data, err := os.ReadFile(filepath.Join(output, "a.json")); err != nil || strings.Contains(string(data), "status") {
+				t.Errorf("-clean: a.json = %s, %v, want no status", data, err)
+			}
+
+			// The same flags again are up to date
+			if _, stderr, _ := runCommand(t, "", append(args, "-clean")...); !strings.Contains(stderr, "Converted 0 files, 2 up to date, 0 failed") {
+				t.Errorf("-clean again: stderr = %s", stderr)
+			}
+		})
+	}
+}
+
+func TestIncrementalPrune(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/a.yaml":     "kind: Service\n",
+		"in/old/b.yaml": "kind: Deployment\n",
+		"out/keep.json": "{}\n",
+	})
+	input := filepath.Join(dir, "in")
+	output := filepath.Join(dir, "out")
+	args := []string{"-input", input, "-output", output, "-incremental=hash", "-checksum", "sha256"}
+
+	if _, stderr, code := runCommand(t, "", args...); code != exitOK {
+		t.Fatalf("first run: exit code = %d, stderr = %s", code, stderr)
+	}
+	if err := os.RemoveAll(filepath.Join(input, "old")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without -prune the stale output is kept
+	if _, stderr, _ := runCommand(t, "", args...); strings.Contains(stderr, "pruned") {
+		t.Errorf("without -prune: stderr = %s", stderr)
+	}
+	assertDirEntries(t, output, stateFileName, "a.json", "a.json.sha256", "keep.json", "old")
+
+	_, stderr, code := runCommand(t, "", append(args, "-prune")...)
+	if code != exitOK || !strings.Contains(stderr, "Converted 0 files, 1 up to date, 0 failed, 1 stale outputs pruned") {
+		t.Errorf("-prune: exit code = %d, stderr = %s", code, stderr)
+	}
+	// Files the tool did not write are never pruned
+	assertDirEntries(t, output, stateFileName, "a.json", "a.json.sha256", "keep.json")
+}
+
+func TestIncrementalUsage(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"in/a.yaml": "kind: Service\n"})
+	input := filepath.Join(dir, "in")
+	output := filepath.Join(dir, "out")
+
+	tests := [][]string{
+		{"-input", filepath.Join(input, "a.yaml"), "-output", filepath.Join(dir, "a.json"), "-incremental"},
+		{"-input", input, "-output", "-", "-incremental"},
+		{"-input", input, "-output", output, "-incremental", "-dry-run"},
+		{"-input", input, "-output", output, "-incremental", "-split"},
+		{"-input", input, "-output", output, "-prune"},
+		{"-input", input, "-output", output, "-incremental=sha1"},
+	}
+	for _, args := range tests {
+		if _, _, code := runCommand(t, "", args...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
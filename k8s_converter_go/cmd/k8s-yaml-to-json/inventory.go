@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// inventoryFiles returns the inventory of every document of files, the files
+// of inputFile, in the inventory format of opts, -format csv, markdown or dot.
+// Files whose documents are all filtered out are skipped. The failed path is
+// returned with the error.
+func inventoryFiles(inputFile string, files []string, opts converter.Options) (data []byte, failedPath string, err error) {
+	var inventories []converter.Inventory
+	for _, path := range files {
+		input, err := readInputFile(path)
+		if err != nil {
+			return nil, path, err
+		}
+		opts.Warn = printWarning(path)
+		documents, err := converter.Decode(input, opts)
+		if errors.Is(err, converter.ErrNoMatch) {
+			continue
+		}
+		if err != nil {
+			return nil, path, err
+		}
+		inventories = append(inventories, converter.Inventory{Source: path, Documents: documents})
+	}
+	if inventories == nil {
+		return nil, inputFile, converter.ErrNoMatch
+	}
+	data, err = converter.MarshalInventory(inventories, opts)
+	if err != nil {
+		return nil, inputFile, err
+	}
+	return data, "", nil
+}
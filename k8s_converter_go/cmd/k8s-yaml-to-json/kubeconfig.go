@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	// Register the auth-provider plugins kubectl supports, such as oidc
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+)
+
+// clusterConfig is how to reach and authenticate to an API server.
+type clusterConfig struct {
+	// source describes where the configuration was loaded from, for
+	// messages.
+	source string
+	rest   *rest.Config
+	// namespace is the namespace of documents of namespaced kinds that
+	// have none.
+	namespace string
+}
+
+// kubeconfigErrorf returns the error of a kubeconfig that cannot be used,
+// a *usageError as for the config file of the tool itself.
+func kubeconfigErrorf(format string, args ...interface{}) error {
+	return &usageError{code: errorKubeconfig, message: fmt.Sprintf(format, args...)}
+}
+
+// loadClusterConfig returns the configuration of the cluster to check
+// against, with the loading rules of kubectl: the kubeconfig file at
+// explicit when it is set, or else the files listed in KUBECONFIG merged so
+// that the first to set a value wins, or else ~/.kube/config. Without any
+// kubeconfig file, the in-cluster service account is used. contextName
+// selects a context other than the current one.
+func loadClusterConfig(explicit, contextName string) (*clusterConfig, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = explicit
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: contextName})
+
+	config, err := loader.ClientConfig()
+	raw, rawErr := loader.RawConfig()
+	found := rawErr == nil && len(raw.Contexts)+len(raw.Clusters)+len(raw.AuthInfos) > 0
+	files := strings.Join(rules.GetLoadingPrecedence(), string(filepath.ListSeparator))
+	name := contextName
+	if found && name == "" {
+		name = raw.CurrentContext
+	}
+	// clientcmd reports a missing context or cluster as an empty config
+	if found {
+		context := raw.Contexts[name]
+		switch {
+		case name == "":
+			return nil, kubeconfigErrorf("kubeconfig %s has no current-context; select one with -context", files)
+		case context == nil:
+			return nil, kubeconfigErrorf("context %s not found in kubeconfig %s", name, files)
+		case raw.Clusters[context.Cluster] == nil:
+			return nil, kubeconfigErrorf("cluster %s of context %s not found in kubeconfig %s", context.Cluster, name, files)
+		}
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && explicit != "":
+		return nil, &inputError{err: fmt.Errorf("reading kubeconfig: %w", err)}
+	case clientcmd.IsEmptyConfig(err) && !found:
+		return nil, kubeconfigErrorf("no kubeconfig found and not running in a cluster: give -kubeconfig, set KUBECONFIG or create ~/.kube/config")
+	case err != nil:
+		return nil, kubeconfigErrorf("invalid kubeconfig: %v", err)
+	}
+	namespace, _, err := loader.Namespace()
+	if err != nil {
+		return nil, kubeconfigErrorf("invalid kubeconfig: %v", err)
+	}
+
+	source := "in-cluster service account"
+	if found {
+		source = fmt.Sprintf("context %s of kubeconfig %s", name, files)
+	}
+	config.Timeout = clusterTimeout
+	// Documents are checked one request after the other; the default rate
+	// limit of client-go would make large batches crawl
+	config.QPS, config.Burst = 50, 100
+	config.UserAgent = fieldManager
+	config.WarningHandler = clusterWarnings{}
+	return &clusterConfig{source: source, rest: config, namespace: namespace}, nil
+}
+
+// clusterWarnings prints the warnings of the API server, such as about
+// deprecated APIs, as the warnings of the tool.
+type clusterWarnings struct{}
+
+func (clusterWarnings) HandleWarningHeader(code int, agent, text string) {
+	if code == 299 && text != "" {
+		warnf("", "%s", text)
+	}
+}
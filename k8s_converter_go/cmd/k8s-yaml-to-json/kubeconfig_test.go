@@ -0,0 +1,117 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestLoadClusterConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"config": `current-context: dev
+contexts:
+  - name: dev
+    context: {cluster: dev, user: dev, namespace: team}
+  - name: prod
+    context: {cluster: prod, user: prod}
+  - name: dangling
+    context: {cluster: missing, user: dev}
+clusters:
+  - name: dev
+    cluster: {server: "https://dev.example.com:6443/", insecure-skip-tls-verify: true}
+  - name: prod
+    cluster: {server: "https://prod.example.com", tls-server-name: api.prod}
+users:
+  - name: dev
+    user: {tokenFile: secrets/dev-token}
+  - name: prod
+    user: {username: admin, password: secret}
+`,
+		"secrets/dev-token": "dev-token\n",
+		"other": `current-context: prod
+clusters:
+  - name: dev
+    cluster: {server: "https://shadowed.example.com"}
+`,
+		"nocurrent": "clusters: [{name: a, cluster: {server: https://a}}]\n",
+		"plugin": "current-context: a\ncontexts: [{name: a, context: {cluster: a, user: a}}]\n" +
+			"clusters: [{name: a, cluster: {server: https://a, proxy-url: http://proxy.example.com:3128}}]\n" +
+			"users: [{name: a, user: {exec: {apiVersion: client.authentication.k8s.io/v1, command: ./bin/credentials, interactiveMode: Never}}}]\n",
+		"broken":            "clusters: [\n",
+		"home/.kube/config": "current-context: home\ncontexts: [{name: home, context: {cluster: home}}]\nclusters: [{name: home, cluster: {server: https://home}}]\n",
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name       string
+		explicit   string
+		context    string
+		kubeconfig string
+		home       string
+		wantServer string
+		wantNS     string
+		wantToken  string
+		// check checks the rest of the configuration
+		check   func(t *testing.T, config *clusterConfig)
+		wantErr string
+	}{
+		{name: "explicit", explicit: path("config"), wantServer: "https://dev.example.com:6443/", wantNS: "team", wantToken: "dev-token"},
+		{name: "context", explicit: path("config"), context: "prod", wantServer: "https://prod.example.com", wantNS: "default"},
+		{name: "KUBECONFIG merges, first wins", kubeconfig: path("config") + string(filepath.ListSeparator) + path("other"), wantServer: "https://dev.example.com:6443/", wantNS: "team", wantToken: "dev-token"},
+		{name: "KUBECONFIG current context of the first file", kubeconfig: path("other") + string(filepath.ListSeparator) + path("config"), wantServer: "https://prod.example.com", wantNS: "default"},
+		{name: "KUBECONFIG skips missing files", kubeconfig: path("missing") + string(filepath.ListSeparator) + path("config"), wantServer: "https://dev.example.com:6443/", wantNS: "team", wantToken: "dev-token"},
+		{name: "home", home: path("home"), wantServer: "https://home", wantNS: "default"},
+		{
+			name: "exec plugin and proxy", explicit: path("plugin"), wantServer: "https://a", wantNS: "default",
+			check: func(t *testing.T, config *clusterConfig) {
+				// Relative commands are run from the directory of the kubeconfig
+				if exec := config.rest.ExecProvider; exec == nil || exec.Command != path("bin/credentials") {
+					t.Errorf("exec provider = %+v, want command %s", exec, path("bin/credentials"))
+				}
+				if config.rest.Proxy == nil {
+					t.Error("proxy-url ignored")
+				} else if proxy, err := config.rest.Proxy(nil); err != nil || proxy.String() != "http://proxy.example.com:3128" {
+					t.Errorf("proxy = %v, %v", proxy, err)
+				}
+			},
+		},
+		{name: "nothing", home: path("emptyhome"), wantErr: "no kubeconfig found"},
+		{name: "missing explicit file", explicit: path("missing"), wantErr: "reading kubeconfig:"},
+		{name: "unknown context", explicit: path("config"), context: "staging", wantErr: "context staging not found"},
+		{name: "missing cluster", explicit: path("config"), context: "dangling", wantErr: "cluster missing of context dangling not found"},
+		{name: "no current context", explicit: path("nocurrent"), wantErr: "has no current-context"},
+		{name: "invalid YAML", explicit: path("broken"), wantErr: "invalid kubeconfig"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KUBECONFIG", tt.kubeconfig)
+			old := clientcmd.RecommendedHomeFile
+			clientcmd.RecommendedHomeFile = filepath.Join(tt.home, ".kube", "config")
+			defer func() { clientcmd.RecommendedHomeFile = old }()
+			t.Setenv("KUBERNETES_SERVICE_HOST", "")
+			t.Setenv("KUBERNETES_SERVICE_PORT", "")
+
+			config, err := loadClusterConfig(tt.explicit, tt.context)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			got := config.rest
+			if got.Host != tt.wantServer || config.namespace != tt.wantNS || strings.TrimSpace(got.BearerToken) != tt.wantToken {
+				t.Errorf("server, namespace, token = %q, %q, %q, want %q, %q, %q", got.Host, config.namespace, got.BearerToken, tt.wantServer, tt.wantNS, tt.wantToken)
+			}
+			if tt.check != nil {
+				tt.check(t, config)
+			}
+		})
+	}
+}
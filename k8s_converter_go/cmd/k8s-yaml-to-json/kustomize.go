@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// kustomizationFileNames are the names kustomize accepts for the
+// kustomization file of a directory, in the order it looks for them.
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// kustomizationFields are the fields of a kustomization listing the files
+// converted for it, in the order they are converted.
+var kustomizationFields = []string{"resources", "patchesStrategicMerge"}
+
+// maxKustomizationDepth is how many levels of kustomizations referenced by
+// the one of the input directory are followed.
+const maxKustomizationDepth = 1
+
+// findKustomization returns the path of the kustomization file in dir, or ""
+// when dir has none.
+func findKustomization(dir string) string {
+	for _, name := range kustomizationFileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+	}
+	return ""
+}
+
+// kustomizationFiles returns the files listed by the kustomization file at
+// path, in order, instead of every YAML file of its directory as kustomize
+// would apply them. Directory entries are followed into their own
+// kustomizations, up to maxKustomizationDepth levels, and a file listed more
+// than once is returned once.
+func kustomizationFiles(path string) ([]string, error) {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, &inputError{err: err}
+	}
+	resolver := kustomizationResolver{seen: make(map[string]bool)}
+	if err := resolver.resolve(path, []string{dir}); err != nil {
+		return nil, &inputError{err: err}
+	}
+	return resolver.files, nil
+}
+
+// kustomizationResolver collects the files of nested kustomizations.
+type kustomizationResolver struct {
+	files []string
+	// seen holds the absolute paths of the files already collected.
+	seen map[string]bool
+}
+
+// resolve adds the files listed by the kustomization file at path. chain is
+// the absolute directories of the kustomizations that led to it, ending with
+// its own, and used to detect cycles.
+func (r *kustomizationResolver) resolve(path string, chain []string) error {
+	dir := filepath.Dir(path)
+
+	entries, err := readKustomization(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if isRemoteResource(entry) {
+			return fmt.Errorf("%s: remote resource '%s' is not supported; only local files and directories are", path, entry)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(entry))
+		info, err := os.Stat(target)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("%s: resource '%s' does not exist", path, entry)
+			}
+			return fmt.Errorf("%s: resource '%s': %v", path, entry, err)
+		}
+
+		absTarget, err := filepath.Abs(target)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			for i, previous := range chain {
+				if previous == absTarget {
+					cycle := append(append([]string(nil), chain[i:]...), absTarget)
+					return fmt.Errorf("%s: kustomization cycle: %s", path, strings.Join(cycle, " -> "))
+				}
+			}
+			if len(chain) > maxKustomizationDepth {
+				return fmt.Errorf("%s: directory '%s' is a kustomization nested more than %d level deep", path, entry, maxKustomizationDepth)
+			}
+			nested := findKustomization(target)
+			if nested == "" {
+				return fmt.Errorf("%s: directory '%s' has no kustomization file", path, entry)
+			}
+			if err := r.resolve(nested, append(chain, absTarget)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isYAMLFile(target) {
+			return fmt.Errorf("%s: resource '%s' is not a YAML file", path, entry)
+		}
+		if !r.seen[absTarget] {
+			r.seen[absTarget] = true
+			r.files = append(r.files, target)
+		}
+	}
+	return nil
+}
+
+// readKustomization returns the entries of the kustomizationFields of the
+// kustomization file at path.
+func readKustomization(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading kustomization: %w", err)
+	}
+	documents, err := converter.Decode(data, converter.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	object := documents[0].Value.(*converter.Object)
+	var entries []string
+	for _, name := range kustomizationFields {
+		value, ok := object.Get(name)
+		if !ok || value == nil {
+			continue
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: %s must be a list of paths", path, name)
+		}
+		for _, item := range items {
+			entry, ok := item.(string)
+			if !ok || entry == "" {
+				return nil, fmt.Errorf("%s: %s must be a list of paths", path, name)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// isRemoteResource reports whether a kustomization entry refers to a remote
+// resource, such as a URL or a git repository like
+// github.com/org/repo//deploy?ref=v1.
+func isRemoteResource(entry string) bool {
+	if strings.Contains(entry, "://") || strings.HasPrefix(entry, "git@") {
+		return true
+	}
+	for _, host := range []string{"github.com/", "gitlab.com/", "bitbucket.org/"} {
+		if strings.HasPrefix(entry, host) {
+			return true
+		}
+	}
+	return false
+}
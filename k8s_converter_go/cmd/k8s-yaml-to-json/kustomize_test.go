@@ -0,0 +1,172 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKustomizationFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		tree map[string]string
+		// dir is the directory of the kustomization, relative to the tree
+		dir     string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "Resources in order",
+			tree: map[string]string{
+				"kustomization.yaml": "resources:\n- service.yaml\n- deployment.yaml\n",
+				"deployment.yaml":    "kind: Deployment\n",
+				"service.yaml":       "kind: Service\n",
+				"unused.yaml":        "kind: ConfigMap\n",
+			},
+			want: []string{"service.yaml", "deployment.yaml"},
+		},
+		{
+			name: "Strategic merge patches after resources",
+			tree: map[string]string{
+				"kustomization.yml":     "patchesStrategicMerge:\n- patches/replicas.yaml\nresources:\n- deployment.yaml\n",
+				"deployment.yaml":       "kind: Deployment\n",
+				"patches/replicas.yaml": "kind: Deployment\n",
+			},
+			want: []string{"deployment.yaml", "patches/replicas.yaml"},
+		},
+		{
+			name: "Nested kustomization",
+			tree: map[string]string{
+				"Kustomization":               "resources:\n- namespace.yaml\n- apps/web\n- apps/web/service.yaml\n",
+				"namespace.yaml":              "kind: Namespace\n",
+				"apps/web/kustomization.yaml": "resources:\n- deployment.yaml\n- service.yaml\n",
+				"apps/web/deployment.yaml":    "kind: Deployment\n",
+				"apps/web/service.yaml":       "kind: Service\n",
+			},
+			want: []string{"namespace.yaml", "apps/web/deployment.yaml", "apps/web/service.yaml"},
+		},
+		{
+			name: "Parent directory",
+			tree: map[string]string{
+				"overlay/kustomization.yaml": "resources:\n- ../base/deployment.yaml\n",
+				"base/deployment.yaml":       "kind: Deployment\n",
+			},
+			dir:  "overlay",
+			want: []string{"base/deployment.yaml"},
+		},
+		{
+			name: "Remote resource",
+			tree: map[string]string{
+				"kustomization.yaml": "resources:\n- github.com/org/repo//deploy?ref=v1\n",
+			},
+			wantErr: "remote resource 'github.com/org/repo//deploy?ref=v1' is not supported",
+		},
+		{
+			name: "URL resource",
+			tree: map[string]string{
+				"kustomization.yaml": "resources:\n- https://example.com/deploy.yaml\n",
+			},
+			wantErr: "remote resource 'https://example.com/deploy.yaml' is not supported",
+		},
+		{
+			name: "Missing resource",
+			tree: map[string]string{
+				"kustomization.yaml": "resources:\n- missing.yaml\n",
+			},
+			wantErr: "resource 'missing.yaml' does not exist",
+		},
+		{
+			name: "Directory without kustomization",
+			tree: map[string]string{
+				"kustomization.yaml": "resources:\n- apps\n",
+				"apps/web.yaml":      "kind: Deployment\n",
+			},
+			wantErr: "directory 'apps' has no kustomization file",
+		},
+		{
+			name: "Cycle",
+			tree: map[string]string{
+				"kustomization.yaml":       "resources:\n- child\n",
+				"child/kustomization.yaml": "resources:\n- ..\n",
+			},
+			wantErr: "kustomization cycle: ",
+		},
+		{
+			name: "Nested too deep",
+			tree: map[string]string{
+				"kustomization.yaml":     "resources:\n- a\n",
+				"a/kustomization.yaml":   "resources:\n- b\n",
+				"a/b/kustomization.yaml": "resources:\n- web.yaml\n",
+				"a/b/web.yaml":           "kind: Deployment\n",
+			},
+			wantErr: "directory 'b' is a kustomization nested more than 1 level deep",
+		},
+		{
+			name: "Not a list",
+			tree: map[string]string{
+				"kustomization.yaml": "resources: deployment.yaml\n",
+			},
+			wantErr: "resources must be a list of paths",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, tt.tree)
+			files, err := kustomizationFiles(findKustomization(filepath.Join(dir, tt.dir)))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("kustomizationFiles() error = %v, want %q", err, tt.wantErr)
+				}
+				if exitCode(err) != exitInput {
+					t.Errorf("exitCode() = %d, want %d", exitCode(err), exitInput)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("kustomizationFiles() error = %v", err)
+			}
+			var got []string
+			for _, file := range files {
+				rel, _ := filepath.Rel(dir, file)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("kustomizationFiles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKustomizationInput(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app/kustomization.yaml":     "resources:\n- service.yaml\n- web\n",
+		"app/service.yaml":           "kind: Service\n",
+		"app/unused.yaml":            "kind: ConfigMap\n",
+		"app/web/kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"app/web/deployment.yaml":    "kind: Deployment\n",
+	})
+
+	output := filepath.Join(dir, "out")
+	if _, errOutput, code := runCommand(t, "", "-input", filepath.Join(dir, "app"), "-output", output); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %q", code, errOutput)
+	}
+	for _, name := range []string{"service.json", "web/deployment.json"} {
+		if _, err := os.Stat(filepath.Join(output, name)); err != nil {
+			t.Errorf("output %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"unused.json", "kustomization.json", "web/kustomization.json"} {
+		if _, err := os.Stat(filepath.Join(output, name)); err == nil {
+			t.Errorf("output %s written for a file the kustomization does not list", name)
+		}
+	}
+
+	stdoutText, errOutput, code := runCommand(t, "", "-merge-list", "-compact", "-input", filepath.Join(dir, "app"))
+	if want := `{"apiVersion":"v1","kind":"List","items":[{"kind":"Service"},{"kind":"Deployment"}]}` + "\n"; code != exitOK || stdoutText != want {
+		t.Errorf("-merge-list: exit code = %d, stdout = %q, stderr = %q, want %q", code, stdoutText, errOutput, want)
+	}
+}
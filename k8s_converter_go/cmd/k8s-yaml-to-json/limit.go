@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxSize is the default -max-size, large enough for any real
+// manifest bundle and small enough not to exhaust memory by accident.
+const defaultMaxSize = 100 << 20
+
+// maxInputSize is the largest input read, in bytes, set with -max-size. It
+// applies to files, stdin, URLs and the request bodies of -serve; 0 means no
+// limit.
+var maxInputSize int64 = defaultMaxSize
+
+// sizeUnits are the suffixes accepted by -max-size, longest first so that
+// MiB is not taken for B.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"KB", 1000}, {"MB", 1000 * 1000}, {"GB", 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+// sizeFlag is a flag holding a size in bytes, written as a number of bytes
+// or with a unit such as 100MiB or 1GB.
+type sizeFlag int64
+
+func (s *sizeFlag) String() string {
+	return formatSize(int64(*s))
+}
+
+func (s *sizeFlag) Set(value string) error {
+	size, err := parseSize(value)
+	if err != nil {
+		return err
+	}
+	*s = sizeFlag(size)
+	return nil
+}
+
+// parseSize parses a size such as 1048576, 512KiB, 100MiB or 1GB.
+func parseSize(value string) (int64, error) {
+	number, multiplier := strings.TrimSpace(value), int64(1)
+	for _, unit := range sizeUnits {
+		if len(number) > len(unit.suffix) && strings.EqualFold(number[len(number)-len(unit.suffix):], unit.suffix) {
+			number, multiplier = strings.TrimSpace(number[:len(number)-len(unit.suffix)]), unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("invalid size %q: must be a number of bytes, optionally with a unit such as KiB, MiB or GiB", value)
+	}
+	return n * multiplier, nil
+}
+
+// formatSize writes size with the largest binary unit that divides it.
+func formatSize(size int64) string {
+	for _, unit := range []struct {
+		suffix string
+		bytes  int64
+	}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if size > 0 && size%unit.bytes == 0 {
+			return strconv.FormatInt(size/unit.bytes, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10)
+}
+
+// tooLargeError reports an input larger than -max-size.
+func tooLargeError(inputFile string) error {
+	return &inputError{code: errorTooLarge, err: fmt.Errorf("%s is %s", displayName(inputFile), tooLargeMessage(maxInputSize))}
+}
+
+// tooLargeMessage describes an input larger than the -max-size limit, as
+// for a *converter.SizeLimitError found while converting it.
+func tooLargeMessage(limit int64) string {
+	return fmt.Sprintf("larger than the -max-size limit of %s; raise -max-size to convert it", formatSize(limit))
+}
+
+// checkInputSize fails for a file larger than -max-size before any of it is
+// read. Special files such as pipes report no size and are only limited
+// while they are read.
+func checkInputSize(inputFile string) error {
+	if maxInputSize == 0 {
+		return nil
+	}
+	if info, err := os.Stat(inputFile); err == nil && info.Mode().IsRegular() && info.Size() > maxInputSize {
+		return tooLargeError(inputFile)
+	}
+	return nil
+}
+
+// limitedReader reads at most maxInputSize bytes from an input, failing with
+// tooLargeError if the input holds more, rather than truncating it as
+// io.LimitReader does.
+type limitedReader struct {
+	io.ReadCloser
+	inputFile string
+	remaining int64
+}
+
+// limitInput limits input, named inputFile, to maxInputSize bytes.
+func limitInput(inputFile string, input io.ReadCloser) io.ReadCloser {
+	if maxInputSize == 0 {
+		return input
+	}
+	return &limitedReader{ReadCloser: input, inputFile: inputFile, remaining: maxInputSize}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Read past the limit to tell an input of exactly the limit from a
+		// larger one
+		var probe [1]byte
+		n, err := io.ReadFull(l.ReadCloser, probe[:])
+		if n > 0 {
+			return 0, tooLargeError(l.inputFile)
+		}
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
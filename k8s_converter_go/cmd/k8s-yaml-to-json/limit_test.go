@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "0", want: 0},
+		{value: "1048576", want: 1 << 20},
+		{value: "512KiB", want: 512 << 10},
+		{value: "100MiB", want: 100 << 20},
+		{value: "2gib", want: 2 << 30},
+		{value: "10 MB", want: 10 * 1000 * 1000},
+		{value: "1KB", want: 1000},
+		{value: "64B", want: 64},
+		{value: "", wantErr: true},
+		{value: "MiB", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "1.5MiB", wantErr: true},
+		{value: "10XB", wantErr: true},
+		{value: "9999999999GiB", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{0: "0", 1000: "1000", 1 << 10: "1KiB", 100 << 20: "100MiB", 3 << 30: "3GiB", 1536 << 10: "1536KiB"}
+	for size, want := range tests {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestLimitInput(t *testing.T) {
+	defer func(size int64) { maxInputSize = size }(maxInputSize)
+	maxInputSize = 8
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "Smaller than the limit", input: "kind: A"},
+		{name: "Exactly the limit", input: "kind: AB"},
+		{name: "Larger than the limit", input: "kind: ABC", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(limitInput("app.yaml", io.NopCloser(strings.NewReader(tt.input))))
+			var inputErr *inputError
+			if tt.wantErr {
+				if !errors.As(err, &inputErr) || inputErr.code != errorTooLarge || !strings.Contains(err.Error(), "app.yaml is larger than the -max-size limit of 8") {
+					t.Errorf("ReadAll() error = %v, want a -max-size error", err)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.input {
+				t.Errorf("ReadAll() = %q, %v, want %q", got, err, tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// collectDocuments decodes every input in order and returns their documents,
+// in file order within each input. Directory and glob inputs contribute their
+// YAML files in lexical order. Files with no document matching the filters of
+// opts are skipped, and converter.ErrNoMatch is returned if every file was.
+// On failure, the path of the failing file is returned with the error.
+func collectDocuments(inputs []string, opts converter.Options) (documents []interface{}, failedPath string, err error) {
+	matched := false
+	for _, input := range inputs {
+		_, files, _, err := expandInput(input)
+		if err != nil {
+			return nil, input, err
+		}
+		if files == nil {
+			files = []string{input}
+		}
+		for _, path := range files {
+			data, err := readInputFile(path)
+			if err != nil {
+				return nil, path, err
+			}
+			opts.Warn = printWarning(path)
+			decoded, err := converter.Decode(data, opts)
+			if errors.Is(err, converter.ErrNoMatch) {
+				continue
+			}
+			if err != nil {
+				return nil, path, err
+			}
+			matched = true
+			documents = append(documents, converter.Values(decoded)...)
+		}
+	}
+	if !matched {
+		return nil, inputs[0], converter.ErrNoMatch
+	}
+	return documents, "", nil
+}
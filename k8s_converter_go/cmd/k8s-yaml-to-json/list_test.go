@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestCollectDocuments(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"b.yaml":         "kind: Deployment\nmetadata:\n  name: b1\n---\nkind: Service\nmetadata:\n  name: b2\n",
+		"a.yaml":         "apiVersion: v1\nkind: List\nitems:\n  - kind: ConfigMap\n    metadata:\n      name: a1\n",
+		"more/c.yaml":    "kind: Secret\nmetadata:\n  name: c1\n",
+		"more/d.yml":     "kind: Service\nmetadata:\n  name: d1\n",
+		"broken/x.yaml":  "This is not valid: YAML: content\n",
+		"unmatched.yaml": "kind: Namespace\nmetadata:\n  name: n1\n",
+	})
+
+	// Files in argument order, then documents in file order
+	inputs := []string{filepath.Join(dir, "b.yaml"), filepath.Join(dir, "a.yaml"), filepath.Join(dir, "more")}
+	documents, _, err := collectDocuments(inputs, converter.Options{})
+	if err != nil {
+		t.Fatalf("collectDocuments() error = %v", err)
+	}
+	got, err := json.Marshal(converter.NewList(documents))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"apiVersion":"v1","kind":"List","items":[{"kind":"Deployment","metadata":{"name":"b1"}},` +
+		`{"kind":"Service","metadata":{"name":"b2"}},{"kind":"ConfigMap","metadata":{"name":"a1"}},` +
+		`{"kind":"Secret","metadata":{"name":"c1"}},{"kind":"Service","metadata":{"name":"d1"}}]}`
+	if string(got) != want {
+		t.Errorf("merged list = %s, want %s", got, want)
+	}
+
+	// Files with no matching documents are skipped
+	inputs = []string{filepath.Join(dir, "unmatched.yaml"), filepath.Join(dir, "more")}
+	documents, _, err = collectDocuments(inputs, converter.Options{Kinds: []string{"Service"}})
+	if err != nil || len(documents) != 1 {
+		t.Errorf("collectDocuments() = %d documents, %v, want 1 document", len(documents), err)
+	}
+	_, _, err = collectDocuments(inputs[:1], converter.Options{Kinds: []string{"Service"}})
+	if !errors.Is(err, converter.ErrNoMatch) {
+		t.Errorf("collectDocuments() error = %v, want ErrNoMatch", err)
+	}
+
+	// The failing file is reported
+	broken := filepath.Join(dir, "broken", "x.yaml")
+	_, failedPath, err := collectDocuments([]string{filepath.Join(dir, "a.yaml"), broken}, converter.Options{})
+	if err == nil || failedPath != broken {
+		t.Errorf("collectDocuments() = %q, %v, want error for %s", failedPath, err, broken)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Converted output is the only thing written to stdout, so that it can be
+// piped into tools such as jq. Every diagnostic, including success messages,
+// goes to stderr.
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+// quiet suppresses success messages, set with -quiet.
+var quiet bool
+
+// verbosity is the level of progress logging, 1 with -v and 2 with -vv. At
+// level 1 every file is logged when it is started and finished, with the
+// time it took, and every file skipped with the reason; level 2 adds the
+// details of each step, such as where the options came from.
+var verbosity int
+
+// jsonLogs writes log lines, success messages and warnings as JSON objects,
+// set with -log-format json.
+var jsonLogs bool
+
+// Values of -log-format.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// Log levels of the JSON log lines.
+const (
+	levelInfo    = "info"
+	levelWarning = "warning"
+	levelDebug   = "debug"
+)
+
+// logMu keeps the lines logged by concurrent batch workers whole.
+var logMu sync.Mutex
+
+// logf prints a diagnostic line to stderr.
+func logf(format string, args ...interface{}) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	fmt.Fprintf(stderr, format+"\n", args...)
+}
+
+// successf prints a success message to stderr unless -quiet is set.
+func successf(format string, args ...interface{}) {
+	if !quiet {
+		infof(format, args...)
+	}
+}
+
+// infof prints an informational message to stderr, such as the address a
+// server listens on.
+func infof(format string, args ...interface{}) {
+	logEvent(levelInfo, fmt.Sprintf(format, args...))
+}
+
+// warnf prints a warning about the file at path to stderr, as "Warning:
+// path: message" or with -log-format json an object with a file field. An
+// empty path is a warning about no file in particular.
+func warnf(path, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	switch {
+	case jsonLogs && path != "":
+		logEvent(levelWarning, message, logAttr{"file", path})
+	case jsonLogs:
+		logEvent(levelWarning, message)
+	case path != "":
+		logf("Warning: %s: %s", path, message)
+	default:
+		logf("Warning: %s", message)
+	}
+}
+
+// verbosef logs a progress message when verbosity is at least level, with
+// attrs as the fields of its JSON log line. Level 1 messages are logged at
+// the info level and level 2 messages at the debug level.
+func verbosef(level int, attrs []logAttr, format string, args ...interface{}) {
+	if verbosity < level {
+		return
+	}
+	jsonLevel := levelInfo
+	if level > 1 {
+		jsonLevel = levelDebug
+	}
+	logEvent(jsonLevel, fmt.Sprintf(format, args...), attrs...)
+}
+
+// logSkip logs, with -v, that the file or directory at path is skipped
+// without being read, and why.
+func logSkip(path, reason string) {
+	verbosef(1, []logAttr{{"file", path}, {"reason", reason}}, "Skipping %s: %s", path, reason)
+}
+
+// verbosityFlag is the -v or -vv flag, raising verbosity to level when set.
+type verbosityFlag struct {
+	level int
+}
+
+func (f *verbosityFlag) IsBoolFlag() bool { return true }
+
+func (f *verbosityFlag) String() string {
+	return strconv.FormatBool(f.level > 0 && verbosity >= f.level)
+}
+
+func (f *verbosityFlag) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on {
+		verbosity = max(verbosity, f.level)
+	} else if verbosity >= f.level {
+		verbosity = f.level - 1
+	}
+	return nil
+}
+
+// logAttr is a field of a JSON log line.
+type logAttr struct {
+	key   string
+	value interface{}
+}
+
+// durationAttr returns the duration_ms field of a JSON log line.
+func durationAttr(d time.Duration) logAttr {
+	return logAttr{"duration_ms", milliseconds(d)}
+}
+
+// logEvent prints message to stderr, or with -log-format json a single-line
+// JSON object with the time, level and message followed by attrs.
+func logEvent(level, message string, attrs ...logAttr) {
+	if !jsonLogs {
+		logf("%s", message)
+		return
+	}
+	var line bytes.Buffer
+	fields := append([]logAttr{
+		{"time", time.Now().UTC().Format(time.RFC3339Nano)},
+		{"level", level},
+		{"msg", message},
+	}, attrs...)
+	line.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.key)
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(field.value))
+		}
+		line.Write(key)
+		line.WriteByte(':')
+		line.Write(value)
+	}
+	line.WriteString("}\n")
+
+	logMu.Lock()
+	defer logMu.Unlock()
+	stderr.Write(line.Bytes())
+}
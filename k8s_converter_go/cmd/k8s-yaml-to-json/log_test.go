@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setLogging sets verbosity and -log-format json for the rest of the test.
+func setLogging(t *testing.T, level int, json bool) {
+	t.Helper()
+	savedLevel, savedJSON := verbosity, jsonLogs
+	t.Cleanup(func() { verbosity, jsonLogs = savedLevel, savedJSON })
+	verbosity, jsonLogs = level, json
+}
+
+func TestVerbosityFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{args: nil, want: 0},
+		{args: []string{"-v"}, want: 1},
+		{args: []string{"-vv"}, want: 2},
+		{args: []string{"-vv", "-v"}, want: 2},
+		{args: []string{"-v", "-vv=false"}, want: 1},
+		{args: []string{"-vv", "-v=false"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			setLogging(t, 0, false)
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.Var(&verbosityFlag{level: 1}, "v", "")
+			flags.Var(&verbosityFlag{level: 2}, "vv", "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if verbosity != tt.want {
+				t.Errorf("verbosity = %d, want %d", verbosity, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerbosef(t *testing.T) {
+	attrs := []logAttr{{"file", "web.yaml"}, durationAttr(1500 * time.Microsecond)}
+	tests := []struct {
+		name      string
+		verbosity int
+		json      bool
+		want      []string
+	}{
+		{name: "Default", verbosity: 0},
+		{name: "Verbose", verbosity: 1, want: []string{"Converting web.yaml"}},
+		{name: "Very verbose", verbosity: 2, want: []string{"Converting web.yaml", "Read web.yaml"}},
+		{
+			name:      "JSON",
+			verbosity: 2,
+			json:      true,
+			want: []string{
+				`"level":"info","msg":"Converting web.yaml","file":"web.yaml","duration_ms":1.5}`,
+				`"level":"debug","msg":"Read web.yaml","file":"web.yaml","duration_ms":1.5}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setLogging(t, tt.verbosity, tt.json)
+			errOutput := captureStderr(t)
+			verbosef(1, attrs, "Converting %s", "web.yaml")
+			verbosef(2, attrs, "Read %s", "web.yaml")
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSuffix(errOutput.String(), "\n"), "\n") {
+				if line == "" {
+					continue
+				}
+				if tt.json {
+					// Drop the time, checked by TestLogEventJSON
+					_, line, _ = strings.Cut(line, `Z",`)
+				}
+				got = append(got, line)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("log lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogEventJSON(t *testing.T) {
+	setLogging(t, 0, true)
+	errOutput := captureStderr(t)
+	warnf("web.yaml", "skipping %s", "file")
+	warnf("", "no file")
+	successf("Converted %d files", 2)
+
+	lines := strings.Split(strings.TrimSpace(errOutput.String()), "\n")
+	want := []map[string]interface{}{
+		{"level": "warning", "msg": "skipping file", "file": "web.yaml"},
+		{"level": "warning", "msg": "no file"},
+		{"level": "info", "msg": "Converted 2 files"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("log lines = %q, want %d lines", lines, len(want))
+	}
+	for i, line := range lines {
+		var got map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, got["time"].(string)); err != nil {
+			t.Errorf("line %q: time: %v", line, err)
+		}
+		delete(got, "time")
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("line %d = %v, want %v", i+1, got, want[i])
+		}
+	}
+}
+
+func TestWarnfText(t *testing.T) {
+	setLogging(t, 0, false)
+	errOutput := captureStderr(t)
+	warnf("web.yaml", "skipping %s", "file")
+	warnf("", "no file")
+	if want := "Warning: web.yaml: skipping file\nWarning: no file\n"; errOutput.String() != want {
+		t.Errorf("stderr = %q, want %q", errOutput, want)
+	}
+}
@@ -0,0 +1,1359 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// stdinInput is the -input value that selects standard input.
+const stdinInput = "-"
+
+// stdoutOutput is the -output value that selects standard output, for
+// directory and glob input too.
+const stdoutOutput = "-"
+
+// teeOutput is the -tee setting: output written to a file is also printed to
+// stdout.
+var teeOutput bool
+
+// stdinIsPiped reports whether stdin is a pipe or file rather than a terminal.
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// displayName returns the name used for an input in messages.
+func displayName(inputFile string) string {
+	if inputFile == stdinInput {
+		return "<stdin>"
+	}
+	return inputFile
+}
+
+// openInput opens the named input file, the URL when inputFile is an http or
+// https URL, stdin when inputFile is "-", or the archive entry read into
+// archiveFiles. Reading more than -max-size bytes from the input fails.
+// Errors are *inputError.
+func openInput(inputFile string) (io.ReadCloser, error) {
+	if data, ok := archiveFiles[inputFile]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	if inputFile == stdinInput {
+		return limitInput(inputFile, io.NopCloser(os.Stdin)), nil
+	}
+	var input io.ReadCloser
+	var err error
+	if isURL(inputFile) {
+		input, err = openURL(inputFile)
+	} else if err = checkInputSize(inputFile); err != nil {
+		return nil, err
+	} else {
+		input, err = os.Open(inputFile)
+	}
+	if err != nil {
+		return nil, &inputError{err: fmt.Errorf("reading input file: %w", err)}
+	}
+	return limitInput(inputFile, input), nil
+}
+
+// readInputFile reads the whole of the input opened by openInput.
+func readInputFile(inputFile string) ([]byte, error) {
+	input, err := openInput(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, &inputError{err: fmt.Errorf("reading input file: %w", err)}
+	}
+	return data, nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command with the given arguments and returns its exit code.
+// Every failure is reported through reportError, which picks the exit code
+// from the class of the error.
+func run(args []string) int {
+	// Define command line flags
+	flags := flag.NewFlagSet("k8s-yaml-to-json", flag.ContinueOnError)
+	var inputs repeatedFlag
+	flags.Var(&inputs, "input", "Input YAML file, directory, tar or zip archive, glob pattern or http(s) URL, or - for stdin (JSON file path in reverse mode); repeatable with -merge-list")
+	outputFile := flags.String("output", "", "Output JSON file path, or directory for directory and glob input, or - for stdout (optional, will print to stdout if not specified)")
+	archiveFlag := flags.String("archive", "", "Write every output into a single -output archive, tar or zip, instead of separate files; chosen by the .tar, .tar.gz, .tgz or .zip extension of -output when not given")
+	flags.BoolVar(&teeOutput, "tee", false, "Print the output to stdout as well as writing it to the -output file or directory")
+	inPlace := flags.Bool("in-place", false, "Write each foo.yaml input to foo.json next to it instead of to -output or stdout, for a single file or every file of a directory or glob")
+	separate := flags.Bool("separate", false, "Emit each YAML document as a separate JSON document instead of a JSON array")
+	compact := flags.Bool("compact", false, "Emit compact JSON on a single line per document instead of indented JSON")
+	format := flags.String("format", converter.FormatJSON, "Output format: json, ndjson for one compact JSON document per line, go for Go source declaring a map literal per document, hcl for a Terraform kubernetes_manifest resource per document, csv for an inventory with a row per document, markdown for a summary table, or dot for a Graphviz graph of the references between resources")
+	var csvColumns listFlag
+	flags.Var(&csvColumns, "csv-columns", "With -format csv, add a column for each of these paths, such as spec.replicas (repeatable or comma-separated)")
+	goPackage := flags.String("go-package", converter.DefaultGoPackage, "Package clause of -format go output")
+	sortKeys := flags.Bool("sort-keys", false, "Sort the keys of every object instead of keeping the order they appear in the YAML")
+	canonical := flags.Bool("canonical", false, "Emit canonical JSON for hashing and caching: sorted keys, no whitespace, normalized numbers and a final newline, identical for the same content however the YAML is formatted")
+	flatten := flags.Bool("flatten", false, "Write each document as a flat object mapping paths such as spec.containers[0].image to their values")
+	explodeList := flags.Bool("explode-list", false, "Treat each item of a v1 List or other *List document as a document of its own")
+	var kinds listFlag
+	var warnDeprecated deprecatedFlag
+	flags.Var(&kinds, "kind", "Keep only documents of this kind, ignoring case (repeatable or comma-separated)")
+	namespace := flags.String("namespace", "", "Keep only documents in this metadata.namespace")
+	name := flags.String("name", "", "Keep only documents whose metadata.name matches this glob pattern, such as web-*")
+	selector := flags.String("selector", "", "Keep only documents whose labels match this selector, such as app=web,tier!=cache or env in (prod,staging)")
+	sortByApplyOrder := flags.Bool("sort-by-apply-order", false, "Sort documents and list items into the order they can be applied in: Namespaces and CRDs first, then RBAC, configuration, workloads, Services and Ingresses")
+	query := flags.String("query", "", "Emit only the value at this path in each document, such as .spec.containers[0].image")
+	raw := flags.Bool("raw", false, "With -query, print string values without JSON quotes")
+	indentFlag := flags.String("indent", "2", "JSON indentation: a number of spaces, or tab")
+	rejectNonStringKeys := flags.Bool("reject-non-string-keys", false, "Fail on YAML map keys that are not strings instead of converting them to strings")
+	strictKeys := flags.Bool("strict-keys", false, "Fail on duplicate YAML mapping keys instead of warning")
+	kubernetesStrict := flags.Bool("k8s-strict", false, "Require apiVersion, kind and metadata.name in every document")
+	validateNames := flags.Bool("validate-names", false, "Check metadata.name, metadata.generateName, metadata.namespace and container names against the DNS-1123 rules of the API server")
+	validateImages := flags.Bool("validate-images", false, "Check the image of every container against the Docker reference grammar, warning about images with neither a tag nor a digest")
+	validateQuantities := flags.Bool("validate-quantities", false, "Check the resource requests and limits of containers and pods against the Kubernetes quantity grammar")
+	validateLabels := flags.Bool("validate-labels", false, "Check label and annotation keys and label values in metadata, spec.selector and pod templates against the rules of the API server")
+	schemaValidate := flags.Bool("schema-validate", false, "Check every document against the Kubernetes OpenAPI schema of its apiVersion and kind, reporting unknown fields and wrong types")
+	strictFields := flags.Bool("strict-fields", false, "Report fields missing from the schema of their document's kind, which the API server would drop, with the nearest known field as a suggestion")
+	schemaDir := flags.String("schema-dir", "", "Directory of OpenAPI schema files, such as the Kubernetes swagger.json, to use with -schema-validate or -strict-fields instead of the embedded Kubernetes "+converter.SchemaKubernetesVersion+" schemas")
+	crdDir := flags.String("crd", "", "Directory of CustomResourceDefinition YAML files whose openAPIV3Schema validates matching custom resources (implies -schema-validate)")
+	requireSchema := flags.Bool("require-schema", false, "With -schema-validate, -strict-fields or -crd, fail on documents of kinds without a schema instead of skipping them")
+	split := flags.Bool("split", false, "Write each YAML document to its own JSON file in the -output directory")
+	nameTemplateText := flags.String("name-template", "", "Go template naming each -split output file under the -output directory, with .Kind, .Name, .Namespace, .APIVersion, .SourceBase and .Index, such as '{{.Kind | lower}}-{{.Name}}.json'")
+	helmPlaceholders := flags.Bool("helm-placeholders", false, "Replace Helm template actions such as {{ .Values.image }} with placeholders before converting")
+	envSubst := flags.Bool("env-subst", false, "Expand ${VAR} and ${VAR:-default} environment variable references before converting")
+	envAllowlist := flags.String("env-allowlist", "", "Comma-separated environment variables that -env-subst may expand (default all)")
+	valuesFile := flags.String("values", "", "YAML file of values substituted for ${values.a.b} references in the input, such as ${values.image.tag}")
+	var setValues repeatedFlag
+	flags.Var(&setValues, "set-value", "Set the value at a dotted key for ${values.a.b} references, such as image.tag=1.25, overriding -values and parsing the value as a YAML scalar (repeatable)")
+	yaml11Bools := flags.Bool("yaml11-bools", false, "Convert unquoted yes, no, on and off values to booleans as YAML 1.1 does")
+	warnYAML11Bools := flags.Bool("warn-yaml11-bools", false, "Warn about every unquoted yes, no, on and off value, which YAML 1.1 reads as a boolean")
+	rawTimestamps := flags.Bool("raw-timestamps", false, "Keep timestamps as written instead of normalizing them to RFC 3339")
+	binary := flags.String("binary", converter.BinaryBase64, "Encoding of !!binary values: base64, hex, or error to reject them")
+	emptyDocuments := flags.String("empty-docs", converter.EmptyDocumentsSkip, "What to do with empty documents: skip them, error to reject them, or null to write them as JSON null")
+	noAliases := flags.Bool("no-aliases", false, "Fail on YAML aliases (*name) instead of expanding them")
+	maxAliasNodes := flags.Int("max-alias-nodes", converter.DefaultMaxAliasNodes, "Most nodes that aliases and merge keys may add to a document when expanded, to reject alias bombs")
+	maxAliasDepth := flags.Int("max-alias-depth", converter.DefaultMaxAliasDepth, "Deepest nesting of aliases within the anchors they expand")
+	decodeSecrets := flags.Bool("decode-secrets", false, "Decode the base64 data of every Secret into plain-text stringData")
+	redactSecrets := flags.Bool("redact-secrets", false, "Replace the data and stringData values of every Secret with "+converter.RedactedValue)
+	keepComments := flags.Bool("keep-comments", false, "Keep YAML comments under the "+converter.CommentsKey+" key of each document, keyed by the path of the field they belong to")
+	verifyRoundTrip := flags.Bool("verify-roundtrip", false, "Check that every converted document comes back unchanged when its JSON is converted back to YAML, failing at the first value that differs")
+	flags.Var(&warnDeprecated, "warn-deprecated", "Warn about documents using deprecated or removed apiVersions, or with =1.29 only those deprecated by that Kubernetes release")
+	failDeprecated := flags.Bool("fail-deprecated", false, "Fail on documents using deprecated or removed apiVersions instead of warning, limited to the release given with -warn-deprecated")
+	typed := flags.Bool("typed", false, "Decode documents of built-in kinds into their k8s.io/api types, failing on values of the wrong type and unknown fields, and convert the typed objects")
+	expandLastApplied := flags.Bool("expand-last-applied", false, "Replace the JSON string of the kubectl.kubernetes.io/last-applied-configuration annotation with the object it describes")
+	clean := flags.Bool("clean", false, "Remove server-populated fields such as status and metadata.managedFields")
+	var deletePaths repeatedFlag
+	flags.Var(&deletePaths, "delete-path", "Remove the value at this path from every document, such as metadata.annotations.\"example.com/name\" or spec.containers[*].resources (repeatable)")
+	strictPaths := flags.Bool("strict-paths", false, "Fail on documents in which a -delete-path matches nothing")
+	var sets, setStrings repeatedFlag
+	flags.Var(&sets, "set", "Set the value at a path in every document, such as spec.replicas=5, parsing the value as a YAML scalar (repeatable)")
+	flags.Var(&setStrings, "set-string", "Like -set, but keep the value a string, such as metadata.labels.version=1.20 (repeatable)")
+	setNamespace := flags.String("set-namespace", "", "Set metadata.namespace on every document of a namespaced kind that has none, as kubectl apply -n does")
+	forceNamespace := flags.String("force-namespace", "", "Set metadata.namespace on every document of a namespaced kind, replacing the namespace it has")
+	var addLabels, addAnnotations repeatedFlag
+	flags.Var(&addLabels, "add-label", "Add the label key=value to every Kubernetes document, keeping the value of a label already set unless -override-labels is given (repeatable)")
+	flags.Var(&addAnnotations, "add-annotation", "Add the annotation key=value to every Kubernetes document, keeping the value of an annotation already set unless -override-labels is given (repeatable)")
+	overrideLabels := flags.Bool("override-labels", false, "Replace the labels and annotations already set with the values of -add-label and -add-annotation")
+	omitNull := flags.Bool("omit-null", false, "Remove every object member whose value is null, such as creationTimestamp: null")
+	omitEmpty := flags.Bool("omit-empty", false, "With -omit-null, also remove the objects and arrays left empty by removing null members")
+	workers := flags.Int("workers", runtime.NumCPU(), "Number of files converted concurrently in directory and glob conversion")
+	reportFile := flags.String("report", "", "Write a JSON summary of directory and glob conversion, with the status and duration of every file, to this file")
+	force := flags.Bool("force", false, "Overwrite existing output files, the same as -overwrite always")
+	overwrite := flags.String("overwrite", overwriteNever, "Whether to replace existing output files: never, always, or prompt to ask for each file when stdin is a terminal")
+	outputMode = 0
+	flags.Var((*modeFlag)(&outputMode), "mode", "Octal permissions of every file written, such as 0600, set whatever the umask; directories created for output get the matching search bits, such as 0700 (default 0644 less the umask, or 0600 for files that may hold Secrets decoded by -decode-secrets)")
+	dryRun := flags.Bool("dry-run", false, "Read, convert and validate the input without writing anything, printing each source -> destination pair instead")
+	diff := flags.Bool("diff", false, "Convert the input and compare it with the existing -output instead of writing it, printing the changed paths and exiting 1 if they differ")
+	diffExact := flags.Bool("diff-exact", false, "Like -diff, but compare the bytes of the output, printing a unified diff")
+	checksum := flags.String("checksum", "", "Record the digest of every output file, sha256 or sha512, in a .sha256 or .sha512 file next to it; for stdout output the digest is printed to stderr")
+	checksumList := flags.Bool("checksum-list", false, "With -checksum, write every digest to "+checksumListName+" in the output directory instead of a file next to each output")
+	failFast := flags.Bool("fail-fast", false, "Stop directory conversion at the first file that fails, starting no further file (the default unless -keep-going is given)")
+	keepGoing := flags.Bool("keep-going", false, "Convert every file of directory and glob input even if some fail, writing every successful output, then list the failures and exit non-zero")
+	var incremental incrementalFlag
+	flags.Var(&incremental, "incremental", "Skip converting the files of directory and glob input whose output is newer than the file, as make does, or with =hash whose content is unchanged since the last run, as recorded in "+stateFileName+" in the output directory")
+	prune := flags.Bool("prune", false, "With -incremental, remove the outputs of files converted by an earlier run that have since been deleted or excluded")
+	noProgress := flags.Bool("no-progress", false, "Do not report the number of files converted so far while converting more than "+strconv.Itoa(progressMinFiles)+" files")
+	var excludeFlags repeatedFlag
+	flags.Var(&excludeFlags, "exclude", "Skip the files and directories of directory and glob input matching this gitignore-style pattern, relative to the input root, such as vendor/ or *-test.yaml (repeatable)")
+	flags.BoolVar(&followSymlinks, "follow-symlinks", false, "Descend into symlinked directories in directory conversion, converting each real file once and skipping links that lead back to a directory already walked")
+	validate := flags.Bool("validate", false, "Only validate the input YAML, printing PASS or FAIL per file without writing output")
+	clusterValidate := flags.Bool("cluster-validate", false, "Submit every document to the API server of the kubeconfig as a server-side dry run with strict field validation, printing whether each resource is admitted, without writing output or persisting anything")
+	clusterDiff := flags.Bool("cluster-diff", false, "Compare every document with its live object in the cluster of the kubeconfig, printing the changed paths of each resource that drifted and the resources that would be created, and exiting 1 if any; nothing is written to the cluster")
+	kubeconfigFlag := flags.String("kubeconfig", "", "Kubeconfig file for -cluster-validate and -cluster-diff, instead of KUBECONFIG, ~/.kube/config or the in-cluster service account")
+	kubeContext := flags.String("context", "", "Kubeconfig context for -cluster-validate and -cluster-diff, instead of the current context")
+	maxInputSize = defaultMaxSize
+	flags.Var((*sizeFlag)(&maxInputSize), "max-size", "Largest input to read, such as 512KiB or 1GiB, from files, stdin, URLs and -serve request bodies; 0 for no limit")
+	reverse := flags.Bool("reverse", false, "Convert JSON to YAML (enabled automatically for .json input files)")
+	normalize := flags.Bool("normalize", false, "Read the input as JSON and write it back as normalized JSON, with every other option applied, instead of converting .json input to YAML")
+	serveAddr := flags.String("serve", "", "Run an HTTP server on this address (such as :8080) that converts YAML posted to /convert")
+	flags.Var(headerFlag(requestHeader), "header", "HTTP header to send when -input is a URL, as 'Name: value' (repeatable)")
+	noExtensionCheck := flags.Bool("no-extension-check", false, "Do not require a .yaml, .yml or .json extension on the input file or URL path")
+	watch := flags.Bool("watch", false, "Watch the input file or directory and convert it again after every change")
+	errorFormat := flags.String("error-format", errorFormatText, "Format of error messages on stderr: text, or json for one JSON object per error")
+	flags.BoolVar(&quiet, "quiet", false, "Do not print success messages; errors and warnings are still printed to stderr")
+	templateFile := flags.String("template", "", "Render each document with this Go text/template file instead of converting it to JSON")
+	mergeList := flags.Bool("merge-list", false, "Merge the documents of every -input into a single v1 List")
+	configFile := flags.String("config", "", "Read default option values from this YAML file instead of "+defaultConfigFile+" in the current directory; flags given on the command line take precedence")
+	flags.Var(&verbosityFlag{level: 1}, "v", "Log the progress of every file, why files are skipped and how long each took to stderr")
+	flags.Var(&verbosityFlag{level: 2}, "vv", "Like -v, also logging the details of each step, such as where each option was set from")
+	logFormat := flags.String("log-format", logFormatText, "Format of log lines, warnings and success messages on stderr: text, or json for one JSON object per line")
+	colorFlag := flags.String("color", colorAuto, "Colorize JSON printed to stdout: auto to colorize when stdout is a terminal and NO_COLOR is not set, always, or never")
+	noEnv := flags.Bool("no-env", false, "Do not read default option values from "+envPrefix+"* environment variables, such as "+envName("compact")+"=true")
+	// Hold back the messages of the flag package until -error-format is known
+	var flagOutput bytes.Buffer
+	flags.SetOutput(&flagOutput)
+	err := flags.Parse(args)
+	flags.SetOutput(stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			stderr.Write(flagOutput.Bytes())
+			return exitOK
+		}
+		if *errorFormat == errorFormatJSON {
+			jsonErrors = true
+			return reportError("", usageErrorf(nil, "%v", err))
+		}
+		// The flag package has already printed the error and usage
+		stderr.Write(flagOutput.Bytes())
+		return exitUsage
+	}
+	// Warnings about the environment follow a -log-format given on the
+	// command line
+	jsonLogs = *logFormat == logFormatJSON
+	sources, err := applyDefaults(flags, configFile, *noEnv)
+	if err != nil {
+		jsonErrors = *errorFormat == errorFormatJSON
+		return reportError("", err)
+	}
+
+	switch *errorFormat {
+	case errorFormatText:
+	case errorFormatJSON:
+		jsonErrors = true
+	default:
+		return reportError("", usageErrorf(flags, "invalid -error-format value '%s': must be text or json", *errorFormat))
+	}
+	switch *logFormat {
+	case logFormatText, logFormatJSON:
+		jsonLogs = *logFormat == logFormatJSON
+	default:
+		return reportError("", usageErrorf(flags, "invalid -log-format value '%s': must be text or json", *logFormat))
+	}
+	logSources(sources)
+
+	var inputFile string
+	if len(inputs) > 0 {
+		inputFile = inputs[0]
+	}
+	if len(inputs) > 1 && !*mergeList {
+		return reportError(inputFile, usageErrorf(flags, "multiple -input values require -merge-list"))
+	}
+
+	// Check the indentation before reading any input
+	indent, err := parseIndent(*indentFlag)
+	if err != nil {
+		return reportError(inputFile, usageErrorf(flags, "%v", err))
+	}
+	if indent == "" {
+		*compact = true
+	}
+	switch *format {
+	case converter.FormatJSON, converter.FormatNDJSON, converter.FormatGo, converter.FormatHCL, converter.FormatCSV, converter.FormatMarkdown, converter.FormatDOT:
+	default:
+		return reportError(inputFile, usageErrorf(flags, "invalid -format value '%s': must be json, ndjson, go, hcl, csv, markdown or dot", *format))
+	}
+	inventory := *format == converter.FormatCSV || *format == converter.FormatMarkdown || *format == converter.FormatDOT
+	if !token.IsIdentifier(*goPackage) {
+		return reportError(inputFile, usageErrorf(flags, "invalid -go-package value '%s': must be a Go identifier", *goPackage))
+	}
+	if (*format == converter.FormatGo || *format == converter.FormatHCL) && (*split || *mergeList || *templateFile != "" || *diff) {
+		return reportError(inputFile, usageErrorf(flags, "-format %s cannot be used with -split, -merge-list, -template or -diff", *format))
+	}
+	if inventory && (*split || *mergeList || *templateFile != "" || *diff || *query != "" || *flatten) {
+		return reportError(inputFile, usageErrorf(flags, "-format %s cannot be used with -split, -merge-list, -template, -diff, -query or -flatten", *format))
+	}
+	if len(csvColumns) > 0 && *format != converter.FormatCSV {
+		return reportError(inputFile, usageErrorf(flags, "-csv-columns requires -format csv"))
+	}
+	if *workers < 1 {
+		return reportError(inputFile, usageErrorf(flags, "invalid -workers value %d: must be at least 1", *workers))
+	}
+	if *keepGoing && *failFast {
+		return reportError(inputFile, usageErrorf(flags, "-keep-going and -fail-fast cannot be used together"))
+	}
+	color, err := parseColor(*colorFlag)
+	if err != nil {
+		return reportError(inputFile, usageErrorf(flags, "%v", err))
+	}
+	// -output - prints to stdout, for directory and glob input too
+	toStdout := *outputFile == stdoutOutput
+	if toStdout {
+		*outputFile = ""
+	}
+	if teeOutput && (*outputFile == "" || *split || *diff || *diffExact || *dryRun || *validate || *serveAddr != "") {
+		return reportError(inputFile, usageErrorf(flags, "-tee requires an -output file or directory, other than -, and cannot be used with -split, -diff, -dry-run, -validate or -serve"))
+	}
+	archive, err := archiveFormat(*outputFile, *archiveFlag)
+	if err != nil {
+		return reportError(inputFile, usageErrorf(flags, "%v", err))
+	}
+	if archive != "" && (*outputFile == "" || *inPlace || *mergeList || *watch || *validate || *dryRun || *diff || *diffExact || *reverse || *normalize || teeOutput || *serveAddr != "" || *templateFile != "" || *reportFile != "") {
+		return reportError(inputFile, usageErrorf(flags, "-archive requires an -output file other than - and cannot be used with -in-place, -merge-list, -watch, -validate, -dry-run, -diff, -reverse, -normalize, -tee, -serve, -template or -report"))
+	}
+	if *clusterValidate && *clusterDiff {
+		return reportError(inputFile, usageErrorf(flags, "-cluster-validate and -cluster-diff cannot be used together"))
+	}
+	clusterMode := "-cluster-validate"
+	if *clusterDiff {
+		clusterMode = "-cluster-diff"
+	}
+	if (*clusterValidate || *clusterDiff) && (*outputFile != "" || toStdout || *inPlace || *split || *mergeList || *validate || *watch || *dryRun || *diff || *diffExact || *reverse || *normalize || teeOutput || *serveAddr != "" || *templateFile != "" || *checksum != "" || *reportFile != "" || incremental.mode != "") {
+		return reportError(inputFile, usageErrorf(flags, "%s writes no output and cannot be used with -output, -in-place, -split, -merge-list, -validate, -watch, -dry-run, -diff, -reverse, -normalize, -tee, -serve, -template, -checksum, -report or -incremental", clusterMode))
+	}
+	if (*kubeconfigFlag != "" || *kubeContext != "") && !*clusterValidate && !*clusterDiff {
+		return reportError(inputFile, usageErrorf(flags, "-kubeconfig and -context require -cluster-validate or -cluster-diff"))
+	}
+	if archive != "" && *format != converter.FormatJSON && *format != converter.FormatNDJSON {
+		return reportError(inputFile, usageErrorf(flags, "-archive requires -format json or ndjson"))
+	}
+	// Only JSON printed to stdout is colorized, never files
+	color = color && *outputFile == "" && !*inPlace && (*format == converter.FormatJSON || *format == converter.FormatNDJSON) && !*raw
+	excludes = nil
+	for _, pattern := range excludeFlags {
+		exclude, err := parseExclude(pattern)
+		if err != nil {
+			return reportError(inputFile, usageErrorf(flags, "%v", err))
+		}
+		excludes = append(excludes, exclude)
+	}
+	if *maxAliasNodes < 1 {
+		return reportError(inputFile, usageErrorf(flags, "invalid -max-alias-nodes value %d: must be at least 1", *maxAliasNodes))
+	}
+	if *maxAliasDepth < 1 {
+		return reportError(inputFile, usageErrorf(flags, "invalid -max-alias-depth value %d: must be at least 1", *maxAliasDepth))
+	}
+	if *raw && *query == "" {
+		return reportError(inputFile, usageErrorf(flags, "-raw requires -query"))
+	}
+	if *omitEmpty && !*omitNull {
+		return reportError(inputFile, usageErrorf(flags, "-omit-empty requires -omit-null"))
+	}
+	if *schemaDir != "" && !*schemaValidate && !*strictFields {
+		return reportError(inputFile, usageErrorf(flags, "-schema-dir requires -schema-validate or -strict-fields"))
+	}
+	if *requireSchema && !*schemaValidate && !*strictFields && *crdDir == "" {
+		return reportError(inputFile, usageErrorf(flags, "-require-schema requires -schema-validate, -strict-fields or -crd"))
+	}
+	if *decodeSecrets && *redactSecrets {
+		return reportError(inputFile, usageErrorf(flags, "-decode-secrets and -redact-secrets cannot be used together"))
+	}
+	decodedSecrets = *decodeSecrets
+	switch *overwrite {
+	case overwriteNever, overwriteAlways, overwritePrompt:
+		overwriteMode = *overwrite
+	default:
+		return reportError(inputFile, usageErrorf(flags, "invalid -overwrite value '%s': must be never, always or prompt", *overwrite))
+	}
+	if *force {
+		overwriteMode = overwriteAlways
+	}
+	if *diffExact {
+		*diff = true
+	}
+	if *inPlace && (*outputFile != "" || toStdout || *split || *mergeList || *templateFile != "" || *reverse || *serveAddr != "") {
+		return reportError(inputFile, usageErrorf(flags, "-in-place cannot be used with -output, -split, -merge-list, -template, -reverse or -serve"))
+	}
+	if *inPlace && *format != converter.FormatJSON && *format != converter.FormatNDJSON {
+		return reportError(inputFile, usageErrorf(flags, "-in-place requires -format json or ndjson"))
+	}
+	if *flatten && (*split || *mergeList || *templateFile != "" || *diff) {
+		return reportError(inputFile, usageErrorf(flags, "-flatten cannot be used with -split, -merge-list, -template or -diff"))
+	}
+	var tmpl *template.Template
+	if *templateFile != "" {
+		if *split || *reverse || *normalize || *validate || *watch || *dryRun || *diff || *mergeList || *serveAddr != "" || *query != "" {
+			return reportError(inputFile, usageErrorf(flags, "-template cannot be used with -split, -reverse, -normalize, -validate, -watch, -dry-run, -diff, -merge-list, -serve or -query"))
+		}
+		if tmpl, err = loadTemplate(*templateFile); err != nil {
+			return reportError(*templateFile, err)
+		}
+	}
+	checksums := checksumOptions{algorithm: *checksum, list: *checksumList}
+	if _, ok := checksumAlgorithms[*checksum]; !ok && *checksum != "" {
+		return reportError(inputFile, usageErrorf(flags, "invalid -checksum value '%s': must be sha256 or sha512", *checksum))
+	}
+	if *checksumList && *checksum == "" {
+		return reportError(inputFile, usageErrorf(flags, "-checksum-list requires -checksum"))
+	}
+	if *checksum != "" && (*watch || *validate || *dryRun || *diff) {
+		return reportError(inputFile, usageErrorf(flags, "-checksum cannot be used with -watch, -validate, -dry-run or -diff"))
+	}
+	if *binary != converter.BinaryBase64 && *binary != converter.BinaryHex && *binary != converter.BinaryError {
+		return reportError(inputFile, usageErrorf(flags, "invalid -binary value '%s': must be base64, hex or error", *binary))
+	}
+	switch *emptyDocuments {
+	case converter.EmptyDocumentsSkip, converter.EmptyDocumentsError, converter.EmptyDocumentsNull:
+	default:
+		return reportError(inputFile, usageErrorf(flags, "invalid -empty-docs value '%s': must be skip, error or null", *emptyDocuments))
+	}
+
+	for _, path := range deletePaths {
+		if err := converter.ValidateDeletePath(path); err != nil {
+			return reportError(inputFile, usageErrorf(flags, "%v", err))
+		}
+	}
+	if *strictPaths && len(deletePaths) == 0 {
+		return reportError(inputFile, usageErrorf(flags, "-strict-paths requires -delete-path"))
+	}
+
+	setFields, err := parseSetFields("-set", sets, false)
+	if err != nil {
+		return reportError(inputFile, usageErrorf(flags, "%v", err))
+	}
+	setStringFields, err := parseSetFields("-set-string", setStrings, true)
+	if err != nil {
+		return reportError(inputFile, usageErrorf(flags, "%v", err))
+	}
+	values, err := loadValues(*valuesFile)
+	if err != nil {
+		return reportError(*valuesFile, err)
+	}
+	if values, err = applySetValues(values, setValues); err != nil {
+		return reportError(inputFile, usageErrorf(flags, "%v", err))
+	}
+
+	if *setNamespace != "" && *forceNamespace != "" {
+		return reportError(inputFile, usageErrorf(flags, "-set-namespace and -force-namespace cannot be used together"))
+	}
+	namespaceValue := *setNamespace
+	if *forceNamespace != "" {
+		namespaceValue = *forceNamespace
+	}
+
+	labels, err := parseKeyValues("-add-label", addLabels)
+	if err != nil {
+		return reportError(inputFile, usageErrorf(flags, "%v", err))
+	}
+	annotations, err := parseKeyValues("-add-annotation", addAnnotations)
+	if err != nil {
+		return reportError(inputFile, usageErrorf(flags, "%v", err))
+	}
+	if *overrideLabels && labels == nil && annotations == nil {
+		return reportError(inputFile, usageErrorf(flags, "-override-labels requires -add-label or -add-annotation"))
+	}
+
+	opts := converter.Options{
+		Format:              *format,
+		GoPackage:           *goPackage,
+		CSVColumns:          csvColumns,
+		Source:              displayName(inputFile),
+		Separate:            *separate,
+		Compact:             *compact,
+		Indent:              indent,
+		RejectNonStringKeys: *rejectNonStringKeys,
+		StrictKeys:          *strictKeys,
+		KubernetesStrict:    *kubernetesStrict,
+		ValidateNames:       *validateNames,
+		ValidateLabels:      *validateLabels,
+		ValidateImages:      *validateImages,
+		ValidateQuantities:  *validateQuantities,
+		SchemaValidate:      *schemaValidate,
+		StrictFields:        *strictFields,
+		SchemaDir:           *schemaDir,
+		CRDDir:              *crdDir,
+		RequireSchema:       *requireSchema,
+		FileMode:            fileMode(true),
+		Typed:               *typed,
+		WarnDeprecated:      warnDeprecated.enabled,
+		KubernetesVersion:   warnDeprecated.version,
+		FailDeprecated:      *failDeprecated,
+		ExpandLastApplied:   *expandLastApplied,
+		Clean:               *clean,
+		DeletePaths:         deletePaths,
+		StrictPaths:         *strictPaths,
+		SetFields:           append(setFields, setStringFields...),
+		SetNamespace:        namespaceValue,
+		ForceNamespace:      *forceNamespace != "",
+		AddLabels:           labels,
+		AddAnnotations:      annotations,
+		OverrideLabels:      *overrideLabels,
+		OmitNull:            *omitNull,
+		OmitEmpty:           *omitEmpty,
+		KeepComments:        *keepComments,
+		VerifyRoundTrip:     *verifyRoundTrip,
+		DecodeSecrets:       *decodeSecrets,
+		RedactSecrets:       *redactSecrets,
+		HelmPlaceholders:    *helmPlaceholders,
+		EnvSubst:            *envSubst,
+		EnvAllowlist:        parseList(*envAllowlist),
+		Values:              values,
+		NoAliases:           *noAliases,
+		MaxAliasNodes:       *maxAliasNodes,
+		MaxAliasDepth:       *maxAliasDepth,
+		MaxSize:             maxInputSize,
+		SortKeys:            *sortKeys,
+		Canonical:           *canonical,
+		Flatten:             *flatten,
+		YAML11Bools:         *yaml11Bools,
+		WarnYAML11Bools:     *warnYAML11Bools,
+		RawTimestamps:       *rawTimestamps,
+		Binary:              *binary,
+		EmptyDocuments:      *emptyDocuments,
+		ExplodeLists:        *explodeList,
+		Kinds:               kinds,
+		Namespace:           *namespace,
+		Name:                *name,
+		Selector:            *selector,
+		SortByApplyOrder:    *sortByApplyOrder,
+		Query:               *query,
+		JSONInput:           *normalize,
+		Raw:                 *raw,
+	}
+
+	// Serve conversions over HTTP instead of converting an input file
+	if *serveAddr != "" {
+		if err := serve(*serveAddr, opts); err != nil {
+			return reportError(inputFile, err)
+		}
+		return exitOK
+	}
+
+	// Read from stdin when no input file is given and input is piped
+	if inputFile == "" && stdinIsPiped() {
+		inputFile = stdinInput
+	}
+
+	// Check if input file is provided
+	if inputFile == "" {
+		return reportError(inputFile, &usageError{code: errorMissingInput, message: "Input file is required", flags: flags})
+	}
+	fromStdin := inputFile == stdinInput
+
+	// Merge every input into a single v1 List
+	if *mergeList {
+		if *split || *reverse || *validate || *watch || *dryRun || *diff || *query != "" {
+			return reportError(inputFile, usageErrorf(flags, "-merge-list cannot be used with -split, -reverse, -validate, -watch, -dry-run, -diff or -query"))
+		}
+		if len(inputs) == 0 {
+			inputs = []string{inputFile}
+		}
+		documents, failedPath, err := collectDocuments(inputs, opts)
+		if err != nil {
+			return reportError(failedPath, err)
+		}
+		if *format == converter.FormatNDJSON {
+			opts.Compact = true
+		}
+		merged := []interface{}{converter.NewList(documents)}
+		if opts.SortByApplyOrder {
+			converter.SortApplyOrder(merged)
+		}
+		outputData, err := converter.MarshalDocuments(merged, opts)
+		if err != nil {
+			return reportError(failedPath, err)
+		}
+		if color {
+			defer colorStdout()()
+		}
+		return reportError(inputFile, checksums.record(*outputFile, func() error {
+			return writeOutput(*outputFile, outputData, "YAML to JSON")
+		}))
+	}
+
+	if *dryRun && (*watch || *validate) {
+		return reportError(inputFile, usageErrorf(flags, "-dry-run cannot be used with -watch or -validate"))
+	}
+	if *diff && ((*outputFile == "" && !*inPlace) || *split || *reverse || *watch || *validate || *dryRun) {
+		return reportError(inputFile, usageErrorf(flags, "-diff requires -output or -in-place and cannot be used with -split, -reverse, -watch, -validate or -dry-run"))
+	}
+	if *inPlace && (fromStdin || isURL(inputFile)) {
+		return reportError(inputFile, usageErrorf(flags, "-in-place cannot be used with stdin or URL input"))
+	}
+
+	// Watch mode needs a file or directory to watch
+	if *watch && (fromStdin || isURL(inputFile)) {
+		return reportError(inputFile, usageErrorf(flags, "-watch cannot be used with stdin or URL input"))
+	}
+
+	// Split mode writes into an output directory and only converts YAML
+	if *split && (*outputFile == "" || *reverse) {
+		return reportError(inputFile, usageErrorf(flags, "-split requires an -output directory and cannot be used with -reverse"))
+	}
+	if *query != "" && (*split || *reverse) {
+		return reportError(inputFile, usageErrorf(flags, "-query cannot be used with -split or -reverse"))
+	}
+	if *nameTemplateText != "" {
+		if !*split {
+			return reportError(inputFile, usageErrorf(flags, "-name-template requires -split"))
+		}
+		if nameTemplate, err = parseNameTemplate(*nameTemplateText); err != nil {
+			return reportError(inputFile, usageErrorf(flags, "invalid -name-template: %v", err))
+		}
+	}
+
+	// Expand directory and glob input into the list of files to process
+	root, files, skipped, err := expandInput(inputFile)
+	if err != nil {
+		return reportError(inputFile, err)
+	}
+	batch := files != nil
+	// The entries of an archive have no directory to be written next to
+	if info, err := os.Stat(root); batch && err == nil && info.Mode().IsRegular() && *outputFile == "" && !toStdout {
+		return reportError(inputFile, usageErrorf(flags, "archive input requires an -output directory, archive or -"))
+	}
+	if len(excludes) > 0 && !batch {
+		return reportError(inputFile, usageErrorf(flags, "-exclude requires directory or glob input"))
+	}
+	if tmpl != nil && batch {
+		return reportError(inputFile, usageErrorf(flags, "-template cannot be used with directory and glob input"))
+	}
+	if (*format == converter.FormatGo || *format == converter.FormatHCL) && batch {
+		return reportError(inputFile, usageErrorf(flags, "-format %s cannot be used with directory and glob input", *format))
+	}
+	if *normalize && (batch || *reverse) {
+		return reportError(inputFile, usageErrorf(flags, "-normalize cannot be used with -reverse or directory and glob input"))
+	}
+
+	if toStdout && batch && (*checksum != "" || *dryRun) {
+		return reportError(inputFile, usageErrorf(flags, "-checksum and -dry-run cannot be used with -output - for directory and glob input"))
+	}
+	if *reportFile != "" && (!batch || *validate) {
+		return reportError(inputFile, usageErrorf(flags, "-report requires directory or glob input and cannot be used with -validate"))
+	}
+	if incremental.mode != "" && (!batch || toStdout || archive != "" || *validate || *dryRun || *diff || *diffExact || teeOutput || inventory) {
+		return reportError(inputFile, usageErrorf(flags, "-incremental requires directory or glob input written to files and cannot be used with -archive, -validate, -dry-run, -diff, -tee or -format csv, markdown or dot"))
+	}
+	if incremental.mode == incrementalMtime && *split {
+		return reportError(inputFile, usageErrorf(flags, "-incremental with -split requires -incremental=hash, as the output files are only known from the state file"))
+	}
+	if *prune && incremental.mode == "" {
+		return reportError(inputFile, usageErrorf(flags, "-prune requires -incremental"))
+	}
+
+	// Write every output into a single archive
+	if archive != "" {
+		if !batch && !fromStdin && !*noExtensionCheck && !isYAMLFile(trimGzipSuffix(inputPath(inputFile))) {
+			return reportError(inputFile, &usageError{
+				code:    errorBadExtension,
+				message: fmt.Sprintf("Input file '%s' does not have a .yaml or .yml extension", inputFile),
+				flags:   flags,
+			})
+		}
+		var entries int
+		failedPath := inputFile
+		err := checksums.record(*outputFile, func() error {
+			var err error
+			entries, failedPath, err = writeArchive(inputFile, root, files, *outputFile, archive, *split, opts)
+			return err
+		})
+		if err != nil {
+			return reportError(failedPath, err)
+		}
+		successf("Successfully converted YAML to JSON and saved %d entries to %s", entries, *outputFile)
+		return exitOK
+	}
+
+	// Only check the input in validate mode, never writing any output
+	if *validate {
+		if !batch {
+			files = []string{inputFile}
+		}
+		if *watch {
+			return runWatch(inputFile, func() {
+				if batch {
+					if _, files, skipped, err = expandInput(inputFile); err != nil {
+						logf("Error: %v", err)
+						return
+					}
+				}
+				validateFiles(files, opts)
+			})
+		}
+		if _, err := validateFiles(files, opts); err != nil {
+			return exitCode(err)
+		}
+		return exitOK
+	}
+
+	// Submit every document to the cluster as a dry run, never writing any
+	// output
+	if *clusterValidate {
+		if !batch {
+			files = []string{inputFile}
+		}
+		config, err := loadClusterConfig(*kubeconfigFlag, *kubeContext)
+		if err != nil {
+			return reportError(inputFile, err)
+		}
+		return exitCode(clusterValidateFiles(config, files, opts))
+	}
+
+	// Compare every document with its live object, never writing anything
+	if *clusterDiff {
+		if !batch {
+			files = []string{inputFile}
+		}
+		config, err := loadClusterConfig(*kubeconfigFlag, *kubeContext)
+		if err != nil {
+			return reportError(inputFile, err)
+		}
+		drifted, err := clusterDiffFiles(config, files, opts)
+		if err != nil {
+			return exitCode(err)
+		}
+		if drifted {
+			return exitStale
+		}
+		return exitOK
+	}
+
+	// Write a single inventory of every YAML file for directory and glob
+	// input
+	if batch && inventory {
+		if *dryRun || *reportFile != "" {
+			return reportError(inputFile, usageErrorf(flags, "-format %s cannot be used with -dry-run or -report for directory and glob input", *format))
+		}
+		direction := "YAML to CSV"
+		switch *format {
+		case converter.FormatMarkdown:
+			direction = "YAML to Markdown"
+		case converter.FormatDOT:
+			direction = "YAML to DOT"
+		}
+		writeInventory := func() (string, error) {
+			data, failedPath, err := inventoryFiles(inputFile, files, opts)
+			if err != nil {
+				return failedPath, err
+			}
+			return inputFile, checksums.record(*outputFile, func() error {
+				return writeOutput(*outputFile, data, direction)
+			})
+		}
+		if *watch {
+			return runWatch(inputFile, func() {
+				if _, files, skipped, err = expandInput(inputFile); err != nil {
+					logf("Error: %v", err)
+					return
+				}
+				if failedPath, err := writeInventory(); err != nil {
+					reportFailure("Failed to convert", failedPath, err)
+				}
+			})
+		}
+		return reportError(writeInventory())
+	}
+
+	// Convert every YAML file for directory and glob input
+	if batch {
+		batchOpts := batchOptions{
+			outputDir:   *outputFile,
+			stdout:      toStdout,
+			failFast:    !*keepGoing,
+			split:       *split,
+			workers:     *workers,
+			dryRun:      *dryRun,
+			diff:        *diff,
+			diffExact:   *diffExact,
+			listOutputs: *inPlace,
+			progress:    !*noProgress,
+			incremental: incremental.mode,
+			prune:       *prune,
+		}
+		if *watch {
+			return runWatch(inputFile, func() {
+				if _, files, skipped, err = expandInput(inputFile); err != nil {
+					logf("Error: %v", err)
+					return
+				}
+				result := convertFiles(root, files, batchOpts, opts)
+				result.unread = skipped
+				if result.failed == 0 && result.err != nil {
+					reportError(inputFile, result.err)
+					return
+				}
+				if err := reportBatch(result, *reportFile); err != nil {
+					logf("Error: %v", err)
+				}
+			})
+		}
+		result := convertFiles(root, files, batchOpts, opts)
+		result.unread = skipped
+		if result.failed == 0 && result.err != nil {
+			return reportError(inputFile, result.err)
+		}
+		// Outputs written next to their sources are listed under the input
+		// root
+		checksumDir := *outputFile
+		if checksumDir == "" {
+			checksumDir = root
+		}
+		if err := checksums.write(checksumDir, result.outputs()); err != nil {
+			return reportError(inputFile, err)
+		}
+		if err := reportBatch(result, *reportFile); err != nil {
+			return reportError(*reportFile, err)
+		}
+		if result.failed > 0 {
+			return exitCode(result.err)
+		}
+		if result.stale > 0 {
+			return exitStale
+		}
+		if result.converted == 0 && result.upToDate == 0 && result.skipped > 0 {
+			return exitCode(converter.ErrNoMatch)
+		}
+		return exitOK
+	}
+
+	// Switch to reverse mode for JSON input files
+	extensionPath := trimGzipSuffix(inputPath(inputFile))
+	if !fromStdin && !*normalize && strings.HasSuffix(strings.ToLower(extensionPath), ".json") {
+		*reverse = true
+	}
+	if *verifyRoundTrip && *reverse {
+		return reportError(inputFile, usageErrorf(flags, "-verify-roundtrip cannot be used with -reverse or JSON input"))
+	}
+	if *flatten && *reverse {
+		return reportError(inputFile, usageErrorf(flags, "-flatten cannot be used with -reverse or JSON input"))
+	}
+	if (*format == converter.FormatGo || *format == converter.FormatHCL || inventory) && *reverse {
+		return reportError(inputFile, usageErrorf(flags, "-format %s cannot be used with -reverse or JSON input", *format))
+	}
+	if *typed && *reverse {
+		return reportError(inputFile, usageErrorf(flags, "-typed cannot be used with -reverse or JSON input"))
+	}
+	if *canonical && *reverse {
+		return reportError(inputFile, usageErrorf(flags, "-canonical cannot be used with -reverse or JSON input"))
+	}
+	if tmpl != nil && *reverse {
+		return reportError(inputFile, usageErrorf(flags, "-template cannot be used with JSON input"))
+	}
+	if *inPlace && *reverse {
+		return reportError(inputFile, usageErrorf(flags, "-in-place cannot be used with -reverse or JSON input"))
+	}
+
+	// Check if file has the extension expected by the conversion mode
+	// (stdin has no file name to check)
+	if !fromStdin && !*noExtensionCheck {
+		if *reverse || *normalize {
+			if !strings.HasSuffix(strings.ToLower(extensionPath), ".json") {
+				return reportError(inputFile, &usageError{
+					code:    errorBadExtension,
+					message: fmt.Sprintf("Input file '%s' does not have a .json extension", inputFile),
+					flags:   flags,
+				})
+			}
+		} else if !isYAMLFile(extensionPath) {
+			return reportError(inputFile, &usageError{
+				code:    errorBadExtension,
+				message: fmt.Sprintf("Input file '%s' does not have a .yaml or .yml extension", inputFile),
+				flags:   flags,
+			})
+		}
+	}
+
+	// Write the output next to the input, named after it
+	if *inPlace {
+		if *outputFile, err = siblingOutputPath(inputFile); err != nil {
+			return reportError(inputFile, err)
+		}
+	}
+
+	// Compare with the existing output instead of writing it
+	if *diff {
+		if *reverse {
+			return reportError(inputFile, usageErrorf(flags, "-diff cannot be used with JSON input"))
+		}
+		opts.Warn = printWarning(inputFile)
+		stale, err := diffFile(inputFile, *outputFile, *diffExact, opts)
+		if err != nil {
+			return reportError(inputFile, err)
+		}
+		if stale {
+			return exitStale
+		}
+		successf("%s is up to date", *outputFile)
+		return exitOK
+	}
+
+	// Only show where the output would be written in a dry run
+	if *dryRun {
+		opts.Reverse = *reverse
+		opts.Warn = printWarning(inputFile)
+		return reportError(inputFile, dryRunFile(inputFile, *outputFile, *split, opts))
+	}
+
+	if color && !*reverse && tmpl == nil {
+		defer colorStdout()()
+	}
+
+	// Convert again after every change in watch mode, reporting errors
+	// without exiting
+	if *watch {
+		opts.Reverse = *reverse
+		opts.Warn = printWarning(inputFile)
+		return runWatch(inputFile, func() {
+			if err := convertOnce(inputFile, *outputFile, *split, opts); err != nil {
+				reportFailure("Failed to convert", inputFile, err)
+			}
+		})
+	}
+
+	// Stream the conversion document by document, so that memory use is
+	// bounded by the largest document
+	start := time.Now()
+	verbosef(1, []logAttr{{"file", displayName(inputFile)}}, "Converting %s", displayName(inputFile))
+	if !*reverse && !*split {
+		opts.Warn = printWarning(inputFile)
+		err := checksums.record(*outputFile, func() error {
+			if tmpl != nil {
+				return streamTemplate(inputFile, *outputFile, tmpl, opts)
+			}
+			return streamOutput(inputFile, *outputFile, opts)
+		})
+		logFinished(displayName(inputFile), err, false, time.Since(start))
+		return reportError(inputFile, err)
+	}
+
+	// Read the input
+	inputData, err := readInputFile(inputFile)
+	if err != nil {
+		logFinished(displayName(inputFile), err, false, time.Since(start))
+		return reportError(inputFile, err)
+	}
+	verbosef(2, []logAttr{{"file", displayName(inputFile)}, {"bytes", len(inputData)}, durationAttr(time.Since(start))},
+		"Read %d bytes of %s in %s", len(inputData), displayName(inputFile), formatDuration(milliseconds(time.Since(start))))
+
+	// Convert the input
+	opts.Reverse = *reverse
+	opts.Warn = printWarning(inputFile)
+
+	// Write each document to its own file in split mode
+	if *split {
+		documents, err := converter.Decode(inputData, opts)
+		if err != nil {
+			logFinished(displayName(inputFile), err, false, time.Since(start))
+			return reportError(inputFile, err)
+		}
+		paths, err := writeSplit(inputFile, documents, *outputFile, opts, make(map[string]string), false)
+		if err == nil {
+			err = checksums.write(*outputFile, paths)
+		}
+		logFinished(displayName(inputFile), err, false, time.Since(start))
+		if err != nil {
+			return reportError(inputFile, err)
+		}
+		successf("Successfully converted YAML to JSON and saved %d files to %s", len(paths), *outputFile)
+		return exitOK
+	}
+
+	// Convert JSON to YAML
+	outputData, err := converter.Convert(inputData, opts)
+	if err == nil {
+		err = checksums.record(*outputFile, func() error {
+			return writeOutput(*outputFile, outputData, "JSON to YAML")
+		})
+	}
+	logFinished(displayName(inputFile), err, false, time.Since(start))
+	return reportError(inputFile, err)
+}
+
+// parseIndent converts an -indent value, a number of spaces or "tab", into
+// the indentation string. Zero spaces means no indentation.
+func parseIndent(value string) (string, error) {
+	if strings.EqualFold(value, "tab") {
+		return "\t", nil
+	}
+	spaces, err := strconv.Atoi(value)
+	if err != nil || spaces < 0 {
+		return "", fmt.Errorf("invalid -indent value '%s': must be a non-negative number of spaces or tab", value)
+	}
+	return strings.Repeat(" ", spaces), nil
+}
+
+// parseList splits a comma-separated flag value, ignoring empty items. It
+// returns nil for an empty value.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseKeyValues parses the key=value values of the repeatable flag name,
+// split at the first "=" so that values may hold "=" themselves. Values may
+// be empty, but keys may not.
+func parseKeyValues(name string, values []string) ([]converter.KeyValue, error) {
+	var pairs []converter.KeyValue
+	for _, value := range values {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid %s value '%s': must be key=value", name, value)
+		}
+		pairs = append(pairs, converter.KeyValue{Key: strings.TrimSpace(key), Value: v})
+	}
+	return pairs, nil
+}
+
+// parseSetFields parses the path=value values of the repeatable flag name,
+// split at the first "=" outside a double-quoted field so that quoted fields
+// and values may hold "=" themselves. With isString the values are kept as
+// strings.
+func parseSetFields(name string, values []string, isString bool) ([]converter.SetField, error) {
+	var fields []converter.SetField
+	for _, value := range values {
+		path, v, ok := cutSetValue(value)
+		if !ok || strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("invalid %s value '%s': must be path=value", name, value)
+		}
+		field := converter.SetField{Path: strings.TrimSpace(path), Value: v, String: isString}
+		if err := converter.ValidateSetField(field); err != nil {
+			return nil, fmt.Errorf("invalid %s value '%s': %v", name, value, err)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// cutSetValue splits a -set value at its first "=" outside double quotes.
+func cutSetValue(value string) (path, v string, ok bool) {
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '"':
+			quoted, err := strconv.QuotedPrefix(value[i:])
+			if err != nil {
+				return "", "", false
+			}
+			i += len(quoted) - 1
+		case '=':
+			return value[:i], value[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// repeatedFlag is a flag that can be given several times, holding each value
+// in order.
+type repeatedFlag []string
+
+func (r *repeatedFlag) String() string {
+	return strings.Join(*r, ", ")
+}
+
+func (r *repeatedFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// listFlag is a flag holding a list of values, given as a comma-separated
+// list, by repeating the flag, or both.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, parseList(value)...)
+	return nil
+}
+
+// deprecatedFlag is the -warn-deprecated flag, given alone to report every
+// known deprecation or with a Kubernetes release such as 1.29.
+type deprecatedFlag struct {
+	enabled bool
+	version string
+}
+
+func (d *deprecatedFlag) String() string {
+	if !d.enabled {
+		return ""
+	}
+	if d.version == "" {
+		return "true"
+	}
+	return d.version
+}
+
+func (d *deprecatedFlag) Set(value string) error {
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		d.enabled, d.version = enabled, ""
+		return nil
+	}
+	if !converter.IsKubernetesVersion(value) {
+		return fmt.Errorf("must be a Kubernetes release such as 1.29")
+	}
+	d.enabled, d.version = true, value
+	return nil
+}
+
+// IsBoolFlag lets -warn-deprecated be given without a value.
+func (d *deprecatedFlag) IsBoolFlag() bool {
+	return true
+}
+
+// formatParseError formats a parse error as file:line:column: message, adding
+// the document number for errors past the first document of a stream.
+func formatParseError(inputFile string, err *converter.ParseError) string {
+	return formatLocated(inputFile, err.Line, err.Column, err.Document, err.Detail())
+}
+
+// formatViolation formats the one violation of a validation error, such as
+// a *converter.NameError, at the line of the document it is in, after prefix.
+func formatViolation(inputFile, prefix string, v converter.NameViolation) string {
+	document := v.Document
+	v.Document = 0
+	return formatLocated(inputFile, v.Line, 0, document, prefix+v.String())
+}
+
+// formatLocated formats message as file:line:column: message, leaving out a
+// line or column of 0, and adding the document number past the first
+// document of a stream.
+func formatLocated(inputFile string, line, column, document int, message string) string {
+	location := displayName(inputFile)
+	if line > 0 {
+		location += ":" + strconv.Itoa(line)
+		if column > 0 {
+			location += ":" + strconv.Itoa(column)
+		}
+	}
+	if document > 1 {
+		message += fmt.Sprintf(" (document %d)", document)
+	}
+	return location + ": " + message
+}
+
+// describeError describes an error for an input file on a single line,
+// including the file name, or on a line per problem when err lists several.
+func describeError(inputFile string, err error) string {
+	if list := problems(inputFile, err); len(list) > 1 {
+		return describeProblems(inputFile, list)
+	}
+	var templateErr *converter.TemplateError
+	if errors.As(err, &templateErr) {
+		return fmt.Sprintf("%s:%d: %s", displayName(inputFile), templateErr.Line, templateErr.Hint())
+	}
+	var parseErr *converter.ParseError
+	if errors.As(err, &parseErr) {
+		return formatParseError(inputFile, parseErr)
+	}
+	var sizeErr *converter.SizeLimitError
+	if errors.As(err, &sizeErr) {
+		return fmt.Sprintf("%s: the input is %s", displayName(inputFile), tooLargeMessage(sizeErr.Limit))
+	}
+	var aliasErr *converter.AliasError
+	if errors.As(err, &aliasErr) {
+		return formatLocated(inputFile, aliasErr.Line, aliasErr.Column, aliasErr.Document, aliasErr.Detail())
+	}
+	var aliasLimitErr *converter.AliasLimitError
+	if errors.As(err, &aliasLimitErr) {
+		return formatLocated(inputFile, aliasLimitErr.Line, aliasLimitErr.Column, aliasLimitErr.Document, aliasLimitErr.Detail())
+	}
+	var nameErr *converter.NameError
+	if errors.As(err, &nameErr) && len(nameErr.Violations) == 1 {
+		return formatViolation(inputFile, "invalid name: ", nameErr.Violations[0])
+	}
+	var labelErr *converter.LabelError
+	if errors.As(err, &labelErr) && len(labelErr.Violations) == 1 {
+		return formatViolation(inputFile, "invalid label: ", labelErr.Violations[0])
+	}
+	var imageErr *converter.ImageError
+	if errors.As(err, &imageErr) && len(imageErr.Violations) == 1 {
+		return formatViolation(inputFile, "invalid image: ", imageErr.Violations[0])
+	}
+	var quantityErr *converter.QuantityError
+	if errors.As(err, &quantityErr) && len(quantityErr.Violations) == 1 {
+		return formatViolation(inputFile, "invalid quantity: ", quantityErr.Violations[0])
+	}
+	var deprecatedErr *converter.DeprecatedAPIError
+	if errors.As(err, &deprecatedErr) {
+		return fmt.Sprintf("%s:%d: %v", displayName(inputFile), deprecatedErr.Line, err)
+	}
+	var clusterErr *clusterError
+	if errors.As(err, &clusterErr) {
+		return fmt.Sprintf("%s:%d: %v", displayName(inputFile), clusterErr.line, err)
+	}
+	return fmt.Sprintf("%s: %v", displayName(inputFile), err)
+}
+
+// printWarning returns a function that prints conversion warnings for
+// inputFile to stderr.
+func printWarning(inputFile string) func(converter.Warning) {
+	return func(w converter.Warning) {
+		if jsonLogs {
+			attrs := []logAttr{{"file", displayName(inputFile)}}
+			if w.Line > 0 {
+				attrs = append(attrs, logAttr{"line", w.Line})
+			}
+			if w.Document > 0 {
+				attrs = append(attrs, logAttr{"document", w.Document})
+			}
+			logEvent(levelWarning, w.Message, attrs...)
+			return
+		}
+		location := displayName(inputFile)
+		if w.Line > 0 {
+			location += ":" + strconv.Itoa(w.Line)
+		}
+		message := w.Message
+		if w.Document > 1 {
+			message += fmt.Sprintf(" (document %d)", w.Document)
+		}
+		logf("Warning: %s: %s", location, message)
+	}
+}
+
+// streamOutput converts inputFile with converter.ConvertStream, writing each
+// document to outputFile or stdout as soon as it has been decoded. The output
+// file is removed if the conversion fails.
+func streamOutput(inputFile, outputFile string, opts converter.Options) error {
+	// NDJSON, Go, HCL and canonical output already end with a newline
+	newline := opts.Format == converter.FormatJSON && !opts.Canonical
+	return streamTo(inputFile, outputFile, "YAML to JSON", newline, func(r io.Reader, w io.Writer) error {
+		return converter.ConvertStream(r, w, opts)
+	})
+}
+
+// streamTemplate renders every document of inputFile with tmpl, writing the
+// results to outputFile or stdout in document order.
+func streamTemplate(inputFile, outputFile string, tmpl *template.Template, opts converter.Options) error {
+	return streamTo(inputFile, outputFile, "YAML with template "+tmpl.Name(), false, func(r io.Reader, w io.Writer) error {
+		return renderTemplate(r, w, tmpl, opts)
+	})
+}
+
+// streamTo opens inputFile and runs convert from it to outputFile, or to
+// stdout followed by a newline when newline is set. The output file is
+// written atomically and keeps its old content if convert fails.
+func streamTo(inputFile, outputFile, direction string, newline bool, convert func(io.Reader, io.Writer) error) error {
+	input, err := openInput(inputFile)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	if outputFile == "" {
+		if err := convert(bufio.NewReader(input), stdout); err != nil {
+			return err
+		}
+		if newline {
+			fmt.Fprintln(stdout)
+		}
+		return nil
+	}
+
+	if err := checkOverwrite([]string{outputFile}); err != nil {
+		return err
+	}
+	file, err := createOutputFile(outputFile, true)
+	if err != nil {
+		return &outputError{fmt.Errorf("writing output file: %w", err)}
+	}
+	var output io.Writer = file
+	if teeOutput {
+		output = io.MultiWriter(file, stdout)
+	}
+	if err := convert(bufio.NewReader(input), output); err != nil {
+		file.Abort()
+		return err
+	}
+	if err := file.Commit(); err != nil {
+		return &converter.IOError{Op: "write", Path: outputFile, Err: err}
+	}
+	if teeOutput && newline {
+		fmt.Fprintln(stdout)
+	}
+	successf("Successfully converted %s and saved to %s", direction, outputFile)
+	return nil
+}
+
+// runWatch runs watchInput and returns the exit code, which is only non-zero
+// when the input cannot be watched.
+func runWatch(inputFile string, convert func()) int {
+	if err := watchInput(inputFile, convert); err != nil {
+		logf("Error watching %s: %v", inputFile, err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// convertOnce converts a single input file, returning any error instead of
+// exiting. It is used in watch mode, where a failed conversion waits for
+// the next change.
+func convertOnce(inputFile, outputFile string, split bool, opts converter.Options) error {
+	if split {
+		return convertSplitFile(inputFile, outputFile, opts, make(map[string]string))
+	}
+	if err := checkInputSize(inputFile); err != nil {
+		return err
+	}
+	if outputFile != "" && !teeOutput {
+		if err := checkOverwrite([]string{outputFile}); err != nil {
+			return err
+		}
+		if err := converter.ConvertFile(inputFile, outputFile, opts); err != nil {
+			return err
+		}
+		successf("Converted %s to %s", inputFile, outputFile)
+		return nil
+	}
+	data, err := readInputFile(inputFile)
+	if err != nil {
+		return err
+	}
+	result, err := converter.Convert(data, opts)
+	if err != nil {
+		return err
+	}
+	direction := "YAML to JSON"
+	if opts.Reverse {
+		direction = "JSON to YAML"
+	}
+	return writeOutput(outputFile, result, direction)
+}
+
+// writeOutput writes data to outputFile, or to stdout when no file is given
+// or with -tee. Output printed to stdout always ends with a newline.
+func writeOutput(outputFile string, data []byte, direction string) error {
+	if outputFile != "" {
+		// Write to output file
+		if err := checkOverwrite([]string{outputFile}); err != nil {
+			return err
+		}
+		err := writeOutputFile(outputFile, data, true)
+		if err != nil {
+			return &outputError{fmt.Errorf("writing output file: %w", err)}
+		}
+		successf("Successfully converted %s and saved to %s", direction, outputFile)
+		if !teeOutput {
+			return nil
+		}
+	}
+	printOutput(data)
+	return nil
+}
+
+// printOutput prints data to stdout, ending it with a newline.
+func printOutput(data []byte) {
+	stdout.Write(data)
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		fmt.Fprintln(stdout)
+	}
+}
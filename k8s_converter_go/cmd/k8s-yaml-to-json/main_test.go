@@ -0,0 +1,1335 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// runMainEnv makes the test binary run the command instead of the tests, so
+// that exit codes and output streams can be checked on a real process.
+const runMainEnv = "K8S_YAML_TO_JSON_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with args in a new process, with stdin as its
+// standard input, and returns what it wrote to stdout and stderr and its exit
+// code.
+func runCommand(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("Failed to run command: %v", err)
+	}
+	return stdout.String(), stderr.String(), 0
+}
+
+func TestOutputStreams(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"config.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n",
+		"broken.yaml": "This is not valid: YAML: content\n",
+	})
+
+	tests := []struct {
+		name       string
+		stdin      string
+		args       []string
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "JSON on stdout",
+			args:       []string{"-input", filepath.Join(dir, "config.yaml"), "-compact"},
+			wantStdout: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"config"}}` + "\n",
+		},
+		{
+			name:       "Stdin with warnings",
+			stdin:      "kind: ConfigMap\nkind: Secret\n",
+			args:       []string{"-input", "-", "-compact"},
+			wantStdout: `{"kind":"Secret"}` + "\n",
+			wantStderr: "Warning: <stdin>:2: duplicate key \"kind\" in . (first defined at line 1)\n",
+		},
+		{
+			name:       "Success message on stderr",
+			args:       []string{"-input", filepath.Join(dir, "config.yaml"), "-output", filepath.Join(dir, "config.json")},
+			wantStderr: "Successfully converted YAML to JSON and saved to " + filepath.Join(dir, "config.json") + "\n",
+		},
+		{
+			name: "Quiet",
+			args: []string{"-input", filepath.Join(dir, "config.yaml"), "-output", filepath.Join(dir, "quiet.json"), "-quiet"},
+		},
+		{
+			name:       "Errors on stderr",
+			args:       []string{"-input", filepath.Join(dir, "broken.yaml"), "-quiet"},
+			wantStderr: "Error parsing YAML: " + filepath.Join(dir, "broken.yaml") + ":1:24: mapping values are not allowed in this context\n",
+		},
+		{
+			name:       "Validate results on stderr",
+			args:       []string{"-validate", "-input", filepath.Join(dir, "config.yaml")},
+			wantStderr: "PASS " + filepath.Join(dir, "config.yaml") + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStdout, gotStderr, _ := runCommand(t, tt.stdin, tt.args...)
+			if gotStdout != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", gotStdout, tt.wantStdout)
+			}
+			if gotStderr != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", gotStderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestVerifyRoundTripFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"config.yaml": "kind: ConfigMap\ndata:\n  replicas: \"3\"\n  big: 123456789012345678901234567890\n  date: 2023-05-01\n",
+		"config.json": `{"kind":"ConfigMap"}`,
+	})
+
+	stdoutText, errOutput, code := runCommand(t, "", "-verify-roundtrip", "-compact", "-input", filepath.Join(dir, "config.yaml"))
+	want := `{"kind":"ConfigMap","data":{"replicas":"3","big":123456789012345678901234567890,"date":"2023-05-01T00:00:00Z"}}` + "\n"
+	if code != exitOK || stdoutText != want {
+		t.Errorf("exit code = %d, stdout = %q, stderr = %q", code, stdoutText, errOutput)
+	}
+	if _, _, code := runCommand(t, "", "-verify-roundtrip", "-input", filepath.Join(dir, "config.json")); code != exitUsage {
+		t.Errorf("JSON input: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestCanonicalFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"config.yaml": "kind: ConfigMap\ndata: {b: 1.50, a: 0x10}\n",
+		"config.json": `{"kind":"ConfigMap"}`,
+	})
+	want := `{"data":{"a":16,"b":1.5},"kind":"ConfigMap"}` + "\n"
+
+	stdoutText, errOutput, code := runCommand(t, "", "-canonical", "-input", filepath.Join(dir, "config.yaml"))
+	if code != exitOK || stdoutText != want {
+		t.Errorf("exit code = %d, stdout = %q, stderr = %q", code, stdoutText, errOutput)
+	}
+	outputFile := filepath.Join(dir, "out.json")
+	if _, errOutput, code := runCommand(t, "", "-canonical", "-input", filepath.Join(dir, "config.yaml"), "-output", outputFile); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %q", code, errOutput)
+	}
+	if data, _ := os.ReadFile(outputFile); string(data) != want {
+		t.Errorf("output file = %q, want %q", data, want)
+	}
+	if _, _, code := runCommand(t, "", "-canonical", "-input", filepath.Join(dir, "config.json")); code != exitUsage {
+		t.Errorf("JSON input: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestOmitNullFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"pod.yaml": "kind: Pod\nmetadata:\n  name: web\n  creationTimestamp: null\nspec:\n  securityContext:\n    runAsUser: null\n",
+	})
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"-omit-null"}, want: `{"kind":"Pod","metadata":{"name":"web"},"spec":{"securityContext":{}}}`},
+		{args: []string{"-omit-null", "-omit-empty"}, want: `{"kind":"Pod","metadata":{"name":"web"}}`},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "-compact", "-input", filepath.Join(dir, "pod.yaml"))
+		stdoutText, errOutput, code := runCommand(t, "", args...)
+		if code != exitOK || stdoutText != tt.want+"\n" {
+			t.Errorf("%v: exit code = %d, stdout = %q, stderr = %q", tt.args, code, stdoutText, errOutput)
+		}
+	}
+	if _, _, code := runCommand(t, "", "-omit-empty", "-input", filepath.Join(dir, "pod.yaml")); code != exitUsage {
+		t.Errorf("-omit-empty alone: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestTypedFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"configmap.yaml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n---\napiVersion: example.com/v1\nkind: Widget\n",
+		"deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: \"3\"\n",
+	})
+
+	stdoutText, errOutput, code := runCommand(t, "", "-typed", "-separate", "-compact", "-input", filepath.Join(dir, "configmap.yaml"))
+	want := `{"kind":"ConfigMap","apiVersion":"v1","metadata":{"name":"settings","creationTimestamp":null}}` + "\n" + `{"apiVersion":"example.com/v1","kind":"Widget"}` + "\n"
+	if code != exitOK || stdoutText != want {
+		t.Errorf("exit code = %d, stdout = %q, want %q", code, stdoutText, want)
+	}
+	if !strings.Contains(errOutput, "configmap.yaml:6: example.com/v1 Widget is not a built-in kind; converted without typed decoding (document 2)") {
+		t.Errorf("stderr = %q, want a warning for the Widget", errOutput)
+	}
+
+	_, errOutput, code = runCommand(t, "", "-typed", "-input", filepath.Join(dir, "deployment.yaml"))
+	if code != exitInvalid || !strings.Contains(errOutput, "DeploymentSpec.spec.replicas of type int32") {
+		t.Errorf("wrong type: exit code = %d, stderr = %q", code, errOutput)
+	}
+	if _, _, code := runCommand(t, "", "-input", filepath.Join(dir, "deployment.yaml")); code != exitOK {
+		t.Errorf("without -typed: exit code = %d, want %d", code, exitOK)
+	}
+}
+
+func TestDeprecatedFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.yaml": "apiVersion: v1\nkind: Service\n---\napiVersion: flowcontrol.apiserver.k8s.io/v1beta3\nkind: FlowSchema\n",
+	})
+	input := filepath.Join(dir, "app.yaml")
+	warning := "flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema is deprecated since Kubernetes 1.29 and removed in 1.32; use flowcontrol.apiserver.k8s.io/v1 instead (document 2)"
+
+	tests := []struct {
+		args        []string
+		wantCode    int
+		wantStderr  string
+		wantNoWarns bool
+	}{
+		{args: []string{"-warn-deprecated"}, wantCode: exitOK, wantStderr: "app.yaml:4: " + warning},
+		{args: []string{"-warn-deprecated=1.29"}, wantCode: exitOK, wantStderr: "app.yaml:4: " + warning},
+		{args: []string{"-warn-deprecated=v1.28"}, wantCode: exitOK, wantNoWarns: true},
+		{args: []string{}, wantCode: exitOK, wantNoWarns: true},
+		{args: []string{"-fail-deprecated"}, wantCode: exitInvalid, wantStderr: "app.yaml:4: " + warning},
+		{args: []string{"-fail-deprecated", "-warn-deprecated=1.28"}, wantCode: exitOK, wantNoWarns: true},
+		{args: []string{"-warn-deprecated=latest"}, wantCode: exitUsage, wantStderr: "must be a Kubernetes release such as 1.29"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "-input", input)
+		_, errOutput, code := runCommand(t, "", args...)
+		if code != tt.wantCode || !strings.Contains(errOutput, tt.wantStderr) || tt.wantNoWarns && errOutput != "" {
+			t.Errorf("%v: exit code = %d, stderr = %q, want %d and %q", tt.args, code, errOutput, tt.wantCode, tt.wantStderr)
+		}
+	}
+}
+
+func TestExpandLastAppliedFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"web.yaml": "kind: Service\nmetadata:\n  annotations:\n    kubectl.kubernetes.io/last-applied-configuration: |\n      {\"kind\":\"Service\",\"metadata\":{\"name\":\"web\"}}\n",
+	})
+	input := filepath.Join(dir, "web.yaml")
+
+	stdoutText, errOutput, code := runCommand(t, "", "-expand-last-applied", "-compact", "-input", input)
+	want := `{"kind":"Service","metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":{"kind":"Service","metadata":{"name":"web"}}}}}` + "\n"
+	if code != exitOK || stdoutText != want {
+		t.Errorf("exit code = %d, stdout = %q, stderr = %q, want %q", code, stdoutText, errOutput, want)
+	}
+	stdoutText, _, _ = runCommand(t, "", "-compact", "-input", input)
+	if !strings.Contains(stdoutText, `"kubectl.kubernetes.io/last-applied-configuration":"{\"kind\"`) {
+		t.Errorf("without -expand-last-applied: stdout = %q, want the annotation as a string", stdoutText)
+	}
+}
+
+func TestFlattenFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.yaml": "kind: Deployment\nmetadata:\n  labels:\n    app.kubernetes.io/name: web\nspec:\n  containers:\n  - image: nginx:1.25\n---\nkind: Service\n",
+	})
+	input := filepath.Join(dir, "app.yaml")
+
+	stdoutText, errOutput, code := runCommand(t, "", "-flatten", "-format", "ndjson", "-input", input)
+	want := `{"kind":"Deployment","metadata.labels[\"app.kubernetes.io/name\"]":"web","spec.containers[0].image":"nginx:1.25"}` + "\n" + `{"kind":"Service"}` + "\n"
+	if code != exitOK || stdoutText != want {
+		t.Errorf("exit code = %d, stdout = %q, stderr = %q, want %q", code, stdoutText, errOutput, want)
+	}
+	if _, _, code := runCommand(t, "", "-flatten", "-split", "-output", dir, "-input", input); code != exitUsage {
+		t.Errorf("-flatten -split: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestFormatGoFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.yaml": "kind: Service\nmetadata:\n  name: web\nspec:\n  port: 80\n",
+	})
+	input := filepath.Join(dir, "app.yaml")
+
+	stdoutText, errOutput, code := runCommand(t, "", "-format", "go", "-go-package", "testfixtures", "-input", input)
+	want := "package testfixtures\n\nvar serviceWeb = map[string]interface{}{\n\t\"kind\": \"Service\",\n\t\"metadata\": map[string]interface{}{\n\t\t\"name\": \"web\",\n\t},\n\t\"spec\": map[string]interface{}{\n\t\t\"port\": int64(80),\n\t},\n}\n"
+	if code != exitOK || stdoutText != want {
+		t.Errorf("exit code = %d, stdout = %q, stderr = %q, want %q", code, stdoutText, errOutput, want)
+	}
+	if _, _, code := runCommand(t, "", "-format", "go", "-go-package", "my-fixtures", "-input", input); code != exitUsage {
+		t.Errorf("-go-package my-fixtures: exit code = %d, want %d", code, exitUsage)
+	}
+	if _, _, code := runCommand(t, "", "-format", "go", "-input", dir); code != exitUsage {
+		t.Errorf("directory input: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestFormatHCLFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.yaml": "kind: Namespace\nmetadata:\n  name: prod\n",
+	})
+	input := filepath.Join(dir, "app.yaml")
+
+	stdoutText, errOutput, code := runCommand(t, "", "-format", "hcl", "-input", input)
+	want := "resource \"kubernetes_manifest\" \"namespace_prod\" {\n  manifest = {\n    \"kind\" = \"Namespace\"\n    \"metadata\" = {\n      \"name\" = \"prod\"\n    }\n  }\n}\n"
+	if code != exitOK || stdoutText != want {
+		t.Errorf("exit code = %d, stdout = %q, stderr = %q, want %q", code, stdoutText, errOutput, want)
+	}
+	if _, _, code := runCommand(t, "", "-format", "hcl", "-split", "-output", dir, "-input", input); code != exitUsage {
+		t.Errorf("-split: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestFormatCSVFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.yaml":         "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: prod\nspec:\n  replicas: 3\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n",
+		"config/cfg.yaml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\n",
+		"config/skip.yaml": "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\n",
+	})
+	app := filepath.Join(dir, "app.yaml")
+	cfg := filepath.Join(dir, "config", "cfg.yaml")
+
+	stdoutText, errOutput, code := runCommand(t, "", "-format", "csv", "-csv-columns", "spec.replicas", "-input", app)
+	want := "apiVersion,kind,namespace,name,source,document,spec.replicas\napps/v1,Deployment,prod,web," + app + ",1,3\nv1,Service,,web," + app + ",2,\n"
+	if code != exitOK || stdoutText != want {
+		t.Errorf("file input: exit code = %d, stdout = %q, stderr = %q, want %q", code, stdoutText, errOutput, want)
+	}
+
+	stdoutText, errOutput, code = runCommand(t, "", "-format", "csv", "-kind", "Deployment,ConfigMap", "-input", dir)
+	want = "apiVersion,kind,namespace,name,source,document\napps/v1,Deployment,prod,web," + app + ",1\nv1,ConfigMap,,cfg," + cfg + ",1\n"
+	if code != exitOK || stdoutText != want {
+		t.Errorf("directory input: exit code = %d, stdout = %q, stderr = %q, want %q", code, stdoutText, errOutput, want)
+	}
+
+	if _, _, code := runCommand(t, "", "-format", "csv", "-kind", "Pod", "-input", dir); code != exitNoMatch {
+		t.Errorf("no match: exit code = %d, want %d", code, exitNoMatch)
+	}
+	if _, _, code := runCommand(t, "", "-csv-columns", "spec.replicas", "-input", app); code != exitUsage {
+		t.Errorf("-csv-columns without -format csv: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestFormatMarkdownFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"b.yaml": "kind: Service\nmetadata:\n  name: web\n",
+		"a.yaml": "kind: Deployment\nmetadata:\n  name: web\n  namespace: prod\n",
+	})
+	output := filepath.Join(dir, "summary.md")
+
+	_, errOutput, code := runCommand(t, "", "-format", "markdown", "-input", dir, "-output", output)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %q", code, errOutput)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "| Kind | Namespace | Name | Source |\n| --- | --- | --- | --- |\n" +
+		"| Deployment | prod | web | " + filepath.Join(dir, "a.yaml") + " |\n" +
+		"| Service |  | web | " + filepath.Join(dir, "b.yaml") + " |\n" +
+		"\n2 documents\n"
+	if string(got) != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if _, _, code := runCommand(t, "", "-format", "markdown", "-query", ".kind", "-input", filepath.Join(dir, "a.yaml")); code != exitUsage {
+		t.Errorf("-query: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestFormatDOTFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"deployment.yaml": "kind: Deployment\nmetadata:\n  name: web\nspec:\n  template:\n    spec:\n      volumes:\n      - configMap:\n          name: web\n      - configMap:\n          name: gone\n",
+		"configmap.yaml":  "kind: ConfigMap\nmetadata:\n  name: web\n",
+	})
+
+	// The documents of every file of a directory make a single graph
+	stdout, errOutput, code := runCommand(t, "", "-format", "dot", "-input", dir)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %q", code, errOutput)
+	}
+	for _, want := range []string{
+		`"ConfigMap//web" [label="ConfigMap/web"];`,
+		`"ConfigMap//gone" [label="ConfigMap/gone", style=dashed];`,
+		`"Deployment//web" -> "ConfigMap//web" [label="mounts"];`,
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout = %s, want it to contain %s", stdout, want)
+		}
+	}
+	if _, _, code := runCommand(t, "", "-format", "dot", "-split", "-input", dir, "-output", t.TempDir()); code != exitUsage {
+		t.Errorf("-split: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestValidateNamesFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"app.yaml": "kind: ConfigMap\nmetadata:\n  name: ok\n---\nkind: ConfigMap\nmetadata:\n  name: My_App\n"})
+	input := filepath.Join(dir, "app.yaml")
+
+	if _, stderr, code := runCommand(t, "", "-input", input); code != exitOK {
+		t.Errorf("without -validate-names: exit code = %d, stderr = %s", code, stderr)
+	}
+	_, stderr, code := runCommand(t, "", "-validate-names", "-error-format", "json", "-input", input)
+	if code != exitInvalid || !strings.Contains(stderr, `"error":"invalid_name"`) || !strings.Contains(stderr, `"line":5,"document":2`) {
+		t.Errorf("-validate-names: exit code = %d, stderr = %s, want %d and an invalid_name error for document 2", code, stderr, exitInvalid)
+	}
+	_, stderr, _ = runCommand(t, "", "-validate-names", "-input", input)
+	if want := "Error: " + input + `:5: invalid name: ConfigMap: metadata.name "My_App" is not a valid `; !strings.HasPrefix(stderr, want) || !strings.HasSuffix(stderr, " (document 2)\n") {
+		t.Errorf("-validate-names: stderr = %q, want it to start with %q and name document 2", stderr, want)
+	}
+}
+
+func TestValidateLabelsFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"app.yaml": "kind: ConfigMap\nmetadata:\n  name: ok\n---\nkind: ConfigMap\nmetadata:\n  name: app\n  labels:\n    my team/app: web\n"})
+	input := filepath.Join(dir, "app.yaml")
+
+	if _, stderr, code := runCommand(t, "", "-input", input); code != exitOK {
+		t.Errorf("without -validate-labels: exit code = %d, stderr = %s", code, stderr)
+	}
+	_, stderr, code := runCommand(t, "", "-validate-labels", "-error-format", "json", "-input", input)
+	if code != exitInvalid || !strings.Contains(stderr, `"error":"invalid_label"`) || !strings.Contains(stderr, `"line":5,"document":2`) {
+		t.Errorf("-validate-labels: exit code = %d, stderr = %s, want %d and an invalid_label error for document 2", code, stderr, exitInvalid)
+	}
+	_, stderr, _ = runCommand(t, "", "-validate-labels", "-input", input)
+	if want := "Error: " + input + `:5: invalid label: ConfigMap: metadata.labels.my team/app "my team/app" is not a valid `; !strings.HasPrefix(stderr, want) || !strings.HasSuffix(stderr, " (document 2)\n") {
+		t.Errorf("-validate-labels: stderr = %q, want it to start with %q and name document 2", stderr, want)
+	}
+}
+
+func TestValidateImagesFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"valid.yaml":   "kind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    image: nginx\n",
+		"invalid.yaml": "kind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    image: nginx@sha256:notahash\n",
+		"dots.yaml":    "kind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    image: nginx:1..25\n",
+	})
+
+	_, stderr, code := runCommand(t, "", "-validate-images", "-input", filepath.Join(dir, "valid.yaml"))
+	if code != exitOK || !strings.Contains(stderr, "neither a tag nor a digest") {
+		t.Errorf("untagged image: exit code = %d, stderr = %s, want %d and a warning", code, stderr, exitOK)
+	}
+	_, stderr, code = runCommand(t, "", "-validate-images", "-error-format", "json", "-input", filepath.Join(dir, "invalid.yaml"))
+	if code != exitInvalid || !strings.Contains(stderr, `"error":"invalid_image"`) {
+		t.Errorf("invalid image: exit code = %d, stderr = %s, want %d and an invalid_image error", code, stderr, exitInvalid)
+	}
+	input := filepath.Join(dir, "dots.yaml")
+	_, stderr, code = runCommand(t, "", "-validate-images", "-input", input)
+	if want := "Error: " + input + `:1: invalid image: Pod: spec.containers[0].image "nginx:1..25" is not a valid image reference: tag "1..25" must not have consecutive dots` + "\n"; code != exitInvalid || stderr != want {
+		t.Errorf("tag with consecutive dots: exit code = %d, stderr = %q, want %d and %q", code, stderr, exitInvalid, want)
+	}
+}
+
+func TestNameTemplateFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/apps/web.yaml": "kind: Deployment\nmetadata:\n  name: web\n  namespace: shop\n---\nkind: ClusterRole\nmetadata:\n  name: reader\n",
+		"in/apps/db.yaml":  "kind: StatefulSet\nmetadata:\n  name: db\n  namespace: shop\n",
+		"clash/a.yaml":     "kind: ConfigMap\nmetadata:\n  name: app\n",
+		"clash/sub/b.yaml": "kind: ConfigMap\nmetadata:\n  name: app\n",
+	})
+	template := `{{.Namespace | default "cluster"}}/{{.Kind | lower}}-{{.Name}}.json`
+
+	out := filepath.Join(dir, "out")
+	if _, stderr, code := runCommand(t, "", "-input", filepath.Join(dir, "in"), "-split", "-name-template", template, "-output", out); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	for _, name := range []string{"shop/deployment-web.json", "cluster/clusterrole-reader.json", "shop/statefulset-db.json"} {
+		if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expected output %s: %v", name, err)
+		}
+	}
+
+	// Documents of different files that map to the same file fail the
+	// batch before anything is written
+	out = filepath.Join(dir, "clash-out")
+	_, stderr, code := runCommand(t, "", "-input", filepath.Join(dir, "clash"), "-split", "-name-template", template, "-output", out)
+	if code == exitOK || !strings.Contains(stderr, "output file name collision") {
+		t.Errorf("collision: exit code = %d, stderr = %s, want a collision error", code, stderr)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("collision: output directory exists: %v", err)
+	}
+
+	// Names outside the output directory are rejected
+	_, stderr, code = runCommand(t, "", "-input", filepath.Join(dir, "in", "apps", "db.yaml"), "-split", "-name-template", "../{{.Name}}.json", "-output", out)
+	if code == exitOK || !strings.Contains(stderr, "leaves the output directory") {
+		t.Errorf("../: exit code = %d, stderr = %s, want an error", code, stderr)
+	}
+
+	for _, args := range [][]string{
+		{"-name-template", template, "-output", out},
+		{"-split", "-name-template", "{{.Name", "-output", out},
+	} {
+		args = append(args, "-input", filepath.Join(dir, "in"))
+		if _, _, code := runCommand(t, "", args...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestStdoutOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/a.yaml": "kind: A\n",
+		"in/b.yaml": "kind: B\n---\nkind: C\n",
+	})
+
+	// The outputs of directory input are printed in file order, and no file
+	// is written next to the inputs
+	stdout, stderr, code := runCommand(t, "", "-input", filepath.Join(dir, "in"), "-output", "-", "-compact")
+	if code != exitOK || stdout != "{\"kind\":\"A\"}\n[{\"kind\":\"B\"},{\"kind\":\"C\"}]\n" {
+		t.Errorf("directory: exit code = %d, stdout = %q, stderr = %s", code, stdout, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "in", "a.json")); !os.IsNotExist(err) {
+		t.Errorf("directory: a.json was written: %v", err)
+	}
+
+	stdout, _, code = runCommand(t, "", "-input", filepath.Join(dir, "in", "a.yaml"), "-output", "-", "-compact")
+	if code != exitOK || stdout != "{\"kind\":\"A\"}\n" {
+		t.Errorf("file: exit code = %d, stdout = %q", code, stdout)
+	}
+
+	for _, args := range [][]string{
+		{"-output", "-", "-split"},
+		{"-output", "-", "-in-place"},
+		{"-output", "-", "-dry-run"},
+	} {
+		args = append(args, "-input", filepath.Join(dir, "in"))
+		if _, _, code := runCommand(t, "", args...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestArchiveFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"in/web.yaml": "kind: Deployment\nmetadata:\n  name: web\n"})
+	input := filepath.Join(dir, "in")
+
+	// Only the archive is written, with no intermediate files
+	out := filepath.Join(dir, "out", "bundle.tar.gz")
+	if err := os.Mkdir(filepath.Dir(out), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, code := runCommand(t, "", "-input", input, "-output", out, "-split"); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	entries := readArchive(t, out, archiveTarGz)
+	if len(entries) != 1 || entries[0].name != "deployment-web.json" {
+		t.Errorf("entries = %v, want deployment-web.json", entries)
+	}
+	assertDirEntries(t, filepath.Dir(out), "bundle.tar.gz")
+	assertDirEntries(t, input, "web.yaml")
+
+	// -archive names the format of any -output
+	out = filepath.Join(dir, "out", "bundle")
+	if _, stderr, code := runCommand(t, "", "-input", filepath.Join(input, "web.yaml"), "-output", out, "-archive", "zip"); code != exitOK {
+		t.Fatalf("-archive zip: exit code = %d, stderr = %s", code, stderr)
+	}
+	if entries := readArchive(t, out, archiveZip); len(entries) != 1 || entries[0].name != "web.json" {
+		t.Errorf("-archive zip: entries = %v, want web.json", entries)
+	}
+
+	for _, args := range [][]string{
+		{"-archive", "rar", "-output", out},
+		{"-archive", "tar"},
+		{"-output", out + ".tar", "-dry-run"},
+		{"-output", out + ".tar", "-format", "csv"},
+	} {
+		args = append(args, "-input", input)
+		if _, _, code := runCommand(t, "", args...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestArchiveInput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "manifests.tgz")
+	makeArchive(t, input, archiveTarGz,
+		testEntry{"apps/web.yaml", "kind: Deployment\nmetadata:\n  name: web\n"},
+		testEntry{"apps/broken.yaml", "kind: [\n"},
+		testEntry{"notes.txt", "not yaml\n"},
+	)
+
+	// Entries map onto the output tree, and errors name the entry
+	out := filepath.Join(dir, "out")
+	_, stderr, code := runCommand(t, "", "-input", input, "-output", out, "-keep-going")
+	if code == exitOK || !strings.Contains(stderr, filepath.Join(input, "apps", "broken.yaml")) || !strings.Contains(stderr, "1 not YAML") {
+		t.Errorf("exit code = %d, stderr = %s, want the broken entry and the skipped count", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(out, "apps", "web.json")); err != nil {
+		t.Errorf("expected output apps/web.json: %v", err)
+	}
+
+	if _, _, code := runCommand(t, "", "-input", input); code != exitUsage {
+		t.Errorf("without -output: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+// assertDirEntries fails t unless dir holds exactly the named entries.
+func assertDirEntries(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	if !reflect.DeepEqual(got, names) {
+		t.Errorf("entries of %s = %v, want %v", dir, got, names)
+	}
+}
+
+func TestTeeFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/a.yaml": "kind: A\n",
+		"in/b.yaml": "kind: B\n",
+	})
+
+	// The success message goes to stderr, never into the teed output
+	out := filepath.Join(dir, "a.json")
+	stdout, stderr, code := runCommand(t, "", "-input", filepath.Join(dir, "in", "a.yaml"), "-output", out, "-tee", "-compact")
+	if code != exitOK || stdout != "{\"kind\":\"A\"}\n" || !strings.Contains(stderr, "Successfully converted") {
+		t.Errorf("file: exit code = %d, stdout = %q, stderr = %s", code, stdout, stderr)
+	}
+	if got, _ := os.ReadFile(out); string(got) != `{"kind":"A"}` {
+		t.Errorf("file: output = %q", got)
+	}
+
+	outDir := filepath.Join(dir, "out")
+	stdout, stderr, code = runCommand(t, "", "-input", filepath.Join(dir, "in"), "-output", outDir, "-tee", "-compact")
+	if code != exitOK || stdout != "{\"kind\":\"A\"}\n{\"kind\":\"B\"}\n" {
+		t.Errorf("directory: exit code = %d, stdout = %q, stderr = %s", code, stdout, stderr)
+	}
+	for _, name := range []string{"a.json", "b.json"} {
+		if _, err := os.Stat(filepath.Join(outDir, name)); err != nil {
+			t.Errorf("directory: expected output %s: %v", name, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-tee"},
+		{"-tee", "-output", "-"},
+		{"-tee", "-output", outDir, "-split"},
+		{"-tee", "-output", out, "-dry-run"},
+	} {
+		args = append(args, "-input", filepath.Join(dir, "in", "a.yaml"))
+		if _, _, code := runCommand(t, "", args...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestKeepGoingFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/a.yaml": "kind: Service\n",
+		"in/b.yaml": "kind: [\n",
+		"in/c.yaml": "kind: Deployment\n",
+		"in/d.yaml": "kind: [\n",
+	})
+	input := filepath.Join(dir, "in")
+
+	// By default the batch stops at the first failure
+	out := filepath.Join(dir, "fail-fast")
+	_, stderr, code := runCommand(t, "", "-input", input, "-output", out, "-workers", "1")
+	if code != exitInvalid || !strings.Contains(stderr, "Converted 1 files, 1 failed, 2 not attempted") {
+		t.Errorf("default: exit code = %d, stderr = %s", code, stderr)
+	}
+	assertDirEntries(t, out, "a.json")
+
+	// -keep-going converts every other file and lists every failure
+	for _, workers := range []string{"1", "4"} {
+		out := filepath.Join(dir, "keep-going-"+workers)
+		_, stderr, code := runCommand(t, "", "-input", input, "-output", out, "-workers", workers, "-keep-going")
+		if code != exitInvalid || !strings.Contains(stderr, "Converted 2 files, 2 failed") || strings.Contains(stderr, "not attempted") {
+			t.Errorf("-keep-going -workers %s: exit code = %d, stderr = %s", workers, code, stderr)
+		}
+		for _, name := range []string{"b.yaml", "d.yaml"} {
+			if !strings.Contains(stderr, "Failed to convert "+filepath.Join(input, name)) {
+				t.Errorf("-keep-going -workers %s: stderr = %s, want the failure of %s", workers, stderr, name)
+			}
+		}
+		assertDirEntries(t, out, "a.json", "c.json")
+	}
+
+	if _, _, code := runCommand(t, "", "-input", input, "-output", out, "-keep-going", "-fail-fast"); code != exitUsage {
+		t.Errorf("-keep-going -fail-fast: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestStrictFieldsFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"web.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replica: 3\n  template:\n    spec:\n      containers:\n      - name: web\n        ports:\n        - containerPort: \"80\"\n"})
+	input := filepath.Join(dir, "web.yaml")
+
+	_, stderr, code := runCommand(t, "", "-strict-fields", "-input", input)
+	if code != exitInvalid || !strings.Contains(stderr, `.spec.replica: unknown field, did you mean "replicas"?`) {
+		t.Errorf("-strict-fields: exit code = %d, stderr = %s, want %d and the unknown field with a suggestion", code, stderr, exitInvalid)
+	}
+	if strings.Contains(stderr, "containerPort") {
+		t.Errorf("-strict-fields: stderr = %s, want only unknown fields", stderr)
+	}
+	if _, _, code := runCommand(t, "", "-schema-dir", dir, "-input", input); code != exitUsage {
+		t.Errorf("-schema-dir alone: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestValidateQuantitiesFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"pod.yaml": "kind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    resources:\n      requests:\n        cpu: 0,5\n"})
+	input := filepath.Join(dir, "pod.yaml")
+
+	if _, stderr, code := runCommand(t, "", "-input", input); code != exitOK {
+		t.Errorf("without -validate-quantities: exit code = %d, stderr = %s", code, stderr)
+	}
+	_, stderr, code := runCommand(t, "", "-validate-quantities", "-error-format", "json", "-input", input)
+	if code != exitInvalid || !strings.Contains(stderr, `"error":"invalid_quantity"`) {
+		t.Errorf("-validate-quantities: exit code = %d, stderr = %s, want %d and an invalid_quantity error", code, stderr, exitInvalid)
+	}
+	_, stderr, _ = runCommand(t, "", "-validate-quantities", "-input", input)
+	if want := "Error: " + input + `:1: invalid quantity: Pod: spec.containers[0].resources.requests.cpu "0,5" is not a valid quantity: `; !strings.HasPrefix(stderr, want) {
+		t.Errorf("-validate-quantities: stderr = %q, want it to start with %q", stderr, want)
+	}
+}
+
+func TestMaxSizeFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.yaml": "kind: Service\nmetadata:\n  name: web\n",
+	})
+	input := filepath.Join(dir, "app.yaml")
+
+	for name, args := range map[string][]string{
+		"file":      {"-max-size", "16B", "-input", input},
+		"stdin":     {"-max-size", "16", "-input", "-"},
+		"directory": {"-max-size", "16B", "-input", dir},
+		"glob":      {"-max-size", "16B", "-input", filepath.Join(dir, "*.yaml"), "-output", t.TempDir()},
+	} {
+		_, errOutput, code := runCommand(t, "kind: Service\nmetadata:\n  name: web\n", args...)
+		if code != exitInput || !strings.Contains(errOutput, "larger than the -max-size limit of 16; raise -max-size to convert it") {
+			t.Errorf("%s: exit code = %d, stderr = %q, want a -max-size error", name, code, errOutput)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.json")); err == nil {
+		t.Errorf("directory: app.json written for an input past the limit")
+	}
+	_, errOutput, _ := runCommand(t, "", "-max-size", "16B", "-input", dir, "-error-format", "json")
+	if !strings.Contains(errOutput, `{"error":"input_too_large","file":"`+input+`","message":"the input is larger than the -max-size limit of 16; raise -max-size to convert it"}`) {
+		t.Errorf("directory: JSON error = %s", errOutput)
+	}
+	if stdoutText, errOutput, code := runCommand(t, "", "-max-size", "1KiB", "-compact", "-input", input); code != exitOK || stdoutText != `{"kind":"Service","metadata":{"name":"web"}}`+"\n" {
+		t.Errorf("within the limit: exit code = %d, stdout = %q, stderr = %q", code, stdoutText, errOutput)
+	}
+	bomb := filepath.Join(t.TempDir(), "bomb.yaml.gz")
+	writeGzip(t, bomb, "kind: ConfigMap\ndata:\n  a: "+strings.Repeat("a", 64<<10)+"\n")
+	if _, errOutput, code := runCommand(t, "", "-max-size", "1KiB", "-input", bomb); code != exitInput || !strings.Contains(errOutput, "larger than the -max-size limit of 1KiB") {
+		t.Errorf("gzip: exit code = %d, stderr = %q, want a -max-size error for the decompressed input", code, errOutput)
+	}
+	if _, _, code := runCommand(t, "", "-max-size", "lots", "-input", input); code != exitUsage {
+		t.Errorf("invalid size: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestMaxAliasFlags(t *testing.T) {
+	input := "kind: ConfigMap\nbase: &base [a, b, c]\ndata:\n  copies: [*base, *base]\n"
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"app.yaml": input})
+	file := filepath.Join(dir, "app.yaml")
+
+	_, errOutput, code := runCommand(t, "", "-max-alias-nodes", "4", "-input", file)
+	if want := "Error: " + file + ":4:19: alias *base expands the document by more than 4 nodes\n"; code != exitInvalid || errOutput != want {
+		t.Errorf("node limit: exit code = %d, stderr = %q, want %q", code, errOutput, want)
+	}
+	_, errOutput, code = runCommand(t, "", "-no-aliases", "-input", file)
+	if want := "Error: " + file + ":4:12: alias *base is not allowed\n"; code != exitInvalid || errOutput != want {
+		t.Errorf("-no-aliases: exit code = %d, stderr = %q, want %q", code, errOutput, want)
+	}
+	_, errOutput, code = runCommand(t, input, "-max-alias-nodes", "4", "-error-format", "json", "-input", "-")
+	if want := `"error":"yaml_alias_limit"`; code != exitInvalid || !strings.Contains(errOutput, want) || !strings.Contains(errOutput, `"line":4`) {
+		t.Errorf("JSON error: exit code = %d, stderr = %q, want %s", code, errOutput, want)
+	}
+	if stdoutText, errOutput, code := runCommand(t, input, "-compact", "-input", "-"); code != exitOK || !strings.Contains(stdoutText, `"copies":[["a","b","c"],["a","b","c"]]`) {
+		t.Errorf("default limits: exit code = %d, stdout = %q, stderr = %q", code, stdoutText, errOutput)
+	}
+	for _, args := range [][]string{{"-max-alias-nodes", "0"}, {"-max-alias-depth", "-1"}} {
+		if _, _, code := runCommand(t, input, append(args, "-input", "-")...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestInPlaceFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"web.yaml":        "kind: Service\n",
+		"apps/db.yml.gz":  "",
+		"apps/cache.yaml": "kind: ConfigMap\n",
+		"data.json":       "{\"kind\": \"Secret\"}\n",
+	})
+	writeGzip(t, filepath.Join(dir, "apps", "db.yml.gz"), "kind: StatefulSet\n")
+
+	_, errOutput, code := runCommand(t, "", "-in-place", "-compact", "-input", filepath.Join(dir, "web.yaml"))
+	if want := "saved to " + filepath.Join(dir, "web.json"); code != exitOK || !strings.Contains(errOutput, want) {
+		t.Errorf("single file: exit code = %d, stderr = %q, want %q", code, errOutput, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "web.json")); err != nil || string(data) != `{"kind":"Service"}` {
+		t.Errorf("web.json = %q, %v", data, err)
+	}
+	if _, _, code := runCommand(t, "", "-in-place", "-input", filepath.Join(dir, "web.yaml")); code != exitOutput {
+		t.Errorf("existing output: exit code = %d, want %d", code, exitOutput)
+	}
+
+	_, errOutput, code = runCommand(t, "", "-in-place", "-input", filepath.Join(dir, "apps"))
+	for _, want := range []string{
+		"Converted " + filepath.Join(dir, "apps", "cache.yaml") + " to " + filepath.Join(dir, "apps", "cache.json"),
+		"Converted " + filepath.Join(dir, "apps", "db.yml.gz") + " to " + filepath.Join(dir, "apps", "db.json"),
+	} {
+		if code != exitOK || !strings.Contains(errOutput, want) {
+			t.Errorf("directory: exit code = %d, stderr = %q, want %q", code, errOutput, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "apps", "db.json")); err != nil {
+		t.Errorf("directory: %v", err)
+	}
+
+	// The JSON output of -normalize would replace its input
+	_, errOutput, code = runCommand(t, "", "-in-place", "-normalize", "-force", "-input", filepath.Join(dir, "data.json"))
+	if code != exitOutput || !strings.Contains(errOutput, "would replace the input file itself") {
+		t.Errorf("same file: exit code = %d, stderr = %q", code, errOutput)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "data.json")); err != nil || string(data) != "{\"kind\": \"Secret\"}\n" {
+		t.Errorf("data.json = %q, %v, want it unchanged", data, err)
+	}
+
+	for name, args := range map[string][]string{
+		"with -output": {"-in-place", "-output", filepath.Join(dir, "out.json"), "-input", filepath.Join(dir, "web.yaml")},
+		"JSON input":   {"-in-place", "-input", filepath.Join(dir, "data.json")},
+		"stdin":        {"-in-place", "-input", "-"},
+		"CSV":          {"-in-place", "-format", "csv", "-input", filepath.Join(dir, "web.yaml")},
+	} {
+		if _, _, code := runCommand(t, "kind: Service\n", args...); code != exitUsage {
+			t.Errorf("%s: exit code = %d, want %d", name, code, exitUsage)
+		}
+	}
+}
+
+func TestExcludeFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"web.yaml":            "kind: Service\n",
+		"web-test.yaml":       "kind: Pod\n",
+		"notes.txt":           "not yaml\n",
+		"vendor/lib/cm.yaml":  "kind: ConfigMap\n",
+		"apps/vendor/x.yaml":  "kind: Secret\n",
+		"apps/deployment.yml": "kind: Deployment\n",
+	})
+	report := filepath.Join(t.TempDir(), "report.json")
+
+	_, errOutput, code := runCommand(t, "", "-exclude", "vendor/", "-exclude", "*-test.yaml", "-report", report, "-input", dir, "-output", filepath.Join(t.TempDir(), "build"))
+	if want := "Converted 2 files, 0 failed; 3 excluded, 1 not YAML"; code != exitOK || !strings.Contains(errOutput, want) {
+		t.Errorf("exit code = %d, stderr = %q, want %q", code, errOutput, want)
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got batchReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Processed != 2 || got.Excluded != 3 || got.NotYAML != 1 || len(got.Files) != 2 {
+		t.Errorf("report = %s, want 2 files processed, 3 excluded and 1 not YAML", data)
+	}
+
+	for name, args := range map[string][]string{
+		"negated pattern": {"-exclude", "!web.yaml", "-input", dir},
+		"single file":     {"-exclude", "vendor/", "-input", filepath.Join(dir, "web.yaml")},
+	} {
+		if _, _, code := runCommand(t, "", args...); code != exitUsage {
+			t.Errorf("%s: exit code = %d, want %d", name, code, exitUsage)
+		}
+	}
+}
+
+func TestConfigFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"web.yaml":    "kind: Service\nstatus: {}\n",
+		"config.yaml": "compact: true\nclean: true\n",
+		"typo.yaml":   "compact: true\ncleen: true\n",
+	})
+	input := filepath.Join(dir, "web.yaml")
+
+	stdout, _, code := runCommand(t, "", "-config", filepath.Join(dir, "config.yaml"), "-input", input)
+	if want := `{"kind":"Service"}`; code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("config file: exit code = %d, stdout = %q, want %s", code, stdout, want)
+	}
+	stdout, _, code = runCommand(t, "", "-config", filepath.Join(dir, "config.yaml"), "-clean=false", "-input", input)
+	if want := `{"kind":"Service","status":{}}`; code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("-clean=false: exit code = %d, stdout = %q, want %s", code, stdout, want)
+	}
+
+	_, errOutput, code := runCommand(t, "", "-config", filepath.Join(dir, "typo.yaml"), "-error-format", "json", "-input", input)
+	if code != exitUsage || !strings.Contains(errOutput, `"error":"invalid_config"`) || !strings.Contains(errOutput, "unknown key 'cleen'") {
+		t.Errorf("unknown key: exit code = %d, stderr = %q", code, errOutput)
+	}
+	_, errOutput, code = runCommand(t, "", "-config", filepath.Join(dir, "missing.yaml"), "-input", input)
+	if code != exitUsage || !strings.Contains(errOutput, "reading config file") {
+		t.Errorf("missing file: exit code = %d, stderr = %q", code, errOutput)
+	}
+}
+
+func TestVerboseFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"in/web.yaml":  "kind: Service\n",
+		"in/README.md": "docs\n",
+	})
+	input := filepath.Join(dir, "in")
+
+	// The default output is unchanged
+	_, errOutput, code := runCommand(t, "", "-input", input, "-output", filepath.Join(dir, "quiet"))
+	if code != exitOK || strings.Contains(errOutput, "Converting") || strings.Contains(errOutput, "Skipping") {
+		t.Errorf("default: exit code = %d, stderr = %q, want no progress lines", code, errOutput)
+	}
+
+	_, errOutput, code = runCommand(t, "", "-v", "-input", input, "-output", filepath.Join(dir, "v"))
+	for _, want := range []string{
+		"Skipping " + filepath.Join(input, "README.md") + ": not a YAML file",
+		"Converting " + filepath.Join(input, "web.yaml"),
+		"Finished " + filepath.Join(input, "web.yaml") + " in ",
+		"Finished 1 files in ",
+	} {
+		if code != exitOK || !strings.Contains(errOutput, want) {
+			t.Errorf("-v: exit code = %d, stderr = %q, want %q", code, errOutput, want)
+		}
+	}
+	if strings.Contains(errOutput, "Output of") {
+		t.Errorf("-v: stderr = %q, want no -vv lines", errOutput)
+	}
+	_, errOutput, _ = runCommand(t, "", "-vv", "-input", input, "-output", filepath.Join(dir, "vv"))
+	if want := "Output of " + filepath.Join(input, "web.yaml"); !strings.Contains(errOutput, want) {
+		t.Errorf("-vv: stderr = %q, want %q", errOutput, want)
+	}
+
+	// Every line on stderr is a JSON object, and stdout is left to the JSON
+	// output
+	stdout, errOutput, code := runCommand(t, "", "-v", "-log-format", "json", "-compact", "-input", filepath.Join(input, "web.yaml"))
+	if code != exitOK || strings.TrimSpace(stdout) != `{"kind":"Service"}` {
+		t.Errorf("-log-format json: exit code = %d, stdout = %q", code, stdout)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(errOutput), "\n") {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil || event["level"] != "info" || event["file"] != filepath.Join(input, "web.yaml") {
+			t.Errorf("-log-format json: stderr line %q is not an info event about the input: %v", line, err)
+		}
+	}
+
+	if _, _, code := runCommand(t, "", "-log-format", "xml", "-input", input); code != exitUsage {
+		t.Errorf("-log-format xml: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestColorFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"web.yaml": "kind: Service\nspec:\n  ports: [80]\n"})
+	input := filepath.Join(dir, "web.yaml")
+
+	// Piped output is never colorized, so it is the same as with -color never
+	plain, _, code := runCommand(t, "", "-input", input)
+	never, _, _ := runCommand(t, "", "-color", "never", "-input", input)
+	if code != exitOK || plain != never || strings.Contains(plain, "\x1b") {
+		t.Errorf("default: exit code = %d, stdout = %q, want %q without colors", code, plain, never)
+	}
+
+	stdout, _, code := runCommand(t, "", "-color", "always", "-compact", "-input", input)
+	if want := "{" + colorKey + `"kind"` + colorReset + ":" + colorString + `"Service"` + colorReset + ","; code != exitOK || !strings.HasPrefix(stdout, want) {
+		t.Errorf("-color always: exit code = %d, stdout = %q, want it to start with %q", code, stdout, want)
+	}
+
+	// Files are never colorized
+	output := filepath.Join(dir, "web.json")
+	if _, _, code := runCommand(t, "", "-color", "always", "-input", input, "-output", output); code != exitOK {
+		t.Fatalf("-output: exit code = %d", code)
+	}
+	if data, err := os.ReadFile(output); err != nil || string(data)+"\n" != plain {
+		t.Errorf("-output: web.json = %q, %v, want %q", data, err, plain)
+	}
+
+	if _, _, code := runCommand(t, "", "-color", "sometimes", "-input", input); code != exitUsage {
+		t.Errorf("-color sometimes: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestEnvFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"web.yaml": "kind: Service\nstatus: {}\n"})
+	input := filepath.Join(dir, "web.yaml")
+	t.Setenv("K8SY2J_COMPACT", "true")
+	t.Setenv("K8SY2J_CLEAN", "true")
+
+	stdout, _, code := runCommand(t, "", "-input", input)
+	if want := `{"kind":"Service"}`; code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("environment: exit code = %d, stdout = %q, want %s", code, stdout, want)
+	}
+	stdout, _, code = runCommand(t, "", "-clean=false", "-input", input)
+	if want := `{"kind":"Service","status":{}}`; code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("-clean=false: exit code = %d, stdout = %q, want %s", code, stdout, want)
+	}
+	stdout, _, code = runCommand(t, "", "-no-env", "-input", input)
+	if code != exitOK || !strings.Contains(stdout, "\n  \"status\": {}") {
+		t.Errorf("-no-env: exit code = %d, stdout = %q, want indented output with status", code, stdout)
+	}
+
+	t.Setenv("K8SY2J_CLEAN", "maybe")
+	_, errOutput, code := runCommand(t, "", "-input", input)
+	if code != exitUsage || !strings.Contains(errOutput, "K8SY2J_CLEAN") {
+		t.Errorf("invalid value: exit code = %d, stderr = %q", code, errOutput)
+	}
+}
+
+func TestNormalizeFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"service.json": "{\"status\": {}, \"metadata\": {\"name\": \"web\"}, \"kind\": \"Service\"}\n",
+		"broken.json":  "{\n  \"kind\": Service\n}\n",
+		"service.yaml": "kind: Service\n",
+	})
+
+	stdoutText, errOutput, code := runCommand(t, "", "-normalize", "-clean", "-sort-keys", "-compact", "-input", filepath.Join(dir, "service.json"))
+	if want := `{"kind":"Service","metadata":{"name":"web"}}` + "\n"; code != exitOK || stdoutText != want {
+		t.Errorf("exit code = %d, stdout = %q, stderr = %q", code, stdoutText, errOutput)
+	}
+	_, errOutput, code = runCommand(t, "", "-normalize", "-input", filepath.Join(dir, "broken.json"))
+	if want := filepath.Join(dir, "broken.json") + ":2:11: invalid character 'S' looking for beginning of value"; code != exitInvalid || !strings.Contains(errOutput, want) {
+		t.Errorf("broken.json: exit code = %d, stderr = %q, want %q", code, errOutput, want)
+	}
+	if _, _, code := runCommand(t, "", "-normalize", "-input", filepath.Join(dir, "service.yaml")); code != exitUsage {
+		t.Errorf("YAML input: exit code = %d, want %d", code, exitUsage)
+	}
+	if _, _, code := runCommand(t, "", "-normalize", "-input", dir); code != exitUsage {
+		t.Errorf("directory input: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestUTF16Input(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"web.yaml":   "\xff\xfek\x00i\x00n\x00d\x00:\x00 \x00A\x00\r\x00\n\x00",
+		"image.yaml": "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+	})
+
+	stdoutText, errOutput, code := runCommand(t, "", "-compact", "-input", filepath.Join(dir, "web.yaml"))
+	if code != exitOK || stdoutText != "{\"kind\":\"A\"}\n" {
+		t.Errorf("exit code = %d, stdout = %q, stderr = %q", code, stdoutText, errOutput)
+	}
+	if want := "Warning: " + filepath.Join(dir, "web.yaml") + ": input is UTF-16LE encoded and was converted to UTF-8\n"; errOutput != want {
+		t.Errorf("stderr = %q, want %q", errOutput, want)
+	}
+	_, errOutput, code = runCommand(t, "", "-input", filepath.Join(dir, "image.yaml"))
+	if want := filepath.Join(dir, "image.yaml") + ": input does not appear to be text"; code != exitInvalid || !strings.Contains(errOutput, want) {
+		t.Errorf("image.yaml: exit code = %d, stderr = %q, want %q", code, errOutput, want)
+	}
+}
+
+func TestParseIndent(t *testing.T) {
+	tests := []struct {
+		value       string
+		want        string
+		expectError bool
+	}{
+		{value: "2", want: "  "},
+		{value: "4", want: "    "},
+		{value: "0", want: ""},
+		{value: "tab", want: "\t"},
+		{value: "TAB", want: "\t"},
+		{value: "-1", expectError: true},
+		{value: "two", expectError: true},
+		{value: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseIndent(tt.value)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseIndent() error = %v, expectError %v", err, tt.expectError)
+			}
+			if got != tt.want {
+				t.Errorf("parseIndent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatParseError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *converter.ParseError
+		want string
+	}{
+		{
+			name: "Line and column",
+			err:  &converter.ParseError{Format: "YAML", Document: 1, Line: 143, Column: 7, Message: "mapping values are not allowed in this context"},
+			want: "deploy.yaml:143:7: mapping values are not allowed in this context",
+		},
+		{
+			name: "Line only in a later document",
+			err:  &converter.ParseError{Format: "YAML", Document: 3, Line: 12, Message: "did not find expected key"},
+			want: "deploy.yaml:12: did not find expected key (document 3)",
+		},
+		{
+			name: "No location",
+			err:  &converter.ParseError{Format: "JSON", Err: errors.New("unexpected end of JSON input")},
+			want: "deploy.yaml: unexpected end of JSON input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatParseError("deploy.yaml", tt.err); got != tt.want {
+				t.Errorf("formatParseError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeTemplateError(t *testing.T) {
+	err := &converter.TemplateError{Line: 4, Err: &converter.ParseError{Format: "YAML", Line: 1}}
+	want := "chart/deployment.yaml:4: this file appears to be an un-rendered Helm template; render it with `helm template` first"
+	if got := describeError("chart/deployment.yaml", err); got != want {
+		t.Errorf("describeError() = %q, want %q", got, want)
+	}
+}
+
+func TestParseList(t *testing.T) {
+	tests := map[string][]string{
+		"":                   nil,
+		"IMAGE_TAG":          {"IMAGE_TAG"},
+		" IMAGE_TAG, ,ZONE ": {"IMAGE_TAG", "ZONE"},
+	}
+	for value, want := range tests {
+		if got := parseList(value); !reflect.DeepEqual(got, want) {
+			t.Errorf("parseList(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestParseKeyValues(t *testing.T) {
+	tests := []struct {
+		values  []string
+		want    []converter.KeyValue
+		wantErr bool
+	}{
+		{values: nil, want: nil},
+		{
+			values: []string{"app.kubernetes.io/managed-by=pipeline", "build=42"},
+			want:   []converter.KeyValue{{Key: "app.kubernetes.io/managed-by", Value: "pipeline"}, {Key: "build", Value: "42"}},
+		},
+		{values: []string{"query=a=b=c"}, want: []converter.KeyValue{{Key: "query", Value: "a=b=c"}}},
+		{values: []string{"empty="}, want: []converter.KeyValue{{Key: "empty", Value: ""}}},
+		{values: []string{"build"}, wantErr: true},
+		{values: []string{"=42"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseKeyValues("-add-label", tt.values)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseKeyValues(%q) = %v, %v, want %v (error %v)", tt.values, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseSetFields(t *testing.T) {
+	tests := []struct {
+		values  []string
+		want    []converter.SetField
+		wantErr bool
+	}{
+		{values: nil, want: nil},
+		{values: []string{"spec.replicas=5"}, want: []converter.SetField{{Path: "spec.replicas", Value: "5"}}},
+		{values: []string{"args[0]=--level=debug"}, want: []converter.SetField{{Path: "args[0]", Value: "--level=debug"}}},
+		{
+			values: []string{`metadata.annotations."example.com/a=b"=c`},
+			want:   []converter.SetField{{Path: `metadata.annotations."example.com/a=b"`, Value: "c"}},
+		},
+		{values: []string{"spec.paused="}, want: []converter.SetField{{Path: "spec.paused", Value: ""}}},
+		{values: []string{"spec.replicas"}, wantErr: true},
+		{values: []string{"=5"}, wantErr: true},
+		{values: []string{"spec..replicas=5"}, wantErr: true},
+		{values: []string{"spec={a: b}"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSetFields("-set", tt.values, false)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSetFields(%q) = %v, %v, want %v (error %v)", tt.values, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSetFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"web.yaml": "kind: Deployment\nspec:\n  template:\n    spec:\n      containers:\n      - name: web\n"})
+	input := filepath.Join(dir, "web.yaml")
+
+	stdout, _, code := runCommand(t, "", "-compact", "-set", "spec.replicas=5", "-set", "spec.template.spec.containers[0].image=nginx:1.25",
+		"-set-string", "metadata.labels.version=1.20", "-input", input)
+	want := `{"kind":"Deployment","spec":{"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.25"}]}},"replicas":5},` +
+		`"metadata":{"labels":{"version":"1.20"}}}`
+	if code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("-set: exit code = %d, stdout = %s, want %s", code, stdout, want)
+	}
+	_, stderr, code := runCommand(t, "", "-set", "spec.template.spec.containers[1].image=nginx", "-error-format", "json", "-input", input)
+	if code != exitInvalid || !strings.Contains(stderr, `"error":"set_path"`) {
+		t.Errorf("-set past the end: exit code = %d, stderr = %s, want %d and a set_path error", code, stderr, exitInvalid)
+	}
+	for _, args := range [][]string{{"-set", "spec.replicas"}, {"-set-string", ".=x"}} {
+		if _, _, code := runCommand(t, "", append(args, "-input", input)...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestNamespaceFlags(t *testing.T) {
+	dir := t.TempDir()
+	content := "kind: Service\nmetadata:\n  name: a\n---\nkind: Service\nmetadata:\n  name: b\n  namespace: prod\n---\nkind: ClusterRole\nmetadata:\n  name: c\n"
+	writeTree(t, dir, map[string]string{"all.yaml": content})
+	input := filepath.Join(dir, "all.yaml")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			args: []string{"-set-namespace", "staging"},
+			want: `{"kind":"Service","metadata":{"name":"a","namespace":"staging"}}` + "\n" +
+				`{"kind":"Service","metadata":{"name":"b","namespace":"prod"}}` + "\n" + `{"kind":"ClusterRole","metadata":{"name":"c"}}`,
+		},
+		{
+			args: []string{"-force-namespace", "staging"},
+			want: `{"kind":"Service","metadata":{"name":"a","namespace":"staging"}}` + "\n" +
+				`{"kind":"Service","metadata":{"name":"b","namespace":"staging"}}` + "\n" + `{"kind":"ClusterRole","metadata":{"name":"c"}}`,
+		},
+		{
+			// The -namespace filter sees the namespace that was set
+			args: []string{"-set-namespace", "staging", "-namespace", "staging"},
+			want: `{"kind":"Service","metadata":{"name":"a","namespace":"staging"}}`,
+		},
+	}
+	for _, tt := range tests {
+		stdout, stderr, code := runCommand(t, "", append(tt.args, "-format", "ndjson", "-input", input)...)
+		if code != exitOK || strings.TrimSpace(stdout) != tt.want {
+			t.Errorf("%v: exit code = %d, stdout = %s, stderr = %s, want %s", tt.args, code, stdout, stderr, tt.want)
+		}
+	}
+	if _, _, code := runCommand(t, "", "-set-namespace", "a", "-force-namespace", "b", "-input", input); code != exitUsage {
+		t.Errorf("-set-namespace with -force-namespace: exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestSortByApplyOrderFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.yaml": "kind: Service\nmetadata:\n  name: web\n---\nkind: Deployment\nmetadata:\n  name: web\n",
+		"ns.yaml":  "kind: Namespace\nmetadata:\n  name: shop\n",
+	})
+	app, ns := filepath.Join(dir, "app.yaml"), filepath.Join(dir, "ns.yaml")
+
+	stdout, _, code := runCommand(t, "", "-compact", "-sort-by-apply-order", "-input", app)
+	if want := `[{"kind":"Deployment","metadata":{"name":"web"}},{"kind":"Service","metadata":{"name":"web"}}]`; code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("-sort-by-apply-order: exit code = %d, stdout = %s, want %s", code, stdout, want)
+	}
+	// The items of a merged List are sorted across inputs
+	stdout, _, code = runCommand(t, "", "-compact", "-merge-list", "-sort-by-apply-order", "-input", app, "-input", ns)
+	want := `{"apiVersion":"v1","kind":"List","items":[{"kind":"Namespace","metadata":{"name":"shop"}},` +
+		`{"kind":"Deployment","metadata":{"name":"web"}},{"kind":"Service","metadata":{"name":"web"}}]}`
+	if code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("-merge-list -sort-by-apply-order: exit code = %d, stdout = %s, want %s", code, stdout, want)
+	}
+}
+
+func TestAddLabelFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"web.yaml": "kind: Service\nmetadata:\n  labels:\n    build: \"7\"\n"})
+	input := filepath.Join(dir, "web.yaml")
+
+	stdout, _, code := runCommand(t, "", "-compact", "-add-label", "build=42", "-add-label", "team=web", "-add-annotation", "example.com/url=a=b", "-input", input)
+	if want := `{"kind":"Service","metadata":{"labels":{"build":"7","team":"web"},"annotations":{"example.com/url":"a=b"}}}`; code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("-add-label: exit code = %d, stdout = %s, want %s", code, stdout, want)
+	}
+	stdout, _, code = runCommand(t, "", "-compact", "-add-label", "build=42", "-override-labels", "-input", input)
+	if want := `{"kind":"Service","metadata":{"labels":{"build":"42"}}}`; code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("-override-labels: exit code = %d, stdout = %s, want %s", code, stdout, want)
+	}
+	for _, args := range [][]string{{"-add-label", "build"}, {"-override-labels"}} {
+		if _, _, code := runCommand(t, "", append(args, "-input", input)...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestDeletePathFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"web.yaml": "kind: Service\nmetadata:\n  name: web\n  annotations:\n    example.com/name: web\n"})
+	input := filepath.Join(dir, "web.yaml")
+
+	stdout, _, code := runCommand(t, "", "-compact", "-delete-path", `metadata.annotations."example.com/name"`, "-delete-path", "status", "-input", input)
+	if want := `{"kind":"Service","metadata":{"name":"web","annotations":{}}}`; code != exitOK || strings.TrimSpace(stdout) != want {
+		t.Errorf("-delete-path: exit code = %d, stdout = %s, want %s", code, stdout, want)
+	}
+	_, stderr, code := runCommand(t, "", "-delete-path", "status", "-strict-paths", "-error-format", "json", "-input", input)
+	if code != exitInvalid || !strings.Contains(stderr, `"error":"path_not_found"`) || !strings.Contains(stderr, `"line":1`) {
+		t.Errorf("-strict-paths: exit code = %d, stderr = %s, want %d and a path_not_found error", code, stderr, exitInvalid)
+	}
+	for _, args := range [][]string{{"-delete-path", "spec..name"}, {"-delete-path", "."}, {"-strict-paths"}} {
+		if _, _, code := runCommand(t, "", append(args, "-input", input)...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestListFlag(t *testing.T) {
+	var kinds listFlag
+	for _, value := range []string{"Deployment", "Service, ConfigMap"} {
+		if err := kinds.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+	want := listFlag{"Deployment", "Service", "ConfigMap"}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("listFlag = %v, want %v", kinds, want)
+	}
+	if got := kinds.String(); got != "Deployment,Service,ConfigMap" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// secretFileMode is the mode of output files that may hold the Secrets
+// decoded by -decode-secrets when -mode is not given.
+const secretFileMode os.FileMode = 0600
+
+// outputMode is the -mode of every file written, set exactly whatever the
+// umask, or 0 for new files to be created with mode 0644 less the umask and
+// replaced files to keep their mode.
+var outputMode os.FileMode
+
+// decodedSecrets is whether -decode-secrets is given, so that files that may
+// hold decoded Secrets default to secretFileMode.
+var decodedSecrets bool
+
+// modeFlag is a flag holding a file mode written in octal, such as 0600.
+type modeFlag os.FileMode
+
+func (m *modeFlag) String() string {
+	if *m == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%04o", uint32(*m))
+}
+
+func (m *modeFlag) Set(value string) error {
+	mode, err := parseMode(value)
+	if err != nil {
+		return err
+	}
+	*m = modeFlag(mode)
+	return nil
+}
+
+// parseMode parses a file mode such as 0600, 600 or 0o640.
+func parseMode(value string) (os.FileMode, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(value), "0o"), "0O")
+	mode, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil || mode == 0 || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q: must be octal permissions from 0001 to 0777, such as 0600", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// fileMode returns the mode to set on an output file, or 0 to leave it to
+// CreateAtomic. secret is whether the file may hold Secret documents.
+func fileMode(secret bool) os.FileMode {
+	switch {
+	case outputMode != 0:
+		return outputMode
+	case secret && decodedSecrets:
+		return secretFileMode
+	}
+	return 0
+}
+
+// dirMode returns the mode of the directories created for files of mode:
+// mode with the search bit of every class that can read or write the files,
+// and full access for the owner, who writes them. 0600 gives 0700 and 0644
+// gives 0755.
+func dirMode(mode os.FileMode) os.FileMode {
+	mode |= 0700
+	for _, shift := range []uint{3, 0} {
+		if mode>>shift&06 != 0 {
+			mode |= 01 << shift
+		}
+	}
+	return mode
+}
+
+// holdsSecret reports whether doc is a Secret or a list with Secret items.
+func holdsSecret(doc converter.Document) bool {
+	if doc.Kind() == "Secret" {
+		return true
+	}
+	object, ok := doc.Value.(*converter.Object)
+	if !ok {
+		return false
+	}
+	items, _ := object.Get("items")
+	list, _ := items.([]interface{})
+	for _, item := range list {
+		if item, ok := item.(*converter.Object); ok {
+			if kind, _ := item.Get("kind"); kind == "Secret" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// makeOutputDir creates dir and its missing parents: with dirMode(outputMode)
+// set exactly when -mode is given, or else with 0755 less the umask.
+// Directories that already exist are left as they are.
+func makeOutputDir(dir string) error {
+	if outputMode == 0 {
+		return os.MkdirAll(dir, 0755)
+	}
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); !os.IsNotExist(err) || filepath.Dir(d) == d {
+			break
+		}
+		missing = append(missing, d)
+	}
+	mode := dirMode(outputMode)
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return err
+	}
+	for _, d := range missing {
+		if err := os.Chmod(d, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createOutputFile starts writing the output file at path, with the mode of
+// fileMode(secret) when it is set.
+func createOutputFile(path string, secret bool) (*converter.AtomicFile, error) {
+	file, err := converter.CreateAtomic(path, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if mode := fileMode(secret); mode != 0 {
+		if err := file.Chmod(mode); err != nil {
+			file.Abort()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// writeOutputFile writes data to the output file at path as an AtomicFile,
+// with the mode of fileMode(secret) when it is set.
+func writeOutputFile(path string, data []byte, secret bool) error {
+	file, err := createOutputFile(path, secret)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Abort()
+		return err
+	}
+	return file.Commit()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{value: "0600", want: 0600},
+		{value: "600", want: 0600},
+		{value: "0o640", want: 0640},
+		{value: "0777", want: 0777},
+		{value: "", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "0800", wantErr: true},
+		{value: "1777", wantErr: true},
+		{value: "rw-------", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseMode(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseMode(%q) = %04o, %v, want %04o, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDirMode(t *testing.T) {
+	tests := map[os.FileMode]os.FileMode{0600: 0700, 0644: 0755, 0640: 0750, 0620: 0730, 0400: 0700, 0604: 0705}
+	for mode, want := range tests {
+		if got := dirMode(mode); got != want {
+			t.Errorf("dirMode(%04o) = %04o, want %04o", mode, got, want)
+		}
+	}
+}
+
+func TestFileMode(t *testing.T) {
+	defer func(mode os.FileMode, secrets bool) { outputMode, decodedSecrets = mode, secrets }(outputMode, decodedSecrets)
+
+	tests := []struct {
+		name    string
+		mode    os.FileMode
+		secrets bool
+		secret  bool
+		want    os.FileMode
+	}{
+		{name: "Default", want: 0},
+		{name: "Secret without -decode-secrets", secret: true, want: 0},
+		{name: "Secret with -decode-secrets", secrets: true, secret: true, want: secretFileMode},
+		{name: "Other file with -decode-secrets", secrets: true, want: 0},
+		{name: "-mode", mode: 0640, want: 0640},
+		{name: "-mode takes precedence", mode: 0644, secrets: true, secret: true, want: 0644},
+	}
+	for _, tt := range tests {
+		outputMode, decodedSecrets = tt.mode, tt.secrets
+		if got := fileMode(tt.secret); got != tt.want {
+			t.Errorf("%s: fileMode(%v) = %04o, want %04o", tt.name, tt.secret, got, tt.want)
+		}
+	}
+}
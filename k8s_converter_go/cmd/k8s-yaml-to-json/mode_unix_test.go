@@ -0,0 +1,60 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestModeFlag(t *testing.T) {
+	// The command inherits the umask, which must not apply to -mode
+	defer syscall.Umask(syscall.Umask(027))
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.yaml")
+	writeTree(t, dir, map[string]string{
+		"in.yaml": "apiVersion: v1\nkind: Secret\nmetadata:\n  name: token\ndata:\n  a: aGk=\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n",
+	})
+	assertMode := func(path string, want os.FileMode) {
+		t.Helper()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("mode of %s = %04o, want %04o", path, info.Mode().Perm(), want)
+		}
+	}
+	run := func(args ...string) {
+		t.Helper()
+		if _, stderr, code := runCommand(t, "", append([]string{"-input", input, "-force"}, args...)...); code != 0 {
+			t.Fatalf("%v: exit code = %d, stderr: %s", args, code, stderr)
+		}
+	}
+
+	run("-output", filepath.Join(dir, "default.json"))
+	assertMode(filepath.Join(dir, "default.json"), 0640)
+	run("-output", filepath.Join(dir, "mode.json"), "-mode", "0604")
+	assertMode(filepath.Join(dir, "mode.json"), 0604)
+	run("-output", filepath.Join(dir, "secrets.json"), "-decode-secrets")
+	assertMode(filepath.Join(dir, "secrets.json"), 0600)
+
+	// -mode applies to a file that is replaced too
+	run("-output", filepath.Join(dir, "default.json"), "-mode", "0600")
+	assertMode(filepath.Join(dir, "default.json"), 0600)
+
+	// Only the split files of Secrets default to 0600
+	run("-output", filepath.Join(dir, "split"), "-split", "-decode-secrets")
+	assertMode(filepath.Join(dir, "split", "secret-token.json"), 0600)
+	assertMode(filepath.Join(dir, "split", "configmap-config.json"), 0640)
+
+	// The directories created get the search bits of -mode, and existing
+	// directories are left as they are
+	run("-output", filepath.Join(dir, "split", "a", "b"), "-split", "-mode", "0604")
+	assertMode(filepath.Join(dir, "split"), 0750)
+	assertMode(filepath.Join(dir, "split", "a"), 0705)
+	assertMode(filepath.Join(dir, "split", "a", "b"), 0705)
+	assertMode(filepath.Join(dir, "split", "a", "b", "secret-token.json"), 0604)
+}
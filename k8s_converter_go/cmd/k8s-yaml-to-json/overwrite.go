@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Values of -overwrite.
+const (
+	// overwriteNever refuses to replace an existing output file.
+	overwriteNever = "never"
+	// overwriteAlways replaces existing output files, as -force does.
+	overwriteAlways = "always"
+	// overwritePrompt asks before replacing each existing output file when
+	// stdin is a terminal, and otherwise refuses as overwriteNever does.
+	overwritePrompt = "prompt"
+)
+
+// overwriteMode is the -overwrite setting.
+var overwriteMode = overwriteNever
+
+// claimedOutputs holds the outputs this run may write. Once a path has passed
+// checkOverwrite it can be written again without another check, so that
+// -watch replaces its own output after every change.
+var claimedOutputs = make(map[string]bool)
+
+// promptInput is where answers to overwrite prompts are read from.
+var promptInput *bufio.Reader
+
+// promptsAllowed reports whether overwrite prompts can be answered, which
+// requires stdin to be a terminal.
+var promptsAllowed = func() bool {
+	return !stdinIsPiped()
+}
+
+// overwriteError is returned when output files exist and may not be
+// overwritten. It lists every such file.
+type overwriteError struct {
+	paths []string
+}
+
+func (e *overwriteError) Error() string {
+	return fmt.Sprintf("refusing to overwrite existing output files (use -force or -overwrite always): %s", strings.Join(e.paths, ", "))
+}
+
+// checkOverwrite checks that each of paths either does not exist or may be
+// overwritten under overwriteMode, prompting for each existing file in prompt
+// mode. Only regular files are protected, so that outputs such as /dev/null
+// keep working. The error lists every path that may not be written.
+func checkOverwrite(paths []string) error {
+	var blocked []string
+	for _, path := range paths {
+		if claimedOutputs[path] {
+			continue
+		}
+		info, err := os.Stat(path)
+		exists := err == nil && info.Mode().IsRegular()
+		if exists && !allowOverwrite(path) {
+			blocked = append(blocked, path)
+			continue
+		}
+		claimedOutputs[path] = true
+	}
+	if len(blocked) > 0 {
+		return &outputError{&overwriteError{paths: blocked}}
+	}
+	return nil
+}
+
+// allowOverwrite reports whether the existing file at path may be replaced.
+func allowOverwrite(path string) bool {
+	switch overwriteMode {
+	case overwriteAlways:
+		return true
+	case overwritePrompt:
+		if !promptsAllowed() {
+			return false
+		}
+		return confirm(fmt.Sprintf("Overwrite %s? [y/N] ", path))
+	}
+	return false
+}
+
+// confirm prints question to stderr and reports whether the answer read from
+// stdin is yes.
+func confirm(question string) bool {
+	if promptInput == nil {
+		promptInput = bufio.NewReader(os.Stdin)
+	}
+	fmt.Fprint(stderr, question)
+	answer, err := promptInput.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// setOverwriteMode sets overwriteMode until the test ends.
+func setOverwriteMode(t *testing.T, mode string) {
+	t.Helper()
+	previous := overwriteMode
+	overwriteMode = mode
+	t.Cleanup(func() { overwriteMode = previous })
+}
+
+func TestCheckOverwrite(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		terminal    bool
+		answers     string
+		wantBlocked []string
+	}{
+		{name: "Never", mode: overwriteNever, wantBlocked: []string{"a.json", "b.json"}},
+		{name: "Always", mode: overwriteAlways},
+		{name: "Prompt", mode: overwritePrompt, terminal: true, answers: "y\nno\n", wantBlocked: []string{"b.json"}},
+		{name: "Prompt without a terminal", mode: overwritePrompt, answers: "y\ny\n", wantBlocked: []string{"a.json", "b.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, map[string]string{"a.json": "{}\n", "b.json": "{}\n", "sub/c.json": "{}\n"})
+			paths := []string{
+				filepath.Join(dir, "a.json"),
+				filepath.Join(dir, "b.json"),
+				filepath.Join(dir, "new.json"),
+				filepath.Join(dir, "sub"),
+			}
+			setOverwriteMode(t, tt.mode)
+			previousAllowed, previousInput := promptsAllowed, promptInput
+			promptsAllowed = func() bool { return tt.terminal }
+			promptInput = bufio.NewReader(strings.NewReader(tt.answers))
+			t.Cleanup(func() { promptsAllowed, promptInput = previousAllowed, previousInput })
+
+			var want []string
+			for _, name := range tt.wantBlocked {
+				want = append(want, filepath.Join(dir, name))
+			}
+			err := checkOverwrite(paths)
+			var overwriteErr *overwriteError
+			if errors.As(err, &overwriteErr) {
+				if !reflect.DeepEqual(overwriteErr.paths, want) {
+					t.Errorf("checkOverwrite() blocked %v, want %v", overwriteErr.paths, want)
+				}
+				if exitCode(err) != exitOutput || errorCode(err) != errorOutputExists {
+					t.Errorf("checkOverwrite() error classified as %d, %s", exitCode(err), errorCode(err))
+				}
+			} else if err != nil || want != nil {
+				t.Fatalf("checkOverwrite() error = %v, want %v blocked", err, want)
+			}
+
+			// Paths that passed may be written again without being asked
+			// about, as -watch does
+			if err := checkOverwrite(paths[2:]); err != nil {
+				t.Errorf("checkOverwrite() of claimed paths error = %v", err)
+			}
+		})
+	}
+}
+
+func TestOverwriteBatch(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"a.yaml":   "kind: Service\nmetadata:\n  name: web\n",
+		"b.yaml":   "kind: Deployment\nmetadata:\n  name: web\n",
+		"c.yaml":   "kind: ConfigMap\nmetadata:\n  name: config\n",
+		"all.yaml": "kind: Service\nmetadata:\n  name: api\n---\nkind: Secret\nmetadata:\n  name: token\n",
+	})
+	files, _, err := findYAMLFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setOverwriteMode(t, overwriteNever)
+
+	tests := []struct {
+		name     string
+		split    bool
+		existing []string
+	}{
+		{name: "Files", existing: []string{"a.json", "c.json"}},
+		{name: "Split", split: true, existing: []string{"service-web.json", "secret-token.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			var want []string
+			for _, name := range tt.existing {
+				writeTree(t, outputDir, map[string]string{name: "hand-edited\n"})
+				want = append(want, filepath.Join(outputDir, name))
+			}
+			result := convertFiles(root, files, batchOptions{outputDir: outputDir, split: tt.split, workers: 2}, converter.Options{})
+			var overwriteErr *overwriteError
+			if !errors.As(result.err, &overwriteErr) {
+				t.Fatalf("convertFiles() error = %v, want *overwriteError", result.err)
+			}
+			if !reflect.DeepEqual(overwriteErr.paths, want) {
+				t.Errorf("convertFiles() blocked %v, want every existing output %v", overwriteErr.paths, want)
+			}
+			entries, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tt.existing) {
+				t.Errorf("convertFiles() wrote files despite existing outputs: %v", entries)
+			}
+			for _, path := range want {
+				if data, _ := os.ReadFile(path); string(data) != "hand-edited\n" {
+					t.Errorf("%s was overwritten", path)
+				}
+			}
+		})
+	}
+}
+
+func TestOverwriteFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"config.yaml": "kind: ConfigMap\nmetadata:\n  name: config\n",
+		"config.json": "hand-edited\n",
+	})
+	input, output := filepath.Join(dir, "config.yaml"), filepath.Join(dir, "config.json")
+
+	_, errOutput, code := runCommand(t, "", "-input", input, "-output", output)
+	if code != exitOutput || !strings.Contains(errOutput, "refusing to overwrite existing output files") {
+		t.Errorf("exit code = %d, stderr = %q", code, errOutput)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "hand-edited\n" {
+		t.Errorf("output was overwritten without -force")
+	}
+
+	for _, args := range [][]string{{"-force"}, {"-overwrite", "always"}} {
+		if _, errOutput, code := runCommand(t, "", append([]string{"-input", input, "-output", output}, args...)...); code != exitOK {
+			t.Errorf("%v: exit code = %d, stderr = %q", args, code, errOutput)
+		}
+	}
+	if _, _, code := runCommand(t, "", "-input", input, "-overwrite", "sometimes"); code != exitUsage {
+		t.Errorf("invalid -overwrite: exit code = %d, want %d", code, exitUsage)
+	}
+}
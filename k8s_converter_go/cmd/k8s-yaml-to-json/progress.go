@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// progressMinFiles is the number of files a batch must have more than
+	// for its progress to be reported.
+	progressMinFiles = 100
+	// progressInterval is the number of files between the progress lines
+	// logged when stderr is not a terminal.
+	progressInterval = 250
+	// progressRedraw is the shortest time between two updates of the
+	// progress line on a terminal.
+	progressRedraw = 100 * time.Millisecond
+)
+
+// progress reports the number of files of a batch finished so far, as a
+// line updated in place when stderr is a terminal, or else as a log line
+// every progressInterval files. It is safe for concurrent use, and a nil
+// *progress reports nothing.
+type progress struct {
+	mu       sync.Mutex
+	total    int
+	done     int
+	failed   int
+	start    time.Time
+	terminal bool
+	// drawn is when the terminal line was last updated, and logged the
+	// number of files done at the last log line.
+	drawn  time.Time
+	logged int
+}
+
+// startProgress returns the progress of a batch of total files, or nil when
+// the batch is too small for its progress to be worth reporting or -quiet is
+// set. The line is only updated in place on a terminal and when nothing else
+// is logged while the files are converted, so not with -v or -log-format
+// json.
+func startProgress(total int) *progress {
+	if quiet || total <= progressMinFiles {
+		return nil
+	}
+	return &progress{
+		total:    total,
+		start:    time.Now(),
+		terminal: verbosity == 0 && !jsonLogs && isTerminal(stderr),
+	}
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// add counts a finished file, which failed if failed is set.
+func (p *progress) add(failed bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if failed {
+		p.failed++
+	}
+	switch {
+	case p.terminal && time.Since(p.drawn) >= progressRedraw:
+		p.drawn = time.Now()
+		fmt.Fprintf(stderr, "\r%s", p.line())
+	case !p.terminal && p.done%progressInterval == 0:
+		p.log()
+	}
+}
+
+// finish reports the final count, ending the line on a terminal, so that it
+// is never left showing a partial count.
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.terminal {
+		fmt.Fprintf(stderr, "\r%s\n", p.line())
+		return
+	}
+	if p.logged != p.done {
+		p.log()
+	}
+}
+
+// log logs the progress line.
+func (p *progress) log() {
+	p.logged = p.done
+	attrs := []logAttr{{"done", p.done}, {"total", p.total}, {"failed", p.failed}, durationAttr(time.Since(p.start))}
+	logEvent(levelInfo, p.line(), attrs...)
+}
+
+// line returns the progress line, such as "473/3921 files, 2 errors, 12s
+// elapsed".
+func (p *progress) line() string {
+	elapsed := time.Since(p.start).Round(time.Second)
+	return fmt.Sprintf("%d/%d files, %d errors, %s elapsed", p.done, p.total, p.failed, elapsed)
+}
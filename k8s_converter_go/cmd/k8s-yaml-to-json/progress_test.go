@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestStartProgress(t *testing.T) {
+	if p := startProgress(progressMinFiles); p != nil {
+		t.Errorf("startProgress(%d) = %+v, want nil for a small batch", progressMinFiles, p)
+	}
+	if p := startProgress(progressMinFiles + 1); p == nil || p.terminal {
+		t.Errorf("startProgress(%d) = %+v, want the progress of a batch logged to a non-terminal", progressMinFiles+1, p)
+	}
+	defer func(saved bool) { quiet = saved }(quiet)
+	quiet = true
+	if p := startProgress(progressMinFiles + 1); p != nil {
+		t.Errorf("startProgress() with -quiet = %+v, want nil", p)
+	}
+}
+
+func TestProgressLog(t *testing.T) {
+	errOutput := captureStderr(t)
+	p := startProgress(2*progressInterval + 10)
+
+	// Count from concurrent workers, as convertFiles does
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < p.total; i += 8 {
+				p.add(i%100 == 0)
+			}
+		}(w)
+	}
+	wg.Wait()
+	p.finish()
+	p.finish()
+
+	lines := strings.Split(strings.TrimSpace(errOutput.String()), "\n")
+	want := []string{
+		fmt.Sprintf("%d/%d files,", progressInterval, p.total),
+		fmt.Sprintf("%d/%d files,", 2*progressInterval, p.total),
+		fmt.Sprintf("%d/%d files, 6 errors, 0s elapsed", p.total, p.total),
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("stderr = %q, want %d lines", lines, len(want))
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %q, want it to start with %q", i+1, lines[i], prefix)
+		}
+	}
+}
+
+func TestProgressTerminal(t *testing.T) {
+	errOutput := captureStderr(t)
+	p := &progress{total: 3, start: time.Now(), terminal: true}
+	p.add(false)
+	p.add(true)
+	p.add(false)
+	p.finish()
+
+	got := errOutput.String()
+	if !strings.HasPrefix(got, "\r1/3 files, 0 errors") || !strings.HasSuffix(got, "\r3/3 files, 1 errors, 0s elapsed\n") {
+		t.Errorf("stderr = %q, want the first count redrawn as the final line", got)
+	}
+	if strings.Count(got, "\n") != 1 {
+		t.Errorf("stderr = %q, want a single line", got)
+	}
+}
+
+func TestConvertFilesProgress(t *testing.T) {
+	root := t.TempDir()
+	tree := map[string]string{"broken.yaml": "key: [\n"}
+	for i := 0; i < progressMinFiles; i++ {
+		tree[fmt.Sprintf("app-%03d.yaml", i)] = "kind: ConfigMap\n"
+	}
+	writeTree(t, root, tree)
+	files, _, err := findYAMLFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, enabled := range []bool{true, false} {
+		errOutput := captureStderr(t)
+		batch := batchOptions{outputDir: t.TempDir(), workers: 4, progress: enabled}
+		convertFiles(root, files, batch, converter.Options{})
+		want := fmt.Sprintf("%d/%d files, 1 errors", len(files), len(files))
+		if got := strings.Contains(errOutput.String(), want); got != enabled {
+			t.Errorf("progress %v: stderr = %q, want %q: %v", enabled, errOutput, want, enabled)
+		}
+	}
+}
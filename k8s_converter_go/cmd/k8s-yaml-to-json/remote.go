@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// fetchTimeout bounds the whole request when reading input from a URL,
+// including following redirects and reading the body.
+const fetchTimeout = 30 * time.Second
+
+// requestHeader holds the headers sent when fetching input from a URL, set
+// with -header.
+var requestHeader = http.Header{}
+
+// headerFlag is a repeatable flag that adds "Name: value" headers.
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	var headers []string
+	for name, values := range h {
+		for _, value := range values {
+			headers = append(headers, name+": "+value)
+		}
+	}
+	return strings.Join(headers, ", ")
+}
+
+func (h headerFlag) Set(value string) error {
+	name, headerValue, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("header must have the form 'Name: value'")
+	}
+	http.Header(h).Add(name, strings.TrimSpace(headerValue))
+	return nil
+}
+
+// isURL reports whether input is an http or https URL.
+func isURL(input string) bool {
+	lower := strings.ToLower(input)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// inputPath returns the path used to check the extension of an input: the
+// URL path for URLs, or the input itself otherwise.
+func inputPath(input string) string {
+	if !isURL(input) {
+		return input
+	}
+	u, err := url.Parse(input)
+	if err != nil {
+		return input
+	}
+	return u.Path
+}
+
+// openURL fetches rawURL with the headers from -header, following redirects,
+// and returns the response body. Responses other than 200 OK are errors.
+func openURL(rawURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range requestHeader {
+		req.Header[name] = values
+	}
+
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", rawURL, resp.Status)
+	}
+	return resp.Body, nil
+}
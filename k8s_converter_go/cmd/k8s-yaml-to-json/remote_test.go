@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old.yaml":
+			http.Redirect(w, r, "/deployment.yaml", http.StatusFound)
+		case "/deployment.yaml":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, "kind: Deployment\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(saved http.Header) { requestHeader = saved }(requestHeader)
+	requestHeader = http.Header{}
+	if err := headerFlag(requestHeader).Set("Authorization: Bearer secret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	data, err := readInputFile(server.URL + "/old.yaml")
+	if err != nil {
+		t.Fatalf("readInputFile() error = %v", err)
+	}
+	if string(data) != "kind: Deployment\n" {
+		t.Errorf("readInputFile() = %q, want the redirected content", data)
+	}
+
+	_, err = readInputFile(server.URL + "/missing.yaml")
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("readInputFile() error = %v, want the 404 status", err)
+	}
+
+	requestHeader = http.Header{}
+	_, err = readInputFile(server.URL + "/deployment.yaml")
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("readInputFile() without header error = %v, want the 401 status", err)
+	}
+}
+
+func TestHeaderFlag(t *testing.T) {
+	header := http.Header{}
+	if err := headerFlag(header).Set("X-Token:  abc "); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got := header.Get("X-Token"); got != "abc" {
+		t.Errorf("header X-Token = %q, want %q", got, "abc")
+	}
+	if err := headerFlag(header).Set("no separator"); err == nil {
+		t.Errorf("Set() expected error for a value without a colon")
+	}
+}
+
+func TestInputPath(t *testing.T) {
+	tests := map[string]string{
+		"deploy.yaml": "deploy.yaml",
+		"https://example.com/raw/deploy.yaml?token=abc": "/raw/deploy.yaml",
+		"HTTP://example.com/app.json":                   "/app.json",
+	}
+	for input, want := range tests {
+		if got := inputPath(input); got != want {
+			t.Errorf("inputPath(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Statuses of a file in a batch report.
+const (
+	statusConverted = "converted"
+	statusSkipped   = "skipped"
+	statusFailed    = "failed"
+	// statusNotAttempted is a file not converted because an earlier file
+	// failed without -keep-going.
+	statusNotAttempted = "not_attempted"
+	// statusUpToDate and statusStale are the files of a -diff run whose
+	// existing output matches the conversion, or differs from it.
+	// statusUpToDate is also a file skipped by -incremental.
+	statusUpToDate = "up_to_date"
+	statusStale    = "stale"
+)
+
+// fileReport is the outcome of a single file of a batch conversion.
+type fileReport struct {
+	File   string `json:"file"`
+	Status string `json:"status"`
+	// Outputs are the JSON files written for a converted file, compared
+	// with -diff, or found up to date with -incremental.
+	Outputs    []string `json:"outputs,omitempty"`
+	DurationMS float64  `json:"duration_ms"`
+	// Error describes the failure of a failed file, with the same fields
+	// as -error-format json.
+	Error *jsonError `json:"error,omitempty"`
+}
+
+// batchReport is the summary written by -report.
+type batchReport struct {
+	// Processed counts the files that were converted, up to date, skipped,
+	// stale or failed; files not attempted after a failure without
+	// -keep-going are counted by NotAttempted instead.
+	Processed    int `json:"processed"`
+	Converted    int `json:"converted"`
+	Skipped      int `json:"skipped"`
+	Failed       int `json:"failed"`
+	NotAttempted int `json:"not_attempted,omitempty"`
+	// Stale counts the files whose output is out of date with -diff; the
+	// files that are up to date count as converted.
+	Stale int `json:"stale,omitempty"`
+	// UpToDate counts the files skipped by -incremental, and Pruned lists
+	// the stale outputs removed by -prune.
+	UpToDate int      `json:"up_to_date,omitempty"`
+	Pruned   []string `json:"pruned,omitempty"`
+	// Excluded and NotYAML count the files skipped without being read, for
+	// matching an -exclude pattern or not having a YAML extension. They are
+	// not included in Processed and have no entry in Files.
+	Excluded int          `json:"excluded,omitempty"`
+	NotYAML  int          `json:"not_yaml,omitempty"`
+	Files    []fileReport `json:"files"`
+}
+
+// newBatchReport returns the report of a batch conversion.
+func newBatchReport(result batchResult) batchReport {
+	files := result.files
+	if files == nil {
+		files = []fileReport{}
+	}
+	return batchReport{
+		Processed:    result.converted + result.upToDate + result.skipped + result.failed + result.stale,
+		Converted:    result.converted,
+		Skipped:      result.skipped,
+		Failed:       result.failed,
+		NotAttempted: result.notAttempted,
+		Stale:        result.stale,
+		UpToDate:     result.upToDate,
+		Pruned:       result.pruned,
+		Excluded:     result.unread.excluded,
+		NotYAML:      result.unread.notYAML,
+		Files:        files,
+	}
+}
+
+// reportBatch prints the totals of a batch conversion and writes its report
+// to reportFile, or prints the status of every file as a table when
+// reportFile is empty.
+func reportBatch(result batchResult, reportFile string) error {
+	// A dry run has already printed the plan of every file
+	if reportFile == "" && !result.dryRun {
+		printBatchTable(result)
+	}
+	printBatchSummary(result)
+	if reportFile == "" {
+		return nil
+	}
+	return writeReport(reportFile, result)
+}
+
+// writeReport writes the report of a batch conversion to path as JSON.
+func writeReport(path string, result batchResult) error {
+	data, err := json.MarshalIndent(newBatchReport(result), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeOutputFile(path, append(data, '\n'), false); err != nil {
+		return &outputError{fmt.Errorf("writing report file: %w", err)}
+	}
+	return nil
+}
+
+// printBatchTable prints the status and duration of every file of a batch
+// conversion as a table, or with -log-format json as a log line per file. A
+// batch without failures prints it as a success message.
+func printBatchTable(result batchResult) {
+	if len(result.files) == 0 || (quiet && result.failed == 0) {
+		return
+	}
+	if jsonLogs {
+		for _, file := range result.files {
+			attrs := []logAttr{{"file", file.File}, {"status", file.Status}}
+			if file.Status != statusNotAttempted {
+				attrs = append(attrs, logAttr{"duration_ms", file.DurationMS})
+			}
+			logEvent(levelInfo, file.Status+" "+file.File, attrs...)
+		}
+		return
+	}
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATUS\tTIME\tFILE")
+	for _, file := range result.files {
+		duration := "-"
+		if file.Status != statusNotAttempted {
+			duration = formatDuration(file.DurationMS)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", file.Status, duration, file.File)
+	}
+	writer.Flush()
+	fmt.Fprint(stderr, table.String())
+}
+
+// milliseconds returns d in milliseconds, rounded to microseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// formatDuration formats a duration in milliseconds for printBatchTable.
+func formatDuration(ms float64) string {
+	return time.Duration(ms * float64(time.Millisecond)).Round(time.Microsecond * 100).String()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestBatchReport(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"a.yaml":        "kind: Deployment\n",
+		"b/broken.yaml": "This is not valid: YAML: content\n",
+		"c.yaml":        "kind: Service\n",
+		"d.yaml":        "kind: Secret\n",
+	})
+	files, _, err := findYAMLFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputDir := filepath.Join(t.TempDir(), "out")
+	parseErr := &jsonError{
+		Error:    errorYAMLParse,
+		File:     filepath.Join(root, "b/broken.yaml"),
+		Line:     1,
+		Column:   24,
+		Document: 1,
+		Message:  "mapping values are not allowed in this context",
+	}
+
+	tests := []struct {
+		name     string
+		failFast bool
+		want     batchReport
+	}{
+		{
+			name: "Every file",
+			want: batchReport{Processed: 4, Converted: 2, Skipped: 1, Failed: 1, Files: []fileReport{
+				{File: filepath.Join(root, "a.yaml"), Status: statusConverted, Outputs: []string{filepath.Join(outputDir, "a.json")}},
+				{File: filepath.Join(root, "b/broken.yaml"), Status: statusFailed, Error: parseErr},
+				{File: filepath.Join(root, "c.yaml"), Status: statusConverted, Outputs: []string{filepath.Join(outputDir, "c.json")}},
+				{File: filepath.Join(root, "d.yaml"), Status: statusSkipped},
+			}},
+		},
+		{
+			name:     "Fail fast",
+			failFast: true,
+			want: batchReport{Processed: 2, Converted: 1, Failed: 1, NotAttempted: 2, Files: []fileReport{
+				{File: filepath.Join(root, "a.yaml"), Status: statusConverted, Outputs: []string{filepath.Join(outputDir, "a.json")}},
+				{File: filepath.Join(root, "b/broken.yaml"), Status: statusFailed, Error: parseErr},
+				{File: filepath.Join(root, "c.yaml"), Status: statusNotAttempted},
+				{File: filepath.Join(root, "d.yaml"), Status: statusNotAttempted},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := convertFiles(root, files, batchOptions{outputDir: outputDir, failFast: tt.failFast, workers: 1}, converter.Options{Kinds: []string{"Deployment", "Service"}})
+			reportFile := filepath.Join(t.TempDir(), "report.json")
+			if err := writeReport(reportFile, result); err != nil {
+				t.Fatalf("writeReport() error = %v", err)
+			}
+			data, err := os.ReadFile(reportFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got batchReport
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("report is not valid JSON: %v", err)
+			}
+			for i := range got.Files {
+				if got.Files[i].DurationMS < 0 || (got.Files[i].Status == statusNotAttempted && got.Files[i].DurationMS != 0) {
+					t.Errorf("file %s duration = %v", got.Files[i].File, got.Files[i].DurationMS)
+				}
+				got.Files[i].DurationMS = 0
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("report = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"batch/a.yaml":   "kind: Deployment\n",
+		"batch/bad.yaml": "This is not valid: YAML: content\n",
+		"single.yaml":    "kind: Deployment\n",
+	})
+	reportFile := filepath.Join(dir, "report.json")
+
+	// The report is written even though a file failed
+	_, errOutput, code := runCommand(t, "", "-input", filepath.Join(dir, "batch"), "-output", filepath.Join(dir, "out"), "-report", reportFile)
+	if code != exitInvalid {
+		t.Errorf("exit code = %d, want %d; stderr:\n%s", code, exitInvalid, errOutput)
+	}
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	var report batchReport
+	if err := json.Unmarshal(data, &report); err != nil || report.Converted != 1 || report.Failed != 1 {
+		t.Errorf("report = %s, error = %v", data, err)
+	}
+
+	if _, _, code := runCommand(t, "", "-input", filepath.Join(dir, "single.yaml"), "-report", reportFile); code != exitUsage {
+		t.Errorf("-report with a single file: exit code = %d, want %d", code, exitUsage)
+	}
+	if _, _, code := runCommand(t, "", "-input", filepath.Join(dir, "batch"), "-output", filepath.Join(dir, "out"), "-report", filepath.Join(dir, "missing/report.json")); code != exitOutput {
+		t.Errorf("unwritable report: exit code = %d, want %d", code, exitOutput)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// shutdownTimeout is how long the server waits for in-flight requests to
+// finish after it has been asked to stop.
+const shutdownTimeout = 10 * time.Second
+
+// yamlContentTypes are the request content types accepted by /convert.
+var yamlContentTypes = map[string]bool{
+	"application/yaml":   true,
+	"application/x-yaml": true,
+	"text/yaml":          true,
+	"text/x-yaml":        true,
+}
+
+// formatContentTypes are the response content types of the -format outputs
+// other than JSON.
+var formatContentTypes = map[string]string{
+	converter.FormatNDJSON:   "application/x-ndjson",
+	converter.FormatGo:       "text/plain; charset=utf-8",
+	converter.FormatHCL:      "text/plain; charset=utf-8",
+	converter.FormatCSV:      "text/csv; charset=utf-8",
+	converter.FormatMarkdown: "text/markdown; charset=utf-8",
+	converter.FormatDOT:      "text/vnd.graphviz; charset=utf-8",
+}
+
+// newHandler returns the HTTP handler for server mode, converting request
+// bodies with opts.
+func newHandler(opts converter.Options) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/convert", func(w http.ResponseWriter, r *http.Request) {
+		handleConvert(w, r, opts)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// handleConvert converts the YAML request body to JSON, or to the output
+// format of opts.
+func handleConvert(w http.ResponseWriter, r *http.Request, opts converter.Options) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSONError(w, http.StatusMethodNotAllowed, "method %s not allowed, use POST", r.Method)
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !yamlContentTypes[mediaType] {
+		writeJSONError(w, http.StatusUnsupportedMediaType, "unsupported content type %q, use application/yaml or text/yaml", r.Header.Get("Content-Type"))
+		return
+	}
+
+	// The request body is limited like any other input, by -max-size, and so
+	// is its content once decompressed
+	opts.MaxSize = maxInputSize
+	requestBody := r.Body
+	if maxInputSize > 0 {
+		requestBody = http.MaxBytesReader(w, r.Body, maxInputSize)
+	}
+	body, err := io.ReadAll(requestBody)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "request body exceeds the -max-size limit of %s", formatSize(tooLarge.Limit))
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "error reading request body: %v", err)
+		return
+	}
+
+	// Warnings such as duplicate keys have nowhere to go in a response
+	opts.Reverse = false
+	opts.Warn = nil
+	result, err := converter.Convert(body, opts)
+	var sizeErr *converter.SizeLimitError
+	if errors.As(err, &sizeErr) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "request body exceeds the -max-size limit of %s", formatSize(sizeErr.Limit))
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%s", describeError("request", err))
+		return
+	}
+
+	contentType, ok := formatContentTypes[opts.Format]
+	if !ok {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(result)
+	if len(result) > 0 && result[len(result)-1] != '\n' {
+		w.Write([]byte("\n"))
+	}
+}
+
+// writeJSONError writes an error response with a JSON body of the form
+// {"error": "message"}.
+func writeJSONError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// serve runs the HTTP server on addr until it receives SIGINT or SIGTERM,
+// then waits for in-flight requests to finish before returning.
+func serve(addr string, opts converter.Options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           newHandler(opts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	infof("Serving on %s", addr)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
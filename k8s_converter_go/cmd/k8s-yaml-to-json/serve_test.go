@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestHandleConvert(t *testing.T) {
+	handler := newHandler(converter.Options{Compact: true})
+	defer func(size int64) { maxInputSize = size }(maxInputSize)
+	maxInputSize = 1 << 20
+
+	// A small gzip body that decompresses past the limit
+	var bomb bytes.Buffer
+	writer := gzip.NewWriter(&bomb)
+	writer.Write([]byte("kind: " + strings.Repeat("a", 2<<20)))
+	writer.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+		wantError   string
+	}{
+		{
+			name:        "application/yaml",
+			method:      http.MethodPost,
+			contentType: "application/yaml",
+			body:        "kind: Service\nmetadata:\n  name: web\n",
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"kind":"Service","metadata":{"name":"web"}}` + "\n",
+		},
+		{
+			name:        "text/yaml with charset",
+			method:      http.MethodPost,
+			contentType: "text/yaml; charset=utf-8",
+			body:        "kind: A\n---\nkind: B\n",
+			wantStatus:  http.StatusOK,
+			wantBody:    `[{"kind":"A"},{"kind":"B"}]` + "\n",
+		},
+		{
+			name:        "parse error",
+			method:      http.MethodPost,
+			contentType: "application/yaml",
+			body:        "kind: [\n",
+			wantStatus:  http.StatusBadRequest,
+			wantError:   "request:2: did not find expected node content",
+		},
+		{
+			name:        "oversized body",
+			method:      http.MethodPost,
+			contentType: "application/yaml",
+			body:        "kind: " + strings.Repeat("a", 1<<20),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantError:   "request body exceeds the -max-size limit of 1MiB",
+		},
+		{
+			name:        "body decompressed past the limit",
+			method:      http.MethodPost,
+			contentType: "application/yaml",
+			body:        bomb.String(),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantError:   "request body exceeds the -max-size limit of 1MiB",
+		},
+		{
+			name:        "unsupported content type",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        "{}",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/convert", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %q)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantStatus != http.StatusOK {
+				var response struct{ Error string }
+				if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || response.Error == "" {
+					t.Fatalf("error body %q is not a JSON error: %v", rec.Body.String(), err)
+				}
+				if tt.wantError != "" && response.Error != tt.wantError {
+					t.Errorf("error = %q, want %q", response.Error, tt.wantError)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleConvertFormats(t *testing.T) {
+	body := "kind: Service\nmetadata:\n  name: web\n"
+	tests := []struct {
+		format      string
+		contentType string
+		wantBody    string
+	}{
+		{format: converter.FormatJSON, contentType: "application/json", wantBody: `"kind": "Service"`},
+		{format: converter.FormatNDJSON, contentType: "application/x-ndjson", wantBody: `{"kind":"Service","metadata":{"name":"web"}}`},
+		{format: converter.FormatCSV, contentType: "text/csv; charset=utf-8", wantBody: "Service"},
+		{format: converter.FormatMarkdown, contentType: "text/markdown; charset=utf-8", wantBody: "| Service |"},
+		{format: converter.FormatDOT, contentType: "text/vnd.graphviz; charset=utf-8", wantBody: "digraph"},
+		{format: converter.FormatGo, contentType: "text/plain; charset=utf-8", wantBody: "package fixtures"},
+		{format: converter.FormatHCL, contentType: "text/plain; charset=utf-8", wantBody: "kubernetes_manifest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/convert", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/yaml")
+			rec := httptest.NewRecorder()
+			newHandler(converter.Options{Format: tt.format}).ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d (body %q)", rec.Code, http.StatusOK, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newHandler(converter.Options{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
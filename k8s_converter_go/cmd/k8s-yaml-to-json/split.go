@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// nameTemplate is the -name-template template that names the files of split
+// output, or nil to name them by splitFileName.
+var nameTemplate *template.Template
+
+// nameFields are the fields of the document a -name-template template is
+// executed with.
+type nameFields struct {
+	Kind       string
+	Name       string
+	Namespace  string
+	APIVersion string
+	// SourceBase is the base name of the input file without its extension,
+	// such as web for manifests/web.yaml.
+	SourceBase string
+	// Index is the 1-based position of the document in the input, and Item
+	// the 1-based position of an item exploded from a list, or 0.
+	Index int
+	Item  int
+}
+
+// parseNameTemplate parses a -name-template template. It has the helper
+// functions of -template, such as lower, default and replace.
+func parseNameTemplate(text string) (*template.Template, error) {
+	return template.New("name-template").Funcs(templateFuncs).Parse(text)
+}
+
+// splitFileName returns the output file name for a document in split mode:
+// <kind>-<name>.json, prefixed with the namespace when the document has one.
+// Documents without a kind or name are named after their position in the
+// stream, such as doc-3.json, or doc-3-2.json for the second item of an
+// exploded list.
+func splitFileName(doc converter.Document) string {
+	kind, name := doc.Kind(), doc.Name()
+	if kind == "" || name == "" {
+		if doc.Item > 0 {
+			return fmt.Sprintf("doc-%d-%d.json", doc.Index, doc.Item)
+		}
+		return fmt.Sprintf("doc-%d.json", doc.Index)
+	}
+	parts := []string{strings.ToLower(kind), name}
+	if namespace := doc.Namespace(); namespace != "" {
+		parts = append([]string{namespace}, parts...)
+	}
+	return sanitizeFileName(strings.Join(parts, "-")) + ".json"
+}
+
+// sanitizeFileName replaces characters that are not safe in file names.
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// splitPath returns the path in dir of the output file of doc, a document of
+// inputFile: named by nameTemplate when it is set, or else by splitFileName.
+func splitPath(inputFile, dir string, doc converter.Document) (string, error) {
+	if nameTemplate == nil {
+		return filepath.Join(dir, splitFileName(doc)), nil
+	}
+	source := filepath.Base(trimGzipSuffix(inputFile))
+	if inputFile == stdinInput {
+		source = "stdin"
+	}
+	var name strings.Builder
+	err := nameTemplate.Execute(&name, nameFields{
+		Kind:       doc.Kind(),
+		Name:       doc.Name(),
+		Namespace:  doc.Namespace(),
+		APIVersion: doc.APIVersion(),
+		SourceBase: strings.TrimSuffix(source, filepath.Ext(source)),
+		Index:      doc.Index,
+		Item:       doc.Item,
+	})
+	if err == nil {
+		var clean string
+		if clean, err = cleanOutputName(name.String()); err == nil {
+			return filepath.Join(dir, clean), nil
+		}
+	}
+	return "", &outputError{fmt.Errorf("-name-template for %s document %d: %w", displayName(inputFile), doc.Index, err)}
+}
+
+// cleanOutputName returns name, a slash-separated path relative to the output
+// directory, with each element made safe by sanitizeFileName. Absolute paths
+// and ".." elements, which could write outside the output directory, are
+// errors.
+func cleanOutputName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%q is an absolute path", name)
+	}
+	var elements []string
+	for _, element := range strings.Split(name, "/") {
+		switch element {
+		case "", ".":
+			continue
+		case "..":
+			return "", fmt.Errorf("%q leaves the output directory", name)
+		}
+		elements = append(elements, sanitizeFileName(element))
+	}
+	if len(elements) == 0 {
+		return "", errors.New("the file name is empty")
+	}
+	return filepath.Join(elements...), nil
+}
+
+// marshalSplitDocument encodes doc as the content of its own output file: a
+// JSON document, or a line of NDJSON with -format ndjson.
+func marshalSplitDocument(doc converter.Document, opts converter.Options) ([]byte, error) {
+	ndjson := opts.Format == converter.FormatNDJSON
+	if ndjson {
+		opts.Compact = true
+	}
+	data, err := converter.MarshalDocuments([]interface{}{doc.Value}, opts)
+	if err == nil && ndjson && !opts.Canonical {
+		data = append(data, '\n')
+	}
+	return data, err
+}
+
+// writeSplit writes each document to its own JSON file in dir and returns the
+// paths written. claimed maps output paths already used in this run to the
+// input that produced them. If two documents would be written to the same
+// path, nothing is written and an error listing every collision is returned.
+// With dryRun set the documents are encoded but nothing is written, and the
+// paths that would have been written are returned.
+func writeSplit(inputFile string, documents []converter.Document, dir string, opts converter.Options, claimed map[string]string, dryRun bool) ([]string, error) {
+	// Compute every output path before writing anything
+	paths := make([]string, len(documents))
+	owners := make(map[string]string)
+	var collisions []string
+	for i, doc := range documents {
+		path, err := splitPath(inputFile, dir, doc)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = path
+		owner := fmt.Sprintf("%s document %d", displayName(inputFile), doc.Index)
+		if previous, ok := claimed[paths[i]]; ok {
+			collisions = append(collisions, fmt.Sprintf("%s and %s both map to %s", previous, owner, paths[i]))
+		} else if previous, ok := owners[paths[i]]; ok {
+			collisions = append(collisions, fmt.Sprintf("%s and %s both map to %s", previous, owner, paths[i]))
+		}
+		owners[paths[i]] = owner
+	}
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return nil, &outputError{fmt.Errorf("output file name collision: %s", strings.Join(collisions, "; "))}
+	}
+	for path, owner := range owners {
+		claimed[path] = owner
+	}
+
+	if !dryRun {
+		if err := checkOverwrite(paths); err != nil {
+			return nil, err
+		}
+		if err := makeOutputDir(dir); err != nil {
+			return nil, &outputError{fmt.Errorf("writing output files: %w", err)}
+		}
+	}
+	// Encode every document before writing any, so that a document that
+	// cannot be encoded leaves none of the files of the input written
+	outputs := make([][]byte, len(documents))
+	for i, doc := range documents {
+		jsonData, err := marshalSplitDocument(doc, opts)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = jsonData
+	}
+	if dryRun {
+		return paths, nil
+	}
+	for i, doc := range documents {
+		// A -name-template may name files in subdirectories
+		if err := makeOutputDir(filepath.Dir(paths[i])); err != nil {
+			return nil, &outputError{fmt.Errorf("writing output files: %w", err)}
+		}
+		if err := writeOutputFile(paths[i], outputs[i], holdsSecret(doc)); err != nil {
+			return nil, &outputError{fmt.Errorf("writing output files: %w", err)}
+		}
+	}
+	return paths, nil
+}
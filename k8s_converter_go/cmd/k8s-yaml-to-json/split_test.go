@@ -0,0 +1,176 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestSplitFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "Kind and name", content: "kind: Deployment\nmetadata:\n  name: nginx\n", want: "deployment-nginx.json"},
+		{name: "Namespaced", content: "kind: Service\nmetadata:\n  name: web\n  namespace: prod\n", want: "prod-service-web.json"},
+		{name: "Unsafe characters", content: "kind: ClusterRole\nmetadata:\n  name: system:controller/x\n", want: "clusterrole-system_controller_x.json"},
+		{name: "Missing name", content: "---\n---\nkind: ConfigMap\n", want: "doc-2.json"},
+		{name: "Missing kind", content: "metadata:\n  name: nginx\n", want: "doc-1.json"},
+		{name: "List item", content: "kind: List\nitems:\n  - kind: Service\n    metadata:\n      name: web\n", want: "service-web.json"},
+		{name: "Unnamed list item", content: "---\n---\nkind: List\nitems:\n  - kind: Service\n  - kind: ConfigMap\n", want: "doc-2-2.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documents, err := converter.Decode([]byte(tt.content), converter.Options{ExplodeLists: true})
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got := splitFileName(documents[len(documents)-1]); got != tt.want {
+				t.Errorf("splitFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSplit(t *testing.T) {
+	content := "kind: Deployment\nmetadata:\n  name: nginx\n---\nkind: Service\nmetadata:\n  name: nginx\n---\nkind: ConfigMap\n"
+	documents, err := converter.Decode([]byte(content), converter.Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "out")
+	paths, err := writeSplit("all.yaml", documents, dir, converter.Options{Compact: true}, make(map[string]string), false)
+	if err != nil {
+		t.Fatalf("writeSplit() error = %v", err)
+	}
+	want := map[string]string{
+		"deployment-nginx.json": `{"kind":"Deployment","metadata":{"name":"nginx"}}`,
+		"service-nginx.json":    `{"kind":"Service","metadata":{"name":"nginx"}}`,
+		"doc-3.json":            `{"kind":"ConfigMap"}`,
+	}
+	if len(paths) != len(want) {
+		t.Errorf("writeSplit() wrote %d files, want %d", len(paths), len(want))
+	}
+	for name, wantContent := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("Expected output %s: %v", name, err)
+			continue
+		}
+		if string(got) != wantContent {
+			t.Errorf("%s = %s, want %s", name, got, wantContent)
+		}
+	}
+}
+
+func TestWriteSplitCollision(t *testing.T) {
+	content := "kind: Deployment\nmetadata:\n  name: nginx\n---\nkind: Service\nmetadata:\n  name: web\n---\nkind: Deployment\nmetadata:\n  name: nginx\n"
+	documents, err := converter.Decode([]byte(content), converter.Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	_, err = writeSplit("all.yaml", documents, dir, converter.Options{}, make(map[string]string), false)
+	if err == nil || !strings.Contains(err.Error(), "all.yaml document 1 and all.yaml document 3") {
+		t.Fatalf("writeSplit() error = %v, want collision between documents 1 and 3", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("writeSplit() wrote %d files despite the collision", len(entries))
+	}
+
+	// Paths claimed by an earlier input also collide
+	claimed := map[string]string{filepath.Join(dir, "service-web.json"): "other.yaml document 1"}
+	if _, err := writeSplit("all.yaml", documents[1:2], dir, converter.Options{}, claimed, false); err == nil {
+		t.Errorf("writeSplit() expected collision with an earlier input")
+	}
+}
+
+func TestCleanOutputName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "deployment-web.json", want: "deployment-web.json"},
+		{name: "prod/service-web.json", want: filepath.Join("prod", "service-web.json")},
+		{name: " ./prod//web.json\n", want: filepath.Join("prod", "web.json")},
+		{name: "cluster/clusterrole-system:reader.json", want: filepath.Join("cluster", "clusterrole-system_reader.json")},
+		{name: "a b/..json", want: filepath.Join("a_b", "..json")},
+		{name: "../web.json", wantErr: `"../web.json" leaves the output directory`},
+		{name: "prod/../../web.json", wantErr: `"prod/../../web.json" leaves the output directory`},
+		{name: "/etc/web.json", wantErr: `"/etc/web.json" is an absolute path`},
+		{name: "./", wantErr: "the file name is empty"},
+	}
+	for _, tt := range tests {
+		got, err := cleanOutputName(tt.name)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("cleanOutputName(%q) error = %v, want %s", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("cleanOutputName(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestSplitPathNameTemplate(t *testing.T) {
+	content := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web.v2\n  namespace: shop\n---\nkind: ClusterRole\nmetadata:\n  name: reader\n"
+	documents, err := converter.Decode([]byte(content), converter.Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	tests := []struct {
+		template string
+		want     []string
+		wantErr  string
+	}{
+		{
+			template: `{{.Namespace | default "cluster"}}/{{.Kind | lower}}-{{.Name}}.json`,
+			want:     []string{"shop/deployment-web.v2.json", "cluster/clusterrole-reader.json"},
+		},
+		{
+			template: `{{.SourceBase}}-{{.Index}}-{{.Name | replace "." "-"}}.json`,
+			want:     []string{"all-1-web-v2.json", "all-2-reader.json"},
+		},
+		{
+			template: `{{.APIVersion | default "v1"}}/{{.Name}}.json`,
+			want:     []string{"apps/v1/web.v2.json", "v1/reader.json"},
+		},
+		{
+			template: `{{.Namespace}}/../../{{.Name}}.json`,
+			wantErr:  `-name-template for manifests/all.yaml.gz document 1: "shop/../../web.v2.json" leaves the output directory`,
+		},
+		{
+			template: `{{.Missing}}`,
+			wantErr:  "can't evaluate field Missing",
+		},
+	}
+	defer func() { nameTemplate = nil }()
+	for _, tt := range tests {
+		if nameTemplate, err = parseNameTemplate(tt.template); err != nil {
+			t.Fatalf("parseNameTemplate(%q) error = %v", tt.template, err)
+		}
+		for i, doc := range documents {
+			got, err := splitPath("manifests/all.yaml.gz", "out", doc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("%s: splitPath() error = %v, want %s", tt.template, err, tt.wantErr)
+				}
+				break
+			}
+			if want := filepath.Join("out", filepath.FromSlash(tt.want[i])); err != nil || got != want {
+				t.Errorf("%s: splitPath() = %q, %v, want %q", tt.template, got, err, want)
+			}
+		}
+	}
+}
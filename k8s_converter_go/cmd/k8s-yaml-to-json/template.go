@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// templateFuncs are the helper functions available to -template templates,
+// named as in Helm so that snippets can be shared.
+var templateFuncs = template.FuncMap{
+	"toJson":  templateToJSON,
+	"indent":  templateIndent,
+	"nindent": func(spaces int, text string) string { return "\n" + templateIndent(spaces, text) },
+	"default": templateDefault,
+	"quote":   func(value interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(value)) },
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"replace": func(old, new, text string) string { return strings.ReplaceAll(text, old, new) },
+}
+
+// renderError is a failure to execute a -template template for a document.
+// The error of text/template names the template line.
+type renderError struct {
+	// document is the 1-based index of the document in the stream.
+	document int
+	err      error
+}
+
+func (e *renderError) Error() string {
+	return fmt.Sprintf("document %d: %v", e.document, e.err)
+}
+
+// loadTemplate parses the -template file at path.
+func loadTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &inputError{err: fmt.Errorf("reading template: %w", err)}
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, &usageError{code: errorTemplate, message: fmt.Sprintf("invalid -template: %v", err)}
+	}
+	return tmpl, nil
+}
+
+// renderTemplate executes tmpl once for every document of the YAML read from
+// r, with the document as the dot, and writes the results to w in document
+// order. Documents are decoded with opts, so filters and cleaning apply.
+func renderTemplate(r io.Reader, w io.Writer, tmpl *template.Template, opts converter.Options) error {
+	var buf bytes.Buffer
+	return converter.DecodeStream(r, opts, func(doc converter.Document) error {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, templateValue(doc.Value)); err != nil {
+			return &renderError{document: doc.Index, err: err}
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return &converter.IOError{Op: "write", Path: "output", Err: err}
+		}
+		return nil
+	})
+}
+
+// templateValue returns v with every *converter.Object replaced by a map, so
+// that templates can read its members as fields, such as .metadata.name.
+func templateValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case *converter.Object:
+		members := make(map[string]interface{}, value.Len())
+		for _, key := range value.Keys() {
+			member, _ := value.Get(key)
+			members[key] = templateValue(member)
+		}
+		return members
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = templateValue(item)
+		}
+		return items
+	}
+	return v
+}
+
+// templateToJSON returns value as compact JSON. Object keys are sorted, as
+// templates see objects as maps.
+func templateToJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// templateIndent indents every line of text by spaces spaces.
+func templateIndent(spaces int, text string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(text, "\n", "\n"+pad)
+}
+
+// templateDefault returns given, or def when given is missing or empty:
+// nil, false, zero, an empty string or an empty object or array. Like Helm,
+// it takes the default first so that it can be used in a pipeline, as in
+// {{ .spec.replicas | default 1 }}.
+func templateDefault(def interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmptyValue(given[0]) {
+		return def
+	}
+	return given[0]
+}
+
+// isEmptyValue reports whether value counts as empty for templateDefault.
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if number, ok := value.(json.Number); ok {
+		f, err := number.Float64()
+		return err == nil && f == 0
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
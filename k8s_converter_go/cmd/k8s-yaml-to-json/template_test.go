@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		input    string
+		opts     converter.Options
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "Fields of each document",
+			template: "{{ .kind }}/{{ .metadata.name }}\n",
+			input:    "kind: Service\nmetadata:\n  name: web\n---\nkind: Deployment\nmetadata:\n  name: api\n",
+			want:     "Service/web\nDeployment/api\n",
+		},
+		{
+			name:     "Range over an array",
+			template: "{{ range .spec.containers }}{{ .name }}={{ .image }} {{ end }}\n",
+			input:    "spec:\n  containers:\n  - name: web\n    image: nginx\n  - name: log\n    image: fluentd\n",
+			want:     "web=nginx log=fluentd \n",
+		},
+		{
+			name:     "toJson",
+			template: "{{ toJson .metadata.labels }} {{ toJson .spec.replicas }}",
+			input:    "metadata:\n  labels:\n    tier: web\n    app: shop\nspec:\n  replicas: 3\n",
+			want:     `{"app":"shop","tier":"web"} 3`,
+		},
+		{
+			name:     "indent and nindent",
+			template: "a:{{ nindent 2 .text }}\n{{ indent 4 .text }}",
+			input:    "text: \"x\\ny\"\n",
+			want:     "a:\n  x\n  y\n    x\n    y",
+		},
+		{
+			name:     "default",
+			template: "{{ .spec.replicas | default 1 }} {{ .name | default \"none\" }} {{ .spec.paused | default false }}\n",
+			input:    "spec:\n  replicas: 0\n---\nspec:\n  replicas: 4\n  paused: true\nname: web\n",
+			want:     "1 none false\n4 web true\n",
+		},
+		{
+			name:     "quote, upper and lower",
+			template: "{{ quote .port }} {{ upper .kind }} {{ lower .name }}",
+			input:    "port: 8080\nkind: Service\nname: WEB\n",
+			want:     `"8080" SERVICE web`,
+		},
+		{
+			name:     "replace",
+			template: `{{ .name | replace "." "-" }}`,
+			input:    "name: web.v2.shop\n",
+			want:     "web-v2-shop",
+		},
+		{
+			name:     "Filtered documents",
+			template: "{{ .metadata.name }}\n",
+			input:    "kind: Service\nmetadata:\n  name: web\n---\nkind: Secret\nmetadata:\n  name: token\n",
+			opts:     converter.Options{Kinds: []string{"Service"}},
+			want:     "web\n",
+		},
+		{
+			name:     "Execution error",
+			template: "{{ .kind }}\n{{ .metadata.name.first }}\n",
+			input:    "kind: Service\nmetadata:\n  name: web\n---\nkind: Service\n",
+			wantErr:  `document 1: template: test.tmpl:2:12: executing "test.tmpl" at <.metadata.name.first>: can't evaluate field first`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("test.tmpl").Funcs(templateFuncs).Parse(tt.template)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var out bytes.Buffer
+			err = renderTemplate(strings.NewReader(tt.input), &out, tmpl, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("renderTemplate() error = %v, want %q", err, tt.wantErr)
+				}
+				if exitCode(err) != exitFailure {
+					t.Errorf("exitCode() = %d, want %d", exitCode(err), exitFailure)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderTemplate() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("renderTemplate() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.yaml":     "kind: Service\nmetadata:\n  name: web\n---\nkind: Deployment\nmetadata:\n  name: api\n",
+		"names.tmpl":   "{{ .kind }} {{ .metadata.name }}\n",
+		"broken.tmpl":  "{{ .kind \n",
+		"failing.tmpl": "{{ .metadata.name.first }}",
+		"service.json": "{\"kind\": \"Service\"}\n",
+	})
+	input := filepath.Join(dir, "app.yaml")
+
+	stdoutText, errOutput, code := runCommand(t, "", "-input", input, "-template", filepath.Join(dir, "names.tmpl"))
+	if want := "Service web\nDeployment api\n"; code != exitOK || stdoutText != want {
+		t.Errorf("stdout: exit code = %d, stdout = %q, stderr = %q, want %q", code, stdoutText, errOutput, want)
+	}
+
+	output := filepath.Join(dir, "names.txt")
+	if _, errOutput, code := runCommand(t, "", "-input", input, "-template", filepath.Join(dir, "names.tmpl"), "-output", output); code != exitOK {
+		t.Fatalf("-output: exit code = %d, stderr = %q", code, errOutput)
+	}
+	if data, err := os.ReadFile(output); err != nil || string(data) != "Service web\nDeployment api\n" {
+		t.Errorf("-output file = %q, %v", data, err)
+	}
+
+	if _, errOutput, code := runCommand(t, "", "-input", input, "-template", filepath.Join(dir, "broken.tmpl")); code != exitUsage || !strings.Contains(errOutput, "invalid -template: template: broken.tmpl:2: unclosed action") {
+		t.Errorf("broken template: exit code = %d, stderr = %q", code, errOutput)
+	}
+	_, errOutput, code = runCommand(t, "", "-input", input, "-template", filepath.Join(dir, "failing.tmpl"), "-output", filepath.Join(dir, "failing.txt"))
+	if code != exitFailure || !strings.Contains(errOutput, "app.yaml: document 1: template: failing.tmpl:1:") {
+		t.Errorf("failing template: exit code = %d, stderr = %q", code, errOutput)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "failing.txt")); err == nil {
+		t.Error("output file kept after the template failed")
+	}
+	if _, _, code := runCommand(t, "", "-input", input, "-template", filepath.Join(dir, "missing.tmpl")); code != exitInput {
+		t.Errorf("missing template: exit code = %d, want %d", code, exitInput)
+	}
+
+	for _, args := range [][]string{
+		{"-input", input, "-split", "-output", dir},
+		{"-input", input, "-query", ".kind"},
+		{"-input", dir},
+		{"-input", filepath.Join(dir, "service.json")},
+	} {
+		args = append(args, "-template", filepath.Join(dir, "names.tmpl"))
+		if _, _, code := runCommand(t, "", args...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
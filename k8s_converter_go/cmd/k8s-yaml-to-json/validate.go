@@ -0,0 +1,26 @@
+package main
+
+import "k8s_converter_go/pkg/converter"
+
+// validateFiles checks that each input parses into YAML mapping documents,
+// printing a PASS or FAIL line per input, and returns the number of failures
+// and the error of the first.
+func validateFiles(files []string, opts converter.Options) (failed int, firstErr error) {
+	for _, path := range files {
+		data, err := readInputFile(path)
+		if err == nil {
+			opts.Warn = printWarning(path)
+			err = converter.Validate(data, opts)
+		}
+		if err != nil {
+			reportFailure("FAIL", path, err)
+			if failed == 0 {
+				firstErr = err
+			}
+			failed++
+			continue
+		}
+		successf("PASS %s", displayName(path))
+	}
+	return failed, firstErr
+}
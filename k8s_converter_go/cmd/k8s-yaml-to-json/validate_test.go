@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+func TestValidateFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"good.yaml":    "kind: Deployment\n---\nkind: Service\n",
+		"broken.yaml":  "This is not valid: YAML: content\n",
+		"empty.yaml":   "---\n",
+		"another.yaml": "kind: ConfigMap\n",
+	})
+
+	files := []string{
+		filepath.Join(dir, "good.yaml"),
+		filepath.Join(dir, "broken.yaml"),
+		filepath.Join(dir, "empty.yaml"),
+		filepath.Join(dir, "another.yaml"),
+		filepath.Join(dir, "missing.yaml"),
+	}
+	failed, err := validateFiles(files, converter.Options{})
+	if failed != 3 {
+		t.Errorf("validateFiles() = %d failures, want 3", failed)
+	}
+	if exitCode(err) != exitInvalid {
+		t.Errorf("validateFiles() first error = %v, want a parse error", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("validateFiles() created files, directory has %d entries", len(entries))
+	}
+}
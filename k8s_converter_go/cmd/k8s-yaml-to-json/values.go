@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// valueKeyPattern matches the dotted keys of -set-value, the paths that
+// ${values.a.b} references can name.
+var valueKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)*$`)
+
+// loadValues returns the values for ${values.a.b} references in the -values
+// file at path, a YAML mapping, or nil when path is empty, so that
+// references are left unchanged.
+func loadValues(path string) (map[string]interface{}, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &inputError{err: fmt.Errorf("reading values: %w", err)}
+	}
+	var decoded interface{}
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		return nil, &usageError{message: fmt.Sprintf("invalid -values file: %v", err)}
+	}
+	switch decoded := decoded.(type) {
+	case nil:
+		return make(map[string]interface{}), nil
+	case map[string]interface{}:
+		return decoded, nil
+	}
+	return nil, &usageError{message: "invalid -values file: must be a mapping of values"}
+}
+
+// applySetValues sets each -set-value key=value of sets in values, in order,
+// so that they override the -values file. values is created if it is nil
+// and sets is not empty.
+func applySetValues(values map[string]interface{}, sets []string) (map[string]interface{}, error) {
+	if values == nil && len(sets) > 0 {
+		values = make(map[string]interface{})
+	}
+	for _, set := range sets {
+		key, text, ok := strings.Cut(set, "=")
+		if !ok || !valueKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid -set-value value '%s': must be key=value, with a dotted key such as image.tag", set)
+		}
+		value, err := parseValue(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -set-value value '%s': %v", set, err)
+		}
+		setValue(values, strings.Split(key, "."), value)
+	}
+	return values, nil
+}
+
+// parseValue parses text as a YAML scalar, as -set does, so that 5 is a
+// number, true a boolean and an empty value null.
+func parseValue(text string) (interface{}, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+	if node.Content[0].Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("not a YAML scalar")
+	}
+	var value interface{}
+	err := node.Content[0].Decode(&value)
+	return value, err
+}
+
+// setValue sets value at the path of keys in values, creating the mappings
+// on the way and replacing any other value in their place.
+func setValue(values map[string]interface{}, keys []string, value interface{}) {
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := values[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			values[key] = next
+		}
+		values = next
+	}
+	values[keys[len(keys)-1]] = value
+}
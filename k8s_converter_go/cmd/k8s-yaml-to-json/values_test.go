@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadValues(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"values.yaml": "image:\n  repository: nginx\n  tag: \"1.25\"\nreplicas: 2\n",
+		"empty.yaml":  "",
+		"list.yaml":   "- a\n",
+		"broken.yaml": "image: [\n",
+	})
+
+	tests := []struct {
+		name    string
+		file    string
+		sets    []string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{name: "no values"},
+		{
+			name: "file",
+			file: "values.yaml",
+			want: map[string]interface{}{"image": map[string]interface{}{"repository": "nginx", "tag": "1.25"}, "replicas": 2},
+		},
+		{
+			name: "overrides",
+			file: "values.yaml",
+			sets: []string{"image.tag=1.26", "replicas=5", "debug=true", "replicas.count=3", "note="},
+			want: map[string]interface{}{
+				"image":    map[string]interface{}{"repository": "nginx", "tag": 1.26},
+				"replicas": map[string]interface{}{"count": 3},
+				"debug":    true,
+				"note":     nil,
+			},
+		},
+		{name: "overrides without a file", sets: []string{"zone=eu-west-1"}, want: map[string]interface{}{"zone": "eu-west-1"}},
+		{name: "empty file", file: "empty.yaml", want: map[string]interface{}{}},
+		{name: "not a mapping", file: "list.yaml", wantErr: "invalid -values file: must be a mapping of values"},
+		{name: "invalid YAML", file: "broken.yaml", wantErr: "invalid -values file: yaml:"},
+		{name: "missing file", file: "missing.yaml", wantErr: "reading values:"},
+		{name: "no value", sets: []string{"image.tag"}, wantErr: "invalid -set-value value 'image.tag': must be key=value"},
+		{name: "bad key", sets: []string{"image..tag=1"}, wantErr: "invalid -set-value value 'image..tag=1': must be key=value"},
+		{name: "not a scalar", sets: []string{"ports=[80]"}, wantErr: "invalid -set-value value 'ports=[80]': not a YAML scalar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.file
+			if path != "" {
+				path = filepath.Join(dir, path)
+			}
+			got, err := loadValues(path)
+			if err == nil {
+				got, err = applySetValues(got, tt.sets)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("values = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	var inputErr *inputError
+	if _, err := loadValues(filepath.Join(dir, "missing.yaml")); !errors.As(err, &inputErr) {
+		t.Errorf("missing file: error = %v, want *inputError", err)
+	}
+}
+
+func TestValuesFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.yaml":    "kind: Deployment\nspec:\n  replicas: ${values.replicas}\n  template:\n    spec:\n      containers:\n        - image: ${values.image.repository}:${values.image.tag}\n          args: [\"${values.args}\"]\n",
+		"values.yaml": "image:\n  repository: nginx\n  tag: \"1.25\"\nreplicas: 2\nargs: 'a: b'\n",
+		"broken.yaml": "kind: ${values.kind}\nzone: ${values.zone}\n",
+	})
+	input := filepath.Join(dir, "app.yaml")
+	values := filepath.Join(dir, "values.yaml")
+
+	stdoutText, errOutput, code := runCommand(t, "", "-input", input, "-values", values, "-set-value", "image.tag=1.26", "-compact")
+	want := `{"kind":"Deployment","spec":{"replicas":2,"template":{"spec":{"containers":[{"image":"nginx:1.26","args":["a: b"]}]}}}}` + "\n"
+	if code != exitOK || stdoutText != want {
+		t.Errorf("exit code = %d, stdout = %q, stderr = %q, want %q", code, stdoutText, errOutput, want)
+	}
+
+	_, errOutput, code = runCommand(t, "", "-input", filepath.Join(dir, "broken.yaml"), "-values", values, "-error-format", "json")
+	if code != exitInvalid || !strings.Contains(errOutput, `"error":"undefined_values"`) || !strings.Contains(errOutput, "undefined values: kind, zone") {
+		t.Errorf("undefined values: exit code = %d, stderr = %q", code, errOutput)
+	}
+
+	output := filepath.Join(dir, "out.json")
+	if _, _, code := runCommand(t, "", "-input", input, "-values", filepath.Join(dir, "missing.yaml"), "-output", output); code != exitInput {
+		t.Errorf("missing values file: exit code = %d, want %d", code, exitInput)
+	}
+	if _, err := os.Stat(output); err == nil {
+		t.Error("output written without the values file")
+	}
+	if _, _, code := runCommand(t, "", "-input", input, "-set-value", "image"); code != exitUsage {
+		t.Errorf("bad -set-value: exit code = %d, want %d", code, exitUsage)
+	}
+}
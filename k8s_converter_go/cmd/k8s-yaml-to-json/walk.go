@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// followSymlinks makes directory walks descend into symlinked directories,
+// set with -follow-symlinks. Symlinked files are always converted.
+var followSymlinks bool
+
+// skippedFiles counts the files of a directory or glob input that are not
+// converted without being read.
+type skippedFiles struct {
+	// excluded counts the files and directories matching an -exclude
+	// pattern; the contents of an excluded directory are not counted.
+	excluded int
+	// notYAML counts the files without a YAML extension.
+	notYAML int
+	// archive is set for the entries of an archive, whose skipped entries
+	// are always reported since they cannot be listed on disk.
+	archive bool
+}
+
+// findYAMLFiles walks dir recursively and returns every YAML file found, in
+// lexical order, with the number of files skipped. Other files, and the files
+// and directories matching an -exclude pattern, are skipped. Files are
+// identified by their resolved absolute path, so a file reached through
+// several symlinks is only returned the first time, and with followSymlinks
+// a symlinked directory leading back to a directory already walked is
+// skipped rather than looping forever. Broken symlinks and the files and
+// directories skipped for being seen before are reported as warnings.
+func findYAMLFiles(dir string) ([]string, skippedFiles, error) {
+	w := fileWalker{root: dir, files: make(map[string]string), dirs: make(map[string]string)}
+	if err := w.walkDir(dir); err != nil {
+		return nil, skippedFiles{}, err
+	}
+	return w.found, w.skipped, nil
+}
+
+// fileWalker collects the YAML files below a directory for findYAMLFiles.
+type fileWalker struct {
+	root    string
+	found   []string
+	skipped skippedFiles
+	// files and dirs map the resolved absolute path of every file found and
+	// directory walked to the path it was first reached by.
+	files map[string]string
+	dirs  map[string]string
+}
+
+// walkDir walks the directory at path, unless it was walked before.
+func (w *fileWalker) walkDir(path string) error {
+	real, err := realPath(path)
+	if err != nil {
+		return err
+	}
+	if first, ok := w.dirs[real]; ok {
+		warnf(path, "skipping symlinked directory, already walked as %s", first)
+		return nil
+	}
+	w.dirs[real] = path
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		switch {
+		case entry.Type()&fs.ModeSymlink != 0:
+			err = w.walkSymlink(child)
+		case w.exclude(child, entry.IsDir()):
+		case entry.IsDir():
+			err = w.walkDir(child)
+		default:
+			err = w.addFile(child)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkSymlink handles a symlink found in a directory: a link to a file is
+// treated as the file, and a link to a directory is only walked with
+// followSymlinks.
+func (w *fileWalker) walkSymlink(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		warnf(path, "skipping broken symlink: %v", unwrapPathError(err))
+		return nil
+	}
+	if info.IsDir() && !followSymlinks {
+		logSkip(path, "symlinked directory, not followed without -follow-symlinks")
+		return nil
+	}
+	if w.exclude(path, info.IsDir()) {
+		return nil
+	}
+	if info.IsDir() {
+		return w.walkDir(path)
+	}
+	return w.addFile(path)
+}
+
+// exclude reports whether the file or directory at path matches an -exclude
+// pattern, counting it if so.
+func (w *fileWalker) exclude(path string, isDir bool) bool {
+	if !isExcluded(w.root, path, isDir) {
+		return false
+	}
+	w.skipped.excluded++
+	logSkip(path, "excluded")
+	return true
+}
+
+// addFile adds the file at path if it is a YAML file, unless the file it
+// resolves to was found before.
+func (w *fileWalker) addFile(path string) error {
+	if !isYAMLFile(path) {
+		w.skipped.notYAML++
+		logSkip(path, "not a YAML file")
+		return nil
+	}
+	real, err := realPath(path)
+	if err != nil {
+		return err
+	}
+	if first, ok := w.files[real]; ok {
+		warnf(path, "skipping file, the same file as %s", first)
+		return nil
+	}
+	w.files[real] = path
+	w.found = append(w.found, path)
+	return nil
+}
+
+// realPath returns the absolute path of path with every symlink resolved.
+func realPath(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(resolved)
+}
+
+// unwrapPathError returns the underlying error of a *fs.PathError, whose
+// path is already part of the message it is reported in.
+func unwrapPathError(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
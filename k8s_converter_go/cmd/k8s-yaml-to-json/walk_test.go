@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s_converter_go/pkg/converter"
+)
+
+// symlink creates a symlink at link pointing to target, skipping the test
+// where symlinks are not supported.
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+}
+
+// captureStderr redirects stderr for the rest of the test and returns what
+// is written to it.
+func captureStderr(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var errOutput bytes.Buffer
+	saved := stderr
+	stderr = &errOutput
+	t.Cleanup(func() { stderr = saved })
+	return &errOutput
+}
+
+// relativePaths returns paths relative to root, with forward slashes.
+func relativePaths(t *testing.T, root string, paths []string) []string {
+	t.Helper()
+	var rels []string
+	for _, path := range paths {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	return rels
+}
+
+func TestFindYAMLFilesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"base/deploy.yaml": "kind: Deployment\n",
+		"prod/config.yaml": "kind: ConfigMap\n",
+	})
+	// A shared base linked into an environment, twice, a link back to the
+	// root and a broken link
+	symlink(t, filepath.Join(root, "base"), filepath.Join(root, "prod", "base"))
+	symlink(t, filepath.Join(root, "base", "deploy.yaml"), filepath.Join(root, "prod", "deploy.yaml"))
+	symlink(t, root, filepath.Join(root, "prod", "loop"))
+	symlink(t, filepath.Join(root, "missing.yaml"), filepath.Join(root, "prod", "stale.yaml"))
+
+	tests := []struct {
+		name         string
+		follow       bool
+		want         []string
+		wantWarnings []string
+	}{
+		{
+			name: "Without following symlinked directories",
+			want: []string{"base/deploy.yaml", "prod/config.yaml"},
+			wantWarnings: []string{
+				"prod/deploy.yaml: skipping file, the same file as " + filepath.Join(root, "base", "deploy.yaml"),
+				"prod/stale.yaml: skipping broken symlink",
+			},
+		},
+		{
+			name:   "Following symlinked directories",
+			follow: true,
+			want:   []string{"base/deploy.yaml", "prod/config.yaml"},
+			wantWarnings: []string{
+				"prod/base: skipping symlinked directory, already walked as " + filepath.Join(root, "base"),
+				"prod/loop: skipping symlinked directory, already walked as " + root,
+				"prod/stale.yaml: skipping broken symlink",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(follow bool) { followSymlinks = follow }(followSymlinks)
+			followSymlinks = tt.follow
+			errOutput := captureStderr(t)
+
+			files, _, err := findYAMLFiles(root)
+			if err != nil {
+				t.Fatalf("findYAMLFiles() error = %v", err)
+			}
+			if got := relativePaths(t, root, files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findYAMLFiles() = %v, want %v", got, tt.want)
+			}
+			for _, want := range tt.wantWarnings {
+				if !strings.Contains(errOutput.String(), "Warning: "+filepath.Join(root, filepath.FromSlash(want))) {
+					t.Errorf("stderr = %q, want a warning %q", errOutput, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertFilesFollowsSymlinksOutsideRoot(t *testing.T) {
+	defer func(follow bool) { followSymlinks = follow }(followSymlinks)
+	followSymlinks = true
+
+	root := t.TempDir()
+	shared := t.TempDir()
+	writeTree(t, root, map[string]string{"app/web.yaml": "kind: Deployment\n"})
+	writeTree(t, shared, map[string]string{
+		"base/service.yaml":  "kind: Service\n",
+		"base/nested/cm.yml": "kind: ConfigMap\n",
+	})
+	// The directory outside the root is linked in twice and links back to
+	// itself
+	symlink(t, filepath.Join(shared, "base"), filepath.Join(root, "app", "base"))
+	symlink(t, filepath.Join(shared, "base"), filepath.Join(root, "app", "copy"))
+	symlink(t, filepath.Join(shared, "base"), filepath.Join(shared, "base", "nested", "cycle"))
+	captureStderr(t)
+
+	files, _, err := findYAMLFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"app/base/nested/cm.yml", "app/base/service.yaml", "app/web.yaml"}
+	if got := relativePaths(t, root, files); !reflect.DeepEqual(got, want) {
+		t.Fatalf("findYAMLFiles() = %v, want %v", got, want)
+	}
+
+	// Outputs mirror the paths the files were found at, inside the output
+	// directory, and nothing is written next to the link targets
+	outputDir := filepath.Join(t.TempDir(), "build")
+	result := convertFiles(root, files, batchOptions{outputDir: outputDir}, converter.Options{})
+	if result.failed > 0 || result.converted != 3 {
+		t.Fatalf("convertFiles() = %d converted, %d failed: %v", result.converted, result.failed, result.err)
+	}
+	for _, name := range []string{"app/base/nested/cm.json", "app/base/service.json", "app/web.json"} {
+		if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("output %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(shared, "base", "service.json")); !os.IsNotExist(err) {
+		t.Errorf("output written next to the link target: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long to wait after the last change to the input before
+// converting it, so that several writes in quick succession convert once.
+const watchDebounce = 100 * time.Millisecond
+
+// watchInput calls convert once, then again after every change to the input
+// until the process is interrupted. input is a file, directory or glob pattern.
+func watchInput(input string, convert func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	match, err := addWatches(watcher, input)
+	if err != nil {
+		return err
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	convert()
+	infof("Watching %s for changes (press Ctrl-C to stop)", input)
+	return watchLoop(watcher, match, convert, interrupt)
+}
+
+// addWatches adds the directories to watch for input and returns a function
+// reporting whether a changed path belongs to the input. A single file is
+// watched through its parent directory so that editors replacing the file
+// with a rename are still noticed.
+func addWatches(watcher *fsnotify.Watcher, input string) (func(string) bool, error) {
+	if hasGlobMeta(input) {
+		return isYAMLFile, addDirectoryTree(watcher, globRoot(input))
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return isYAMLFile, addDirectoryTree(watcher, input)
+	}
+	file := filepath.Clean(input)
+	match := func(path string) bool {
+		return filepath.Clean(path) == file
+	}
+	return match, watcher.Add(filepath.Dir(file))
+}
+
+// addDirectoryTree watches dir and every directory below it.
+func addDirectoryTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// watchLoop waits for changes to paths accepted by match and calls convert
+// once no further change has arrived for watchDebounce. Directories created
+// inside a watched directory are watched too. It returns nil when stop
+// receives a value.
+func watchLoop(watcher *fsnotify.Watcher, match func(string) bool, convert func(), stop <-chan os.Signal) error {
+	var pending <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addDirectoryTree(watcher, event.Name); err != nil {
+						logf("Error watching %s: %v", event.Name, err)
+					}
+				}
+			}
+			// Removing or renaming the file away waits for its replacement,
+			// which arrives as a create event
+			if event.Op&(fsnotify.Write|fsnotify.Create) != 0 && match(event.Name) {
+				pending = time.After(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logf("Error watching input: %v", err)
+		case <-pending:
+			pending = nil
+			convert()
+		case <-stop:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatchLoop(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "app.yaml")
+	writeTree(t, dir, map[string]string{"app.yaml": "kind: A\n"})
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	defer watcher.Close()
+	match, err := addWatches(watcher, input)
+	if err != nil {
+		t.Fatalf("addWatches() error = %v", err)
+	}
+
+	conversions := make(chan struct{}, 10)
+	stop := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchLoop(watcher, match, func() { conversions <- struct{}{} }, stop)
+	}()
+
+	waitForConversion := func(name string) {
+		t.Helper()
+		select {
+		case <-conversions:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: no conversion after change", name)
+		}
+	}
+
+	// Several quick writes convert once
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(input, []byte("kind: B\n"), 0644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+	}
+	waitForConversion("write")
+	select {
+	case <-conversions:
+		t.Errorf("write: successive writes converted more than once")
+	case <-time.After(3 * watchDebounce):
+	}
+
+	// Changes to other files are ignored
+	writeTree(t, dir, map[string]string{"other.yaml": "kind: C\n"})
+	select {
+	case <-conversions:
+		t.Errorf("other file: unexpected conversion")
+	case <-time.After(3 * watchDebounce):
+	}
+
+	// Editors often write a new file and rename it over the original
+	replacement := filepath.Join(dir, ".app.yaml.swp")
+	if err := os.WriteFile(replacement, []byte("kind: D\n"), 0644); err != nil {
+		t.Fatalf("Failed to write replacement: %v", err)
+	}
+	if err := os.Rename(replacement, input); err != nil {
+		t.Fatalf("Failed to rename replacement: %v", err)
+	}
+	waitForConversion("rename")
+
+	stop <- os.Interrupt
+	if err := <-done; err != nil {
+		t.Errorf("watchLoop() error = %v", err)
+	}
+}
+
+func TestWatchLoopNewDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	defer watcher.Close()
+	match, err := addWatches(watcher, dir)
+	if err != nil {
+		t.Fatalf("addWatches() error = %v", err)
+	}
+
+	conversions := make(chan struct{}, 10)
+	stop := make(chan os.Signal, 1)
+	go watchLoop(watcher, match, func() { conversions <- struct{}{} }, stop)
+	defer func() { stop <- os.Interrupt }()
+
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	// Give the loop time to watch the new directory
+	time.Sleep(3 * watchDebounce)
+	writeTree(t, dir, map[string]string{"nested/app.yml": "kind: A\n"})
+
+	select {
+	case <-conversions:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no conversion after a file was added to a new directory")
+	}
+}
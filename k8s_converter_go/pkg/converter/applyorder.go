@@ -0,0 +1,112 @@
+package converter
+
+import "sort"
+
+// applyOrder is the order in which kubectl-style tools apply the common
+// kinds: the namespaces and definitions other objects live in first, then
+// the identities and configuration workloads use, the workloads, and last
+// what routes to them or intercepts requests. Kinds not listed come after
+// every listed kind.
+var applyOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"PriorityClass",
+	"StorageClass",
+	"ResourceQuota",
+	"LimitRange",
+	"NetworkPolicy",
+	"PodSecurityPolicy",
+	"ServiceAccount",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Secret",
+	"ConfigMap",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"DaemonSet",
+	"Deployment",
+	"StatefulSet",
+	"ReplicaSet",
+	"ReplicationController",
+	"Pod",
+	"Job",
+	"CronJob",
+	"Service",
+	"HorizontalPodAutoscaler",
+	"PodDisruptionBudget",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// applyRanks maps each kind of applyOrder to its position.
+var applyRanks = func() map[string]int {
+	ranks := make(map[string]int, len(applyOrder))
+	for i, kind := range applyOrder {
+		ranks[kind] = i
+	}
+	return ranks
+}()
+
+// SortApplyOrder sorts values, decoded documents, into the order they can be
+// applied to a cluster in, as for Options.SortByApplyOrder, and the items of
+// the lists among them, such as a v1 List made by NewList.
+func SortApplyOrder(values []interface{}) {
+	for _, value := range values {
+		if items, ok := listArray(value); ok {
+			sortValues(items)
+		}
+	}
+	sortValues(values)
+}
+
+// sortApplyOrder sorts documents as SortApplyOrder does.
+func sortApplyOrder(documents []Document) {
+	for _, doc := range documents {
+		if items, ok := listArray(doc.Value); ok {
+			sortValues(items)
+		}
+	}
+	sort.SliceStable(documents, func(i, j int) bool {
+		return applyLess(documents[i].Value, documents[j].Value)
+	})
+}
+
+// sortValues sorts values by applyLess, keeping the order of equal ones.
+func sortValues(values []interface{}) {
+	sort.SliceStable(values, func(i, j int) bool {
+		return applyLess(values[i], values[j])
+	})
+}
+
+// applyLess reports whether the document a is applied before b: by the
+// position of its kind in applyOrder, with unknown kinds last and sorted by
+// name, and then by namespace and name.
+func applyLess(a, b interface{}) bool {
+	kindA, kindB := stringField(a, "kind"), stringField(b, "kind")
+	rankA, rankB := applyRank(kindA), applyRank(kindB)
+	if rankA != rankB {
+		return rankA < rankB
+	}
+	if kindA != kindB {
+		return kindA < kindB
+	}
+	metadataA, metadataB := field(a, "metadata"), field(b, "metadata")
+	if namespaceA, namespaceB := stringField(metadataA, "namespace"), stringField(metadataB, "namespace"); namespaceA != namespaceB {
+		return namespaceA < namespaceB
+	}
+	return stringField(metadataA, "name") < stringField(metadataB, "name")
+}
+
+// applyRank returns the position of kind in applyOrder, or len(applyOrder)
+// for kinds not in it.
+func applyRank(kind string) int {
+	if rank, ok := applyRanks[kind]; ok {
+		return rank
+	}
+	return len(applyOrder)
+}
@@ -0,0 +1,181 @@
+package converter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// applyBundle is a realistic bundle of an application, in the order it was
+// written rather than the order it can be applied in.
+const applyBundle = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: gadget
+  namespace: shop
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: shop
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-tls
+  namespace: shop
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: db-config
+  namespace: shop
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: admin
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: widgets
+---
+apiVersion: example.com/v1
+kind: Gizmo
+metadata:
+  name: g
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+`
+
+// documentKeys returns the kind, namespace and name of each document.
+func documentKeys(documents []Document) []string {
+	keys := make([]string, len(documents))
+	for i, doc := range documents {
+		keys[i] = fmt.Sprintf("%s %s/%s", doc.Kind(), doc.Namespace(), doc.Name())
+	}
+	return keys
+}
+
+func TestDecodeSortByApplyOrder(t *testing.T) {
+	want := []string{
+		"Namespace /shop",
+		"CustomResourceDefinition /widgets.example.com",
+		"ServiceAccount shop/web",
+		"Role shop/web",
+		"RoleBinding shop/web",
+		"Secret shop/web-tls",
+		"ConfigMap admin/web-config",
+		"ConfigMap shop/db-config",
+		"ConfigMap shop/web-config",
+		"Deployment shop/web",
+		"Service shop/web",
+		"Ingress shop/web",
+		"ValidatingWebhookConfiguration /widgets",
+		"Gizmo /g",
+		"Widget shop/gadget",
+	}
+
+	documents, err := Decode([]byte(applyBundle), Options{SortByApplyOrder: true})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got := documentKeys(documents); !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() order =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var streamed []Document
+	err = DecodeStream(strings.NewReader(applyBundle), Options{SortByApplyOrder: true}, func(doc Document) error {
+		streamed = append(streamed, doc)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeStream() error = %v", err)
+	}
+	if got := documentKeys(streamed); !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeStream() order =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Without the option, the documents stay in input order
+	documents, err = Decode([]byte(applyBundle), Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got := documentKeys(documents); got[0] != "Ingress shop/web" || got[len(got)-1] != "Namespace /shop" {
+		t.Errorf("Decode() without SortByApplyOrder reordered the documents: %q", got)
+	}
+}
+
+func TestConvertSortByApplyOrderList(t *testing.T) {
+	content := "apiVersion: v1\nkind: List\nitems:\n- kind: Service\n  metadata: {name: web}\n- kind: Deployment\n  metadata: {name: web}\n- kind: Namespace\n  metadata: {name: shop}\n"
+	got, err := Convert([]byte(content), Options{Compact: true, SortByApplyOrder: true})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	want := `{"apiVersion":"v1","kind":"List","items":[{"kind":"Namespace","metadata":{"name":"shop"}},` +
+		`{"kind":"Deployment","metadata":{"name":"web"}},{"kind":"Service","metadata":{"name":"web"}}]}`
+	if string(got) != want {
+		t.Errorf("Convert() = %s, want %s", got, want)
+	}
+}
+
+func TestSortApplyOrder(t *testing.T) {
+	documents, err := Decode([]byte(applyBundle), Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	values := []interface{}{NewList(Values(documents))}
+	SortApplyOrder(values)
+	items := field(values[0], "items").([]interface{})
+	if first, last := stringField(items[0], "kind"), stringField(items[len(items)-1], "kind"); first != "Namespace" || last != "Widget" {
+		t.Errorf("SortApplyOrder() items run from %s to %s, want Namespace to Widget", first, last)
+	}
+}
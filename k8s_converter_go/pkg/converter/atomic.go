@@ -0,0 +1,135 @@
+package converter
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// AtomicFile is a file written to a temporary file in the directory of its
+// destination and renamed into place by Commit, so that readers of the
+// destination only ever see its old content or the complete new content,
+// even if the process is killed or the disk fills while writing.
+type AtomicFile struct {
+	// path is the destination, and file the temporary file.
+	path string
+	file *os.File
+	// err is the first write error, which fails Commit.
+	err error
+}
+
+// CreateAtomic starts writing the file at path. The file gets the mode of the
+// file it replaces, or, like a file created by os.WriteFile, perm less the
+// umask of the process for a new file. A symbolic link at path is followed,
+// so that the file it points to is replaced rather than the link. Errors are
+// those of the os package.
+func CreateAtomic(path string, perm os.FileMode) (*AtomicFile, error) {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	info, statErr := os.Stat(path)
+	file, err := createTemp(path, perm)
+	if err != nil {
+		return nil, destinationError(path, err)
+	}
+	atomic := &AtomicFile{path: path, file: file}
+	if statErr == nil {
+		if err := atomic.Chmod(info.Mode().Perm()); err != nil {
+			atomic.Abort()
+			return nil, err
+		}
+	}
+	return atomic, nil
+}
+
+// createTemp creates a new temporary file next to path with perm, which the
+// umask applies to as it does to os.OpenFile, unlike os.CreateTemp, which
+// always creates files with mode 0600.
+func createTemp(path string, perm os.FileMode) (*os.File, error) {
+	prefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	for try := 0; ; try++ {
+		file, err := os.OpenFile(prefix+strconv.FormatUint(rand.Uint64(), 36), os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if !os.IsExist(err) || try == 100 {
+			return file, err
+		}
+	}
+}
+
+// Chmod sets the mode of the file to mode once it is committed, exactly, as
+// the umask does not apply to it.
+func (f *AtomicFile) Chmod(mode os.FileMode) error {
+	return destinationError(f.path, f.file.Chmod(mode))
+}
+
+// Write writes p to the temporary file.
+func (f *AtomicFile) Write(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	n, err := f.file.Write(p)
+	if err != nil {
+		f.err = destinationError(f.path, err)
+	}
+	return n, f.err
+}
+
+// Commit flushes the temporary file to disk, renames it to the destination
+// and flushes the directory, so that the rename survives a crash too. If
+// anything was not written, or the rename fails, the temporary file is
+// removed and the destination is left as it was.
+func (f *AtomicFile) Commit() error {
+	err := f.err
+	if err == nil {
+		err = f.file.Sync()
+	}
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.file.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.file.Name())
+		return destinationError(f.path, err)
+	}
+	return syncDir(filepath.Dir(f.path))
+}
+
+// Abort removes the temporary file, leaving the destination as it was.
+func (f *AtomicFile) Abort() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
+
+// destinationError returns err, an error of the os package about the
+// temporary file of an AtomicFile, as an error about its destination path.
+func destinationError(path string, err error) error {
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &pathErr):
+		return &os.PathError{Op: pathErr.Op, Path: path, Err: pathErr.Err}
+	case errors.As(err, &linkErr):
+		return &os.PathError{Op: linkErr.Op, Path: path, Err: linkErr.Err}
+	}
+	return err
+}
+
+// WriteFileAtomic writes data to the file at path as an AtomicFile, so that
+// the file is never left truncated: on failure it keeps its old content. Like
+// os.WriteFile, it returns the errors of the os package.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	file, err := CreateAtomic(path, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Abort()
+		return err
+	}
+	return file.Commit()
+}
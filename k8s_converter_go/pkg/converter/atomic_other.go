@@ -0,0 +1,9 @@
+//go:build !unix
+
+package converter
+
+// syncDir does nothing where directories cannot be opened for syncing, as
+// on Windows, whose renames are flushed with the file system metadata.
+func syncDir(dir string) error {
+	return nil
+}
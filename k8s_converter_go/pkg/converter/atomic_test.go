@@ -0,0 +1,140 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// assertOnlyFiles fails t unless dir holds exactly the named files, so that
+// no temporary file is left behind.
+func assertOnlyFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	if len(got) != len(names) {
+		t.Fatalf("files in %s = %v, want %v", dir, got, names)
+	}
+	for i := range names {
+		if got[i] != names[i] {
+			t.Fatalf("files in %s = %v, want %v", dir, got, names)
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.json")
+
+	if err := WriteFileAtomic(path, []byte(`{"a":1}`), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Fatalf("new file: %v, %v, want mode 0644", info, err)
+	}
+
+	// Replacing a file keeps its mode
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(path, []byte(`{"a":2}`), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	if info, _ := os.Stat(path); string(got) != `{"a":2}` || info.Mode().Perm() != 0600 {
+		t.Errorf("replaced file = %s with mode %v, want {\"a\":2} with mode 0600", got, info.Mode().Perm())
+	}
+	assertOnlyFiles(t, dir, "out.json")
+
+	// A symbolic link is followed rather than replaced
+	link := filepath.Join(dir, "link.json")
+	if err := os.Symlink(path, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := WriteFileAtomic(link, []byte(`{"a":3}`), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != `{"a":3}` {
+		t.Errorf("link target = %s, want {\"a\":3}", got)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link was replaced: %v, %v", info, err)
+	}
+
+	// A missing directory is reported with the destination path
+	missing := filepath.Join(dir, "missing", "out.json")
+	if err := WriteFileAtomic(missing, nil, 0644); err == nil {
+		t.Error("WriteFileAtomic() into a missing directory succeeded")
+	} else if pathErr, ok := err.(*os.PathError); !ok || pathErr.Path != missing {
+		t.Errorf("WriteFileAtomic() error = %v, want an *os.PathError for %s", err, missing)
+	}
+}
+
+func TestAtomicFileWriteError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.json")
+	if err := os.WriteFile(path, []byte("old content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := CreateAtomic(path, 0644)
+	if err != nil {
+		t.Fatalf("CreateAtomic() error = %v", err)
+	}
+	if _, err := file.Write([]byte(`{"partial":`)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// Fail the next write partway through the output, as a full disk would
+	file.file.Close()
+	if _, err := file.Write([]byte(`"value"}`)); err == nil {
+		t.Fatal("Write() after the file failed succeeded")
+	}
+	if err := file.Commit(); err == nil {
+		t.Fatal("Commit() after a failed write succeeded")
+	}
+	if got, _ := os.ReadFile(path); string(got) != "old content" {
+		t.Errorf("destination = %q, want the original content", got)
+	}
+	assertOnlyFiles(t, dir, "out.json")
+
+	// Abort leaves the destination as it was too
+	file, err = CreateAtomic(path, 0644)
+	if err != nil {
+		t.Fatalf("CreateAtomic() error = %v", err)
+	}
+	file.Write([]byte("new content"))
+	file.Abort()
+	if got, _ := os.ReadFile(path); string(got) != "old content" {
+		t.Errorf("destination after Abort() = %q, want the original content", got)
+	}
+	assertOnlyFiles(t, dir, "out.json")
+}
+
+func TestConvertFileKeepsOutputOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.yaml")
+	out := filepath.Join(dir, "out.json")
+	// The first document is streamed to the output before the second fails
+	if err := os.WriteFile(in, []byte("kind: ConfigMap\n---\nkind: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(out, []byte(`{"kind":"Secret"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reverse := range []bool{false, true} {
+		if err := ConvertFile(in, out, Options{Reverse: reverse}); err == nil {
+			t.Fatalf("ConvertFile(Reverse: %v) error = nil, want an error", reverse)
+		}
+		if got, _ := os.ReadFile(out); string(got) != `{"kind":"Secret"}` {
+			t.Errorf("ConvertFile(Reverse: %v) left %q, want the original output", reverse, got)
+		}
+		assertOnlyFiles(t, dir, "in.yaml", "out.json")
+	}
+}
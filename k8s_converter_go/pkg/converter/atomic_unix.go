@@ -0,0 +1,24 @@
+//go:build unix
+
+package converter
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// syncDir flushes the entries of the directory at dir to disk, so that a
+// file renamed into it is still there after a crash. File systems that
+// cannot sync a directory report EINVAL, which is ignored.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+//go:build unix
+
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestFileModeUmask(t *testing.T) {
+	defer syscall.Umask(syscall.Umask(027))
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.yaml")
+	if err := os.WriteFile(in, []byte("kind: Secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		reverse bool
+		mode    os.FileMode
+		want    os.FileMode
+	}{
+		// The umask applies to new files, as it does for os.WriteFile
+		{"default", false, 0, 0640},
+		// FileMode is set exactly, even with bits the umask clears
+		{"file mode", false, 0604, 0604},
+		{"file mode reverse", true, 0604, 0604},
+		{"private", false, 0600, 0600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out")
+			src := in
+			if tt.reverse {
+				src = filepath.Join(dir, "in.json")
+				if err := os.WriteFile(src, []byte(`{"kind":"Secret"}`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := ConvertFile(src, out, Options{Reverse: tt.reverse, FileMode: tt.mode}); err != nil {
+				t.Fatalf("ConvertFile() error = %v", err)
+			}
+			info, err := os.Stat(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tt.want {
+				t.Errorf("ConvertFile() mode = %v, want %v", info.Mode().Perm(), tt.want)
+			}
+
+			// A file that is replaced keeps its mode unless FileMode is set
+			if err := os.Chmod(out, 0660); err != nil {
+				t.Fatal(err)
+			}
+			if err := ConvertFile(src, out, Options{Reverse: tt.reverse, FileMode: tt.mode}); err != nil {
+				t.Fatalf("ConvertFile() error = %v", err)
+			}
+			want := tt.mode
+			if want == 0 {
+				want = 0660
+			}
+			if info, err := os.Stat(out); err != nil || info.Mode().Perm() != want {
+				t.Errorf("replaced file: %v, %v, want mode %v", info, err, want)
+			}
+		})
+	}
+}
+
+func TestSyncDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := syncDir(dir); err != nil {
+		t.Errorf("syncDir(%s) error = %v", dir, err)
+	}
+	missing := filepath.Join(dir, "missing")
+	if err := syncDir(missing); !os.IsNotExist(err) {
+		t.Errorf("syncDir(%s) error = %v, want not exist", missing, err)
+	}
+}
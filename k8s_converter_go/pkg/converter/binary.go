@@ -0,0 +1,35 @@
+package converter
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// binaryTag is the YAML tag of base64-encoded binary data.
+const binaryTag = "!!binary"
+
+// decodeBinary decodes a !!binary scalar and encodes its data as a string
+// per d.opts.Binary. Whitespace in the scalar, such as the line breaks of a
+// block scalar, is ignored.
+func (d *valueDecoder) decodeBinary(node *yaml.Node) (interface{}, error) {
+	switch d.opts.Binary {
+	case "", BinaryBase64, BinaryHex:
+	case BinaryError:
+		return nil, d.errorAt(node, "!!binary values are not allowed")
+	default:
+		return nil, fmt.Errorf("unknown binary encoding %q", d.opts.Binary)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(node.Value), ""))
+	if err != nil {
+		return nil, d.errorAt(node, fmt.Sprintf("!!binary value contains invalid base64 data: %v", err))
+	}
+	if d.opts.Binary == BinaryHex {
+		return hex.EncodeToString(data), nil
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"errors"
+	"testing"
+)
+
+const binaryContent = `apiVersion: v1
+kind: ConfigMap
+binaryData:
+  short: !!binary aGVsbG8=
+  wrapped: !!binary |
+    aGVsbG8g
+    d29ybGQ=
+`
+
+func TestConvertBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "base64 by default",
+			opts: Options{Compact: true},
+			want: `{"apiVersion":"v1","kind":"ConfigMap","binaryData":{"short":"aGVsbG8=","wrapped":"aGVsbG8gd29ybGQ="}}`,
+		},
+		{
+			name: "base64",
+			opts: Options{Compact: true, Binary: BinaryBase64},
+			want: `{"apiVersion":"v1","kind":"ConfigMap","binaryData":{"short":"aGVsbG8=","wrapped":"aGVsbG8gd29ybGQ="}}`,
+		},
+		{
+			name: "hex",
+			opts: Options{Compact: true, Binary: BinaryHex},
+			want: `{"apiVersion":"v1","kind":"ConfigMap","binaryData":{"short":"68656c6c6f","wrapped":"68656c6c6f20776f726c64"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(binaryContent), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertBinaryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+		want  string
+	}{
+		{
+			name:  "invalid base64",
+			input: "kind: ConfigMap\nbinaryData:\n  bad: !!binary not*base64\n",
+			want:  "error parsing YAML at line 3, column 8: !!binary value contains invalid base64 data: illegal base64 data at input byte 3",
+		},
+		{
+			name:  "rejected",
+			input: "kind: ConfigMap\nbinaryData:\n  short: !!binary aGVsbG8=\n",
+			opts:  Options{Binary: BinaryError},
+			want:  "error parsing YAML at line 3, column 10: !!binary values are not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert([]byte(tt.input), tt.opts)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Convert() error = %v, want *ParseError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("Convert() error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package converter
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// canonicalNumbers replaces every number in v with its canonicalNumber form.
+func canonicalNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case *Object:
+		for i, item := range value.values {
+			value.values[i] = canonicalNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = canonicalNumbers(item)
+		}
+	case json.Number:
+		return canonicalNumber(value)
+	}
+	return v
+}
+
+// canonicalNumber returns the one form of n that every spelling of its value
+// shares, so that 3, 3.0, 0.3e1 and 0x3 in the YAML all become 3. The digits
+// are exact, with leading and trailing zeros removed. The number is written
+// as a plain decimal unless it has more than 21 integer digits or 6 or more
+// zeros after the decimal point, and otherwise in exponent form with one
+// digit before the point, such as 1.5e+30 or 2e-7, as JavaScript writes
+// numbers. Negative zero is written as 0.
+func canonicalNumber(n json.Number) json.Number {
+	literal := string(n)
+	sign := ""
+	if strings.HasPrefix(literal, "-") {
+		sign, literal = "-", literal[1:]
+	}
+	exponent := 0
+	if i := strings.IndexAny(literal, "eE"); i >= 0 {
+		e, err := strconv.Atoi(literal[i+1:])
+		if err != nil {
+			// Exponents beyond an int are kept as written
+			return n
+		}
+		exponent, literal = e, literal[:i]
+	}
+	digits := literal
+	if i := strings.IndexByte(literal, '.'); i >= 0 {
+		digits = literal[:i] + literal[i+1:]
+		exponent -= len(literal) - i - 1
+	}
+	trimmed := strings.TrimLeft(digits, "0")
+	if trimmed == "" {
+		return "0"
+	}
+	digits = strings.TrimRight(trimmed, "0")
+	exponent += len(trimmed) - len(digits)
+
+	// point is the position of the decimal point relative to the first digit
+	point := len(digits) + exponent
+	switch {
+	case point > 21 || point <= -6:
+		mantissa := digits[:1]
+		if len(digits) > 1 {
+			mantissa += "." + digits[1:]
+		}
+		exponentSign := "+"
+		if point < 1 {
+			exponentSign = ""
+		}
+		return json.Number(sign + mantissa + "e" + exponentSign + strconv.Itoa(point-1))
+	case point >= len(digits):
+		return json.Number(sign + digits + strings.Repeat("0", point-len(digits)))
+	case point > 0:
+		return json.Number(sign + digits[:point] + "." + digits[point:])
+	default:
+		return json.Number(sign + "0." + strings.Repeat("0", -point) + digits)
+	}
+}
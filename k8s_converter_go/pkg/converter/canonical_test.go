@@ -0,0 +1,190 @@
+package converter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalNumber(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"3", "3"},
+		{"3.0", "3"},
+		{"0.3e1", "3"},
+		{"300e-2", "3"},
+		{"-0", "0"},
+		{"-0.0e5", "0"},
+		{"0.10", "0.1"},
+		{"-12.50", "-12.5"},
+		{"1e3", "1000"},
+		{"12E03", "12000"},
+		{"2.5E-10", "2.5e-10"},
+		{"0.000001", "0.000001"},
+		{"0.0000001", "1e-7"},
+		{"123456789012345678901", "123456789012345678901"},
+		{"1234567890123456789012", "1.234567890123456789012e+21"},
+		{"1e400", "1e+400"},
+		{"3.14159265358979323846264338327950288", "3.14159265358979323846264338327950288"},
+		{"1e99999999999999999999", "1e99999999999999999999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := canonicalNumber(json.Number(tt.input)); string(got) != tt.want {
+				t.Errorf("canonicalNumber(%s) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertCanonicalHash(t *testing.T) {
+	renderings := map[string]string{
+		"Block style": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: "nginx:1.25"
+          ports:
+            - containerPort: 80
+          resources:
+            limits:
+              cpu: 0.5
+`,
+		"Flow style and reordered keys": `{spec: {template: {spec: {containers: [{resources: {limits: {cpu: 0.50}}, ports: [{containerPort: 80}], image: 'nginx:1.25', name: web}]}, metadata: {labels: {app: web}}}, replicas: 3.0}, kind: Deployment, apiVersion: apps/v1, metadata: {labels: {app: web}, name: web}}
+`,
+		"Anchors, comments and number forms": `# The web frontend
+kind: "Deployment"
+apiVersion: 'apps/v1'
+metadata:
+  name: web
+  labels: &labels
+    app: web   # shared with the pod template
+spec:
+  replicas: 0x3
+  template:
+    metadata:
+      labels: *labels
+    spec:
+      containers:
+      - image: >-
+          nginx:1.25
+        name: web
+        ports: [{containerPort: 8e1}]
+        resources: {limits: {cpu: 5e-1}}
+`,
+		"Merge keys and a document marker": `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels: &labels {app: web}
+  name: web
+spec:
+  replicas: +3
+  template:
+    metadata:
+      labels:
+        <<: *labels
+    spec:
+      containers:
+        - {name: web, image: nginx:1.25, ports: [{containerPort: 80}], resources: {limits: {cpu: .5}}}
+`,
+	}
+
+	want := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app":"web"},"name":"web"},"spec":{"replicas":3,"template":{"metadata":{"labels":{"app":"web"}},"spec":{"containers":[{"image":"nginx:1.25","name":"web","ports":[{"containerPort":80}],"resources":{"limits":{"cpu":0.5}}}]}}}}` + "\n"
+	sum := sha256.Sum256([]byte(want))
+	wantHash := hex.EncodeToString(sum[:])
+
+	for name, input := range renderings {
+		t.Run(name, func(t *testing.T) {
+			got, err := Convert([]byte(input), Options{Canonical: true})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			sum := sha256.Sum256(got)
+			if hash := hex.EncodeToString(sum[:]); hash != wantHash {
+				t.Errorf("Convert() = %s (sha256 %s), want %s (sha256 %s)", got, hash, want, wantHash)
+			}
+		})
+	}
+}
+
+func TestConvertCanonical(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+		want  string
+	}{
+		{
+			name:  "Overrides indentation",
+			input: "b: 1\na: [1.50, x]\n",
+			opts:  Options{Indent: "    "},
+			want:  `{"a":[1.5,"x"],"b":1}` + "\n",
+		},
+		{
+			name:  "Escaping",
+			input: "html: <a href=\"x\">&amp;</a>\nunicode: \"caf\\u00e9 \\t\"\n",
+			want:  `{"html":"\u003ca href=\"x\"\u003e\u0026amp;\u003c/a\u003e","unicode":"café \t"}` + "\n",
+		},
+		{
+			name:  "Several documents",
+			input: "b: 1\n---\na: 2\n",
+			want:  `[{"b":1},{"a":2}]` + "\n",
+		},
+		{
+			name:  "Separate documents",
+			input: "b: 1\n---\na: 2\n",
+			opts:  Options{Separate: true},
+			want:  `{"b":1}` + "\n" + `{"a":2}` + "\n",
+		},
+		{
+			name:  "Query",
+			input: "a: {y: 1.0, x: 2}\n---\na: {x: 3}\n",
+			opts:  Options{Query: ".a"},
+			want:  `{"x":2,"y":1}` + "\n" + `{"x":3}` + "\n",
+		},
+		{
+			name:  "NDJSON",
+			input: "b: 1e2\n---\na: 2\n",
+			opts:  Options{Format: FormatNDJSON},
+			want:  `{"b":100}` + "\n" + `{"a":2}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Canonical = true
+			got, err := Convert([]byte(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %q, want %q", got, tt.want)
+			}
+			var streamed bytes.Buffer
+			if err := ConvertStream(strings.NewReader(tt.input), &streamed, tt.opts); err != nil {
+				t.Fatalf("ConvertStream() error = %v", err)
+			}
+			if streamed.String() != tt.want {
+				t.Errorf("ConvertStream() = %q, want %q", streamed.String(), tt.want)
+			}
+		})
+	}
+}
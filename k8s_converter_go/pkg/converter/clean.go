@@ -0,0 +1,56 @@
+package converter
+
+import "strings"
+
+// serverMetadataFields are the metadata fields populated by the Kubernetes API
+// server that Options.Clean removes. They are removed from every metadata
+// mapping in the document, including nested ones such as pod templates.
+var serverMetadataFields = []string{
+	"creationTimestamp",
+	"deletionGracePeriodSeconds",
+	"deletionTimestamp",
+	"generation",
+	"managedFields",
+	"resourceVersion",
+	"selfLink",
+	"uid",
+}
+
+// cleanObject removes server-populated fields from a Kubernetes object: the
+// top-level status and the serverMetadataFields of every metadata mapping.
+// The items of list kinds such as v1 List are cleaned as objects too.
+func cleanObject(v interface{}) {
+	object, ok := v.(*Object)
+	if !ok {
+		return
+	}
+	object.Delete("status")
+	if kind := stringField(object, "kind"); strings.HasSuffix(kind, "List") {
+		if items, ok := field(object, "items").([]interface{}); ok {
+			for _, item := range items {
+				cleanObject(item)
+			}
+		}
+	}
+	cleanMetadata(object)
+}
+
+// cleanMetadata removes the serverMetadataFields from every metadata mapping
+// under v.
+func cleanMetadata(v interface{}) {
+	switch value := v.(type) {
+	case *Object:
+		if metadata, ok := field(value, "metadata").(*Object); ok {
+			for _, name := range serverMetadataFields {
+				metadata.Delete(name)
+			}
+		}
+		for _, key := range value.keys {
+			cleanMetadata(value.values[key])
+		}
+	case []interface{}:
+		for _, item := range value {
+			cleanMetadata(item)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package converter
+
+import "testing"
+
+const exportedDeploymentYAML = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+  uid: 0b6c2a1e-1f0e-4c1a-9d3b-6d4a3e2f1c0b
+  resourceVersion: "123456"
+  generation: 4
+  creationTimestamp: "2023-05-01T10:00:00Z"
+  selfLink: /apis/apps/v1/namespaces/prod/deployments/web
+  managedFields:
+  - manager: kubectl
+    operation: Update
+  labels:
+    app: web
+spec:
+  replicas: 2
+  template:
+    metadata:
+      creationTimestamp: null
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+status:
+  replicas: 2
+`
+
+func TestConvertClean(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "Deployment exported from a cluster",
+			content: exportedDeploymentYAML,
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"prod","labels":{"app":"web"}},` +
+				`"spec":{"replicas":2,"template":{"metadata":{"labels":{"app":"web"}},"spec":{"containers":[{"name":"web","image":"nginx"}]}}}}`,
+		},
+		{
+			name: "CronJob job template",
+			content: "apiVersion: batch/v1\nkind: CronJob\nmetadata:\n  name: backup\n  creationTimestamp: null\nspec:\n  jobTemplate:\n" +
+				"    metadata:\n      creationTimestamp: null\n    spec:\n      template:\n        metadata:\n          creationTimestamp: null\n",
+			want: `{"apiVersion":"batch/v1","kind":"CronJob","metadata":{"name":"backup"},"spec":{"jobTemplate":{"metadata":{},"spec":{"template":{"metadata":{}}}}}}`,
+		},
+		{
+			name: "Custom resource",
+			content: "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n  uid: abc\nspec:\n  size: 3\n" +
+				"status:\n  ready: true\n",
+			want: `{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"w"},"spec":{"size":3}}`,
+		},
+		{
+			name: "List items",
+			content: "apiVersion: v1\nkind: List\nmetadata:\n  resourceVersion: \"1\"\nitems:\n" +
+				"- kind: Pod\n  metadata:\n    name: a\n    uid: x\n  status:\n    phase: Running\n",
+			want: `{"apiVersion":"v1","kind":"List","metadata":{},"items":[{"kind":"Pod","metadata":{"name":"a"}}]}`,
+		},
+		{
+			name:    "Nested status fields are kept",
+			content: "kind: Widget\nspec:\n  status: enabled\n",
+			want:    `{"kind":"Widget","spec":{"status":"enabled"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(tt.content), Options{Compact: true, Clean: true})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertWithoutClean(t *testing.T) {
+	got, err := Convert([]byte(exportedDeploymentYAML), Options{Compact: true})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	want := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"prod",` +
+		`"uid":"0b6c2a1e-1f0e-4c1a-9d3b-6d4a3e2f1c0b","resourceVersion":"123456","generation":4,"creationTimestamp":"2023-05-01T10:00:00Z",` +
+		`"selfLink":"/apis/apps/v1/namespaces/prod/deployments/web","managedFields":[{"manager":"kubectl","operation":"Update"}],"labels":{"app":"web"}},` +
+		`"spec":{"replicas":2,"template":{"metadata":{"creationTimestamp":null,"labels":{"app":"web"}},` +
+		`"spec":{"containers":[{"name":"web","image":"nginx"}]}}},"status":{"replicas":2}}`
+	if string(got) != want {
+		t.Errorf("Convert() = %s, want %s", got, want)
+	}
+}
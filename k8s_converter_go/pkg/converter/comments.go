@@ -0,0 +1,130 @@
+package converter
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommentsKey is the key under which Options.KeepComments adds the comments
+// of a document to its root object. Its value maps the query path of each
+// commented field, such as ".spec.replicas" or ".spec.containers[0]", or "."
+// for the document itself, to an object with the "head" comment on the lines
+// before the field, the "line" comment at the end of its line and the "foot"
+// comment after it. The comment text has the leading "# " of every line
+// removed. A comment at the top of a document belongs to the document when a
+// blank line separates it from the first field, and to that field otherwise.
+const CommentsKey = "x-yaml-comments"
+
+// keepComments adds the comments of item, decoded from doc, to its value
+// under CommentsKey. The comments around the whole document are only kept
+// for the document itself, not for the items of an exploded list.
+func keepComments(doc parsedDocument, item listItem, opts Options) {
+	object, ok := item.value.(*Object)
+	if !ok {
+		return
+	}
+	collector := commentCollector{
+		decoder:  valueDecoder{document: doc.index, opts: opts},
+		comments: NewObject(),
+	}
+	root := commentText{head: item.node.HeadComment, line: item.node.LineComment, foot: item.node.FootComment}
+	if item.position == 0 {
+		root = root.merge(commentText{head: doc.headComment, foot: doc.footComment})
+	}
+	collector.add("", root)
+	collector.walk(item.node, "")
+	if collector.comments.Len() > 0 {
+		object.Set(CommentsKey, collector.comments)
+	}
+}
+
+// commentCollector gathers the comments of a node tree by query path.
+type commentCollector struct {
+	// decoder turns mapping keys into the keys of the decoded objects.
+	decoder  valueDecoder
+	comments *Object
+}
+
+// walk collects the comments of the fields and items below node, found at
+// path. Aliases are not followed; their comments belong to the anchor.
+func (c *commentCollector) walk(node *yaml.Node, path string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			key, err := c.decoder.mappingKey(keyNode, path)
+			if err != nil {
+				continue
+			}
+			fieldPath := path + querySegment{field: key}.String()
+			c.add(fieldPath, nodeComments(keyNode).merge(nodeComments(valueNode)))
+			c.walk(valueNode, fieldPath)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			itemPath := path + querySegment{index: i, isIndex: true}.String()
+			c.add(itemPath, nodeComments(child))
+			c.walk(child, itemPath)
+		}
+	}
+}
+
+// add records the comments of the field at path, if there are any.
+func (c *commentCollector) add(path string, text commentText) {
+	entry := NewObject()
+	for _, part := range []struct{ name, comment string }{
+		{"head", text.head},
+		{"line", text.line},
+		{"foot", text.foot},
+	} {
+		if comment := cleanComment(part.comment); comment != "" {
+			entry.Set(part.name, comment)
+		}
+	}
+	if entry.Len() > 0 {
+		c.comments.Set(pathOrRoot(path), entry)
+	}
+}
+
+// commentText holds the raw head, line and foot comments of a field.
+type commentText struct {
+	head, line, foot string
+}
+
+// nodeComments returns the comments yaml.v3 attached to node.
+func nodeComments(node *yaml.Node) commentText {
+	return commentText{head: node.HeadComment, line: node.LineComment, foot: node.FootComment}
+}
+
+// merge joins the comments of t and other, such as the comments of a mapping
+// key and of its value.
+func (t commentText) merge(other commentText) commentText {
+	return commentText{
+		head: joinComments(t.head, other.head),
+		line: joinComments(t.line, other.line),
+		foot: joinComments(t.foot, other.foot),
+	}
+}
+
+func joinComments(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n" + b
+}
+
+// cleanComment removes the leading "#" and the space after it from every line
+// of a comment, and drops blank lines.
+func cleanComment(comment string) string {
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		line = strings.TrimPrefix(line, "#")
+		lines = append(lines, strings.TrimPrefix(line, " "))
+	}
+	return strings.Join(lines, "\n")
+}
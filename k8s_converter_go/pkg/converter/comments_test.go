@@ -0,0 +1,118 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeepComments(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+		want  string
+	}{
+		{
+			name: "mapping keys",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  # owned by team-payments
+  name: web
+  labels:
+    app: web # used by the service selector
+spec:
+  replicas: 3 # do not scale above 5
+`,
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","labels":{"app":"web"}},"spec":{"replicas":3},` +
+				`"x-yaml-comments":{".metadata.name":{"head":"owned by team-payments"},".metadata.labels.app":{"line":"used by the service selector"},".spec.replicas":{"line":"do not scale above 5"}}}`,
+		},
+		{
+			name: "sequence items",
+			input: `spec:
+  containers:
+    # the application
+    - name: web
+      image: nginx # pinned by the platform team
+    - name: sidecar # injected
+  args: ["--verbose"] # for debugging
+`,
+			want: `{"spec":{"containers":[{"name":"web","image":"nginx"},{"name":"sidecar"}],"args":["--verbose"]},` +
+				`"x-yaml-comments":{".spec.containers[0]":{"head":"the application"},".spec.containers[0].image":{"line":"pinned by the platform team"},".spec.containers[1].name":{"line":"injected"},".spec.args":{"line":"for debugging"}}}`,
+		},
+		{
+			name: "document head comments",
+			input: `# Source: payments/templates/service.yaml
+#   generated, do not edit
+
+kind: Service
+metadata:
+  annotations:
+    "example.com/owner": payments # quoted key
+---
+# the kind
+kind: ConfigMap
+`,
+			want: `[{"kind":"Service","metadata":{"annotations":{"example.com/owner":"payments"}},` +
+				`"x-yaml-comments":{".":{"head":"Source: payments/templates/service.yaml\n  generated, do not edit"},".metadata.annotations[\"example.com/owner\"]":{"line":"quoted key"}}},` +
+				`{"kind":"ConfigMap","x-yaml-comments":{".kind":{"head":"the kind"}}}]`,
+		},
+		{
+			name:  "no comments",
+			input: "kind: ConfigMap\ndata:\n  key: value\n",
+			want:  `{"kind":"ConfigMap","data":{"key":"value"}}`,
+		},
+		{
+			name: "exploded list items",
+			input: `# the list
+apiVersion: v1
+kind: List
+items:
+  - kind: ConfigMap # first item
+  - kind: Secret
+    # the token
+    data: {}
+`,
+			opts: Options{ExplodeLists: true},
+			want: `[{"kind":"ConfigMap","x-yaml-comments":{".kind":{"line":"first item"}}},{"kind":"Secret","data":{},"x-yaml-comments":{".data":{"head":"the token"}}}]`,
+		},
+		{
+			name:  "sorted keys",
+			input: "# config\n\nkind: ConfigMap # the kind\napiVersion: v1\n",
+			opts:  Options{SortKeys: true},
+			want:  `{"apiVersion":"v1","kind":"ConfigMap","x-yaml-comments":{".":{"head":"config"},".kind":{"line":"the kind"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.KeepComments = true
+			opts.Compact = true
+			got, err := Convert([]byte(tt.input), opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentsOffByDefault(t *testing.T) {
+	got, err := Convert([]byte("# owned by team-payments\nkind: ConfigMap # the kind\n"), Options{Compact: true})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if strings.Contains(string(got), CommentsKey) {
+		t.Errorf("Convert() = %s, want no comments", got)
+	}
+}
+
+func TestKeepCommentsSchemaValidate(t *testing.T) {
+	input := "# config\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config # the name\n"
+	if err := Validate([]byte(input), Options{KeepComments: true, SchemaValidate: true}); err != nil {
+		t.Errorf("Validate() error = %v, want comments to be ignored by schema validation", err)
+	}
+}
@@ -0,0 +1,725 @@
+// Package converter converts Kubernetes YAML manifests to JSON and back.
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Options controls how documents are converted.
+type Options struct {
+	// Separate emits each YAML document as its own JSON document instead of
+	// wrapping multiple documents in a JSON array.
+	Separate bool
+	// Reverse converts JSON input to YAML instead.
+	Reverse bool
+	// JSONInput reads the input as JSON instead of YAML, converting it to
+	// JSON again with every other option applied, such as SortKeys, Clean
+	// or KubernetesStrict. The input is a JSON value, or a stream of values
+	// such as NDJSON; each value is a document, and so is each item of a
+	// top-level array. Malformed JSON is reported as a *ParseError with
+	// Format "JSON" at the line and column of the problem. JSON strings are
+	// always strings, so "yes" or "2023-05-01" are never read as booleans or
+	// timestamps.
+	JSONInput bool
+	// Compact emits each JSON value on a single line without indentation.
+	Compact bool
+	// Format is the output format, FormatJSON, FormatNDJSON, FormatGo,
+	// FormatHCL, FormatCSV, FormatMarkdown or FormatDOT. It defaults to
+	// FormatJSON when empty.
+	Format string
+	// GoPackage is the package clause of FormatGo output. It defaults to
+	// DefaultGoPackage when empty.
+	GoPackage string
+	// CSVColumns are query paths, such as spec.replicas, whose values
+	// FormatCSV writes in extra columns after the fixed ones.
+	CSVColumns []string
+	// Source names the input in the source column of FormatCSV and
+	// FormatMarkdown output.
+	Source string
+	// Indent is the string used for each indentation level of JSON output.
+	// It defaults to DefaultIndent when empty.
+	Indent string
+	// RejectNonStringKeys reports mapping keys that are not strings as a
+	// *KeyError instead of converting them to strings.
+	RejectNonStringKeys bool
+	// StrictKeys reports duplicate mapping keys as a *DuplicateKeyError.
+	// Otherwise each duplicate is reported as a warning and the last
+	// occurrence of the key wins.
+	StrictKeys bool
+	// KubernetesStrict requires every document to have a non-empty
+	// apiVersion, kind, and metadata.name, returning a *MissingFieldsError
+	// otherwise.
+	KubernetesStrict bool
+	// HelmPlaceholders replaces Helm template actions such as
+	// {{ .Values.image }} with HelmPlaceholder before parsing, so that the
+	// structure of an un-rendered template can be converted for inspection.
+	// Actions inside block scalars are left unchanged.
+	HelmPlaceholders bool
+	// EnvSubst expands ${VAR} and ${VAR:-default} references to environment
+	// variables before parsing. A reference to an unset variable without a
+	// default is reported in a *MissingEnvError.
+	EnvSubst bool
+	// EnvAllowlist restricts EnvSubst to the listed variables; references to
+	// other variables are left unchanged. All variables may be substituted
+	// when it is nil.
+	EnvAllowlist []string
+	// LookupEnv looks up environment variables for EnvSubst. It defaults to
+	// os.LookupEnv when nil.
+	LookupEnv func(string) (string, bool)
+	// Values substitutes ${values.a.b} references in the scalars of the
+	// input with the value at that dotted path of these nested mappings,
+	// which are map[string]interface{} as decoded from YAML. A scalar that
+	// is only a reference takes the value with its type, and a reference
+	// inside a longer or quoted string adds the text of the value, so
+	// values never change the structure of a document. $${values.x} gives a
+	// literal ${values.x}. References to undefined values are reported in a
+	// *ValuesError. Nothing is substituted when it is nil.
+	Values map[string]interface{}
+	// NoAliases rejects documents that use YAML aliases with an *AliasError.
+	// Merge keys with an inline mapping are still allowed.
+	NoAliases bool
+	// MaxAliasNodes limits how far aliases and merge keys may expand a
+	// document: decoding fails with an *AliasLimitError once it produces
+	// more than MaxAliasNodes nodes beyond those written in the document.
+	// It defaults to DefaultMaxAliasNodes when zero.
+	MaxAliasNodes int
+	// MaxAliasDepth limits how deeply aliases may nest, where the anchored
+	// node expanded by an alias itself contains an alias, and so on. It
+	// defaults to DefaultMaxAliasDepth when zero.
+	MaxAliasDepth int
+	// MaxSize limits the input read, in bytes: decoding fails with a
+	// *SizeLimitError once the input holds more, or once gzip input
+	// decompresses to more. Zero is no limit.
+	MaxSize int64
+	// SortKeys emits the keys of every object, including objects nested in
+	// arrays, sorted byte-wise instead of in the order they appear in the
+	// input, so that documents with the same content convert to identical
+	// bytes regardless of how their keys were ordered.
+	SortKeys bool
+	// Canonical emits the canonical form of JSON output, for hashing and
+	// caching: keys are sorted as with SortKeys, values are compact with no
+	// insignificant whitespace, numbers are rewritten by canonicalNumber so
+	// that 3, 3.0 and 0x3 are the same, and every JSON value is followed by
+	// a newline. Strings are escaped as encoding/json does, with <, > and &
+	// written as \u003c, \u003e and \u0026. Documents with the same content
+	// convert to identical bytes however the YAML was formatted, including
+	// its key order, quoting, flow or block style, anchors and aliases. It
+	// overrides Compact and Indent.
+	Canonical bool
+	// YAML11Bools decodes the unquoted scalars yes, no, on and off, in
+	// lowercase, capitalized or uppercase form, as booleans the way YAML 1.1
+	// does, instead of as strings. Quoted values and mapping keys are never
+	// changed.
+	YAML11Bools bool
+	// WarnYAML11Bools reports a warning for every unquoted scalar that
+	// YAML11Bools would change, so that authors can quote them.
+	WarnYAML11Bools bool
+	// RawTimestamps keeps unquoted timestamps such as 2023-05-01 as the
+	// string they were written as, instead of normalizing them to RFC 3339
+	// form.
+	RawTimestamps bool
+	// Binary is how !!binary scalars are written: BinaryBase64, BinaryHex, or
+	// BinaryError to reject them. It defaults to BinaryBase64 when empty.
+	Binary string
+	// EmptyDocuments is what happens to empty documents in a stream, such as
+	// the documents holding only a comment in rendered Helm output:
+	// EmptyDocumentsSkip, EmptyDocumentsError or EmptyDocumentsNull. It
+	// defaults to EmptyDocumentsSkip when empty. A stream in which every
+	// document is empty returns ErrInvalidYAML whatever the policy.
+	EmptyDocuments string
+	// DecodeSecrets base64-decodes the values under data in every Secret and
+	// moves them into stringData. Values that are not valid base64 or not
+	// UTF-8 text are left encoded and reported as warnings. Other kinds,
+	// including the binaryData of a ConfigMap, are never changed.
+	DecodeSecrets bool
+	// RedactSecrets replaces every value under data and stringData in every
+	// Secret, and sensitive annotations such as
+	// kubectl.kubernetes.io/last-applied-configuration, with RedactedValue,
+	// keeping the keys. It takes precedence over DecodeSecrets.
+	RedactSecrets bool
+	// ExplodeLists replaces each list document, a v1 List or a kind ending in
+	// List such as DeploymentList, with its items, so that they are filtered,
+	// split and output as documents of their own. Nested lists are
+	// flattened, and a list with no items is reported as a warning.
+	ExplodeLists bool
+	// Kinds keeps only the documents whose kind is one of the listed kinds,
+	// ignoring case. Documents without a kind are dropped. When this or any
+	// other document filter is set and no document matches them all,
+	// ErrNoMatch is returned. All kinds are kept when it is nil.
+	Kinds []string
+	// Namespace keeps only the documents whose metadata.namespace is exactly
+	// Namespace.
+	Namespace string
+	// Name keeps only the documents whose metadata.name matches the glob
+	// pattern Name, in path.Match syntax, such as web-*.
+	Name string
+	// Selector keeps only the documents whose metadata.labels match the label
+	// selector, such as app=web,tier!=cache or env in (prod, staging).
+	// Documents without metadata match no selector.
+	Selector string
+	// SortByApplyOrder sorts the documents, and the items of list kinds,
+	// into the order they can be applied to a cluster in: Namespaces and
+	// CustomResourceDefinitions first, then ServiceAccounts, RBAC, Secrets
+	// and ConfigMaps, workloads, Services and Ingresses, with kinds not in
+	// the list after the others. Documents of the same kind are sorted by
+	// namespace and name. Streaming conversion reads the whole input first.
+	SortByApplyOrder bool
+	// Query is a path such as .spec.containers[0].image. When set, only the
+	// value at the path in each document is emitted, one value per document
+	// with no surrounding array. A path missing from a document returns a
+	// *QueryError.
+	Query string
+	// Raw emits string results of Query without JSON quoting.
+	Raw bool
+	// Typed decodes every document of a built-in kind, such as an apps/v1
+	// Deployment, into its k8s.io/api type and converts the typed object
+	// instead, so that a value of the wrong type or an unknown field returns a
+	// *TypedError, as the API server would reject it. The output has the
+	// fields of the type in its order, including those it always writes,
+	// such as metadata.creationTimestamp. Documents of other kinds are
+	// converted as usual with a warning. API server defaults are not applied.
+	Typed bool
+	// WarnDeprecated warns about every document whose apiVersion is
+	// deprecated or removed for its kind, such as an extensions/v1beta1
+	// Ingress, naming the apiVersion to use instead.
+	WarnDeprecated bool
+	// KubernetesVersion limits WarnDeprecated and FailDeprecated to the
+	// apiVersions deprecated by this Kubernetes release, such as 1.29. All
+	// known deprecations are reported when it is empty.
+	KubernetesVersion string
+	// FailDeprecated returns a *DeprecatedAPIError for the first document
+	// that WarnDeprecated would warn about. It does not need WarnDeprecated.
+	FailDeprecated bool
+	// ExpandLastApplied replaces the JSON string of the
+	// kubectl.kubernetes.io/last-applied-configuration annotation of every
+	// document with the object it describes, so that it can be read. The
+	// annotation is then no longer a string, as Kubernetes requires. A value
+	// that is not valid JSON is left as it is and reported as a warning.
+	ExpandLastApplied bool
+	// Clean removes fields populated by the Kubernetes API server, such as
+	// status and metadata.managedFields, from every document.
+	Clean bool
+	// DeletePaths removes the value at each path from every document, as
+	// for Clean. A path is made of fields and array indices, as for Query,
+	// a field holding dots can be double-quoted after its dot, as in
+	// metadata.annotations."kubectl.kubernetes.io/last-applied-configuration",
+	// and [*] stands for every element of an array, as in
+	// spec.containers[*].resources. Paths matching nothing in a document are
+	// ignored unless StrictPaths is set, which makes them a
+	// *PathNotFoundError.
+	DeletePaths []string
+	StrictPaths bool
+	// SetFields sets a value at a path in every document, after
+	// DeletePaths, creating the objects missing on the way. The values of
+	// fields not set as strings are parsed as YAML scalars. A path that
+	// cannot be set returns a *SetFieldError.
+	SetFields []SetField
+	// SetNamespace is set as the metadata.namespace of every document of a
+	// namespaced kind that has none, as kubectl apply -n does, and of those
+	// that have one too with ForceNamespace. Cluster-scoped kinds such as
+	// Namespace and ClusterRole are left alone, and so are kinds whose scope
+	// is not known, such as custom resources, with a warning. The items of
+	// list kinds such as v1 List are set instead of the list.
+	SetNamespace   string
+	ForceNamespace bool
+	// AddLabels and AddAnnotations are added to the metadata.labels and
+	// metadata.annotations of every document with a kind, in order, creating
+	// the mappings when they are missing. Documents without a kind are left
+	// untouched, and the items of list kinds such as v1 List get them
+	// instead of the list. Labels and annotations already set keep their
+	// value unless OverrideLabels is set.
+	AddLabels      []KeyValue
+	AddAnnotations []KeyValue
+	OverrideLabels bool
+	// OmitNull removes every mapping member whose value is null, at any
+	// depth and in any YAML, such as the creationTimestamp: null of exported
+	// manifests. Null sequence items are kept.
+	OmitNull bool
+	// OmitEmpty, with OmitNull, also removes the mappings and sequences that
+	// are left empty by removing null members. Those that are empty in the
+	// input are kept.
+	OmitEmpty bool
+	// Flatten writes each document, or its Query result, as a single object
+	// mapping the path of every value to the value, such as
+	// "spec.containers[0].image": "nginx:1.25", for tools that want flat
+	// properties. Paths are query paths without the leading dot, with fields
+	// holding dots quoted as in metadata.labels["app.kubernetes.io/name"].
+	// Empty objects and arrays are kept as values. Filters and validation
+	// see the documents before they are flattened.
+	Flatten bool
+	// KeepComments adds the YAML comments of every document to it under
+	// CommentsKey, keyed by the query path of the field they belong to.
+	KeepComments bool
+	// VerifyRoundTrip checks that every converted document survives being
+	// written as JSON, converted back to YAML and read again, returning a
+	// *RoundTripError for the first value that changes. Key order, quoting
+	// and comments are not compared, and numbers are compared by value.
+	VerifyRoundTrip bool
+	// SchemaValidate checks every converted document against the OpenAPI
+	// schema of its apiVersion and kind, returning a *SchemaError that lists
+	// every violation, such as an unknown field or a string where an integer
+	// belongs. Documents of kinds without a schema are skipped with a warning.
+	SchemaValidate bool
+	// StrictFields checks every converted document of a kind with a schema
+	// for fields the schema does not have, which the API server would drop,
+	// returning a *SchemaError that lists each with its path and the nearest
+	// field of the schema as a suggestion. Fields under
+	// x-kubernetes-preserve-unknown-fields are kept. Unlike SchemaValidate,
+	// no other violations are reported, and documents of kinds without a
+	// schema are skipped with a warning.
+	StrictFields bool
+	// ValidateNames checks the names of every converted document against
+	// the rules of the API server, returning a *NameError that lists every
+	// name it would reject: metadata.name as a DNS-1123 subdomain, or for
+	// some kinds a DNS-1123 or DNS-1035 label or a path segment,
+	// metadata.generateName as a prefix of one, and metadata.namespace and
+	// container names as DNS-1123 labels.
+	ValidateNames bool
+	// ValidateLabels checks the label and annotation keys and label values
+	// of every converted document, in metadata, spec.selector and pod
+	// templates, returning a *LabelError that lists every one the API server
+	// would reject. Annotations over the 256KiB the API server accepts in
+	// total are reported as a warning.
+	ValidateLabels bool
+	// ValidateImages checks the image of every container, init container and
+	// ephemeral container of every converted document against the Docker
+	// reference grammar, returning an *ImageError that lists every invalid
+	// reference with its path. Images with neither a tag nor a digest are
+	// reported as a warning.
+	ValidateImages bool
+	// ValidateQuantities checks the requests and limits of the containers
+	// and pods of every converted document, returning a *QuantityError that
+	// lists every resource name or amount the API server would reject, such
+	// as memory: 512mb or cpu: 0,5.
+	ValidateQuantities bool
+	// SchemaDir is a directory of OpenAPI documents, such as the Kubernetes
+	// swagger.json or the OpenAPI v3 files, used by SchemaValidate instead of
+	// the embedded Kubernetes schemas.
+	SchemaDir string
+	// CRDDir is a directory of CustomResourceDefinition YAML or JSON files.
+	// Documents whose group, version and kind match a CRD are validated
+	// against the openAPIV3Schema of that version. Setting it enables
+	// SchemaValidate, or with StrictFields only the unknown-field check.
+	CRDDir string
+	// RequireSchema makes documents of kinds without a schema violations
+	// instead of warnings.
+	RequireSchema bool
+	// FileMode is the mode of the files written by ConvertFile, set exactly
+	// whatever the umask. When it is 0, a new file is created with mode 0644
+	// less the umask, and a file that is replaced keeps its mode.
+	FileMode os.FileMode
+	// Warn is called for each non-fatal problem found in the input. Warnings
+	// are discarded when it is nil.
+	Warn func(Warning)
+}
+
+// DefaultIndent is the JSON indentation used when Options.Indent is empty.
+const DefaultIndent = "  "
+
+// Default limits on alias expansion, far beyond what manifests that reuse a
+// few anchors need, but small enough that a "billion laughs" document fails
+// in milliseconds instead of exhausting memory.
+const (
+	// DefaultMaxAliasNodes is the node budget used when
+	// Options.MaxAliasNodes is zero.
+	DefaultMaxAliasNodes = 1000000
+	// DefaultMaxAliasDepth is the nesting limit used when
+	// Options.MaxAliasDepth is zero.
+	DefaultMaxAliasDepth = 10
+)
+
+// Output formats for Options.Format.
+const (
+	// FormatJSON emits a single JSON value per document, wrapped in an array
+	// for multi-document input unless Options.Separate is set.
+	FormatJSON = "json"
+	// FormatNDJSON emits each document as a compact JSON value on its own
+	// line, with no surrounding array.
+	FormatNDJSON = "ndjson"
+	// FormatGo emits gofmt-formatted Go source declaring a
+	// map[string]interface{} variable for each document, for test fixtures.
+	// The output is only written once every document has been decoded.
+	FormatGo = "go"
+	// FormatHCL emits a Terraform kubernetes_manifest resource for each
+	// document, with the document as its manifest in HCL object syntax. The
+	// output is only written once every document has been decoded.
+	FormatHCL = "hcl"
+	// FormatCSV emits an inventory of the documents instead of their
+	// content: a header row, then a row per document with its apiVersion,
+	// kind, namespace, name, source and index, and Options.CSVColumns.
+	FormatCSV = "csv"
+	// FormatMarkdown emits a summary of the documents as a GitHub-flavored
+	// Markdown table of their kind, namespace, name and source, sorted by
+	// kind and name, followed by the number of documents.
+	FormatMarkdown = "markdown"
+	// FormatDOT emits a Graphviz digraph of the documents and the references
+	// between them, such as the ConfigMaps a Deployment mounts, the pods a
+	// Service selects and the Services an Ingress routes to. Referenced
+	// resources missing from the input are drawn dashed.
+	FormatDOT = "dot"
+)
+
+// Encodings of !!binary scalars for Options.Binary.
+const (
+	// BinaryBase64 writes binary data as a standard base64 string, the form
+	// the Kubernetes API expects in a ConfigMap's binaryData.
+	BinaryBase64 = "base64"
+	// BinaryHex writes binary data as a lowercase hexadecimal string.
+	BinaryHex = "hex"
+	// BinaryError rejects documents containing binary data with a
+	// *ParseError.
+	BinaryError = "error"
+)
+
+// Policies for empty documents for Options.EmptyDocuments.
+const (
+	// EmptyDocumentsSkip leaves empty documents out of the output.
+	EmptyDocumentsSkip = "skip"
+	// EmptyDocumentsError rejects the first empty document with an error
+	// wrapping ErrInvalidYAML that names its position and line.
+	EmptyDocumentsError = "error"
+	// EmptyDocumentsNull writes a JSON null for every empty document, so
+	// that each document of the output is at the position of its source in
+	// the stream.
+	EmptyDocumentsNull = "null"
+)
+
+// Convert converts YAML data to JSON, or JSON data to YAML when opts.Reverse
+// is set. Gzip-compressed data is decompressed transparently.
+func Convert(data []byte, opts Options) ([]byte, error) {
+	if opts.Reverse {
+		return ConvertJSONToYAML(data)
+	}
+
+	switch opts.Format {
+	case "", FormatJSON:
+		documents, err := Decode(data, opts)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Query != "" {
+			values, err := queryDocuments(documents, opts)
+			if err != nil {
+				return nil, err
+			}
+			return marshalQueryResults(flattenValues(values, opts), opts)
+		}
+		return MarshalDocuments(flattenValues(Values(documents), opts), opts)
+	case FormatNDJSON:
+		var buf bytes.Buffer
+		if err := writeNDJSON(bytes.NewReader(data), &buf, opts); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatCSV:
+		var buf bytes.Buffer
+		if err := writeCSV(bytes.NewReader(data), &buf, opts); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatMarkdown, FormatDOT:
+		documents, err := Decode(data, opts)
+		if err != nil {
+			return nil, err
+		}
+		return MarshalInventory([]Inventory{{Source: opts.Source, Documents: documents}}, opts)
+	case FormatGo, FormatHCL:
+		documents, err := Decode(data, opts)
+		if err != nil {
+			return nil, err
+		}
+		values := Values(documents)
+		if opts.Query != "" {
+			if values, err = queryDocuments(documents, opts); err != nil {
+				return nil, err
+			}
+		}
+		if opts.Format == FormatHCL {
+			return marshalHCL(documents, flattenValues(values, opts))
+		}
+		return marshalGo(documents, flattenValues(values, opts), opts)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", opts.Format)
+	}
+}
+
+// ConvertStream reads the input from r and writes the converted result to w.
+// YAML input is decoded and written document by document, so memory use is
+// bounded by the largest document rather than the whole stream, and the
+// output is the same as that of Convert. Unlike Convert, each document is
+// validated as it is read, so an error in a later document is returned
+// after the earlier ones have been written, and errors that Convert
+// collects across documents are reported for the first failing document.
+// Reverse conversion, FormatGo, FormatHCL, FormatMarkdown and FormatDOT
+// read the whole input first.
+func ConvertStream(r io.Reader, w io.Writer, opts Options) error {
+	if !opts.Reverse {
+		switch opts.Format {
+		case "", FormatJSON:
+			return writeJSON(r, w, opts)
+		case FormatNDJSON:
+			return writeNDJSON(r, w, opts)
+		case FormatCSV:
+			return writeCSV(r, w, opts)
+		case FormatGo, FormatHCL, FormatMarkdown, FormatDOT:
+		default:
+			return fmt.Errorf("unknown output format %q", opts.Format)
+		}
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return &IOError{Op: "read", Path: "input", Err: err}
+	}
+	result, err := Convert(data, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(result); err != nil {
+		return &IOError{Op: "write", Path: "output", Err: err}
+	}
+	return nil
+}
+
+// writeNDJSON decodes the YAML stream read from r and writes each document, or
+// its Query result, to w as a compact JSON value followed by a newline.
+func writeNDJSON(r io.Reader, w io.Writer, opts Options) error {
+	opts.Compact = true
+	return DecodeStream(r, opts, func(doc Document) error {
+		value := doc.Value
+		if opts.Query != "" {
+			values, err := queryDocuments([]Document{doc}, opts)
+			if err != nil {
+				return err
+			}
+			value = values[0]
+		}
+		if opts.Flatten {
+			value = flatten(value, opts)
+		}
+		line, err := marshalValue(value, opts)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return &IOError{Op: "write", Path: "output", Err: err}
+		}
+		return nil
+	})
+}
+
+// Validate checks that data is a YAML stream of mapping documents that are
+// not all empty, without converting it. Every document is
+// validated, whether or not it is selected by the filters of opts.
+func Validate(data []byte, opts Options) error {
+	_, err := Decode(data, opts)
+	if errors.Is(err, ErrNoMatch) {
+		return nil
+	}
+	return err
+}
+
+// decodeAll decodes parsed documents into JSON-compatible Go values.
+func decodeAll(documents []parsedDocument) ([]interface{}, error) {
+	var values []interface{}
+	for _, doc := range documents {
+		value, err := doc.decode(Options{})
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// ConvertFile converts the file at in and writes the result to out. YAML
+// input is streamed to out as it is decoded. out is written as an
+// AtomicFile, so that it keeps its old content if the conversion fails.
+// A file larger than opts.MaxSize fails before any of it is read.
+func ConvertFile(in, out string, opts Options) error {
+	if info, err := os.Stat(in); err == nil && opts.MaxSize > 0 && info.Mode().IsRegular() && info.Size() > opts.MaxSize {
+		return &SizeLimitError{Limit: opts.MaxSize}
+	}
+	if !opts.Reverse {
+		return streamFile(in, out, opts)
+	}
+
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return &IOError{Op: "read", Path: in, Err: err}
+	}
+
+	result, err := Convert(data, opts)
+	if err != nil {
+		return err
+	}
+
+	output, err := createOutput(out, opts)
+	if err != nil {
+		return &IOError{Op: "write", Path: out, Err: err}
+	}
+	if _, err := output.Write(result); err != nil {
+		output.Abort()
+		return &IOError{Op: "write", Path: out, Err: err}
+	}
+	if err := output.Commit(); err != nil {
+		return &IOError{Op: "write", Path: out, Err: err}
+	}
+	return nil
+}
+
+// createOutput starts writing the output file of ConvertFile at out, with
+// opts.FileMode when it is set.
+func createOutput(out string, opts Options) (*AtomicFile, error) {
+	output, err := CreateAtomic(out, 0644)
+	if err != nil || opts.FileMode == 0 {
+		return output, err
+	}
+	if err := output.Chmod(opts.FileMode); err != nil {
+		output.Abort()
+		return nil, err
+	}
+	return output, nil
+}
+
+// streamFile converts the file at in with ConvertStream, writing to out as
+// an AtomicFile that is only renamed into place once the whole stream has
+// been converted.
+func streamFile(in, out string, opts Options) error {
+	input, err := os.Open(in)
+	if err != nil {
+		return &IOError{Op: "read", Path: in, Err: err}
+	}
+	defer input.Close()
+
+	output, err := createOutput(out, opts)
+	if err != nil {
+		return &IOError{Op: "write", Path: out, Err: err}
+	}
+	if err := ConvertStream(bufio.NewReader(limitReader(input, opts.MaxSize)), output, opts); err != nil {
+		output.Abort()
+		return err
+	}
+	if err := output.Commit(); err != nil {
+		return &IOError{Op: "write", Path: out, Err: err}
+	}
+	return nil
+}
+
+// ConvertJSONToYAML converts a JSON value to YAML. A top-level array is
+// emitted as multiple YAML documents separated by ---. Numbers keep their
+// exact digits. Gzip-compressed input is decompressed first, UTF-16 input is
+// transcoded to UTF-8, and a leading byte order mark is ignored.
+func ConvertJSONToYAML(data []byte) ([]byte, error) {
+	data, err := decompressText(data, Options{})
+	if err != nil {
+		return nil, err
+	}
+	value, err := decodeJSON(trimBOM(data))
+	if err != nil {
+		return nil, err
+	}
+
+	documents, ok := value.([]interface{})
+	if !ok {
+		documents = []interface{}{value}
+	}
+	return marshalYAML(documents)
+}
+
+// IsValidYAML reports whether data is a YAML stream of mapping documents that
+// are not all empty. Empty documents in the stream are ignored.
+func IsValidYAML(data []byte) bool {
+	return Validate(data, Options{}) == nil
+}
+
+// DecodeDocuments parses every document in a YAML stream, skipping empty ones.
+func DecodeDocuments(data []byte) ([]interface{}, error) {
+	documents, err := parseDocuments(data, Options{})
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll(documents)
+}
+
+// MarshalDocuments converts the parsed documents to JSON. A single document is
+// emitted as-is; multiple documents are emitted as a JSON array, or one after
+// another when opts.Separate is set. Separate compact documents are written
+// one per line. Canonical output ends with a newline.
+func MarshalDocuments(documents []interface{}, opts Options) ([]byte, error) {
+	data, err := marshalDocuments(documents, opts)
+	if err != nil || !opts.Canonical || (opts.Separate && len(documents) == 0) {
+		return data, err
+	}
+	return append(data, '\n'), nil
+}
+
+func marshalDocuments(documents []interface{}, opts Options) ([]byte, error) {
+	if len(documents) == 1 {
+		return marshalJSON(documents[0], opts)
+	}
+	if !opts.Separate {
+		if documents == nil {
+			documents = []interface{}{}
+		}
+		return marshalJSON(documents, opts)
+	}
+	var buf bytes.Buffer
+	for i, doc := range documents {
+		jsonData, err := marshalJSON(doc, opts)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.Write(jsonData)
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalJSON encodes v as indented JSON, or on a single line when
+// opts.compact reports true.
+func marshalJSON(v interface{}, opts Options) ([]byte, error) {
+	var jsonData []byte
+	var err error
+	if opts.compact() {
+		jsonData, err = json.Marshal(v)
+	} else {
+		indent := opts.Indent
+		if indent == "" {
+			indent = DefaultIndent
+		}
+		jsonData, err = json.MarshalIndent(v, "", indent)
+	}
+	if err != nil {
+		return nil, &EncodeError{Format: "JSON", Err: err}
+	}
+	return jsonData, nil
+}
+
+// maxAliasNodes returns Options.MaxAliasNodes or its default.
+func (opts Options) maxAliasNodes() int {
+	if opts.MaxAliasNodes > 0 {
+		return opts.MaxAliasNodes
+	}
+	return DefaultMaxAliasNodes
+}
+
+// maxAliasDepth returns Options.MaxAliasDepth or its default.
+func (opts Options) maxAliasDepth() int {
+	if opts.MaxAliasDepth > 0 {
+		return opts.MaxAliasDepth
+	}
+	return DefaultMaxAliasDepth
+}
+
+// compact reports whether JSON values are written on a single line.
+func (opts Options) compact() bool {
+	return opts.Compact || opts.Canonical
+}
@@ -0,0 +1,372 @@
+package converter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNonYAMLInput(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name        string
+		content     []byte
+		expectError bool
+	}{
+		{
+			name:        "Plain text with .txt extension",
+			content:     []byte("This is not a YAML file\nIt's just plain text"),
+			expectError: true,
+		},
+		{
+			name:        "Invalid YAML with .yaml extension",
+			content:     []byte("This is not valid: YAML: content"),
+			expectError: true,
+		},
+		{
+			name:        "Empty YAML with .yaml extension",
+			content:     []byte(""),
+			expectError: true,
+		},
+		{
+			name:        "Valid YAML with .yaml extension",
+			content:     []byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: test-pod"),
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Test YAML validation
+			isValid := IsValidYAML(tt.content)
+			if isValid == tt.expectError {
+				t.Errorf("IsValidYAML() = %v, want %v for content: %s", isValid, !tt.expectError, string(tt.content))
+			}
+
+			// Test conversion
+			_, err := Convert(tt.content, Options{})
+			if (err != nil) != tt.expectError {
+				t.Errorf("Convert() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestConvertErrorTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		opts    Options
+		check   func(error) bool
+	}{
+		{
+			name:    "YAML syntax error",
+			content: []byte("This is not valid: YAML: content"),
+			check: func(err error) bool {
+				var parseErr *ParseError
+				return errors.As(err, &parseErr) && parseErr.Format == "YAML"
+			},
+		},
+		{
+			name:    "Scalar document",
+			content: []byte("just a string"),
+			check: func(err error) bool {
+				return errors.Is(err, ErrInvalidYAML)
+			},
+		},
+		{
+			name:    "JSON syntax error",
+			content: []byte(`{"kind":`),
+			opts:    Options{Reverse: true},
+			check: func(err error) bool {
+				var parseErr *ParseError
+				return errors.As(err, &parseErr) && parseErr.Format == "JSON"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert(tt.content, tt.opts)
+			if err == nil || !tt.check(err) {
+				t.Errorf("Convert() error = %v (%T), unexpected type", err, err)
+			}
+		})
+	}
+}
+
+func TestConvertFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "pod.yaml")
+	out := filepath.Join(dir, "pod.json")
+	if err := os.WriteFile(in, []byte("kind: Pod\n"), 0644); err != nil {
+		t.Fatalf("Failed to write content: %v", err)
+	}
+
+	if err := ConvertFile(in, out, Options{}); err != nil {
+		t.Fatalf("ConvertFile() error = %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if want := "{\n  \"kind\": \"Pod\"\n}"; string(got) != want {
+		t.Errorf("ConvertFile() wrote %q, want %q", got, want)
+	}
+
+	err = ConvertFile(filepath.Join(dir, "missing.yaml"), out, Options{})
+	var ioErr *IOError
+	if !errors.As(err, &ioErr) || ioErr.Op != "read" {
+		t.Errorf("ConvertFile() error = %v, want read IOError", err)
+	}
+}
+
+func TestMultiDocumentYAML(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   []byte
+		valid     bool
+		documents int
+	}{
+		{
+			name:      "Three documents",
+			content:   []byte("kind: Deployment\n---\nkind: Service\n---\nkind: ConfigMap\n"),
+			valid:     true,
+			documents: 3,
+		},
+		{
+			name:      "Empty first document",
+			content:   []byte("---\n---\nkind: Service\n"),
+			valid:     true,
+			documents: 1,
+		},
+		{
+			name:      "Empty and comment-only documents are skipped",
+			content:   []byte("kind: Deployment\n---\n# only a comment\n---\nkind: Service\n---\n"),
+			valid:     true,
+			documents: 2,
+		},
+		{
+			name:      "Only empty documents",
+			content:   []byte("---\n---\n"),
+			valid:     false,
+			documents: 0,
+		},
+		{
+			name:      "Invalid second document",
+			content:   []byte("kind: Deployment\n---\nThis is not valid: YAML: content\n"),
+			valid:     false,
+			documents: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if isValid := IsValidYAML(tt.content); isValid != tt.valid {
+				t.Errorf("IsValidYAML() = %v, want %v", isValid, tt.valid)
+			}
+			if !tt.valid {
+				return
+			}
+			documents, err := DecodeDocuments(tt.content)
+			if err != nil {
+				t.Fatalf("DecodeDocuments() error = %v", err)
+			}
+			if len(documents) != tt.documents {
+				t.Errorf("DecodeDocuments() returned %d documents, want %d", len(documents), tt.documents)
+			}
+		})
+	}
+}
+
+func TestMarshalDocuments(t *testing.T) {
+	documents := []interface{}{
+		map[string]interface{}{"kind": "Deployment"},
+		map[string]interface{}{"kind": "Service"},
+	}
+
+	tests := []struct {
+		name      string
+		documents []interface{}
+		separate  bool
+		compact   bool
+		want      string
+	}{
+		{
+			name:      "Single document is not wrapped",
+			documents: documents[:1],
+			want:      "{\n  \"kind\": \"Deployment\"\n}",
+		},
+		{
+			name:      "Multiple documents as array",
+			documents: documents,
+			want:      "[\n  {\n    \"kind\": \"Deployment\"\n  },\n  {\n    \"kind\": \"Service\"\n  }\n]",
+		},
+		{
+			name:      "Multiple documents separately",
+			documents: documents,
+			separate:  true,
+			want:      "{\n  \"kind\": \"Deployment\"\n}\n{\n  \"kind\": \"Service\"\n}",
+		},
+		{
+			name:      "Compact single document",
+			documents: documents[:1],
+			compact:   true,
+			want:      `{"kind":"Deployment"}`,
+		},
+		{
+			name:      "Compact array",
+			documents: documents,
+			compact:   true,
+			want:      `[{"kind":"Deployment"},{"kind":"Service"}]`,
+		},
+		{
+			name:      "Compact documents one per line",
+			documents: documents,
+			separate:  true,
+			compact:   true,
+			want:      "{\"kind\":\"Deployment\"}\n{\"kind\":\"Service\"}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalDocuments(tt.documents, Options{Separate: tt.separate, Compact: tt.compact})
+			if err != nil {
+				t.Fatalf("MarshalDocuments() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalDocuments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertJSONToYAML(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     []byte
+		want        string
+		expectError bool
+	}{
+		{
+			name:    "Single object",
+			content: []byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"test-pod"}}`),
+			want:    "apiVersion: v1\nkind: Pod\nmetadata:\n  name: test-pod\n",
+		},
+		{
+			name:    "Top-level array",
+			content: []byte(`[{"kind":"Deployment"},{"kind":"Service"}]`),
+			want:    "kind: Deployment\n---\nkind: Service\n",
+		},
+		{
+			name:    "Exact numbers",
+			content: []byte(`{"big":123456789012345678901234567890,"float":1.0,"exponent":1e400,"string":"3"}`),
+			want:    "big: !!int 123456789012345678901234567890\nexponent: !!float 1e400\nfloat: 1.0\nstring: \"3\"\n",
+		},
+		{
+			name:        "Invalid JSON",
+			content:     []byte(`{"kind":`),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertJSONToYAML(tt.content)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ConvertJSONToYAML() expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertJSONToYAML() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ConvertJSONToYAML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertMultiDocument(t *testing.T) {
+	content := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: test-pod\n---\nkind: Service\n"
+
+	got, err := Convert([]byte(content), Options{})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if !strings.HasPrefix(string(got), "[") {
+		t.Errorf("Convert() = %q, want a JSON array", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]byte("kind: Pod\n---\nkind: Service\n"), Options{}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	var parseErr *ParseError
+	if err := Validate([]byte("This is not valid: YAML: content"), Options{}); !errors.As(err, &parseErr) {
+		t.Errorf("Validate() error = %v, want ParseError", err)
+	}
+	if err := Validate([]byte("---\n"), Options{}); !errors.Is(err, ErrInvalidYAML) {
+		t.Errorf("Validate() error = %v, want ErrInvalidYAML", err)
+	}
+}
+
+func TestConvertIndent(t *testing.T) {
+	content := []byte("apiVersion: v1\nkind: ConfigMap\ndata:\n  key: value\n")
+
+	tests := []struct {
+		name   string
+		indent string
+		want   string
+	}{
+		{
+			name: "Default two spaces",
+			want: "{\n  \"apiVersion\": \"v1\",\n  \"kind\": \"ConfigMap\",\n  \"data\": {\n    \"key\": \"value\"\n  }\n}",
+		},
+		{
+			name:   "Four spaces",
+			indent: "    ",
+			want:   "{\n    \"apiVersion\": \"v1\",\n    \"kind\": \"ConfigMap\",\n    \"data\": {\n        \"key\": \"value\"\n    }\n}",
+		},
+		{
+			name:   "Tabs",
+			indent: "\t",
+			want:   "{\n\t\"apiVersion\": \"v1\",\n\t\"kind\": \"ConfigMap\",\n\t\"data\": {\n\t\t\"key\": \"value\"\n\t}\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(content, Options{Indent: tt.indent})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertNDJSON(t *testing.T) {
+	content := []byte("kind: Deployment\nspec:\n  replicas: 3\n---\n---\nkind: Service\n")
+
+	got, err := Convert(content, Options{Format: FormatNDJSON})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	want := "{\"kind\":\"Deployment\",\"spec\":{\"replicas\":3}}\n{\"kind\":\"Service\"}\n"
+	if string(got) != want {
+		t.Errorf("Convert() = %q, want %q", got, want)
+	}
+
+	if _, err := Convert(content, Options{Format: "xml"}); err == nil {
+		t.Errorf("Convert() expected error for unknown format")
+	}
+}
@@ -0,0 +1,126 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// crdKind is the kind of the CustomResourceDefinition documents read from
+// Options.CRDDir.
+const crdKind = "CustomResourceDefinition"
+
+// addCRDs adds the schema of every version of the CustomResourceDefinitions
+// in the YAML and JSON files of dir to the set. Other documents in the files
+// are ignored.
+func (s *schemaSet) addCRDs(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return &IOError{Op: "read", Path: dir, Err: err}
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.IsDir() || !hasCRDExtension(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return &IOError{Op: "read", Path: path, Err: err}
+		}
+		documents, err := Decode(data, Options{ExplodeLists: true, Kinds: []string{crdKind}})
+		if errors.Is(err, ErrNoMatch) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("invalid CRD file %s: %w", path, err)
+		}
+		for _, doc := range documents {
+			if err := s.addCRD(doc); err != nil {
+				return fmt.Errorf("invalid CRD %s in %s: %w", doc.Name(), path, err)
+			}
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no CustomResourceDefinitions found in %s", dir)
+	}
+	return nil
+}
+
+func hasCRDExtension(name string) bool {
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// addCRD adds the openAPIV3Schema of each version of a CustomResourceDefinition
+// to the set. The schema of the apiextensions.k8s.io/v1beta1 spec.validation
+// field applies to every version without a schema of its own.
+func (s *schemaSet) addCRD(doc Document) error {
+	spec := field(doc.Value, "spec")
+	group := stringField(spec, "group")
+	kind := stringField(field(spec, "names"), "kind")
+	if group == "" || kind == "" {
+		return fmt.Errorf("spec.group and spec.names.kind are required")
+	}
+
+	shared := field(field(spec, "validation"), "openAPIV3Schema")
+	versions, _ := field(spec, "versions").([]interface{})
+	if len(versions) == 0 {
+		if version := stringField(spec, "version"); version != "" {
+			versions = []interface{}{NewObject()}
+			versions[0].(*Object).Set("name", version)
+		}
+	}
+	for _, version := range versions {
+		name := stringField(version, "name")
+		value := field(field(version, "schema"), "openAPIV3Schema")
+		if value == nil {
+			value = shared
+		}
+		if name == "" || value == nil {
+			continue
+		}
+		definition, err := crdSchema(value)
+		if err != nil {
+			return fmt.Errorf("version %s: %w", name, err)
+		}
+		s.kinds[kindKey(group+"/"+name, kind)] = definition
+	}
+	return nil
+}
+
+// crdSchema converts a decoded openAPIV3Schema into a schema. The API server
+// accepts apiVersion, kind and metadata on every custom resource, so they are
+// added when the schema lists the fields of the resource.
+func crdSchema(value interface{}) (*schema, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var definition schema
+	if err := decoder.Decode(&definition); err != nil {
+		return nil, err
+	}
+	if len(definition.Properties) == 0 {
+		return &definition, nil
+	}
+	for name, property := range map[string]*schema{
+		"apiVersion": {Type: schemaTypes{"string"}},
+		"kind":       {Type: schemaTypes{"string"}},
+		"metadata":   {Ref: "#/definitions/" + objectMetaDefinition},
+	} {
+		if _, ok := definition.Properties[name]; !ok {
+			definition.Properties[name] = property
+		}
+	}
+	return &definition, nil
+}
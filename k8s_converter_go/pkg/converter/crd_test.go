@@ -0,0 +1,252 @@
+package converter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const certificateCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+spec:
+  group: cert-manager.io
+  names:
+    kind: Certificate
+    plural: certificates
+  scope: Namespaced
+  versions:
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              required: [secretName]
+              properties:
+                secretName:
+                  type: string
+                dnsNames:
+                  type: array
+                  items:
+                    type: string
+                duration:
+                  type: string
+                privateKey:
+                  type: object
+                  properties:
+                    algorithm:
+                      type: string
+                      enum: [RSA, ECDSA, Ed25519]
+                    size:
+                      type: integer
+            status:
+              type: object
+              x-kubernetes-preserve-unknown-fields: true
+    - name: v1alpha1
+      served: false
+      storage: false
+      schema:
+        openAPIV3Schema:
+          type: object
+          x-kubernetes-preserve-unknown-fields: true
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: not-a-crd
+`
+
+const legacyCRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: applications.argoproj.io
+spec:
+  group: argoproj.io
+  version: v1alpha1
+  names:
+    kind: Application
+  validation:
+    openAPIV3Schema:
+      properties:
+        spec:
+          type: object
+          required: [destination]
+          properties:
+            destination:
+              type: object
+            project:
+              type: string
+`
+
+func writeCRDs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"cert-manager.yaml": certificateCRD,
+		"argo.yml":          legacyCRD,
+		"README.md":         "not a CRD\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidateCRDs(t *testing.T) {
+	dir := writeCRDs(t)
+
+	tests := []struct {
+		name          string
+		input         string
+		requireSchema bool
+		want          []SchemaViolation
+	}{
+		{
+			name: "valid custom resources",
+			input: `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: web-tls
+  namespace: web
+spec:
+  secretName: web-tls
+  dnsNames: [example.com]
+  privateKey:
+    algorithm: ECDSA
+status:
+  conditions: []
+---
+apiVersion: cert-manager.io/v1alpha1
+kind: Certificate
+metadata:
+  name: old
+spec:
+  anything: true
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  project: default
+  destination:
+    namespace: guestbook
+`,
+		},
+		{
+			name: "type, required and enum violations",
+			input: `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: web-tls
+spec:
+  dnsNames: example.com
+  privateKey:
+    algorithm: DSA
+    size: "2048"
+    encoding: PKCS8
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  project: 1
+`,
+			want: []SchemaViolation{
+				{Document: 1, Kind: "Certificate", Name: "web-tls", Path: ".spec.secretName", Message: "missing required field"},
+				{Document: 1, Kind: "Certificate", Name: "web-tls", Path: ".spec.dnsNames", Message: "expected array, got string"},
+				{Document: 1, Kind: "Certificate", Name: "web-tls", Path: ".spec.privateKey.algorithm", Message: `value "DSA" is not one of "ECDSA", "Ed25519", "RSA"`},
+				{Document: 1, Kind: "Certificate", Name: "web-tls", Path: ".spec.privateKey.size", Message: "expected integer, got string"},
+				{Document: 1, Kind: "Certificate", Name: "web-tls", Path: ".spec.privateKey.encoding", Message: "unknown field"},
+				{Document: 2, Kind: "Application", Name: "guestbook", Path: ".spec.destination", Message: "missing required field"},
+				{Document: 2, Kind: "Application", Name: "guestbook", Path: ".spec.project", Message: "expected string, got integer"},
+			},
+		},
+		{
+			name: "built-in kinds use the embedded schemas",
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  port: 80
+`,
+			want: []SchemaViolation{
+				{Document: 1, Kind: "ConfigMap", Name: "config", Path: ".data.port", Message: "expected string, got integer"},
+			},
+		},
+		{
+			name: "no schema without require-schema",
+			input: `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: web
+`,
+		},
+		{
+			name: "no schema with require-schema",
+			input: `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: web
+---
+apiVersion: cert-manager.io/v2
+kind: Certificate
+metadata:
+  name: web-tls
+`,
+			requireSchema: true,
+			want: []SchemaViolation{
+				{Document: 1, Kind: "ServiceMonitor", Name: "web", Path: ".", Message: "no schema for monitoring.coreos.com/v1 ServiceMonitor"},
+				{Document: 2, Kind: "Certificate", Name: "web-tls", Path: ".", Message: "no schema for cert-manager.io/v2 Certificate"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate([]byte(tt.input), Options{CRDDir: dir, RequireSchema: tt.requireSchema})
+			var got []SchemaViolation
+			var schemaErr *SchemaError
+			if errors.As(err, &schemaErr) {
+				got = schemaErr.Violations
+			} else if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() violations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCRDDirErrors(t *testing.T) {
+	empty := t.TempDir()
+	invalid := t.TempDir()
+	if err := os.WriteFile(filepath.Join(invalid, "broken.yaml"), []byte("kind: CustomResourceDefinition\nspec: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n")
+	for name, dir := range map[string]string{
+		"missing": filepath.Join(empty, "missing"),
+		"no CRDs": empty,
+		"invalid": invalid,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := Validate(input, Options{CRDDir: dir}); err == nil {
+				t.Errorf("Validate() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// csvFixedColumns are the columns of every FormatCSV row, before those of
+// Options.CSVColumns.
+var csvFixedColumns = []string{"apiVersion", "kind", "namespace", "name", "source", "document"}
+
+// csvHeader returns the header row of FormatCSV output: apiVersion, kind,
+// namespace, name, source and document, followed by opts.CSVColumns.
+func csvHeader(opts Options) []string {
+	return append(append([]string(nil), csvFixedColumns...), opts.CSVColumns...)
+}
+
+// marshalCSV writes inventories in FormatCSV, under a single header row.
+func marshalCSV(inventories []Inventory, opts Options) ([]byte, error) {
+	columns, err := parseCSVColumns(opts)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	out.Write(csvHeader(opts))
+	for _, inventory := range inventories {
+		opts.Source = inventory.Source
+		for _, doc := range inventory.Documents {
+			record, err := csvRecord(doc, columns, opts)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(record)
+		}
+	}
+	out.Flush()
+	return buf.Bytes(), out.Error()
+}
+
+// writeCSV converts the YAML stream read from r to FormatCSV, writing the
+// header row and then the row of each document as soon as it has been
+// decoded.
+func writeCSV(r io.Reader, w io.Writer, opts Options) error {
+	columns, err := parseCSVColumns(opts)
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader(opts)); err != nil {
+		return &IOError{Op: "write", Path: "output", Err: err}
+	}
+	err = DecodeStream(r, opts, func(doc Document) error {
+		record, err := csvRecord(doc, columns, opts)
+		if err != nil {
+			return err
+		}
+		if err := out.Write(record); err != nil {
+			return &IOError{Op: "write", Path: "output", Err: err}
+		}
+		return nil
+	})
+	out.Flush()
+	if err == nil {
+		if err = out.Error(); err != nil {
+			return &IOError{Op: "write", Path: "output", Err: err}
+		}
+	}
+	return err
+}
+
+// parseCSVColumns parses the query paths of opts.CSVColumns.
+func parseCSVColumns(opts Options) ([][]querySegment, error) {
+	columns := make([][]querySegment, len(opts.CSVColumns))
+	for i, column := range opts.CSVColumns {
+		segments, err := parseQuery(column)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV column: %w", err)
+		}
+		columns[i] = segments
+	}
+	return columns, nil
+}
+
+// csvRecord returns the FormatCSV row of doc, with a cell for each of the
+// parsed columns of opts.CSVColumns.
+func csvRecord(doc Document, columns [][]querySegment, opts Options) ([]string, error) {
+	metadata := field(doc.Value, "metadata")
+	record := []string{
+		stringField(doc.Value, "apiVersion"),
+		stringField(doc.Value, "kind"),
+		stringField(metadata, "namespace"),
+		stringField(metadata, "name"),
+		opts.Source,
+		strconv.Itoa(doc.Index),
+	}
+	for i, segments := range columns {
+		value, err := querySegments(doc.Value, opts.CSVColumns[i], segments)
+		if err != nil {
+			// Missing paths are empty cells
+			record = append(record, "")
+			continue
+		}
+		cell, err := csvCell(value)
+		if err != nil {
+			return nil, err
+		}
+		record = append(record, cell)
+	}
+	return record, nil
+}
+
+// csvCell returns the text of a cell holding v: strings as they are, null as
+// an empty cell, and objects and arrays as compact JSON.
+func csvCell(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+	data, err := marshalJSON(v, Options{Compact: true})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
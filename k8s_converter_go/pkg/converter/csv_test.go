@@ -0,0 +1,96 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConvertCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+	}{
+		{
+			name:    "Fixed columns",
+			content: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: prod\n---\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n",
+			opts:    Options{Source: "app.yaml"},
+			want:    "apiVersion,kind,namespace,name,source,document\napps/v1,Deployment,prod,web,app.yaml,1\nv1,Service,,web,app.yaml,3\n",
+		},
+		{
+			name:    "Extra columns",
+			content: "kind: Deployment\nmetadata:\n  labels:\n    app: web\n    app.kubernetes.io/part-of: shop\nspec:\n  replicas: 3\n  paused: false\n  selector:\n    matchLabels: {app: web}\n---\nkind: Service\nspec:\n  replicas: null\n",
+			opts:    Options{CSVColumns: []string{"spec.replicas", ".metadata.labels.app", `metadata.labels["app.kubernetes.io/part-of"]`, "spec.paused", "spec.selector", "spec.replicas[0]"}},
+			want: "apiVersion,kind,namespace,name,source,document,spec.replicas,.metadata.labels.app,\"metadata.labels[\"\"app.kubernetes.io/part-of\"\"]\",spec.paused,spec.selector,spec.replicas[0]\n" +
+				",Deployment,,,,1,3,web,shop,false,\"{\"\"matchLabels\"\":{\"\"app\"\":\"\"web\"\"}}\",\n" +
+				",Service,,,,2,,,,,,\n",
+		},
+		{
+			name:    "Quoting",
+			content: "kind: ConfigMap\nmetadata:\n  name: \"a,b\"\ndata:\n  script: \"echo \\\"hi\\\"\\nexit\"\n",
+			opts:    Options{CSVColumns: []string{"data.script"}},
+			want:    "apiVersion,kind,namespace,name,source,document,data.script\n,ConfigMap,,\"a,b\",,1,\"echo \"\"hi\"\"\nexit\"\n",
+		},
+		{
+			name:    "Filtered",
+			content: "kind: Deployment\nmetadata:\n  name: web\n---\nkind: Service\nmetadata:\n  name: web\n",
+			opts:    Options{Kinds: []string{"service"}},
+			want:    "apiVersion,kind,namespace,name,source,document\n,Service,,web,,2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Format = FormatCSV
+			got, err := Convert([]byte(tt.content), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %q, want %q", got, tt.want)
+			}
+
+			var streamed bytes.Buffer
+			if err := ConvertStream(strings.NewReader(tt.content), &streamed, tt.opts); err != nil {
+				t.Fatalf("ConvertStream() error = %v", err)
+			}
+			if streamed.String() != tt.want {
+				t.Errorf("ConvertStream() = %q, want %q", streamed.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalInventoryCSV(t *testing.T) {
+	var inventories []Inventory
+	for _, input := range []struct{ source, content string }{
+		{"pods.yaml", "kind: Pod\nmetadata:\n  name: a\n---\nkind: Pod\nmetadata:\n  name: b\n"},
+		{"svc.yaml", "kind: Service\nmetadata:\n  name: c\n"},
+	} {
+		documents, err := Decode([]byte(input.content), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		inventories = append(inventories, Inventory{Source: input.source, Documents: documents})
+	}
+	got, err := MarshalInventory(inventories, Options{Format: FormatCSV, CSVColumns: []string{"metadata.name"}})
+	if err != nil {
+		t.Fatalf("MarshalInventory() error = %v", err)
+	}
+	want := "apiVersion,kind,namespace,name,source,document,metadata.name\n,Pod,,a,pods.yaml,1,a\n,Pod,,b,pods.yaml,2,b\n,Service,,c,svc.yaml,1,c\n"
+	if string(got) != want {
+		t.Errorf("MarshalInventory() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertCSVErrors(t *testing.T) {
+	if _, err := Convert([]byte("kind: Pod\n"), Options{Format: FormatCSV, CSVColumns: []string{"spec[x]"}}); err == nil || !strings.Contains(err.Error(), "invalid CSV column") {
+		t.Errorf("invalid column: Convert() error = %v, want an invalid CSV column error", err)
+	}
+	if _, err := Convert([]byte("kind: Pod\n"), Options{Format: FormatCSV, Kinds: []string{"Service"}}); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("no match: Convert() error = %v, want ErrNoMatch", err)
+	}
+}
@@ -0,0 +1,452 @@
+package converter
+
+import (
+	"bytes"
+	"io"
+	"sort"
+)
+
+// Decode parses every document in a YAML stream, validates the result and
+// decodes the documents into JSON-compatible Go values, with mappings as
+// *Object. Empty documents and documents not selected by the filters of opts
+// are skipped; ErrNoMatch is returned if the filters select none. With
+// opts.SchemaValidate the selected documents are checked against the schemas
+// of their kinds. Every document is checked even after one fails, and when
+// more than one problem is found they are all returned in a *MultiError.
+func Decode(data []byte, opts Options) ([]Document, error) {
+	filter, err := newDocumentFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := documentSchemas(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err = preprocess(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse every YAML document in the stream, keeping those that fail
+	// to parse as errors
+	var errs errorList
+	var documents []parsedDocument
+	err = readDocuments(bytes.NewReader(data), opts, func(doc parsedDocument) error {
+		if doc.err != nil {
+			errs.add(doc.index, doc.err)
+		} else {
+			documents = append(documents, doc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := substituteValues(documents, opts); err != nil {
+		return nil, err
+	}
+
+	found := false
+	var result []Document
+	for _, doc := range documents {
+		found = found || !isNullNode(doc.node)
+		for _, document := range decodeDocument(doc, opts, &errs) {
+			if filter.matches(document) {
+				result = append(result, document)
+			}
+		}
+	}
+	if opts.SortByApplyOrder {
+		sortApplyOrder(result)
+	}
+	for _, document := range result {
+		validateDocument(schemas, document, opts, &errs)
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrInvalidYAML
+	}
+	if len(result) == 0 && filter != nil {
+		return nil, ErrNoMatch
+	}
+	return result, nil
+}
+
+// decodeDocument checks and decodes a single parsed document into the
+// documents it holds, recording its problems in errs. A document with a
+// problem that stops it being decoded holds no documents.
+func decodeDocument(doc parsedDocument, opts Options, errs *errorList) []Document {
+	if !isNullNode(doc.node) {
+		if err := checkMapping(doc); err != nil {
+			errs.add(doc.index, err)
+			return nil
+		}
+	}
+	single := []parsedDocument{doc}
+	if err := resolveDuplicateKeys(single, opts); err != nil {
+		errs.add(doc.index, err)
+		return nil
+	}
+	if err := resolveMerges(single, opts); err != nil {
+		errs.add(doc.index, err)
+		return nil
+	}
+	errs.add(doc.index, checkKubernetesFields(single, opts))
+	converted, err := convertDocument(doc, opts)
+	if err != nil {
+		errs.add(doc.index, err)
+		return nil
+	}
+	return converted
+}
+
+// validateDocument applies the validations selected by opts to a decoded
+// document, recording every one it fails in errs.
+func validateDocument(schemas *schemaSet, document Document, opts Options, errs *errorList) {
+	single := []Document{document}
+	errs.add(document.Index, validateSchemas(schemas, single, opts))
+	errs.add(document.Index, validateNames(single, opts))
+	errs.add(document.Index, validateLabels(single, opts))
+	errs.add(document.Index, validateImages(single, opts))
+	errs.add(document.Index, validateQuantities(single, opts))
+}
+
+// errorList collects the problems of the documents of a stream.
+type errorList struct {
+	errors    []error
+	documents []int
+}
+
+// add records err, found in the document at position document, unless it
+// is nil.
+func (l *errorList) add(document int, err error) {
+	if err != nil {
+		l.errors = append(l.errors, err)
+		l.documents = append(l.documents, document)
+	}
+}
+
+// err returns nil when no problem was recorded, the problem when there was
+// one, and otherwise a *MultiError with every problem in document order. The
+// errors that list every problem of their kind in the input, such as
+// *NameError, are merged into one error for all documents.
+func (l *errorList) err() error {
+	order := make([]int, len(l.errors))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return l.documents[order[i]] < l.documents[order[j]] })
+
+	multi := &MultiError{}
+	for _, i := range order {
+		merged := false
+		for _, into := range multi.Errors {
+			if merged = mergeError(into, l.errors[i]); merged {
+				break
+			}
+		}
+		if !merged {
+			multi.Errors = append(multi.Errors, l.errors[i])
+			multi.Documents = append(multi.Documents, l.documents[i])
+		}
+	}
+	switch len(multi.Errors) {
+	case 0:
+		return nil
+	case 1:
+		return multi.Errors[0]
+	}
+	return multi
+}
+
+// mergeError adds the problems listed by err to into when both are errors
+// of the same kind that list problems, reporting whether it did.
+func mergeError(into, err error) bool {
+	switch err := err.(type) {
+	case *DuplicateKeyError:
+		if into, ok := into.(*DuplicateKeyError); ok {
+			into.Duplicates = append(into.Duplicates, err.Duplicates...)
+			return true
+		}
+	case *MissingFieldsError:
+		if into, ok := into.(*MissingFieldsError); ok {
+			into.Documents = append(into.Documents, err.Documents...)
+			return true
+		}
+	case *SchemaError:
+		if into, ok := into.(*SchemaError); ok {
+			into.Violations = append(into.Violations, err.Violations...)
+			return true
+		}
+	case *NameError:
+		if into, ok := into.(*NameError); ok {
+			into.Violations = append(into.Violations, err.Violations...)
+			return true
+		}
+	case *LabelError:
+		if into, ok := into.(*LabelError); ok {
+			into.Violations = append(into.Violations, err.Violations...)
+			return true
+		}
+	case *ImageError:
+		if into, ok := into.(*ImageError); ok {
+			into.Violations = append(into.Violations, err.Violations...)
+			return true
+		}
+	case *QuantityError:
+		if into, ok := into.(*QuantityError); ok {
+			into.Violations = append(into.Violations, err.Violations...)
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeStream decodes the documents of a YAML stream read from r one at a
+// time, calling fn for each document as soon as it has been decoded, so memory
+// use is bounded by the largest document rather than the whole stream. Each
+// document is validated on its own; ErrInvalidYAML is returned after the
+// stream ends if every document in it was empty. Once a document has a
+// problem fn is not called again, but the rest of the stream is still
+// checked, and when more than one problem is found they are all returned in
+// a *MultiError.
+func DecodeStream(r io.Reader, opts Options, fn func(Document) error) error {
+	// The first document in apply order is only known once every document
+	// has been read, and every missing value is reported before any
+	// document is decoded
+	if opts.SortByApplyOrder || opts.Values != nil {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return &IOError{Op: "read", Path: "input", Err: err}
+		}
+		documents, err := Decode(data, opts)
+		if err != nil {
+			return err
+		}
+		for _, document := range documents {
+			if err := fn(document); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	filter, err := newDocumentFilter(opts)
+	if err != nil {
+		return err
+	}
+	schemas, err := documentSchemas(opts)
+	if err != nil {
+		return err
+	}
+
+	// Variables are substituted in the whole input, so that every missing
+	// variable is reported before any document is decoded
+	if opts.EnvSubst {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return &IOError{Op: "read", Path: "input", Err: err}
+		}
+		data, err = preprocess(data, opts)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+
+	var errs errorList
+	found, matched := false, false
+	err = readDocuments(r, opts, func(doc parsedDocument) error {
+		if doc.err != nil {
+			errs.add(doc.index, doc.err)
+			return nil
+		}
+		found = found || !isNullNode(doc.node)
+		for _, document := range decodeDocument(doc, opts, &errs) {
+			if !filter.matches(document) {
+				continue
+			}
+			matched = true
+			validateDocument(schemas, document, opts, &errs)
+			if len(errs.errors) > 0 {
+				continue
+			}
+			if err := fn(document); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := errs.err(); err != nil {
+		return err
+	}
+	if !found {
+		return ErrInvalidYAML
+	}
+	if !matched && filter != nil {
+		return ErrNoMatch
+	}
+	return nil
+}
+
+// documentSchemas returns the schemas to validate documents against, with
+// the CustomResourceDefinitions of opts.CRDDir added, or nil when none of
+// opts.SchemaValidate, opts.StrictFields and opts.CRDDir is set.
+func documentSchemas(opts Options) (*schemaSet, error) {
+	if !opts.SchemaValidate && !opts.StrictFields && opts.CRDDir == "" {
+		return nil, nil
+	}
+	schemas, err := loadSchemas(opts)
+	if err != nil || opts.CRDDir == "" {
+		return schemas, err
+	}
+	schemas = schemas.clone()
+	if err := schemas.addCRDs(opts.CRDDir); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// preprocess applies the text substitutions selected by opts to the raw
+// input, decompressing it and transcoding it to UTF-8 first when needed.
+func preprocess(data []byte, opts Options) ([]byte, error) {
+	if !opts.EnvSubst {
+		return data, nil
+	}
+	data, err := decompressText(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	return substituteEnv(data, opts)
+}
+
+// checkKubernetesFields returns a *MissingFieldsError listing every document
+// that lacks required Kubernetes fields when opts.KubernetesStrict is set.
+func checkKubernetesFields(documents []parsedDocument, opts Options) error {
+	if !opts.KubernetesStrict {
+		return nil
+	}
+	var missing []MissingFields
+	for _, doc := range documents {
+		if isNullNode(doc.node) {
+			continue
+		}
+		if fields := missingKubernetesFields(doc); len(fields) > 0 {
+			missing = append(missing, MissingFields{Document: doc.index, Line: doc.node.Line, Fields: fields})
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingFieldsError{Documents: missing}
+	}
+	return nil
+}
+
+// resolveDuplicateKeys finds duplicate mapping keys in the documents. With
+// opts.StrictKeys they are returned as a *DuplicateKeyError; otherwise each
+// is reported as a warning and removed so the last occurrence wins.
+func resolveDuplicateKeys(documents []parsedDocument, opts Options) error {
+	var duplicates []DuplicateKey
+	for _, doc := range documents {
+		duplicates = append(duplicates, findDuplicateKeys(doc)...)
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	if opts.StrictKeys {
+		return &DuplicateKeyError{Duplicates: duplicates}
+	}
+	for _, duplicate := range duplicates {
+		opts.warn(duplicate.warning())
+	}
+	for _, doc := range documents {
+		removeDuplicateKeys(doc.node)
+	}
+	return nil
+}
+
+// convertDocument decodes a validated document and applies the conversion
+// options to its value. It returns the document itself or, with
+// opts.ExplodeLists, the items of a list document.
+func convertDocument(doc parsedDocument, opts Options) ([]Document, error) {
+	if isNullNode(doc.node) {
+		// An empty document kept by EmptyDocumentsNull is written as null
+		return []Document{{Index: doc.index, Line: doc.node.Line, Empty: true}}, nil
+	}
+	value, err := doc.decode(opts)
+	if err != nil {
+		return nil, err
+	}
+	items := []listItem{{node: doc.node, value: value}}
+	if opts.ExplodeLists {
+		items = explodeList(doc, value, opts)
+	}
+
+	documents := make([]Document, len(items))
+	for i, item := range items {
+		// Decode typed objects first, so that the later steps see the fields
+		// the type writes
+		if opts.Typed {
+			if item.value, err = decodeTyped(doc.index, item.node.Line, item.value, opts); err != nil {
+				return nil, err
+			}
+		}
+		if opts.WarnDeprecated || opts.FailDeprecated {
+			if err := checkDeprecated(doc.index, item.node.Line, item.value, opts); err != nil {
+				return nil, err
+			}
+		}
+		if opts.RedactSecrets {
+			redactSecret(item.value)
+		} else if opts.DecodeSecrets {
+			decodeSecret(doc.index, item.node, item.value, opts)
+		}
+		if opts.ExpandLastApplied {
+			expandLastApplied(doc.index, item.node.Line, item.value, opts)
+		}
+		if opts.Clean {
+			cleanObject(item.value)
+		}
+		if len(opts.DeletePaths) > 0 {
+			if item.value, err = deletePaths(doc.index, item.node.Line, item.value, opts); err != nil {
+				return nil, err
+			}
+		}
+		if len(opts.SetFields) > 0 {
+			if item.value, err = setFields(doc.index, item.node.Line, item.value, opts); err != nil {
+				return nil, err
+			}
+		}
+		if opts.SetNamespace != "" {
+			setNamespace(doc.index, item.node.Line, item.value, opts)
+		}
+		if len(opts.AddLabels) > 0 || len(opts.AddAnnotations) > 0 {
+			addMetadata(doc.index, item.node.Line, item.value, opts)
+		}
+		if opts.OmitNull {
+			item.value, _ = omitNulls(item.value, opts.OmitEmpty)
+		}
+		if opts.KeepComments {
+			keepComments(doc, item, opts)
+		}
+		// Sort last so the canonical form also covers keys added by earlier
+		// steps
+		if opts.SortKeys || opts.Canonical {
+			sortKeys(item.value)
+		}
+		if opts.Canonical {
+			item.value = canonicalNumbers(item.value)
+		}
+		documents[i] = Document{Index: doc.index, Item: item.position, Line: item.node.Line, Value: item.value}
+		if opts.VerifyRoundTrip {
+			if err := verifyRoundTrip(documents[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return documents, nil
+}
@@ -0,0 +1,144 @@
+package converter
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStream(t *testing.T) {
+	content := "kind: Deployment\n---\n# comment only\n---\nkind: Service\n"
+
+	var kinds []string
+	err := DecodeStream(strings.NewReader(content), Options{}, func(doc Document) error {
+		kinds = append(kinds, doc.Kind())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeStream() error = %v", err)
+	}
+	if strings.Join(kinds, ",") != "Deployment,Service" {
+		t.Errorf("DecodeStream() decoded %v, want Deployment and Service", kinds)
+	}
+
+	if err := DecodeStream(strings.NewReader("---\n"), Options{}, func(Document) error { return nil }); !errors.Is(err, ErrInvalidYAML) {
+		t.Errorf("DecodeStream() error = %v, want ErrInvalidYAML", err)
+	}
+}
+
+func TestConvertStreamNDJSON(t *testing.T) {
+	// Hold the input stream open to check that the first document is written
+	// before the end of the stream has been reached
+	inputReader, inputWriter := io.Pipe()
+	outputReader, outputWriter := io.Pipe()
+	done := make(chan error, 1)
+	release := make(chan struct{})
+	go func() {
+		err := ConvertStream(inputReader, outputWriter, Options{Format: FormatNDJSON})
+		outputWriter.CloseWithError(err)
+		done <- err
+	}()
+
+	lines := bufio.NewReader(outputReader)
+	go func() {
+		io.WriteString(inputWriter, "kind: Deployment\nmetadata:\n  name: web\n---\nkind: Service\n")
+		<-release
+		inputWriter.Close()
+	}()
+	line, err := lines.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read first line: %v", err)
+	}
+	if want := `{"kind":"Deployment","metadata":{"name":"web"}}` + "\n"; line != want {
+		t.Errorf("First line = %q, want %q", line, want)
+	}
+
+	close(release)
+	rest, err := io.ReadAll(lines)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if want := `{"kind":"Service"}` + "\n"; string(rest) != want {
+		t.Errorf("Remaining output = %q, want %q", rest, want)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("ConvertStream() error = %v", err)
+	}
+}
+
+func TestDecodeCollectsErrors(t *testing.T) {
+	content := "kind: Service\nmetadata:\n  name: Bad_Name\n" +
+		"---\nkind: ConfigMap\ndata: [\n" +
+		"---\nkind: Pod\nmetadata:\n  name: web\n" +
+		"---\nkind: Pod\nmetadata:\n  name: web\n  name: api\n" +
+		"---\nkind: Pod\nmetadata:\n  name: Q_x\n"
+	opts := Options{ValidateNames: true, StrictKeys: true}
+
+	check := func(t *testing.T, err error) {
+		t.Helper()
+		var multiErr *MultiError
+		if !errors.As(err, &multiErr) {
+			t.Fatalf("error = %v, want *MultiError", err)
+		}
+		if want := []int{1, 2, 4}; !reflect.DeepEqual(multiErr.Documents, want) {
+			t.Errorf("Documents = %v, want %v", multiErr.Documents, want)
+		}
+		var nameErr *NameError
+		if !errors.As(err, &nameErr) || len(nameErr.Violations) != 2 || nameErr.Violations[1].Document != 5 {
+			t.Errorf("error = %v, want a NameError for documents 1 and 5", err)
+		}
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Document != 2 || parseErr.Line != 7 {
+			t.Errorf("error = %v, want a ParseError at line 7 of document 2", err)
+		}
+		var duplicateErr *DuplicateKeyError
+		if !errors.As(err, &duplicateErr) || duplicateErr.Duplicates[0].Line != 15 {
+			t.Errorf("error = %v, want a DuplicateKeyError at line 15", err)
+		}
+	}
+
+	t.Run("Decode", func(t *testing.T) {
+		documents, err := Decode([]byte(content), opts)
+		check(t, err)
+		if documents != nil {
+			t.Errorf("Decode() = %v, want no documents", documents)
+		}
+	})
+
+	t.Run("DecodeStream", func(t *testing.T) {
+		var kinds []string
+		err := DecodeStream(strings.NewReader(content), opts, func(doc Document) error {
+			kinds = append(kinds, doc.Kind())
+			return nil
+		})
+		check(t, err)
+		if len(kinds) != 0 {
+			t.Errorf("DecodeStream() decoded %v after an error", kinds)
+		}
+	})
+
+	t.Run("Single error", func(t *testing.T) {
+		_, err := Decode([]byte("kind: ConfigMap\n---\ndata: [\n---\nkind: Secret\n"), Options{})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Document != 2 {
+			t.Errorf("Decode() error = %v, want only a ParseError", err)
+		}
+		var multiErr *MultiError
+		if errors.As(err, &multiErr) {
+			t.Errorf("Decode() error = %v, want no MultiError for a single error", err)
+		}
+	})
+}
+
+func TestMultiError(t *testing.T) {
+	err := &MultiError{Errors: []error{ErrInvalidYAML, ErrNoMatch}, Documents: []int{1, 3}}
+	if want := "2 errors: " + ErrInvalidYAML.Error() + "; " + ErrNoMatch.Error(); err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if !errors.Is(err, ErrNoMatch) {
+		t.Error("errors.Is(err, ErrNoMatch) = false, want true")
+	}
+}
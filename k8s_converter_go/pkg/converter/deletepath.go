@@ -0,0 +1,99 @@
+package converter
+
+import "fmt"
+
+// ValidateDeletePath reports whether path is a valid path for
+// Options.DeletePaths, returning an error describing the problem if not.
+func ValidateDeletePath(path string) error {
+	_, err := parseDeletePath(path)
+	return err
+}
+
+// parseDeletePath parses a path of Options.DeletePaths, which may hold [*]
+// wildcards and cannot be the whole document.
+func parseDeletePath(path string) ([]querySegment, error) {
+	segments, err := parsePath(path, "delete path", true)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid delete path %q: cannot delete the whole document", path)
+	}
+	return segments, nil
+}
+
+// deletePaths removes the values at opts.DeletePaths from v, a document
+// starting on line, returning v with them removed. With opts.StrictPaths a
+// path matching nothing returns a *PathNotFoundError.
+func deletePaths(index, line int, v interface{}, opts Options) (interface{}, error) {
+	for _, path := range opts.DeletePaths {
+		segments, err := parseDeletePath(path)
+		if err != nil {
+			return nil, err
+		}
+		var found bool
+		v, found = deleteSegments(v, segments)
+		if !found && opts.StrictPaths {
+			return nil, &PathNotFoundError{Path: path, Document: index, Line: line}
+		}
+	}
+	return v, nil
+}
+
+// deleteSegments removes the value at segments from v and reports whether
+// there was one. A [*] segment matches every element of an array, so a path
+// with wildcards may remove many values, and is found if any of them is.
+// Removing an array element shifts the elements after it, so it returns a
+// new array rather than changing the one in v, and the caller stores it in
+// place of the old one.
+func deleteSegments(v interface{}, segments []querySegment) (interface{}, bool) {
+	segment, rest := segments[0], segments[1:]
+	switch {
+	case segment.wildcard:
+		items, ok := v.([]interface{})
+		if !ok || len(items) == 0 {
+			return v, false
+		}
+		if len(rest) == 0 {
+			return []interface{}{}, true
+		}
+		found := false
+		for i, item := range items {
+			var itemFound bool
+			items[i], itemFound = deleteSegments(item, rest)
+			found = found || itemFound
+		}
+		return items, found
+	case segment.isIndex:
+		items, ok := v.([]interface{})
+		if !ok || segment.index >= len(items) {
+			return v, false
+		}
+		if len(rest) == 0 {
+			kept := make([]interface{}, 0, len(items)-1)
+			kept = append(kept, items[:segment.index]...)
+			return append(kept, items[segment.index+1:]...), true
+		}
+		var found bool
+		items[segment.index], found = deleteSegments(items[segment.index], rest)
+		return items, found
+	default:
+		object, ok := v.(*Object)
+		if !ok {
+			return v, false
+		}
+		child, ok := object.Get(segment.field)
+		if !ok {
+			return v, false
+		}
+		if len(rest) == 0 {
+			object.Delete(segment.field)
+			return v, true
+		}
+		child, found := deleteSegments(child, rest)
+		if found {
+			object.Set(segment.field, child)
+		}
+		return v, found
+	}
+}
@@ -0,0 +1,114 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConvertDeletePaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		paths   []string
+		want    string
+	}{
+		{
+			name:    "Quoted key with dots",
+			content: "kind: Service\nmetadata:\n  name: web\n  annotations:\n    kubectl.kubernetes.io/last-applied-configuration: \"{}\"\n    team: web\n",
+			paths:   []string{`metadata.annotations."kubectl.kubernetes.io/last-applied-configuration"`},
+			want:    `{"kind":"Service","metadata":{"name":"web","annotations":{"team":"web"}}}`,
+		},
+		{
+			name:    "Several paths",
+			content: "kind: Deployment\nspec:\n  replicas: 3\n  template:\n    metadata:\n      creationTimestamp: null\n      labels:\n        app: web\n",
+			paths:   []string{"spec.template.metadata.creationTimestamp", ".spec.replicas"},
+			want:    `{"kind":"Deployment","spec":{"template":{"metadata":{"labels":{"app":"web"}}}}}`,
+		},
+		{
+			name:    "Array index",
+			content: "args: [a, b, c]\n",
+			paths:   []string{"args[1]"},
+			want:    `{"args":["a","c"]}`,
+		},
+		{
+			name:    "Wildcard over an array of objects",
+			content: "containers:\n- name: a\n  resources: {cpu: 1}\n- name: b\n- name: c\n  resources: {cpu: 2}\n",
+			paths:   []string{"containers[*].resources"},
+			want:    `{"containers":[{"name":"a"},{"name":"b"},{"name":"c"}]}`,
+		},
+		{
+			name:    "Nested wildcards over arrays of arrays",
+			content: "rows:\n- cells: [{v: 1, note: x}, {v: 2}]\n- cells: []\n- cells: [{v: 3, note: y}]\n",
+			paths:   []string{"rows[*].cells[*].note"},
+			want:    `{"rows":[{"cells":[{"v":1},{"v":2}]},{"cells":[]},{"cells":[{"v":3}]}]}`,
+		},
+		{
+			name:    "Index under a wildcard",
+			content: "containers:\n- args: [run, --debug]\n- args: [serve]\n- args: []\n",
+			paths:   []string{"containers[*].args[0]"},
+			want:    `{"containers":[{"args":["--debug"]},{"args":[]},{"args":[]}]}`,
+		},
+		{
+			name:    "Wildcard as the last segment empties the array",
+			content: "finalizers: [a, b]\nname: x\n",
+			paths:   []string{"finalizers[*]"},
+			want:    `{"finalizers":[],"name":"x"}`,
+		},
+		{
+			name:    "Paths matching nothing",
+			content: "kind: Pod\nspec:\n  containers: [{name: a}]\n",
+			paths:   []string{"status", "spec.containers[3]", "spec.containers[*].image", "kind.name", "spec[0]"},
+			want:    `{"kind":"Pod","spec":{"containers":[{"name":"a"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(tt.content), Options{Compact: true, DeletePaths: tt.paths})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertStrictPaths(t *testing.T) {
+	content := "kind: Pod\nmetadata:\n  name: a\n---\nkind: Pod\nspec: {}\n"
+	opts := Options{Compact: true, DeletePaths: []string{"metadata.name"}, StrictPaths: true}
+	_, err := Convert([]byte(content), opts)
+	var pathErr *PathNotFoundError
+	if !errors.As(err, &pathErr) || pathErr.Document != 2 || pathErr.Line != 5 || pathErr.Path != "metadata.name" {
+		t.Fatalf("Convert() error = %#v, want a *PathNotFoundError for document 2 on line 5", err)
+	}
+	if want := "delete path metadata.name matches nothing in document 2"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	// Any match within a wildcard is enough
+	opts.DeletePaths = []string{"items[*].name"}
+	if _, err := Convert([]byte("items: [{name: a}, {}]\n"), opts); err != nil {
+		t.Errorf("Convert() with a partial wildcard match error = %v", err)
+	}
+}
+
+func TestValidateDeletePath(t *testing.T) {
+	for _, path := range []string{"metadata.name", `metadata.annotations."a.b/c"`, "spec.containers[*].env[0]", `$.a["b.c"]`} {
+		if err := ValidateDeletePath(path); err != nil {
+			t.Errorf("ValidateDeletePath(%q) error = %v", path, err)
+		}
+	}
+	for _, path := range []string{"", ".", "$", "a..b", `a."b`, "a[-1]", "a[*", "a[**]"} {
+		err := ValidateDeletePath(path)
+		if err == nil || !strings.HasPrefix(err.Error(), "invalid delete path") {
+			t.Errorf("ValidateDeletePath(%q) error = %v, want invalid delete path", path, err)
+		}
+	}
+	// The wildcard is only part of delete paths
+	if _, err := Query([]interface{}{"a"}, "[*]"); err == nil {
+		t.Error("Query([*]) succeeded, want an error")
+	}
+}
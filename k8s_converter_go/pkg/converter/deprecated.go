@@ -0,0 +1,125 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// deprecatedAPI is a group-version whose kinds are deprecated and later
+// removed from Kubernetes. Versions are the minor releases of Kubernetes 1.
+type deprecatedAPI struct {
+	apiVersion   string
+	kinds        []string
+	deprecatedIn int
+	removedIn    int
+	// replacement is the apiVersion to use instead, or "" when the kinds
+	// were removed without one.
+	replacement string
+}
+
+// deprecatedAPIs lists the deprecated and removed API versions that
+// Options.WarnDeprecated reports, by release of removal. New entries go
+// here.
+var deprecatedAPIs = []deprecatedAPI{
+	{apiVersion: "extensions/v1beta1", kinds: []string{"DaemonSet", "Deployment", "ReplicaSet"}, deprecatedIn: 9, removedIn: 16, replacement: "apps/v1"},
+	{apiVersion: "apps/v1beta1", kinds: []string{"Deployment", "StatefulSet", "ControllerRevision"}, deprecatedIn: 9, removedIn: 16, replacement: "apps/v1"},
+	{apiVersion: "apps/v1beta2", kinds: []string{"DaemonSet", "Deployment", "ReplicaSet", "StatefulSet", "ControllerRevision"}, deprecatedIn: 9, removedIn: 16, replacement: "apps/v1"},
+	{apiVersion: "extensions/v1beta1", kinds: []string{"NetworkPolicy"}, deprecatedIn: 9, removedIn: 16, replacement: "networking.k8s.io/v1"},
+	{apiVersion: "extensions/v1beta1", kinds: []string{"PodSecurityPolicy"}, deprecatedIn: 10, removedIn: 16, replacement: "policy/v1beta1"},
+
+	{apiVersion: "extensions/v1beta1", kinds: []string{"Ingress"}, deprecatedIn: 14, removedIn: 22, replacement: "networking.k8s.io/v1"},
+	{apiVersion: "networking.k8s.io/v1beta1", kinds: []string{"Ingress", "IngressClass"}, deprecatedIn: 19, removedIn: 22, replacement: "networking.k8s.io/v1"},
+	{apiVersion: "apiextensions.k8s.io/v1beta1", kinds: []string{"CustomResourceDefinition"}, deprecatedIn: 16, removedIn: 22, replacement: "apiextensions.k8s.io/v1"},
+	{apiVersion: "admissionregistration.k8s.io/v1beta1", kinds: []string{"MutatingWebhookConfiguration", "ValidatingWebhookConfiguration"}, deprecatedIn: 16, removedIn: 22, replacement: "admissionregistration.k8s.io/v1"},
+	{apiVersion: "apiregistration.k8s.io/v1beta1", kinds: []string{"APIService"}, deprecatedIn: 19, removedIn: 22, replacement: "apiregistration.k8s.io/v1"},
+	{apiVersion: "rbac.authorization.k8s.io/v1beta1", kinds: []string{"ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"}, deprecatedIn: 17, removedIn: 22, replacement: "rbac.authorization.k8s.io/v1"},
+	{apiVersion: "scheduling.k8s.io/v1beta1", kinds: []string{"PriorityClass"}, deprecatedIn: 14, removedIn: 22, replacement: "scheduling.k8s.io/v1"},
+	{apiVersion: "storage.k8s.io/v1beta1", kinds: []string{"CSIDriver", "CSINode", "StorageClass", "VolumeAttachment"}, deprecatedIn: 19, removedIn: 22, replacement: "storage.k8s.io/v1"},
+	{apiVersion: "coordination.k8s.io/v1beta1", kinds: []string{"Lease"}, deprecatedIn: 19, removedIn: 22, replacement: "coordination.k8s.io/v1"},
+	{apiVersion: "certificates.k8s.io/v1beta1", kinds: []string{"CertificateSigningRequest"}, deprecatedIn: 19, removedIn: 22, replacement: "certificates.k8s.io/v1"},
+
+	{apiVersion: "batch/v1beta1", kinds: []string{"CronJob"}, deprecatedIn: 21, removedIn: 25, replacement: "batch/v1"},
+	{apiVersion: "discovery.k8s.io/v1beta1", kinds: []string{"EndpointSlice"}, deprecatedIn: 21, removedIn: 25, replacement: "discovery.k8s.io/v1"},
+	{apiVersion: "events.k8s.io/v1beta1", kinds: []string{"Event"}, deprecatedIn: 21, removedIn: 25, replacement: "events.k8s.io/v1"},
+	{apiVersion: "autoscaling/v2beta1", kinds: []string{"HorizontalPodAutoscaler"}, deprecatedIn: 23, removedIn: 25, replacement: "autoscaling/v2"},
+	{apiVersion: "policy/v1beta1", kinds: []string{"PodDisruptionBudget"}, deprecatedIn: 21, removedIn: 25, replacement: "policy/v1"},
+	{apiVersion: "policy/v1beta1", kinds: []string{"PodSecurityPolicy"}, deprecatedIn: 21, removedIn: 25},
+	{apiVersion: "node.k8s.io/v1beta1", kinds: []string{"RuntimeClass"}, deprecatedIn: 22, removedIn: 25, replacement: "node.k8s.io/v1"},
+
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta1", kinds: []string{"FlowSchema", "PriorityLevelConfiguration"}, deprecatedIn: 23, removedIn: 26, replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{apiVersion: "autoscaling/v2beta2", kinds: []string{"HorizontalPodAutoscaler"}, deprecatedIn: 23, removedIn: 26, replacement: "autoscaling/v2"},
+
+	{apiVersion: "storage.k8s.io/v1beta1", kinds: []string{"CSIStorageCapacity"}, deprecatedIn: 24, removedIn: 27, replacement: "storage.k8s.io/v1"},
+
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta2", kinds: []string{"FlowSchema", "PriorityLevelConfiguration"}, deprecatedIn: 26, removedIn: 29, replacement: "flowcontrol.apiserver.k8s.io/v1"},
+
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta3", kinds: []string{"FlowSchema", "PriorityLevelConfiguration"}, deprecatedIn: 29, removedIn: 32, replacement: "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// kubernetesVersion matches a Kubernetes release such as 1.29, v1.29 or
+// 1.29.3, capturing the minor version.
+var kubernetesVersion = regexp.MustCompile(`^v?1\.(0|[1-9][0-9]*)(?:\.[0-9]+)?$`)
+
+// IsKubernetesVersion reports whether version is a Kubernetes release that
+// Options.KubernetesVersion accepts, such as 1.29.
+func IsKubernetesVersion(version string) bool {
+	return kubernetesVersion.MatchString(version)
+}
+
+// checkDeprecated reports value, the document at position document starting
+// on line, when its apiVersion and kind are in deprecatedAPIs and deprecated
+// by opts.KubernetesVersion, or at all when no version is set. It returns a
+// *DeprecatedAPIError with opts.FailDeprecated and warns otherwise.
+func checkDeprecated(document, line int, value interface{}, opts Options) error {
+	object, ok := value.(*Object)
+	if !ok {
+		return nil
+	}
+	apiVersion, _ := object.values["apiVersion"].(string)
+	kind, _ := object.values["kind"].(string)
+	api, ok := findDeprecatedAPI(apiVersion, kind)
+	if !ok {
+		return nil
+	}
+	if opts.KubernetesVersion != "" {
+		match := kubernetesVersion.FindStringSubmatch(opts.KubernetesVersion)
+		if match == nil {
+			return fmt.Errorf("invalid Kubernetes version %q: must be a release such as 1.29", opts.KubernetesVersion)
+		}
+		if minor, _ := strconv.Atoi(match[1]); minor < api.deprecatedIn {
+			return nil
+		}
+	}
+
+	err := &DeprecatedAPIError{
+		Document:     document,
+		Line:         line,
+		APIVersion:   apiVersion,
+		Kind:         kind,
+		DeprecatedIn: fmt.Sprintf("1.%d", api.deprecatedIn),
+		RemovedIn:    fmt.Sprintf("1.%d", api.removedIn),
+		Replacement:  api.replacement,
+	}
+	if opts.FailDeprecated {
+		return err
+	}
+	opts.warn(Warning{Document: document, Line: line, Message: err.message()})
+	return nil
+}
+
+// findDeprecatedAPI returns the entry of deprecatedAPIs for apiVersion and
+// kind.
+func findDeprecatedAPI(apiVersion, kind string) (deprecatedAPI, bool) {
+	for _, api := range deprecatedAPIs {
+		if api.apiVersion != apiVersion {
+			continue
+		}
+		for _, k := range api.kinds {
+			if k == kind {
+				return api, true
+			}
+		}
+	}
+	return deprecatedAPI{}, false
+}
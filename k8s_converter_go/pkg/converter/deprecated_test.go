@@ -0,0 +1,124 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDeprecatedAPIs(t *testing.T) {
+	for _, api := range deprecatedAPIs {
+		for _, kind := range api.kinds {
+			t.Run(api.apiVersion+" "+kind, func(t *testing.T) {
+				if api.removedIn <= api.deprecatedIn {
+					t.Errorf("removed in 1.%d, not after it was deprecated in 1.%d", api.removedIn, api.deprecatedIn)
+				}
+				if api.replacement == api.apiVersion {
+					t.Errorf("replacement is the deprecated apiVersion itself")
+				}
+				content := fmt.Sprintf("apiVersion: %s\nkind: %s\nmetadata:\n  name: test\n", api.apiVersion, kind)
+				var warnings []string
+				opts := Options{WarnDeprecated: true, Warn: func(w Warning) { warnings = append(warnings, w.Message) }}
+				if _, err := Convert([]byte(content), opts); err != nil {
+					t.Fatalf("Convert() error = %v", err)
+				}
+				want := fmt.Sprintf("%s %s is deprecated since Kubernetes 1.%d and removed in 1.%d", api.apiVersion, kind, api.deprecatedIn, api.removedIn)
+				if len(warnings) != 1 || !strings.HasPrefix(warnings[0], want) || !strings.Contains(warnings[0], api.replacement) {
+					t.Errorf("warnings = %q, want %q naming %q", warnings, want, api.replacement)
+				}
+			})
+		}
+	}
+}
+
+func TestCheckDeprecated(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		opts        Options
+		wantWarning string
+		wantErr     string
+	}{
+		{
+			name:        "Removed apiVersion",
+			content:     "apiVersion: extensions/v1beta1\nkind: Ingress\nmetadata:\n  name: web\n",
+			wantWarning: "line 1: extensions/v1beta1 Ingress is deprecated since Kubernetes 1.14 and removed in 1.22; use networking.k8s.io/v1 instead",
+		},
+		{
+			name:        "No replacement",
+			content:     "kind: Service\n---\napiVersion: policy/v1beta1\nkind: PodSecurityPolicy\n",
+			wantWarning: "line 3: policy/v1beta1 PodSecurityPolicy is deprecated since Kubernetes 1.21 and removed in 1.25, with no replacement (document 2)",
+		},
+		{
+			name:    "Current apiVersion",
+			content: "apiVersion: networking.k8s.io/v1\nkind: Ingress\n---\napiVersion: v1\nkind: Service\n",
+		},
+		{
+			name:    "Kind not deprecated in the group-version",
+			content: "apiVersion: policy/v1beta1\nkind: Eviction\n",
+		},
+		{
+			name:        "Deprecated by the target version",
+			content:     "apiVersion: flowcontrol.apiserver.k8s.io/v1beta3\nkind: FlowSchema\n",
+			opts:        Options{KubernetesVersion: "1.29"},
+			wantWarning: "line 1: flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema is deprecated since Kubernetes 1.29 and removed in 1.32; use flowcontrol.apiserver.k8s.io/v1 instead",
+		},
+		{
+			name:    "Not yet deprecated by the target version",
+			content: "apiVersion: flowcontrol.apiserver.k8s.io/v1beta3\nkind: FlowSchema\n",
+			opts:    Options{KubernetesVersion: "v1.28.4"},
+		},
+		{
+			name:        "Removed before the target version",
+			content:     "apiVersion: batch/v1beta1\nkind: CronJob\n",
+			opts:        Options{KubernetesVersion: "1.29"},
+			wantWarning: "line 1: batch/v1beta1 CronJob is deprecated since Kubernetes 1.21 and removed in 1.25; use batch/v1 instead",
+		},
+		{
+			name:    "Fail",
+			content: "apiVersion: v1\nkind: Service\n---\napiVersion: policy/v1beta1\nkind: PodDisruptionBudget\n",
+			opts:    Options{FailDeprecated: true},
+			wantErr: "policy/v1beta1 PodDisruptionBudget is deprecated since Kubernetes 1.21 and removed in 1.25; use policy/v1 instead (document 2)",
+		},
+		{
+			name:    "Invalid version",
+			content: "apiVersion: batch/v1beta1\nkind: CronJob\n",
+			opts:    Options{KubernetesVersion: "latest"},
+			wantErr: `invalid Kubernetes version "latest": must be a release such as 1.29`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			tt.opts.WarnDeprecated = !tt.opts.FailDeprecated
+			tt.opts.Warn = func(w Warning) { warnings = append(warnings, w.String()) }
+			_, err := Convert([]byte(tt.content), tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Convert() error = %v, want %q", err, tt.wantErr)
+				}
+				var deprecatedErr *DeprecatedAPIError
+				if tt.opts.FailDeprecated && (!errors.As(err, &deprecatedErr) || deprecatedErr.Line != 4) {
+					t.Errorf("Convert() error = %#v, want a *DeprecatedAPIError at line 4", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if strings.Join(warnings, "\n") != tt.wantWarning {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestIsKubernetesVersion(t *testing.T) {
+	for version, want := range map[string]bool{"1.29": true, "v1.29": true, "1.29.3": true, "1.0": true, "2.1": false, "1.": false, "1.09": false, "latest": false, "": false} {
+		if got := IsKubernetesVersion(version); got != want {
+			t.Errorf("IsKubernetesVersion(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// Kinds of Difference.
+const (
+	// DiffAdded is a value only found in the new document.
+	DiffAdded = "added"
+	// DiffRemoved is a value only found in the old document.
+	DiffRemoved = "removed"
+	// DiffChanged is a value found in both documents that differs.
+	DiffChanged = "changed"
+)
+
+// Difference is a value that differs between two documents compared by Diff.
+type Difference struct {
+	// Kind is DiffAdded, DiffRemoved or DiffChanged.
+	Kind string
+	// Path is the query path of the value, such as .spec.replicas, or "."
+	// for the document root.
+	Path string
+	// Old and New are the value in each document. Old is unset for an
+	// added value and New for a removed one.
+	Old interface{}
+	New interface{}
+}
+
+// String formats the difference as a line starting with + for an added
+// value, - for a removed value and ~ for a changed value, followed by its
+// path and JSON values, such as "~ .spec.replicas: 3 -> 5".
+func (d Difference) String() string {
+	switch d.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %s", d.Path, formatValue(d.New))
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %s", d.Path, formatValue(d.Old))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", d.Path, formatValue(d.Old), formatValue(d.New))
+}
+
+// Diff returns every difference between old and new, which are JSON-compatible
+// values such as the Value of a Document or a value decoded from JSON. Object
+// keys are compared regardless of their order, and numbers by value, so 1e3
+// and 1000 are the same. Array items are compared by position. The
+// differences are ordered as the fields of old, followed by the fields only
+// found in new.
+func Diff(old, new interface{}) []Difference {
+	var differences []Difference
+	walkDifferences(old, new, "", func(d Difference) bool {
+		differences = append(differences, d)
+		return true
+	})
+	return differences
+}
+
+// walkDifferences calls fn for each difference between old and new, found
+// at path, until fn returns false. It reports whether every difference was
+// visited.
+func walkDifferences(old, new interface{}, path string, fn func(Difference) bool) bool {
+	if oldObject, ok := asObject(old); ok {
+		newObject, ok := asObject(new)
+		if !ok {
+			return fn(Difference{Kind: DiffChanged, Path: pathOrRoot(path), Old: old, New: new})
+		}
+		for _, key := range oldObject.Keys() {
+			fieldPath := path + querySegment{field: key}.String()
+			oldValue, _ := oldObject.Get(key)
+			newValue, ok := newObject.Get(key)
+			if !ok {
+				if !fn(Difference{Kind: DiffRemoved, Path: fieldPath, Old: oldValue}) {
+					return false
+				}
+				continue
+			}
+			if !walkDifferences(oldValue, newValue, fieldPath, fn) {
+				return false
+			}
+		}
+		for _, key := range newObject.Keys() {
+			if _, ok := oldObject.Get(key); ok {
+				continue
+			}
+			newValue, _ := newObject.Get(key)
+			if !fn(Difference{Kind: DiffAdded, Path: path + querySegment{field: key}.String(), New: newValue}) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if oldItems, ok := old.([]interface{}); ok {
+		newItems, ok := new.([]interface{})
+		if !ok {
+			return fn(Difference{Kind: DiffChanged, Path: pathOrRoot(path), Old: old, New: new})
+		}
+		for i := 0; i < len(oldItems) || i < len(newItems); i++ {
+			itemPath := path + querySegment{index: i, isIndex: true}.String()
+			var more bool
+			switch {
+			case i >= len(newItems):
+				more = fn(Difference{Kind: DiffRemoved, Path: itemPath, Old: oldItems[i]})
+			case i >= len(oldItems):
+				more = fn(Difference{Kind: DiffAdded, Path: itemPath, New: newItems[i]})
+			default:
+				more = walkDifferences(oldItems[i], newItems[i], itemPath, fn)
+			}
+			if !more {
+				return false
+			}
+		}
+		return true
+	}
+
+	if valuesEqual(old, new) {
+		return true
+	}
+	return fn(Difference{Kind: DiffChanged, Path: pathOrRoot(path), Old: old, New: new})
+}
+
+// asObject returns v as an *Object, converting a map decoded from JSON into
+// one with its keys sorted.
+func asObject(v interface{}) (*Object, bool) {
+	switch v := v.(type) {
+	case *Object:
+		return v, true
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		object := NewObject()
+		for _, key := range keys {
+			object.Set(key, v[key])
+		}
+		return object, true
+	}
+	return nil, false
+}
+
+// valuesEqual reports whether two scalar values are equal, comparing numbers
+// by value.
+func valuesEqual(a, b interface{}) bool {
+	x, okX := numberValue(a)
+	y, okY := numberValue(b)
+	if okX || okY {
+		return okX && okY && x.Cmp(y) == 0
+	}
+	return a == b
+}
+
+// numberValue returns the value of a json.Number or float64 and whether v is
+// one. A float64 stands for the shortest decimal that reads back as it, so
+// that 0.1 decoded from JSON equals the json.Number 0.1.
+func numberValue(v interface{}) (*big.Rat, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(string(v))
+	case float64:
+		return new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	return nil, false
+}
+
+// formatValue returns the compact JSON form of v for a message.
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,111 @@
+package converter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	decode := func(input string) interface{} {
+		documents, err := Decode([]byte(input), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return documents[0].Value
+	}
+
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []string
+	}{
+		{name: "equal", old: "a: 1\nb: [x]\n", new: "a: 1\nb: [x]\n"},
+		{name: "key order", old: "a: 1\nb: 2\n", new: "b: 2\na: 1\n"},
+		{name: "number digits", old: "a: 1e3\nb: 0.50\n", new: "a: 1000.0\nb: 0.5\n"},
+		{
+			name: "changed values",
+			old:  "spec:\n  replicas: 3\n  paused: true\n  ratio: 1.5\n",
+			new:  "spec:\n  replicas: \"3\"\n  paused: \"true\"\n  ratio: 1.25\n",
+			want: []string{`~ .spec.replicas: 3 -> "3"`, `~ .spec.paused: true -> "true"`, `~ .spec.ratio: 1.5 -> 1.25`},
+		},
+		{
+			name: "added and removed fields",
+			old:  "a: 1\nb: 2\n",
+			new:  "\"x.y\": 3\na: 1\n",
+			want: []string{`- .b: 2`, `+ ["x.y"]: 3`},
+		},
+		{
+			name: "array items",
+			old:  "a: [1, 2]\nb: [x]\n",
+			new:  "a: [1]\nb: [x, {y: 2}]\n",
+			want: []string{`- .a[1]: 2`, `+ .b[1]: {"y":2}`},
+		},
+		{
+			name: "changed types",
+			old:  "a: {b: 1}\nc: [1]\nd: null\n",
+			new:  "a: [1]\nc: x\nd: \"\"\n",
+			want: []string{`~ .a: {"b":1} -> [1]`, `~ .c: [1] -> "x"`, `~ .d: null -> ""`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, difference := range Diff(decode(tt.old), decode(tt.new)) {
+				got = append(got, difference.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffDecodedJSON(t *testing.T) {
+	documents, err := Decode([]byte("kind: A\nspec:\n  replicas: 3\n  big: 123456789012345678901\n"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plain, exact interface{}
+	if err := json.Unmarshal([]byte(`{"spec":{"big":123456789012345678901,"replicas":3.0},"kind":"A"}`), &plain); err != nil {
+		t.Fatal(err)
+	}
+	if exact, err = decodeJSON([]byte(`{"spec":{"big":123456789012345678901,"replicas":3.0},"kind":"A"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// float64 cannot hold the digits of big
+	want := []Difference{{Kind: DiffChanged, Path: ".spec.big", Old: json.Number("123456789012345678901"), New: float64(123456789012345678901)}}
+	if got := Diff(documents[0].Value, plain); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() with float64 numbers = %v, want %v", got, want)
+	}
+	if got := Diff(documents[0].Value, exact); got != nil {
+		t.Errorf("Diff() with json.Number numbers = %v, want none", got)
+	}
+}
+
+func TestValuesEqual(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		want bool
+	}{
+		{json.Number("1"), json.Number("1.0"), true},
+		{json.Number("1e3"), json.Number("1000"), true},
+		{json.Number("-0"), json.Number("0"), true},
+		{json.Number("0.5"), 0.5, true},
+		{json.Number("0.1"), 0.1, true},
+		{json.Number("0.1"), json.Number("0.10000000000000001"), false},
+		{json.Number("123456789012345678901"), json.Number("123456789012345678900"), false},
+		{json.Number("1"), "1", false},
+		{"a", "a", true},
+		{true, "true", false},
+		{nil, nil, true},
+	}
+	for _, tt := range tests {
+		if got := valuesEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("valuesEqual(%#v, %#v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package converter
+
+// Document is a single decoded document from a YAML stream.
+type Document struct {
+	// Index is the 1-based position of the document in the stream, counting
+	// empty documents.
+	Index int
+	// Item is the 1-based position of the document among the items of a
+	// list exploded with Options.ExplodeLists, or 0 when the document is not
+	// a list item.
+	Item int
+	// Line is the line the document starts on.
+	Line int
+	// Value is the decoded document. Mappings are *Object.
+	Value interface{}
+	// Empty is set for an empty document kept by EmptyDocumentsNull, whose
+	// Value is nil.
+	Empty bool
+}
+
+// Values returns the decoded values of documents.
+func Values(documents []Document) []interface{} {
+	values := make([]interface{}, len(documents))
+	for i, doc := range documents {
+		values[i] = doc.Value
+	}
+	return values
+}
+
+// APIVersion returns the apiVersion of the document, or "" when it has none.
+func (d Document) APIVersion() string {
+	return stringField(d.Value, "apiVersion")
+}
+
+// Kind returns the kind of the document, or "" when it has none.
+func (d Document) Kind() string {
+	return stringField(d.Value, "kind")
+}
+
+// Name returns metadata.name of the document, or "" when it has none.
+func (d Document) Name() string {
+	return stringField(d.metadata(), "name")
+}
+
+// Namespace returns metadata.namespace of the document, or "" when it has
+// none.
+func (d Document) Namespace() string {
+	return stringField(d.metadata(), "namespace")
+}
+
+// metadata returns the metadata mapping of the document, or nil.
+func (d Document) metadata() interface{} {
+	return field(d.Value, "metadata")
+}
+
+// field returns the value of key in a mapping, or nil when v is not a
+// mapping or has no such key.
+func field(v interface{}, key string) interface{} {
+	object, ok := v.(*Object)
+	if !ok {
+		return nil
+	}
+	value, _ := object.Get(key)
+	return value
+}
+
+// stringField returns the string value of key in a mapping, or "" when v is
+// not a mapping or the value is not a string.
+func stringField(v interface{}, key string) string {
+	value, _ := field(v, key).(string)
+	return value
+}
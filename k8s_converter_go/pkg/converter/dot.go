@@ -0,0 +1,360 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// graphReference is a kind of reference from a resource to others, drawn as
+// an edge with label from the resource to each target that targets returns.
+// New kinds of edges are added to graphReferences.
+type graphReference struct {
+	label   string
+	targets func(resource *Object) []graphTarget
+}
+
+// graphTarget is the resource a reference points at: the one of kind and
+// name in the namespace of the referring resource, or with selector the
+// workloads whose pods have all the labels of selector.
+type graphTarget struct {
+	kind     string
+	name     string
+	selector *Object
+}
+
+// graphReferences are the references FormatDOT draws.
+var graphReferences = []graphReference{
+	{label: "mounts", targets: volumeTargets},
+	{label: "env", targets: envTargets},
+	{label: "pulls with", targets: imagePullSecretTargets},
+	{label: "runs as", targets: serviceAccountTargets},
+	{label: "selects", targets: serviceSelectorTargets},
+	{label: "routes to", targets: ingressBackendTargets},
+	{label: "tls", targets: ingressTLSTargets},
+}
+
+// podTemplatePaths are the paths of the pod template of each workload kind.
+// A Pod is its own template.
+var podTemplatePaths = map[string][]string{
+	"Pod":                   nil,
+	"Deployment":            {"spec", "template"},
+	"StatefulSet":           {"spec", "template"},
+	"DaemonSet":             {"spec", "template"},
+	"ReplicaSet":            {"spec", "template"},
+	"ReplicationController": {"spec", "template"},
+	"Job":                   {"spec", "template"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template"},
+}
+
+// podTemplate returns the pod template of a workload, with the pod's
+// metadata and spec, or nil for other kinds.
+func podTemplate(resource *Object) interface{} {
+	path, ok := podTemplatePaths[stringField(resource, "kind")]
+	if !ok {
+		return nil
+	}
+	var template interface{} = resource
+	for _, key := range path {
+		template = field(template, key)
+	}
+	return template
+}
+
+// podSpec returns the pod spec of a workload, or nil.
+func podSpec(resource *Object) interface{} {
+	return field(podTemplate(resource), "spec")
+}
+
+// arrayField returns the array at key in a mapping, or nil.
+func arrayField(v interface{}, key string) []interface{} {
+	items, _ := field(v, key).([]interface{})
+	return items
+}
+
+// namedTarget returns a target of kind named by the string at path in v, or
+// false when there is none.
+func namedTarget(kind string, v interface{}, path ...string) (graphTarget, bool) {
+	for _, key := range path[:len(path)-1] {
+		v = field(v, key)
+	}
+	name := stringField(v, path[len(path)-1])
+	return graphTarget{kind: kind, name: name}, name != ""
+}
+
+// appendTarget appends the target of kind named at path in v to targets, if
+// there is one.
+func appendTarget(targets []graphTarget, kind string, v interface{}, path ...string) []graphTarget {
+	if target, ok := namedTarget(kind, v, path...); ok {
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// volumeTargets returns the ConfigMaps and Secrets mounted as volumes of a
+// workload, including projected ones.
+func volumeTargets(resource *Object) []graphTarget {
+	var targets []graphTarget
+	for _, volume := range arrayField(podSpec(resource), "volumes") {
+		targets = appendTarget(targets, "ConfigMap", volume, "configMap", "name")
+		targets = appendTarget(targets, "Secret", volume, "secret", "secretName")
+		for _, source := range arrayField(field(volume, "projected"), "sources") {
+			targets = appendTarget(targets, "ConfigMap", source, "configMap", "name")
+			targets = appendTarget(targets, "Secret", source, "secret", "name")
+		}
+	}
+	return targets
+}
+
+// envTargets returns the ConfigMaps and Secrets the containers of a workload
+// read environment variables from.
+func envTargets(resource *Object) []graphTarget {
+	spec := podSpec(resource)
+	var targets []graphTarget
+	for _, containers := range [][]interface{}{arrayField(spec, "initContainers"), arrayField(spec, "containers")} {
+		for _, container := range containers {
+			for _, source := range arrayField(container, "envFrom") {
+				targets = appendTarget(targets, "ConfigMap", source, "configMapRef", "name")
+				targets = appendTarget(targets, "Secret", source, "secretRef", "name")
+			}
+			for _, env := range arrayField(container, "env") {
+				valueFrom := field(env, "valueFrom")
+				targets = appendTarget(targets, "ConfigMap", valueFrom, "configMapKeyRef", "name")
+				targets = appendTarget(targets, "Secret", valueFrom, "secretKeyRef", "name")
+			}
+		}
+	}
+	return targets
+}
+
+// imagePullSecretTargets returns the image pull Secrets of a workload or
+// ServiceAccount.
+func imagePullSecretTargets(resource *Object) []graphTarget {
+	secrets := arrayField(podSpec(resource), "imagePullSecrets")
+	if stringField(resource, "kind") == "ServiceAccount" {
+		secrets = arrayField(resource, "imagePullSecrets")
+	}
+	var targets []graphTarget
+	for _, secret := range secrets {
+		targets = appendTarget(targets, "Secret", secret, "name")
+	}
+	return targets
+}
+
+// serviceAccountTargets returns the ServiceAccount the pods of a workload run
+// as, named by serviceAccountName or the deprecated serviceAccount.
+func serviceAccountTargets(resource *Object) []graphTarget {
+	spec := podSpec(resource)
+	if target, ok := namedTarget("ServiceAccount", spec, "serviceAccountName"); ok {
+		return []graphTarget{target}
+	}
+	if target, ok := namedTarget("ServiceAccount", spec, "serviceAccount"); ok {
+		return []graphTarget{target}
+	}
+	return nil
+}
+
+// serviceSelectorTargets returns the pods a Service selects.
+func serviceSelectorTargets(resource *Object) []graphTarget {
+	if stringField(resource, "kind") != "Service" {
+		return nil
+	}
+	selector, ok := field(field(resource, "spec"), "selector").(*Object)
+	if !ok || selector.Len() == 0 {
+		return nil
+	}
+	return []graphTarget{{kind: "Pod", selector: selector}}
+}
+
+// ingressBackendTargets returns the Services an Ingress routes to, in the
+// networking.k8s.io/v1 and the older extensions/v1beta1 form.
+func ingressBackendTargets(resource *Object) []graphTarget {
+	if stringField(resource, "kind") != "Ingress" {
+		return nil
+	}
+	spec := field(resource, "spec")
+	backends := []interface{}{field(spec, "defaultBackend"), field(spec, "backend")}
+	for _, rule := range arrayField(spec, "rules") {
+		for _, path := range arrayField(field(rule, "http"), "paths") {
+			backends = append(backends, field(path, "backend"))
+		}
+	}
+	var targets []graphTarget
+	for _, backend := range backends {
+		targets = appendTarget(targets, "Service", backend, "service", "name")
+		targets = appendTarget(targets, "Service", backend, "serviceName")
+	}
+	return targets
+}
+
+// ingressTLSTargets returns the certificate Secrets of an Ingress.
+func ingressTLSTargets(resource *Object) []graphTarget {
+	if stringField(resource, "kind") != "Ingress" {
+		return nil
+	}
+	var targets []graphTarget
+	for _, tls := range arrayField(field(resource, "spec"), "tls") {
+		targets = appendTarget(targets, "Secret", tls, "secretName")
+	}
+	return targets
+}
+
+// graphNode is a node of a FormatDOT graph: a resource of the input, or a
+// resource referenced but missing from it.
+type graphNode struct {
+	id, label string
+	resource  *Object
+	missing   bool
+}
+
+// graphEdge is an edge of a FormatDOT graph.
+type graphEdge struct {
+	from, to, label string
+}
+
+// resourceGraph is the graph of the resources of one or more inputs.
+type resourceGraph struct {
+	nodes []*graphNode
+	byID  map[string]*graphNode
+	edges []graphEdge
+	seen  map[graphEdge]bool
+}
+
+// nodeID returns the ID of the node of the resource of kind, namespace and
+// name. Resources of the same kind and name in different namespaces are
+// different nodes.
+func nodeID(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// addNode adds a node for the resource of kind, namespace and name, unless
+// there is one already, and returns it.
+func (g *resourceGraph) addNode(kind, namespace, name string, resource *Object) *graphNode {
+	id := nodeID(kind, namespace, name)
+	if node, ok := g.byID[id]; ok {
+		return node
+	}
+	node := &graphNode{id: id, label: kind + "/" + name, resource: resource, missing: resource == nil}
+	g.nodes = append(g.nodes, node)
+	g.byID[id] = node
+	return node
+}
+
+// addEdge adds an edge, unless the graph has it already.
+func (g *resourceGraph) addEdge(edge graphEdge) {
+	if !g.seen[edge] {
+		g.seen[edge] = true
+		g.edges = append(g.edges, edge)
+	}
+}
+
+// newResourceGraph returns the graph of the resources of inventories: a node
+// for every document with a kind and a name, or item of a list kind, and an
+// edge for every reference of graphReferences. A reference to a resource not
+// in the inventories adds a node for it, drawn dashed.
+func newResourceGraph(inventories []Inventory) *resourceGraph {
+	g := &resourceGraph{byID: make(map[string]*graphNode), seen: make(map[graphEdge]bool)}
+	for _, inventory := range inventories {
+		for _, doc := range inventory.Documents {
+			values := []interface{}{doc.Value}
+			if items, ok := listItems(doc.Value); ok {
+				values = items
+			}
+			for _, value := range values {
+				resource, ok := value.(*Object)
+				metadata := field(value, "metadata")
+				kind, name := stringField(value, "kind"), stringField(metadata, "name")
+				if ok && kind != "" && name != "" {
+					g.addNode(kind, stringField(metadata, "namespace"), name, resource)
+				}
+			}
+		}
+	}
+
+	// References are resolved once every resource has a node, so that a
+	// resource defined after the one referring to it is found
+	resources := make([]*graphNode, len(g.nodes))
+	copy(resources, g.nodes)
+	for _, node := range resources {
+		namespace := stringField(field(node.resource, "metadata"), "namespace")
+		for _, reference := range graphReferences {
+			for _, target := range reference.targets(node.resource) {
+				if target.selector == nil {
+					to := g.addNode(target.kind, namespace, target.name, nil)
+					g.addEdge(graphEdge{from: node.id, to: to.id, label: reference.label})
+					continue
+				}
+				matched := false
+				for _, candidate := range resources {
+					if stringField(field(candidate.resource, "metadata"), "namespace") == namespace && selectsPods(target.selector, candidate.resource) {
+						g.addEdge(graphEdge{from: node.id, to: candidate.id, label: reference.label})
+						matched = true
+					}
+				}
+				if !matched {
+					to := g.addNode(target.kind, namespace, selectorString(target.selector), nil)
+					g.addEdge(graphEdge{from: node.id, to: to.id, label: reference.label})
+				}
+			}
+		}
+	}
+	return g
+}
+
+// selectsPods reports whether the pods of the workload resource have every
+// label of selector.
+func selectsPods(selector, resource *Object) bool {
+	labels, ok := field(field(podTemplate(resource), "metadata"), "labels").(*Object)
+	if !ok {
+		return false
+	}
+	for _, key := range selector.Keys() {
+		want, _ := selector.Get(key)
+		got, exists := labels.Get(key)
+		if !exists || labelValue(got) != labelValue(want) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectorString returns selector in the key=value,... form of kubectl.
+func selectorString(selector *Object) string {
+	pairs := make([]string, 0, selector.Len())
+	for _, key := range selector.Keys() {
+		value, _ := selector.Get(key)
+		pairs = append(pairs, key+"="+labelValue(value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// marshalDOT writes the resource graph of inventories as a Graphviz digraph
+// for FormatDOT: a box for every resource, labelled kind/name, a dashed box
+// for every resource referenced but not defined, and a labelled edge for
+// every reference. Nodes and edges are in input order.
+func marshalDOT(inventories []Inventory) []byte {
+	g := newResourceGraph(inventories)
+	var buf bytes.Buffer
+	buf.WriteString("digraph resources {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, node := range g.nodes {
+		if node.missing {
+			fmt.Fprintf(&buf, "  %s [label=%s, style=dashed];\n", dotQuote(node.id), dotQuote(node.label))
+		} else {
+			fmt.Fprintf(&buf, "  %s [label=%s];\n", dotQuote(node.id), dotQuote(node.label))
+		}
+	}
+	for _, edge := range g.edges {
+		fmt.Fprintf(&buf, "  %s -> %s [label=%s];\n", dotQuote(edge.from), dotQuote(edge.to), dotQuote(edge.label))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// dotQuoteReplacer escapes the characters that would end a DOT string, and
+// line breaks, which DOT strings cannot hold.
+var dotQuoteReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// dotQuote returns s as a double-quoted DOT string.
+func dotQuote(s string) string {
+	return `"` + dotQuoteReplacer.Replace(s) + `"`
+}
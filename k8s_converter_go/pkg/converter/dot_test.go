@@ -0,0 +1,225 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+// dotBundle is an application whose resources reference each other in every
+// way FormatDOT draws, with a ConfigMap and a Service that are missing.
+const dotBundle = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+        tier: frontend
+    spec:
+      serviceAccountName: web
+      imagePullSecrets:
+      - name: registry
+      volumes:
+      - name: config
+        configMap:
+          name: web-config
+      - name: tls
+        secret:
+          secretName: web-tls
+      - name: extra
+        projected:
+          sources:
+          - configMap:
+              name: missing-config
+      containers:
+      - name: web
+        envFrom:
+        - secretRef:
+            name: web-env
+        env:
+        - name: MODE
+          valueFrom:
+            configMapKeyRef:
+              name: web-config
+              key: mode
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: shop
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-tls
+  namespace: shop
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+spec:
+  selector:
+    app: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: orphan
+  namespace: shop
+spec:
+  selector:
+    app: gone
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+  namespace: shop
+spec:
+  tls:
+  - secretName: web-tls
+  rules:
+  - http:
+      paths:
+      - path: /
+        backend:
+          service:
+            name: web
+      - path: /api
+        backend:
+          service:
+            name: api
+`
+
+func TestConvertDOT(t *testing.T) {
+	want := `digraph resources {
+  rankdir=LR;
+  node [shape=box];
+  "Deployment/shop/web" [label="Deployment/web"];
+  "ConfigMap/shop/web-config" [label="ConfigMap/web-config"];
+  "Secret/shop/web-tls" [label="Secret/web-tls"];
+  "ServiceAccount/shop/web" [label="ServiceAccount/web"];
+  "Service/shop/web" [label="Service/web"];
+  "Service/shop/orphan" [label="Service/orphan"];
+  "Ingress/shop/web" [label="Ingress/web"];
+  "ConfigMap/shop/missing-config" [label="ConfigMap/missing-config", style=dashed];
+  "Secret/shop/web-env" [label="Secret/web-env", style=dashed];
+  "Secret/shop/registry" [label="Secret/registry", style=dashed];
+  "Pod/shop/app=gone" [label="Pod/app=gone", style=dashed];
+  "Service/shop/api" [label="Service/api", style=dashed];
+  "Deployment/shop/web" -> "ConfigMap/shop/web-config" [label="mounts"];
+  "Deployment/shop/web" -> "Secret/shop/web-tls" [label="mounts"];
+  "Deployment/shop/web" -> "ConfigMap/shop/missing-config" [label="mounts"];
+  "Deployment/shop/web" -> "Secret/shop/web-env" [label="env"];
+  "Deployment/shop/web" -> "ConfigMap/shop/web-config" [label="env"];
+  "Deployment/shop/web" -> "Secret/shop/registry" [label="pulls with"];
+  "Deployment/shop/web" -> "ServiceAccount/shop/web" [label="runs as"];
+  "Service/shop/web" -> "Deployment/shop/web" [label="selects"];
+  "Service/shop/orphan" -> "Pod/shop/app=gone" [label="selects"];
+  "Ingress/shop/web" -> "Service/shop/web" [label="routes to"];
+  "Ingress/shop/web" -> "Service/shop/api" [label="routes to"];
+  "Ingress/shop/web" -> "Secret/shop/web-tls" [label="tls"];
+}
+`
+	got, err := Convert([]byte(dotBundle), Options{Format: FormatDOT})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Convert() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConvertDOTReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "References resolve within the namespace",
+			content: "kind: Pod\nmetadata:\n  name: a\n  namespace: one\nspec:\n  serviceAccountName: sa\n---\nkind: ServiceAccount\nmetadata:\n  name: sa\n  namespace: two\n",
+			want:    []string{`"ServiceAccount/one/sa" [label="ServiceAccount/sa", style=dashed];`, `"Pod/one/a" -> "ServiceAccount/one/sa" [label="runs as"];`},
+		},
+		{
+			name:    "CronJob pod template",
+			content: "kind: CronJob\nmetadata:\n  name: backup\nspec:\n  jobTemplate:\n    spec:\n      template:\n        spec:\n          serviceAccount: backup\n",
+			want:    []string{`"CronJob//backup" -> "ServiceAccount//backup" [label="runs as"];`},
+		},
+		{
+			name: "Selector needs every label",
+			content: "kind: Service\nmetadata:\n  name: web\nspec:\n  selector: {app: web, tier: db}\n---\n" +
+				"kind: Pod\nmetadata:\n  name: web\n  labels: {app: web}\n",
+			want:    []string{`"Service//web" -> "Pod//app=web,tier=db" [label="selects"];`},
+			notWant: []string{`-> "Pod//web"`},
+		},
+		{
+			name:    "Items of a List",
+			content: "kind: List\nitems:\n- kind: Ingress\n  metadata: {name: web}\n  spec:\n    backend: {serviceName: web}\n- kind: Service\n  metadata: {name: web}\n",
+			want:    []string{`"Ingress//web" -> "Service//web" [label="routes to"];`, `"Service//web" [label="Service/web"];`},
+			notWant: []string{`"List`},
+		},
+		{
+			name:    "Documents without a kind or name",
+			content: "replicas: 3\n---\nkind: ConfigMap\n",
+			want:    []string{"digraph resources {\n  rankdir=LR;\n  node [shape=box];\n}\n"},
+		},
+		{
+			name:    "Names quoted",
+			content: "kind: ConfigMap\nmetadata:\n  name: 'a\"b\\c'\n",
+			want:    []string{`"ConfigMap//a\"b\\c" [label="ConfigMap/a\"b\\c"];`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(tt.content), Options{Format: FormatDOT})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("Convert() =\n%s\nwant it to contain %s", got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(string(got), notWant) {
+					t.Errorf("Convert() =\n%s\nwant it not to contain %s", got, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalInventoryDOT(t *testing.T) {
+	// Inventories of several inputs make a single graph, so references
+	// resolve across files
+	deployment, err := Decode([]byte("kind: Deployment\nmetadata:\n  name: web\nspec:\n  template:\n    spec:\n      serviceAccountName: web\n"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := Decode([]byte("kind: ServiceAccount\nmetadata:\n  name: web\n"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := MarshalInventory([]Inventory{{Source: "a.yaml", Documents: deployment}, {Source: "b.yaml", Documents: account}}, Options{Format: FormatDOT})
+	if err != nil {
+		t.Fatalf("MarshalInventory() error = %v", err)
+	}
+	if strings.Contains(string(got), "dashed") || !strings.Contains(string(got), `"Deployment//web" -> "ServiceAccount//web" [label="runs as"];`) {
+		t.Errorf("MarshalInventory() =\n%s\nwant the ServiceAccount of b.yaml referenced", got)
+	}
+}
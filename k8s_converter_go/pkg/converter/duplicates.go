@@ -0,0 +1,116 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DuplicateKey describes a mapping key that appears more than once in the same
+// mapping.
+type DuplicateKey struct {
+	Key string
+	// Path is the location of the mapping in the document, such as
+	// ".spec.template.spec.containers[0]".
+	Path string
+	// Document is the 1-based position of the document in the stream.
+	Document int
+	// Line and Column locate the duplicate occurrence of the key.
+	Line   int
+	Column int
+	// FirstLine is the line of the first occurrence of the key.
+	FirstLine int
+}
+
+func (d DuplicateKey) String() string {
+	return fmt.Sprintf("duplicate key %q in %s at line %d, column %d (first defined at line %d)",
+		d.Key, d.Path, d.Line, d.Column, d.FirstLine)
+}
+
+// warning returns the duplicate as a Warning.
+func (d DuplicateKey) warning() Warning {
+	return Warning{
+		Document: d.Document,
+		Line:     d.Line,
+		Message:  fmt.Sprintf("duplicate key %q in %s (first defined at line %d)", d.Key, d.Path, d.FirstLine),
+	}
+}
+
+// findDuplicateKeys walks the document and returns every duplicate mapping
+// key in document order. yaml.v3 stops at the first duplicate when decoding,
+// so the node tree is inspected directly.
+func findDuplicateKeys(doc parsedDocument) []DuplicateKey {
+	var duplicates []DuplicateKey
+	walkDuplicateKeys(doc.node, ".", func(key *yaml.Node, first *yaml.Node, path string) {
+		duplicates = append(duplicates, DuplicateKey{
+			Key:       key.Value,
+			Path:      path,
+			Document:  doc.index,
+			Line:      key.Line,
+			Column:    key.Column,
+			FirstLine: first.Line,
+		})
+	})
+	return duplicates
+}
+
+// walkDuplicateKeys calls found for every key of a mapping under node that
+// repeats an earlier key of the same mapping.
+func walkDuplicateKeys(node *yaml.Node, path string, found func(key, first *yaml.Node, path string)) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		seen := make(map[string]*yaml.Node)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind == yaml.ScalarNode && key.Tag != "!!merge" {
+				if first, ok := seen[key.Value]; ok {
+					found(key, first, path)
+				} else {
+					seen[key.Value] = key
+				}
+			}
+			walkDuplicateKeys(value, joinPath(path, key.Value), found)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			walkDuplicateKeys(item, path+"["+strconv.Itoa(i)+"]", found)
+		}
+	}
+}
+
+// removeDuplicateKeys removes every earlier occurrence of a repeated mapping
+// key under node, so the last occurrence wins when the document is decoded.
+func removeDuplicateKeys(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		last := make(map[string]int)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Kind == yaml.ScalarNode && key.Tag != "!!merge" {
+				last[key.Value] = i
+			}
+		}
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind == yaml.ScalarNode && key.Tag != "!!merge" && last[key.Value] != i {
+				continue
+			}
+			removeDuplicateKeys(value)
+			content = append(content, key, value)
+		}
+		node.Content = content
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			removeDuplicateKeys(item)
+		}
+	}
+}
+
+// joinPath appends a mapping key to a document path.
+func joinPath(path, key string) string {
+	if path == "." {
+		return "." + key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,77 @@
+package converter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const duplicateKeysYAML = `apiVersion: apps/v1
+kind: Deployment
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        env:
+        - name: A
+        env:
+        - name: B
+---
+kind: Service
+metadata:
+  name: web
+  name: web-svc
+`
+
+func TestDuplicateKeysWarn(t *testing.T) {
+	var warnings []Warning
+	got, err := Convert([]byte(duplicateKeysYAML), Options{
+		Compact:  true,
+		Separate: true,
+		Warn:     func(w Warning) { warnings = append(warnings, w) },
+	})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := `{"apiVersion":"apps/v1","kind":"Deployment","spec":{"template":{"spec":{"containers":[{"name":"web","env":[{"name":"B"}]}]}}}}` +
+		"\n" + `{"kind":"Service","metadata":{"name":"web-svc"}}`
+	if string(got) != want {
+		t.Errorf("Convert() = %s, want %s", got, want)
+	}
+
+	wantWarnings := []Warning{
+		{Document: 1, Line: 3, Message: `duplicate key "kind" in . (first defined at line 2)`},
+		{Document: 1, Line: 11, Message: `duplicate key "env" in .spec.template.spec.containers[0] (first defined at line 9)`},
+		{Document: 2, Line: 17, Message: `duplicate key "name" in .metadata (first defined at line 16)`},
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("Convert() warnings = %v, want %v", warnings, wantWarnings)
+	}
+}
+
+func TestDuplicateKeysStrict(t *testing.T) {
+	_, err := Convert([]byte(duplicateKeysYAML), Options{StrictKeys: true})
+	var duplicateErr *DuplicateKeyError
+	if !errors.As(err, &duplicateErr) {
+		t.Fatalf("Convert() error = %v, want DuplicateKeyError", err)
+	}
+
+	want := []DuplicateKey{
+		{Key: "kind", Path: ".", Document: 1, Line: 3, Column: 1, FirstLine: 2},
+		{Key: "env", Path: ".spec.template.spec.containers[0]", Document: 1, Line: 11, Column: 9, FirstLine: 9},
+		{Key: "name", Path: ".metadata", Document: 2, Line: 17, Column: 3, FirstLine: 16},
+	}
+	if !reflect.DeepEqual(duplicateErr.Duplicates, want) {
+		t.Errorf("DuplicateKeyError.Duplicates = %v, want %v", duplicateErr.Duplicates, want)
+	}
+}
+
+func TestNoDuplicateKeys(t *testing.T) {
+	content := "kind: Pod\nmetadata:\n  name: a\nspec:\n  containers:\n  - name: a\n  - name: b\n"
+	if _, err := Convert([]byte(content), Options{StrictKeys: true}); err != nil {
+		t.Errorf("Convert() error = %v", err)
+	}
+}
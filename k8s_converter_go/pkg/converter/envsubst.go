@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"os"
+	"regexp"
+	"sort"
+)
+
+// envReference matches ${VAR} and ${VAR:-default} references, and the $${
+// escape that produces a literal ${.
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// substituteEnv expands the environment variable references in data. A reference with a default uses it when the variable
+// is unset or empty. A reference to an unset variable without a default is
+// missing, and every missing variable is reported in a *MissingEnvError. References to variables outside
+// opts.EnvAllowlist are left unchanged.
+func substituteEnv(data []byte, opts Options) ([]byte, error) {
+	lookup := opts.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	var allowed map[string]bool
+	if opts.EnvAllowlist != nil {
+		allowed = make(map[string]bool, len(opts.EnvAllowlist))
+		for _, name := range opts.EnvAllowlist {
+			allowed[name] = true
+		}
+	}
+
+	missing := make(map[string]bool)
+	result := envReference.ReplaceAllFunc(data, func(match []byte) []byte {
+		if string(match) == "$${" {
+			return []byte("${")
+		}
+		groups := envReference.FindSubmatch(match)
+		name := string(groups[1])
+		if allowed != nil && !allowed[name] {
+			return match
+		}
+		value, ok := lookup(name)
+		if groups[2] != nil {
+			if value == "" {
+				return groups[2][len(":-"):]
+			}
+			return []byte(value)
+		}
+		if ok {
+			return []byte(value)
+		}
+		missing[name] = true
+		return match
+	})
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, &MissingEnvError{Variables: names}
+	}
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package converter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSubstituteEnv(t *testing.T) {
+	env := map[string]string{"IMAGE_TAG": "1.25", "EMPTY": "", "SECRET": "hunter2"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		allowlist   []string
+		want        string
+		wantMissing []string
+	}{
+		{
+			name:    "variable",
+			content: "image: nginx:${IMAGE_TAG}\n",
+			want:    "image: nginx:1.25\n",
+		},
+		{
+			name:    "default",
+			content: "replicas: ${REPLICAS:-3}\nlabel: ${EMPTY:-none}\ntag: ${IMAGE_TAG:-latest}\n",
+			want:    "replicas: 3\nlabel: none\ntag: 1.25\n",
+		},
+		{
+			name:    "empty variable without default",
+			content: "value: '${EMPTY}'\n",
+			want:    "value: ''\n",
+		},
+		{
+			name:    "escaped and shell references",
+			content: "a: $${IMAGE_TAG}\nb: $IMAGE_TAG\nc: $$(HOSTNAME)\n",
+			want:    "a: ${IMAGE_TAG}\nb: $IMAGE_TAG\nc: $$(HOSTNAME)\n",
+		},
+		{
+			name:        "missing variables",
+			content:     "a: ${ZONE}\nb: ${REGION}\nc: ${ZONE}\n",
+			wantMissing: []string{"REGION", "ZONE"},
+		},
+		{
+			name:      "allowlist",
+			content:   "tag: ${IMAGE_TAG}\npassword: ${SECRET}\nother: ${UNSET}\n",
+			allowlist: []string{"IMAGE_TAG"},
+			want:      "tag: 1.25\npassword: ${SECRET}\nother: ${UNSET}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{EnvSubst: true, EnvAllowlist: tt.allowlist, LookupEnv: lookup}
+			got, err := substituteEnv([]byte(tt.content), opts)
+			if tt.wantMissing != nil {
+				var missingErr *MissingEnvError
+				if !errors.As(err, &missingErr) {
+					t.Fatalf("substituteEnv() error = %v, want *MissingEnvError", err)
+				}
+				if !reflect.DeepEqual(missingErr.Variables, tt.wantMissing) {
+					t.Errorf("substituteEnv() missing = %v, want %v", missingErr.Variables, tt.wantMissing)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("substituteEnv() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("substituteEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertEnvSubst(t *testing.T) {
+	content := []byte("kind: Deployment\nspec:\n  replicas: ${REPLICAS}\n---\nkind: Service\n")
+	lookup := func(name string) (string, bool) { return "3", name == "REPLICAS" }
+
+	for _, format := range []string{FormatJSON, FormatNDJSON} {
+		got, err := Convert(content, Options{Format: format, Compact: true, EnvSubst: true, LookupEnv: lookup})
+		if err != nil {
+			t.Fatalf("Convert() %s error = %v", format, err)
+		}
+		want := `[{"kind":"Deployment","spec":{"replicas":3}},{"kind":"Service"}]`
+		if format == FormatNDJSON {
+			want = "{\"kind\":\"Deployment\",\"spec\":{\"replicas\":3}}\n{\"kind\":\"Service\"}\n"
+		}
+		if string(got) != want {
+			t.Errorf("Convert() %s = %q, want %q", format, got, want)
+		}
+	}
+
+	// References are left alone unless substitution is enabled
+	got, err := Convert(content, Options{Compact: true, Format: FormatNDJSON})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if want := "{\"kind\":\"Deployment\",\"spec\":{\"replicas\":\"${REPLICAS}\"}}\n{\"kind\":\"Service\"}\n"; string(got) != want {
+		t.Errorf("Convert() without substitution = %q, want %q", got, want)
+	}
+}
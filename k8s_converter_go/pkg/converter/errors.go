@@ -0,0 +1,542 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidYAML is returned when the input parses but every document in it
+// is empty, or a document is not a mapping.
+var ErrInvalidYAML = errors.New("invalid YAML content")
+
+// ErrNotText is returned when the input is neither UTF-8 nor UTF-16 text,
+// such as a binary file.
+var ErrNotText = errors.New("input does not appear to be text")
+
+// ErrNoMatch is returned when document filters such as Options.Kinds are set
+// and no document in the input matches them.
+var ErrNoMatch = errors.New("no documents match the filters")
+
+// ParseError is returned when the input cannot be parsed.
+type ParseError struct {
+	// Format is the input format that failed to parse, "YAML" or "JSON".
+	Format string
+	// Document is the 1-based position of the failing document in a
+	// multi-document stream, or 0 when not known.
+	Document int
+	// Line and Column locate the error in the input, starting at 1. They are
+	// 0 when not known.
+	Line   int
+	Column int
+	// Message describes the problem without its location. When empty, the
+	// message of Err is used.
+	Message string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	var location string
+	if e.Line > 0 {
+		location = fmt.Sprintf(" at line %d", e.Line)
+		if e.Column > 0 {
+			location += fmt.Sprintf(", column %d", e.Column)
+		}
+	}
+	if e.Document > 1 {
+		location += fmt.Sprintf(" in document %d", e.Document)
+	}
+	return fmt.Sprintf("error parsing %s%s: %s", e.Format, location, e.Detail())
+}
+
+// Detail returns the message describing the problem without its location.
+func (e *ParseError) Detail() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeError is returned when parsed documents cannot be encoded to the
+// output format.
+type EncodeError struct {
+	// Format is the output format that failed to encode, "JSON" or "YAML".
+	Format string
+	Err    error
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("error converting to %s: %v", e.Format, e.Err)
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// KeyError is returned when a mapping has a key that is not a string and
+// Options.RejectNonStringKeys is set.
+type KeyError struct {
+	// Path is the location of the mapping in the document, such as
+	// ".spec.ports".
+	Path string
+	Key  interface{}
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("non-string map key %s at %s", keyString(e.Key), e.Path)
+}
+
+// DuplicateKeyError is returned when Options.StrictKeys is set and a mapping
+// repeats a key. It lists every duplicate found in the input.
+type DuplicateKeyError struct {
+	Duplicates []DuplicateKey
+}
+
+func (e *DuplicateKeyError) Error() string {
+	messages := make([]string, len(e.Duplicates))
+	for i, duplicate := range e.Duplicates {
+		messages[i] = duplicate.String()
+		if duplicate.Document > 1 {
+			messages[i] += fmt.Sprintf(" in document %d", duplicate.Document)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// MissingFieldsError is returned when Options.KubernetesStrict is set and
+// documents lack required Kubernetes fields. It lists every such document.
+type MissingFieldsError struct {
+	Documents []MissingFields
+}
+
+func (e *MissingFieldsError) Error() string {
+	messages := make([]string, len(e.Documents))
+	for i, doc := range e.Documents {
+		messages[i] = fmt.Sprintf("document %d at line %d is missing %s",
+			doc.Document, doc.Line, strings.Join(doc.Fields, ", "))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// SchemaError is returned when Options.SchemaValidate is set and documents do
+// not match the OpenAPI schemas of their kinds. It lists every violation.
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return "schema validation failed: " + strings.Join(messages, "; ")
+}
+
+// NameError is returned when Options.ValidateNames is set and documents
+// have names the API server would reject. It lists every such name.
+type NameError struct {
+	Violations []NameViolation
+}
+
+func (e *NameError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return "invalid names: " + strings.Join(messages, "; ")
+}
+
+// LabelError is returned when Options.ValidateLabels is set and documents
+// have label or annotation keys or label values the API server would reject.
+// It lists every one, with its path.
+type LabelError struct {
+	Violations []NameViolation
+}
+
+func (e *LabelError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return "invalid labels: " + strings.Join(messages, "; ")
+}
+
+// ImageError is returned when Options.ValidateImages is set and documents
+// have container images that are not valid image references. It lists every
+// one, with its path.
+type ImageError struct {
+	Violations []NameViolation
+}
+
+func (e *ImageError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return "invalid images: " + strings.Join(messages, "; ")
+}
+
+// QuantityError is returned when Options.ValidateQuantities is set and
+// documents have requests or limits the API server would reject. It lists
+// every one, with its path.
+type QuantityError struct {
+	Violations []NameViolation
+}
+
+func (e *QuantityError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return "invalid quantities: " + strings.Join(messages, "; ")
+}
+
+// RoundTripError is returned when Options.VerifyRoundTrip is set and a
+// document changes when its JSON is converted back to YAML.
+type RoundTripError struct {
+	// Document is the 1-based position of the document in the stream.
+	Document int
+	// Path is the query path of the first value that changed, or "." for
+	// the document root.
+	Path string
+	// Original and RoundTrip are the JSON forms of the value before and
+	// after the round trip, or "missing" when it is absent.
+	Original  string
+	RoundTrip string
+}
+
+func (e *RoundTripError) Error() string {
+	message := fmt.Sprintf("round trip through JSON changed %s", e.Path)
+	if e.Document > 1 {
+		message += fmt.Sprintf(" in document %d", e.Document)
+	}
+	return fmt.Sprintf("%s: %s became %s", message, e.Original, e.RoundTrip)
+}
+
+// TypedError is returned when Options.Typed is set and a document does not
+// decode into the Kubernetes API type of its kind, such as a string where
+// the type has an integer or a field the type does not have.
+type TypedError struct {
+	// Document is the 1-based position of the document in the stream.
+	Document   int
+	APIVersion string
+	Kind       string
+	Err        error
+}
+
+func (e *TypedError) Error() string {
+	message := fmt.Sprintf("decoding %s %s", e.APIVersion, e.Kind)
+	if e.Document > 1 {
+		message += fmt.Sprintf(" in document %d", e.Document)
+	}
+	return fmt.Sprintf("%s: %v", message, e.Err)
+}
+
+func (e *TypedError) Unwrap() error {
+	return e.Err
+}
+
+// DeprecatedAPIError is returned when Options.FailDeprecated is set and a
+// document uses a deprecated or removed apiVersion for its kind.
+type DeprecatedAPIError struct {
+	// Document is the 1-based position of the document in the stream, and
+	// Line the line it starts on.
+	Document   int
+	Line       int
+	APIVersion string
+	Kind       string
+	// DeprecatedIn and RemovedIn are the Kubernetes releases that deprecate
+	// and remove the apiVersion, such as 1.22.
+	DeprecatedIn string
+	RemovedIn    string
+	// Replacement is the apiVersion to use instead, or "" when there is
+	// none.
+	Replacement string
+}
+
+func (e *DeprecatedAPIError) Error() string {
+	message := e.message()
+	if e.Document > 1 {
+		message += fmt.Sprintf(" (document %d)", e.Document)
+	}
+	return message
+}
+
+// message describes the deprecation without the position of the document,
+// as a warning reports it.
+func (e *DeprecatedAPIError) message() string {
+	message := fmt.Sprintf("%s %s is deprecated since Kubernetes %s and removed in %s", e.APIVersion, e.Kind, e.DeprecatedIn, e.RemovedIn)
+	if e.Replacement == "" {
+		return message + ", with no replacement"
+	}
+	return fmt.Sprintf("%s; use %s instead", message, e.Replacement)
+}
+
+// IOError is returned when reading the input or writing the output fails.
+type IOError struct {
+	// Op is the failed operation, "read" or "write".
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e *IOError) Error() string {
+	return fmt.Sprintf("error %s %s: %v", ioVerbs[e.Op], e.Path, e.Err)
+}
+
+func (e *IOError) Unwrap() error {
+	return e.Err
+}
+
+var ioVerbs = map[string]string{
+	"read":  "reading",
+	"write": "writing",
+}
+
+// SizeLimitError is returned when the input is larger than Options.MaxSize.
+type SizeLimitError struct {
+	// Limit is Options.MaxSize.
+	Limit int64
+}
+
+func (e *SizeLimitError) Error() string {
+	return fmt.Sprintf("input is larger than the limit of %d bytes", e.Limit)
+}
+
+// DecompressError is returned when gzip-compressed input is corrupt.
+type DecompressError struct {
+	Err error
+}
+
+func (e *DecompressError) Error() string {
+	return fmt.Sprintf("error decompressing gzip input: %v", e.Err)
+}
+
+func (e *DecompressError) Unwrap() error {
+	return e.Err
+}
+
+// TemplateError is returned when input that fails to parse contains Helm
+// template actions, which usually means it is an un-rendered Helm template.
+type TemplateError struct {
+	// Line is the first line with a template action outside a block scalar.
+	Line int
+	// Err is the error that parsing the input failed with.
+	Err error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("template action at line %d: %s", e.Line, e.Hint())
+}
+
+// Hint returns the advice for resolving the error, without its location.
+func (e *TemplateError) Hint() string {
+	return "this file appears to be an un-rendered Helm template; render it with `helm template` first"
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// MissingEnvError is returned when Options.EnvSubst is set and the input
+// refers to environment variables that are unset and have no default.
+type MissingEnvError struct {
+	// Variables lists the missing variables in sorted order.
+	Variables []string
+}
+
+func (e *MissingEnvError) Error() string {
+	return "undefined environment variables: " + strings.Join(e.Variables, ", ")
+}
+
+// ValuesError is returned when Options.Values is set and the input refers
+// to values that are not defined, or uses a mapping or sequence inside a
+// string.
+type ValuesError struct {
+	// Missing lists the undefined values, as dotted paths such as image.tag,
+	// in sorted order.
+	Missing []string
+	// NotScalar lists the mappings and sequences referred to inside a
+	// string, in sorted order.
+	NotScalar []string
+}
+
+func (e *ValuesError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "undefined values: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.NotScalar) > 0 {
+		parts = append(parts, "values used inside a string must be scalars: "+strings.Join(e.NotScalar, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// AliasError is returned when Options.NoAliases is set and a document uses a
+// YAML alias.
+type AliasError struct {
+	// Document is the 1-based position of the document in the stream.
+	Document int
+	// Line and Column locate the alias in the input.
+	Line   int
+	Column int
+	// Anchor is the name of the anchor the alias refers to.
+	Anchor string
+}
+
+func (e *AliasError) Error() string {
+	message := fmt.Sprintf("alias *%s at line %d, column %d is not allowed", e.Anchor, e.Line, e.Column)
+	if e.Document > 1 {
+		message += fmt.Sprintf(" in document %d", e.Document)
+	}
+	return message
+}
+
+// Detail returns the message describing the problem without its location.
+func (e *AliasError) Detail() string {
+	return fmt.Sprintf("alias *%s is not allowed", e.Anchor)
+}
+
+// AliasLimitError is returned when the aliases of a document expand past
+// Options.MaxAliasNodes or nest deeper than Options.MaxAliasDepth, as in a
+// "billion laughs" document built to exhaust memory.
+type AliasLimitError struct {
+	// Document is the 1-based position of the document in the stream.
+	Document int
+	// Line and Column locate the outermost alias being expanded when the
+	// node limit was reached, the alias nested too deeply when the depth
+	// limit was reached, or the node being decoded when the node limit was
+	// reached outside of any alias, in the values that merge keys share
+	// between mappings.
+	Line   int
+	Column int
+	// Anchor is the name of the anchor the alias refers to, or empty
+	// outside of any alias.
+	Anchor string
+	// Depth is set when the aliases nest too deeply, and Limit is the limit
+	// that was reached.
+	Depth bool
+	Limit int
+}
+
+func (e *AliasLimitError) Error() string {
+	var message string
+	switch {
+	case e.Anchor == "":
+		message = fmt.Sprintf("merge keys expand the document by more than %d nodes at line %d, column %d", e.Limit, e.Line, e.Column)
+	case e.Depth:
+		message = fmt.Sprintf("alias *%s at line %d, column %d nests aliases more than %d levels deep", e.Anchor, e.Line, e.Column, e.Limit)
+	default:
+		message = fmt.Sprintf("alias *%s at line %d, column %d expands the document by more than %d nodes", e.Anchor, e.Line, e.Column, e.Limit)
+	}
+	if e.Document > 1 {
+		message += fmt.Sprintf(" in document %d", e.Document)
+	}
+	return message
+}
+
+// Detail returns the message describing the problem without its location.
+func (e *AliasLimitError) Detail() string {
+	switch {
+	case e.Anchor == "":
+		return fmt.Sprintf("merge keys expand the document by more than %d nodes", e.Limit)
+	case e.Depth:
+		return fmt.Sprintf("alias *%s nests aliases more than %d levels deep", e.Anchor, e.Limit)
+	}
+	return fmt.Sprintf("alias *%s expands the document by more than %d nodes", e.Anchor, e.Limit)
+}
+
+// QueryError is returned when the path of Options.Query does not exist in a
+// document.
+type QueryError struct {
+	// Query is the query path as given.
+	Query string
+	// Document is the 1-based position of the document in the stream, or 0
+	// when not known.
+	Document int
+	// Path is the part of the query that was found, or "." for the document
+	// root, and Segment is the segment missing from it, such as ".image" or
+	// "[2]".
+	Path    string
+	Segment string
+	// Message describes the missing segment.
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	message := fmt.Sprintf("query %s: %s", e.Query, e.Message)
+	if e.Document > 1 {
+		message += fmt.Sprintf(" in document %d", e.Document)
+	}
+	return message
+}
+
+// PathNotFoundError is returned when Options.StrictPaths is set and a path
+// of Options.DeletePaths matches nothing in a document.
+type PathNotFoundError struct {
+	// Path is the delete path as given.
+	Path string
+	// Document is the 1-based position of the document in the stream, and
+	// Line the line it starts on.
+	Document int
+	Line     int
+}
+
+func (e *PathNotFoundError) Error() string {
+	message := fmt.Sprintf("delete path %s matches nothing", e.Path)
+	if e.Document > 1 {
+		message += fmt.Sprintf(" in document %d", e.Document)
+	}
+	return message
+}
+
+// SetFieldError is returned when a path of Options.SetFields cannot be set
+// in a document, such as an index past the end of an array.
+type SetFieldError struct {
+	// Path is the set path as given.
+	Path string
+	// Document is the 1-based position of the document in the stream, and
+	// Line the line it starts on.
+	Document int
+	Line     int
+	// Message describes why the path cannot be set.
+	Message string
+}
+
+func (e *SetFieldError) Error() string {
+	message := fmt.Sprintf("set %s: %s", e.Path, e.Message)
+	if e.Document > 1 {
+		message += fmt.Sprintf(" in document %d", e.Document)
+	}
+	return message
+}
+
+// MultiError is returned by Decode and DecodeStream when the input has more
+// than one problem, such as several documents that fail to parse or a
+// document breaking more than one validation, so that every problem can be
+// fixed in one pass. A single problem is returned as its own error.
+type MultiError struct {
+	// Errors are the problems found, ordered by the document they are in.
+	Errors []error
+	// Documents holds, for each error, the 1-based position of its document
+	// in the stream.
+	Documents []int
+}
+
+func (e *MultiError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwrap returns every error, so that errors.Is and errors.As find the
+// problems of each kind.
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// documentFilter selects documents by the filters of Options: Kinds,
+// Namespace, Name and Selector.
+type documentFilter struct {
+	kinds     []string
+	namespace string
+	name      string
+	selector  []labelRequirement
+}
+
+// newDocumentFilter returns the filter for opts, or nil when opts select
+// every document. Invalid name patterns and selectors are reported as errors.
+func newDocumentFilter(opts Options) (*documentFilter, error) {
+	if len(opts.Kinds) == 0 && opts.Namespace == "" && opts.Name == "" && opts.Selector == "" {
+		return nil, nil
+	}
+	filter := &documentFilter{kinds: opts.Kinds, namespace: opts.Namespace, name: opts.Name}
+	if opts.Name != "" {
+		if _, err := path.Match(opts.Name, ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %v", opts.Name, err)
+		}
+	}
+	if opts.Selector != "" {
+		selector, err := parseSelector(opts.Selector)
+		if err != nil {
+			return nil, err
+		}
+		filter.selector = selector
+	}
+	return filter, nil
+}
+
+// matches reports whether doc is selected by the filter. A nil filter
+// selects every document.
+func (f *documentFilter) matches(doc Document) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.kinds) > 0 && !matchesKind(doc.Kind(), f.kinds) {
+		return false
+	}
+	if f.namespace != "" && doc.Namespace() != f.namespace {
+		return false
+	}
+	if f.name != "" {
+		if ok, _ := path.Match(f.name, doc.Name()); !ok {
+			return false
+		}
+	}
+	if f.selector != nil {
+		// Documents without metadata match no selector
+		metadata, ok := doc.metadata().(*Object)
+		if !ok {
+			return false
+		}
+		labels, _ := field(metadata, "labels").(*Object)
+		for _, requirement := range f.selector {
+			if !requirement.matches(labels) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchesKind reports whether kind is one of kinds, ignoring case. A
+// document without a kind matches none.
+func matchesKind(kind string, kinds []string) bool {
+	if kind == "" {
+		return false
+	}
+	for _, k := range kinds {
+		if strings.EqualFold(kind, k) {
+			return true
+		}
+	}
+	return false
+}
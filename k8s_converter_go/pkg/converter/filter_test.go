@@ -0,0 +1,167 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const filterContent = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+metadata:
+  name: no-kind
+---
+apiVersion: apps/v1
+kind: deployment
+metadata:
+  name: api
+`
+
+func TestConvertKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "array",
+			opts: Options{Compact: true, Kinds: []string{"Deployment"}},
+			want: `[{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web"}},` +
+				`{"apiVersion":"apps/v1","kind":"deployment","metadata":{"name":"api"}}]`,
+		},
+		{
+			name: "single match",
+			opts: Options{Compact: true, Kinds: []string{"SERVICE"}},
+			want: `{"apiVersion":"v1","kind":"Service","metadata":{"name":"web"}}`,
+		},
+		{
+			name: "several kinds",
+			opts: Options{Format: FormatNDJSON, Kinds: []string{"service", "deployment"}},
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web"}}` + "\n" +
+				`{"apiVersion":"v1","kind":"Service","metadata":{"name":"web"}}` + "\n" +
+				`{"apiVersion":"apps/v1","kind":"deployment","metadata":{"name":"api"}}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(filterContent), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertKindsNoMatch(t *testing.T) {
+	opts := Options{Kinds: []string{"Deploymnet"}}
+	if _, err := Convert([]byte(filterContent), opts); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("Convert() error = %v, want ErrNoMatch", err)
+	}
+
+	opts.Format = FormatNDJSON
+	var buf bytes.Buffer
+	if err := ConvertStream(strings.NewReader(filterContent), &buf, opts); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("ConvertStream() error = %v, want ErrNoMatch", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("ConvertStream() wrote %q", buf.String())
+	}
+
+	if err := Validate([]byte(filterContent), opts); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
+
+const selectorContent = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-frontend
+  namespace: prod
+  labels:
+    app: web
+    tier: frontend
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-cache
+  namespace: prod
+  labels:
+    app: web
+    tier: cache
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web-frontend
+  namespace: prod
+  labels:
+    app: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-frontend
+  namespace: staging
+---
+apiVersion: v1
+kind: List
+items: []
+`
+
+func TestConvertFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"namespace", Options{Namespace: "prod"}, []string{"Deployment/web-frontend", "Deployment/web-cache", "Service/web-frontend"}},
+		{"name glob", Options{Name: "web-c*"}, []string{"Deployment/web-cache"}},
+		{"selector", Options{Selector: "app=web,tier!=cache"}, []string{"Deployment/web-frontend", "Service/web-frontend"}},
+		{"selector with kind", Options{Kinds: []string{"Deployment"}, Selector: "app=web,tier!=cache"}, []string{"Deployment/web-frontend"}},
+		{"set-based selector", Options{Selector: "tier in (cache, backend)"}, []string{"Deployment/web-cache"}},
+		{"notin matches missing labels", Options{Kinds: []string{"deployment"}, Selector: "tier notin (cache)"},
+			[]string{"Deployment/web-frontend", "Deployment/web-frontend"}},
+		{"missing metadata never matches", Options{Selector: "!app"}, []string{"Deployment/web-frontend"}},
+		{"all filters", Options{Kinds: []string{"Deployment"}, Namespace: "prod", Name: "web-*", Selector: "app"},
+			[]string{"Deployment/web-frontend", "Deployment/web-cache"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documents, err := Decode([]byte(selectorContent), tt.opts)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			var got []string
+			for _, doc := range documents {
+				got = append(got, doc.Kind()+"/"+doc.Name())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertFiltersInvalid(t *testing.T) {
+	for _, opts := range []Options{{Selector: "env in ("}, {Name: "web-["}} {
+		if _, err := Convert([]byte(selectorContent), opts); err == nil || errors.Is(err, ErrNoMatch) {
+			t.Errorf("Convert(%+v) error = %v, want invalid filter", opts, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package converter
+
+import "strings"
+
+// flatten returns v, an output document or query result, as a single object
+// mapping the path of every scalar under v to its value, for Options.Flatten.
+// Paths are query paths without the leading dot, such as
+// spec.containers[0].image, with fields holding dots quoted as in
+// metadata.labels["app.kubernetes.io/name"]. Empty objects and arrays are
+// kept as values so that the structure can be rebuilt from the paths.
+// Scalars, and empty objects and arrays, are returned unchanged.
+func flatten(v interface{}, opts Options) interface{} {
+	switch value := v.(type) {
+	case *Object:
+		if value.Len() == 0 {
+			return v
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			return v
+		}
+	default:
+		return v
+	}
+	flat := NewObject()
+	flattenInto(flat, "", v)
+	// Paths are sorted as strings, which their segments alone do not give
+	if opts.SortKeys || opts.Canonical {
+		sortKeys(flat)
+	}
+	return flat
+}
+
+// flattenInto adds the scalars under v, found at path, to flat.
+func flattenInto(flat *Object, path string, v interface{}) {
+	switch value := v.(type) {
+	case *Object:
+		if value.Len() > 0 {
+			for _, key := range value.keys {
+				flattenInto(flat, path+querySegment{field: key}.String(), value.values[key])
+			}
+			return
+		}
+	case []interface{}:
+		if len(value) > 0 {
+			for i, item := range value {
+				flattenInto(flat, path+querySegment{index: i, isIndex: true}.String(), item)
+			}
+			return
+		}
+	}
+	flat.Set(strings.TrimPrefix(path, "."), v)
+}
+
+// flattenValues returns values, flattened when opts.Flatten is set.
+func flattenValues(values []interface{}, opts Options) []interface{} {
+	if !opts.Flatten {
+		return values
+	}
+	flat := make([]interface{}, len(values))
+	for i, value := range values {
+		flat[i] = flatten(value, opts)
+	}
+	return flat
+}
@@ -0,0 +1,159 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unflatten rebuilds the value that flatten turned into flat.
+func unflatten(t *testing.T, flat *Object) interface{} {
+	t.Helper()
+	var root interface{}
+	for _, key := range flat.Keys() {
+		path := key
+		if !strings.HasPrefix(path, "[") {
+			path = "." + path
+		}
+		segments, err := parseQuery(path)
+		if err != nil {
+			t.Fatalf("parseQuery(%q) error = %v", path, err)
+		}
+		value, _ := flat.Get(key)
+		root = setPath(root, segments, value)
+	}
+	return root
+}
+
+// setPath returns v with value set at segments, creating the objects and
+// arrays on the way.
+func setPath(v interface{}, segments []querySegment, value interface{}) interface{} {
+	if len(segments) == 0 {
+		return value
+	}
+	segment := segments[0]
+	if segment.isIndex {
+		items, _ := v.([]interface{})
+		for len(items) <= segment.index {
+			items = append(items, nil)
+		}
+		items[segment.index] = setPath(items[segment.index], segments[1:], value)
+		return items
+	}
+	object, ok := v.(*Object)
+	if !ok {
+		object = NewObject()
+	}
+	member, _ := object.Get(segment.field)
+	object.Set(segment.field, setPath(member, segments[1:], value))
+	return object
+}
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+	}{
+		{
+			name:    "Nested objects and arrays",
+			content: "kind: Deployment\nspec:\n  replicas: 3\n  template:\n    spec:\n      containers:\n      - name: web\n        image: nginx:1.25\n        args: [--port, 80]\n",
+			want:    `{"kind":"Deployment","spec.replicas":3,"spec.template.spec.containers[0].name":"web","spec.template.spec.containers[0].image":"nginx:1.25","spec.template.spec.containers[0].args[0]":"--port","spec.template.spec.containers[0].args[1]":80}`,
+		},
+		{
+			name:    "Keys with dots and quotes",
+			content: "metadata:\n  labels:\n    app.kubernetes.io/name: web\n    'say \"hi\"': yes\n    '': empty\n",
+			want:    `{"metadata.labels[\"app.kubernetes.io/name\"]":"web","metadata.labels[\"say \\\"hi\\\"\"]":"yes","metadata.labels[\"\"]":"empty"}`,
+		},
+		{
+			name:    "Scalar types kept",
+			content: "a: true\nb: null\nc: 1.50\nd: \"1\"\n",
+			want:    `{"a":true,"b":null,"c":1.50,"d":"1"}`,
+		},
+		{
+			name:    "Empty objects and arrays kept",
+			content: "spec:\n  volumes:\n  - name: cache\n    emptyDir: {}\n  args: []\nstatus: {}\n",
+			want:    `{"spec.volumes[0].name":"cache","spec.volumes[0].emptyDir":{},"spec.args":[],"status":{}}`,
+		},
+		{
+			name:    "Array of flat objects for several documents",
+			content: "kind: Service\nmetadata:\n  name: web\n---\nkind: ConfigMap\ndata:\n  a: b\n",
+			want:    `[{"kind":"Service","metadata.name":"web"},{"kind":"ConfigMap","data.a":"b"}]`,
+		},
+		{
+			name:    "Sorted as strings",
+			content: "a:\n  x: 1\na-b: 2\n",
+			opts:    Options{SortKeys: true},
+			want:    `{"a-b":2,"a.x":1}`,
+		},
+		{
+			name:    "Query result",
+			content: "spec:\n  selector:\n    app: web\n    tier: front\n",
+			opts:    Options{Query: ".spec"},
+			want:    `{"selector.app":"web","selector.tier":"front"}`,
+		},
+		{
+			name:    "Scalar query result",
+			content: "spec:\n  replicas: 2\n",
+			opts:    Options{Query: ".spec.replicas"},
+			want:    `2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Flatten, tt.opts.Compact = true, true
+			got, err := Convert([]byte(tt.content), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+
+			var streamed bytes.Buffer
+			if err := ConvertStream(strings.NewReader(tt.content), &streamed, tt.opts); err != nil {
+				t.Fatalf("ConvertStream() error = %v", err)
+			}
+			if streamed.String() != tt.want {
+				t.Errorf("ConvertStream() = %s, want %s", streamed.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenInverse(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.yaml"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no testdata files: %v", err)
+	}
+	files = append(files, "")
+
+	for _, file := range files {
+		content := []byte("a.b:\n  '[0]': {c: [1, [2, {}], []]}\n  '': '\"x\\\\y\"'\nlist: [{}, null]\n")
+		if file != "" {
+			if content, err = os.ReadFile(file); err != nil {
+				t.Fatal(err)
+			}
+		}
+		documents, err := Decode(content, Options{})
+		if err != nil {
+			continue
+		}
+		for _, doc := range documents {
+			flat, ok := flatten(doc.Value, Options{}).(*Object)
+			if !ok {
+				continue
+			}
+			want, _ := json.Marshal(doc.Value)
+			got, _ := json.Marshal(unflatten(t, flat))
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s document %d: unflattened =\n%s\nwant:\n%s", file, doc.Index, got, want)
+			}
+		}
+	}
+}
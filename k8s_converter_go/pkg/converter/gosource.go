@@ -0,0 +1,144 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultGoPackage is the package clause of FormatGo output when
+// Options.GoPackage is empty.
+const DefaultGoPackage = "fixtures"
+
+// marshalGo writes values, the output values of documents, as gofmt-formatted
+// Go source for FormatGo: a package clause followed by a variable for each
+// document, named after its kind and metadata.name.
+func marshalGo(documents []Document, values []interface{}, opts Options) ([]byte, error) {
+	pkg := opts.GoPackage
+	if pkg == "" {
+		pkg = DefaultGoPackage
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid Go package name %q", pkg)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n", pkg)
+	names := make(map[string]bool)
+	for i, doc := range documents {
+		name := goVariableName(doc, names)
+		if values[i] == nil {
+			fmt.Fprintf(&buf, "\nvar %s interface{} = nil\n", name)
+			continue
+		}
+		fmt.Fprintf(&buf, "\nvar %s = ", name)
+		if err := writeGoLiteral(&buf, values[i]); err != nil {
+			return nil, &EncodeError{Format: "Go", Err: err}
+		}
+		buf.WriteByte('\n')
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, &EncodeError{Format: "Go", Err: err}
+	}
+	return source, nil
+}
+
+// writeGoLiteral writes v as a Go expression: objects as
+// map[string]interface{} literals in the order of their keys, arrays as
+// []interface{} literals, integers as int64 and other numbers as float64,
+// the types the Kubernetes unstructured packages expect.
+func writeGoLiteral(buf *bytes.Buffer, v interface{}) error {
+	switch value := v.(type) {
+	case *Object:
+		buf.WriteString("map[string]interface{}{")
+		for _, key := range value.keys {
+			fmt.Fprintf(buf, "\n%s: ", strconv.Quote(key))
+			if err := writeGoLiteral(buf, value.values[key]); err != nil {
+				return err
+			}
+			buf.WriteByte(',')
+		}
+		if value.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteString("[]interface{}{")
+		for _, item := range value {
+			buf.WriteByte('\n')
+			if err := writeGoLiteral(buf, item); err != nil {
+				return err
+			}
+			buf.WriteByte(',')
+		}
+		if len(value) > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('}')
+	case string:
+		buf.WriteString(strconv.Quote(value))
+	case json.Number:
+		buf.WriteString(goNumber(value))
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case nil:
+		buf.WriteString("nil")
+	default:
+		return fmt.Errorf("cannot write a value of type %T", v)
+	}
+	return nil
+}
+
+// goNumber returns the Go expression for a JSON number. Untyped float
+// constants already default to float64, while integers are converted
+// explicitly, to int64 when they fit and to float64 otherwise.
+func goNumber(n json.Number) string {
+	text := n.String()
+	if strings.ContainsAny(text, ".eE") {
+		return text
+	}
+	if _, err := strconv.ParseInt(text, 10, 64); err != nil {
+		return "float64(" + text + ")"
+	}
+	return "int64(" + text + ")"
+}
+
+// goVariableName returns the variable name for doc, in lower camel case from
+// its kind and metadata.name, such as deploymentWeb for a Deployment named
+// web, or documentN for the Nth document when they do not give a valid name.
+// names holds the names already used, and a repeated name gets a number.
+func goVariableName(doc Document, names map[string]bool) string {
+	words := strings.FieldsFunc(stringField(doc.Value, "kind")+" "+stringField(field(doc.Value, "metadata"), "name"), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var name strings.Builder
+	for i, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		if i == 0 {
+			name.WriteRune(unicode.ToLower(first))
+		} else {
+			name.WriteRune(unicode.ToUpper(first))
+		}
+		name.WriteString(word[size:])
+	}
+	base := name.String()
+	if !token.IsIdentifier(base) {
+		base = fmt.Sprintf("document%d", doc.Index)
+		if doc.Item > 0 {
+			base += fmt.Sprintf("Item%d", doc.Item)
+		}
+	}
+	unique := base
+	for n := 2; names[unique]; n++ {
+		unique = fmt.Sprintf("%s%d", base, n)
+	}
+	names[unique] = true
+	return unique
+}
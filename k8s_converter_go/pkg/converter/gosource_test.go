@@ -0,0 +1,190 @@
+package converter
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parseGoSource parses source generated for FormatGo, returning its package
+// name and the names of its variables in order.
+func parseGoSource(t *testing.T, source []byte) (string, []string) {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "fixtures.go", source, 0)
+	if err != nil {
+		t.Fatalf("parser.ParseFile() error = %v\n%s", err, source)
+	}
+	var names []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			t.Fatalf("unexpected declaration %T in\n%s", decl, source)
+		}
+		for _, spec := range gen.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				names = append(names, name.Name)
+			}
+		}
+	}
+	return file.Name.Name, names
+}
+
+func TestConvertGo(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		opts      Options
+		wantPkg   string
+		wantNames []string
+		// wantLines are lines the output must contain, without their
+		// indentation.
+		wantLines []string
+	}{
+		{
+			name:      "Named after kind and name",
+			content:   "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web-app\nspec:\n  replicas: 3\n",
+			wantPkg:   DefaultGoPackage,
+			wantNames: []string{"deploymentWebApp"},
+			wantLines: []string{
+				"var deploymentWebApp = map[string]interface{}{",
+				`"apiVersion": "apps/v1",`,
+				`"replicas": int64(3),`,
+			},
+		},
+		{
+			name:      "Repeated names numbered",
+			content:   "kind: Service\nmetadata:\n  name: web\n---\nkind: Service\nmetadata:\n  name: web\n---\nkind: ConfigMap\nmetadata:\n  name: web.config\n",
+			wantPkg:   DefaultGoPackage,
+			wantNames: []string{"serviceWeb", "serviceWeb2", "configMapWebConfig"},
+		},
+		{
+			name:      "Document number without kind or name",
+			content:   "a: 1\n---\nkind: '123'\n---\nmetadata:\n  name: '-'\n",
+			wantPkg:   DefaultGoPackage,
+			wantNames: []string{"document1", "document2", "document3"},
+		},
+		{
+			name:      "Go keyword falls back to document number",
+			content:   "kind: type\n",
+			wantPkg:   DefaultGoPackage,
+			wantNames: []string{"document1"},
+		},
+		{
+			name:      "Scalar types",
+			content:   "int: -7\nfloat: 1.5\nexp: 1e3\nbig: 99999999999999999999\nbool: false\nnull: null\nstring: \"a \\\"b\\\"\\n\"\nempty: {}\nlist: []\n",
+			wantPkg:   DefaultGoPackage,
+			wantNames: []string{"document1"},
+			wantLines: []string{
+				`"int":    int64(-7),`,
+				`"float":  1.5,`,
+				`"exp":    1e3,`,
+				`"big":    float64(99999999999999999999),`,
+				`"bool":   false,`,
+				`"null":   nil,`,
+				`"string": "a \"b\"\n",`,
+				`"empty":  map[string]interface{}{},`,
+				`"list":   []interface{}{},`,
+			},
+		},
+		{
+			name:      "Custom package",
+			content:   "kind: Namespace\nmetadata:\n  name: prod\n",
+			opts:      Options{GoPackage: "testdata_test"},
+			wantPkg:   "testdata_test",
+			wantNames: []string{"namespaceProd"},
+		},
+		{
+			name:      "List items",
+			content:   "kind: List\nitems:\n- kind: Pod\n  metadata:\n    name: a\n- kind: Pod\n  metadata:\n    name: b\n",
+			opts:      Options{ExplodeLists: true},
+			wantPkg:   DefaultGoPackage,
+			wantNames: []string{"podA", "podB"},
+		},
+		{
+			name:      "Query result",
+			content:   "kind: Service\nmetadata:\n  name: web\nspec:\n  ports:\n  - port: 80\n",
+			opts:      Options{Query: ".spec.ports[0]"},
+			wantPkg:   DefaultGoPackage,
+			wantNames: []string{"serviceWeb"},
+			wantLines: []string{`"port": int64(80),`},
+		},
+		{
+			name:      "Scalar and null query results",
+			content:   "kind: Service\nmetadata:\n  name: web\nspec:\n  type: null\n---\nkind: Service\nmetadata:\n  name: api\nspec:\n  type: NodePort\n",
+			opts:      Options{Query: ".spec.type"},
+			wantPkg:   DefaultGoPackage,
+			wantNames: []string{"serviceWeb", "serviceApi"},
+			wantLines: []string{
+				"var serviceWeb interface{} = nil",
+				`var serviceApi = "NodePort"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Format = FormatGo
+			got, err := Convert([]byte(tt.content), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			pkg, names := parseGoSource(t, got)
+			if pkg != tt.wantPkg {
+				t.Errorf("package = %q, want %q", pkg, tt.wantPkg)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("variables = %v, want %v", names, tt.wantNames)
+			}
+			lines := make(map[string]bool)
+			for _, line := range strings.Split(string(got), "\n") {
+				lines[strings.TrimSpace(line)] = true
+			}
+			for _, line := range tt.wantLines {
+				if !lines[line] {
+					t.Errorf("output has no line %q:\n%s", line, got)
+				}
+			}
+
+			var streamed bytes.Buffer
+			if err := ConvertStream(strings.NewReader(tt.content), &streamed, tt.opts); err != nil {
+				t.Fatalf("ConvertStream() error = %v", err)
+			}
+			if streamed.String() != string(got) {
+				t.Errorf("ConvertStream() = %s, want %s", streamed.String(), got)
+			}
+		})
+	}
+}
+
+func TestConvertGoTestdata(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.yaml"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no testdata files: %v", err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Convert(content, Options{Format: FormatGo})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			parseGoSource(t, got)
+		})
+	}
+}
+
+func TestConvertGoInvalidPackage(t *testing.T) {
+	for _, pkg := range []string{"my-fixtures", "func", "1st"} {
+		if _, err := Convert([]byte("kind: Service\n"), Options{Format: FormatGo, GoPackage: pkg}); err == nil {
+			t.Errorf("GoPackage %q: Convert() error = nil, want an error", pkg)
+		}
+	}
+}
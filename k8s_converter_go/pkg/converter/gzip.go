@@ -0,0 +1,76 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// gzipMagic is the header that starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decompress returns the decompressed content of data when it is a gzip
+// stream, and data unchanged otherwise. A *SizeLimitError is returned once
+// the decompressed content holds more than limit bytes, unless limit is zero.
+func decompress(data []byte, limit int64) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, &DecompressError{Err: err}
+	}
+	result, err := io.ReadAll(limitReader(reader, limit))
+	if err != nil {
+		return nil, decompressError(err)
+	}
+	return result, nil
+}
+
+// decompressError wraps an error reading a gzip stream in a
+// *DecompressError, unless it is the *SizeLimitError of an input that
+// decompresses past its limit.
+func decompressError(err error) error {
+	var limitErr *SizeLimitError
+	if errors.As(err, &limitErr) {
+		return err
+	}
+	return &DecompressError{Err: err}
+}
+
+// gzipReader decompresses a gzip stream, remembering the first decompression
+// error so that it can be reported instead of the error it causes in the YAML
+// decoder.
+type gzipReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (g *gzipReader) Read(p []byte) (int, error) {
+	n, err := g.reader.Read(p)
+	if err != nil && err != io.EOF && g.err == nil {
+		g.err = decompressError(err)
+	}
+	return n, err
+}
+
+// decompressReader returns a reader for the decompressed content of r when r
+// holds a gzip stream, and a reader for r unchanged otherwise. The returned
+// *gzipReader is nil when r is not compressed. Reading fails with a
+// *SizeLimitError once the decompressed content holds more than limit bytes,
+// unless limit is zero, so that a small stream cannot expand without bound.
+func decompressReader(r io.Reader, limit int64) (io.Reader, *gzipReader, error) {
+	buffered := bufio.NewReader(r)
+	header, _ := buffered.Peek(len(gzipMagic))
+	if !bytes.Equal(header, gzipMagic) {
+		return buffered, nil, nil
+	}
+	reader, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, nil, &DecompressError{Err: err}
+	}
+	gz := &gzipReader{reader: limitReader(reader, limit)}
+	return gz, gz, nil
+}
@@ -0,0 +1,105 @@
+package converter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// gzipData compresses data with gzip.
+func gzipData(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestConvertGzip(t *testing.T) {
+	compressed := gzipData(t, "kind: A\n---\nkind: B\n")
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{name: "json", opts: Options{Compact: true}, want: `[{"kind":"A"},{"kind":"B"}]`},
+		{name: "ndjson", opts: Options{Format: FormatNDJSON}, want: "{\"kind\":\"A\"}\n{\"kind\":\"B\"}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(compressed, tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	got, err := Convert(gzipData(t, `{"kind": "A"}`), Options{Reverse: true})
+	if err != nil {
+		t.Fatalf("Convert() reverse error = %v", err)
+	}
+	if string(got) != "kind: A\n" {
+		t.Errorf("Convert() reverse = %q, want %q", got, "kind: A\n")
+	}
+}
+
+func TestConvertCorruptGzip(t *testing.T) {
+	compressed := gzipData(t, "kind: Deployment\nmetadata:\n  name: web\n")
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "truncated", data: compressed[:len(compressed)-6]},
+		{name: "bad header", data: append([]byte{0x1f, 0x8b}, "not gzip"...)},
+	}
+	for _, tt := range tests {
+		for _, format := range []string{FormatJSON, FormatNDJSON} {
+			t.Run(tt.name+" "+format, func(t *testing.T) {
+				_, err := Convert(tt.data, Options{Format: format})
+				var decompressErr *DecompressError
+				if !errors.As(err, &decompressErr) {
+					t.Errorf("Convert() error = %v, want *DecompressError", err)
+				}
+			})
+		}
+	}
+}
+
+func TestConvertGzipMaxSize(t *testing.T) {
+	// A few hundred bytes compressed, 64KiB once decompressed
+	compressed := gzipData(t, "kind: ConfigMap\ndata:\n  a: "+strings.Repeat("a", 64<<10)+"\n")
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "json", opts: Options{MaxSize: 1024}},
+		{name: "ndjson", opts: Options{Format: FormatNDJSON, MaxSize: 1024}},
+		{name: "envsubst", opts: Options{EnvSubst: true, MaxSize: 1024}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert(compressed, tt.opts)
+			var limitErr *SizeLimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != 1024 {
+				t.Errorf("Convert() error = %v, want *SizeLimitError with limit 1024", err)
+			}
+		})
+	}
+
+	if _, err := Convert(compressed, Options{MaxSize: 128 << 10}); err != nil {
+		t.Errorf("Convert() within the limit error = %v", err)
+	}
+}
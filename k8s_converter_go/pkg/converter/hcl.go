@@ -0,0 +1,176 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// hclIndent is the indentation of FormatHCL output, that of terraform fmt.
+const hclIndent = "  "
+
+// marshalHCL writes values, the output values of documents, as Terraform
+// configuration for FormatHCL: a kubernetes_manifest resource for each
+// document, named after its kind and metadata.name, with the value as its
+// manifest.
+func marshalHCL(documents []Document, values []interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	names := make(map[string]bool)
+	for i, doc := range values {
+		if _, ok := doc.(*Object); !ok {
+			return nil, &EncodeError{Format: "HCL", Err: fmt.Errorf("document %d has type %s, but a kubernetes_manifest needs an object", documents[i].Index, jsonType(doc))}
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "resource \"kubernetes_manifest\" %s {\n%smanifest = ", hclString(hclResourceName(documents[i], names)), hclIndent)
+		if err := writeHCLValue(&buf, doc, hclIndent); err != nil {
+			return nil, &EncodeError{Format: "HCL", Err: err}
+		}
+		buf.WriteString("\n}\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// writeHCLValue writes v as an HCL expression, with the lines after the first
+// indented by indent. Objects are written with quoted keys, in the order of
+// their keys, and the = of consecutive single-line attributes aligned as
+// terraform fmt does.
+func writeHCLValue(buf *bytes.Buffer, v interface{}, indent string) error {
+	inner := indent + hclIndent
+	switch value := v.(type) {
+	case *Object:
+		if value.Len() == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		keys := make([]string, len(value.keys))
+		rendered := make([]string, len(value.keys))
+		for i, key := range value.keys {
+			var member bytes.Buffer
+			if err := writeHCLValue(&member, value.values[key], inner); err != nil {
+				return err
+			}
+			keys[i], rendered[i] = hclString(key), member.String()
+		}
+		buf.WriteString("{\n")
+		for start := 0; start < len(keys); {
+			// Align a run of single-line attributes; a multi-line value is
+			// written on its own
+			end, width := start, 0
+			for end < len(keys) && !strings.Contains(rendered[end], "\n") {
+				if n := utf8.RuneCountInString(keys[end]); n > width {
+					width = n
+				}
+				end++
+			}
+			if end == start {
+				fmt.Fprintf(buf, "%s%s = %s\n", inner, keys[start], rendered[start])
+				start++
+				continue
+			}
+			for i := start; i < end; i++ {
+				padding := strings.Repeat(" ", width-utf8.RuneCountInString(keys[i]))
+				fmt.Fprintf(buf, "%s%s%s = %s\n", inner, keys[i], padding, rendered[i])
+			}
+			start = end
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		if len(value) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for _, item := range value {
+			buf.WriteString(inner)
+			if err := writeHCLValue(buf, item, inner); err != nil {
+				return err
+			}
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(indent + "]")
+	case string:
+		buf.WriteString(hclString(value))
+	case json.Number:
+		buf.WriteString(value.String())
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case nil:
+		buf.WriteString("null")
+	default:
+		return fmt.Errorf("cannot write a value of type %T", v)
+	}
+	return nil
+}
+
+// hclString returns s as a quoted HCL string. Besides the usual escapes, the
+// ${ and %{ sequences that would start a template interpolation or directive
+// are escaped as $${ and %%{, so that the string is taken literally.
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// hclResourceName returns the Terraform resource name for doc, the lowercase
+// kind and metadata.name joined by an underscore, such as deployment_web for
+// a Deployment named web, or document_N for the Nth document when it has
+// neither. Characters that Terraform does not allow in names become
+// underscores. names holds the names already used, and a repeated name gets
+// a number.
+func hclResourceName(doc Document, names map[string]bool) string {
+	var parts []string
+	for _, part := range []string{strings.ToLower(stringField(doc.Value, "kind")), stringField(field(doc.Value, "metadata"), "name")} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, strings.Join(parts, "_"))
+	switch {
+	case base == "":
+		base = fmt.Sprintf("document_%d", doc.Index)
+		if doc.Item > 0 {
+			base += fmt.Sprintf("_item_%d", doc.Item)
+		}
+	case base[0] == '-' || base[0] >= '0' && base[0] <= '9':
+		// Names must start with a letter or an underscore
+		base = "_" + base
+	}
+	unique := base
+	for n := 2; names[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", base, n)
+	}
+	names[unique] = true
+	return unique
+}
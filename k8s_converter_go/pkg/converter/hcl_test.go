@@ -0,0 +1,159 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConvertHCL(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+	}{
+		{
+			name:    "Nested objects and arrays",
+			content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n  labels:\n    app.kubernetes.io/name: web\ndata:\n  port: \"80\"\nlist:\n- 1\n- {a: true, bc: null}\n- []\nempty: {}\n",
+			want: `resource "kubernetes_manifest" "configmap_web" {
+  manifest = {
+    "apiVersion" = "v1"
+    "kind"       = "ConfigMap"
+    "metadata" = {
+      "name" = "web"
+      "labels" = {
+        "app.kubernetes.io/name" = "web"
+      }
+    }
+    "data" = {
+      "port" = "80"
+    }
+    "list" = [
+      1,
+      {
+        "a"  = true
+        "bc" = null
+      },
+      [],
+    ]
+    "empty" = {}
+  }
+}
+`,
+		},
+		{
+			name:    "Strings escaped",
+			content: "kind: Secret\nmetadata:\n  name: s\nstringData:\n  script: \"echo ${HOME} %{ if x }\\t\\\"q\\\" \\\\ $ %\\n\\x01\"\n",
+			want: `resource "kubernetes_manifest" "secret_s" {
+  manifest = {
+    "kind" = "Secret"
+    "metadata" = {
+      "name" = "s"
+    }
+    "stringData" = {
+      "script" = "echo $${HOME} %%{ if x }\t\"q\" \\ $ %\n\u0001"
+    }
+  }
+}
+`,
+		},
+		{
+			name:    "Scalar types",
+			content: "kind: A\nint: -7\nfloat: 1.50\nexp: 1e3\nbool: false\n",
+			want: `resource "kubernetes_manifest" "a" {
+  manifest = {
+    "kind"  = "A"
+    "int"   = -7
+    "float" = 1.50
+    "exp"   = 1e3
+    "bool"  = false
+  }
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Format = FormatHCL
+			got, err := Convert([]byte(tt.content), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+
+			var streamed bytes.Buffer
+			if err := ConvertStream(strings.NewReader(tt.content), &streamed, tt.opts); err != nil {
+				t.Fatalf("ConvertStream() error = %v", err)
+			}
+			if streamed.String() != tt.want {
+				t.Errorf("ConvertStream() = %s, want %s", streamed.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHCLResourceNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    []string
+	}{
+		{
+			name:    "Kind and name",
+			content: "kind: Deployment\nmetadata:\n  name: web-app\n---\nkind: ClusterRole\nmetadata:\n  name: system:controller\n",
+			want:    []string{"deployment_web-app", "clusterrole_system_controller"},
+		},
+		{
+			name:    "Repeated names numbered",
+			content: "kind: Service\nmetadata:\n  name: web\n  namespace: a\n---\nkind: Service\nmetadata:\n  name: web\n  namespace: b\n---\nkind: Service\nmetadata:\n  name: web_2\n",
+			want:    []string{"service_web", "service_web_2", "service_web_2_2"},
+		},
+		{
+			name:    "Document number without kind or name",
+			content: "a: 1\n---\nb: 2\n",
+			want:    []string{"document_1", "document_2"},
+		},
+		{
+			name:    "Leading digit",
+			content: "metadata:\n  name: 1st\n",
+			want:    []string{"_1st"},
+		},
+		{
+			name:    "List items",
+			content: "kind: List\nitems:\n- a: 1\n- kind: Pod\n  metadata:\n    name: b\n",
+			opts:    Options{ExplodeLists: true},
+			want:    []string{"document_1_item_1", "pod_b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Format = FormatHCL
+			got, err := Convert([]byte(tt.content), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			var names []string
+			for _, line := range strings.Split(string(got), "\n") {
+				if name, ok := strings.CutPrefix(line, `resource "kubernetes_manifest" "`); ok {
+					names = append(names, strings.TrimSuffix(name, `" {`))
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("resource names = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHCLNotObject(t *testing.T) {
+	_, err := Convert([]byte("kind: Service\nspec:\n  type: NodePort\n"), Options{Format: FormatHCL, Query: ".spec.type"})
+	if err == nil || !strings.Contains(err.Error(), "document 1 has type string, but a kubernetes_manifest needs an object") {
+		t.Errorf("Convert() error = %v, want a kubernetes_manifest error", err)
+	}
+}
@@ -0,0 +1,139 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// HelmPlaceholder replaces each Helm template action when
+// Options.HelmPlaceholders is set.
+const HelmPlaceholder = "HELM_TEMPLATE"
+
+// templateAction matches a Helm template action such as {{ .Values.image }}.
+var templateAction = regexp.MustCompile(`\{\{.*?\}\}`)
+
+// blockScalarStart matches a line whose value starts a literal or folded
+// block scalar, such as "script: |" or "- >-".
+var blockScalarStart = regexp.MustCompile(`(?:^\s*-|:)\s+[|>][-+1-9]*\s*(?:#.*)?$`)
+
+// templateScanner follows a YAML stream line by line, tracking block scalars,
+// where template braces are ordinary text and valid YAML.
+type templateScanner struct {
+	// line is the number of the last line scanned.
+	line int
+	// blockIndent is the indentation of the line that started the current
+	// block scalar, or -1 outside block scalars.
+	blockIndent int
+}
+
+func newTemplateScanner() *templateScanner {
+	return &templateScanner{blockIndent: -1}
+}
+
+// inBlockScalar scans the next line and reports whether it is part of the
+// content of a block scalar.
+func (s *templateScanner) inBlockScalar(text string) bool {
+	s.line++
+	trimmed := strings.TrimSpace(text)
+	indent := len(text) - len(strings.TrimLeft(text, " "))
+	if s.blockIndent >= 0 {
+		if trimmed == "" || indent > s.blockIndent {
+			return true
+		}
+		s.blockIndent = -1
+	}
+	if blockScalarStart.MatchString(strings.TrimRight(text, "\r\n")) {
+		s.blockIndent = indent
+	}
+	return false
+}
+
+// templateDetector is an io.Writer that records the first line written to it
+// with a template action outside a block scalar.
+type templateDetector struct {
+	scanner *templateScanner
+	partial []byte
+	// line is the first line with a template action, or 0 if none was seen.
+	line int
+}
+
+func (d *templateDetector) Write(p []byte) (int, error) {
+	if d.line > 0 {
+		return len(p), nil
+	}
+	d.partial = append(d.partial, p...)
+	for {
+		end := bytes.IndexByte(d.partial, '\n')
+		if end < 0 {
+			break
+		}
+		d.scan(string(d.partial[:end]))
+		d.partial = d.partial[end+1:]
+	}
+	return len(p), nil
+}
+
+// flush scans a final line with no trailing newline.
+func (d *templateDetector) flush() {
+	if len(d.partial) > 0 && d.line == 0 {
+		d.scan(string(d.partial))
+	}
+	d.partial = nil
+}
+
+func (d *templateDetector) scan(text string) {
+	if !d.scanner.inBlockScalar(text) && d.line == 0 && templateAction.MatchString(text) {
+		d.line = d.scanner.line
+	}
+}
+
+// replaceTemplateActions returns a reader for r with every Helm template
+// action outside block scalars replaced by HelmPlaceholder. Lines holding
+// nothing but template actions, such as {{- if .Values.enabled }}, are
+// emptied so that line numbers are preserved.
+func replaceTemplateActions(r io.Reader) io.Reader {
+	return &placeholderReader{reader: bufio.NewReader(r), scanner: newTemplateScanner()}
+}
+
+// placeholderReader replaces template actions one line at a time.
+type placeholderReader struct {
+	reader  *bufio.Reader
+	scanner *templateScanner
+	// pending is the rest of the current line after replacement.
+	pending string
+	err     error
+}
+
+func (p *placeholderReader) Read(b []byte) (int, error) {
+	for p.pending == "" {
+		if p.err != nil {
+			return 0, p.err
+		}
+		var text string
+		text, p.err = p.reader.ReadString('\n')
+		if text != "" && !p.scanner.inBlockScalar(text) {
+			text = replaceLineActions(text)
+		}
+		p.pending = text
+	}
+	n := copy(b, p.pending)
+	p.pending = p.pending[n:]
+	return n, nil
+}
+
+// replaceLineActions replaces the template actions in a single line.
+func replaceLineActions(text string) string {
+	if !templateAction.MatchString(text) {
+		return text
+	}
+	if strings.TrimSpace(templateAction.ReplaceAllString(text, "")) == "" {
+		if strings.HasSuffix(text, "\n") {
+			return "\n"
+		}
+		return ""
+	}
+	return templateAction.ReplaceAllLiteralString(text, HelmPlaceholder)
+}
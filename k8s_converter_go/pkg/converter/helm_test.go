@@ -0,0 +1,109 @@
+package converter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConvertHelmTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantLine int
+	}{
+		{
+			name:     "template value",
+			content:  "kind: Deployment\nspec:\n  image: {{ .Values.image }}\n",
+			wantLine: 3,
+		},
+		{
+			name:     "control structure",
+			content:  "{{- if .Values.enabled }}\nkind: Service\n{{- end }}\n",
+			wantLine: 1,
+		},
+		{
+			name: "block scalar before template",
+			content: "kind: ConfigMap\ndata:\n  script: |\n    echo {{ .Values.greeting }}\n" +
+				"  name: {{ .Release.Name }}\n",
+			wantLine: 5,
+		},
+		{
+			name:     "later document",
+			content:  "kind: A\n---\nkind: B\nmetadata:\n  labels: {{ .Values.labels }}\n",
+			wantLine: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, format := range []string{FormatJSON, FormatNDJSON} {
+				_, err := Convert([]byte(tt.content), Options{Format: format})
+				var templateErr *TemplateError
+				if !errors.As(err, &templateErr) {
+					t.Fatalf("Convert() %s error = %v, want *TemplateError", format, err)
+				}
+				if templateErr.Line != tt.wantLine {
+					t.Errorf("Convert() %s template line = %d, want %d", format, templateErr.Line, tt.wantLine)
+				}
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					t.Errorf("Convert() %s error does not wrap a *ParseError", format)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertBlockScalarTemplate(t *testing.T) {
+	// Braces inside block scalars are ordinary text and valid YAML
+	content := "kind: ConfigMap\ndata:\n  template: |-\n    Hello {{ .Name }}\n    {{- range .Items }}\n  other: >\n    {{ folded }}\n"
+	want := `{"kind":"ConfigMap","data":{"template":"Hello {{ .Name }}\n{{- range .Items }}","other":"{{ folded }}\n"}}`
+
+	for _, placeholders := range []bool{false, true} {
+		got, err := Convert([]byte(content), Options{Compact: true, HelmPlaceholders: placeholders})
+		if err != nil {
+			t.Fatalf("Convert() placeholders=%v error = %v", placeholders, err)
+		}
+		if string(got) != want {
+			t.Errorf("Convert() placeholders=%v = %s, want %s", placeholders, got, want)
+		}
+	}
+}
+
+func TestConvertHelmPlaceholders(t *testing.T) {
+	content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "app.fullname" . }}
+  labels:
+    {{- include "app.labels" . | nindent 4 }}
+    tier: web
+spec:
+  replicas: {{ .Values.replicas }}
+  template:
+    spec:
+      containers:
+        - name: app
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+          command:
+            - sh
+            - -c
+            - |
+              echo {{ .Values.message }}
+{{- if .Values.service.enabled }}
+---
+kind: Service
+{{- end }}
+`
+	want := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"HELM_TEMPLATE","labels":{"tier":"web"}},` +
+		`"spec":{"replicas":"HELM_TEMPLATE","template":{"spec":{"containers":[{"name":"app","image":"HELM_TEMPLATE:HELM_TEMPLATE",` +
+		`"command":["sh","-c","echo {{ .Values.message }}\n"]}]}}}}` + "\n" + `{"kind":"Service"}` + "\n"
+
+	got, err := Convert([]byte(content), Options{Format: FormatNDJSON, HelmPlaceholders: true})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Convert() = %s\nwant %s", got, want)
+	}
+}
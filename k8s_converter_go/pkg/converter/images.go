@@ -0,0 +1,167 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RuleImageReference is the rule for the image of a container: the Docker
+// reference grammar, an optional registry host and port, a repository of
+// lowercase path components, and an optional :tag and @digest.
+const RuleImageReference = "image reference"
+
+var (
+	imageDomainPattern     = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*|\[[0-9a-fA-F:]+\])(?::[0-9]+)?$`)
+	imageComponentPattern  = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	imageTagPattern        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestAlgorithmPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*$`)
+	digestHexPattern       = regexp.MustCompile(`^[0-9a-fA-F]{32,}$`)
+)
+
+// digestLengths are the lengths of the hex digests of the algorithms the
+// registries support.
+var digestLengths = map[string]int{"sha256": 64, "sha384": 96, "sha512": 128}
+
+// imageReference is an image reference split into its parts.
+type imageReference struct {
+	domain, repository, tag, digest string
+}
+
+// parseImageReference splits ref by the Docker reference grammar, returning
+// how it breaks the grammar as a message, or "" when it does not.
+func parseImageReference(ref string) (imageReference, string) {
+	var image imageReference
+	if ref == "" {
+		return image, "must not be empty"
+	}
+	name := ref
+	if at := strings.Index(name, "@"); at >= 0 {
+		name, image.digest = name[:at], name[at+1:]
+		algorithm, hex, ok := strings.Cut(image.digest, ":")
+		if !ok || !digestAlgorithmPattern.MatchString(algorithm) || !digestHexPattern.MatchString(hex) {
+			return image, fmt.Sprintf("digest %q must be an algorithm and hexadecimal characters, as in sha256:<64 hex digits>", image.digest)
+		}
+		if length, ok := digestLengths[algorithm]; ok && len(hex) != length {
+			return image, fmt.Sprintf("%s digest must be %d hexadecimal characters, not %d", algorithm, length, len(hex))
+		}
+	}
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		name, image.tag = name[:colon], name[colon+1:]
+		if !imageTagPattern.MatchString(image.tag) {
+			return image, fmt.Sprintf("tag %q must be at most 128 letters, digits, '_', '.' or '-', not starting with '.' or '-'", image.tag)
+		}
+		// The grammar allows them, but no registry tags a release so, and
+		// the kubelet would fail to pull it
+		if strings.Contains(image.tag, "..") {
+			return image, fmt.Sprintf("tag %q must not have consecutive dots", image.tag)
+		}
+	}
+	if len(name) > 255 {
+		return image, fmt.Sprintf("name must be no more than 255 characters, not %d", len(name))
+	}
+	// As docker does, the first component is the registry when it holds a
+	// '.' or ':', is localhost or has uppercase letters
+	image.repository = name
+	if slash := strings.Index(name, "/"); slash >= 0 {
+		first := name[:slash]
+		if strings.ContainsAny(first, ".:") || first == "localhost" || strings.ToLower(first) != first {
+			image.domain, image.repository = first, name[slash+1:]
+			if !imageDomainPattern.MatchString(image.domain) {
+				return image, fmt.Sprintf("registry %q must be a host name or IP address with an optional port", image.domain)
+			}
+		}
+	}
+	for _, component := range strings.Split(image.repository, "/") {
+		if !imageComponentPattern.MatchString(component) {
+			return image, fmt.Sprintf("repository component %q must be lowercase letters and digits separated by '.', '_', '__' or '-'", component)
+		}
+	}
+	return image, ""
+}
+
+// walkPodSpecs calls fn with every pod spec in v, a mapping with a
+// containers array, and its path. It walks the whole of v rather than the pod
+// templates of known kinds, so that it finds the pod specs of Pods,
+// workloads, CronJob job templates, PodTemplates and custom resources alike.
+func walkPodSpecs(v interface{}, path string, fn func(path string, spec *Object)) {
+	switch value := v.(type) {
+	case *Object:
+		if _, ok := field(value, "containers").([]interface{}); ok {
+			fn(path, value)
+			return
+		}
+		for _, key := range value.Keys() {
+			child, _ := value.Get(key)
+			walkPodSpecs(child, path+querySegment{field: key}.String(), fn)
+		}
+	case []interface{}:
+		for i, item := range value {
+			walkPodSpecs(item, path+"["+strconv.Itoa(i)+"]", fn)
+		}
+	}
+}
+
+// imageViolations returns the images of the containers of v, a document,
+// that break the Docker reference grammar or have a tag with consecutive
+// dots. Images with neither a tag nor a digest, which the kubelet pulls as
+// :latest, are reported as warnings. Documents
+// without a kind are not Kubernetes objects, and the items of list kinds are
+// checked instead of the list.
+func imageViolations(doc Document, v interface{}, opts Options) []NameViolation {
+	object, ok := v.(*Object)
+	kind := stringField(v, "kind")
+	if !ok || kind == "" {
+		return nil
+	}
+	if items, ok := listItems(object); ok {
+		var violations []NameViolation
+		for _, item := range items {
+			violations = append(violations, imageViolations(doc, item, opts)...)
+		}
+		return violations
+	}
+
+	var violations []NameViolation
+	walkPodSpecs(object, "", func(path string, spec *Object) {
+		for _, key := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			for i, container := range arrayField(spec, key) {
+				ref, ok := field(container, "image").(string)
+				if !ok {
+					continue
+				}
+				imagePath := strings.TrimPrefix(path+"."+key+"["+strconv.Itoa(i)+"].image", ".")
+				image, message := parseImageReference(ref)
+				switch {
+				case message != "":
+					violations = append(violations, NameViolation{
+						Document: doc.Index, Line: doc.Line, Kind: kind,
+						Field: imagePath, Value: ref, Rule: RuleImageReference, Message: message,
+					})
+				case image.tag == "" && image.digest == "":
+					opts.warn(Warning{Document: doc.Index, Line: doc.Line, Message: fmt.Sprintf(
+						"%s: %s %q has neither a tag nor a digest, so the latest tag is pulled", kind, imagePath, ref)})
+				}
+			}
+		}
+	})
+	return violations
+}
+
+// validateImages returns an *ImageError listing every container image of
+// documents that is not a valid image reference, when opts.ValidateImages is
+// set.
+func validateImages(documents []Document, opts Options) error {
+	if !opts.ValidateImages {
+		return nil
+	}
+	var violations []NameViolation
+	for _, doc := range documents {
+		violations = append(violations, imageViolations(doc, doc.Value, opts)...)
+	}
+	if len(violations) > 0 {
+		return &ImageError{Violations: violations}
+	}
+	return nil
+}
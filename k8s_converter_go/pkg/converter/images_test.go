@@ -0,0 +1,157 @@
+package converter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseImageReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	tests := []struct {
+		ref  string
+		want imageReference
+		err  string
+	}{
+		{ref: "nginx", want: imageReference{repository: "nginx"}},
+		{ref: "nginx:1.25", want: imageReference{repository: "nginx", tag: "1.25"}},
+		{ref: "library/nginx:1.25-alpine", want: imageReference{repository: "library/nginx", tag: "1.25-alpine"}},
+		{ref: "registry.local:5000/team/app:v2", want: imageReference{domain: "registry.local:5000", repository: "team/app", tag: "v2"}},
+		{ref: "localhost/app", want: imageReference{domain: "localhost", repository: "app"}},
+		{ref: "[::1]:5000/app", want: imageReference{domain: "[::1]:5000", repository: "app"}},
+		{ref: "ghcr.io/org/app@" + digest, want: imageReference{domain: "ghcr.io", repository: "org/app", digest: digest}},
+		{ref: "app:v1@" + digest, want: imageReference{repository: "app", tag: "v1", digest: digest}},
+		{ref: "my__app/web-server_1", want: imageReference{repository: "my__app/web-server_1"}},
+		{ref: "", err: "must not be empty"},
+		{ref: "registry.local/team/app@sha256:notahash", err: `digest "sha256:notahash" must be an algorithm and hexadecimal characters, as in sha256:<64 hex digits>`},
+		{ref: "app@sha256:" + strings.Repeat("a", 40), err: "sha256 digest must be 64 hexadecimal characters, not 40"},
+		{ref: "nginx:.25", err: `tag ".25" must be at most 128 letters, digits, '_', '.' or '-', not starting with '.' or '-'`},
+		{ref: "nginx:1..25", err: `tag "1..25" must not have consecutive dots`},
+		{ref: "nginx:", err: `tag "" must be at most 128 letters, digits, '_', '.' or '-', not starting with '.' or '-'`},
+		{ref: "Nginx:1.25", err: `repository component "Nginx" must be lowercase letters and digits separated by '.', '_', '__' or '-'`},
+		{ref: "registry.local//app", err: `repository component "" must be lowercase letters and digits separated by '.', '_', '__' or '-'`},
+		{ref: "team/app.:v1", err: `repository component "app." must be lowercase letters and digits separated by '.', '_', '__' or '-'`},
+		{ref: "registry-.local/app", err: `registry "registry-.local" must be a host name or IP address with an optional port`},
+		{ref: strings.Repeat("a", 256), err: "name must be no more than 255 characters, not 256"},
+	}
+	for _, tt := range tests {
+		got, err := parseImageReference(tt.ref)
+		if err != tt.err {
+			t.Errorf("parseImageReference(%q) error = %q, want %q", tt.ref, err, tt.err)
+			continue
+		}
+		if tt.err == "" && got != tt.want {
+			t.Errorf("parseImageReference(%q) = %+v, want %+v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeValidateImages(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		want         []NameViolation
+		wantWarnings []string
+	}{
+		{
+			name:    "Valid images",
+			content: "kind: Deployment\nmetadata:\n  name: web\nspec:\n  template:\n    spec:\n      initContainers:\n      - name: init\n        image: busybox:1.36\n      containers:\n      - name: web\n        image: registry.local:5000/shop/web:v2\n",
+		},
+		{
+			name: "Every container list",
+			content: "kind: Pod\nmetadata:\n  name: web\nspec:\n  initContainers:\n  - name: init\n    image: Busybox\n  containers:\n  - name: web\n    image: nginx:1.25\n" +
+				"  - name: sidecar\n    image: envoy:.1\n  ephemeralContainers:\n  - name: debug\n    image: busybox@sha256:abc\n",
+			want: []NameViolation{
+				{Document: 1, Line: 1, Kind: "Pod", Field: "spec.initContainers[0].image", Value: "Busybox", Rule: RuleImageReference,
+					Message: `repository component "Busybox" must be lowercase letters and digits separated by '.', '_', '__' or '-'`},
+				{Document: 1, Line: 1, Kind: "Pod", Field: "spec.containers[1].image", Value: "envoy:.1", Rule: RuleImageReference,
+					Message: `tag ".1" must be at most 128 letters, digits, '_', '.' or '-', not starting with '.' or '-'`},
+				{Document: 1, Line: 1, Kind: "Pod", Field: "spec.ephemeralContainers[0].image", Value: "busybox@sha256:abc", Rule: RuleImageReference,
+					Message: `digest "sha256:abc" must be an algorithm and hexadecimal characters, as in sha256:<64 hex digits>`},
+			},
+		},
+		{
+			name:    "CronJob job template",
+			content: "kind: ConfigMap\nmetadata:\n  name: app\n---\nkind: CronJob\nmetadata:\n  name: backup\nspec:\n  jobTemplate:\n    spec:\n      template:\n        spec:\n          containers:\n          - name: dump\n            image: registry.local/team/app@sha256:notahash\n",
+			want: []NameViolation{{
+				Document: 2, Line: 5, Kind: "CronJob", Field: "spec.jobTemplate.spec.template.spec.containers[0].image", Value: "registry.local/team/app@sha256:notahash",
+				Rule: RuleImageReference, Message: `digest "sha256:notahash" must be an algorithm and hexadecimal characters, as in sha256:<64 hex digits>`,
+			}},
+		},
+		{
+			name:    "Pod spec of a custom resource",
+			content: "apiVersion: argoproj.io/v1alpha1\nkind: Rollout\nmetadata:\n  name: web\nspec:\n  template:\n    spec:\n      containers:\n      - name: web\n        image: web:v1:v2\n",
+			want: []NameViolation{{
+				Document: 1, Line: 1, Kind: "Rollout", Field: "spec.template.spec.containers[0].image", Value: "web:v1:v2", Rule: RuleImageReference,
+				Message: `repository component "web:v1" must be lowercase letters and digits separated by '.', '_', '__' or '-'`,
+			}},
+		},
+		{
+			name:    "List items",
+			content: "apiVersion: v1\nkind: List\nitems:\n- kind: Pod\n  metadata:\n    name: web\n  spec:\n    containers:\n    - name: web\n      image: WEB\n",
+			want: []NameViolation{{
+				Document: 1, Line: 1, Kind: "Pod", Field: "spec.containers[0].image", Value: "WEB", Rule: RuleImageReference,
+				Message: `repository component "WEB" must be lowercase letters and digits separated by '.', '_', '__' or '-'`,
+			}},
+		},
+		{
+			name:    "Untagged images warn",
+			content: "kind: Job\nmetadata:\n  name: migrate\nspec:\n  template:\n    spec:\n      containers:\n      - name: migrate\n        image: registry.local/migrate\n",
+			wantWarnings: []string{
+				`line 1: Job: spec.template.spec.containers[0].image "registry.local/migrate" has neither a tag nor a digest, so the latest tag is pulled`,
+			},
+		},
+		{
+			name:    "Tag with consecutive dots",
+			content: "kind: Job\nmetadata:\n  name: migrate\nspec:\n  template:\n    spec:\n      containers:\n      - name: web\n        image: nginx:1..25\n",
+			want: []NameViolation{{
+				Document: 1, Line: 1, Kind: "Job", Field: "spec.template.spec.containers[0].image", Value: "nginx:1..25", Rule: RuleImageReference,
+				Message: `tag "1..25" must not have consecutive dots`,
+			}},
+		},
+		{
+			name:    "Not a Kubernetes object",
+			content: "containers:\n- image: Anything\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			_, err := Decode([]byte(tt.content), Options{
+				ValidateImages: true,
+				Warn:           func(w Warning) { warnings = append(warnings, w.String()) },
+			})
+			if strings.Join(warnings, "\n") != strings.Join(tt.wantWarnings, "\n") {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				return
+			}
+			var imageErr *ImageError
+			if !errors.As(err, &imageErr) {
+				t.Fatalf("Decode() error = %v, want an *ImageError", err)
+			}
+			if !reflect.DeepEqual(imageErr.Violations, tt.want) {
+				t.Errorf("Violations = %+v, want %+v", imageErr.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageErrorMessage(t *testing.T) {
+	content := "kind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    image: Nginx:1.25\n"
+	_, err := Convert([]byte(content), Options{ValidateImages: true})
+	want := `invalid images: Pod: spec.containers[0].image "Nginx:1.25" is not a valid image reference: ` +
+		`repository component "Nginx" must be lowercase letters and digits separated by '.', '_', '__' or '-'`
+	if err == nil || err.Error() != want {
+		t.Errorf("Convert() error = %v, want %s", err, want)
+	}
+	if _, err := Convert([]byte(content), Options{}); err != nil {
+		t.Errorf("Convert() without ValidateImages error = %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package converter
+
+import "fmt"
+
+// Inventory is the documents decoded from one input and the name of the
+// input, for the inventory formats FormatCSV, FormatMarkdown and FormatDOT,
+// which describe the documents of one or more inputs rather than their
+// content.
+type Inventory struct {
+	// Source names the input in the source column.
+	Source string
+	// Documents are the documents decoded from the input.
+	Documents []Document
+}
+
+// MarshalInventory writes a single inventory of the documents of
+// inventories in opts.Format, FormatCSV, FormatMarkdown or FormatDOT.
+func MarshalInventory(inventories []Inventory, opts Options) ([]byte, error) {
+	switch opts.Format {
+	case FormatCSV:
+		return marshalCSV(inventories, opts)
+	case FormatMarkdown:
+		return marshalMarkdown(inventories), nil
+	case FormatDOT:
+		return marshalDOT(inventories), nil
+	}
+	return nil, fmt.Errorf("output format %q is not an inventory format", opts.Format)
+}
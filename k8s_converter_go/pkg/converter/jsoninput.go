@@ -0,0 +1,163 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// streamJSONDocuments calls fn for each document in data, a stream of JSON
+// values, for Options.JSONInput. Every value is a document, except that the
+// items of a top-level array are documents of their own, as in the output of
+// a multi-document conversion, and null values are empty documents. The input is checked with
+// encoding/json first, so that malformed JSON is reported as a *ParseError
+// at the line and column of the problem, and then parsed into the nodes the
+// same content would have in YAML, keeping the position of every value.
+func streamJSONDocuments(data []byte, opts Options, fn func(parsedDocument) error) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for values := 1; ; values++ {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return newJSONParseError(data, values, err)
+		}
+	}
+
+	parser := jsonParser{data: data}
+	index := 0
+	for parser.skipSpace(); parser.pos < len(parser.data); parser.skipSpace() {
+		node := parser.value()
+		items := []*yaml.Node{node}
+		if node.Kind == yaml.SequenceNode {
+			items = node.Content
+		}
+		for _, item := range items {
+			index++
+			if isNullNode(item) {
+				if err := emptyDocument(index, item.Line, opts, fn); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := fn(parsedDocument{node: item, index: index, nodes: countNodes(item)}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newJSONParseError returns the *ParseError for err, returned by
+// encoding/json while decoding the value at position document in data.
+func newJSONParseError(data []byte, document int, err error) *ParseError {
+	parseErr := &ParseError{Format: "JSON", Document: document, Err: err}
+	offset := len(data)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Offset > 0 && int(syntaxErr.Offset) <= len(data) {
+		// The offset is just past the offending character
+		_, size := utf8.DecodeLastRune(data[:syntaxErr.Offset])
+		offset = int(syntaxErr.Offset) - size
+	} else if errors.Is(err, io.ErrUnexpectedEOF) {
+		parseErr.Message = "unexpected end of JSON input"
+	}
+	prefix := data[:offset]
+	parseErr.Line = bytes.Count(prefix, []byte("\n")) + 1
+	parseErr.Column = utf8.RuneCount(prefix[bytes.LastIndexByte(prefix, '\n')+1:]) + 1
+	return parseErr
+}
+
+// jsonParser parses JSON that encoding/json has already accepted into YAML
+// nodes, tracking the line and column of every value.
+type jsonParser struct {
+	data []byte
+	pos  int
+	// line is the 0-based line of pos, and lineStart the offset it starts at.
+	line      int
+	lineStart int
+}
+
+// skipSpace moves past whitespace.
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case '\n':
+			p.line++
+			p.lineStart = p.pos + 1
+		case ' ', '\t', '\r':
+		default:
+			return
+		}
+		p.pos++
+	}
+}
+
+// value parses the value at pos, after any whitespace.
+func (p *jsonParser) value() *yaml.Node {
+	p.skipSpace()
+	node := &yaml.Node{
+		Line:   p.line + 1,
+		Column: utf8.RuneCount(p.data[p.lineStart:p.pos]) + 1,
+	}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		node.Kind, node.Tag, node.Style = yaml.MappingNode, "!!map", yaml.FlowStyle
+		p.pos++
+		for p.skipSpace(); p.data[p.pos] != '}'; p.skipSpace() {
+			key := p.value()
+			p.skipSpace()
+			p.pos++ // :
+			node.Content = append(node.Content, key, p.value())
+			if p.skipSpace(); p.data[p.pos] == ',' {
+				p.pos++
+			}
+		}
+		p.pos++
+	case c == '[':
+		node.Kind, node.Tag, node.Style = yaml.SequenceNode, "!!seq", yaml.FlowStyle
+		p.pos++
+		for p.skipSpace(); p.data[p.pos] != ']'; p.skipSpace() {
+			node.Content = append(node.Content, p.value())
+			if p.skipSpace(); p.data[p.pos] == ',' {
+				p.pos++
+			}
+		}
+		p.pos++
+	case c == '"':
+		start := p.pos
+		for p.pos++; p.data[p.pos] != '"'; p.pos++ {
+			if p.data[p.pos] == '\\' {
+				p.pos++
+			}
+		}
+		p.pos++
+		var text string
+		json.Unmarshal(p.data[start:p.pos], &text)
+		node.Kind, node.Tag, node.Style, node.Value = yaml.ScalarNode, "!!str", yaml.DoubleQuotedStyle, text
+	default:
+		start := p.pos
+		for p.pos < len(p.data) && strings.IndexByte("+-.0123456789eEtruefalsn", p.data[p.pos]) >= 0 {
+			p.pos++
+		}
+		literal := string(p.data[start:p.pos])
+		node.Kind, node.Value = yaml.ScalarNode, literal
+		switch {
+		case literal == "true" || literal == "false":
+			node.Tag = "!!bool"
+		case literal == "null":
+			node.Tag = "!!null"
+		case strings.ContainsAny(literal, ".eE"):
+			node.Tag = "!!float"
+		default:
+			node.Tag = "!!int"
+		}
+	}
+	return node
+}
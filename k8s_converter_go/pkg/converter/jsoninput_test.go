@@ -0,0 +1,164 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConvertJSONInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+		want  string
+	}{
+		{
+			name:  "Key order and numbers kept",
+			input: `{"kind": "Deployment", "spec": {"replicas": 3, "ratio": 1.0, "big": 123456789012345678901234567890}}`,
+			want:  `{"kind":"Deployment","spec":{"replicas":3,"ratio":1.0,"big":123456789012345678901234567890}}`,
+		},
+		{
+			name:  "Sorted and cleaned",
+			input: "{\n\t\"status\": {\"ready\": true},\n\t\"metadata\": {\"name\": \"web\", \"uid\": \"1234\"},\n\t\"kind\": \"Service\"\n}\n",
+			opts:  Options{SortKeys: true, Clean: true},
+			want:  `{"kind":"Service","metadata":{"name":"web"}}`,
+		},
+		{
+			name:  "Strings stay strings",
+			input: `{"enabled": "yes", "date": "2023-05-01", "merge": {"<<": "x"}, "empty": "", "null": null}`,
+			opts:  Options{YAML11Bools: true},
+			want:  `{"enabled":"yes","date":"2023-05-01","merge":{"\u003c\u003c":"x"},"empty":"","null":null}`,
+		},
+		{
+			name:  "Escapes",
+			input: `{"path": "a\/b", "emoji": "😀", "quote": "\"\\\né"}`,
+			want:  `{"path":"a/b","emoji":"😀","quote":"\"\\\né"}`,
+		},
+		{
+			name:  "Stream of values",
+			input: "{\"kind\": \"A\"}\n{\"kind\": \"B\"}\n",
+			opts:  Options{Separate: true},
+			want:  "{\"kind\":\"A\"}\n{\"kind\":\"B\"}",
+		},
+		{
+			name:  "Top-level array",
+			input: `[{"kind": "A"}, null, {"kind": "B"}]`,
+			want:  `[{"kind":"A"},{"kind":"B"}]`,
+		},
+		{
+			name:  "Filtered array",
+			input: `[{"kind": "Service"}, {"kind": "Deployment"}]`,
+			opts:  Options{Kinds: []string{"deployment"}},
+			want:  `{"kind":"Deployment"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.JSONInput, tt.opts.Compact = true, true
+			got, err := Convert([]byte(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertJSONInputGzip(t *testing.T) {
+	got, err := Convert(gzipData(t, `{"kind": "Service"}`), Options{JSONInput: true, Compact: true})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if string(got) != `{"kind":"Service"}` {
+		t.Errorf("Convert() = %s", got)
+	}
+}
+
+func TestJSONInputErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantLine     int
+		wantColumn   int
+		wantDocument int
+		wantMessage  string
+	}{
+		{
+			name:         "Invalid literal",
+			input:        "{\n  \"kind\": \"Service\",\n  \"spec\": tru\n}\n",
+			wantLine:     3,
+			wantColumn:   14,
+			wantDocument: 1,
+			wantMessage:  "invalid character '\\n' in literal true (expecting 'e')",
+		},
+		{
+			name:         "Trailing comma",
+			input:        `{"kind": "Service",}`,
+			wantLine:     1,
+			wantColumn:   20,
+			wantDocument: 1,
+			wantMessage:  "invalid character '}' looking for beginning of object key string",
+		},
+		{
+			name:         "Columns count characters",
+			input:        `{"é": x}`,
+			wantLine:     1,
+			wantColumn:   7,
+			wantDocument: 1,
+			wantMessage:  "invalid character 'x' looking for beginning of value",
+		},
+		{
+			name:         "Later value",
+			input:        "{\"kind\": \"A\"}\n{\"kind\" \"B\"}\n",
+			wantLine:     2,
+			wantColumn:   9,
+			wantDocument: 2,
+			wantMessage:  "invalid character '\"' after object key",
+		},
+		{
+			name:         "Unexpected end",
+			input:        "{\"kind\":\n  [1, 2",
+			wantLine:     2,
+			wantColumn:   8,
+			wantDocument: 1,
+			wantMessage:  "unexpected end of JSON input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert([]byte(tt.input), Options{JSONInput: true})
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Convert() error = %v, want *ParseError", err)
+			}
+			if parseErr.Format != "JSON" || parseErr.Line != tt.wantLine || parseErr.Column != tt.wantColumn || parseErr.Document != tt.wantDocument {
+				t.Errorf("ParseError = %s %d:%d document %d, want JSON %d:%d document %d",
+					parseErr.Format, parseErr.Line, parseErr.Column, parseErr.Document, tt.wantLine, tt.wantColumn, tt.wantDocument)
+			}
+			if parseErr.Detail() != tt.wantMessage {
+				t.Errorf("Detail() = %q, want %q", parseErr.Detail(), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestJSONInputLocations(t *testing.T) {
+	input := "{\n  \"kind\": \"ConfigMap\",\n  \"data\": {\"a\": \"1\",\n           \"a\": \"2\"}\n}\n"
+	var warnings []Warning
+	if _, err := Convert([]byte(input), Options{JSONInput: true, Warn: func(w Warning) { warnings = append(warnings, w) }}); err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].Line != 4 {
+		t.Errorf("warnings = %+v, want a duplicate key on line 4", warnings)
+	}
+
+	_, err := Convert([]byte("{\"kind\": \"A\"}\n  [\"x\"]\n"), Options{JSONInput: true})
+	if err == nil || !strings.Contains(err.Error(), "document 2 at line 2, column 4 is not a mapping") {
+		t.Errorf("Convert() error = %v", err)
+	}
+}
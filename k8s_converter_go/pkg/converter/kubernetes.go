@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MissingFields describes a document that lacks required Kubernetes fields.
+type MissingFields struct {
+	// Document is the 1-based position of the document in the stream.
+	Document int
+	// Line is the line the document starts on.
+	Line int
+	// Fields lists the missing fields, such as "metadata.name".
+	Fields []string
+}
+
+// missingKubernetesFields returns the required Kubernetes object fields that
+// are missing or empty in the document: apiVersion, kind, and metadata.name.
+// metadata.generateName is accepted in place of metadata.name, and list kinds
+// such as v1 List do not need a name.
+func missingKubernetesFields(doc parsedDocument) []string {
+	var missing []string
+	if scalarValue(doc.node, "apiVersion") == "" {
+		missing = append(missing, "apiVersion")
+	}
+	kind := scalarValue(doc.node, "kind")
+	if kind == "" {
+		missing = append(missing, "kind")
+	}
+	if !strings.HasSuffix(kind, "List") {
+		metadata := mappingValue(doc.node, "metadata")
+		if scalarValue(metadata, "name") == "" && scalarValue(metadata, "generateName") == "" {
+			missing = append(missing, "metadata.name")
+		}
+	}
+	return missing
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil when
+// node is not a mapping or does not contain key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalarValue returns the scalar value for key in a mapping node, or "" when
+// it is missing, null, or not a scalar.
+func scalarValue(node *yaml.Node, key string) string {
+	value := mappingValue(node, key)
+	if value == nil || value.Kind != yaml.ScalarNode || isNullNode(value) {
+		return ""
+	}
+	return value.Value
+}
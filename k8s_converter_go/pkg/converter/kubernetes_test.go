@@ -0,0 +1,75 @@
+package converter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestKubernetesStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []MissingFields
+	}{
+		{
+			name:    "Complete object",
+			content: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n",
+		},
+		{
+			name:    "Generate name",
+			content: "apiVersion: batch/v1\nkind: Job\nmetadata:\n  generateName: migrate-\n",
+		},
+		{
+			name:    "List without a name",
+			content: "apiVersion: v1\nkind: List\nitems: []\n",
+		},
+		{
+			name:    "Empty and comment-only documents are skipped",
+			content: "---\n# comment\n---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n---\n",
+		},
+		{
+			name:    "Missing every field",
+			content: "spec:\n  replicas: 3\n",
+			want:    []MissingFields{{Document: 1, Line: 1, Fields: []string{"apiVersion", "kind", "metadata.name"}}},
+		},
+		{
+			name:    "Empty values",
+			content: "apiVersion: \"\"\nkind: ~\nmetadata:\n  name: \"\"\n",
+			want:    []MissingFields{{Document: 1, Line: 1, Fields: []string{"apiVersion", "kind", "metadata.name"}}},
+		},
+		{
+			name:    "Problems in several documents",
+			content: "apiVersion: v1\nkind: Service\n---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n---\nkind: ConfigMap\nmetadata:\n  name: cfg\n",
+			want: []MissingFields{
+				{Document: 1, Line: 1, Fields: []string{"metadata.name"}},
+				{Document: 3, Line: 9, Fields: []string{"apiVersion"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate([]byte(tt.content), Options{KubernetesStrict: true})
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			var missingErr *MissingFieldsError
+			if !errors.As(err, &missingErr) {
+				t.Fatalf("Validate() error = %v, want MissingFieldsError", err)
+			}
+			if !reflect.DeepEqual(missingErr.Documents, tt.want) {
+				t.Errorf("MissingFieldsError.Documents = %v, want %v", missingErr.Documents, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubernetesStrictDisabled(t *testing.T) {
+	if err := Validate([]byte("spec:\n  replicas: 3\n"), Options{}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Rules for label and annotation keys and label values.
+const (
+	// RuleQualifiedName is the rule for label and annotation keys: a name of
+	// at most 63 alphanumeric characters, '-', '_' or '.', starting and
+	// ending with an alphanumeric character, with an optional DNS-1123
+	// subdomain prefix and a '/', as in app.kubernetes.io/name.
+	RuleQualifiedName = "qualified name"
+	// RuleLabelValue is the rule for label values: empty, or at most 63
+	// characters of the name charset of RuleQualifiedName.
+	RuleLabelValue = "label value"
+)
+
+// maxAnnotationsSize is the largest total size of the keys and values of the
+// annotations of an object that the API server accepts.
+const maxAnnotationsSize = 256 * 1024
+
+var qualifiedNamePattern = regexp.MustCompile(`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`)
+
+// checkQualifiedName returns how key breaks RuleQualifiedName, or "" when it
+// does not.
+func checkQualifiedName(key string) string {
+	name := key
+	if prefix, rest, ok := strings.Cut(key, "/"); ok {
+		if prefix == "" {
+			return "prefix must not be empty"
+		}
+		if message := checkName(prefix, RuleDNS1123Subdomain); message != "" {
+			return "prefix " + message
+		}
+		name = rest
+	}
+	switch {
+	case name == "":
+		return "name part must not be empty"
+	case len(name) > 63:
+		return fmt.Sprintf("name part must be no more than 63 characters, not %d", len(name))
+	case !qualifiedNamePattern.MatchString(name):
+		return "name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character"
+	}
+	return ""
+}
+
+// checkLabelValue returns how value breaks RuleLabelValue, or "" when it
+// does not.
+func checkLabelValue(value interface{}) string {
+	text, ok := value.(string)
+	switch {
+	case value == nil:
+		return ""
+	case !ok:
+		return "must be a string"
+	case len(text) > 63:
+		return fmt.Sprintf("must be no more than 63 characters, not %d", len(text))
+	case text != "" && !qualifiedNamePattern.MatchString(text):
+		return "must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character"
+	}
+	return ""
+}
+
+// labelChecker collects the label violations of an object of a document.
+type labelChecker struct {
+	doc        Document
+	kind       string
+	violations []NameViolation
+}
+
+func (c *labelChecker) add(field, value, rule, message string) {
+	if message != "" {
+		c.violations = append(c.violations, NameViolation{
+			Document: c.doc.Index, Line: c.doc.Line, Kind: c.kind,
+			Field: field, Value: value, Rule: rule, Message: message,
+		})
+	}
+}
+
+// checkLabels checks the keys and values of labels, a mapping at path.
+func (c *labelChecker) checkLabels(path string, labels interface{}) {
+	object, ok := labels.(*Object)
+	if !ok {
+		return
+	}
+	for _, key := range object.Keys() {
+		value, _ := object.Get(key)
+		keyPath := path + querySegment{field: key}.String()
+		c.add(keyPath, key, RuleQualifiedName, checkQualifiedName(key))
+		c.add(keyPath, labelValue(value), RuleLabelValue, checkLabelValue(value))
+	}
+}
+
+// checkAnnotations checks the keys of annotations, a mapping at path, and
+// returns their total size with their values.
+func (c *labelChecker) checkAnnotations(path string, annotations interface{}) int {
+	object, ok := annotations.(*Object)
+	if !ok {
+		return 0
+	}
+	size := 0
+	for _, key := range object.Keys() {
+		value, _ := object.Get(key)
+		c.add(path+querySegment{field: key}.String(), key, RuleQualifiedName, checkQualifiedName(key))
+		size += len(key) + len(labelValue(value))
+	}
+	return size
+}
+
+// checkSelector checks spec.selector at path: a label selector with
+// matchLabels and matchExpressions, or for Services and
+// ReplicationControllers a mapping of labels.
+func (c *labelChecker) checkSelector(path string, selector interface{}) {
+	_, hasLabels := field(selector, "matchLabels").(*Object)
+	expressions, hasExpressions := field(selector, "matchExpressions").([]interface{})
+	if !hasLabels && !hasExpressions {
+		c.checkLabels(path, selector)
+		return
+	}
+	c.checkLabels(path+".matchLabels", field(selector, "matchLabels"))
+	for i, expression := range expressions {
+		if key := stringField(expression, "key"); key != "" {
+			c.add(path+".matchExpressions["+strconv.Itoa(i)+"].key", key, RuleQualifiedName, checkQualifiedName(key))
+		}
+	}
+}
+
+// labelViolations returns the label and annotation keys and label values of
+// v, a document, that break the rules of the API server, in
+// metadata.labels, metadata.annotations, spec.selector and the labels and
+// annotations of pod templates. Annotations larger in total than the API
+// server accepts are reported as a warning. Documents without a kind are not
+// Kubernetes objects, and the items of list kinds are checked instead of the
+// list.
+func labelViolations(doc Document, v interface{}, opts Options) []NameViolation {
+	object, ok := v.(*Object)
+	kind := stringField(v, "kind")
+	if !ok || kind == "" {
+		return nil
+	}
+	if items, ok := listItems(object); ok {
+		var violations []NameViolation
+		for _, item := range items {
+			violations = append(violations, labelViolations(doc, item, opts)...)
+		}
+		return violations
+	}
+
+	c := &labelChecker{doc: doc, kind: kind}
+	metadata := field(object, "metadata")
+	c.checkLabels("metadata.labels", field(metadata, "labels"))
+	if size := c.checkAnnotations("metadata.annotations", field(metadata, "annotations")); size > maxAnnotationsSize {
+		opts.warn(Warning{Document: doc.Index, Line: doc.Line, Message: fmt.Sprintf(
+			"%s: metadata.annotations total %d bytes, more than the %d the API server accepts", kind, size, maxAnnotationsSize)})
+	}
+	if selector := field(field(object, "spec"), "selector"); selector != nil {
+		c.checkSelector("spec.selector", selector)
+	}
+	if path := podTemplatePaths[kind]; len(path) > 0 {
+		templateMetadata := field(podTemplate(object), "metadata")
+		templatePath := strings.Join(path, ".") + ".metadata"
+		c.checkLabels(templatePath+".labels", field(templateMetadata, "labels"))
+		c.checkAnnotations(templatePath+".annotations", field(templateMetadata, "annotations"))
+	}
+	return c.violations
+}
+
+// validateLabels returns a *LabelError listing every label and annotation
+// key and label value of documents that the API server would reject, when
+// opts.ValidateLabels is set.
+func validateLabels(documents []Document, opts Options) error {
+	if !opts.ValidateLabels {
+		return nil
+	}
+	var violations []NameViolation
+	for _, doc := range documents {
+		violations = append(violations, labelViolations(doc, doc.Value, opts)...)
+	}
+	if len(violations) > 0 {
+		return &LabelError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckQualifiedName(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"app", true},
+		{"app.kubernetes.io/name", true},
+		{"Team_Name.v2", true},
+		{"my team/app", false},
+		{"Example.com/app", false},
+		{"/app", false},
+		{"example.com/", false},
+		{"example.com/a/b", false},
+		{"-app", false},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+		{strings.Repeat("a", 253) + "/app", true},
+		{strings.Repeat("a", 254) + "/app", false},
+	}
+	for _, tt := range tests {
+		if message := checkQualifiedName(tt.key); (message == "") != tt.valid {
+			t.Errorf("checkQualifiedName(%q) = %q, want valid %v", tt.key, message, tt.valid)
+		}
+	}
+}
+
+func TestCheckLabelValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		valid bool
+	}{
+		{"", true},
+		{nil, true},
+		{"v1.2.3", true},
+		{"Web_App", true},
+		{"web app", false},
+		{"v1-", false},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+		{int64(2), false},
+		{true, false},
+	}
+	for _, tt := range tests {
+		if message := checkLabelValue(tt.value); (message == "") != tt.valid {
+			t.Errorf("checkLabelValue(%v) = %q, want valid %v", tt.value, message, tt.valid)
+		}
+	}
+}
+
+func TestDecodeValidateLabels(t *testing.T) {
+	const keyMessage = "name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character"
+	const valueMessage = "must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character"
+	tests := []struct {
+		name         string
+		content      string
+		want         []NameViolation
+		wantWarnings []string
+	}{
+		{
+			name: "Valid labels",
+			content: "kind: Deployment\nmetadata:\n  name: web\n  labels:\n    app.kubernetes.io/name: web\n    tier: \"\"\n" +
+				"  annotations:\n    example.com/notes: anything goes here\nspec:\n  selector:\n    matchLabels:\n      app: web\n" +
+				"    matchExpressions:\n    - key: tier\n      operator: Exists\n  template:\n    metadata:\n      labels:\n        app: web\n",
+		},
+		{
+			name:    "Every invalid key and value of metadata",
+			content: "kind: ConfigMap\nmetadata:\n  name: app\n  labels:\n    my team/app: web\n    version: 1\n    ok: web app\n  annotations:\n    Example.com/notes: x\n",
+			want: []NameViolation{
+				{Document: 1, Line: 1, Kind: "ConfigMap", Field: "metadata.labels.my team/app", Value: "my team/app", Rule: RuleQualifiedName,
+					Message: "prefix must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character"},
+				{Document: 1, Line: 1, Kind: "ConfigMap", Field: "metadata.labels.version", Value: "1", Rule: RuleLabelValue, Message: "must be a string"},
+				{Document: 1, Line: 1, Kind: "ConfigMap", Field: "metadata.labels.ok", Value: "web app", Rule: RuleLabelValue, Message: valueMessage},
+				{Document: 1, Line: 1, Kind: "ConfigMap", Field: `metadata.annotations["Example.com/notes"]`, Value: "Example.com/notes", Rule: RuleQualifiedName,
+					Message: "prefix must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character"},
+			},
+		},
+		{
+			name: "Selectors and pod templates",
+			content: "kind: Service\nmetadata:\n  name: web\nspec:\n  selector:\n    app_: web\n---\n" +
+				"kind: CronJob\nmetadata:\n  name: backup\nspec:\n  jobTemplate:\n    spec:\n      selector:\n        matchExpressions:\n        - key: -tier\n" +
+				"      template:\n        metadata:\n          labels:\n            run: " + strings.Repeat("r", 64) + "\n",
+			want: []NameViolation{
+				{Document: 1, Line: 1, Kind: "Service", Field: "spec.selector.app_", Value: "app_", Rule: RuleQualifiedName, Message: keyMessage},
+				{Document: 2, Line: 8, Kind: "CronJob", Field: "spec.jobTemplate.spec.template.metadata.labels.run", Value: strings.Repeat("r", 64), Rule: RuleLabelValue,
+					Message: "must be no more than 63 characters, not 64"},
+			},
+		},
+		{
+			name:    "Label selector of a workload",
+			content: "kind: Deployment\nmetadata:\n  name: web\nspec:\n  selector:\n    matchLabels:\n      app: web-\n    matchExpressions:\n    - key: a b\n      operator: Exists\n",
+			want: []NameViolation{
+				{Document: 1, Line: 1, Kind: "Deployment", Field: "spec.selector.matchLabels.app", Value: "web-", Rule: RuleLabelValue, Message: valueMessage},
+				{Document: 1, Line: 1, Kind: "Deployment", Field: "spec.selector.matchExpressions[0].key", Value: "a b", Rule: RuleQualifiedName, Message: keyMessage},
+			},
+		},
+		{
+			name:    "List items",
+			content: "apiVersion: v1\nkind: List\nitems:\n- kind: Pod\n  metadata:\n    name: web\n    labels:\n      " + strings.Repeat("k", 64) + ": web\n",
+			want: []NameViolation{{
+				Document: 1, Line: 1, Kind: "Pod", Field: "metadata.labels." + strings.Repeat("k", 64), Value: strings.Repeat("k", 64), Rule: RuleQualifiedName,
+				Message: "name part must be no more than 63 characters, not 64",
+			}},
+		},
+		{
+			name:         "Annotations over the size limit",
+			content:      "kind: ConfigMap\nmetadata:\n  name: big\n  annotations:\n    notes: " + strings.Repeat("x", maxAnnotationsSize) + "\n",
+			wantWarnings: []string{"line 1: ConfigMap: metadata.annotations total 262149 bytes, more than the 262144 the API server accepts"},
+		},
+		{
+			name:    "Not a Kubernetes object",
+			content: "metadata:\n  labels:\n    my team/app: web\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			_, err := Decode([]byte(tt.content), Options{
+				ValidateLabels: true,
+				Warn:           func(w Warning) { warnings = append(warnings, w.String()) },
+			})
+			if strings.Join(warnings, "\n") != strings.Join(tt.wantWarnings, "\n") {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				return
+			}
+			var labelErr *LabelError
+			if !errors.As(err, &labelErr) {
+				t.Fatalf("Decode() error = %v, want a *LabelError", err)
+			}
+			if !reflect.DeepEqual(labelErr.Violations, tt.want) {
+				t.Errorf("Violations = %+v, want %+v", labelErr.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelErrorMessage(t *testing.T) {
+	content := "kind: Pod\nmetadata:\n  name: ok\n---\nkind: Pod\nmetadata:\n  name: web\n  labels:\n    my team/app: web\n"
+	_, err := Convert([]byte(content), Options{ValidateLabels: true})
+	want := `invalid labels: Pod: metadata.labels.my team/app "my team/app" is not a valid qualified name: ` +
+		`prefix must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (document 2)`
+	if err == nil || err.Error() != want {
+		t.Errorf("Convert() error = %v, want %s", err, want)
+	}
+	if _, err := Convert([]byte(content), Options{}); err != nil {
+		t.Errorf("Convert() without ValidateLabels error = %v", err)
+	}
+}
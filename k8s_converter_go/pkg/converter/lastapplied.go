@@ -0,0 +1,36 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// lastAppliedAnnotation is the annotation in which kubectl apply records the
+// configuration it applied, as a JSON string.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// expandLastApplied replaces the lastAppliedAnnotation of value, the document
+// at position document starting on line, with the object its JSON describes,
+// for Options.ExpandLastApplied. An annotation that is not valid JSON is left
+// as it is with a warning, and one redacted by Options.RedactSecrets is left
+// silently.
+func expandLastApplied(document, line int, value interface{}, opts Options) {
+	annotations, ok := field(field(value, "metadata"), "annotations").(*Object)
+	if !ok {
+		return
+	}
+	text, ok := annotations.values[lastAppliedAnnotation].(string)
+	if !ok || text == RedactedValue {
+		return
+	}
+	if !json.Valid([]byte(text)) {
+		opts.warn(Warning{Document: document, Line: line, Message: fmt.Sprintf("annotation %s is not valid JSON; left as a string", lastAppliedAnnotation)})
+		return
+	}
+	expanded, err := decodeOrderedJSON([]byte(text), document)
+	if err != nil {
+		opts.warn(Warning{Document: document, Line: line, Message: fmt.Sprintf("annotation %s: %v; left as a string", lastAppliedAnnotation, err)})
+		return
+	}
+	annotations.values[lastAppliedAnnotation] = expanded
+}
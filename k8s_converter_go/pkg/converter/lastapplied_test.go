@@ -0,0 +1,65 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandLastApplied(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		opts        Options
+		want        string
+		wantWarning string
+	}{
+		{
+			name: "Annotation expanded",
+			content: "kind: Deployment\nmetadata:\n  name: web\n  annotations:\n    team: shop\n" +
+				"    kubectl.kubernetes.io/last-applied-configuration: |\n      {\"kind\":\"Deployment\",\"spec\":{\"replicas\":3,\"paused\":false}}\n",
+			want: `{"kind":"Deployment","metadata":{"name":"web","annotations":{"team":"shop",` +
+				`"kubectl.kubernetes.io/last-applied-configuration":{"kind":"Deployment","spec":{"replicas":3,"paused":false}}}}}`,
+		},
+		{
+			name:    "Other strings kept",
+			content: "kind: Deployment\nmetadata:\n  annotations:\n    note: '{\"a\":1}'\n",
+			want:    `{"kind":"Deployment","metadata":{"annotations":{"note":"{\"a\":1}"}}}`,
+		},
+		{
+			name:        "Invalid JSON kept",
+			content:     "kind: Service\n---\nkind: Deployment\nmetadata:\n  annotations:\n    kubectl.kubernetes.io/last-applied-configuration: '{\"kind\":'\n",
+			want:        `[{"kind":"Service"},{"kind":"Deployment","metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":"}}}]`,
+			wantWarning: "line 3: annotation kubectl.kubernetes.io/last-applied-configuration is not valid JSON; left as a string (document 2)",
+		},
+		{
+			name:    "Redacted Secret",
+			content: "kind: Secret\nmetadata:\n  annotations:\n    kubectl.kubernetes.io/last-applied-configuration: '{\"data\":{\"a\":\"b\"}}'\n",
+			opts:    Options{RedactSecrets: true},
+			want:    `{"kind":"Secret","metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"***REDACTED***"}}}`,
+		},
+		{
+			name:    "Sorted with the document",
+			content: "metadata:\n  annotations:\n    kubectl.kubernetes.io/last-applied-configuration: '{\"b\":1,\"a\":2}'\n",
+			opts:    Options{SortKeys: true},
+			want:    `{"metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":{"a":2,"b":1}}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			tt.opts.ExpandLastApplied, tt.opts.Compact = true, true
+			tt.opts.Warn = func(w Warning) { warnings = append(warnings, w.String()) }
+			got, err := Convert([]byte(tt.content), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+			if strings.Join(warnings, "\n") != tt.wantWarning {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarning)
+			}
+		})
+	}
+}
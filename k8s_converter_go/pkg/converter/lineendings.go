@@ -0,0 +1,72 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// utf8BOM is the UTF-8 encoding of the byte order mark U+FEFF, which editors
+// on Windows often write at the start of a file.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// lineReader normalizes the stream it reads as written on Windows: it turns
+// CRLF line endings into LF and removes UTF-8 byte order marks from the
+// start of every line.
+//
+// YAML reads every line break in a scalar as LF, but yaml.v3 attaches
+// comments differently when lines end with CRLF. YAML also allows a byte
+// order mark before each document, and files that each started with one
+// keep them when concatenated into a stream, but yaml.v3 only skips one at
+// the very start of the stream and reads any other as part of the first key
+// of its document. A byte order mark cannot appear inside a document, so
+// removing it at the start of any line never changes the content.
+type lineReader struct {
+	r *bufio.Reader
+	// line is the rest of the current line not yet returned, and lineStart
+	// whether the next byte read from r starts a line.
+	line      []byte
+	lineStart bool
+	// err is the error that ended the current line, returned once the line
+	// has been read.
+	err error
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReader(r), lineStart: true}
+}
+
+func (b *lineReader) Read(p []byte) (int, error) {
+	if len(b.line) == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		if b.lineStart {
+			if prefix, _ := b.r.Peek(len(utf8BOM)); bytes.Equal(prefix, utf8BOM) {
+				b.r.Discard(len(utf8BOM))
+			}
+		}
+		b.line, b.err = b.r.ReadSlice('\n')
+		// A line longer than the buffer is returned in parts
+		b.lineStart = b.err == nil
+		if b.err == bufio.ErrBufferFull {
+			b.err = nil
+		}
+		if len(b.line) == 0 {
+			return 0, b.err
+		}
+		// The line has been read from r, so its bytes can be changed
+		if n := len(b.line); b.lineStart && n >= 2 && b.line[n-2] == '\r' {
+			b.line[n-2] = '\n'
+			b.line = b.line[:n-1]
+		}
+	}
+	n := copy(p, b.line)
+	b.line = b.line[n:]
+	return n, nil
+}
+
+// trimBOM removes a UTF-8 byte order mark from the start of data.
+func trimBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, utf8BOM)
+}
@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLineReader(t *testing.T) {
+	bom := string(utf8BOM)
+	long := strings.Repeat("x", 5000)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "No byte order mark", input: "a: 1\nb: 2\n", want: "a: 1\nb: 2\n"},
+		{name: "Start of stream", input: bom + "a: 1\n", want: "a: 1\n"},
+		{name: "Start of a later line", input: "a: 1\n---\n" + bom + "b: 2\n", want: "a: 1\n---\nb: 2\n"},
+		{name: "CRLF", input: "a: 1\r\n\r\nb: 2\r\n", want: "a: 1\n\nb: 2\n"},
+		{name: "CRLF and byte order marks", input: bom + "a: 1\r\n---\r\n" + bom + "b: 2\r\n", want: "a: 1\n---\nb: 2\n"},
+		{name: "Carriage return inside a line", input: "a: \"x\ry\"\r\n", want: "a: \"x\ry\"\n"},
+		{name: "No final line ending", input: "a: 1\r", want: "a: 1\r"},
+		{name: "Inside a line", input: "a: x" + bom + "\n", want: "a: x" + bom + "\n"},
+		{name: "After a long line", input: long + "\n" + bom + "b: 2", want: long + "\nb: 2"},
+		{name: "Inside a long line", input: long + bom + "\n", want: long + bom + "\n"},
+		{name: "Only a byte order mark", input: bom, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(newLineReader(iotest.HalfReader(strings.NewReader(tt.input))))
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ReadAll() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineReaderError(t *testing.T) {
+	failure := errors.New("connection reset")
+	reader := newLineReader(io.MultiReader(strings.NewReader("a: 1\nb: 2"), iotest.ErrReader(failure)))
+	got, err := io.ReadAll(reader)
+	if !errors.Is(err, failure) || string(got) != "a: 1\nb: 2" {
+		t.Errorf("ReadAll() = %q, %v, want the data read and %v", got, err, failure)
+	}
+}
+
+func TestConvertLineEndings(t *testing.T) {
+	opts := Options{KeepComments: true}
+	lf, err := os.ReadFile(filepath.Join("testdata", "line-endings", "lf.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Convert(lf, opts)
+	if err != nil {
+		t.Fatalf("Convert(lf.yaml) error = %v", err)
+	}
+	if bytes.Contains(want, []byte(`\r`)) || !bytes.Contains(want, []byte(`"kept": "trailing newlines\n\n"`)) {
+		t.Fatalf("Convert(lf.yaml) = %s", want)
+	}
+
+	for _, name := range []string{"bom.yaml", "crlf.yaml", "bom-crlf.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("testdata", "line-endings", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Convert(input, opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Convert() =\n%s\nwant:\n%s", got, want)
+			}
+			var streamed bytes.Buffer
+			if err := ConvertStream(bytes.NewReader(input), &streamed, opts); err != nil {
+				t.Fatalf("ConvertStream() error = %v", err)
+			}
+			if !bytes.Equal(streamed.Bytes(), want) {
+				t.Errorf("ConvertStream() =\n%s\nwant:\n%s", streamed.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestJSONByteOrderMark(t *testing.T) {
+	input := string(utf8BOM) + "{\"kind\": \"A\"}\r\n" + string(utf8BOM) + "{\"kind\": \"B\"}\r\n"
+	got, err := Convert([]byte(input), Options{JSONInput: true, Separate: true, Compact: true})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if string(got) != "{\"kind\":\"A\"}\n{\"kind\":\"B\"}" {
+		t.Errorf("Convert() = %q", got)
+	}
+
+	yamlData, err := ConvertJSONToYAML([]byte(string(utf8BOM) + "{\"kind\": \"A\"}\r\n"))
+	if err != nil {
+		t.Fatalf("ConvertJSONToYAML() error = %v", err)
+	}
+	if string(yamlData) != "kind: A\n" {
+		t.Errorf("ConvertJSONToYAML() = %q", yamlData)
+	}
+}
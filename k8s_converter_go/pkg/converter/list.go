@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxListDepth is the deepest nesting of lists flattened into their items,
+// where a list nested deeper is kept as an item of its own.
+const maxListDepth = 32
+
+// NewList returns a v1 List with documents as its items, in order, the shape
+// kubectl uses for several objects. Documents that are themselves lists, such
+// as a v1 List or a DeploymentList, are replaced by their items rather than
+// nested, and so are the lists among their items.
+func NewList(documents []interface{}) *Object {
+	items := []interface{}{}
+	for _, doc := range documents {
+		if docItems, ok := listItems(doc); ok {
+			items = append(items, docItems...)
+			continue
+		}
+		items = append(items, doc)
+	}
+
+	list := NewObject()
+	list.Set("apiVersion", "v1")
+	list.Set("kind", "List")
+	list.Set("items", items)
+	return list
+}
+
+// listItems returns the items of a list document, one whose kind ends in
+// List and that has an items array, and whether v is one. The items of the
+// lists among its items are returned in their place, down to maxListDepth
+// levels of nesting.
+func listItems(v interface{}) ([]interface{}, bool) {
+	items, ok := listArray(v)
+	if !ok {
+		return nil, false
+	}
+	return flattenItems(items, 1), true
+}
+
+// flattenItems returns items, the items of a list nested depth levels deep,
+// with the lists among them replaced by their items.
+func flattenItems(items []interface{}, depth int) []interface{} {
+	flat := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if nested, ok := listArray(item); ok && depth < maxListDepth {
+			flat = append(flat, flattenItems(nested, depth+1)...)
+			continue
+		}
+		flat = append(flat, item)
+	}
+	return flat
+}
+
+// listArray returns the items array of a list document as it is, without
+// flattening the lists among its items, and whether v is a list document.
+func listArray(v interface{}) ([]interface{}, bool) {
+	if !strings.HasSuffix(stringField(v, "kind"), "List") {
+		return nil, false
+	}
+	items, ok := field(v, "items").([]interface{})
+	return items, ok
+}
+
+// listItem is a value decoded from a document, with the node it was decoded
+// from.
+type listItem struct {
+	node  *yaml.Node
+	value interface{}
+	// position is the 1-based position of the item among the items of an
+	// exploded list, or 0 for a document that is not a list item.
+	position int
+}
+
+// explodeList returns the items of a list document, decoded from doc as v,
+// flattening nested lists down to maxListDepth levels, so that each item can
+// be handled as a document of its own. The metadata of the lists is
+// discarded. A list with no items is reported as a warning. Other documents
+// are returned unchanged.
+func explodeList(doc parsedDocument, v interface{}, opts Options) []listItem {
+	var items []listItem
+	var walk func(node *yaml.Node, v interface{}, depth int)
+	walk = func(node *yaml.Node, v interface{}, depth int) {
+		values, ok := listArray(v)
+		if !ok || depth > maxListDepth {
+			items = append(items, listItem{node: node, value: v, position: len(items) + 1})
+			return
+		}
+		if len(values) == 0 {
+			opts.warn(Warning{Document: doc.index, Line: node.Line, Message: stringField(v, "kind") + " has no items"})
+		}
+		itemsNode := mappingValue(node, "items")
+		for i, value := range values {
+			itemNode := node
+			if itemsNode != nil && i < len(itemsNode.Content) {
+				itemNode = itemsNode.Content[i]
+				if itemNode.Kind == yaml.AliasNode && itemNode.Alias != nil {
+					itemNode = itemNode.Alias
+				}
+			}
+			walk(itemNode, value, depth+1)
+		}
+	}
+
+	if _, ok := listArray(v); !ok {
+		return []listItem{{node: doc.node, value: v}}
+	}
+	walk(doc.node, v, 1)
+	return items
+}
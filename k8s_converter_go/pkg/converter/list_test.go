@@ -0,0 +1,192 @@
+package converter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNewList(t *testing.T) {
+	documents, err := Decode([]byte(`kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: List
+items:
+  - kind: Service
+    metadata:
+      name: web
+  - kind: ConfigMap
+    metadata:
+      name: config
+---
+kind: Secret
+metadata:
+  name: creds
+`), Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	got, err := json.Marshal(NewList(Values(documents)))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"apiVersion":"v1","kind":"List","items":[{"kind":"Deployment","metadata":{"name":"web"}},` +
+		`{"kind":"Service","metadata":{"name":"web"}},{"kind":"ConfigMap","metadata":{"name":"config"}},` +
+		`{"kind":"Secret","metadata":{"name":"creds"}}]}`
+	if string(got) != want {
+		t.Errorf("NewList() = %s, want %s", got, want)
+	}
+
+	if got, _ := json.Marshal(NewList(nil)); string(got) != `{"apiVersion":"v1","kind":"List","items":[]}` {
+		t.Errorf("NewList(nil) = %s", got)
+	}
+}
+
+func TestNewListNested(t *testing.T) {
+	documents, err := Decode([]byte(`apiVersion: v1
+kind: List
+items:
+  - kind: Deployment
+    metadata:
+      name: web
+  - apiVersion: v1
+    kind: ServiceList
+    items:
+      - kind: Service
+        metadata:
+          name: web
+      - kind: List
+        items:
+          - kind: ConfigMap
+            metadata:
+              name: config
+`), Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	got, err := json.Marshal(NewList(Values(documents)))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"apiVersion":"v1","kind":"List","items":[{"kind":"Deployment","metadata":{"name":"web"}},` +
+		`{"kind":"Service","metadata":{"name":"web"}},{"kind":"ConfigMap","metadata":{"name":"config"}}]}`
+	if string(got) != want {
+		t.Errorf("NewList() = %s, want %s", got, want)
+	}
+
+	// Lists nested past maxListDepth are kept as items
+	var value interface{} = NewObject()
+	value.(*Object).Set("kind", "Pod")
+	for i := 0; i <= maxListDepth; i++ {
+		list := NewObject()
+		list.Set("kind", "List")
+		list.Set("items", []interface{}{value})
+		value = list
+	}
+	items := field(NewList([]interface{}{value}), "items").([]interface{})
+	if len(items) != 1 || stringField(items[0], "kind") != "List" {
+		t.Fatalf("NewList() of lists %d deep = %v, want the innermost list", maxListDepth+1, items)
+	}
+	if inner, _ := listArray(items[0]); len(inner) != 1 || stringField(inner[0], "kind") != "Pod" {
+		t.Errorf("NewList() of lists %d deep kept %v, want the list of the Pod", maxListDepth+1, inner)
+	}
+}
+
+const explodeContent = `apiVersion: v1
+kind: List
+metadata:
+  resourceVersion: "12345"
+items:
+  - apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: web
+  - apiVersion: v1
+    kind: ServiceList
+    items:
+      - apiVersion: v1
+        kind: Service
+        metadata:
+          name: web
+      - apiVersion: v1
+        kind: Service
+        metadata:
+          name: api
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+---
+apiVersion: v1
+kind: List
+items: []
+`
+
+func TestExplodeLists(t *testing.T) {
+	var warnings []Warning
+	opts := Options{ExplodeLists: true, Warn: func(w Warning) { warnings = append(warnings, w) }}
+	documents, err := Decode([]byte(explodeContent), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	type summary struct {
+		Index, Item, Line int
+		Kind, Name        string
+	}
+	var got []summary
+	for _, doc := range documents {
+		got = append(got, summary{doc.Index, doc.Item, doc.Line, doc.Kind(), doc.Name()})
+	}
+	want := []summary{
+		{1, 1, 6, "Deployment", "web"},
+		{1, 2, 13, "Service", "web"},
+		{1, 3, 17, "Service", "api"},
+		{2, 0, 22, "Secret", "creds"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %+v, want %+v", got, want)
+	}
+	wantWarnings := []Warning{{Document: 3, Line: 27, Message: "List has no items"}}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("warnings = %v, want %v", warnings, wantWarnings)
+	}
+}
+
+func TestConvertExplodeLists(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "array with filter",
+			opts: Options{Compact: true, ExplodeLists: true, Kinds: []string{"Service"}},
+			want: `[{"apiVersion":"v1","kind":"Service","metadata":{"name":"web"}},` +
+				`{"apiVersion":"v1","kind":"Service","metadata":{"name":"api"}}]`,
+		},
+		{
+			name: "NDJSON",
+			opts: Options{Format: FormatNDJSON, ExplodeLists: true, Name: "web"},
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web"}}` + "\n" +
+				`{"apiVersion":"v1","kind":"Service","metadata":{"name":"web"}}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(explodeContent), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
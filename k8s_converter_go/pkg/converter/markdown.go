@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// markdownRow is a row of a FormatMarkdown table.
+type markdownRow struct {
+	kind, namespace, name, source string
+}
+
+// marshalMarkdown writes inventories as a GitHub-flavored Markdown table for
+// FormatMarkdown, with a row for each document sorted by kind and then name,
+// followed by the number of documents.
+func marshalMarkdown(inventories []Inventory) []byte {
+	var rows []markdownRow
+	for _, inventory := range inventories {
+		for _, doc := range inventory.Documents {
+			metadata := field(doc.Value, "metadata")
+			rows = append(rows, markdownRow{
+				kind:      stringField(doc.Value, "kind"),
+				namespace: stringField(metadata, "namespace"),
+				name:      stringField(metadata, "name"),
+				source:    inventory.Source,
+			})
+		}
+	}
+	// Documents of the same kind and name keep their input order
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].kind != rows[j].kind {
+			return rows[i].kind < rows[j].kind
+		}
+		return rows[i].name < rows[j].name
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("| Kind | Namespace | Name | Source |\n| --- | --- | --- | --- |\n")
+	for _, row := range rows {
+		fmt.Fprintf(&buf, "| %s | %s | %s | %s |\n", markdownCell(row.kind), markdownCell(row.namespace), markdownCell(row.name), markdownCell(row.source))
+	}
+	if len(rows) == 1 {
+		buf.WriteString("\n1 document\n")
+	} else {
+		fmt.Fprintf(&buf, "\n%d documents\n", len(rows))
+	}
+	return buf.Bytes()
+}
+
+// markdownCellReplacer escapes the pipes that would end a table cell, the
+// backslashes that would escape them, and < so that names such as <stdin>
+// are not taken for HTML. Line breaks would end the row and become spaces.
+var markdownCellReplacer = strings.NewReplacer(`\`, `\\`, "|", `\|`, "<", "&lt;", "\r\n", " ", "\n", " ", "\r", " ")
+
+// markdownCell returns s escaped for a cell of a Markdown table.
+func markdownCell(s string) string {
+	return markdownCellReplacer.Replace(s)
+}
@@ -0,0 +1,94 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConvertMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+	}{
+		{
+			name:    "Sorted by kind and name",
+			content: "kind: Service\nmetadata:\n  name: web\n  namespace: prod\n---\nkind: Deployment\nmetadata:\n  name: web\n---\nkind: Service\nmetadata:\n  name: api\n",
+			opts:    Options{Source: "app.yaml"},
+			want: "| Kind | Namespace | Name | Source |\n| --- | --- | --- | --- |\n" +
+				"| Deployment |  | web | app.yaml |\n" +
+				"| Service |  | api | app.yaml |\n" +
+				"| Service | prod | web | app.yaml |\n" +
+				"\n3 documents\n",
+		},
+		{
+			name:    "Single document",
+			content: "kind: Namespace\nmetadata:\n  name: prod\n",
+			want:    "| Kind | Namespace | Name | Source |\n| --- | --- | --- | --- |\n| Namespace |  | prod |  |\n\n1 document\n",
+		},
+		{
+			name:    "Cells escaped",
+			content: "kind: A|B\nmetadata:\n  name: \"x\\\\|y\\nz\"\n",
+			opts:    Options{Source: "<stdin>"},
+			want:    "| Kind | Namespace | Name | Source |\n| --- | --- | --- | --- |\n| A\\|B |  | x\\\\\\|y z | &lt;stdin> |\n\n1 document\n",
+		},
+		{
+			name:    "Filtered",
+			content: "kind: Service\nmetadata:\n  name: web\n---\nkind: Deployment\nmetadata:\n  name: web\n",
+			opts:    Options{Kinds: []string{"Deployment"}},
+			want:    "| Kind | Namespace | Name | Source |\n| --- | --- | --- | --- |\n| Deployment |  | web |  |\n\n1 document\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Format = FormatMarkdown
+			got, err := Convert([]byte(tt.content), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %q, want %q", got, tt.want)
+			}
+
+			var streamed bytes.Buffer
+			if err := ConvertStream(strings.NewReader(tt.content), &streamed, tt.opts); err != nil {
+				t.Fatalf("ConvertStream() error = %v", err)
+			}
+			if streamed.String() != tt.want {
+				t.Errorf("ConvertStream() = %q, want %q", streamed.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalInventoryMarkdown(t *testing.T) {
+	var inventories []Inventory
+	for _, input := range []struct{ source, content string }{
+		{"web.yaml", "kind: Service\nmetadata:\n  name: web\n"},
+		{"api.yaml", "kind: Service\nmetadata:\n  name: api\n---\nkind: Service\nmetadata:\n  name: web\n"},
+	} {
+		documents, err := Decode([]byte(input.content), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		inventories = append(inventories, Inventory{Source: input.source, Documents: documents})
+	}
+	got, err := MarshalInventory(inventories, Options{Format: FormatMarkdown})
+	if err != nil {
+		t.Fatalf("MarshalInventory() error = %v", err)
+	}
+	want := "| Kind | Namespace | Name | Source |\n| --- | --- | --- | --- |\n" +
+		"| Service |  | api | api.yaml |\n" +
+		"| Service |  | web | web.yaml |\n" +
+		"| Service |  | web | api.yaml |\n" +
+		"\n3 documents\n"
+	if string(got) != want {
+		t.Errorf("MarshalInventory() = %q, want %q", got, want)
+	}
+	if _, err := MarshalInventory(inventories, Options{Format: FormatJSON}); err == nil {
+		t.Error("MarshalInventory() with FormatJSON error = nil, want an error")
+	}
+}
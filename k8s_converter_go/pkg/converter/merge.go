@@ -0,0 +1,182 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxMergeDepth is the deepest chain of merge keys expanded, where a merged
+// mapping itself merges another mapping, and so on.
+const maxMergeDepth = 32
+
+// resolveMerges expands the merge keys of every document in place. With
+// opts.NoAliases, documents that use aliases are rejected with an
+// *AliasError first.
+func resolveMerges(documents []parsedDocument, opts Options) error {
+	for _, doc := range documents {
+		if opts.NoAliases {
+			if alias := findAlias(doc.node); alias != nil {
+				return &AliasError{Document: doc.index, Line: alias.Line, Column: alias.Column, Anchor: alias.Value}
+			}
+		}
+		expander := mergeExpander{document: doc.index, expanded: make(map[*yaml.Node]int)}
+		if err := expander.walk(doc.node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findAlias returns the first alias node in the tree rooted at node, or nil.
+func findAlias(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode {
+		return node
+	}
+	for _, child := range node.Content {
+		if alias := findAlias(child); alias != nil {
+			return alias
+		}
+	}
+	return nil
+}
+
+// isMergeKey reports whether node is a << merge key.
+func isMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!merge"
+}
+
+// mergeExpander replaces merge keys with the pairs they merge in, using the
+// same rules as kubectl: the pairs of a mapping apply in order, so a key
+// written after a merge key overrides the merged value and a merge key
+// overrides the keys before it. When a merge key lists several mappings,
+// earlier mappings take precedence over later ones.
+type mergeExpander struct {
+	document int
+	// expanded records the length of the merge chain behind each mapping
+	// whose merge keys have been replaced, so that a mapping merged in
+	// several places is only expanded once.
+	expanded map[*yaml.Node]int
+}
+
+// walk expands every mapping in the tree rooted at node. Aliases are not
+// followed, since their targets appear earlier in the same tree.
+func (e *mergeExpander) walk(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		if _, err := e.expand(node, 0); err != nil {
+			return err
+		}
+	}
+	for _, child := range node.Content {
+		if child.Kind != yaml.AliasNode {
+			if err := e.walk(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expand replaces the merge keys of a single mapping, first expanding the
+// mappings it merges in, and returns the length of the longest chain of
+// merges behind it. depth is the number of merges being expanded that led to
+// node.
+func (e *mergeExpander) expand(node *yaml.Node, depth int) (int, error) {
+	if chain, ok := e.expanded[node]; ok {
+		return chain, nil
+	}
+	if depth > maxMergeDepth {
+		return 0, e.errorAt(node, fmt.Sprintf("merge keys are chained more than %d levels deep", maxMergeDepth))
+	}
+
+	var pairs []*yaml.Node
+	index := make(map[string]int)
+	set := func(key, value *yaml.Node) {
+		id := mergeKeyID(key)
+		if i, ok := index[id]; ok && id != "" {
+			pairs[i+1] = value
+			return
+		}
+		index[id] = len(pairs)
+		pairs = append(pairs, key, value)
+	}
+
+	chain := 0
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !isMergeKey(key) {
+			set(key, value)
+			continue
+		}
+		sources, err := e.mergeSources(value)
+		if err != nil {
+			return 0, err
+		}
+		// Apply later sources first so that earlier ones take precedence
+		for j := len(sources) - 1; j >= 0; j-- {
+			sourceChain, err := e.expand(sources[j], depth+1)
+			if err != nil {
+				return 0, err
+			}
+			if sourceChain+1 > maxMergeDepth {
+				return 0, e.errorAt(key, fmt.Sprintf("merge keys are chained more than %d levels deep", maxMergeDepth))
+			}
+			if sourceChain+1 > chain {
+				chain = sourceChain + 1
+			}
+			for k := 0; k+1 < len(sources[j].Content); k += 2 {
+				set(sources[j].Content[k], sources[j].Content[k+1])
+			}
+		}
+	}
+
+	node.Content = pairs
+	e.expanded[node] = chain
+	return chain, nil
+}
+
+// mergeSources returns the mappings merged in by the value of a merge key: a
+// mapping, an alias to one, or a sequence of them.
+func (e *mergeExpander) mergeSources(value *yaml.Node) ([]*yaml.Node, error) {
+	items := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		items = value.Content
+	}
+	sources := make([]*yaml.Node, 0, len(items))
+	for _, item := range items {
+		source := item
+		if source.Kind == yaml.AliasNode {
+			source = source.Alias
+		}
+		if source == nil || source.Kind != yaml.MappingNode {
+			return nil, e.errorAt(item, "merge key value must be a mapping or a sequence of mappings")
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// errorAt returns a *ParseError for a problem found at node.
+func (e *mergeExpander) errorAt(node *yaml.Node, message string) error {
+	return &ParseError{
+		Format:   "YAML",
+		Document: e.document,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  message,
+		Err:      errors.New(message),
+	}
+}
+
+// mergeKeyID identifies a mapping key when merging. Keys that are not
+// scalars return "" and never override one another.
+func mergeKeyID(key *yaml.Node) string {
+	if key.Kind == yaml.AliasNode && key.Alias != nil {
+		key = key.Alias
+	}
+	if key.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return key.ShortTag() + " " + key.Value
+}
@@ -0,0 +1,230 @@
+package converter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConvertMergeKeysMatchKubectl(t *testing.T) {
+	// testdata/merge.json is the output of sigs.k8s.io/yaml, the YAML to JSON
+	// conversion kubectl applies to manifests, for testdata/merge.yaml
+	input, err := os.ReadFile("testdata/merge.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read input: %v", err)
+	}
+	expected, err := os.ReadFile("testdata/merge.json")
+	if err != nil {
+		t.Fatalf("Failed to read expected output: %v", err)
+	}
+
+	got, err := Convert(input, Options{})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("Convert() produced invalid JSON: %v", err)
+	}
+	if err := json.Unmarshal(expected, &wantValue); err != nil {
+		t.Fatalf("Invalid expected JSON: %v", err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("Convert() = %s\nwant %s", got, expected)
+	}
+}
+
+func TestConvertMergeKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "later keys override merged ones",
+			content: "base: &base {a: 1, b: 1}\nkind: X\nm:\n  <<: *base\n  b: 2\n",
+			want:    `{"base":{"a":1,"b":1},"kind":"X","m":{"a":1,"b":2}}`,
+		},
+		{
+			name:    "merge overrides earlier keys",
+			content: "base: &base {a: 1, b: 1}\nkind: X\nm:\n  b: 2\n  <<: *base\n",
+			want:    `{"base":{"a":1,"b":1},"kind":"X","m":{"b":1,"a":1}}`,
+		},
+		{
+			name:    "earlier mappings in a list take precedence",
+			content: "x: &x {a: x}\ny: &y {a: y, b: y}\nkind: X\nm:\n  <<: [*x, *y]\n",
+			want:    `{"x":{"a":"x"},"y":{"a":"y","b":"y"},"kind":"X","m":{"a":"x","b":"y"}}`,
+		},
+		{
+			name:    "inline mapping",
+			content: "kind: X\nm:\n  <<: {a: 1}\n",
+			want:    `{"kind":"X","m":{"a":1}}`,
+		},
+		{
+			name:    "merged mapping is expanded before merging",
+			content: "one: &one {a: 1}\ntwo: &two {<<: *one, b: 2}\nkind: X\nm: {<<: *two, c: 3}\n",
+			want:    `{"one":{"a":1},"two":{"a":1,"b":2},"kind":"X","m":{"a":1,"b":2,"c":3}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(tt.content), Options{Compact: true})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertMergeErrors(t *testing.T) {
+	// Build a chain of anchors, each merging the previous one
+	var chain strings.Builder
+	chain.WriteString("kind: X\nm0: &m0 {a: 1}\n")
+	for i := 1; i <= maxMergeDepth+2; i++ {
+		fmt.Fprintf(&chain, "m%d: &m%d {<<: *m%d}\n", i, i, i-1)
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		wantMessage string
+	}{
+		{
+			name:        "scalar merge value",
+			content:     "kind: X\nvalue: &v text\nm:\n  <<: *v\n",
+			wantMessage: "merge key value must be a mapping or a sequence of mappings",
+		},
+		{
+			name:        "deep chain",
+			content:     chain.String(),
+			wantMessage: fmt.Sprintf("merge keys are chained more than %d levels deep", maxMergeDepth),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert([]byte(tt.content), Options{})
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Convert() error = %v, want *ParseError", err)
+			}
+			if parseErr.Message != tt.wantMessage {
+				t.Errorf("Convert() message = %q, want %q", parseErr.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestConvertNoAliases(t *testing.T) {
+	content := []byte("kind: X\nbase: &base {a: 1}\n---\nkind: Y\nlabels: &labels {a: 1}\ncopy: *labels\n")
+
+	_, err := Convert(content, Options{NoAliases: true})
+	var aliasErr *AliasError
+	if !errors.As(err, &aliasErr) {
+		t.Fatalf("Convert() error = %v, want *AliasError", err)
+	}
+	want := AliasError{Document: 2, Line: 6, Column: 7, Anchor: "labels"}
+	if *aliasErr != want {
+		t.Errorf("Convert() error = %+v, want %+v", *aliasErr, want)
+	}
+
+	// Anchors without aliases and inline merges are allowed
+	if _, err := Convert([]byte("kind: X\nbase: &base {a: 1}\nm: {<<: {a: 1}}\n"), Options{NoAliases: true}); err != nil {
+		t.Errorf("Convert() error = %v, want nil", err)
+	}
+}
+
+func TestConvertAliasBomb(t *testing.T) {
+	// testdata/alias-bomb/quadratic.yaml is about 14 KB and expands to over
+	// two million nodes, so it must be rejected long before it is expanded
+	input, err := os.ReadFile("testdata/alias-bomb/quadratic.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read input: %v", err)
+	}
+
+	start := time.Now()
+	_, err = Convert(input, Options{})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Convert() took %v to reject the document", elapsed)
+	}
+	var limitErr *AliasLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Convert() error = %v, want *AliasLimitError", err)
+	}
+	if limitErr.Anchor != "items" || limitErr.Line != 9 || limitErr.Depth || limitErr.Limit != DefaultMaxAliasNodes {
+		t.Errorf("Convert() error = %+v, want the node limit reached at an alias *items on line 9", *limitErr)
+	}
+}
+
+func TestConvertAliasLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+		detail  string
+	}{
+		{
+			name:    "node limit",
+			content: "kind: X\nbase: &base [a, b, c]\ncopies: [*base, *base, *base]\n",
+			opts:    Options{MaxAliasNodes: 8},
+			want:    "alias *base at line 3, column 24 expands the document by more than 8 nodes",
+			detail:  "alias *base expands the document by more than 8 nodes",
+		},
+		{
+			name:    "node limit through merge keys",
+			content: "kind: X\nbase: &base {a: [1, 2, 3]}\none: {<<: *base}\ntwo: {<<: *base}\n",
+			opts:    Options{MaxAliasNodes: 4},
+			want:    "merge keys expand the document by more than 4 nodes at line 2, column 21",
+			detail:  "merge keys expand the document by more than 4 nodes",
+		},
+		{
+			name:    "depth limit",
+			content: "kind: X\na: &a [x]\nb: &b [*a]\nc: &c [*b]\nd: [*c]\n",
+			opts:    Options{MaxAliasDepth: 2},
+			want:    "alias *a at line 3, column 8 nests aliases more than 2 levels deep",
+			detail:  "alias *a nests aliases more than 2 levels deep",
+		},
+		{
+			name:    "later document",
+			content: "kind: X\n---\nkind: Y\nbase: &base [a, b]\ncopies: [*base, *base]\n",
+			opts:    Options{MaxAliasNodes: 2},
+			want:    "alias *base at line 5, column 17 expands the document by more than 2 nodes in document 2",
+			detail:  "alias *base expands the document by more than 2 nodes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert([]byte(tt.content), tt.opts)
+			var limitErr *AliasLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Convert() error = %v, want *AliasLimitError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("Convert() error = %q, want %q", err, tt.want)
+			}
+			if got := limitErr.Detail(); got != tt.detail {
+				t.Errorf("Detail() = %q, want %q", got, tt.detail)
+			}
+		})
+	}
+
+	// A few anchors reused within the limits convert unchanged
+	got, err := Convert([]byte("kind: X\na: &a [x]\nb: &b [*a]\nc: [*b, *b]\n"), Options{Compact: true, MaxAliasNodes: 10, MaxAliasDepth: 2})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if want := `{"kind":"X","a":["x"],"b":[["x"]],"c":[[["x"]],[["x"]]]}`; string(got) != want {
+		t.Errorf("Convert() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyValue is a label or annotation added to every document by
+// Options.AddLabels and Options.AddAnnotations.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// addMetadata adds opts.AddLabels and opts.AddAnnotations to the
+// metadata.labels and metadata.annotations of a Kubernetes object, creating
+// metadata and the mappings when they are missing. Values already set are
+// kept unless opts.OverrideLabels is set. Documents without a kind are not
+// Kubernetes objects and are left untouched, and the entries of list kinds
+// such as v1 List are added to their items rather than the list.
+func addMetadata(index, line int, v interface{}, opts Options) {
+	object, ok := v.(*Object)
+	if !ok {
+		return
+	}
+	kind := stringField(object, "kind")
+	if kind == "" {
+		return
+	}
+	if strings.HasSuffix(kind, "List") {
+		if items, ok := field(object, "items").([]interface{}); ok {
+			for _, item := range items {
+				addMetadata(index, line, item, opts)
+			}
+			return
+		}
+	}
+	addMetadataEntries(index, line, object, "labels", opts.AddLabels, opts)
+	addMetadataEntries(index, line, object, "annotations", opts.AddAnnotations, opts)
+}
+
+// addMetadataEntries adds entries to the metadata mapping name of object.
+// A metadata or name field that is not a mapping is left as it is and
+// reported as a warning.
+func addMetadataEntries(index, line int, object *Object, name string, entries []KeyValue, opts Options) {
+	if len(entries) == 0 {
+		return
+	}
+	metadata, ok := childObject(object, "metadata")
+	if !ok {
+		opts.warn(Warning{Document: index, Line: line, Message: fmt.Sprintf("metadata is not a mapping, not adding %s", name)})
+		return
+	}
+	values, ok := childObject(metadata, name)
+	if !ok {
+		opts.warn(Warning{Document: index, Line: line, Message: fmt.Sprintf("metadata.%s is not a mapping, not adding %s", name, name)})
+		return
+	}
+	for _, entry := range entries {
+		if _, exists := values.Get(entry.Key); exists && !opts.OverrideLabels {
+			continue
+		}
+		values.Set(entry.Key, entry.Value)
+	}
+}
+
+// childObject returns the mapping at key in object, adding an empty one when
+// the key is missing or null. It reports false for a value of another type.
+func childObject(object *Object, key string) (*Object, bool) {
+	switch value := field(object, key).(type) {
+	case *Object:
+		return value, true
+	case nil:
+		child := NewObject()
+		object.Set(key, child)
+		return child, true
+	}
+	return nil, false
+}
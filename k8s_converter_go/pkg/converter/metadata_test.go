@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertAddMetadata(t *testing.T) {
+	labels := []KeyValue{{"app.kubernetes.io/managed-by", "pipeline"}, {"build", "42"}}
+	annotations := []KeyValue{{"example.com/query", "a=b=c"}}
+	tests := []struct {
+		name         string
+		content      string
+		override     bool
+		want         string
+		wantWarnings []string
+	}{
+		{
+			name:    "Metadata without labels or annotations",
+			content: "kind: Service\nmetadata:\n  name: web\n",
+			want: `{"kind":"Service","metadata":{"name":"web","labels":{"app.kubernetes.io/managed-by":"pipeline","build":"42"},` +
+				`"annotations":{"example.com/query":"a=b=c"}}}`,
+		},
+		{
+			name:    "Document without metadata",
+			content: "kind: Namespace\n",
+			want: `{"kind":"Namespace","metadata":{"labels":{"app.kubernetes.io/managed-by":"pipeline","build":"42"},` +
+				`"annotations":{"example.com/query":"a=b=c"}}}`,
+		},
+		{
+			name:    "Existing values are kept",
+			content: "kind: Service\nmetadata:\n  labels:\n    build: \"7\"\n    app: web\n  annotations: null\n",
+			want: `{"kind":"Service","metadata":{"labels":{"build":"7","app":"web","app.kubernetes.io/managed-by":"pipeline"},` +
+				`"annotations":{"example.com/query":"a=b=c"}}}`,
+		},
+		{
+			name:     "Existing values are overridden",
+			content:  "kind: Service\nmetadata:\n  labels:\n    build: \"7\"\n    app: web\n",
+			override: true,
+			want: `{"kind":"Service","metadata":{"labels":{"build":"42","app":"web","app.kubernetes.io/managed-by":"pipeline"},` +
+				`"annotations":{"example.com/query":"a=b=c"}}}`,
+		},
+		{
+			name:    "Not a Kubernetes object",
+			content: "replicas: 3\nimage: nginx\n",
+			want:    `{"replicas":3,"image":"nginx"}`,
+		},
+		{
+			name:    "List items",
+			content: "apiVersion: v1\nkind: List\nitems:\n- kind: Pod\n- name: not-an-object\n",
+			want: `{"apiVersion":"v1","kind":"List","items":[{"kind":"Pod","metadata":{"labels":{"app.kubernetes.io/managed-by":"pipeline","build":"42"},` +
+				`"annotations":{"example.com/query":"a=b=c"}}},{"name":"not-an-object"}]}`,
+		},
+		{
+			name:         "Labels that are not a mapping",
+			content:      "kind: Service\nmetadata:\n  labels: [app]\n",
+			want:         `{"kind":"Service","metadata":{"labels":["app"],"annotations":{"example.com/query":"a=b=c"}}}`,
+			wantWarnings: []string{"line 1: metadata.labels is not a mapping, not adding labels"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			opts := Options{
+				Compact:        true,
+				AddLabels:      labels,
+				AddAnnotations: annotations,
+				OverrideLabels: tt.override,
+				Warn:           func(w Warning) { warnings = append(warnings, w.String()) },
+			}
+			got, err := Convert([]byte(tt.content), opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(tt.wantWarnings, "\n") {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
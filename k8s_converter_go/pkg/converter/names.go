@@ -0,0 +1,186 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Rules for the names of Kubernetes objects, as the API server applies them.
+const (
+	// RuleDNS1123Subdomain is the rule for the names of most kinds: at most
+	// 253 lowercase alphanumeric characters, '-' or '.', starting and ending
+	// with an alphanumeric character.
+	RuleDNS1123Subdomain = "DNS-1123 subdomain"
+	// RuleDNS1123Label is the rule for namespaces and container names: at
+	// most 63 lowercase alphanumeric characters or '-', starting and ending
+	// with an alphanumeric character.
+	RuleDNS1123Label = "DNS-1123 label"
+	// RuleDNS1035Label is the rule for Service names: a DNS-1123 label that
+	// starts with a letter.
+	RuleDNS1035Label = "DNS-1035 label"
+	// RulePathSegment is the rule for RBAC names, such as
+	// system:controller:job-controller: anything but "." and "..", without
+	// '/' or '%'.
+	RulePathSegment = "path segment name"
+)
+
+var (
+	dns1123SubdomainPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	dns1123LabelPattern     = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	dns1035LabelPattern     = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// nameRules are the rules for metadata.name of the kinds that do not follow
+// RuleDNS1123Subdomain.
+var nameRules = map[string]string{
+	"Namespace":          RuleDNS1123Label,
+	"Service":            RuleDNS1035Label,
+	"Role":               RulePathSegment,
+	"RoleBinding":        RulePathSegment,
+	"ClusterRole":        RulePathSegment,
+	"ClusterRoleBinding": RulePathSegment,
+}
+
+// NameViolation is a name in a document that the API server would reject.
+type NameViolation struct {
+	// Document is the 1-based position of the document in the stream, and
+	// Line the line it starts on.
+	Document int
+	Line     int
+	// Kind is the kind of the object, which is an item of the document for
+	// list kinds.
+	Kind string
+	// Field is the path of the name, such as metadata.name or
+	// spec.template.spec.containers[0].name, and Value the name.
+	Field string
+	Value string
+	// Rule is the rule the name breaks, such as RuleDNS1123Subdomain, and
+	// Message how it breaks it.
+	Rule    string
+	Message string
+}
+
+func (v NameViolation) String() string {
+	message := fmt.Sprintf("%s: %s %q is not a valid %s: %s", v.Kind, v.Field, v.Value, v.Rule, v.Message)
+	if v.Document > 1 {
+		message += fmt.Sprintf(" (document %d)", v.Document)
+	}
+	return message
+}
+
+// checkName returns how value breaks rule, or "" when it does not.
+func checkName(value, rule string) string {
+	switch rule {
+	case RulePathSegment:
+		if value == "." || value == ".." {
+			return fmt.Sprintf("may not be %q", value)
+		}
+		if strings.ContainsAny(value, "/%") {
+			return "may not contain '/' or '%'"
+		}
+		return ""
+	case RuleDNS1123Subdomain:
+		if len(value) > 253 {
+			return fmt.Sprintf("must be no more than 253 characters, not %d", len(value))
+		}
+		if !dns1123SubdomainPattern.MatchString(value) {
+			return "must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character"
+		}
+		return ""
+	}
+	if len(value) > 63 {
+		return fmt.Sprintf("must be no more than 63 characters, not %d", len(value))
+	}
+	if rule == RuleDNS1035Label && !dns1035LabelPattern.MatchString(value) {
+		return "must consist of lower case alphanumeric characters or '-', start with an alphabetic character, and end with an alphanumeric character"
+	}
+	if rule == RuleDNS1123Label && !dns1123LabelPattern.MatchString(value) {
+		return "must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character"
+	}
+	return ""
+}
+
+// nameViolations returns the names of v, a document, that break the rules
+// of the API server: metadata.name by the rule of its kind, metadata.namespace
+// as a DNS-1123 label, and the names of the containers of workloads as
+// DNS-1123 labels. metadata.generateName is checked as a prefix, the API
+// server adding random characters after its trailing dash. Documents without
+// a kind are not Kubernetes objects, and the items of list kinds are checked
+// instead of the list.
+func nameViolations(doc Document, v interface{}) []NameViolation {
+	object, ok := v.(*Object)
+	kind := stringField(v, "kind")
+	if !ok || kind == "" {
+		return nil
+	}
+	if items, ok := listItems(object); ok {
+		var violations []NameViolation
+		for _, item := range items {
+			violations = append(violations, nameViolations(doc, item)...)
+		}
+		return violations
+	}
+
+	var violations []NameViolation
+	add := func(field, value, rule, message string) {
+		if message != "" {
+			violations = append(violations, NameViolation{
+				Document: doc.Index, Line: doc.Line, Kind: kind,
+				Field: field, Value: value, Rule: rule, Message: message,
+			})
+		}
+	}
+	rule := RuleDNS1123Subdomain
+	if kindRule, ok := nameRules[kind]; ok {
+		rule = kindRule
+	}
+	metadata := field(object, "metadata")
+	if name := stringField(metadata, "name"); name != "" {
+		add("metadata.name", name, rule, checkName(name, rule))
+	}
+	if prefix := stringField(metadata, "generateName"); prefix != "" {
+		// As the API server does, a trailing dash is checked as if it were
+		// followed by the generated characters
+		masked := prefix
+		if strings.HasSuffix(masked, "-") {
+			masked = masked[:len(masked)-1] + "a"
+		}
+		add("metadata.generateName", prefix, rule, checkName(masked, rule))
+	}
+	if namespace := stringField(metadata, "namespace"); namespace != "" {
+		add("metadata.namespace", namespace, RuleDNS1123Label, checkName(namespace, RuleDNS1123Label))
+	}
+
+	spec := podSpec(object)
+	specPath := strings.Join(podTemplatePaths[kind], ".")
+	if specPath != "" {
+		specPath += "."
+	}
+	specPath += "spec"
+	for _, key := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		for i, container := range arrayField(spec, key) {
+			if name := stringField(container, "name"); name != "" {
+				add(specPath+"."+key+"["+strconv.Itoa(i)+"].name", name, RuleDNS1123Label, checkName(name, RuleDNS1123Label))
+			}
+		}
+	}
+	return violations
+}
+
+// validateNames returns a *NameError listing every name of documents that
+// the API server would reject, when opts.ValidateNames is set.
+func validateNames(documents []Document, opts Options) error {
+	if !opts.ValidateNames {
+		return nil
+	}
+	var violations []NameViolation
+	for _, doc := range documents {
+		violations = append(violations, nameViolations(doc, doc.Value)...)
+	}
+	if len(violations) > 0 {
+		return &NameError{Violations: violations}
+	}
+	return nil
+}
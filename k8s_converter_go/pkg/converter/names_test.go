@@ -0,0 +1,145 @@
+package converter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		value string
+		rule  string
+		valid bool
+	}{
+		{"web", RuleDNS1123Subdomain, true},
+		{"web-1.shop.example.com", RuleDNS1123Subdomain, true},
+		{"My_App", RuleDNS1123Subdomain, false},
+		{"-web", RuleDNS1123Subdomain, false},
+		{"web.", RuleDNS1123Subdomain, false},
+		{strings.Repeat("a", 253), RuleDNS1123Subdomain, true},
+		{strings.Repeat("a", 254), RuleDNS1123Subdomain, false},
+		{"shop", RuleDNS1123Label, true},
+		{"1shop", RuleDNS1123Label, true},
+		{"shop.prod", RuleDNS1123Label, false},
+		{strings.Repeat("a", 63), RuleDNS1123Label, true},
+		{strings.Repeat("a", 64), RuleDNS1123Label, false},
+		{"web", RuleDNS1035Label, true},
+		{"1web", RuleDNS1035Label, false},
+		{"system:controller:job-controller", RulePathSegment, true},
+		{"..", RulePathSegment, false},
+		{"a/b", RulePathSegment, false},
+	}
+	for _, tt := range tests {
+		if message := checkName(tt.value, tt.rule); (message == "") != tt.valid {
+			t.Errorf("checkName(%q, %s) = %q, want valid %v", tt.value, tt.rule, message, tt.valid)
+		}
+	}
+}
+
+func TestDecodeValidateNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []NameViolation
+	}{
+		{
+			name:    "Valid names",
+			content: "kind: Deployment\nmetadata:\n  name: web.v2\n  namespace: shop\nspec:\n  template:\n    spec:\n      containers:\n      - name: web\n",
+		},
+		{
+			name:    "Invalid name",
+			content: "kind: ConfigMap\nmetadata:\n  name: My_App\n",
+			want: []NameViolation{{
+				Document: 1, Line: 1, Kind: "ConfigMap", Field: "metadata.name", Value: "My_App", Rule: RuleDNS1123Subdomain,
+				Message: "must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character",
+			}},
+		},
+		{
+			name:    "Names by kind",
+			content: "kind: Service\nmetadata:\n  name: 1web\n---\nkind: Namespace\nmetadata:\n  name: shop.prod\n---\nkind: ClusterRole\nmetadata:\n  name: system:reader\n",
+			want: []NameViolation{
+				{Document: 1, Line: 1, Kind: "Service", Field: "metadata.name", Value: "1web", Rule: RuleDNS1035Label,
+					Message: "must consist of lower case alphanumeric characters or '-', start with an alphabetic character, and end with an alphanumeric character"},
+				{Document: 2, Line: 5, Kind: "Namespace", Field: "metadata.name", Value: "shop.prod", Rule: RuleDNS1123Label,
+					Message: "must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character"},
+			},
+		},
+		{
+			name:    "Namespace too long",
+			content: "kind: Secret\nmetadata:\n  name: tls\n  namespace: " + strings.Repeat("n", 64) + "\n",
+			want: []NameViolation{{
+				Document: 1, Line: 1, Kind: "Secret", Field: "metadata.namespace", Value: strings.Repeat("n", 64), Rule: RuleDNS1123Label,
+				Message: "must be no more than 63 characters, not 64",
+			}},
+		},
+		{
+			name:    "Container names",
+			content: "kind: CronJob\nmetadata:\n  name: backup\nspec:\n  jobTemplate:\n    spec:\n      template:\n        spec:\n          initContainers:\n          - name: Init\n          containers:\n          - name: ok\n          - name: db.dump\n",
+			want: []NameViolation{
+				{Document: 1, Line: 1, Kind: "CronJob", Field: "spec.jobTemplate.spec.template.spec.initContainers[0].name", Value: "Init", Rule: RuleDNS1123Label,
+					Message: "must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character"},
+				{Document: 1, Line: 1, Kind: "CronJob", Field: "spec.jobTemplate.spec.template.spec.containers[1].name", Value: "db.dump", Rule: RuleDNS1123Label,
+					Message: "must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character"},
+			},
+		},
+		{
+			name:    "generateName prefix with a trailing dash",
+			content: "kind: Pod\nmetadata:\n  generateName: web-\nspec:\n  containers:\n  - name: web\n",
+		},
+		{
+			name:    "Invalid generateName",
+			content: "kind: Job\nmetadata:\n  generateName: Backup-\n",
+			want: []NameViolation{{
+				Document: 1, Line: 1, Kind: "Job", Field: "metadata.generateName", Value: "Backup-", Rule: RuleDNS1123Subdomain,
+				Message: "must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character",
+			}},
+		},
+		{
+			name:    "List items",
+			content: "apiVersion: v1\nkind: List\nitems:\n- kind: Pod\n  metadata:\n    name: Web\n",
+			want: []NameViolation{{
+				Document: 1, Line: 1, Kind: "Pod", Field: "metadata.name", Value: "Web", Rule: RuleDNS1123Subdomain,
+				Message: "must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character",
+			}},
+		},
+		{
+			name:    "Not a Kubernetes object",
+			content: "metadata:\n  name: Anything_Goes\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode([]byte(tt.content), Options{ValidateNames: true})
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				return
+			}
+			var nameErr *NameError
+			if !errors.As(err, &nameErr) {
+				t.Fatalf("Decode() error = %v, want a *NameError", err)
+			}
+			if !reflect.DeepEqual(nameErr.Violations, tt.want) {
+				t.Errorf("Violations = %+v, want %+v", nameErr.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameErrorMessage(t *testing.T) {
+	content := "kind: Pod\nmetadata:\n  name: ok\n---\nkind: Pod\nmetadata:\n  name: My_App\n"
+	_, err := Convert([]byte(content), Options{ValidateNames: true})
+	want := `invalid names: Pod: metadata.name "My_App" is not a valid DNS-1123 subdomain: ` +
+		`must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (document 2)`
+	if err == nil || err.Error() != want {
+		t.Errorf("Convert() error = %v, want %s", err, want)
+	}
+	// Without the option, the same names convert
+	if _, err := Convert([]byte(content), Options{}); err != nil {
+		t.Errorf("Convert() without ValidateNames error = %v", err)
+	}
+}
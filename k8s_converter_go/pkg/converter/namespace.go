@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// namespacedKinds tells whether the common built-in kinds are namespaced
+// (true) or cluster-scoped (false). Kinds are matched without their API
+// group, as Kubernetes has no two built-in kinds of the same name with
+// different scopes.
+var namespacedKinds = map[string]bool{
+	"Binding":                          true,
+	"ConfigMap":                        true,
+	"ControllerRevision":               true,
+	"CronJob":                          true,
+	"CSIStorageCapacity":               true,
+	"DaemonSet":                        true,
+	"Deployment":                       true,
+	"Endpoints":                        true,
+	"EndpointSlice":                    true,
+	"Event":                            true,
+	"HorizontalPodAutoscaler":          true,
+	"Ingress":                          true,
+	"Job":                              true,
+	"Lease":                            true,
+	"LimitRange":                       true,
+	"LocalSubjectAccessReview":         true,
+	"NetworkPolicy":                    true,
+	"PersistentVolumeClaim":            true,
+	"Pod":                              true,
+	"PodDisruptionBudget":              true,
+	"PodTemplate":                      true,
+	"ReplicaSet":                       true,
+	"ReplicationController":            true,
+	"ResourceQuota":                    true,
+	"Role":                             true,
+	"RoleBinding":                      true,
+	"Secret":                           true,
+	"Service":                          true,
+	"ServiceAccount":                   true,
+	"StatefulSet":                      true,
+	"VerticalPodAutoscaler":            true,
+	"APIService":                       false,
+	"CertificateSigningRequest":        false,
+	"ClusterRole":                      false,
+	"ClusterRoleBinding":               false,
+	"ComponentStatus":                  false,
+	"CSIDriver":                        false,
+	"CSINode":                          false,
+	"CustomResourceDefinition":         false,
+	"FlowSchema":                       false,
+	"IngressClass":                     false,
+	"MutatingWebhookConfiguration":     false,
+	"Namespace":                        false,
+	"Node":                             false,
+	"PersistentVolume":                 false,
+	"PodSecurityPolicy":                false,
+	"PriorityClass":                    false,
+	"PriorityLevelConfiguration":       false,
+	"RuntimeClass":                     false,
+	"SelfSubjectAccessReview":          false,
+	"SelfSubjectRulesReview":           false,
+	"StorageClass":                     false,
+	"SubjectAccessReview":              false,
+	"TokenReview":                      false,
+	"ValidatingAdmissionPolicy":        false,
+	"ValidatingAdmissionPolicyBinding": false,
+	"ValidatingWebhookConfiguration":   false,
+	"VolumeAttachment":                 false,
+}
+
+// setNamespace sets the metadata.namespace of a namespaced Kubernetes object
+// to opts.SetNamespace when it has none, or always with opts.ForceNamespace.
+// Cluster-scoped kinds are left alone, and so are kinds missing from
+// namespacedKinds, such as custom resources, with a warning, since their
+// scope is not known. Documents without a kind are not Kubernetes objects,
+// and the items of list kinds such as v1 List are set rather than the list.
+func setNamespace(index, line int, v interface{}, opts Options) {
+	object, ok := v.(*Object)
+	if !ok {
+		return
+	}
+	kind := stringField(object, "kind")
+	if kind == "" {
+		return
+	}
+	if strings.HasSuffix(kind, "List") {
+		if items, ok := field(object, "items").([]interface{}); ok {
+			for _, item := range items {
+				setNamespace(index, line, item, opts)
+			}
+			return
+		}
+	}
+	namespaced, known := namespacedKinds[kind]
+	if !known {
+		opts.warn(Warning{Document: index, Line: line, Message: fmt.Sprintf("scope of kind %s is not known, not setting its namespace", kind)})
+		return
+	}
+	if !namespaced {
+		return
+	}
+	metadata, ok := childObject(object, "metadata")
+	if !ok {
+		opts.warn(Warning{Document: index, Line: line, Message: "metadata is not a mapping, not setting the namespace"})
+		return
+	}
+	if stringField(metadata, "namespace") != "" && !opts.ForceNamespace {
+		return
+	}
+	metadata.Set("namespace", opts.SetNamespace)
+}
@@ -0,0 +1,107 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertSetNamespace(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		force        bool
+		want         string
+		wantWarnings []string
+	}{
+		{
+			name:    "Namespaced kind without a namespace",
+			content: "kind: Deployment\nmetadata:\n  name: web\n",
+			want:    `{"kind":"Deployment","metadata":{"name":"web","namespace":"staging"}}`,
+		},
+		{
+			name:    "Namespaced kind without metadata",
+			content: "kind: ConfigMap\n",
+			want:    `{"kind":"ConfigMap","metadata":{"namespace":"staging"}}`,
+		},
+		{
+			name:    "Empty namespace",
+			content: "kind: Service\nmetadata:\n  namespace: \"\"\n",
+			want:    `{"kind":"Service","metadata":{"namespace":"staging"}}`,
+		},
+		{
+			name:    "Existing namespace is kept",
+			content: "kind: Service\nmetadata:\n  namespace: prod\n",
+			want:    `{"kind":"Service","metadata":{"namespace":"prod"}}`,
+		},
+		{
+			name:    "Existing namespace is forced",
+			content: "kind: Service\nmetadata:\n  namespace: prod\n",
+			force:   true,
+			want:    `{"kind":"Service","metadata":{"namespace":"staging"}}`,
+		},
+		{
+			name:    "Cluster-scoped kinds",
+			content: "kind: ClusterRole\nmetadata:\n  name: reader\n---\nkind: Namespace\nmetadata:\n  name: staging\n",
+			force:   true,
+			want:    `[{"kind":"ClusterRole","metadata":{"name":"reader"}},{"kind":"Namespace","metadata":{"name":"staging"}}]`,
+		},
+		{
+			name:         "Unknown kind",
+			content:      "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n",
+			want:         `{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"w"}}`,
+			wantWarnings: []string{"line 1: scope of kind Widget is not known, not setting its namespace"},
+		},
+		{
+			name:    "Not a Kubernetes object",
+			content: "replicas: 3\n",
+			want:    `{"replicas":3}`,
+		},
+		{
+			name: "List items",
+			content: "apiVersion: v1\nkind: List\nitems:\n- kind: Pod\n  metadata:\n    name: a\n- kind: PersistentVolume\n  metadata:\n    name: b\n" +
+				"- kind: Secret\n  metadata:\n    namespace: prod\n",
+			want: `{"apiVersion":"v1","kind":"List","items":[{"kind":"Pod","metadata":{"name":"a","namespace":"staging"}},` +
+				`{"kind":"PersistentVolume","metadata":{"name":"b"}},{"kind":"Secret","metadata":{"namespace":"prod"}}]}`,
+		},
+		{
+			name:         "Metadata that is not a mapping",
+			content:      "kind: Pod\nmetadata: [a]\n",
+			want:         `{"kind":"Pod","metadata":["a"]}`,
+			wantWarnings: []string{"line 1: metadata is not a mapping, not setting the namespace"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			opts := Options{
+				Compact:        true,
+				SetNamespace:   "staging",
+				ForceNamespace: tt.force,
+				Warn:           func(w Warning) { warnings = append(warnings, w.String()) },
+			}
+			got, err := Convert([]byte(tt.content), opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(tt.wantWarnings, "\n") {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestConvertSetNamespaceExplodedList(t *testing.T) {
+	content := "apiVersion: v1\nkind: List\nitems:\n- kind: Pod\n- kind: Node\n"
+	got, err := Convert([]byte(content), Options{Compact: true, ExplodeLists: true, SetNamespace: "staging"})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	want := `[{"kind":"Pod","metadata":{"namespace":"staging"}},{"kind":"Node"}]`
+	if string(got) != want {
+		t.Errorf("Convert() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package converter
+
+import (
+	"encoding/json"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonNumberLiteral matches a number in JSON syntax.
+var jsonNumberLiteral = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?$`)
+
+// decodeNumber returns the JSON number for an int or float scalar and whether
+// node is one. Integers are read per YAML rules, including hexadecimal,
+// octal and binary forms and values beyond 64 bits, and written in decimal.
+// Floats keep the digits they were written with, so 1.0 stays 1.0 rather
+// than becoming 1. Infinity and NaN, which JSON cannot represent, return
+// false.
+func decodeNumber(node *yaml.Node) (json.Number, bool) {
+	if node.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		var value big.Int
+		if _, ok := value.SetString(strings.ReplaceAll(node.Value, "_", ""), 0); !ok {
+			return "", false
+		}
+		return json.Number(value.String()), true
+	case "!!float":
+		literal := strings.TrimPrefix(strings.ReplaceAll(node.Value, "_", ""), "+")
+		sign := ""
+		if strings.HasPrefix(literal, "-") {
+			sign, literal = "-", literal[1:]
+		}
+		// YAML allows .5 and 1. where JSON needs digits on both sides
+		if strings.HasPrefix(literal, ".") {
+			literal = "0" + literal
+		}
+		if i := strings.IndexByte(literal, '.'); i >= 0 && (i+1 == len(literal) || literal[i+1] < '0' || literal[i+1] > '9') {
+			literal = literal[:i+1] + "0" + literal[i+1:]
+		}
+		literal = sign + literal
+		if !jsonNumberLiteral.MatchString(literal) {
+			return "", false
+		}
+		return json.Number(literal), true
+	}
+	return "", false
+}
@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConvertNumberPrecision(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"19-digit integer", "resourceVersion: 1234567890123456789", `{"resourceVersion":1234567890123456789}`},
+		{"largest int64", "max: 9223372036854775807", `{"max":9223372036854775807}`},
+		{"smallest int64", "min: -9223372036854775808", `{"min":-9223372036854775808}`},
+		{"beyond 64 bits", "big: 123456789012345678901234567890", `{"big":123456789012345678901234567890}`},
+		{"explicit int tag beyond 64 bits", "big: !!int 99999999999999999999", `{"big":99999999999999999999}`},
+		{"high-precision float", "pi: 3.14159265358979323846264338327950288", `{"pi":3.14159265358979323846264338327950288}`},
+		{"float with zero fraction", "ratio: 1.0", `{"ratio":1.0}`},
+		{"float exponent", "values: [1e3, 2.5E-10, 12e03]", `{"values":[1e3,2.5E-10,12e03]}`},
+		{"hexadecimal", "value: 0x1A", `{"value":26}`},
+		{"octal", "value: 0o17", `{"value":15}`},
+		{"binary", "value: 0b101", `{"value":5}`},
+		{"signs and underscores", "values: [+5, -12, 1_000, +1.5]", `{"values":[5,-12,1000,1.5]}`},
+		{"YAML float forms", "values: [.5, -.25, 1.]", `{"values":[0.5,-0.25,1.0]}`},
+		{"quoted numbers stay strings", `values: ["0x1A", '1.0']`, `{"values":["0x1A","1.0"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(tt.input), Options{Compact: true})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertNumberIndented(t *testing.T) {
+	got, err := Convert([]byte("metadata:\n  resourceVersion: 1234567890123456789\n  weight: 0.10\n"), Options{})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	want := "{\n  \"metadata\": {\n    \"resourceVersion\": 1234567890123456789,\n    \"weight\": 0.10\n  }\n}"
+	if string(got) != want {
+		t.Errorf("Convert() = %s, want %s", got, want)
+	}
+}
+
+func TestConvertNumberInfinity(t *testing.T) {
+	_, err := Convert([]byte("limit: .inf"), Options{})
+	var encodeErr *EncodeError
+	if !errors.As(err, &encodeErr) {
+		t.Errorf("Convert() error = %v, want *EncodeError", err)
+	}
+}
@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Object is a decoded YAML mapping. It keeps its keys in the order they
+// appear in the input and is encoded to JSON in that order, so converted
+// documents can be compared line by line with their source.
+type Object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewObject returns an empty Object.
+func NewObject() *Object {
+	return &Object{values: make(map[string]interface{})}
+}
+
+// Len returns the number of keys in the object.
+func (o *Object) Len() int {
+	return len(o.keys)
+}
+
+// Keys returns the keys of the object in order.
+func (o *Object) Keys() []string {
+	return append([]string(nil), o.keys...)
+}
+
+// Get returns the value of key and whether the object has it.
+func (o *Object) Get(key string) (interface{}, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// Set sets the value of key. A new key is added after the existing ones; an
+// existing key keeps its position.
+func (o *Object) Set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// Delete removes key from the object.
+func (o *Object) Delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, existing := range o.keys {
+		if existing == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// MarshalJSON encodes the object with its keys in order.
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		encodedValue, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// sortKeys sorts the keys of every object in v, including objects nested in
+// arrays.
+func sortKeys(v interface{}) {
+	switch value := v.(type) {
+	case *Object:
+		sort.Strings(value.keys)
+		for _, item := range value.values {
+			sortKeys(item)
+		}
+	case []interface{}:
+		for _, item := range value {
+			sortKeys(item)
+		}
+	}
+}
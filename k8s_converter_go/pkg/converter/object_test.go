@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestObject(t *testing.T) {
+	object := NewObject()
+	object.Set("kind", "Service")
+	object.Set("apiVersion", "v1")
+	object.Set("metadata", NewObject())
+	object.Set("kind", "Pod")
+	object.Delete("metadata")
+	object.Delete("missing")
+
+	if want := []string{"kind", "apiVersion"}; !reflect.DeepEqual(object.Keys(), want) {
+		t.Errorf("Keys() = %v, want %v", object.Keys(), want)
+	}
+	if value, ok := object.Get("kind"); !ok || value != "Pod" {
+		t.Errorf("Get(kind) = %v, %v, want Pod, true", value, ok)
+	}
+	if _, ok := object.Get("metadata"); ok {
+		t.Errorf("Get(metadata) found a deleted key")
+	}
+
+	got, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"kind":"Pod","apiVersion":"v1"}`; string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestConvertKeyOrder(t *testing.T) {
+	content := []byte("kind: Role\napiVersion: rbac.authorization.k8s.io/v1\nrules:\n- verbs: [get]\n  apiGroups: ['']\n  resources: [pods]\n")
+
+	tests := []struct {
+		name     string
+		sortKeys bool
+		want     string
+	}{
+		{
+			name: "input order",
+			want: `{"kind":"Role","apiVersion":"rbac.authorization.k8s.io/v1","rules":[{"verbs":["get"],"apiGroups":[""],"resources":["pods"]}]}`,
+		},
+		{
+			name:     "sorted",
+			sortKeys: true,
+			want:     `{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"Role","rules":[{"apiGroups":[""],"resources":["pods"],"verbs":["get"]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, format := range []string{FormatJSON, FormatNDJSON} {
+				got, err := Convert(content, Options{Format: format, Compact: true, SortKeys: tt.sortKeys})
+				if err != nil {
+					t.Fatalf("Convert() %s error = %v", format, err)
+				}
+				want := tt.want
+				if format == FormatNDJSON {
+					want += "\n"
+				}
+				if string(got) != want {
+					t.Errorf("Convert() %s = %s, want %s", format, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertSortKeysCanonical(t *testing.T) {
+	// The same resources with the keys of every object in a different order
+	first := []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels: {app: web, tier: frontend}
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        ports: [{containerPort: 80, name: http}, {name: https, containerPort: 443}]
+        env:
+        - {name: MODE, value: prod}
+---
+kind: Service
+metadata: {name: web}
+`)
+	second := []byte(`spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - {value: prod, name: MODE}
+        ports: [{name: http, containerPort: 80}, {containerPort: 443, name: https}]
+        image: nginx
+        name: web
+metadata:
+  labels: {tier: frontend, app: web}
+  name: web
+kind: Deployment
+apiVersion: apps/v1
+---
+metadata: {name: web}
+kind: Service
+`)
+
+	for _, opts := range []Options{
+		{SortKeys: true},
+		{SortKeys: true, Compact: true, Separate: true},
+		{SortKeys: true, Format: FormatNDJSON},
+	} {
+		firstJSON, err := Convert(first, opts)
+		if err != nil {
+			t.Fatalf("Convert() error = %v", err)
+		}
+		secondJSON, err := Convert(second, opts)
+		if err != nil {
+			t.Fatalf("Convert() error = %v", err)
+		}
+		if string(firstJSON) != string(secondJSON) {
+			t.Errorf("Convert() with %+v differs by key order:\n%s\n%s", opts, firstJSON, secondJSON)
+		}
+	}
+}
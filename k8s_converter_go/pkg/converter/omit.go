@@ -0,0 +1,46 @@
+package converter
+
+// omitNulls removes every member of a mapping under v whose value is null,
+// for Options.OmitNull, and returns v with the members removed. Null items of
+// sequences are kept, so that the positions of the other items do not change.
+//
+// With omitEmpty a mapping or sequence that the removal leaves empty is
+// removed from the mapping or sequence that holds it too, and so on up.
+// Mappings and sequences that are empty in the input, such as emptyDir: {},
+// are kept, as they often mean something. The result reports whether v was
+// left empty.
+func omitNulls(v interface{}, omitEmpty bool) (interface{}, bool) {
+	switch value := v.(type) {
+	case *Object:
+		if value.Len() == 0 {
+			return value, false
+		}
+		for _, key := range value.Keys() {
+			member := value.values[key]
+			if member == nil {
+				value.Delete(key)
+				continue
+			}
+			member, emptied := omitNulls(member, omitEmpty)
+			if emptied && omitEmpty {
+				value.Delete(key)
+				continue
+			}
+			value.values[key] = member
+		}
+		return value, value.Len() == 0
+	case []interface{}:
+		if len(value) == 0 {
+			return value, false
+		}
+		items := value[:0]
+		for _, item := range value {
+			item, emptied := omitNulls(item, omitEmpty)
+			if !emptied || !omitEmpty {
+				items = append(items, item)
+			}
+		}
+		return items, len(items) == 0
+	}
+	return v, false
+}
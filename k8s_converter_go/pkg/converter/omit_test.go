@@ -0,0 +1,76 @@
+package converter
+
+import "testing"
+
+func TestConvertOmitNull(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+	}{
+		{
+			name:    "Exported manifest",
+			content: exportedDeploymentYAML,
+			opts:    Options{Clean: true},
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"prod","labels":{"app":"web"}},` +
+				`"spec":{"replicas":2,"template":{"metadata":{"labels":{"app":"web"}},"spec":{"containers":[{"name":"web","image":"nginx"}]}}}}`,
+		},
+		{
+			name:    "Arbitrary YAML",
+			content: "name: app\nversion: ~\nsettings:\n  debug: null\n  level: 3\n",
+			want:    `{"name":"app","settings":{"level":3}}`,
+		},
+		{
+			name:    "Objects inside arrays",
+			content: "items:\n- name: a\n  value: null\n- name: b\n",
+			want:    `{"items":[{"name":"a"},{"name":"b"}]}`,
+		},
+		{
+			name:    "Null array items kept",
+			content: "args: [a, null, b]\n",
+			want:    `{"args":["a",null,"b"]}`,
+		},
+		{
+			name:    "Emptied containers kept by default",
+			content: "metadata:\n  creationTimestamp: null\nitems:\n- value: null\nspec: {}\n",
+			want:    `{"metadata":{},"items":[{}],"spec":{}}`,
+		},
+		{
+			name:    "Emptied containers dropped",
+			content: "metadata:\n  creationTimestamp: null\nitems:\n- value: null\n- name: b\nnested:\n  list:\n  - a: null\n",
+			opts:    Options{OmitEmpty: true},
+			want:    `{"items":[{"name":"b"}]}`,
+		},
+		{
+			name:    "Empty input containers kept",
+			content: "volumes:\n- name: cache\n  emptyDir: {}\n  extra: null\nargs: []\n",
+			opts:    Options{OmitEmpty: true},
+			want:    `{"volumes":[{"name":"cache","emptyDir":{}}],"args":[]}`,
+		},
+		{
+			name:    "Document left empty",
+			content: "a: null\n",
+			opts:    Options{OmitEmpty: true},
+			want:    `{}`,
+		},
+		{
+			name:    "Null string kept",
+			content: "a: \"null\"\nb: 'null'\n",
+			want:    `{"a":"null","b":"null"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.OmitNull, tt.opts.Compact = true, true
+			got, err := Convert([]byte(tt.content), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parsedDocument is a single document from a YAML stream. Its node is a null
+// scalar when it is an empty document kept by EmptyDocumentsNull.
+type parsedDocument struct {
+	// node is the root content node of the document.
+	node *yaml.Node
+	// headComment and footComment are the comments before and after the
+	// root node of the document, such as a comment on its first line.
+	headComment string
+	footComment string
+	// index is the 1-based position of the document in the stream, counting
+	// empty documents.
+	index int
+	// templateLine is the first line with a Helm template action seen in the
+	// stream by the time the document was parsed, or 0 if there was none.
+	templateLine int
+	// nodes is the number of nodes written in the document, counting each
+	// alias once without expanding it.
+	nodes int
+	// err is the error parsing the document when it is not valid YAML, and
+	// node is nil then.
+	err error
+}
+
+// parseDocuments parses every document in a YAML stream into nodes. Empty
+// documents, including documents that only contain comments, are handled as
+// opts.EmptyDocuments selects.
+func parseDocuments(data []byte, opts Options) ([]parsedDocument, error) {
+	var documents []parsedDocument
+	err := streamDocuments(bytes.NewReader(data), opts, func(doc parsedDocument) error {
+		documents = append(documents, doc)
+		return nil
+	})
+	return documents, err
+}
+
+// streamDocuments parses the documents of a YAML stream one at a time and
+// calls fn for each document as soon as it has been parsed, applying
+// opts.EmptyDocuments to the empty ones. It stops at the first document that
+// fails to parse, returning its error.
+func streamDocuments(r io.Reader, opts Options, fn func(parsedDocument) error) error {
+	return readDocuments(r, opts, func(doc parsedDocument) error {
+		if doc.err != nil {
+			return doc.err
+		}
+		return fn(doc)
+	})
+}
+
+// readDocuments parses the documents of a YAML stream like streamDocuments,
+// but calls fn with the error of a document that fails to parse too, and
+// then resumes at the next "---" document start after the error, so that
+// every broken document of the stream is found in one pass. A
+// gzip-compressed stream is decompressed first, UTF-16 text is transcoded to
+// UTF-8 by textReader, and its line endings are normalized by a lineReader.
+// A document that fails to parse in a stream containing Helm template
+// actions is reported as a *TemplateError. With opts.JSONInput the stream is
+// read whole and parsed as JSON instead, stopping at the first error.
+func readDocuments(r io.Reader, opts Options, fn func(parsedDocument) error) error {
+	input := &inputReader{reader: r}
+	r, gz, err := decompressReader(input, opts.MaxSize)
+	if err != nil {
+		return err
+	}
+	if r, err = textReader(r, opts); err != nil {
+		return err
+	}
+	r = newLineReader(r)
+	if opts.HelmPlaceholders {
+		r = replaceTemplateActions(r)
+	}
+	if opts.JSONInput {
+		data, err := io.ReadAll(r)
+		if gz != nil && gz.err != nil {
+			return gz.err
+		}
+		if err != nil {
+			return &IOError{Op: "read", Path: "input", Err: err}
+		}
+		return streamJSONDocuments(data, opts, fn)
+	}
+	// Each run decodes the stream from its start or from where it resumed
+	// after an error, offset lines into the run
+	index, offset, templateLine := 1, 0, 0
+	for {
+		detector := &templateDetector{scanner: newTemplateScanner()}
+		record := &runRecorder{line: 1}
+		decoder := yaml.NewDecoder(io.TeeReader(r, io.MultiWriter(detector, record)))
+		for ; ; index++ {
+			var node yaml.Node
+			err := decoder.Decode(&node)
+			if gz != nil && gz.err != nil {
+				return gz.err
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil && input.err != nil {
+				return &IOError{Op: "read", Path: "input", Err: input.err}
+			}
+			if templateLine == 0 && detector.line > 0 {
+				templateLine = detector.line + offset
+			}
+			if err != nil {
+				parseErr := newYAMLParseError(err, index, nil)
+				line := parseErr.Line
+				parseErr.Column = errorColumn(record.data, line-record.line+1, parseErr.Message)
+				parseErr.Line += offset
+				var docErr error = parseErr
+				if detector.flush(); templateLine == 0 && detector.line > 0 {
+					templateLine = detector.line + offset
+				}
+				if templateLine > 0 {
+					docErr = &TemplateError{Line: templateLine, Err: parseErr}
+				}
+				if err := fn(parsedDocument{index: index, err: docErr}); err != nil {
+					return err
+				}
+				start, ok := record.resume(&r, line)
+				if input.err != nil {
+					return &IOError{Op: "read", Path: "input", Err: input.err}
+				}
+				if !ok {
+					return nil
+				}
+				index, offset = index+1, offset+start-1
+				break
+			}
+			record.trim(node.Line)
+			shiftLines(&node, offset)
+			if len(node.Content) == 0 || isNullNode(node.Content[0]) {
+				if err := emptyDocument(index, node.Line, opts, fn); err != nil {
+					return err
+				}
+				continue
+			}
+			doc := parsedDocument{
+				node:         node.Content[0],
+				headComment:  node.HeadComment,
+				footComment:  node.FootComment,
+				index:        index,
+				templateLine: templateLine,
+				nodes:        countNodes(node.Content[0]),
+			}
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// runRecorder keeps the text a YAML decoder has read, from the start of the
+// line of the last document it decoded, so that decoding can resume after a
+// syntax error without reading the whole stream into memory.
+type runRecorder struct {
+	data []byte
+	// line is the line of the decoder's input that data starts on.
+	line int
+}
+
+func (r *runRecorder) Write(p []byte) (int, error) {
+	r.data = append(r.data, p...)
+	return len(p), nil
+}
+
+// trim drops the text before line, which is after the documents already
+// decoded, so that no error can be found there.
+func (r *runRecorder) trim(line int) {
+	for r.line < line {
+		end := bytes.IndexByte(r.data, '\n')
+		if end < 0 {
+			return
+		}
+		r.data = r.data[end+1:]
+		r.line++
+	}
+}
+
+// resume skips the text of a document that failed to parse at errorLine, up
+// to the next line that starts a document with "---" after the first line
+// of the run, and sets *rest to read the stream from there. It returns the
+// line of the run the new run starts on, reporting false when no document
+// follows.
+func (r *runRecorder) resume(rest *io.Reader, errorLine int) (int, bool) {
+	reader := bufio.NewReader(io.MultiReader(bytes.NewReader(r.data), *rest))
+	for line := r.line; ; line++ {
+		text, err := reader.ReadString('\n')
+		if text == "" {
+			return 0, false
+		}
+		if line > 1 && line >= errorLine && isDocumentStart(text) {
+			*rest = io.MultiReader(strings.NewReader(text), reader)
+			return line, true
+		}
+		if err != nil {
+			return 0, false
+		}
+	}
+}
+
+// isDocumentStart reports whether line, which may end in a newline, is a
+// "---" document start marker. The marker cannot appear at the start of a
+// line inside a document, not even in a block scalar, so documents can be
+// found without parsing.
+func isDocumentStart(line string) bool {
+	rest, ok := strings.CutPrefix(line, "---")
+	return ok && (rest == "" || strings.ContainsRune(" \t\r\n", rune(rest[0])))
+}
+
+// maxColumnSearch is the most text errorColumn decodes again for each column
+// it tries, so that an error near the end of a huge document is reported
+// without a column rather than slowly.
+const maxColumnSearch = 1 << 20
+
+// errorColumn returns the column of a syntax error, which yaml.v3 leaves out
+// of its messages, reported with message at line of text: the first column
+// that line can be cut after and still fail with the same error, which is
+// where the failing token starts. It returns 0 when no cut gives the error,
+// as for errors found past line, or when the text is too long to search.
+func errorColumn(text []byte, line int, message string) int {
+	start := 0
+	for ; line > 1; line-- {
+		end := bytes.IndexByte(text[start:], '\n')
+		if end < 0 {
+			return 0
+		}
+		start += end + 1
+	}
+	end := bytes.IndexByte(text[start:], '\n')
+	if end < 0 {
+		end = len(text) - start
+	}
+	if start+end > maxColumnSearch {
+		return 0
+	}
+	column := 0
+	for offset := start; offset < start+end; {
+		_, size := utf8.DecodeRune(text[offset:])
+		offset += size
+		column++
+		if failsAtEnd(text[:offset], message) {
+			return column
+		}
+	}
+	return 0
+}
+
+// failsAtEnd reports whether decoding text fails with message at its last
+// line.
+func failsAtEnd(text []byte, message string) bool {
+	decoder := yaml.NewDecoder(bytes.NewReader(text))
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if err == io.EOF {
+			return false
+		}
+		if err != nil {
+			parseErr := newYAMLParseError(err, 0, nil)
+			return parseErr.Message == message && parseErr.Line == bytes.Count(text, []byte("\n"))+1
+		}
+	}
+}
+
+// shiftLines moves node and its children offset lines down, for documents
+// decoded by a run that resumed after an error.
+func shiftLines(node *yaml.Node, offset int) {
+	if offset == 0 {
+		return
+	}
+	node.Line += offset
+	for _, child := range node.Content {
+		shiftLines(child, offset)
+	}
+}
+
+// inputReader remembers the first error reading the input, so that it can be
+// reported instead of the parse error it causes in the YAML decoder.
+type inputReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (i *inputReader) Read(p []byte) (int, error) {
+	n, err := i.reader.Read(p)
+	if err != nil && err != io.EOF && i.err == nil {
+		i.err = err
+	}
+	return n, err
+}
+
+// emptyDocument applies opts.EmptyDocuments to the empty document at position
+// index in the stream, which starts at line. With EmptyDocumentsNull fn is
+// called with the document as a null scalar.
+func emptyDocument(index, line int, opts Options, fn func(parsedDocument) error) error {
+	switch opts.EmptyDocuments {
+	case "", EmptyDocumentsSkip:
+		return nil
+	case EmptyDocumentsError:
+		return fmt.Errorf("%w: document %d at line %d is empty", ErrInvalidYAML, index, line)
+	case EmptyDocumentsNull:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null", Line: line, Column: 1}
+		return fn(parsedDocument{node: node, index: index, nodes: 1})
+	default:
+		return fmt.Errorf("unknown empty document policy %q", opts.EmptyDocuments)
+	}
+}
+
+// countNodes returns the number of nodes in the tree rooted at node, without
+// following aliases.
+func countNodes(node *yaml.Node) int {
+	count := 1
+	for _, child := range node.Content {
+		count += countNodes(child)
+	}
+	return count
+}
+
+// isNullNode reports whether node is an empty or explicit null scalar.
+func isNullNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// decode decodes the document into JSON-compatible Go values with a
+// valueDecoder. Template actions such as {{ .Values.image }} parse as flow
+// mappings and only fail here, so they are reported as a *TemplateError too.
+func (d parsedDocument) decode(opts Options) (interface{}, error) {
+	decoder := valueDecoder{document: d.index, opts: opts, maxNodes: d.nodes + opts.maxAliasNodes()}
+	value, err := decoder.decode(d.node, "")
+	var parseErr *ParseError
+	if d.templateLine > 0 && errors.As(err, &parseErr) {
+		return nil, &TemplateError{Line: d.templateLine, Err: err}
+	}
+	return value, err
+}
+
+// validateDocuments checks that every document is a mapping and that at least
+// one of them is not empty. Empty documents are only present with
+// EmptyDocumentsNull.
+func validateDocuments(documents []parsedDocument) error {
+	found := false
+	for _, doc := range documents {
+		if isNullNode(doc.node) {
+			continue
+		}
+		if err := checkMapping(doc); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return ErrInvalidYAML
+	}
+	return nil
+}
+
+// checkMapping returns an error wrapping ErrInvalidYAML when the document is
+// not a mapping.
+func checkMapping(doc parsedDocument) error {
+	if doc.node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%w: document %d at line %d, column %d is not a mapping",
+			ErrInvalidYAML, doc.index, doc.node.Line, doc.node.Column)
+	}
+	return nil
+}
+
+// yamlErrorLine matches the location prefix of yaml.v3 error messages.
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?(?:line (\d+): )?`)
+
+// parserErrors are the yaml.v3 messages raised by the parser rather than the
+// scanner. yaml.v3 reports these with a 0-based line number.
+var parserErrors = []string{
+	"did not find expected <document start>",
+	"did not find expected node content",
+	"did not find expected key",
+	"did not find expected '-' indicator",
+	"did not find expected ',' or ']'",
+	"did not find expected ',' or '}'",
+}
+
+// newYAMLParseError converts an error returned by yaml.v3 into a
+// *ParseError, extracting the line number from its message. yaml.v3 omits the
+// line for errors on the first line of the stream. When the error has no
+// location and node is given, the node's position is used instead.
+func newYAMLParseError(err error, document int, node *yaml.Node) *ParseError {
+	message := err.Error()
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		message = typeErr.Errors[0]
+	}
+
+	parseErr := &ParseError{Format: "YAML", Document: document, Err: err}
+	match := yamlErrorLine.FindStringSubmatch(message)
+	parseErr.Message = strings.TrimPrefix(message, match[0])
+	switch {
+	case match[1] != "":
+		parseErr.Line, _ = strconv.Atoi(match[1])
+		for _, parserError := range parserErrors {
+			if strings.HasPrefix(parseErr.Message, parserError) {
+				parseErr.Line++
+				break
+			}
+		}
+	case node != nil:
+		parseErr.Line, parseErr.Column = node.Line, node.Column
+	default:
+		parseErr.Line = 1
+	}
+	return parseErr
+}
@@ -0,0 +1,153 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorLocation(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantLine     int
+		wantColumn   int
+		wantDocument int
+		wantMessage  string
+	}{
+		{
+			name:         "Error on the first line",
+			content:      "This is not valid: YAML: content",
+			wantLine:     1,
+			wantColumn:   24,
+			wantDocument: 1,
+			wantMessage:  "mapping values are not allowed in this context",
+		},
+		{
+			name:         "Scanner error",
+			content:      "kind: Pod\nspec:\n\tcontainers: []\n",
+			wantLine:     3,
+			wantColumn:   1,
+			wantDocument: 1,
+			wantMessage:  "found character that cannot start any token",
+		},
+		{
+			name:         "Parser error",
+			content:      "kind: Pod\nports: [80\n",
+			wantLine:     2,
+			wantColumn:   9,
+			wantDocument: 1,
+			wantMessage:  "did not find expected ',' or ']'",
+		},
+		{
+			name:         "Indentation error",
+			content:      "metadata:\n  name: web\n    labels: {}\n",
+			wantLine:     3,
+			wantColumn:   11,
+			wantDocument: 1,
+			wantMessage:  "mapping values are not allowed in this context",
+		},
+		{
+			name:         "Error without a column",
+			content:      "kind: Pod\nspec:\n  containers:\n  - name: a\n    image: x\n   ports: []\n",
+			wantLine:     3,
+			wantDocument: 1,
+			wantMessage:  "did not find expected key",
+		},
+		{
+			name:         "Error in a later document",
+			content:      "kind: Deployment\n---\n# comment\n---\nkind: Service\nspec: x: y\n",
+			wantLine:     6,
+			wantColumn:   8,
+			wantDocument: 3,
+			wantMessage:  "mapping values are not allowed in this context",
+		},
+		{
+			name:         "Decode error uses the value position",
+			content:      "kind: Deployment\n---\nreplicas: !!int three\n",
+			wantLine:     3,
+			wantColumn:   11,
+			wantDocument: 2,
+			wantMessage:  "cannot decode !!str `three` as a !!int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate([]byte(tt.content), Options{})
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Validate() error = %v, want ParseError", err)
+			}
+			if parseErr.Line != tt.wantLine || parseErr.Column != tt.wantColumn || parseErr.Document != tt.wantDocument {
+				t.Errorf("ParseError location = %d:%d document %d, want %d:%d document %d",
+					parseErr.Line, parseErr.Column, parseErr.Document, tt.wantLine, tt.wantColumn, tt.wantDocument)
+			}
+			if parseErr.Detail() != tt.wantMessage {
+				t.Errorf("ParseError.Detail() = %q, want %q", parseErr.Detail(), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestInvalidDocumentLocation(t *testing.T) {
+	err := Validate([]byte("kind: Deployment\n---\n- not\n- a mapping\n"), Options{})
+	if !errors.Is(err, ErrInvalidYAML) {
+		t.Fatalf("Validate() error = %v, want ErrInvalidYAML", err)
+	}
+	want := "invalid YAML content: document 2 at line 3, column 1 is not a mapping"
+	if err.Error() != want {
+		t.Errorf("Validate() error = %q, want %q", err, want)
+	}
+}
+
+func TestEmptyDocuments(t *testing.T) {
+	const helmOutput = "---\n# Source: chart/templates/service.yaml\nkind: Service\n---\n# Source: chart/templates/unused.yaml\n---\n~\n---\nkind: Deployment\n---\n"
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+		wantErr string
+	}{
+		{name: "Skipped by default", content: helmOutput, want: `[{"kind":"Service"},{"kind":"Deployment"}]`},
+		{name: "Skipped", content: helmOutput, opts: Options{EmptyDocuments: EmptyDocumentsSkip}, want: `[{"kind":"Service"},{"kind":"Deployment"}]`},
+		{name: "Null", content: helmOutput, opts: Options{EmptyDocuments: EmptyDocumentsNull}, want: `[{"kind":"Service"},null,null,{"kind":"Deployment"},null]`},
+		{name: "Null separate", content: "a: 1\n---\n---\nb: 2\n", opts: Options{EmptyDocuments: EmptyDocumentsNull, Separate: true}, want: "{\"a\":1}\nnull\n{\"b\":2}"},
+		{name: "Null query", content: "kind: A\n---\n", opts: Options{EmptyDocuments: EmptyDocumentsNull, Query: ".kind"}, want: "\"A\"\nnull"},
+		{name: "Null filtered", content: helmOutput, opts: Options{EmptyDocuments: EmptyDocumentsNull, Kinds: []string{"Service"}}, want: `{"kind":"Service"}`},
+		{name: "Null JSON input", content: `[{"kind": "A"}, null]`, opts: Options{EmptyDocuments: EmptyDocumentsNull, JSONInput: true}, want: `[{"kind":"A"},null]`},
+		{name: "Null strict Kubernetes", content: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: a\n---\n", opts: Options{EmptyDocuments: EmptyDocumentsNull, KubernetesStrict: true}, want: `[{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"a"}},null]`},
+		{name: "Error", content: helmOutput, opts: Options{EmptyDocuments: EmptyDocumentsError}, wantErr: "invalid YAML content: document 2 at line 4 is empty"},
+		{name: "Error on explicit null", content: "kind: A\n--- ~\n", opts: Options{EmptyDocuments: EmptyDocumentsError}, wantErr: "invalid YAML content: document 2 at line 2 is empty"},
+		{name: "Empty mapping is a document", content: "{}\n", want: `{}`},
+		{name: "Entirely empty stream", content: "---\n# nothing\n---\n", opts: Options{EmptyDocuments: EmptyDocumentsNull}, wantErr: "invalid YAML content"},
+		{name: "No documents", content: "# nothing\n", wantErr: "invalid YAML content"},
+		{name: "Unknown policy", content: "a: 1\n---\n", opts: Options{EmptyDocuments: "keep"}, wantErr: `unknown empty document policy "keep"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Compact = true
+			got, err := Convert([]byte(tt.content), tt.opts)
+			var streamed bytes.Buffer
+			streamErr := ConvertStream(strings.NewReader(tt.content), &streamed, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Convert() error = %v, want %q", err, tt.wantErr)
+				}
+				if streamErr == nil || streamErr.Error() != tt.wantErr {
+					t.Errorf("ConvertStream() error = %v, want %q", streamErr, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Errorf("Convert() = %s, %v, want %s", got, err, tt.want)
+			}
+			if streamErr != nil || streamed.String() != tt.want {
+				t.Errorf("ConvertStream() = %s, %v, want %s", streamed.String(), streamErr, tt.want)
+			}
+		})
+	}
+}
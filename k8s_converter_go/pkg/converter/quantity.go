@@ -0,0 +1,156 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Rules for the requests and limits of containers.
+const (
+	// RuleQuantity is the rule for resource amounts: a number, optionally
+	// signed, with a binary suffix (Ki, Mi, Gi, Ti, Pi, Ei), a decimal suffix
+	// (n, u, m, k, M, G, T, P, E) or a decimal exponent, as in 512Mi, 500m or
+	// 1e3.
+	RuleQuantity = "quantity"
+	// RuleResourceName is the rule for the names of resources: cpu, memory,
+	// ephemeral-storage, hugepages-<size>, or an extended resource with a
+	// domain prefix, as in nvidia.com/gpu.
+	RuleResourceName = "resource name"
+)
+
+var (
+	quantityNumberPattern = regexp.MustCompile(`^[+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)`)
+	quantitySuffixPattern = regexp.MustCompile(`^(?:Ki|Mi|Gi|Ti|Pi|Ei|[numkMGTPE]|[eE][+-]?[0-9]+)?$`)
+)
+
+// standardResources are the resource names without a domain prefix.
+var standardResources = map[string]bool{"cpu": true, "memory": true, "ephemeral-storage": true, "storage": true}
+
+// checkQuantity returns how value breaks RuleQuantity as an amount of a
+// resource, or "" when it does not. YAML numbers are quantities too.
+func checkQuantity(value interface{}) string {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case nil, bool, *Object, []interface{}:
+		return "must be a string or a number"
+	default:
+		text = fmt.Sprint(v)
+	}
+	number := quantityNumberPattern.FindString(text)
+	suffix := text[len(number):]
+	switch {
+	case strings.TrimLeft(number, "+-") == "":
+		return "must start with a number"
+	case !quantitySuffixPattern.MatchString(suffix):
+		if strings.IndexFunc(suffix, isASCIILetter) == 0 {
+			return fmt.Sprintf("suffix %q is not one of Ki, Mi, Gi, Ti, Pi, Ei, n, u, m, k, M, G, T, P or E", suffix)
+		}
+		return fmt.Sprintf("must be a number with '.' as the decimal point, then an optional suffix, not %q", text)
+	case strings.HasPrefix(number, "-") && strings.Trim(number, "-0.") != "":
+		return "must not be negative"
+	}
+	return ""
+}
+
+func isASCIILetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// checkResourceName returns how name breaks RuleResourceName, or "" when it
+// does not.
+func checkResourceName(name string) string {
+	if standardResources[name] || strings.HasPrefix(name, "hugepages-") {
+		return ""
+	}
+	if !strings.Contains(name, "/") {
+		return "must be cpu, memory, ephemeral-storage, hugepages-<size> or an extended resource with a domain prefix, such as example.com/gpu"
+	}
+	return checkQualifiedName(name)
+}
+
+// quantityViolations returns the requests and limits of the containers and
+// pods of v, a document, with names that break RuleResourceName or amounts
+// that break RuleQuantity. Extended resources must also be whole numbers.
+// Pod specs are found as by walkPodSpecs. Documents without a kind are not
+// Kubernetes objects, and the items of list kinds are checked instead of the
+// list.
+func quantityViolations(doc Document, v interface{}) []NameViolation {
+	object, ok := v.(*Object)
+	kind := stringField(v, "kind")
+	if !ok || kind == "" {
+		return nil
+	}
+	if items, ok := listItems(object); ok {
+		var violations []NameViolation
+		for _, item := range items {
+			violations = append(violations, quantityViolations(doc, item)...)
+		}
+		return violations
+	}
+
+	var violations []NameViolation
+	add := func(field, value, rule, message string) {
+		if message != "" {
+			violations = append(violations, NameViolation{
+				Document: doc.Index, Line: doc.Line, Kind: kind,
+				Field: field, Value: value, Rule: rule, Message: message,
+			})
+		}
+	}
+	checkResources := func(path string, resources interface{}) {
+		for _, key := range []string{"requests", "limits"} {
+			amounts, ok := field(resources, key).(*Object)
+			if !ok {
+				continue
+			}
+			for _, name := range amounts.Keys() {
+				amount, _ := amounts.Get(name)
+				amountPath := path + ".resources." + key + querySegment{field: name}.String()
+				add(amountPath, name, RuleResourceName, checkResourceName(name))
+				message := checkQuantity(amount)
+				if message == "" && strings.Contains(name, "/") && !isWholeQuantity(amount) {
+					message = "must be a whole number for an extended resource"
+				}
+				add(amountPath, labelValue(amount), RuleQuantity, message)
+			}
+		}
+	}
+	walkPodSpecs(object, "", func(path string, spec *Object) {
+		path = strings.TrimPrefix(path, ".")
+		checkResources(path, field(spec, "resources"))
+		for _, key := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			for i, container := range arrayField(spec, key) {
+				checkResources(strings.TrimPrefix(path+"."+key+"["+strconv.Itoa(i)+"]", "."), field(container, "resources"))
+			}
+		}
+	})
+	return violations
+}
+
+// isWholeQuantity reports whether amount, a valid quantity, is a whole
+// number without a suffix.
+func isWholeQuantity(amount interface{}) bool {
+	text := strings.TrimPrefix(labelValue(amount), "+")
+	return text != "" && strings.Trim(text, "0123456789") == ""
+}
+
+// validateQuantities returns a *QuantityError listing every request and
+// limit of documents that the API server would reject, when
+// opts.ValidateQuantities is set.
+func validateQuantities(documents []Document, opts Options) error {
+	if !opts.ValidateQuantities {
+		return nil
+	}
+	var violations []NameViolation
+	for _, doc := range documents {
+		violations = append(violations, quantityViolations(doc, doc.Value)...)
+	}
+	if len(violations) > 0 {
+		return &QuantityError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package converter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCheckQuantity(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"500m", ""},
+		{"512Mi", ""},
+		{"1.5Gi", ""},
+		{"2", ""},
+		{"0.5", ""},
+		{".5", ""},
+		{"1e3", ""},
+		{"+1k", ""},
+		{int64(2), ""},
+		{0.25, ""},
+		{1e6, ""},
+		{"512mb", `suffix "mb" is not one of Ki, Mi, Gi, Ti, Pi, Ei, n, u, m, k, M, G, T, P or E`},
+		{"1gi", `suffix "gi" is not one of Ki, Mi, Gi, Ti, Pi, Ei, n, u, m, k, M, G, T, P or E`},
+		{"0,5", `must be a number with '.' as the decimal point, then an optional suffix, not "0,5"`},
+		{"1.5.2", `must be a number with '.' as the decimal point, then an optional suffix, not "1.5.2"`},
+		{"1 Gi", `must be a number with '.' as the decimal point, then an optional suffix, not "1 Gi"`},
+		{"Gi", "must start with a number"},
+		{"", "must start with a number"},
+		{"-1", "must not be negative"},
+		{"-0", ""},
+		{true, "must be a string or a number"},
+		{nil, "must be a string or a number"},
+	}
+	for _, tt := range tests {
+		if got := checkQuantity(tt.value); got != tt.want {
+			t.Errorf("checkQuantity(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCheckResourceName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"cpu", true},
+		{"memory", true},
+		{"ephemeral-storage", true},
+		{"hugepages-2Mi", true},
+		{"nvidia.com/gpu", true},
+		{"gpu", false},
+		{"Example.com/gpu", false},
+		{"example.com/my gpu", false},
+	}
+	for _, tt := range tests {
+		if message := checkResourceName(tt.name); (message == "") != tt.valid {
+			t.Errorf("checkResourceName(%q) = %q, want valid %v", tt.name, message, tt.valid)
+		}
+	}
+}
+
+func TestDecodeValidateQuantities(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []NameViolation
+	}{
+		{
+			name: "Valid quantities",
+			content: "kind: Deployment\nmetadata:\n  name: web\nspec:\n  template:\n    spec:\n      containers:\n      - name: web\n        resources:\n" +
+				"          requests:\n            cpu: 500m\n            memory: 512Mi\n            ephemeral-storage: 1Gi\n          limits:\n            cpu: 1\n            nvidia.com/gpu: 2\n",
+		},
+		{
+			name: "Every invalid request and limit",
+			content: "kind: Pod\nmetadata:\n  name: web\nspec:\n  initContainers:\n  - name: init\n    resources:\n      limits:\n        memory: 512mb\n" +
+				"  containers:\n  - name: web\n    resources:\n      requests:\n        cpu: 0,5\n        gpu: 1\n      limits:\n        example.com/gpu: 0.5\n",
+			want: []NameViolation{
+				{Document: 1, Line: 1, Kind: "Pod", Field: "spec.initContainers[0].resources.limits.memory", Value: "512mb", Rule: RuleQuantity,
+					Message: `suffix "mb" is not one of Ki, Mi, Gi, Ti, Pi, Ei, n, u, m, k, M, G, T, P or E`},
+				{Document: 1, Line: 1, Kind: "Pod", Field: "spec.containers[0].resources.requests.cpu", Value: "0,5", Rule: RuleQuantity,
+					Message: `must be a number with '.' as the decimal point, then an optional suffix, not "0,5"`},
+				{Document: 1, Line: 1, Kind: "Pod", Field: "spec.containers[0].resources.requests.gpu", Value: "gpu", Rule: RuleResourceName,
+					Message: "must be cpu, memory, ephemeral-storage, hugepages-<size> or an extended resource with a domain prefix, such as example.com/gpu"},
+				{Document: 1, Line: 1, Kind: "Pod", Field: `spec.containers[0].resources.limits["example.com/gpu"]`, Value: "0.5", Rule: RuleQuantity,
+					Message: "must be a whole number for an extended resource"},
+			},
+		},
+		{
+			name: "Pod resources and CronJob job templates",
+			content: "kind: ConfigMap\nmetadata:\n  name: app\n---\nkind: CronJob\nmetadata:\n  name: backup\nspec:\n  jobTemplate:\n    spec:\n      template:\n        spec:\n" +
+				"          resources:\n            limits:\n              memory: 1GB\n          containers:\n          - name: dump\n            resources:\n              requests:\n                ephemeral-storage: -1Gi\n",
+			want: []NameViolation{
+				{Document: 2, Line: 5, Kind: "CronJob", Field: "spec.jobTemplate.spec.template.spec.resources.limits.memory", Value: "1GB", Rule: RuleQuantity,
+					Message: `suffix "GB" is not one of Ki, Mi, Gi, Ti, Pi, Ei, n, u, m, k, M, G, T, P or E`},
+				{Document: 2, Line: 5, Kind: "CronJob", Field: "spec.jobTemplate.spec.template.spec.containers[0].resources.requests.ephemeral-storage", Value: "-1Gi", Rule: RuleQuantity,
+					Message: "must not be negative"},
+			},
+		},
+		{
+			name:    "List items",
+			content: "apiVersion: v1\nkind: List\nitems:\n- kind: Pod\n  metadata:\n    name: web\n  spec:\n    containers:\n    - name: web\n      resources:\n        limits:\n          cpu: one\n",
+			want: []NameViolation{{
+				Document: 1, Line: 1, Kind: "Pod", Field: "spec.containers[0].resources.limits.cpu", Value: "one", Rule: RuleQuantity, Message: "must start with a number",
+			}},
+		},
+		{
+			name:    "Not a Kubernetes object",
+			content: "containers:\n- resources:\n    limits:\n      cpu: lots\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode([]byte(tt.content), Options{ValidateQuantities: true})
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				return
+			}
+			var quantityErr *QuantityError
+			if !errors.As(err, &quantityErr) {
+				t.Fatalf("Decode() error = %v, want a *QuantityError", err)
+			}
+			if !reflect.DeepEqual(quantityErr.Violations, tt.want) {
+				t.Errorf("Violations = %+v, want %+v", quantityErr.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantityErrorMessage(t *testing.T) {
+	content := "kind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    resources:\n      limits:\n        memory: 512mb\n"
+	_, err := Convert([]byte(content), Options{ValidateQuantities: true})
+	want := `invalid quantities: Pod: spec.containers[0].resources.limits.memory "512mb" is not a valid quantity: ` +
+		`suffix "mb" is not one of Ki, Mi, Gi, Ti, Pi, Ei, n, u, m, k, M, G, T, P or E`
+	if err == nil || err.Error() != want {
+		t.Errorf("Convert() error = %v, want %s", err, want)
+	}
+	if _, err := Convert([]byte(content), Options{}); err != nil {
+		t.Errorf("Convert() without ValidateQuantities error = %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// querySegment is a single step of a query path: an object field, an array
+// index, or in a delete path the [*] wildcard for every element of an array.
+type querySegment struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func (s querySegment) String() string {
+	if s.wildcard {
+		return "[*]"
+	}
+	if s.isIndex {
+		return "[" + strconv.Itoa(s.index) + "]"
+	}
+	if s.field == "" || strings.ContainsAny(s.field, ".[]\"\\") {
+		return "[" + strconv.Quote(s.field) + "]"
+	}
+	return "." + s.field
+}
+
+// parseQuery parses a query path made of .field and [index] segments, such as
+// .spec.containers[0].image. Fields holding dots, such as annotation names,
+// can be written as ["app.kubernetes.io/name"]. A leading $ and the leading
+// dot are optional, and "." alone selects the whole document.
+func parseQuery(query string) ([]querySegment, error) {
+	return parsePath(query, "query", false)
+}
+
+// parsePath parses a path as parseQuery does, with noun naming the kind of
+// path in errors. A field after a dot may also be double-quoted, as in
+// metadata.annotations."app.kubernetes.io/name", and with wildcards set [*]
+// is accepted for every element of an array.
+func parsePath(query, noun string, wildcards bool) ([]querySegment, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(query), "$")
+	if rest == "" && strings.TrimSpace(query) != "$" {
+		return nil, fmt.Errorf("invalid %s %q: empty path", noun, query)
+	}
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
+	}
+	if rest == "." {
+		return nil, nil
+	}
+
+	var segments []querySegment
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			if strings.HasPrefix(rest, `."`) {
+				quoted, err := strconv.QuotedPrefix(rest[1:])
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s %q: unterminated quoted field", noun, query)
+				}
+				field, _ := strconv.Unquote(quoted)
+				segments = append(segments, querySegment{field: field})
+				rest = rest[len(quoted)+1:]
+				continue
+			}
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("invalid %s %q: empty field name", noun, query)
+			}
+			segments = append(segments, querySegment{field: name})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid %s %q: missing ]", noun, query)
+			}
+			if wildcards && rest[:end+1] == "[*]" {
+				segments = append(segments, querySegment{wildcard: true})
+				rest = rest[end+1:]
+				continue
+			}
+			if strings.HasPrefix(rest, `["`) {
+				// A double-quoted field is a Go string literal, so that
+				// querySegment.String is parsed back to the same field
+				quoted, err := strconv.QuotedPrefix(rest[1:])
+				if err != nil || !strings.HasPrefix(rest[1+len(quoted):], "]") {
+					return nil, fmt.Errorf("invalid %s %q: unterminated quoted field", noun, query)
+				}
+				field, _ := strconv.Unquote(quoted)
+				segments = append(segments, querySegment{field: field})
+				rest = rest[len(quoted)+2:]
+				continue
+			}
+			if strings.HasPrefix(rest, "['") {
+				// A quoted field may itself contain ]
+				closing := strings.Index(rest[2:], rest[1:2]+"]")
+				if closing < 0 {
+					return nil, fmt.Errorf("invalid %s %q: unterminated quoted field", noun, query)
+				}
+				segments = append(segments, querySegment{field: rest[2 : closing+2]})
+				rest = rest[closing+4:]
+				continue
+			}
+			inner := rest[1:end]
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid %s %q: array index %q is not a non-negative integer", noun, query, inner)
+			}
+			segments = append(segments, querySegment{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid %s %q: unexpected %q", noun, query, rest[0])
+		}
+	}
+	return segments, nil
+}
+
+// Query returns the value at the query path in v, a decoded document. The
+// path is made of .field and [index] segments, such as
+// .spec.containers[0].image. A path that does not exist in v returns a
+// *QueryError naming the missing segment.
+func Query(v interface{}, query string) (interface{}, error) {
+	segments, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return querySegments(v, query, segments)
+}
+
+// querySegments follows segments from v.
+func querySegments(v interface{}, query string, segments []querySegment) (interface{}, error) {
+	path := ""
+	for _, segment := range segments {
+		queryErr := &QueryError{Query: query, Path: pathOrRoot(path), Segment: segment.String()}
+		if segment.isIndex {
+			items, ok := v.([]interface{})
+			switch {
+			case !ok:
+				queryErr.Message = fmt.Sprintf("cannot index %s with %s: not an array", queryErr.Path, segment)
+				return nil, queryErr
+			case segment.index >= len(items):
+				queryErr.Message = fmt.Sprintf("index %s out of range at %s, which has %d items", segment, queryErr.Path, len(items))
+				return nil, queryErr
+			}
+			v = items[segment.index]
+		} else {
+			object, ok := v.(*Object)
+			if !ok {
+				queryErr.Message = fmt.Sprintf("no field %q at %s: not an object", segment.field, queryErr.Path)
+				return nil, queryErr
+			}
+			if v, ok = object.Get(segment.field); !ok {
+				queryErr.Message = fmt.Sprintf("no field %q at %s", segment.field, queryErr.Path)
+				return nil, queryErr
+			}
+		}
+		path += segment.String()
+	}
+	return v, nil
+}
+
+// queryDocuments returns the value at opts.Query in each document.
+func queryDocuments(documents []Document, opts Options) ([]interface{}, error) {
+	segments, err := parseQuery(opts.Query)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(documents))
+	for i, doc := range documents {
+		// Empty documents stay null, keeping the results in position
+		if doc.Empty {
+			continue
+		}
+		value, err := querySegments(doc.Value, opts.Query, segments)
+		if err != nil {
+			var queryErr *QueryError
+			if errors.As(err, &queryErr) {
+				queryErr.Document = doc.Index
+			}
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// marshalValue encodes v with marshalJSON, or as the bare string when
+// opts.Raw is set and v is a string.
+func marshalValue(v interface{}, opts Options) ([]byte, error) {
+	if text, ok := v.(string); ok && opts.Raw {
+		return []byte(text), nil
+	}
+	return marshalJSON(v, opts)
+}
+
+// marshalQueryResults encodes the query result of each document, one after
+// another, separated by newlines. Canonical output ends with a newline.
+func marshalQueryResults(values []interface{}, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, value := range values {
+		data, err := marshalValue(value, opts)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.Write(data)
+	}
+	if opts.Canonical && len(values) > 0 {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
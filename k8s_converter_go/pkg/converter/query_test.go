@@ -0,0 +1,149 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const queryContent = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    app.kubernetes.io/name: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+        - name: sidecar
+          image: envoy:1.28
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: api
+          image: api:2.0
+`
+
+func TestConvertQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "string per document",
+			opts: Options{Query: ".spec.template.spec.containers[0].image"},
+			want: "\"nginx:1.25\"\n\"api:2.0\"",
+		},
+		{
+			name: "raw strings",
+			opts: Options{Query: ".spec.template.spec.containers[0].image", Raw: true},
+			want: "nginx:1.25\napi:2.0",
+		},
+		{
+			name: "without leading dot",
+			opts: Options{Query: "spec.replicas", Raw: true},
+			want: "3\n1",
+		},
+		{
+			name: "objects",
+			opts: Options{Query: ".metadata", Compact: true},
+			want: `{"name":"web","annotations":{"app.kubernetes.io/name":"web"}}` + "\n" + `{"name":"api"}`,
+		},
+		{
+			name: "NDJSON",
+			opts: Options{Query: "$.metadata.name", Format: FormatNDJSON},
+			want: "\"web\"\n\"api\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(queryContent), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertQueryMissing(t *testing.T) {
+	tests := []struct {
+		query       string
+		wantSegment string
+		want        string
+	}{
+		{".spec.template.spec.containers[1].image", "[1]",
+			"query .spec.template.spec.containers[1].image: index [1] out of range at .spec.template.spec.containers, which has 1 items in document 2"},
+		{".spec.strategy.type", ".strategy", `query .spec.strategy.type: no field "strategy" at .spec`},
+		{".spec.replicas.count", ".count", `query .spec.replicas.count: no field "count" at .spec.replicas: not an object`},
+		{".metadata[0]", "[0]", "query .metadata[0]: cannot index .metadata with [0]: not an array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Convert([]byte(queryContent), Options{Query: tt.query})
+			var queryErr *QueryError
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("Convert() error = %v, want *QueryError", err)
+			}
+			if queryErr.Segment != tt.wantSegment {
+				t.Errorf("Segment = %q, want %q", queryErr.Segment, tt.wantSegment)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("Convert() error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	documents, err := Decode([]byte(queryContent), Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	tests := []struct {
+		query string
+		want  interface{}
+	}{
+		{`.metadata.annotations["app.kubernetes.io/name"]`, "web"},
+		{`.metadata.annotations['app.kubernetes.io/name']`, "web"},
+		{`metadata.annotations."app.kubernetes.io/name"`, "web"},
+		{`.spec.template.spec.containers[1].name`, "sidecar"},
+		{`$`, documents[0].Value},
+		{`.`, documents[0].Value},
+	}
+	for _, tt := range tests {
+		got, err := Query(documents[0].Value, tt.query)
+		if err != nil {
+			t.Errorf("Query(%q) error = %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Query(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryInvalid(t *testing.T) {
+	for _, query := range []string{"", ".spec..name", ".items[-1]", ".items[x]", ".items[0", `.a["b]`} {
+		_, err := Query(NewObject(), query)
+		if err == nil || !strings.HasPrefix(err.Error(), "invalid query") {
+			t.Errorf("Query(%q) error = %v, want invalid query", query, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// verifyRoundTrip checks that doc survives being written as JSON, converted
+// back to YAML and read again. Both sides are compared in their normalized
+// form: key order, quoting and comments are not compared, and numbers are
+// compared by value, as Diff does. The first difference is returned as a
+// *RoundTripError.
+func verifyRoundTrip(doc Document) error {
+	data, err := json.Marshal(doc.Value)
+	if err != nil {
+		return &EncodeError{Format: "JSON", Err: err}
+	}
+	value, err := decodeJSON(data)
+	if err != nil {
+		return err
+	}
+	yamlData, err := marshalYAML([]interface{}{value})
+	if err != nil {
+		return err
+	}
+	documents, err := parseDocuments(yamlData, Options{})
+	if err != nil {
+		return err
+	}
+	var got interface{}
+	if len(documents) > 0 {
+		if got, err = documents[0].decode(Options{}); err != nil {
+			return err
+		}
+	}
+	var difference *Difference
+	walkDifferences(doc.Value, got, "", func(d Difference) bool {
+		difference = &d
+		return false
+	})
+	if difference == nil {
+		return nil
+	}
+	roundTripErr := &RoundTripError{Document: doc.Index, Path: difference.Path, Original: "missing", RoundTrip: "missing"}
+	if difference.Kind != DiffAdded {
+		roundTripErr.Original = formatValue(difference.Old)
+	}
+	if difference.Kind != DiffRemoved {
+		roundTripErr.RoundTrip = formatValue(difference.New)
+	}
+	return roundTripErr
+}
+
+// decodeJSON decodes a JSON value, keeping the exact digits of numbers as
+// json.Number.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, &ParseError{Format: "JSON", Err: err}
+	}
+	return value, nil
+}
+
+// marshalYAML encodes each of documents, decoded by decodeJSON, as a YAML
+// document. Numbers are written with their JSON digits.
+func marshalYAML(documents []interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range documents {
+		if err := encoder.Encode(yamlNumbers(doc)); err != nil {
+			return nil, &EncodeError{Format: "YAML", Err: err}
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, &EncodeError{Format: "YAML", Err: err}
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlNumbers replaces the json.Number values in v, which the YAML encoder
+// would write as strings, with int and float scalar nodes holding the same
+// digits.
+func yamlNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = yamlNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = yamlNumbers(value)
+		}
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(string(v), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(v)}
+	}
+	return v
+}
@@ -0,0 +1,93 @@
+package converter
+
+import "testing"
+
+func TestVerifyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+	}{
+		{
+			name: "numbers",
+			input: `big: 123456789012345678901234567890
+negative: -9223372036854775809
+hex: 0x1F
+float: 1.0
+exponent: 1e3
+fraction: .5
+zero: -0.0
+`,
+		},
+		{
+			name: "strings that look like other types",
+			input: `a: "yes"
+b: "1.0"
+c: "null"
+d: "~"
+e: "2023-05-01"
+f: "0x1F"
+g: ""
+h: "true"
+i: "- item"
+j: "key: value"
+`,
+		},
+		{
+			name:  "unquoted YAML 1.1 booleans",
+			input: "a: yes\nb: off\nc: true\n",
+		},
+		{
+			name:  "YAML 1.1 booleans decoded as booleans",
+			input: "a: yes\nb: off\n",
+			opts:  Options{YAML11Bools: true},
+		},
+		{
+			name:  "timestamps",
+			input: "date: 2023-05-01\ntime: 2023-05-01T10:00:00.5+02:00\n",
+		},
+		{
+			name:  "raw timestamps",
+			input: "date: 2023-05-01\n",
+			opts:  Options{RawTimestamps: true},
+		},
+		{
+			name:  "binary",
+			input: "data: !!binary aGVsbG8=\n",
+		},
+		{
+			name:  "non-string keys",
+			input: "1: one\ntrue: yes\nnull: none\n2023-05-01: date\n",
+		},
+		{
+			name:  "special keys and values",
+			input: "\"<<\": merge\n\"a.b\": dotted\nmultiline: |\n  first\n  second\nunicode: \"caf\\u00e9 \\t\"\nempty: {}\nlist: []\nnothing: null\n",
+		},
+		{
+			name:  "sorted keys and comments",
+			input: "b: 1 # one\na: [x, {y: 2}]\n",
+			opts:  Options{SortKeys: true, KeepComments: true},
+		},
+		{
+			name:  "several documents",
+			input: "kind: A\n---\nkind: B\nspec:\n  replicas: 3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.VerifyRoundTrip = true
+			if _, err := Convert([]byte(tt.input), tt.opts); err != nil {
+				t.Errorf("Convert() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestRoundTripError(t *testing.T) {
+	err := &RoundTripError{Document: 2, Path: ".spec.replicas", Original: "3", RoundTrip: `"3"`}
+	want := `round trip through JSON changed .spec.replicas in document 2: 3 became "3"`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,497 @@
+package converter
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SchemaKubernetesVersion is the Kubernetes version of the embedded schemas
+// used by Options.SchemaValidate when Options.SchemaDir is not set.
+const SchemaKubernetesVersion = "1.30"
+
+// embeddedSchemas is a trimmed copy of the Kubernetes OpenAPI v2 document
+// covering the common built-in kinds. Deeply nested types such as probes and
+// volumes are only checked to be objects.
+//
+//go:embed schemas/kubernetes.json
+var embeddedSchemas []byte
+
+var (
+	embeddedSchemaSet     *schemaSet
+	embeddedSchemaSetErr  error
+	embeddedSchemaSetOnce sync.Once
+)
+
+// quantityDefinition is the Kubernetes resource quantity type, which is a
+// string in the OpenAPI documents but is often written as a number, such as
+// cpu: 1.
+const quantityDefinition = "io.k8s.apimachinery.pkg.api.resource.Quantity"
+
+// objectMetaDefinition is the type of the metadata of every Kubernetes object.
+const objectMetaDefinition = "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"
+
+// SchemaViolation describes a value that does not match the OpenAPI schema
+// of its document's kind.
+type SchemaViolation struct {
+	// Document is the 1-based position of the document in the stream.
+	Document int
+	// Kind and Name are the kind and metadata.name of the document.
+	Kind string
+	Name string
+	// Path is the location of the value in the document, such as
+	// ".spec.replicas", or "." for the whole document.
+	Path    string
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	message := v.Message
+	if v.Path != "." {
+		message = v.Path + ": " + message
+	}
+	if v.Name != "" {
+		message = v.Kind + "/" + v.Name + ": " + message
+	} else if v.Kind != "" {
+		message = v.Kind + ": " + message
+	}
+	if v.Document > 1 {
+		message += fmt.Sprintf(" (document %d)", v.Document)
+	}
+	return message
+}
+
+// schema is the part of an OpenAPI v2 or v3 schema used to validate
+// documents.
+type schema struct {
+	Ref                   string                `json:"$ref"`
+	Type                  schemaTypes           `json:"type"`
+	Format                string                `json:"format"`
+	Properties            map[string]*schema    `json:"properties"`
+	AdditionalProperties  *additionalProperties `json:"additionalProperties"`
+	Items                 *schema               `json:"items"`
+	Required              []string              `json:"required"`
+	Enum                  []interface{}         `json:"enum"`
+	AllOf                 []*schema             `json:"allOf"`
+	IntOrString           bool                  `json:"x-kubernetes-int-or-string"`
+	PreserveUnknownFields bool                  `json:"x-kubernetes-preserve-unknown-fields"`
+	GroupVersionKinds     []groupVersionKind    `json:"x-kubernetes-group-version-kind"`
+
+	// quantity is set for resource quantities, which accept numbers.
+	quantity bool
+}
+
+// schemaTypes holds the type of a schema, which may be written as a single
+// string or a list of strings.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// additionalProperties holds the additionalProperties of a schema, which is
+// either a boolean or the schema of every additional property.
+type additionalProperties struct {
+	allowed bool
+	schema  *schema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+	a.allowed = true
+	return json.Unmarshal(data, &a.schema)
+}
+
+type groupVersionKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// apiVersion returns the apiVersion of documents of the kind, such as
+// "apps/v1", or "v1" for the core group.
+func (gvk groupVersionKind) apiVersion() string {
+	if gvk.Group == "" {
+		return gvk.Version
+	}
+	return gvk.Group + "/" + gvk.Version
+}
+
+// schemaDocument is an OpenAPI v2 document with definitions, such as the
+// Kubernetes swagger.json, or an OpenAPI v3 document with component schemas.
+type schemaDocument struct {
+	Definitions map[string]*schema `json:"definitions"`
+	Components  struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// schemaSet holds the schema definitions loaded from OpenAPI documents and
+// the definition of each kind, keyed by apiVersion and kind.
+type schemaSet struct {
+	definitions map[string]*schema
+	kinds       map[string]*schema
+}
+
+// loadSchemas returns the schemas selected by opts: those in the OpenAPI
+// documents of opts.SchemaDir, or the embedded Kubernetes schemas.
+func loadSchemas(opts Options) (*schemaSet, error) {
+	if opts.SchemaDir == "" {
+		embeddedSchemaSetOnce.Do(func() {
+			embeddedSchemaSet = newSchemaSet()
+			embeddedSchemaSetErr = embeddedSchemaSet.add(embeddedSchemas)
+		})
+		return embeddedSchemaSet, embeddedSchemaSetErr
+	}
+
+	entries, err := os.ReadDir(opts.SchemaDir)
+	if err != nil {
+		return nil, &IOError{Op: "read", Path: opts.SchemaDir, Err: err}
+	}
+	set := newSchemaSet()
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(opts.SchemaDir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, &IOError{Op: "read", Path: path, Err: err}
+		}
+		if err := set.add(data); err != nil {
+			return nil, fmt.Errorf("invalid OpenAPI schema file %s: %w", path, err)
+		}
+	}
+	if len(set.kinds) == 0 {
+		return nil, fmt.Errorf("no Kubernetes kinds found in the OpenAPI schema files of %s", opts.SchemaDir)
+	}
+	return set, nil
+}
+
+func newSchemaSet() *schemaSet {
+	return &schemaSet{definitions: make(map[string]*schema), kinds: make(map[string]*schema)}
+}
+
+// add adds the definitions of an OpenAPI document to the set.
+func (s *schemaSet) add(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document schemaDocument
+	if err := decoder.Decode(&document); err != nil {
+		return err
+	}
+	for _, definitions := range []map[string]*schema{document.Definitions, document.Components.Schemas} {
+		for name, definition := range definitions {
+			if definition == nil {
+				continue
+			}
+			definition.quantity = name == quantityDefinition
+			s.definitions[name] = definition
+			for _, gvk := range definition.GroupVersionKinds {
+				s.kinds[kindKey(gvk.apiVersion(), gvk.Kind)] = definition
+			}
+		}
+	}
+	return nil
+}
+
+func kindKey(apiVersion, kind string) string {
+	return apiVersion + " " + kind
+}
+
+// resolve follows the $ref of sc to the definition it names. It returns nil
+// when the definition is not in the set, so that the value is not checked.
+func (s *schemaSet) resolve(sc *schema) *schema {
+	for sc != nil && sc.Ref != "" {
+		sc = s.definitions[sc.Ref[strings.LastIndex(sc.Ref, "/")+1:]]
+	}
+	return sc
+}
+
+// clone returns a copy of the set that kinds can be added to without
+// changing s.
+func (s *schemaSet) clone() *schemaSet {
+	copied := newSchemaSet()
+	for name, definition := range s.definitions {
+		copied.definitions[name] = definition
+	}
+	for key, definition := range s.kinds {
+		copied.kinds[key] = definition
+	}
+	return copied
+}
+
+// validateDocument checks the value of doc against the schema of its
+// apiVersion and kind and returns every violation, or only the unknown fields
+// with opts.StrictFields and without opts.SchemaValidate. Documents of kinds
+// without a schema, such as custom resources without a CRD, are skipped with
+// a warning, or reported as a violation with opts.RequireSchema.
+func (s *schemaSet) validateDocument(doc Document, opts Options) []SchemaViolation {
+	apiVersion, kind := doc.APIVersion(), doc.Kind()
+	validator := &schemaValidator{schemas: s, document: doc, unknownOnly: opts.StrictFields && !opts.SchemaValidate}
+	definition := s.kinds[kindKey(apiVersion, kind)]
+	if definition == nil {
+		message := fmt.Sprintf("no schema for %s %s", apiVersion, kind)
+		if apiVersion == "" || kind == "" {
+			message = "document has no apiVersion or kind"
+		}
+		if opts.RequireSchema {
+			validator.add("", message)
+		} else {
+			opts.warn(Warning{Document: doc.Index, Line: doc.Line, Message: message + "; skipping schema validation"})
+		}
+		return validator.violations
+	}
+	validator.validate(definition, doc.Value, "")
+	return validator.violations
+}
+
+// schemaValidator collects the violations found in a single document.
+type schemaValidator struct {
+	schemas  *schemaSet
+	document Document
+	// unknownOnly reports unknown fields only, as Options.StrictFields does.
+	unknownOnly bool
+	violations  []SchemaViolation
+}
+
+// report adds a violation other than an unknown field, unless only unknown
+// fields are reported.
+func (v *schemaValidator) report(path, message string) {
+	if !v.unknownOnly {
+		v.add(path, message)
+	}
+}
+
+func (v *schemaValidator) add(path, message string) {
+	v.violations = append(v.violations, SchemaViolation{
+		Document: v.document.Index,
+		Kind:     v.document.Kind(),
+		Name:     v.document.Name(),
+		Path:     pathOrRoot(path),
+		Message:  message,
+	})
+}
+
+// validate checks value against sc. Null values are accepted everywhere, as
+// Kubernetes treats them as unset.
+func (v *schemaValidator) validate(sc *schema, value interface{}, path string) {
+	sc = v.schemas.resolve(sc)
+	if sc == nil || value == nil {
+		return
+	}
+	for _, part := range sc.AllOf {
+		v.validate(part, value, path)
+	}
+	if !v.checkType(sc, value, path) {
+		return
+	}
+	if len(sc.Enum) > 0 && !enumContains(sc.Enum, value) {
+		v.report(path, fmt.Sprintf("value %s is not one of %s", formatEnumValue(value), formatEnum(sc.Enum)))
+	}
+
+	switch value := value.(type) {
+	case *Object:
+		v.validateObject(sc, value, path)
+	case []interface{}:
+		if sc.Items != nil {
+			for i, item := range value {
+				v.validate(sc.Items, item, path+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	case json.Number:
+		v.checkRange(sc, value, path)
+	}
+}
+
+// validateObject checks the fields of an object. An object schema listing
+// properties rejects fields it does not list, unless it allows additional
+// properties or preserves unknown fields.
+func (v *schemaValidator) validateObject(sc *schema, object *Object, path string) {
+	for _, name := range sc.Required {
+		if _, ok := object.Get(name); !ok {
+			v.report(path+querySegment{field: name}.String(), "missing required field")
+		}
+	}
+	for _, key := range object.Keys() {
+		// Comments kept by Options.KeepComments are not part of the resource
+		if path == "" && key == CommentsKey {
+			continue
+		}
+		value, _ := object.Get(key)
+		fieldPath := path + querySegment{field: key}.String()
+		if property, ok := sc.Properties[key]; ok {
+			v.validate(property, value, fieldPath)
+			continue
+		}
+		switch {
+		case sc.AdditionalProperties != nil && sc.AdditionalProperties.allowed:
+			v.validate(sc.AdditionalProperties.schema, value, fieldPath)
+		case sc.AdditionalProperties != nil || (len(sc.Properties) > 0 && !sc.PreserveUnknownFields):
+			message := "unknown field"
+			if suggestion := nearestProperty(sc, key); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			v.add(fieldPath, message)
+		}
+	}
+}
+
+// nearestProperty returns the property of sc closest to key by edit
+// distance, the first in sorted order on a tie, or "" when none is close
+// enough to be a likely misspelling: within 2 edits, or a third of the
+// length of key for longer keys.
+func nearestProperty(sc *schema, key string) string {
+	names := make([]string, 0, len(sc.Properties))
+	for name := range sc.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	limit := len(key) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	nearest, best := "", limit+1
+	for _, name := range names {
+		if distance := editDistance(key, name); distance < best {
+			nearest, best = name, distance
+		}
+	}
+	return nearest
+}
+
+// editDistance returns the Levenshtein distance between a and b, the number
+// of single-byte insertions, deletions and substitutions between them.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// checkType reports a value whose JSON type is not allowed by sc and returns
+// whether the type matched.
+func (v *schemaValidator) checkType(sc *schema, value interface{}, path string) bool {
+	if len(sc.Type) == 0 {
+		return true
+	}
+	got := jsonType(value)
+	for _, want := range sc.Type {
+		if want == got || (want == "number" && got == "integer") {
+			return true
+		}
+		if want == "string" && (sc.IntOrString || sc.Format == "int-or-string") && got == "integer" {
+			return true
+		}
+		if want == "string" && sc.quantity && (got == "integer" || got == "number") {
+			return true
+		}
+	}
+	v.report(path, fmt.Sprintf("expected %s, got %s", strings.Join(sc.Type, " or "), got))
+	return false
+}
+
+// checkRange reports integers that do not fit the int32 or int64 format of
+// sc.
+func (v *schemaValidator) checkRange(sc *schema, number json.Number, path string) {
+	bits := map[string]int{"int32": 32, "int64": 64}[sc.Format]
+	if bits == 0 || jsonType(number) != "integer" {
+		return
+	}
+	if _, err := strconv.ParseInt(number.String(), 10, bits); err != nil {
+		v.report(path, fmt.Sprintf("value %s is out of range for %s", number, sc.Format))
+	}
+}
+
+// jsonType returns the JSON schema type name of a decoded value.
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case *Object:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if strings.ContainsAny(value.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) && jsonType(allowed) == jsonType(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, value := range enum {
+		values[i] = formatEnumValue(value)
+	}
+	sort.Strings(values)
+	return strings.Join(values, ", ")
+}
+
+func formatEnumValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+// validateSchemas checks the decoded documents against schemas and returns a
+// *SchemaError listing every violation.
+func validateSchemas(schemas *schemaSet, documents []Document, opts Options) error {
+	if schemas == nil {
+		return nil
+	}
+	var violations []SchemaViolation
+	for _, doc := range documents {
+		if doc.Empty {
+			continue
+		}
+		violations = append(violations, schemas.validateDocument(doc, opts)...)
+	}
+	if len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+	return nil
+}
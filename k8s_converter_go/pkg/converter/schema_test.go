@@ -0,0 +1,394 @@
+package converter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchemas(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		want         []SchemaViolation
+		wantWarnings []string
+	}{
+		{
+			name: "valid deployment",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app.kubernetes.io/name: web
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: web
+  strategy:
+    rollingUpdate:
+      maxSurge: 25%
+      maxUnavailable: 1
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+          ports:
+            - containerPort: 80
+          resources:
+            limits:
+              cpu: 0.5
+              memory: 1Gi
+          livenessProbe:
+            httpGet:
+              path: /
+              port: 80
+`,
+		},
+		{
+			name: "unknown field and wrong type",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replica: 3
+  selector:
+    matchLabels:
+      app: web
+  template:
+    spec:
+      containers:
+        - name: web
+          ports:
+            - containerPort: "80"
+`,
+			want: []SchemaViolation{
+				{Document: 1, Kind: "Deployment", Name: "web", Path: ".spec.replica", Message: `unknown field, did you mean "replicas"?`},
+				{Document: 1, Kind: "Deployment", Name: "web", Path: ".spec.template.spec.containers[0].ports[0].containerPort", Message: "expected integer, got string"},
+			},
+		},
+		{
+			name: "missing required fields",
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - targetPort: http
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec: {}
+`,
+			want: []SchemaViolation{
+				{Document: 1, Kind: "Service", Name: "web", Path: ".spec.ports[0].port", Message: "missing required field"},
+				{Document: 2, Kind: "Deployment", Name: "web", Path: ".spec.selector", Message: "missing required field"},
+				{Document: 2, Kind: "Deployment", Name: "web", Path: ".spec.template", Message: "missing required field"},
+			},
+		},
+		{
+			name: "map values and ranges",
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  labels:
+    tier: [web]
+data:
+  enabled: true
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 4294967296
+  selector: {}
+  template:
+    spec:
+      containers: []
+`,
+			want: []SchemaViolation{
+				{Document: 1, Kind: "ConfigMap", Name: "config", Path: ".metadata.labels.tier", Message: "expected string, got array"},
+				{Document: 1, Kind: "ConfigMap", Name: "config", Path: ".data.enabled", Message: "expected string, got boolean"},
+				{Document: 2, Kind: "Deployment", Name: "web", Path: ".spec.replicas", Message: "value 4294967296 is out of range for int32"},
+			},
+		},
+		{
+			name: "null values are accepted",
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  labels: null
+spec:
+  selector: ~
+`,
+		},
+		{
+			name: "unknown kinds are skipped",
+			input: `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+spec:
+  anything: true
+---
+metadata:
+  name: no-kind
+`,
+			wantWarnings: []string{
+				"line 1: no schema for example.com/v1 Widget; skipping schema validation",
+				"line 8: document has no apiVersion or kind; skipping schema validation (document 2)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			opts := Options{SchemaValidate: true, Warn: func(w Warning) {
+				warnings = append(warnings, w.String())
+			}}
+			err := Validate([]byte(tt.input), opts)
+			var got []SchemaViolation
+			var schemaErr *SchemaError
+			if errors.As(err, &schemaErr) {
+				got = schemaErr.Violations
+			} else if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() violations = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("Validate() warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestSchemaErrorMessage(t *testing.T) {
+	err := &SchemaError{Violations: []SchemaViolation{
+		{Document: 1, Kind: "Deployment", Name: "web", Path: ".spec.replica", Message: "unknown field"},
+		{Document: 3, Kind: "Service", Path: ".spec.ports[0].port", Message: "missing required field"},
+		{Document: 4, Kind: "Widget", Name: "w", Path: ".", Message: "no schema for example.com/v1 Widget"},
+	}}
+	want := "schema validation failed: Deployment/web: .spec.replica: unknown field; " +
+		"Service: .spec.ports[0].port: missing required field (document 3); " +
+		"Widget/w: no schema for example.com/v1 Widget (document 4)"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestStrictFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		want         []SchemaViolation
+		wantWarnings []string
+	}{
+		{
+			name: "misspelled fields with suggestions",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  lables:
+    app: web
+spec:
+  replica: 3
+  template:
+    spec:
+      containers:
+        - name: web
+          imagePullPolcy: Always
+          ports:
+            - containerPort: "80"
+          xyz: true
+`,
+			want: []SchemaViolation{
+				{Document: 1, Kind: "Deployment", Name: "web", Path: ".metadata.lables", Message: `unknown field, did you mean "labels"?`},
+				{Document: 1, Kind: "Deployment", Name: "web", Path: ".spec.replica", Message: `unknown field, did you mean "replicas"?`},
+				{Document: 1, Kind: "Deployment", Name: "web", Path: ".spec.template.spec.containers[0].imagePullPolcy", Message: `unknown field, did you mean "imagePullPolicy"?`},
+				{Document: 1, Kind: "Deployment", Name: "web", Path: ".spec.template.spec.containers[0].xyz", Message: "unknown field"},
+			},
+		},
+		{
+			name: "preserved unknown fields",
+			input: `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+spec:
+  size: 3
+  config:
+    anything: goes
+`,
+		},
+		{
+			name:         "kind without a schema",
+			input:        "apiVersion: example.com/v1\nkind: Gadget\nmetadata:\n  name: g\nspec:\n  anything: goes\n",
+			wantWarnings: []string{"line 1: no schema for example.com/v1 Gadget; skipping schema validation"},
+		},
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "widget.json"), []byte(widgetSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			opts := Options{StrictFields: true, Warn: func(w Warning) { warnings = append(warnings, w.String()) }}
+			if strings.HasPrefix(tt.input, "apiVersion: example.com") {
+				opts.SchemaDir = dir
+			}
+			_, err := Decode([]byte(tt.input), opts)
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				return
+			}
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("Decode() error = %v, want a *SchemaError", err)
+			}
+			if !reflect.DeepEqual(schemaErr.Violations, tt.want) {
+				t.Errorf("Violations = %v, want %v", schemaErr.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearestProperty(t *testing.T) {
+	sc := &schema{Properties: map[string]*schema{"replicas": nil, "selector": nil, "template": nil, "strategy": nil, "minReadySeconds": nil}}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"replica", "replicas"},
+		{"Replicas", "replicas"},
+		{"selecter", "selector"},
+		{"minReadySecs", "minReadySeconds"},
+		{"paused", ""},
+		{"xy", ""},
+	}
+	for _, tt := range tests {
+		if got := nearestProperty(sc, tt.key); got != tt.want {
+			t.Errorf("nearestProperty(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+const widgetSchema = `{
+  "openapi": "3.0.0",
+  "components": {
+    "schemas": {
+      "com.example.v1.Widget": {
+        "type": "object",
+        "required": ["spec"],
+        "properties": {
+          "apiVersion": {"type": "string"},
+          "kind": {"type": "string"},
+          "metadata": {"allOf": [{"$ref": "#/components/schemas/Metadata"}]},
+          "spec": {
+            "type": "object",
+            "properties": {
+              "size": {"type": "string", "enum": ["small", "large"]},
+              "port": {"x-kubernetes-int-or-string": true},
+              "config": {"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+              "tags": {"type": "object", "additionalProperties": false}
+            }
+          }
+        },
+        "x-kubernetes-group-version-kind": [{"group": "example.com", "version": "v1", "kind": "Widget"}]
+      },
+      "Metadata": {
+        "type": "object",
+        "properties": {"name": {"type": "string"}}
+      }
+    }
+  }
+}
+`
+
+func TestValidateSchemaDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "widgets.json"), []byte(widgetSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  namespace: default
+spec:
+  size: medium
+  port: 8080
+  config:
+    anything: [1, 2]
+  tags:
+    team: web
+`
+	err := Validate([]byte(input), Options{SchemaValidate: true, SchemaDir: dir})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Validate() error = %v, want *SchemaError", err)
+	}
+	want := []SchemaViolation{
+		{Document: 1, Kind: "Widget", Name: "widget", Path: ".metadata.namespace", Message: "unknown field"},
+		{Document: 1, Kind: "Widget", Name: "widget", Path: ".spec.size", Message: `value "medium" is not one of "large", "small"`},
+		{Document: 1, Kind: "Widget", Name: "widget", Path: ".spec.tags.team", Message: "unknown field"},
+	}
+	if !reflect.DeepEqual(schemaErr.Violations, want) {
+		t.Errorf("Validate() violations = %v, want %v", schemaErr.Violations, want)
+	}
+
+	// Kinds of the embedded schemas are not known from the directory
+	var warnings []Warning
+	err = Validate([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"), Options{
+		SchemaValidate: true,
+		SchemaDir:      dir,
+		Warn:           func(w Warning) { warnings = append(warnings, w) },
+	})
+	if err != nil || len(warnings) != 1 {
+		t.Errorf("Validate() = %v with warnings %v, want one warning", err, warnings)
+	}
+}
+
+func TestValidateSchemaDirErrors(t *testing.T) {
+	empty := t.TempDir()
+	invalid := t.TempDir()
+	if err := os.WriteFile(filepath.Join(invalid, "swagger.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n")
+	for name, dir := range map[string]string{
+		"missing": filepath.Join(empty, "missing"),
+		"empty":   empty,
+		"invalid": invalid,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := Validate(input, Options{SchemaValidate: true, SchemaDir: dir}); err == nil {
+				t.Errorf("Validate() error = nil, want an error")
+			}
+		})
+	}
+}
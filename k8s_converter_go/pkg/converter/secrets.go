@@ -0,0 +1,101 @@
+package converter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeSecret base64-decodes the values under data in a Secret, decoded
+// from node in the given document, and moves
+// them into stringData, so that they can be read as plain text. Values that
+// are not valid base64 or do not decode to UTF-8 text are left under data
+// and reported as warnings. Keys already present in stringData, which take
+// precedence over data in the Kubernetes API, are left alone. Documents of
+// other kinds are not changed.
+func decodeSecret(document int, node *yaml.Node, v interface{}, opts Options) {
+	object, ok := v.(*Object)
+	if !ok || stringField(object, "kind") != "Secret" {
+		return
+	}
+	data, ok := field(object, "data").(*Object)
+	if !ok {
+		return
+	}
+	stringData, ok := field(object, "stringData").(*Object)
+	if !ok {
+		if field(object, "stringData") != nil {
+			return
+		}
+		stringData = NewObject()
+	}
+
+	dataNode := mappingValue(node, "data")
+	for _, key := range data.Keys() {
+		encoded, ok := field(data, key).(string)
+		if !ok {
+			continue
+		}
+		if _, exists := stringData.Get(key); exists {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || !utf8.Valid(decoded) {
+			problem := "is not valid base64"
+			if err == nil {
+				problem = "does not decode to UTF-8 text"
+			}
+			warning := Warning{Document: document, Message: fmt.Sprintf("Secret data key %q %s; leaving it encoded", key, problem)}
+			if node := mappingValue(dataNode, key); node != nil {
+				warning.Line = node.Line
+			}
+			opts.warn(warning)
+			continue
+		}
+		stringData.Set(key, string(decoded))
+		data.Delete(key)
+	}
+
+	if stringData.Len() > 0 {
+		object.Set("stringData", stringData)
+	}
+	if data.Len() == 0 {
+		object.Delete("data")
+	}
+}
+
+// RedactedValue replaces each redacted value when Options.RedactSecrets is
+// set.
+const RedactedValue = "***REDACTED***"
+
+// sensitiveAnnotations are the annotations of a Secret whose values can hold
+// its data and are redacted along with it.
+var sensitiveAnnotations = []string{
+	lastAppliedAnnotation,
+}
+
+// redactSecret replaces every value under data and stringData in a Secret,
+// and the values of its sensitiveAnnotations, with RedactedValue. The keys
+// are kept. Documents of other kinds are not changed.
+func redactSecret(v interface{}) {
+	object, ok := v.(*Object)
+	if !ok || stringField(object, "kind") != "Secret" {
+		return
+	}
+	for _, name := range []string{"data", "stringData"} {
+		if values, ok := field(object, name).(*Object); ok {
+			for _, key := range values.keys {
+				values.values[key] = RedactedValue
+			}
+		}
+	}
+	if annotations, ok := field(field(object, "metadata"), "annotations").(*Object); ok {
+		for _, name := range sensitiveAnnotations {
+			if _, ok := annotations.Get(name); ok {
+				annotations.Set(name, RedactedValue)
+			}
+		}
+	}
+}
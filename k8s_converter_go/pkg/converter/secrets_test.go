@@ -0,0 +1,94 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertDecodeSecrets(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		want         string
+		wantWarnings []Warning
+	}{
+		{
+			name:  "data moved to stringData",
+			input: "apiVersion: v1\nkind: Secret\ndata:\n  username: YWRtaW4=\n  password: czNjcjN0\ntype: Opaque\n",
+			want:  `{"apiVersion":"v1","kind":"Secret","type":"Opaque","stringData":{"username":"admin","password":"s3cr3t"}}`,
+		},
+		{
+			name:  "existing stringData kept",
+			input: "kind: Secret\ndata:\n  token: b2xk\n  user: YWRtaW4=\nstringData:\n  token: new\n",
+			want:  `{"kind":"Secret","data":{"token":"b2xk"},"stringData":{"token":"new","user":"admin"}}`,
+		},
+		{
+			name:  "invalid values left encoded",
+			input: "kind: Secret\ndata:\n  bad: not-base64!\n  binary: //79\n  ok: b2s=\n",
+			want:  `{"kind":"Secret","data":{"bad":"not-base64!","binary":"//79"},"stringData":{"ok":"ok"}}`,
+			wantWarnings: []Warning{
+				{Document: 1, Line: 3, Message: `Secret data key "bad" is not valid base64; leaving it encoded`},
+				{Document: 1, Line: 4, Message: `Secret data key "binary" does not decode to UTF-8 text; leaving it encoded`},
+			},
+		},
+		{
+			name:  "other kinds unchanged",
+			input: "kind: ConfigMap\ndata:\n  key: YWRtaW4=\nbinaryData:\n  blob: YWRtaW4=\n",
+			want:  `{"kind":"ConfigMap","data":{"key":"YWRtaW4="},"binaryData":{"blob":"YWRtaW4="}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []Warning
+			opts := Options{Compact: true, DecodeSecrets: true, Warn: func(w Warning) { warnings = append(warnings, w) }}
+			got, err := Convert([]byte(tt.input), opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestConvertRedactSecrets(t *testing.T) {
+	input := `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{"data":{"password":"czNjcjN0"}}'
+    team: payments
+data:
+  password: czNjcjN0
+stringData:
+  token: plain
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{}'
+data:
+  password: visible
+`
+	opts := Options{Compact: true, Separate: true, RedactSecrets: true, DecodeSecrets: true}
+	got, err := Convert([]byte(input), opts)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"creds","annotations":` +
+		`{"kubectl.kubernetes.io/last-applied-configuration":"***REDACTED***","team":"payments"}},` +
+		`"data":{"password":"***REDACTED***"},"stringData":{"token":"***REDACTED***"}}` + "\n" +
+		`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"annotations":` +
+		`{"kubectl.kubernetes.io/last-applied-configuration":"{}"}},"data":{"password":"visible"}}`
+	if string(got) != want {
+		t.Errorf("Convert() = %s, want %s", got, want)
+	}
+}
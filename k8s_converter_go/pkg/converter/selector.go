@@ -0,0 +1,156 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Label selector operators.
+const (
+	selectorEquals       = "="
+	selectorNotEquals    = "!="
+	selectorIn           = "in"
+	selectorNotIn        = "notin"
+	selectorExists       = "exists"
+	selectorDoesNotExist = "!"
+)
+
+// labelRequirement is a single requirement of a label selector, such as
+// app=web or tier notin (cache, queue).
+type labelRequirement struct {
+	key    string
+	op     string
+	values []string
+}
+
+var (
+	// setRequirement matches a set-based requirement such as "env in (a, b)".
+	setRequirement = regexp.MustCompile(`^([^\s!=(),]+)\s+(in|notin)\s*\(([^()]*)\)$`)
+	// equalityRequirement matches an equality-based requirement such as
+	// "app=web", "app==web" or "tier!=cache".
+	equalityRequirement = regexp.MustCompile(`^([^\s!=(),]+)\s*(==|=|!=)\s*([^\s!=(),]*)$`)
+	// existenceRequirement matches "key" or "!key".
+	existenceRequirement = regexp.MustCompile(`^(!?)\s*([^\s!=(),]+)$`)
+)
+
+// parseSelector parses a label selector in the syntax kubectl accepts:
+// comma-separated requirements, each of them key=value, key==value,
+// key!=value, key in (v1, v2), key notin (v1, v2), key or !key.
+func parseSelector(selector string) ([]labelRequirement, error) {
+	var requirements []labelRequirement
+	for _, part := range splitSelector(selector) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid selector %q: empty requirement", selector)
+		}
+		if match := setRequirement.FindStringSubmatch(part); match != nil {
+			values := parseList(match[3])
+			if len(values) == 0 {
+				return nil, fmt.Errorf("invalid selector %q: %s needs at least one value", selector, match[2])
+			}
+			requirements = append(requirements, labelRequirement{key: match[1], op: match[2], values: values})
+			continue
+		}
+		if match := equalityRequirement.FindStringSubmatch(part); match != nil {
+			op := selectorEquals
+			if match[2] == "!=" {
+				op = selectorNotEquals
+			}
+			requirements = append(requirements, labelRequirement{key: match[1], op: op, values: []string{match[3]}})
+			continue
+		}
+		if match := existenceRequirement.FindStringSubmatch(part); match != nil {
+			op := selectorExists
+			if match[1] != "" {
+				op = selectorDoesNotExist
+			}
+			requirements = append(requirements, labelRequirement{key: match[2], op: op})
+			continue
+		}
+		return nil, fmt.Errorf("invalid selector %q: cannot parse requirement %q", selector, part)
+	}
+	return requirements, nil
+}
+
+// splitSelector splits a selector at the commas between requirements,
+// leaving the commas inside the value lists of set-based requirements.
+func splitSelector(selector string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range selector {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, selector[start:])
+}
+
+// parseList splits a comma-separated list, trimming spaces and ignoring
+// empty items.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// matches reports whether labels satisfy the requirement. As in Kubernetes,
+// != and notin also match when the label is absent.
+func (r labelRequirement) matches(labels *Object) bool {
+	var value string
+	var ok bool
+	if labels != nil {
+		if v, exists := labels.Get(r.key); exists {
+			value, ok = labelValue(v), true
+		}
+	}
+	switch r.op {
+	case selectorEquals:
+		return ok && value == r.values[0]
+	case selectorNotEquals:
+		return !ok || value != r.values[0]
+	case selectorIn:
+		return ok && containsString(r.values, value)
+	case selectorNotIn:
+		return !ok || !containsString(r.values, value)
+	case selectorExists:
+		return ok
+	default:
+		return !ok
+	}
+}
+
+// labelValue returns the string form of a label value. Label values are
+// strings in valid manifests, but unquoted numbers and booleans are compared
+// as they were written.
+func labelValue(v interface{}) string {
+	if text, ok := v.(string); ok {
+		return text
+	}
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
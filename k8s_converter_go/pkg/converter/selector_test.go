@@ -0,0 +1,46 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []labelRequirement
+	}{
+		{"app=web", []labelRequirement{{key: "app", op: selectorEquals, values: []string{"web"}}}},
+		{"app==web,tier!=cache", []labelRequirement{
+			{key: "app", op: selectorEquals, values: []string{"web"}},
+			{key: "tier", op: selectorNotEquals, values: []string{"cache"}},
+		}},
+		{"env in (prod, staging),tier notin (cache)", []labelRequirement{
+			{key: "env", op: selectorIn, values: []string{"prod", "staging"}},
+			{key: "tier", op: selectorNotIn, values: []string{"cache"}},
+		}},
+		{"app.kubernetes.io/name, !canary", []labelRequirement{
+			{key: "app.kubernetes.io/name", op: selectorExists},
+			{key: "canary", op: selectorDoesNotExist},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := parseSelector(tt.selector)
+		if err != nil {
+			t.Errorf("parseSelector(%q) error = %v", tt.selector, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSelector(%q) = %+v, want %+v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestParseSelectorInvalid(t *testing.T) {
+	for _, selector := range []string{"app=web,", "env in ()", "env in (prod", "a b", "=web", "app=web=x"} {
+		if _, err := parseSelector(selector); err == nil || !strings.HasPrefix(err.Error(), "invalid selector") {
+			t.Errorf("parseSelector(%q) error = %v, want invalid selector", selector, err)
+		}
+	}
+}
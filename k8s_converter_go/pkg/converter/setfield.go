@@ -0,0 +1,129 @@
+package converter
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetField is a value set at a path in every document by Options.SetFields.
+type SetField struct {
+	// Path is the path to set, in the syntax of Options.DeletePaths.
+	Path string
+	// Value is parsed as a YAML scalar, so that 5 is a number, true a
+	// boolean and an empty value null, unless String is set to keep it a
+	// string.
+	Value  string
+	String bool
+}
+
+// ValidateSetField reports whether field can be set, returning an error
+// describing its path or value if not.
+func ValidateSetField(field SetField) error {
+	_, _, err := parseSetField(field, Options{})
+	return err
+}
+
+// parseSetField parses the path of field and decodes its value.
+func parseSetField(field SetField, opts Options) ([]querySegment, interface{}, error) {
+	segments, err := parsePath(field.Path, "set path", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(segments) == 0 {
+		return nil, nil, fmt.Errorf("invalid set path %q: cannot set the whole document", field.Path)
+	}
+	if field.String {
+		return segments, field.Value, nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(field.Value), &node); err != nil {
+		return nil, nil, fmt.Errorf("invalid value %q for %s: %v", field.Value, field.Path, err)
+	}
+	if len(node.Content) == 0 {
+		return segments, nil, nil
+	}
+	scalar := node.Content[0]
+	if scalar.Kind != yaml.ScalarNode {
+		return nil, nil, fmt.Errorf("invalid value %q for %s: not a YAML scalar", field.Value, field.Path)
+	}
+	// The value is the same in every document, so it is not warned about
+	// once per document
+	opts.WarnYAML11Bools = false
+	decoder := valueDecoder{opts: opts}
+	value, err := decoder.decode(scalar, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid value %q for %s: %v", field.Value, field.Path, err)
+	}
+	return segments, value, nil
+}
+
+// setFields sets opts.SetFields in v, a document starting on line, returning
+// v with them set. A path that cannot be set, such as one indexing past the
+// end of an array, returns a *SetFieldError.
+func setFields(index, line int, v interface{}, opts Options) (interface{}, error) {
+	for _, field := range opts.SetFields {
+		segments, value, err := parseSetField(field, opts)
+		if err != nil {
+			return nil, err
+		}
+		if v, err = setSegments(v, "", segments, value); err != nil {
+			return nil, &SetFieldError{Path: field.Path, Document: index, Line: line, Message: err.Error()}
+		}
+	}
+	return v, nil
+}
+
+// setSegments sets the value at segments, below path in the document, to
+// value in v, returning v with it set. Missing and null objects on the way
+// are created, but arrays are not, and an index must be within its array, so
+// that a typo never pads an array with nulls. A [*] segment sets the value in
+// every element of an array.
+func setSegments(v interface{}, path string, segments []querySegment, value interface{}) (interface{}, error) {
+	segment, rest := segments[0], segments[1:]
+	here := pathOrRoot(path)
+	path += segment.String()
+	if segment.wildcard || segment.isIndex {
+		items, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot index %s with %s: not an array", here, segment)
+		}
+		if segment.isIndex && segment.index >= len(items) {
+			return nil, fmt.Errorf("index %s out of range at %s, which has %d items", segment, here, len(items))
+		}
+		for i := range items {
+			if segment.isIndex && i != segment.index {
+				continue
+			}
+			if len(rest) == 0 {
+				items[i] = value
+				continue
+			}
+			item, err := setSegments(items[i], path, rest, value)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+
+	if v == nil {
+		v = NewObject()
+	}
+	object, ok := v.(*Object)
+	if !ok {
+		return nil, fmt.Errorf("cannot set field %q at %s: not an object", segment.field, here)
+	}
+	if len(rest) == 0 {
+		object.Set(segment.field, value)
+		return object, nil
+	}
+	child, _ := object.Get(segment.field)
+	child, err := setSegments(child, path, rest, value)
+	if err != nil {
+		return nil, err
+	}
+	object.Set(segment.field, child)
+	return object, nil
+}
@@ -0,0 +1,179 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConvertSetFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		fields  []SetField
+		opts    Options
+		want    string
+	}{
+		{
+			name:    "Number and new intermediate objects",
+			content: "kind: Deployment\nmetadata:\n  name: web\n",
+			fields:  []SetField{{Path: "spec.replicas", Value: "5"}, {Path: "metadata.namespace", Value: "staging"}},
+			want:    `{"kind":"Deployment","metadata":{"name":"web","namespace":"staging"},"spec":{"replicas":5}}`,
+		},
+		{
+			name:    "Existing field keeps its position",
+			content: "spec:\n  replicas: 1\n  paused: false\n",
+			fields:  []SetField{{Path: "spec.replicas", Value: "3"}},
+			want:    `{"spec":{"replicas":3,"paused":false}}`,
+		},
+		{
+			name:    "Array index",
+			content: "containers:\n- name: web\n  image: nginx\n- name: sidecar\n",
+			fields:  []SetField{{Path: "containers[0].image", Value: "nginx:1.25"}},
+			want:    `{"containers":[{"name":"web","image":"nginx:1.25"},{"name":"sidecar"}]}`,
+		},
+		{
+			name:    "Wildcard",
+			content: "containers:\n- name: web\n- name: sidecar\n",
+			fields:  []SetField{{Path: "containers[*].imagePullPolicy", Value: "Always"}},
+			want:    `{"containers":[{"name":"web","imagePullPolicy":"Always"},{"name":"sidecar","imagePullPolicy":"Always"}]}`,
+		},
+		{
+			name:    "Quoted key",
+			content: "metadata:\n  annotations: null\n",
+			fields:  []SetField{{Path: `metadata.annotations."example.com/team"`, Value: "web"}},
+			want:    `{"metadata":{"annotations":{"example.com/team":"web"}}}`,
+		},
+		{
+			name:    "Scalar types",
+			content: "a: 1\n",
+			fields: []SetField{
+				{Path: "bool", Value: "true"},
+				{Path: "float", Value: "1.50"},
+				{Path: "null", Value: "null"},
+				{Path: "empty", Value: ""},
+				{Path: "quoted", Value: `"5"`},
+				{Path: "octal", Value: "0o17"},
+				{Path: "yes", Value: "yes"},
+				{Path: "date", Value: "2024-01-02"},
+			},
+			want: `{"a":1,"bool":true,"float":1.50,"null":null,"empty":null,"quoted":"5","octal":15,"yes":"yes","date":"2024-01-02T00:00:00Z"}`,
+		},
+		{
+			name:    "YAML 1.1 booleans",
+			content: "a: 1\n",
+			fields:  []SetField{{Path: "enabled", Value: "yes"}},
+			opts:    Options{YAML11Bools: true},
+			want:    `{"a":1,"enabled":true}`,
+		},
+		{
+			name:    "Strings",
+			content: "a: 1\n",
+			fields: []SetField{
+				{Path: "version", Value: "1.20", String: true},
+				{Path: "enabled", Value: "true", String: true},
+				{Path: "empty", Value: "", String: true},
+				{Path: "list", Value: "[a, b]", String: true},
+			},
+			want: `{"a":1,"version":"1.20","enabled":"true","empty":"","list":"[a, b]"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Compact = true
+			opts.SetFields = tt.fields
+			got, err := Convert([]byte(tt.content), opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertSetFieldsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		path     string
+		wantErr  string
+		wantLine int
+	}{
+		{
+			name:     "Index past the end",
+			content:  "containers: [{name: web}]\n",
+			path:     "containers[1].image",
+			wantErr:  "set containers[1].image: index [1] out of range at .containers, which has 1 items",
+			wantLine: 1,
+		},
+		{
+			name:     "Second document",
+			content:  "containers: [{}, {}]\n---\ncontainers: [{}]\n",
+			path:     "containers[1].image",
+			wantErr:  "which has 1 items in document 2",
+			wantLine: 3,
+		},
+		{
+			name:     "Missing array",
+			content:  "spec: {}\n",
+			path:     "spec.containers[0].image",
+			wantErr:  "cannot index .spec.containers with [0]: not an array",
+			wantLine: 1,
+		},
+		{
+			name:     "Field of a scalar",
+			content:  "spec: 3\n",
+			path:     "spec.replicas",
+			wantErr:  `cannot set field "replicas" at .spec: not an object`,
+			wantLine: 1,
+		},
+		{
+			name:     "Wildcard over an object",
+			content:  "containers: {name: web}\n",
+			path:     "containers[*].image",
+			wantErr:  "cannot index .containers with [*]: not an array",
+			wantLine: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert([]byte(tt.content), Options{SetFields: []SetField{{Path: tt.path, Value: "x"}}})
+			var setErr *SetFieldError
+			if !errors.As(err, &setErr) || setErr.Line != tt.wantLine || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Convert() error = %v, want a *SetFieldError containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSetField(t *testing.T) {
+	tests := []struct {
+		field   SetField
+		wantErr string
+	}{
+		{field: SetField{Path: "spec.replicas", Value: "5"}},
+		{field: SetField{Path: "a", Value: "{b: c}", String: true}},
+		{field: SetField{Path: ".", Value: "5"}, wantErr: "cannot set the whole document"},
+		{field: SetField{Path: "a[x]", Value: "5"}, wantErr: "invalid set path"},
+		{field: SetField{Path: "a", Value: "{b: c}"}, wantErr: "not a YAML scalar"},
+		{field: SetField{Path: "a", Value: "[1, 2]"}, wantErr: "not a YAML scalar"},
+		{field: SetField{Path: "a", Value: `"unterminated`}, wantErr: `invalid value "\"unterminated" for a`},
+	}
+	for _, tt := range tests {
+		err := ValidateSetField(tt.field)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateSetField(%+v) error = %v", tt.field, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateSetField(%+v) error = %v, want one containing %q", tt.field, err, tt.wantErr)
+		}
+	}
+}
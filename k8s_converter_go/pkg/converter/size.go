@@ -0,0 +1,43 @@
+package converter
+
+import "io"
+
+// sizeLimitReader reads at most limit bytes from r, failing with a
+// *SizeLimitError if r holds more, rather than truncating it as
+// io.LimitReader does.
+type sizeLimitReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+// limitReader limits r to limit bytes, or returns r unchanged when limit is
+// 0.
+func limitReader(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return &sizeLimitReader{r: r, limit: limit, remaining: limit}
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Read past the limit to tell an input of exactly the limit from a
+		// larger one
+		var probe [1]byte
+		n, err := io.ReadFull(l.r, probe[:])
+		if n > 0 {
+			return 0, &SizeLimitError{Limit: l.limit}
+		}
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
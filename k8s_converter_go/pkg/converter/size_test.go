@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLimitReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		limit   int64
+		wantErr bool
+	}{
+		{name: "no limit", input: "kind: Service\n", limit: 0},
+		{name: "within the limit", input: "kind: Service\n", limit: 100},
+		{name: "exactly the limit", input: "kind: Service\n", limit: 14},
+		{name: "past the limit", input: "kind: Service\n", limit: 13, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := io.ReadAll(limitReader(strings.NewReader(tt.input), tt.limit))
+			var limitErr *SizeLimitError
+			if tt.wantErr {
+				if !errors.As(err, &limitErr) || limitErr.Limit != tt.limit {
+					t.Errorf("ReadAll() error = %v, want a *SizeLimitError for %d bytes", err, tt.limit)
+				}
+				return
+			}
+			if err != nil || string(data) != tt.input {
+				t.Errorf("ReadAll() = %q, %v, want %q", data, err, tt.input)
+			}
+		})
+	}
+}
+
+func TestConvertFileMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.yaml"), filepath.Join(dir, "out.json")
+	if err := os.WriteFile(in, []byte("kind: Service\nmetadata:\n  name: web\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reverse := range []bool{false, true} {
+		err := ConvertFile(in, out, Options{MaxSize: 16, Reverse: reverse})
+		var limitErr *SizeLimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != 16 {
+			t.Errorf("ConvertFile(reverse %v) error = %v, want a *SizeLimitError", reverse, err)
+		}
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("output written for an input past the limit: %v", err)
+	}
+	if err := ConvertFile(in, out, Options{MaxSize: 1024}); err != nil {
+		t.Errorf("ConvertFile() within the limit error = %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package converter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// jsonStreamWriter writes JSON documents as they are decoded, in the same
+// layout as MarshalDocuments: a single document as-is, several documents as
+// an array, or one after another with Options.Separate or Options.Query.
+// Only the first document is held in memory, until a second one shows
+// whether the output is an array.
+type jsonStreamWriter struct {
+	w    *bufio.Writer
+	opts Options
+	// count is the number of documents written so far.
+	count int
+	first interface{}
+}
+
+func newJSONStreamWriter(w io.Writer, opts Options) *jsonStreamWriter {
+	return &jsonStreamWriter{w: bufio.NewWriter(w), opts: opts}
+}
+
+// separate reports whether documents are written one after another instead
+// of in an array.
+func (s *jsonStreamWriter) separate() bool {
+	return s.opts.Separate || s.opts.Query != ""
+}
+
+// write writes the next document.
+func (s *jsonStreamWriter) write(v interface{}) error {
+	s.count++
+	if s.separate() {
+		data, err := marshalValue(v, s.opts)
+		if err != nil {
+			return err
+		}
+		if s.count > 1 {
+			s.w.WriteByte('\n')
+		}
+		_, err = s.w.Write(data)
+		return s.writeError(err)
+	}
+
+	switch s.count {
+	case 1:
+		s.first = v
+		return nil
+	case 2:
+		s.w.WriteByte('[')
+		if err := s.writeElement(s.first); err != nil {
+			return err
+		}
+		s.first = nil
+	}
+	s.w.WriteByte(',')
+	return s.writeElement(v)
+}
+
+// writeElement writes a document as an element of the top-level array.
+func (s *jsonStreamWriter) writeElement(v interface{}) error {
+	var data []byte
+	var err error
+	if s.opts.compact() {
+		data, err = json.Marshal(v)
+	} else {
+		indent := s.opts.Indent
+		if indent == "" {
+			indent = DefaultIndent
+		}
+		s.w.WriteString("\n" + indent)
+		data, err = json.MarshalIndent(v, indent, indent)
+	}
+	if err != nil {
+		return &EncodeError{Format: "JSON", Err: err}
+	}
+	_, err = s.w.Write(data)
+	return s.writeError(err)
+}
+
+// close finishes the output once every document has been written.
+func (s *jsonStreamWriter) close() error {
+	if !s.separate() {
+		switch {
+		case s.count == 0:
+			s.w.WriteString("[]")
+		case s.count == 1:
+			data, err := marshalJSON(s.first, s.opts)
+			if err != nil {
+				return err
+			}
+			s.w.Write(data)
+		case s.opts.compact():
+			s.w.WriteByte(']')
+		default:
+			s.w.WriteString("\n]")
+		}
+	}
+	if s.opts.Canonical && (s.count > 0 || !s.separate()) {
+		s.w.WriteByte('\n')
+	}
+	return s.writeError(s.w.Flush())
+}
+
+// writeError wraps a failed write in an *IOError.
+func (s *jsonStreamWriter) writeError(err error) error {
+	if err != nil {
+		return &IOError{Op: "write", Path: "output", Err: err}
+	}
+	return nil
+}
+
+// writeJSON decodes the YAML stream read from r and writes the JSON output to
+// w document by document, so that memory use is bounded by the largest
+// document rather than the whole stream.
+func writeJSON(r io.Reader, w io.Writer, opts Options) error {
+	stream := newJSONStreamWriter(w, opts)
+	err := DecodeStream(r, opts, func(doc Document) error {
+		value := doc.Value
+		if opts.Query != "" {
+			values, err := queryDocuments([]Document{doc}, opts)
+			if err != nil {
+				return err
+			}
+			value = values[0]
+		}
+		if opts.Flatten {
+			value = flatten(value, opts)
+		}
+		return stream.write(value)
+	})
+	if err != nil {
+		return err
+	}
+	return stream.close()
+}
@@ -0,0 +1,145 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestConvertStreamMatchesConvert(t *testing.T) {
+	inputs := map[string]string{
+		"single":   "apiVersion: v1\nkind: ConfigMap\ndata:\n  key: value\n",
+		"multiple": "kind: Deployment\nspec:\n  replicas: 2\n---\nkind: Service\n---\n# empty\n---\nkind: List\nitems: []\n",
+		"empty":    "{}\n",
+	}
+	optionSets := map[string]Options{
+		"indented":     {},
+		"compact":      {Compact: true},
+		"tabs":         {Indent: "\t"},
+		"separate":     {Separate: true},
+		"query":        {Query: ".kind"},
+		"exploded":     {ExplodeLists: true, Compact: true},
+		"ndjson":       {Format: FormatNDJSON},
+		"sorted":       {SortKeys: true, Separate: true, Compact: true},
+		"kind filter":  {Kinds: []string{"Service", "ConfigMap"}},
+		"query raw":    {Query: ".kind", Raw: true, Compact: true},
+		"explode only": {ExplodeLists: true},
+	}
+
+	for inputName, input := range inputs {
+		for optsName, opts := range optionSets {
+			t.Run(inputName+"/"+optsName, func(t *testing.T) {
+				want, wantErr := Convert([]byte(input), opts)
+				var got bytes.Buffer
+				err := ConvertStream(strings.NewReader(input), &got, opts)
+				if (err != nil) != (wantErr != nil) {
+					t.Fatalf("ConvertStream() error = %v, Convert() error = %v", err, wantErr)
+				}
+				if err == nil && got.String() != string(want) {
+					t.Errorf("ConvertStream() = %q, want %q", got.String(), want)
+				}
+			})
+		}
+	}
+}
+
+// generatedStream is a reader producing a YAML stream of count small
+// documents without holding the stream in memory.
+type generatedStream struct {
+	count, next int
+	pending     []byte
+}
+
+func (g *generatedStream) Read(p []byte) (int, error) {
+	for len(g.pending) == 0 {
+		if g.next == g.count {
+			return 0, io.EOF
+		}
+		g.pending = []byte(generatedDocument(g.next))
+		g.next++
+	}
+	n := copy(p, g.pending)
+	g.pending = g.pending[n:]
+	return n, nil
+}
+
+func generatedDocument(i int) string {
+	return fmt.Sprintf("---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config-%d\ndata:\n  index: \"%d\"\n", i, i)
+}
+
+// checkingWriter checks each line of output against the expected conversion
+// of the generated documents and records the peak heap size.
+type checkingWriter struct {
+	t        *testing.T
+	partial  []byte
+	lines    int
+	peakHeap uint64
+}
+
+func (c *checkingWriter) Write(p []byte) (int, error) {
+	c.partial = append(c.partial, p...)
+	for {
+		end := bytes.IndexByte(c.partial, '\n')
+		if end < 0 {
+			break
+		}
+		c.check(string(c.partial[:end]))
+		c.partial = c.partial[end+1:]
+	}
+	return len(p), nil
+}
+
+func (c *checkingWriter) check(line string) {
+	want := fmt.Sprintf(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"config-%d"},"data":{"index":"%d"}}`, c.lines, c.lines)
+	if line != want && !c.t.Failed() {
+		c.t.Errorf("line %d = %s, want %s", c.lines+1, line, want)
+	}
+	c.lines++
+	if c.lines%10000 == 0 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > c.peakHeap {
+			c.peakHeap = stats.HeapAlloc
+		}
+	}
+}
+
+func TestConvertStreamLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large stream test skipped in short mode")
+	}
+	const count = 100000
+	output := &checkingWriter{t: t}
+	err := ConvertStream(bufio.NewReader(&generatedStream{count: count}), output, Options{Separate: true, Compact: true})
+	if err != nil {
+		t.Fatalf("ConvertStream() error = %v", err)
+	}
+	output.Write([]byte("\n"))
+	if output.lines != count {
+		t.Errorf("ConvertStream() wrote %d documents, want %d", output.lines, count)
+	}
+
+	// Holding the whole stream would take hundreds of megabytes
+	const maxHeap = 64 << 20
+	if output.peakHeap > maxHeap {
+		t.Errorf("peak heap = %d MiB, want at most %d MiB", output.peakHeap>>20, maxHeap>>20)
+	}
+}
+
+func TestConvertStreamReadError(t *testing.T) {
+	failure := errors.New("input too large")
+	for name, opts := range map[string]Options{"YAML": {}, "JSON": {JSONInput: true}} {
+		r := io.MultiReader(strings.NewReader("kind: Service\n---\nkind: Pod\nmetadata:\n  na"), iotest.ErrReader(failure))
+		err := ConvertStream(r, io.Discard, opts)
+		var ioErr *IOError
+		if !errors.As(err, &ioErr) || ioErr.Op != "read" || !errors.Is(err, failure) {
+			t.Errorf("%s: ConvertStream() error = %v, want a read *IOError wrapping the failure", name, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// textSniffSize is the most of the input examined to detect its encoding.
+const textSniffSize = 1024
+
+// textReader returns a reader for the content of r as UTF-8 text. Input
+// encoded as UTF-16, which Windows PowerShell writes when redirecting the
+// output of a command to a file, is transcoded and reported through
+// opts.Warn. The encoding is detected from a byte order mark, or else from
+// the first two characters being ASCII, as the first characters of a YAML or
+// JSON document almost always are. Input that is neither UTF-8 nor UTF-16
+// text, such as a binary file, is rejected with ErrNotText.
+func textReader(r io.Reader, opts Options) (io.Reader, error) {
+	// Only what the first read returns is examined, so that a stream that is
+	// still being written is converted as it arrives
+	buffered := bufio.NewReaderSize(r, textSniffSize)
+	if _, err := buffered.Peek(1); err != nil {
+		// Leave the error, or the end of an empty input, to the first read
+		return buffered, nil
+	}
+	prefix, _ := buffered.Peek(buffered.Buffered())
+
+	if name, order := detectUTF16(prefix); order != nil {
+		opts.warn(Warning{Message: fmt.Sprintf("input is %s encoded and was converted to UTF-8", name)})
+		return &utf16Reader{r: buffered, order: order}, nil
+	}
+	if err := checkText(prefix); err != nil {
+		return nil, err
+	}
+	return buffered, nil
+}
+
+// decompressText returns the content of data, decompressed when it is a gzip
+// stream, as UTF-8 text read by textReader.
+func decompressText(data []byte, opts Options) ([]byte, error) {
+	data, err := decompress(data, opts.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	r, err := textReader(bytes.NewReader(data), opts)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// detectUTF16 returns the name and byte order of the UTF-16 encoding of the
+// text starting with prefix, or a nil order when prefix does not look like
+// UTF-16.
+func detectUTF16(prefix []byte) (string, binary.ByteOrder) {
+	switch {
+	case len(prefix) >= 2 && prefix[0] == 0xff && prefix[1] == 0xfe:
+		return "UTF-16LE", binary.LittleEndian
+	case len(prefix) >= 2 && prefix[0] == 0xfe && prefix[1] == 0xff:
+		return "UTF-16BE", binary.BigEndian
+	case len(prefix) < 4:
+		return "", nil
+	case isASCII(prefix[0]) && prefix[1] == 0 && isASCII(prefix[2]) && prefix[3] == 0:
+		return "UTF-16LE", binary.LittleEndian
+	case prefix[0] == 0 && isASCII(prefix[1]) && prefix[2] == 0 && isASCII(prefix[3]):
+		return "UTF-16BE", binary.BigEndian
+	}
+	return "", nil
+}
+
+// isASCII reports whether b is a non-NUL ASCII character.
+func isASCII(b byte) bool {
+	return b != 0 && b < utf8.RuneSelf
+}
+
+// checkText returns an error wrapping ErrNotText when prefix, the start of
+// the input, contains a NUL byte or is not valid UTF-8. prefix may end in the
+// middle of a character.
+func checkText(prefix []byte) error {
+	for offset := 0; offset < len(prefix); {
+		c, size := utf8.DecodeRune(prefix[offset:])
+		switch {
+		case c == 0:
+			return fmt.Errorf("%w: it contains a NUL byte at offset %d", ErrNotText, offset)
+		case c == utf8.RuneError && size == 1:
+			if !utf8.FullRune(prefix[offset:]) {
+				return nil
+			}
+			return fmt.Errorf("%w: byte 0x%02x at offset %d is not valid UTF-8", ErrNotText, prefix[offset], offset)
+		}
+		offset += size
+	}
+	return nil
+}
+
+// utf16Reader transcodes UTF-16 text in the byte order order to UTF-8. A
+// byte order mark is kept, as U+FEFF, for lineReader to remove. A surrogate
+// that is not part of a pair, or an odd byte at the end of the input, is read
+// as U+FFFD.
+type utf16Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	// text is the transcoded text not yet returned, and err the error that
+	// ended the input, returned once text has been read.
+	text []byte
+	err  error
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.text) == 0 {
+		if u.err != nil {
+			return 0, u.err
+		}
+		u.fill()
+	}
+	n := copy(p, u.text)
+	u.text = u.text[n:]
+	return n, nil
+}
+
+// fill transcodes the next character of r, and any more that r has already
+// buffered, into text, so that reading never waits for more input than it
+// returns.
+func (u *utf16Reader) fill() {
+	var text []byte
+	var unit [2]byte
+	for len(text) == 0 || (u.r.Buffered() >= len(unit) && len(text) < textSniffSize) {
+		if _, err := io.ReadFull(u.r, unit[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				text, err = utf8.AppendRune(text, utf8.RuneError), io.EOF
+			}
+			u.err = err
+			break
+		}
+		c := rune(u.order.Uint16(unit[:]))
+		if utf16.IsSurrogate(c) {
+			c = utf8.RuneError
+			if next, _ := u.r.Peek(len(unit)); len(next) == len(unit) {
+				if pair := utf16.DecodeRune(rune(u.order.Uint16(unit[:])), rune(u.order.Uint16(next))); pair != utf8.RuneError {
+					c = pair
+					u.r.Discard(len(unit))
+				}
+			}
+		}
+		text = utf8.AppendRune(text, c)
+	}
+	u.text = text
+}
@@ -0,0 +1,161 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// encodeUTF16LE returns s encoded as UTF-16LE, without a byte order mark.
+func encodeUTF16LE(s string) []byte {
+	var data []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		data = append(data, byte(unit), byte(unit>>8))
+	}
+	return data
+}
+
+func TestTextReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		want        string
+		wantWarning string
+		wantErr     error
+	}{
+		{name: "UTF-8", input: []byte("kind: Café\n"), want: "kind: Café\n"},
+		{name: "Empty", input: nil, want: ""},
+		{
+			name:        "UTF-16LE with byte order mark",
+			input:       append([]byte{0xff, 0xfe}, encodeUTF16LE("kind: A\n")...),
+			want:        "\ufeffkind: A\n",
+			wantWarning: "input is UTF-16LE encoded and was converted to UTF-8",
+		},
+		{
+			name:        "UTF-16BE with byte order mark",
+			input:       []byte{0xfe, 0xff, 0, 'k', 0, ':', 0x00, 0xe9},
+			want:        "\ufeffk:é",
+			wantWarning: "input is UTF-16BE encoded and was converted to UTF-8",
+		},
+		{
+			name:        "UTF-16LE without byte order mark",
+			input:       encodeUTF16LE("a: 😀\n"),
+			want:        "a: 😀\n",
+			wantWarning: "input is UTF-16LE encoded and was converted to UTF-8",
+		},
+		{
+			name:        "UTF-16BE without byte order mark",
+			input:       []byte{0, 'a', 0, ':', 0, ' ', 0xd8, 0x3d, 0xde, 0x00},
+			want:        "a: 😀",
+			wantWarning: "input is UTF-16BE encoded and was converted to UTF-8",
+		},
+		{
+			name:        "Unpaired surrogate and odd length",
+			input:       append(encodeUTF16LE("a: "), 0x3d, 0xd8, 'b', 0, 'c'),
+			want:        "a: �b�",
+			wantWarning: "input is UTF-16LE encoded and was converted to UTF-8",
+		},
+		{name: "NUL byte", input: []byte("kind: A\x00\x01"), wantErr: ErrNotText},
+		{name: "Invalid UTF-8", input: []byte("\x89PNG\r\n\x1a\n"), wantErr: ErrNotText},
+		{name: "Latin-1", input: []byte("name: caf\xe9\n"), wantErr: ErrNotText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			opts := Options{Warn: func(w Warning) { warnings = append(warnings, w.Message) }}
+			r, err := textReader(bytes.NewReader(tt.input), opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("textReader() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("textReader() error = %v", err)
+			}
+			got, err := io.ReadAll(iotest.OneByteReader(r))
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+			if tt.wantWarning == "" && len(warnings) > 0 || tt.wantWarning != "" && (len(warnings) != 1 || warnings[0] != tt.wantWarning) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestTextReaderTruncatedPrefix(t *testing.T) {
+	// The first read ends in the middle of a character
+	input := io.MultiReader(strings.NewReader("a: caf\xc3"), strings.NewReader("\xa9\n"))
+	r, err := textReader(input, Options{})
+	if err != nil {
+		t.Fatalf("textReader() error = %v", err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "a: café\n" {
+		t.Errorf("text = %q", got)
+	}
+}
+
+func TestConvertUTF16(t *testing.T) {
+	opts := Options{KeepComments: true}
+	utf8Data, err := os.ReadFile(filepath.Join("testdata", "utf16", "utf8.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Convert(utf8Data, opts)
+	if err != nil {
+		t.Fatalf("Convert(utf8.yaml) error = %v", err)
+	}
+
+	for _, name := range []string{"utf16le.yaml", "utf16be.yaml", "utf16le-no-bom.yaml", "utf16be-no-bom.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("testdata", "utf16", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Convert(input, opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Convert() =\n%s\nwant:\n%s", got, want)
+			}
+			var streamed bytes.Buffer
+			if err := ConvertStream(bytes.NewReader(gzipData(t, string(input))), &streamed, opts); err != nil {
+				t.Fatalf("ConvertStream() error = %v", err)
+			}
+			if !bytes.Equal(streamed.Bytes(), want) {
+				t.Errorf("ConvertStream() =\n%s\nwant:\n%s", streamed.Bytes(), want)
+			}
+		})
+	}
+
+	envData := encodeUTF16LE("kind: ConfigMap\ndata:\n  name: ${NAME}\n")
+	t.Setenv("NAME", "web")
+	if got, err := Convert(envData, Options{EnvSubst: true, Compact: true}); err != nil || string(got) != `{"kind":"ConfigMap","data":{"name":"web"}}` {
+		t.Errorf("Convert() with EnvSubst = %s, %v", got, err)
+	}
+	if got, err := ConvertJSONToYAML(encodeUTF16LE(`{"kind": "A"}`)); err != nil || string(got) != "kind: A\n" {
+		t.Errorf("ConvertJSONToYAML() = %q, %v", got, err)
+	}
+}
+
+func TestConvertNotText(t *testing.T) {
+	_, err := Convert([]byte("\x7fELF\x02\x01\x01\x00\x00\x00"), Options{})
+	if !errors.Is(err, ErrNotText) {
+		t.Fatalf("Convert() error = %v, want ErrNotText", err)
+	}
+	if want := "input does not appear to be text: it contains a NUL byte at offset 7"; err.Error() != want {
+		t.Errorf("Convert() error = %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,26 @@
+package converter
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeTimestamp returns the string form of a timestamp scalar and whether
+// node is one. Timestamps are written in RFC 3339 form with fractional
+// seconds kept and the offset they were written with, so a date-only
+// 2023-05-01 becomes 2023-05-01T00:00:00Z and a timestamp without an offset
+// is read as UTC. With raw set, the scalar is returned as written.
+func decodeTimestamp(node *yaml.Node, raw bool) (string, bool, error) {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!timestamp" {
+		return "", false, nil
+	}
+	if raw {
+		return node.Value, true, nil
+	}
+	var value time.Time
+	if err := node.Decode(&value); err != nil {
+		return "", true, err
+	}
+	return value.Format(time.RFC3339Nano), true, nil
+}
@@ -0,0 +1,47 @@
+package converter
+
+import "testing"
+
+const timestampContent = `date: 2023-05-01
+utc: 2023-05-01T10:00:00Z
+offset: 2023-05-01T10:00:00+02:00
+negativeOffset: 2023-05-01t10:00:00.123456789-05:30
+spaced: 2023-05-01 10:00:00
+quoted: "2023-05-01"
+2023-05-01: key
+`
+
+func TestConvertTimestamps(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "normalized to RFC 3339",
+			opts: Options{Compact: true},
+			want: `{"date":"2023-05-01T00:00:00Z","utc":"2023-05-01T10:00:00Z","offset":"2023-05-01T10:00:00+02:00",` +
+				`"negativeOffset":"2023-05-01T10:00:00.123456789-05:30","spaced":"2023-05-01T10:00:00Z",` +
+				`"quoted":"2023-05-01","2023-05-01T00:00:00Z":"key"}`,
+		},
+		{
+			name: "raw timestamps",
+			opts: Options{Compact: true, RawTimestamps: true},
+			want: `{"date":"2023-05-01","utc":"2023-05-01T10:00:00Z","offset":"2023-05-01T10:00:00+02:00",` +
+				`"negativeOffset":"2023-05-01t10:00:00.123456789-05:30","spaced":"2023-05-01 10:00:00",` +
+				`"quoted":"2023-05-01","2023-05-01":"key"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(timestampContent), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
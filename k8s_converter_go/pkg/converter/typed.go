@@ -0,0 +1,108 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kschema "k8s.io/apimachinery/pkg/runtime/schema"
+	kjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
+)
+
+// typedScheme registers the k8s.io/api types that Options.Typed decodes
+// documents into: the stable versions of the built-in API groups.
+var typedScheme = newTypedScheme()
+
+// typedDecoder decodes documents into the types of typedScheme, failing on
+// unknown and duplicate fields as the API server does with strict field
+// validation.
+var typedDecoder = kjson.NewSerializerWithOptions(kjson.DefaultMetaFactory, typedScheme, typedScheme, kjson.SerializerOptions{Strict: true})
+
+// newTypedScheme returns the scheme for typedScheme.
+func newTypedScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		appsv1.AddToScheme,
+		autoscalingv1.AddToScheme,
+		autoscalingv2.AddToScheme,
+		batchv1.AddToScheme,
+		coordinationv1.AddToScheme,
+		corev1.AddToScheme,
+		discoveryv1.AddToScheme,
+		networkingv1.AddToScheme,
+		policyv1.AddToScheme,
+		rbacv1.AddToScheme,
+		schedulingv1.AddToScheme,
+		storagev1.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			panic(err)
+		}
+	}
+	return scheme
+}
+
+// decodeTyped passes value, the document at position document starting on
+// line, through the k8s.io/api type of its apiVersion and kind for
+// Options.Typed, and returns the typed object as it marshals to JSON, with
+// its fields in the order of the Go type. Documents of kinds that are not
+// registered are returned unchanged with a warning.
+func decodeTyped(document, line int, value interface{}, opts Options) (interface{}, error) {
+	object, ok := value.(*Object)
+	if !ok {
+		return value, nil
+	}
+	apiVersion, _ := object.values["apiVersion"].(string)
+	kind, _ := object.values["kind"].(string)
+	if apiVersion == "" || kind == "" {
+		opts.warn(Warning{Document: document, Line: line, Message: "document has no apiVersion or kind; converted without typed decoding"})
+		return value, nil
+	}
+	if !typedScheme.Recognizes(kschema.FromAPIVersionAndKind(apiVersion, kind)) {
+		opts.warn(Warning{Document: document, Line: line, Message: fmt.Sprintf("%s %s is not a built-in kind; converted without typed decoding", apiVersion, kind)})
+		return value, nil
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, &EncodeError{Format: "JSON", Err: err}
+	}
+	// Strict errors are returned along with the decoded object
+	typed, _, err := typedDecoder.Decode(data, nil, nil)
+	if err != nil {
+		return nil, &TypedError{Document: document, APIVersion: apiVersion, Kind: kind, Err: err}
+	}
+	data, err = json.Marshal(typed)
+	if err != nil {
+		return nil, &EncodeError{Format: "JSON", Err: err}
+	}
+	value, err = decodeOrderedJSON(data, document)
+	if err != nil {
+		return nil, &EncodeError{Format: "JSON", Err: err}
+	}
+	return value, nil
+}
+
+// decodeOrderedJSON decodes data, a valid JSON value, into the values that
+// documents decode to, keeping the order of object keys.
+func decodeOrderedJSON(data []byte, document int) (interface{}, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	decoder := valueDecoder{document: document, opts: Options{RawTimestamps: true}}
+	return decoder.decode(node.Content[0], "")
+}
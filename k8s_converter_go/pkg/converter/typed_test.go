@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConvertTyped(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		opts        Options
+		want        string
+		wantWarning string
+		wantErr     string
+	}{
+		{
+			name:    "Fields written by the type",
+			content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\ndata:\n  level: debug\n",
+			want:    `{"kind":"ConfigMap","apiVersion":"v1","metadata":{"name":"settings","creationTimestamp":null},"data":{"level":"debug"}}`,
+		},
+		{
+			name: "Quantities and int-or-string values",
+			content: "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\nspec:\n  ports:\n  - port: 80\n    targetPort: http\n" +
+				"---\napiVersion: v1\nkind: LimitRange\nmetadata:\n  name: limits\nspec:\n  limits:\n  - type: Container\n    max:\n      cpu: 2\n      memory: 1Gi\n",
+			want: `[{"kind":"Service","apiVersion":"v1","metadata":{"name":"web","creationTimestamp":null},"spec":{"ports":[{"port":80,"targetPort":"http"}]},"status":{"loadBalancer":{}}},` +
+				`{"kind":"LimitRange","apiVersion":"v1","metadata":{"name":"limits","creationTimestamp":null},"spec":{"limits":[{"type":"Container","max":{"cpu":"2","memory":"1Gi"}}]}}]`,
+		},
+		{
+			name:    "Later steps see the typed fields",
+			content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n",
+			opts:    Options{OmitNull: true, OmitEmpty: true, SortKeys: true},
+			want:    `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings"}}`,
+		},
+		{
+			name:        "Kind that is not built in",
+			content:     "apiVersion: example.com/v1\nkind: Widget\nspec:\n  size: \"3\"\n",
+			want:        `{"apiVersion":"example.com/v1","kind":"Widget","spec":{"size":"3"}}`,
+			wantWarning: "example.com/v1 Widget is not a built-in kind; converted without typed decoding",
+		},
+		{
+			name:        "No apiVersion",
+			content:     "kind: Deployment\nspec:\n  replicas: \"3\"\n",
+			want:        `{"kind":"Deployment","spec":{"replicas":"3"}}`,
+			wantWarning: "document has no apiVersion or kind; converted without typed decoding",
+		},
+		{
+			name:    "Wrong value type",
+			content: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: \"3\"\n",
+			wantErr: "decoding apps/v1 Deployment: json: cannot unmarshal string into Go struct field DeploymentSpec.spec.replicas of type int32",
+		},
+		{
+			name:    "Unknown field",
+			content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n  label:\n    app: web\n",
+			wantErr: `decoding v1 ConfigMap in document 2: strict decoding error: unknown field "metadata.label"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			tt.opts.Typed, tt.opts.Compact = true, true
+			tt.opts.Warn = func(w Warning) { warnings = append(warnings, w.Message) }
+			got, err := Convert([]byte(tt.content), tt.opts)
+			if tt.wantErr != "" {
+				var typedErr *TypedError
+				if !errors.As(err, &typedErr) || err.Error() != tt.wantErr {
+					t.Fatalf("Convert() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+			if strings.Join(warnings, "\n") != tt.wantWarning {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// valueDecoder decodes a document's node tree into JSON-compatible Go values:
+// mappings become *Object with their keys in input order, sequences become
+// []interface{}, numbers become json.Number holding their exact digits,
+// timestamps become RFC 3339 strings, binary data is encoded per
+// Options.Binary, and other scalars are resolved by their
+// YAML tag.
+type valueDecoder struct {
+	// document is the 1-based position of the document in the stream.
+	document int
+	opts     Options
+	// maxNodes is the number of nodes decode may produce before failing
+	// with an *AliasLimitError, or 0 for no limit, and decoded counts them.
+	maxNodes int
+	decoded  int
+	// aliases are the aliases being expanded, innermost last.
+	aliases []*yaml.Node
+}
+
+// decode decodes node, found at path in the document.
+func (d *valueDecoder) decode(node *yaml.Node, path string) (interface{}, error) {
+	if err := d.count(node); err != nil {
+		return nil, err
+	}
+	switch node.Kind {
+	case yaml.AliasNode:
+		if len(d.aliases) >= d.opts.maxAliasDepth() {
+			return nil, d.aliasLimit(node, true, d.opts.maxAliasDepth())
+		}
+		d.aliases = append(d.aliases, node)
+		defer func() { d.aliases = d.aliases[:len(d.aliases)-1] }()
+		return d.decode(node.Alias, path)
+	case yaml.MappingNode:
+		return d.decodeMapping(node, path)
+	case yaml.SequenceNode:
+		items := make([]interface{}, len(node.Content))
+		for i, child := range node.Content {
+			item, err := d.decode(child, path+"["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		if boolValue, ok := yaml11Bool(node); ok {
+			if d.opts.WarnYAML11Bools {
+				d.opts.warn(yaml11BoolWarning(d.document, node, path))
+			}
+			if d.opts.YAML11Bools {
+				return boolValue, nil
+			}
+		}
+		if node.ShortTag() == binaryTag {
+			return d.decodeBinary(node)
+		}
+		if timestamp, ok, err := decodeTimestamp(node, d.opts.RawTimestamps); ok {
+			if err != nil {
+				return nil, newYAMLParseError(err, d.document, node)
+			}
+			return timestamp, nil
+		}
+		if number, ok := decodeNumber(node); ok {
+			return number, nil
+		}
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, newYAMLParseError(err, d.document, node)
+		}
+		return value, nil
+	}
+}
+
+// decodeMapping decodes a mapping node into an *Object. Non-string keys such
+// as integers and booleans are converted to their string form and a null key
+// becomes "null", unless Options.RejectNonStringKeys is set. Keys are never
+// read as YAML 1.1 booleans.
+func (d *valueDecoder) decodeMapping(node *yaml.Node, path string) (*Object, error) {
+	object := NewObject()
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		stringKey, err := d.mappingKey(node.Content[i], path)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decode(node.Content[i+1], path+"."+stringKey)
+		if err != nil {
+			return nil, err
+		}
+		object.Set(stringKey, value)
+	}
+	return object, nil
+}
+
+// count counts node, decoded as a value or a mapping key, against maxNodes.
+func (d *valueDecoder) count(node *yaml.Node) error {
+	d.decoded++
+	if d.maxNodes > 0 && d.decoded > d.maxNodes {
+		return d.aliasLimit(node, false, d.opts.maxAliasNodes())
+	}
+	return nil
+}
+
+// aliasLimit returns the *AliasLimitError for a limit reached while
+// decoding node. The node limit is reported at the outermost alias being
+// expanded, the one written in the document rather than in an anchor.
+func (d *valueDecoder) aliasLimit(node *yaml.Node, depth bool, limit int) error {
+	err := &AliasLimitError{Document: d.document, Line: node.Line, Column: node.Column, Depth: depth, Limit: limit}
+	if !depth && len(d.aliases) > 0 {
+		node = d.aliases[0]
+		err.Line, err.Column = node.Line, node.Column
+	}
+	if node.Kind == yaml.AliasNode {
+		err.Anchor = node.Value
+	}
+	return err
+}
+
+// mappingKey decodes the key of a mapping found at path into the string used
+// as the key of its *Object.
+func (d *valueDecoder) mappingKey(keyNode *yaml.Node, path string) (string, error) {
+	if err := d.count(keyNode); err != nil {
+		return "", err
+	}
+	if keyNode.Kind == yaml.AliasNode {
+		keyNode = keyNode.Alias
+	}
+	if keyNode.Kind != yaml.ScalarNode {
+		return "", d.errorAt(keyNode, "mapping keys must be scalars")
+	}
+	var key interface{}
+	if err := keyNode.Decode(&key); err != nil {
+		return "", newYAMLParseError(err, d.document, keyNode)
+	}
+	stringKey, ok := key.(string)
+	if !ok {
+		if d.opts.RejectNonStringKeys {
+			return "", &KeyError{Path: pathOrRoot(path), Key: key}
+		}
+		stringKey = keyString(key)
+		if timestamp, ok, _ := decodeTimestamp(keyNode, d.opts.RawTimestamps); ok {
+			stringKey = timestamp
+		}
+	}
+	return stringKey, nil
+}
+
+// errorAt returns a *ParseError for a problem found at node.
+func (d *valueDecoder) errorAt(node *yaml.Node, message string) error {
+	return &ParseError{
+		Format:   "YAML",
+		Document: d.document,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  message,
+		Err:      errors.New(message),
+	}
+}
+
+// keyString returns the string form of a non-string map key.
+func keyString(key interface{}) string {
+	if key == nil {
+		return "null"
+	}
+	return fmt.Sprint(key)
+}
+
+// pathOrRoot returns path, or "." for the document root.
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
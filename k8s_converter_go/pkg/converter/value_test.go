@@ -0,0 +1,81 @@
+package converter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConvertNonStringKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "Integer keys",
+			content: "ports:\n  80: http\n  443: https\n",
+			want:    `{"ports":{"80":"http","443":"https"}}`,
+		},
+		{
+			name:    "Boolean keys",
+			content: "flags:\n  true: enabled\n  false: disabled\n",
+			want:    `{"flags":{"true":"enabled","false":"disabled"}}`,
+		},
+		{
+			name:    "Null key",
+			content: "values:\n  ~: empty\n",
+			want:    `{"values":{"null":"empty"}}`,
+		},
+		{
+			name:    "Keys inside sequences",
+			content: "items:\n- 1: one\n- name: two\n",
+			want:    `{"items":[{"1":"one"},{"name":"two"}]}`,
+		},
+		{
+			name:    "Top-level integer key",
+			content: "80: http\nkind: Service\n",
+			want:    `{"80":"http","kind":"Service"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(tt.content), Options{Compact: true})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertRejectNonStringKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantPath string
+	}{
+		{name: "Integer key", content: "spec:\n  ports:\n    80: http\n", wantPath: ".spec.ports"},
+		{name: "Boolean key", content: "items:\n- true: x\n", wantPath: ".items[0]"},
+		{name: "Null key", content: "kind: Pod\nnull: x\n", wantPath: "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert([]byte(tt.content), Options{RejectNonStringKeys: true})
+			var keyErr *KeyError
+			if !errors.As(err, &keyErr) {
+				t.Fatalf("Convert() error = %v, want KeyError", err)
+			}
+			if keyErr.Path != tt.wantPath {
+				t.Errorf("KeyError.Path = %q, want %q", keyErr.Path, tt.wantPath)
+			}
+		})
+	}
+
+	if _, err := Convert([]byte("kind: Pod\n"), Options{RejectNonStringKeys: true}); err != nil {
+		t.Errorf("Convert() error = %v for string keys", err)
+	}
+}
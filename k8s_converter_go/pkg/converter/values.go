@@ -0,0 +1,159 @@
+package converter
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// valuesReference matches ${values.a.b} references to Options.Values, and
+// the $${values. escape, so that $${values.a} produces a literal ${values.a}.
+var valuesReference = regexp.MustCompile(`\$\$\{values\.|\$\{values\.([A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)*)\}`)
+
+// valuesSubstitution substitutes the references to opts.Values in the nodes
+// of documents, recording the references it cannot substitute.
+type valuesSubstitution struct {
+	values    map[string]interface{}
+	missing   map[string]bool
+	notScalar map[string]bool
+}
+
+// substituteValues replaces the ${values.a.b} references in the scalars of
+// documents with opts.Values, when it is set. A plain scalar that is a
+// single reference becomes the value itself, so that a number stays a number
+// and a mapping is inserted as a mapping; a reference inside a longer or
+// quoted scalar is replaced by the text of the value, and the scalar is a
+// string. Substituting into nodes rather than the raw text means a value such
+// as "a: b" never changes the structure of a document. Every reference to a
+// missing value, and every mapping or sequence referred to inside a string,
+// is reported in a *ValuesError.
+func substituteValues(documents []parsedDocument, opts Options) error {
+	if opts.Values == nil {
+		return nil
+	}
+	s := &valuesSubstitution{values: opts.Values, missing: make(map[string]bool), notScalar: make(map[string]bool)}
+	for _, doc := range documents {
+		s.node(doc.node, false)
+	}
+	if len(s.missing) > 0 || len(s.notScalar) > 0 {
+		return &ValuesError{Missing: sortedKeys(s.missing), NotScalar: sortedKeys(s.notScalar)}
+	}
+	return nil
+}
+
+// node substitutes the references in node and its children. Aliases are
+// skipped, as the node they refer to is substituted where it is anchored.
+func (s *valuesSubstitution) node(node *yaml.Node, key bool) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			s.node(child, false)
+		}
+	case yaml.MappingNode:
+		for i, child := range node.Content {
+			s.node(child, i%2 == 0)
+		}
+	case yaml.ScalarNode:
+		s.scalar(node, key)
+	}
+}
+
+// scalar substitutes the references in node, a mapping key when key is set.
+func (s *valuesSubstitution) scalar(node *yaml.Node, key bool) {
+	if !strings.Contains(node.Value, "${values.") {
+		return
+	}
+	if groups := valuesReference.FindStringSubmatchIndex(node.Value); !key && node.Style == 0 &&
+		groups != nil && groups[0] == 0 && groups[1] == len(node.Value) && groups[2] >= 0 {
+		name := node.Value[groups[2]:groups[3]]
+		value, ok := s.lookup(name)
+		if !ok {
+			s.missing[name] = true
+			return
+		}
+		var replacement yaml.Node
+		if err := replacement.Encode(value); err != nil {
+			s.notScalar[name] = true
+			return
+		}
+		setPosition(&replacement, node.Line, node.Column)
+		replacement.Anchor = node.Anchor
+		replacement.HeadComment, replacement.LineComment, replacement.FootComment = node.HeadComment, node.LineComment, node.FootComment
+		*node = replacement
+		return
+	}
+
+	node.Value = valuesReference.ReplaceAllStringFunc(node.Value, func(match string) string {
+		if match == "$${values." {
+			return "${values."
+		}
+		name := match[len("${values.") : len(match)-len("}")]
+		value, ok := s.lookup(name)
+		if !ok {
+			s.missing[name] = true
+			return match
+		}
+		text, ok := scalarText(value)
+		if !ok {
+			s.notScalar[name] = true
+			return match
+		}
+		return text
+	})
+	if node.Style&yaml.TaggedStyle == 0 {
+		node.Tag = "!!str"
+	}
+}
+
+// lookup returns the value at name, a dotted path through nested mappings
+// of s.values.
+func (s *valuesSubstitution) lookup(name string) (interface{}, bool) {
+	var value interface{} = s.values
+	for _, segment := range strings.Split(name, ".") {
+		mapping, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = mapping[segment]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// scalarText returns the text of value, a scalar, as it is substituted into
+// a string: null is empty. It reports false for mappings and sequences.
+func scalarText(value interface{}) (string, bool) {
+	if value == nil {
+		return "", true
+	}
+	var node yaml.Node
+	if err := node.Encode(value); err != nil || node.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	return node.Value, true
+}
+
+// setPosition sets the line and column of node and its children to those
+// of the reference they replace, so that errors about them point at it.
+func setPosition(node *yaml.Node, line, column int) {
+	node.Line, node.Column = line, column
+	for _, child := range node.Content {
+		setPosition(child, line, column)
+	}
+}
+
+// sortedKeys returns the keys of set in sorted order, or nil if it is empty.
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
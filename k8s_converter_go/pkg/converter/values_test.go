@@ -0,0 +1,124 @@
+package converter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSubstituteValues(t *testing.T) {
+	values := map[string]interface{}{
+		"replicas": 3,
+		"debug":    true,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+		"command":  "sh -c 'echo a: b'",
+		"empty":    nil,
+		"labels":   map[string]interface{}{"app": "web", "tier": "frontend"},
+		"ports":    []interface{}{80, 443},
+	}
+
+	tests := []struct {
+		name          string
+		content       string
+		want          string
+		wantMissing   []string
+		wantNotScalar []string
+	}{
+		{
+			name:    "typed values",
+			content: "replicas: ${values.replicas}\ndebug: ${values.debug}\nempty: ${values.empty}\n",
+			want:    `{"replicas":3,"debug":true,"empty":null}`,
+		},
+		{
+			name:    "inside a string",
+			content: "image: ${values.image.repository}:${values.image.tag}\nlabel: v${values.replicas}\nnote: 'x${values.empty}'\n",
+			want:    `{"image":"nginx:1.25","label":"v3","note":"x"}`,
+		},
+		{
+			name:    "quoted reference stays a string",
+			content: "replicas: \"${values.replicas}\"\n",
+			want:    `{"replicas":"3"}`,
+		},
+		{
+			name:    "value that needs quoting",
+			content: "command: ${values.command}\nargs:\n  - --run=${values.command}\n",
+			want:    `{"command":"sh -c 'echo a: b'","args":["--run=sh -c 'echo a: b'"]}`,
+		},
+		{
+			name:    "mappings and sequences",
+			content: "labels: ${values.labels}\nports: ${values.ports}\n",
+			want:    `{"labels":{"app":"web","tier":"frontend"},"ports":[80,443]}`,
+		},
+		{
+			name:    "keys",
+			content: "labels:\n  ${values.image.repository}/tag: ${values.image.tag}\n",
+			want:    `{"labels":{"nginx/tag":"1.25"}}`,
+		},
+		{
+			name:    "escaped and other references",
+			content: "a: $${values.replicas}\nb: ${REPLICAS}\nc: ${values}\n",
+			want:    `{"a":"${values.replicas}","b":"${REPLICAS}","c":"${values}"}`,
+		},
+		{
+			name:    "explicit tag",
+			content: "port: !!int \"80${values.replicas}\"\n",
+			want:    `{"port":803}`,
+		},
+		{
+			name:        "missing values",
+			content:     "a: ${values.zone}\nb: ${values.image.digest}\n---\nc: x-${values.zone}\nd: ${values.replicas.count}\n",
+			wantMissing: []string{"image.digest", "replicas.count", "zone"},
+		},
+		{
+			name:          "mapping inside a string",
+			content:       "a: 'labels: ${values.labels}'\nb: ${values.unset}\n",
+			wantMissing:   []string{"unset"},
+			wantNotScalar: []string{"labels"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, format := range []string{FormatJSON, FormatNDJSON} {
+				got, err := Convert([]byte(tt.content), Options{Format: format, Compact: true, Values: values})
+				if tt.wantMissing != nil || tt.wantNotScalar != nil {
+					var valuesErr *ValuesError
+					if !errors.As(err, &valuesErr) {
+						t.Fatalf("Convert() %s error = %v, want *ValuesError", format, err)
+					}
+					if !reflect.DeepEqual(valuesErr.Missing, tt.wantMissing) || !reflect.DeepEqual(valuesErr.NotScalar, tt.wantNotScalar) {
+						t.Errorf("Convert() %s error = %+v, want missing %v and not scalar %v", format, valuesErr, tt.wantMissing, tt.wantNotScalar)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("Convert() %s error = %v", format, err)
+				}
+				want := tt.want
+				if format == FormatNDJSON {
+					want += "\n"
+				}
+				if string(got) != want {
+					t.Errorf("Convert() %s = %q, want %q", format, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSubstituteValuesDisabled(t *testing.T) {
+	got, err := Convert([]byte("tag: ${values.tag}\n"), Options{Compact: true})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if want := `{"tag":"${values.tag}"}`; string(got) != want {
+		t.Errorf("Convert() = %q, want %q", got, want)
+	}
+}
+
+func TestValuesError(t *testing.T) {
+	err := &ValuesError{Missing: []string{"image.tag", "zone"}, NotScalar: []string{"labels"}}
+	if want := "undefined values: image.tag, zone; values used inside a string must be scalars: labels"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
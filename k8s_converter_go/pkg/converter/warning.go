@@ -0,0 +1,31 @@
+package converter
+
+import "fmt"
+
+// Warning is a non-fatal problem found while converting the input.
+type Warning struct {
+	// Document is the 1-based position of the document in the stream, or 0
+	// when the warning does not refer to a single document.
+	Document int
+	// Line is the line the warning refers to, or 0 when not known.
+	Line    int
+	Message string
+}
+
+func (w Warning) String() string {
+	message := w.Message
+	if w.Line > 0 {
+		message = fmt.Sprintf("line %d: %s", w.Line, message)
+	}
+	if w.Document > 1 {
+		message += fmt.Sprintf(" (document %d)", w.Document)
+	}
+	return message
+}
+
+// warn reports w through opts.Warn when it is set.
+func (opts Options) warn(w Warning) {
+	if opts.Warn != nil {
+		opts.Warn(w)
+	}
+}
@@ -0,0 +1,40 @@
+package converter
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yaml11Bools are the plain scalars other than true and false that YAML 1.1
+// resolves as booleans. YAML 1.2, which yaml.v3 follows, reads them as
+// strings. The single-letter forms y and n are left out, as in most YAML 1.1
+// parsers, since they are far more often meant as strings.
+var yaml11Bools = map[string]bool{
+	"yes": true, "Yes": true, "YES": true,
+	"on": true, "On": true, "ON": true,
+	"no": false, "No": false, "NO": false,
+	"off": false, "Off": false, "OFF": false,
+}
+
+// yaml11Bool returns the YAML 1.1 boolean value of node and whether node is
+// an unquoted, untagged scalar that YAML 1.1 reads as a boolean but YAML 1.2
+// reads as a string. Quoted and explicitly tagged scalars always return false.
+func yaml11Bool(node *yaml.Node) (value, ok bool) {
+	if node.Kind != yaml.ScalarNode || node.Style != 0 || node.ShortTag() != "!!str" {
+		return false, false
+	}
+	value, ok = yaml11Bools[node.Value]
+	return value, ok
+}
+
+// yaml11BoolWarning returns the warning for a scalar at path that YAML 1.1
+// and YAML 1.2 read differently.
+func yaml11BoolWarning(document int, node *yaml.Node, path string) Warning {
+	return Warning{
+		Document: document,
+		Line:     node.Line,
+		Message: fmt.Sprintf("unquoted %q at %s is a boolean in YAML 1.1 but a string in YAML 1.2; quote it to keep it a string",
+			node.Value, pathOrRoot(path)),
+	}
+}
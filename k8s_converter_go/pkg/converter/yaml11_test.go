@@ -0,0 +1,65 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+const yaml11Content = `kind: Config
+values: [yes, Yes, YES, no, No, NO, on, On, ON, off, Off, OFF]
+quoted: ["yes", 'No', "on", 'OFF']
+tagged: !!str yes
+literal: |-
+  yes
+other: [y, n, yess, true]
+on: key
+`
+
+func TestConvertYAML11Bools(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "YAML 1.2 strings by default",
+			opts: Options{Compact: true},
+			want: `{"kind":"Config","values":["yes","Yes","YES","no","No","NO","on","On","ON","off","Off","OFF"],` +
+				`"quoted":["yes","No","on","OFF"],"tagged":"yes","literal":"yes","other":["y","n","yess",true],"on":"key"}`,
+		},
+		{
+			name: "YAML 1.1 booleans",
+			opts: Options{Compact: true, YAML11Bools: true},
+			want: `{"kind":"Config","values":[true,true,true,false,false,false,true,true,true,false,false,false],` +
+				`"quoted":["yes","No","on","OFF"],"tagged":"yes","literal":"yes","other":["y","n","yess",true],"on":"key"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert([]byte(yaml11Content), tt.opts)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Convert() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertWarnYAML11Bools(t *testing.T) {
+	var warnings []Warning
+	opts := Options{WarnYAML11Bools: true, Warn: func(w Warning) { warnings = append(warnings, w) }}
+	if _, err := Convert([]byte("kind: Config\n---\nspec:\n  enabled: Yes\n  quoted: \"no\"\n  flags: [OFF]\n"), opts); err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := []Warning{
+		{Document: 2, Line: 4, Message: `unquoted "Yes" at .spec.enabled is a boolean in YAML 1.1 but a string in YAML 1.2; quote it to keep it a string`},
+		{Document: 2, Line: 6, Message: `unquoted "OFF" at .spec.flags[0] is a boolean in YAML 1.1 but a string in YAML 1.2; quote it to keep it a string`},
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("Convert() warnings = %v, want %v", warnings, want)
+	}
+}